@@ -9,6 +9,7 @@ import (
 	"github.com/bgentry/go-netrc/netrc"
 	"github.com/bgentry/speakeasy"
 	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
 	"github.com/gemnasium/toolbelt/utils"
 	"github.com/heroku/hk/term"
 	"github.com/urfave/cli"
@@ -85,6 +86,9 @@ func Logout() error {
 
 // Lambda to be overriden in tests
 var getCredentials = func() (email, password string, err error) {
+	if config.NonInteractive {
+		return "", "", errors.New(i18n.T("common.non_interactive", "your email and password", "pass your API token with --token or GEMNASIUM_TOKEN instead of logging in"))
+	}
 	fmt.Printf("Enter your email: ")
 	fmt.Scanf("%s", &email)
 	// NOTE: gopass doesn't support multi-byte chars on Windows
@@ -184,6 +188,16 @@ func removeCreds(host string) error {
 	return writeNetrcFile(body)
 }
 
+// Return the credentials stored in .netrc for host, if any.
+// Used to authenticate against private registries and mirrors.
+func HostCreds(host string) (user, pass string) {
+	m := loadNetrc().FindMachine(host)
+	if m == nil || m.IsDefault() {
+		return "", ""
+	}
+	return m.Login, m.Password
+}
+
 func getCreds() (user, pass string) {
 	nrc := loadNetrc()
 	if nrc == nil {
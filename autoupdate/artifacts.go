@@ -0,0 +1,119 @@
+package autoupdate
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+const (
+	ARTIFACTS_API = "api"
+	ARTIFACTS_S3  = "s3"
+)
+
+// What's needed to investigate a failed update set after an unattended run
+type FailureArtifact struct {
+	ProjectSlug string     `json:"project_slug"`
+	Revision    string     `json:"revision"`
+	UpdateSet   *UpdateSet `json:"update_set"`
+	State       string     `json:"state"`
+	// Output of the test suite, or error of the package manager or resolver
+	Log string `json:"log"`
+	// Changes of the dependency files attempted (unified diff), and their
+	// description
+	Diff      string         `json:"diff"`
+	Metadata  *PatchMetadata `json:"metadata"`
+	CreatedAt time.Time      `json:"created_at"`
+}
+
+func newFailureArtifact(projectSlug string, updateSet *UpdateSet, state, log string, orgDepFiles, uptDepFiles []models.DependencyFile, alerts []models.Alert) *FailureArtifact {
+	artifact := &FailureArtifact{
+		ProjectSlug: projectSlug,
+		Revision:    utils.GetCurrentRevision(),
+		UpdateSet:   updateSet,
+		State:       state,
+		Log:         log,
+		Metadata:    newPatchMetadata(updateSet, uptDepFiles, alerts),
+		CreatedAt:   time.Now().UTC(),
+	}
+	artifact.Diff = updateSetDiff(orgDepFiles, uptDepFiles)
+	return artifact
+}
+
+// Append the diff of an update set to the file, after a line naming the set
+// (ignored by git apply and patch)
+func appendPatch(path string, updateSetID int, diff string) error {
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_APPEND|os.O_CREATE, 0644)
+	if err != nil {
+		return err
+	}
+	if _, err := fmt.Fprintf(f, "Update set %d\n%s", updateSetID, diff); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// Unified diff of the original and updated dependency files, which can be
+// applied with git apply or patch -p1
+func updateSetDiff(orgDepFiles, uptDepFiles []models.DependencyFile) string {
+	diff := ""
+	for _, upt := range uptDepFiles {
+		var from []byte
+		for _, org := range orgDepFiles {
+			if org.Path == upt.Path {
+				from = org.Content
+			}
+		}
+		diff += models.Diff("a/"+upt.Path, "b/"+upt.Path, from, upt.Content)
+	}
+	return diff
+}
+
+// Upload the artifact where config.Artifacts tells, and return its location.
+func uploadFailureArtifact(artifact *FailureArtifact) (string, error) {
+	switch config.Artifacts {
+	case ARTIFACTS_API:
+		uri := fmt.Sprintf("/projects/%s/revisions/%s/auto_update_steps/%d/artifacts", artifact.ProjectSlug, artifact.Revision, artifact.UpdateSet.ID)
+		opts := &gemnasium.APIRequestOptions{
+			Method: "POST",
+			URI:    uri,
+			Body:   artifact,
+		}
+		return config.APIEndpoint + uri, gemnasium.APIRequest(opts)
+	case ARTIFACTS_S3:
+		if config.S3Bucket == "" {
+			return "", fmt.Errorf("S3 bucket is not set (see %s)", config.ENV_S3_BUCKET)
+		}
+		body, err := json.MarshalIndent(artifact, "", "  ")
+		if err != nil {
+			return "", err
+		}
+		key := fmt.Sprintf("%s%s/%s/update-set-%d-%d.json", config.S3Prefix, artifact.ProjectSlug, artifact.Revision, artifact.UpdateSet.ID, artifact.CreatedAt.Unix())
+		bucket := utils.S3Bucket{Endpoint: config.S3Endpoint, Region: config.S3Region, Name: config.S3Bucket}
+		return bucket.PutObject(key, "application/json", body)
+	}
+	return "", fmt.Errorf("Unknown artifacts target: %s (expected %s or %s)", config.Artifacts, ARTIFACTS_API, ARTIFACTS_S3)
+}
+
+// Upload the artifacts of a failed update set, if enabled in config. Errors
+// are only reported: they must not stop the run.
+func saveFailureArtifacts(projectSlug string, updateSet *UpdateSet, state, log string, orgDepFiles, uptDepFiles []models.DependencyFile, alerts []models.Alert) {
+	if config.Artifacts == "" {
+		return
+	}
+	fmt.Fprint(Output, i18n.T("autoupdate.uploading_artifacts"))
+	location, err := uploadFailureArtifact(newFailureArtifact(projectSlug, updateSet, state, log, orgDepFiles, uptDepFiles, alerts))
+	if err != nil {
+		fmt.Fprint(Output, i18n.T("autoupdate.artifacts_error", err))
+		return
+	}
+	fmt.Fprintln(Output, location)
+}
@@ -0,0 +1,90 @@
+package autoupdate
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+func TestUploadFailureArtifact(t *testing.T) {
+	var path string
+	var received FailureArtifact
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		path = r.URL.Path
+		json.NewDecoder(r.Body).Decode(&received)
+		w.Header().Set("Content-Type", "application/json")
+		w.Write([]byte("{}"))
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	defer func() { config.Artifacts = "" }()
+	os.Setenv("REVISION", "abc123")
+	defer os.Unsetenv("REVISION")
+
+	orgDepFiles := []models.DependencyFile{{Path: "Gemfile.lock", Content: []byte("rails (4.0.2)\n")}}
+	uptDepFiles := []models.DependencyFile{{Path: "Gemfile.lock", Content: []byte("rails (4.0.3)\n")}}
+	artifact := newFailureArtifact("blah", &UpdateSet{ID: 3}, UPDATE_SET_FAIL, "1 failure", orgDepFiles, uptDepFiles, nil)
+	if !strings.Contains(artifact.Diff, "-rails (4.0.2)\n+rails (4.0.3)\n") {
+		t.Errorf("Artifact should have the diff of the dependency files, got: %q", artifact.Diff)
+	}
+
+	config.Artifacts = ARTIFACTS_API
+	if _, err := uploadFailureArtifact(artifact); err != nil {
+		t.Fatal(err)
+	}
+	if path != "/projects/blah/revisions/abc123/auto_update_steps/3/artifacts" {
+		t.Errorf("Unexpected path: %s", path)
+	}
+	if received.Log != "1 failure" || received.State != UPDATE_SET_FAIL {
+		t.Errorf("Unexpected artifact: %#v", received)
+	}
+
+	config.Artifacts = ARTIFACTS_S3
+	config.S3Endpoint, config.S3Bucket, config.S3Prefix = ts.URL, "ci", "nightly/"
+	defer func() { config.S3Bucket, config.S3Prefix = "", "" }()
+	os.Setenv("AWS_ACCESS_KEY_ID", "key")
+	os.Setenv("AWS_SECRET_ACCESS_KEY", "secret")
+	defer os.Unsetenv("AWS_ACCESS_KEY_ID")
+	defer os.Unsetenv("AWS_SECRET_ACCESS_KEY")
+	if _, err := uploadFailureArtifact(artifact); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.HasPrefix(path, "/ci/nightly/blah/abc123/update-set-3-") {
+		t.Errorf("Unexpected S3 key: %s", path)
+	}
+}
+
+func TestUpdateSetDiff(t *testing.T) {
+	orgDepFiles := []models.DependencyFile{{Path: "Gemfile.lock", Content: []byte("rails (4.0.2)\n")}}
+	uptDepFiles := []models.DependencyFile{
+		{Path: "Gemfile.lock", Content: []byte("rails (4.0.3)\n")},
+		{Path: "package-lock.json", Content: []byte("{}\n")},
+	}
+	diff := updateSetDiff(orgDepFiles, uptDepFiles)
+	for _, expected := range []string{"--- a/Gemfile.lock\n+++ b/Gemfile.lock\n", "-rails (4.0.2)\n+rails (4.0.3)\n", "+++ b/package-lock.json\n", "+{}\n"} {
+		if !strings.Contains(diff, expected) {
+			t.Errorf("Expected %q in the diff:\n%s", expected, diff)
+		}
+	}
+}
+
+func TestAppendPatch(t *testing.T) {
+	f, err := ioutil.TempFile("", "gemnasium-patch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	f.Close()
+	defer os.Remove(f.Name())
+	appendPatch(f.Name(), 1, "--- a/Gemfile.lock\n")
+	appendPatch(f.Name(), 2, "--- a/package-lock.json\n")
+	if content, _ := ioutil.ReadFile(f.Name()); string(content) != "Update set 1\n--- a/Gemfile.lock\nUpdate set 2\n--- a/package-lock.json\n" {
+		t.Errorf("Both patches should be in the file, got: %q", content)
+	}
+}
@@ -7,24 +7,29 @@ These functions are meant to be used during CI tests.
 */
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"io/ioutil"
+	"net/url"
 	"os"
-	"os/exec"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/gemnasium/toolbelt/config"
 	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/gemnasium/toolbelt/i18n"
 	"github.com/gemnasium/toolbelt/models"
 	"github.com/gemnasium/toolbelt/utils"
 )
 
 const (
-	UPDATE_SET_INVALID = "invalid"
-	UPDATE_SET_SUCCESS = "test_passed"
-	UPDATE_SET_FAIL    = "test_failed"
+	UPDATE_SET_INVALID           = "invalid"
+	UPDATE_SET_SUCCESS           = "test_passed"
+	UPDATE_SET_FAIL              = "test_failed"
+	UPDATE_SET_ALREADY_SATISFIED = "already_satisfied"
+	UPDATE_SET_DEFERRED          = "deferred"
 )
 
 type RequirementUpdate struct {
@@ -49,9 +54,11 @@ type UpdateSetResult struct {
 	ProjectSlug     string                  `json:"-"`
 	State           string                  `json:"state"`
 	DependencyFiles []models.DependencyFile `json:"dependency_files"`
+	// Description of the changes of the dependency files
+	Metadata *PatchMetadata `json:"metadata,omitempty"`
 }
 
-var ErrProjectRevisionEmpty error = fmt.Errorf("The current revision (%s) is unknown on Gemnasium, please push your dependency files before running autoupdate.\nSee `gemnasium df help push`.\n", utils.GetCurrentRevision())
+var ErrProjectRevisionEmpty error = errors.New(i18n.T("autoupdate.revision_unknown", utils.GetCurrentRevision()))
 
 // Apply the best dependency files that have been found so far
 func Apply(projectSlug string, testSuite []string) error {
@@ -67,7 +74,7 @@ func Apply(projectSlug string, testSuite []string) error {
 
 	err = updateDepFiles(dfiles)
 	if err != nil {
-		fmt.Printf("Error while restoring files: %s\n", err)
+		fmt.Fprint(Output, i18n.T("autoupdate.restore_error", err))
 		return err
 	}
 	// No need to try the update, it will fail
@@ -91,23 +98,114 @@ func fetchDependencyFiles(projectSlug string) (dfiles []models.DependencyFile, e
 	return dfiles, err
 }
 
+// Most recent update set validated on a branch, with its dependency files
+type PatchSet struct {
+	UpdateSetID     int                     `json:"update_set_id"`
+	Revision        string                  `json:"revision"`
+	DependencyFiles []models.DependencyFile `json:"dependency_files"`
+}
+
+// Apply the most recent patch set validated on the current branch, without
+// running the update sets again. Files are checked against their SHA before
+// anything is written.
+func ApplyLatest(projectSlug string) error {
+	branch := utils.GetCurrentBranch()
+	ps, err := fetchLatestPatchSet(projectSlug, branch)
+	if err != nil {
+		return err
+	}
+	if ps == nil || len(ps.DependencyFiles) == 0 {
+		return errors.New(i18n.T("autoupdate.no_patch_set", branch))
+	}
+	for _, df := range ps.DependencyFiles {
+		if sha := models.ContentSHA1(df.Content); df.SHA != "" && sha != df.SHA {
+			return errors.New(i18n.T("df.signature_mismatch", df.Path, df.SHA, sha))
+		}
+	}
+	// The files of a patch set validated on an older revision would overwrite
+	// the changes made since
+	if ps.Revision != "" && ps.Revision != utils.GetCurrentRevision() {
+		if err := models.CheckUnchangedSince(ps.Revision, ps.DependencyFiles); err != nil {
+			return err
+		}
+	}
+	fmt.Fprint(Output, i18n.T("autoupdate.applying_patch_set", ps.UpdateSetID, ps.Revision))
+
+	err = updateDepFiles(ps.DependencyFiles)
+	if err != nil {
+		fmt.Fprint(Output, i18n.T("autoupdate.restore_error", err))
+		return err
+	}
+	return nil
+}
+
+func fetchLatestPatchSet(projectSlug, branch string) (ps *PatchSet, err error) {
+	opts := &gemnasium.APIRequestOptions{
+		Method: "GET",
+		URI:    fmt.Sprintf("/projects/%s/branches/%s/auto_update_steps/latest", projectSlug, url.PathEscape(branch)),
+		Result: &ps,
+	}
+	err = gemnasium.APIRequest(opts)
+	return ps, err
+}
+
 // Update dependency files with given one (best dependency files)
 // REFACTOR: this is very similar to restoreDepFiles
 func updateDepFiles(dfiles []models.DependencyFile) error {
-	fmt.Printf("%d file(s) to be updated.\n", len(dfiles))
+	fmt.Fprint(Output, i18n.T("autoupdate.files_to_update", len(dfiles)))
 	for _, df := range dfiles {
-		fmt.Printf("Updating file %s: ", df.Path)
+		fmt.Fprint(Output, i18n.T("autoupdate.updating_file", df.Path))
 		err := ioutil.WriteFile(df.Path, df.Content, 0644)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("done\n")
+		fmt.Fprintln(Output, i18n.T("common.done"))
 	}
 	return nil
 }
 
 // Download and loop over update sets, apply changes, run test suite, and finally notify gemnasium
-func Run(projectSlug string, testSuite []string) error {
+// A summary of the run is emailed when SMTP is configured.
+// The loop stops before the next update set once ctx is done: the update and
+// test commands running are killed, and the dependency files are restored.
+// With config.DryRun, the changes of the update sets and the commands that
+// would apply them are only printed: no file is changed, no test is run, and
+// no result is sent to Gemnasium.
+func Run(ctx context.Context, projectSlug string, testSuite []string) error {
+	summary := &RunSummary{Project: projectSlug, Results: map[string]int{}, StartedAt: time.Now()}
+	// Only the summary is printed on Output in JSON, messages go to stderr
+	output := Output
+	if config.JSONOutput {
+		Output = os.Stderr
+	}
+	commandContext = ctx
+	err := run(ctx, projectSlug, testSuite, summary)
+	commandContext = context.Background()
+	Output = output
+	summary.Err = err
+	summary.FinishedAt = time.Now()
+	if config.JSONOutput {
+		if jsonErr := utils.PrintJSON(Output, summary); jsonErr != nil {
+			return jsonErr
+		}
+	}
+	if config.SMTPHost != "" {
+		if mailErr := sendSummaryEmail(summary); mailErr != nil {
+			fmt.Fprint(Output, i18n.T("autoupdate.email_error", mailErr))
+		}
+	}
+	return err
+}
+
+func run(ctx context.Context, projectSlug string, testSuite []string, summary *RunSummary) error {
+	push := func(rs *UpdateSetResult) error {
+		summary.Results[rs.State]++
+		if config.DryRun {
+			return nil
+		}
+		return pushUpdateSetResult(ctx, rs)
+	}
+
 	err := checkProject(projectSlug)
 	if err != nil {
 		return err
@@ -116,43 +214,182 @@ func Run(projectSlug string, testSuite []string) error {
 	if envTS := os.Getenv(config.ENV_GEMNASIUM_TESTSUITE); envTS != "" {
 		testSuite = strings.Fields(envTS)
 	}
-	if len(testSuite) == 0 {
-		return errors.New("Arg [testSuite] can't be empty")
+	if len(testSuite) == 0 && !config.DryRun {
+		return errors.New(i18n.T("autoupdate.testsuite_empty"))
 	}
 
-	out, err := executeTestSuite(testSuite)
+	var minReleaseAge time.Duration
+	if config.MinReleaseAge != "" {
+		minReleaseAge, err = utils.ParseDuration(config.MinReleaseAge)
+		if err != nil {
+			return err
+		}
+	}
+
+	groups, err := newBranchGroups()
 	if err != nil {
-		fmt.Println("Aborting, initial test suite run is failing:")
-		fmt.Printf("%s\n", out)
 		return err
 	}
+	var prs *pullRequests
+	if config.PullRequest && !config.DryRun {
+		if prs, err = newPullRequests(); err != nil {
+			return err
+		}
+	}
+
+	// Owners are added to the metadata of the update sets
+	if _, err := models.LoadOwners(); err != nil {
+		return err
+	}
+
+	if config.DryRun {
+		fmt.Fprint(Output, i18n.T("autoupdate.dry_run", strings.Join(testSuite, " ")))
+	} else {
+		// The patches of the successful update sets of the run are appended
+		if config.PatchOut != "" {
+			if err := ioutil.WriteFile(config.PatchOut, nil, 0644); err != nil {
+				return err
+			}
+		}
+
+		err = checkTooling(testSuite)
+		if err != nil {
+			return err
+		}
+
+		out, err := executeTestSuite(testSuite)
+		if err != nil {
+			fmt.Fprintln(Output, i18n.T("autoupdate.initial_testsuite_failing"))
+			fmt.Fprintf(Output, "%s\n", out)
+			return err
+		}
+	}
+
+	// Open alerts, to tell which advisories are fixed by the update sets. Best
+	// effort: the run goes on without them.
+	alerts, _ := (&models.Project{Slug: projectSlug}).Alerts()
+
+	// Without results, Gemnasium may send the same update sets again (dry run)
+	seen := map[int]bool{}
 
 	// Loop until tests are green
 	for {
-		updateSet, err := fetchUpdateSet(projectSlug)
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		updateSet, err := fetchUpdateSet(ctx, projectSlug)
 		if err != nil {
 			return err
 		}
-		if updateSet.ID == 0 {
-			fmt.Println("Job done!")
+		if updateSet.ID == 0 || seen[updateSet.ID] {
+			fmt.Fprintln(Output, i18n.T("autoupdate.job_done"))
 			break
 		}
-		fmt.Printf("\n========= [UpdateSet #%d] =========\n", updateSet.ID)
+		seen[updateSet.ID] = true
+		fmt.Fprint(Output, i18n.T("autoupdate.update_set_header", updateSet.ID))
+
+		// Packages may have been updated manually since the revision was pushed
+		if isAlreadySatisfied(updateSet) {
+			fmt.Fprintln(Output, i18n.T("autoupdate.already_satisfied"))
+			resultSet := &UpdateSetResult{UpdateSetID: updateSet.ID, ProjectSlug: projectSlug, State: UPDATE_SET_ALREADY_SATISFIED}
+			err := push(resultSet)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+
+		// Don't try again an update set that already failed with the same
+		// lockfiles
+		lockSHA := lockfilesSHA1()
+		fingerprint, err := updateSetFingerprint(updateSet, lockSHA)
+		if err != nil {
+			return err
+		}
+		if fs, ok := loadFailedSets(projectSlug)[fingerprint]; ok {
+			fmt.Fprint(Output, i18n.T("autoupdate.already_failed", fs.UpdateSetID, fs.FailedAt.Format(time.RFC822)))
+			resultSet := &UpdateSetResult{UpdateSetID: updateSet.ID, ProjectSlug: projectSlug, State: fs.State}
+			err := push(resultSet)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		recordFailure := func(state, log string, orgDepFiles, uptDepFiles []models.DependencyFile) {
+			if config.DryRun {
+				return
+			}
+			fs := failedSet{UpdateSetID: updateSet.ID, State: state, Lockfiles: lockSHA, FailedAt: time.Now()}
+			if err := recordFailedSet(projectSlug, fingerprint, fs); err != nil {
+				fmt.Fprint(Output, i18n.T("autoupdate.cant_record_failure", err))
+			}
+			saveFailureArtifacts(projectSlug, updateSet, state, log, orgDepFiles, uptDepFiles, alerts)
+		}
+
+		if config.VerifyVersions {
+			if err := verifyUpdateSet(updateSet); err != nil {
+				fmt.Fprint(Output, i18n.T("autoupdate.verification_failed", err))
+				resultSet := &UpdateSetResult{UpdateSetID: updateSet.ID, ProjectSlug: projectSlug, State: UPDATE_SET_INVALID}
+				err := push(resultSet)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if minReleaseAge > 0 {
+			if err := checkReleaseAge(updateSet, minReleaseAge); err != nil {
+				fmt.Fprint(Output, i18n.T("autoupdate.deferring", err))
+				resultSet := &UpdateSetResult{UpdateSetID: updateSet.ID, ProjectSlug: projectSlug, State: UPDATE_SET_DEFERRED}
+				err := push(resultSet)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if config.SimulateSets {
+			if err := simulateUpdateSet(updateSet); err != nil {
+				fmt.Fprint(Output, i18n.T("autoupdate.simulation_failed", err))
+				recordFailure(UPDATE_SET_INVALID, err.Error(), nil, nil)
+				resultSet := &UpdateSetResult{UpdateSetID: updateSet.ID, ProjectSlug: projectSlug, State: UPDATE_SET_INVALID}
+				err := push(resultSet)
+				if err != nil {
+					return err
+				}
+				continue
+			}
+		}
+
+		if config.DryRun {
+			if err := describeUpdateSet(updateSet); err != nil {
+				return err
+			}
+			continue
+		}
 
 		// We have an updateSet, let's patch files and run tests
 		// We need to keep a list of updated files to restore them after this run
 		orgDepFiles, uptDepFiles, err := applyUpdateSet(updateSet)
+		setAppliedDepFiles(orgDepFiles)
+		if ctx.Err() != nil {
+			return interruptUpdateSet(ctx, orgDepFiles)
+		}
 		resultSet := &UpdateSetResult{UpdateSetID: updateSet.ID, ProjectSlug: projectSlug, DependencyFiles: uptDepFiles}
+		resultSet.Metadata = newPatchMetadata(updateSet, uptDepFiles, alerts)
 		if err == cantInstallRequirements || err == cantUpdateVersions {
 			resultSet.State = UPDATE_SET_INVALID
-			err := pushUpdateSetResult(resultSet)
+			recordFailure(resultSet.State, err.Error(), orgDepFiles, uptDepFiles)
+			err := push(resultSet)
 			if err != nil {
 				return err
 			}
 
 			err = restoreDepFiles(orgDepFiles)
 			if err != nil {
-				fmt.Printf("Error while restoring files: %s\n", err)
+				fmt.Fprint(Output, i18n.T("autoupdate.restore_error", err))
 			}
 			// No need to try the update, it will fail
 			continue
@@ -161,15 +398,43 @@ func Run(projectSlug string, testSuite []string) error {
 			return err
 		}
 
-		out, err := executeTestSuite(testSuite)
+		out, err := executeTestSuiteWithRetries(testSuite, config.TestRetries)
+		// The test suite was killed, it didn't fail
+		if ctx.Err() != nil {
+			return interruptUpdateSet(ctx, orgDepFiles)
+		}
 		if err == nil {
 			// we found a valid candidate
 			resultSet.State = UPDATE_SET_SUCCESS
-			err := pushUpdateSetResult(resultSet)
+			err := push(resultSet)
 			if err != nil {
 				return err
 			}
 
+			if config.PatchOut != "" {
+				err = appendPatch(config.PatchOut, updateSet.ID, updateSetDiff(orgDepFiles, uptDepFiles))
+				if err != nil {
+					fmt.Fprint(Output, i18n.T("autoupdate.patch_out_error", err))
+				} else {
+					fmt.Fprint(Output, i18n.T("autoupdate.patch_out", config.PatchOut))
+				}
+			}
+			if config.GitBranch || config.GitPush {
+				branch, err := groups.commit(updateSet, resultSet.Metadata, orgDepFiles, testSuite, config.GitPush)
+				if err != nil {
+					fmt.Fprint(Output, i18n.T("autoupdate.git_branch_error", err))
+				} else {
+					fmt.Fprint(Output, i18n.T("autoupdate.git_branch", branch))
+					if prs != nil {
+						if pr, err := prs.publish(branch, resultSet.Metadata); err != nil {
+							fmt.Fprint(Output, i18n.T("autoupdate.pull_request_error", branch, err))
+						} else {
+							fmt.Fprint(Output, i18n.T("autoupdate.pull_request", pr.HTMLURL))
+						}
+					}
+				}
+			}
+
 			err = restoreDepFiles(orgDepFiles)
 			if err != nil {
 				return err
@@ -178,22 +443,23 @@ func Run(projectSlug string, testSuite []string) error {
 			continue
 		}
 		// display cmd output
-		fmt.Printf("%s\n", out)
+		fmt.Fprintf(Output, "%s\n", out)
 		resultSet.State = UPDATE_SET_FAIL
-		err = pushUpdateSetResult(resultSet)
+		recordFailure(resultSet.State, string(out), orgDepFiles, uptDepFiles)
+		err = push(resultSet)
 		if err != nil {
 			return err
 		}
 		err = restoreDepFiles(orgDepFiles)
 		if err != nil {
-			fmt.Printf("Error while restoring files: %s\n", err)
+			fmt.Fprint(Output, i18n.T("autoupdate.restore_error", err))
 		}
 		// Let's continue with another set
 	}
 	return nil
 }
 
-func fetchUpdateSet(projectSlug string) (*UpdateSet, error) {
+func fetchUpdateSet(ctx context.Context, projectSlug string) (*UpdateSet, error) {
 	revision, err := getRevision()
 	if err != nil {
 		return nil, err
@@ -201,9 +467,10 @@ func fetchUpdateSet(projectSlug string) (*UpdateSet, error) {
 
 	var updateSet *UpdateSet
 	opts := &gemnasium.APIRequestOptions{
-		Method: "POST",
-		URI:    fmt.Sprintf("/projects/%s/revisions/%s/auto_update_steps/next", projectSlug, revision),
-		Result: &updateSet,
+		Method:  "POST",
+		URI:     fmt.Sprintf("/projects/%s/revisions/%s/auto_update_steps/next", projectSlug, revision),
+		Result:  &updateSet,
+		Context: ctx,
 	}
 	err = gemnasium.APIRequest(opts)
 	return updateSet, err
@@ -213,6 +480,8 @@ func fetchUpdateSet(projectSlug string) (*UpdateSet, error) {
 // Will return a slice of original files and a slice of the updated files, with
 // their content
 func applyUpdateSet(updateSet *UpdateSet) (orgDepFiles, uptDepFiles []models.DependencyFile, err error) {
+	// The toolchain of the previous update set isn't used anymore
+	removeToolchain()
 	for packageType, reqUpdates := range updateSet.RequirementUpdates {
 		installer, err := NewRequirementsInstaller(packageType)
 		if err != nil {
@@ -236,17 +505,17 @@ func applyUpdateSet(updateSet *UpdateSet) (orgDepFiles, uptDepFiles []models.Dep
 			return orgDepFiles, uptDepFiles, err
 		}
 	}
-	fmt.Println("Done")
+	fmt.Fprintln(Output, i18n.T("common.done_capitalized"))
 	return orgDepFiles, uptDepFiles, nil
 }
 
 // Once update set has been tested, we must send the result to Gemnasium,
 // in order to update statitics.
-func pushUpdateSetResult(rs *UpdateSetResult) error {
-	fmt.Printf("Pushing result (status='%s'): ", rs.State)
+func pushUpdateSetResult(ctx context.Context, rs *UpdateSetResult) error {
+	fmt.Fprint(Output, i18n.T("autoupdate.pushing_result", rs.State))
 
 	if rs.UpdateSetID == 0 || rs.State == "" {
-		return errors.New("Missing updateSet ID and/or State args")
+		return errors.New(i18n.T("autoupdate.missing_result_args"))
 	}
 
 	revision, err := getRevision()
@@ -255,34 +524,89 @@ func pushUpdateSetResult(rs *UpdateSetResult) error {
 	}
 
 	opts := &gemnasium.APIRequestOptions{
-		Method: "PATCH",
-		URI:    fmt.Sprintf("/projects/%s/revisions/%s/auto_update_steps/%d", rs.ProjectSlug, revision, rs.UpdateSetID),
-		Body:   rs,
+		Method:  "PATCH",
+		URI:     fmt.Sprintf("/projects/%s/revisions/%s/auto_update_steps/%d", rs.ProjectSlug, revision, rs.UpdateSetID),
+		Body:    rs,
+		Context: ctx,
 	}
 	err = gemnasium.APIRequest(opts)
 	if err != nil {
 		return err
 	}
 
-	fmt.Printf("done\n")
+	fmt.Fprintln(Output, i18n.T("common.done"))
 	return nil
 }
 
+// Restore the files of the update set interrupted by ctx, and return why it
+// was interrupted
+func interruptUpdateSet(ctx context.Context, orgDepFiles []models.DependencyFile) error {
+	if err := restoreDepFiles(orgDepFiles); err != nil {
+		fmt.Fprint(Output, i18n.T("autoupdate.restore_error", err))
+	}
+	return ctx.Err()
+}
+
+var (
+	// Original dependency files of the update set applied, not restored yet
+	appliedDepFiles   []models.DependencyFile
+	appliedDepFilesMu sync.Mutex
+)
+
+// Restore the dependency files of the update set being tested, if any. Used
+// before exiting without waiting for the run (see commands.startWatchdog).
+func RestoreAppliedDepFiles() error {
+	appliedDepFilesMu.Lock()
+	dfiles := appliedDepFiles
+	appliedDepFilesMu.Unlock()
+	if len(dfiles) == 0 {
+		return nil
+	}
+	return restoreDepFiles(dfiles)
+}
+
+func setAppliedDepFiles(dfiles []models.DependencyFile) {
+	appliedDepFilesMu.Lock()
+	appliedDepFiles = dfiles
+	appliedDepFilesMu.Unlock()
+}
+
 // Restore original files.
 // Needed after each run
 func restoreDepFiles(dfiles []models.DependencyFile) error {
-	fmt.Printf("%d file(s) to be restored.\n", len(dfiles))
+	setAppliedDepFiles(nil)
+	removeToolchain()
+	fmt.Fprint(Output, i18n.T("autoupdate.files_to_restore", len(dfiles)))
 	for _, df := range dfiles {
-		fmt.Printf("Restoring file %s: ", df.Path)
+		fmt.Fprint(Output, i18n.T("autoupdate.restoring_file", df.Path))
 		err := ioutil.WriteFile(df.Path, df.Content, 0644)
 		if err != nil {
 			return err
 		}
-		fmt.Printf("done\n")
+		fmt.Fprintln(Output, i18n.T("common.done"))
 	}
 	return nil
 }
 
+// Run the test suite, and run it again up to retries times if it fails, so
+// flaky test suites don't reject good update sets. The output of the failed
+// attempts is displayed, the output of the last one is returned.
+func executeTestSuiteWithRetries(ts []string, retries int) ([]byte, error) {
+	for attempt := 1; ; attempt++ {
+		out, err := executeTestSuite(ts)
+		if err == nil {
+			if attempt > 1 {
+				fmt.Fprintln(Output, i18n.T("autoupdate.flaky_testsuite", attempt))
+			}
+			return out, nil
+		}
+		if attempt > retries {
+			return out, err
+		}
+		fmt.Fprint(Output, i18n.T("autoupdate.test_attempt_failed", attempt, retries+1, out))
+	}
+}
+
 func executeTestSuite(ts []string) ([]byte, error) {
 	type Result struct {
 		Output []byte
@@ -292,10 +616,10 @@ func executeTestSuite(ts []string) ([]byte, error) {
 	defer close(done)
 	var out []byte
 	var err error
-	fmt.Printf("Executing test script: ")
+	fmt.Fprint(Output, i18n.T("autoupdate.executing_testsuite"))
 	start := time.Now()
 	go func() {
-		result, err := exec.Command(ts[0], ts[1:]...).Output()
+		result, err := command(ts[0], ts[1:]...).Output()
 		done <- Result{result, err}
 	}()
 	var stop bool
@@ -306,14 +630,14 @@ func executeTestSuite(ts []string) ([]byte, error) {
 			out = result.Output
 			err = result.Err
 		default:
-			fmt.Print(".")
+			fmt.Fprint(Output, ".")
 			time.Sleep(1 * time.Second)
 		}
 		if stop {
 			break
 		}
 	}
-	fmt.Printf("done (%fs)\n", time.Since(start).Seconds())
+	fmt.Fprint(Output, i18n.T("autoupdate.testsuite_done", time.Since(start).Seconds()))
 	return out, err
 }
 
@@ -332,7 +656,7 @@ func checkProject(slug string) error {
 func getRevision() (string, error) {
 	revision := utils.GetCurrentRevision()
 	if revision == "" {
-		return revision, errors.New("Can't determine current revision, please use REVISION env var to specify it")
+		return revision, errors.New(i18n.T("autoupdate.revision_undetermined"))
 	}
 	return revision, nil
 }
@@ -1,6 +1,9 @@
 package autoupdate
 
 import (
+	"bytes"
+	"context"
+	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"net/http"
@@ -8,6 +11,7 @@ import (
 	"os"
 	"reflect"
 	"testing"
+	"time"
 
 	"github.com/gemnasium/toolbelt/config"
 	"github.com/gemnasium/toolbelt/models"
@@ -38,7 +42,7 @@ func TestFetchUpdateSet(t *testing.T) {
 		VersionUpdates: map[string][]VersionUpdate{},
 	}
 
-	resultSet, err := fetchUpdateSet("blah")
+	resultSet, err := fetchUpdateSet(context.Background(), "blah")
 	if err != nil {
 		t.Error(err)
 	}
@@ -143,3 +147,153 @@ func TestApplyUpdateSet(t *testing.T) {
 		t.Errorf("Expectd uptDepFiles to be: %#v, got: %#v", expUptDepFiles, uptDepFiles)
 	}
 }
+
+func TestApplyLatest(t *testing.T) {
+	content := []byte("gem 'rails', '~> 4.0.3'\n")
+	sha := models.ContentSHA1(content)
+	var uri string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		uri = r.URL.Path
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(PatchSet{
+			UpdateSetID:     12,
+			Revision:        "abc123",
+			DependencyFiles: []models.DependencyFile{{Path: "Gemfile", SHA: sha, Content: content}},
+		})
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	os.Setenv(config.ENV_BRANCH, "feature/x")
+	defer os.Unsetenv(config.ENV_BRANCH)
+
+	dir, err := ioutil.TempDir("", "gemnasium-apply-latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	if err := ApplyLatest("blah"); err != nil {
+		t.Fatal(err)
+	}
+	if uri != "/projects/blah/branches/feature/x/auto_update_steps/latest" {
+		t.Errorf("Unexpected URI: %s", uri)
+	}
+	body, err := ioutil.ReadFile("Gemfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(body) != string(content) {
+		t.Errorf("Expected Gemfile to be updated, got: %s", body)
+	}
+}
+
+func TestApplyLatestWithSHAMismatch(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(PatchSet{
+			UpdateSetID:     12,
+			DependencyFiles: []models.DependencyFile{{Path: "Gemfile", SHA: "dc6bdc865c85a4f5c6ef0f4ba8909d8652fd8cd0", Content: []byte("tampered")}},
+		})
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	dir, err := ioutil.TempDir("", "gemnasium-apply-latest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	if err := ApplyLatest("blah"); err == nil {
+		t.Error("ApplyLatest should fail when the SHA doesn't match")
+	}
+	if _, err := os.Stat("Gemfile"); !os.IsNotExist(err) {
+		t.Error("Gemfile should not have been written")
+	}
+}
+
+func TestExecuteTestSuiteWithRetries(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-retries")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	counter := dir + "/attempts"
+
+	// Fails on the first attempt only
+	ts := []string{"sh", "-c", "echo run >> " + counter + "; test $(wc -l < " + counter + ") -gt 1"}
+	if _, err := executeTestSuiteWithRetries(ts, 0); err == nil {
+		t.Error("Test suite should fail without retries")
+	}
+	os.Remove(counter)
+	if _, err := executeTestSuiteWithRetries(ts, 1); err != nil {
+		t.Errorf("Test suite should pass on the second attempt, got: %s", err)
+	}
+	body, _ := ioutil.ReadFile(counter)
+	if string(body) != "run\nrun\n" {
+		t.Errorf("Test suite should have been run twice, got: %q", body)
+	}
+}
+
+func TestRunInterrupted(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-interrupted")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	ioutil.WriteFile("Gemfile.lock", []byte("GEM\n"), 0644)
+	ioutil.WriteFile("fake.sh", []byte(`echo "$@" >> Gemfile.lock`), 0755)
+	os.Setenv(config.ENV_GEMNASIUM_BUNDLE_UPDATE_CMD, "sh fake.sh bundle update")
+	defer os.Unsetenv(config.ENV_GEMNASIUM_BUNDLE_UPDATE_CMD)
+	os.Setenv(config.ENV_GEMNASIUM_BUNDLE_INSTALL_CMD, "sh fake.sh bundle install")
+	defer os.Unsetenv(config.ENV_GEMNASIUM_BUNDLE_INSTALL_CMD)
+	config.MinToolVersions["sh"] = ""
+	defer delete(config.MinToolVersions, "sh")
+	os.Setenv("REVISION", "abc123")
+	defer os.Unsetenv("REVISION")
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/projects/blah":
+			fmt.Fprint(w, `{"slug": "blah", "commit_sha": "abc123"}`)
+		case "/projects/blah/revisions/abc123/auto_update_steps/next":
+			fmt.Fprint(w, `{"id": 1, "version_updates": {"Rubygem": [{"package": {"name": "rails", "type": "Rubygem"}, "old_version": "4.2.0", "target_version": "4.2.11"}]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	var out bytes.Buffer
+	Output = &out
+	defer func() { Output = os.Stdout }()
+
+	// The test suite hangs with the update set: it's killed when the run
+	// times out, and the files are restored
+	ctx, cancel := context.WithTimeout(context.Background(), 500*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err = Run(ctx, "blah", []string{"sh", "-c", "grep -q rails Gemfile.lock && sleep 10; true"})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected the run to be interrupted, got %v\n%s", err, out.String())
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Expected the test suite to be killed, the run took %s", elapsed)
+	}
+	if content, _ := ioutil.ReadFile("Gemfile.lock"); string(content) != "GEM\n" {
+		t.Errorf("Expected Gemfile.lock to be restored, got:\n%s", content)
+	}
+	if err := RestoreAppliedDepFiles(); err != nil || appliedDepFiles != nil {
+		t.Errorf("Expected no files left to restore, got %v (%v)", appliedDepFiles, err)
+	}
+}
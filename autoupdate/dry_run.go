@@ -0,0 +1,97 @@
+package autoupdate
+
+import (
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+)
+
+// Stands for the toolchain directory of the update set in the commands of
+// dry runs (see toolchainPrefix)
+const DRY_RUN_TOOLCHAIN = "<toolchain>"
+
+// Commands the updater of the package type would run to install the version
+// updates, in order (see the updaters). Gradle build scripts are rewritten
+// directly, without commands.
+func updateCommands(packageType string, versionUpdates []VersionUpdate) [][]string {
+	commands := [][]string{}
+	switch packageType {
+	case "Rubygem":
+		bundler, others := splitToolchainUpdate("bundler", versionUpdates)
+		if bundler != nil {
+			commands = append(commands, upgradeBundlerCommands(*bundler, DRY_RUN_TOOLCHAIN)...)
+		}
+		if len(others) > 0 {
+			commands = append(commands, bundleUpdateCommand(others))
+		}
+	case "Npm":
+		if !isNpmDependency("npm") {
+			var npm *VersionUpdate
+			npm, versionUpdates = splitToolchainUpdate("npm", versionUpdates)
+			if npm != nil {
+				commands = append(commands, upgradeNpmCommand(*npm, DRY_RUN_TOOLCHAIN))
+			}
+		}
+		if len(versionUpdates) > 0 {
+			commands = append(commands, npmInstallCommand(versionUpdates))
+		}
+	case "Go":
+		commands = goGetCommands(versionUpdates)
+	case "Cargo", "Maven", "Nuget":
+		build := map[string]func(VersionUpdate) []string{"Cargo": cargoUpdateCommand, "Maven": mvnUpdateCommand, "Nuget": dotnetAddCommand}[packageType]
+		for _, vu := range versionUpdates {
+			commands = append(commands, build(vu))
+		}
+	}
+	return commands
+}
+
+// Print the changes of the update set, and the commands that would be run to
+// apply them, without running them nor changing any file (dry run)
+func describeUpdateSet(updateSet *UpdateSet) error {
+	types := []string{}
+	for packageType := range updateSet.RequirementUpdates {
+		types = append(types, packageType)
+	}
+	sort.Strings(types)
+	for _, packageType := range types {
+		if _, err := NewRequirementsInstaller(packageType); err != nil {
+			return err
+		}
+		for _, ru := range updateSet.RequirementUpdates[packageType] {
+			fmt.Fprint(Output, i18n.T("autoupdate.dry_run_patch", ru.File.Path))
+		}
+		install := envCommand(config.ENV_GEMNASIUM_BUNDLE_INSTALL_CMD, BUNDLE_INSTALL_CMD)
+		fmt.Fprint(Output, i18n.T("autoupdate.dry_run_command", strings.Join(install, " ")))
+	}
+
+	types = types[:0]
+	for packageType := range updateSet.VersionUpdates {
+		types = append(types, packageType)
+	}
+	sort.Strings(types)
+	for _, packageType := range types {
+		if _, err := NewUpdater(packageType); err != nil {
+			return err
+		}
+		versionUpdates := updateSet.VersionUpdates[packageType]
+		for _, vu := range versionUpdates {
+			fmt.Fprint(Output, i18n.T("autoupdate.updating_dependency", vu.Package.Name, vu.OldVersion, vu.TargetVersion))
+		}
+		if packageType == "Gradle" {
+			script := "build.gradle"
+			if _, err := os.Stat(script); os.IsNotExist(err) {
+				script = "build.gradle.kts"
+			}
+			fmt.Fprint(Output, i18n.T("autoupdate.dry_run_rewrite", script))
+		}
+		for _, parts := range updateCommands(packageType, versionUpdates) {
+			fmt.Fprint(Output, i18n.T("autoupdate.dry_run_command", strings.Join(parts, " ")))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,77 @@
+package autoupdate
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestDryRun(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-dry-run")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	ioutil.WriteFile("Gemfile.lock", []byte("GEM\n"), 0644)
+	os.Setenv("REVISION", "abc123")
+	defer os.Unsetenv("REVISION")
+
+	var fetched int
+	var methods []string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		methods = append(methods, r.Method)
+		switch r.URL.Path {
+		case "/projects/blah":
+			fmt.Fprint(w, `{"slug": "blah", "commit_sha": "abc123"}`)
+		case "/projects/blah/revisions/abc123/auto_update_steps/next":
+			// Without results, the same update set is sent again
+			fetched++
+			fmt.Fprint(w, `{"id": 1, "version_updates": {"Rubygem": [{"package": {"name": "bundler", "type": "Rubygem"}, "old_version": "1.17.3", "target_version": "2.1.4"}, {"package": {"name": "rails", "type": "Rubygem"}, "old_version": "4.2.0", "target_version": "4.2.11"}]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	config.DryRun = true
+	defer func() { config.DryRun = false }()
+
+	var out bytes.Buffer
+	Output = &out
+	defer func() { Output = os.Stdout }()
+	if err := Run(context.Background(), "blah", nil); err != nil {
+		t.Fatalf("%s\n%s", err, out.String())
+	}
+
+	for _, expected := range []string{
+		"Updating dependency rails (4.2.0 => 4.2.11)",
+		"Would run: gem install --install-dir <toolchain> --bindir <toolchain>/bin bundler -v 2.1.4\nWould run: bundle update --bundler=2.1.4\nWould run: bundle update rails\n",
+	} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in the output:\n%s", expected, out.String())
+		}
+	}
+	if fetched != 2 {
+		t.Errorf("Expected the run to stop once the update set is sent again, fetched %d times", fetched)
+	}
+	for _, method := range methods {
+		if method == "PATCH" {
+			t.Error("No result should be sent")
+		}
+	}
+	if content, _ := ioutil.ReadFile("Gemfile.lock"); string(content) != "GEM\n" {
+		t.Errorf("Files shouldn't be changed, got %s", content)
+	}
+}
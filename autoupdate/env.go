@@ -0,0 +1,75 @@
+package autoupdate
+
+import (
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// Vars passed to update and test commands when the environment is clean
+var baseEnv = []string{"PATH", "HOME", "USER", "LOGNAME", "SHELL", "TMPDIR", "TERM", "LANG", "LC_ALL"}
+
+// Return the environment of the update and test commands, built from the
+// current environment (or its base vars only, when config.CleanEnv is set)
+// and the vars set and unset in config.
+// It's built again for each command, so update sets can't leak env vars to
+// each other.
+func commandEnv(environ []string) []string {
+	vars := map[string]string{}
+	for _, kv := range environ {
+		if i := strings.Index(kv, "="); i > 0 {
+			vars[kv[:i]] = kv[i+1:]
+		}
+	}
+	if config.CleanEnv {
+		passed := map[string]string{}
+		for _, name := range append(baseEnv, config.PassEnv...) {
+			if value, ok := vars[name]; ok {
+				passed[name] = value
+			}
+		}
+		vars = passed
+	}
+	for _, name := range config.UnsetEnv {
+		delete(vars, name)
+	}
+	for name, value := range config.SubprocessEnv {
+		vars[name] = value
+	}
+	// Package managers upgraded by the update set come first
+	if toolchainDir != "" {
+		vars["PATH"] = prependPath(filepath.Join(toolchainDir, "bin"), vars["PATH"])
+		vars["GEM_PATH"] = prependPath(toolchainDir, vars["GEM_PATH"])
+	}
+
+	env := []string{}
+	for name, value := range vars {
+		env = append(env, name+"="+value)
+	}
+	sort.Strings(env)
+	return env
+}
+
+func prependPath(dir, list string) string {
+	if list == "" {
+		return dir
+	}
+	return dir + string(os.PathListSeparator) + list
+}
+
+// Context of the update and test commands: they're killed when it's done
+// (see Run)
+var commandContext = context.Background()
+
+// Same as exec.Command, with the environment and the context of update and
+// test commands
+func command(name string, arg ...string) *exec.Cmd {
+	cmd := exec.CommandContext(commandContext, name, arg...)
+	cmd.Env = commandEnv(os.Environ())
+	return cmd
+}
@@ -0,0 +1,32 @@
+package autoupdate
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestCommandEnv(t *testing.T) {
+	defer func() {
+		config.SubprocessEnv = map[string]string{}
+		config.UnsetEnv = nil
+		config.CleanEnv = false
+		config.PassEnv = nil
+	}()
+	environ := []string{"PATH=/usr/bin", "HOME=/home/ci", "SPRING_SERVER=1", "GEM_HOME=/gems", "AWS_SECRET=s3cr3t"}
+	config.SubprocessEnv = map[string]string{"RAILS_ENV": "test"}
+	config.UnsetEnv = []string{"SPRING_SERVER"}
+
+	expected := []string{"AWS_SECRET=s3cr3t", "GEM_HOME=/gems", "HOME=/home/ci", "PATH=/usr/bin", "RAILS_ENV=test"}
+	if env := commandEnv(environ); !reflect.DeepEqual(env, expected) {
+		t.Errorf("Expected env to be:\n%#v\nGot:\n%#v\n", expected, env)
+	}
+
+	config.CleanEnv = true
+	config.PassEnv = []string{"GEM_HOME"}
+	expected = []string{"GEM_HOME=/gems", "HOME=/home/ci", "PATH=/usr/bin", "RAILS_ENV=test"}
+	if env := commandEnv(environ); !reflect.DeepEqual(env, expected) {
+		t.Errorf("Expected clean env to be:\n%#v\nGot:\n%#v\n", expected, env)
+	}
+}
@@ -0,0 +1,103 @@
+package autoupdate
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gemnasium/toolbelt/cache"
+)
+
+// Lockfiles taken into account when fingerprinting update sets
+var lockfiles = []string{"Gemfile.lock", "package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "composer.lock", "go.sum", "Cargo.lock", "pom.xml", "build.gradle", "build.gradle.kts", "gradle.lockfile", "packages.lock.json", "packages.config"}
+
+// An update set which failed to resolve or to pass the tests.
+// Failures are remembered across runs, and the same update set is skipped
+// until the lockfiles change.
+type failedSet struct {
+	UpdateSetID int       `json:"update_set_id"`
+	State       string    `json:"state"`
+	Lockfiles   string    `json:"lockfiles"`
+	FailedAt    time.Time `json:"failed_at"`
+}
+
+// Return the SHA1 of the current lockfiles
+func lockfilesSHA1() string {
+	h := sha1.New()
+	for _, path := range lockfiles {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		fmt.Fprintf(h, "%s\x00%d\x00", path, len(content))
+		h.Write(content)
+	}
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
+
+// Return the fingerprint of the update set: the SHA1 of its contents and of
+// the current lockfiles. The update set ID isn't part of it, since the same
+// updates may be proposed again with a new ID.
+func updateSetFingerprint(updateSet *UpdateSet, lockfilesSHA string) (string, error) {
+	content, err := json.Marshal(struct {
+		RequirementUpdates map[string][]RequirementUpdate `json:"requirement_updates"`
+		VersionUpdates     map[string][]VersionUpdate     `json:"version_updates"`
+	}{updateSet.RequirementUpdates, updateSet.VersionUpdates})
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%x", sha1.Sum(append(content, lockfilesSHA...))), nil
+}
+
+func failedSetsPath(projectSlug string) string {
+	dir := cache.Dir(cache.FAILED)
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, strings.Replace(projectSlug, string(filepath.Separator), "_", -1)+".json")
+}
+
+// Load the failed sets of the project, by fingerprint
+func loadFailedSets(projectSlug string) map[string]failedSet {
+	failedSets := map[string]failedSet{}
+	path := failedSetsPath(projectSlug)
+	if path == "" {
+		return failedSets
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return failedSets
+	}
+	json.Unmarshal(data, &failedSets)
+	return failedSets
+}
+
+// Remember that the update set failed with the current lockfiles. Failures
+// recorded with other lockfiles are dropped, they can't match anymore.
+func recordFailedSet(projectSlug, fingerprint string, fs failedSet) error {
+	path := failedSetsPath(projectSlug)
+	if path == "" {
+		return nil
+	}
+	failedSets := loadFailedSets(projectSlug)
+	for fp, other := range failedSets {
+		if other.Lockfiles != fs.Lockfiles {
+			delete(failedSets, fp)
+		}
+	}
+	failedSets[fingerprint] = fs
+
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(failedSets)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
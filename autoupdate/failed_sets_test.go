@@ -0,0 +1,67 @@
+package autoupdate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+func TestFailedSets(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-failed-sets")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.CacheDir = dir
+	defer func() { config.CacheDir = "" }()
+
+	newUpdateSet := func(id int, target string) *UpdateSet {
+		return &UpdateSet{
+			ID: id,
+			VersionUpdates: map[string][]VersionUpdate{
+				"Rubygem": []VersionUpdate{
+					VersionUpdate{Package: models.Package{Name: "rails"}, OldVersion: "4.0.2", TargetVersion: target},
+				},
+			},
+		}
+	}
+
+	fp1, err := updateSetFingerprint(newUpdateSet(1, "4.0.3"), "lock1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	fp2, _ := updateSetFingerprint(newUpdateSet(2, "4.0.3"), "lock1")
+	if fp1 != fp2 {
+		t.Error("Update sets with the same contents should have the same fingerprint")
+	}
+	if fp, _ := updateSetFingerprint(newUpdateSet(1, "4.0.4"), "lock1"); fp == fp1 {
+		t.Error("Update sets with different contents should have different fingerprints")
+	}
+	fp3, _ := updateSetFingerprint(newUpdateSet(1, "4.0.3"), "lock2")
+	if fp3 == fp1 {
+		t.Error("Fingerprint should change with the lockfiles")
+	}
+
+	err = recordFailedSet("blah", fp1, failedSet{UpdateSetID: 1, State: UPDATE_SET_FAIL, Lockfiles: "lock1", FailedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	fs, ok := loadFailedSets("blah")[fp1]
+	if !ok || fs.UpdateSetID != 1 || fs.State != UPDATE_SET_FAIL {
+		t.Errorf("Failed set should have been recorded, got: %#v", fs)
+	}
+
+	// Lockfiles have changed: previous failures are dropped
+	err = recordFailedSet("blah", fp3, failedSet{UpdateSetID: 3, State: UPDATE_SET_INVALID, Lockfiles: "lock2", FailedAt: time.Now()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	failedSets := loadFailedSets("blah")
+	if _, ok := failedSets[fp1]; ok || len(failedSets) != 1 {
+		t.Errorf("Failures recorded with other lockfiles should be dropped, got: %#v", failedSets)
+	}
+}
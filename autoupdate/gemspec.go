@@ -0,0 +1,97 @@
+package autoupdate
+
+import (
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+var gemspecRequirement = regexp.MustCompile(`["']([^"']*)["']`)
+
+// Return the regexp matching the dependency declarations of the gem in a
+// gemspec (add_dependency, add_runtime_dependency and
+// add_development_dependency), the requirements being the first submatch
+// (ie: `, "~> 1.2", ">= 1.2.1"`)
+func gemspecDependency(name string) *regexp.Regexp {
+	return regexp.MustCompile(`add_(?:development_|runtime_)?dependency[\s(]+["']` + regexp.QuoteMeta(name) + `["']((?:\s*,\s*["'][^"']*["'])+)`)
+}
+
+// Return the requirement replacing requirement, which doesn't allow version:
+// pessimistic and exact requirements keep their operator and precision
+// ("~> 1.2" => "~> 2.0"), other ones are replaced with "~> <major>.<minor>".
+func bumpGemRequirement(requirement, version string) string {
+	first := strings.TrimSpace(strings.Split(requirement, ",")[0])
+	segments := strings.Split(version, ".")
+	switch {
+	case strings.HasPrefix(first, "~>"):
+		precision := len(strings.Split(strings.TrimSpace(strings.TrimPrefix(first, "~>")), "."))
+		if precision < len(segments) {
+			segments = segments[:precision]
+		}
+		return "~> " + strings.Join(segments, ".")
+	case strings.HasPrefix(first, "="):
+		return "= " + version
+	case isVersionNumber(first):
+		return version
+	}
+	if len(segments) > 2 {
+		segments = segments[:2]
+	}
+	return "~> " + strings.Join(segments, ".")
+}
+
+// Rewrite the requirements of the gems in content, when they don't allow the
+// target versions anymore. Return the new content, and whether it changed.
+func rewriteGemspec(content []byte, versionUpdates []VersionUpdate) ([]byte, bool) {
+	changed := false
+	for _, vu := range versionUpdates {
+		re := gemspecDependency(vu.Package.Name)
+		content = re.ReplaceAllFunc(content, func(declaration []byte) []byte {
+			m := re.FindSubmatchIndex(declaration)
+			list := string(declaration[m[2]:m[3]])
+			requirements := []string{}
+			for _, r := range gemspecRequirement.FindAllStringSubmatch(list, -1) {
+				requirements = append(requirements, r[1])
+			}
+			requirement := strings.Join(requirements, ", ")
+			if ok, err := satisfiesRequirement("Rubygem", vu.TargetVersion, requirement); err != nil || ok {
+				return declaration
+			}
+			changed = true
+			quote := list[strings.IndexAny(list, `"'`)]
+			bumped := fmt.Sprintf(", %c%s%c", quote, bumpGemRequirement(requirement, vu.TargetVersion), quote)
+			return append(append(append([]byte{}, declaration[:m[2]]...), bumped...), declaration[m[3]:]...)
+		})
+	}
+	return content, changed
+}
+
+// Rewrite the requirements of the gemspecs of the current directory which
+// don't allow the target versions, so "bundle update" can install them. The
+// gemspecs are recorded for later restoration.
+func updateGemspecs(versionUpdates []VersionUpdate, orgDepFiles, uptDepFiles *[]models.DependencyFile) error {
+	paths, _ := filepath.Glob("*.gemspec")
+	for _, path := range paths {
+		gemspec, err := models.NewDependencyFileE(path)
+		if err != nil {
+			return err
+		}
+		content, changed := rewriteGemspec(gemspec.Content, versionUpdates)
+		if !changed {
+			continue
+		}
+		fmt.Fprintln(Output, i18n.T("autoupdate.patching", path))
+		*orgDepFiles = append(*orgDepFiles, *gemspec)
+		if err := ioutil.WriteFile(path, content, 0644); err != nil {
+			return err
+		}
+		gemspec.Update()
+		*uptDepFiles = append(*uptDepFiles, *gemspec)
+	}
+	return nil
+}
@@ -0,0 +1,74 @@
+package autoupdate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+func TestRewriteGemspec(t *testing.T) {
+	gemspec := `Gem::Specification.new do |s|
+  s.add_dependency "rack", "~> 1.6"
+  s.add_runtime_dependency('json', '>= 1.8', '< 2')
+  s.add_development_dependency "rspec", "3.4.0"
+  s.add_development_dependency "rake", ">= 10.0"
+end
+`
+	versionUpdates := []VersionUpdate{
+		{Package: models.Package{Name: "rack", Type: "Rubygem"}, OldVersion: "1.6.4", TargetVersion: "2.0.1"},
+		{Package: models.Package{Name: "json", Type: "Rubygem"}, OldVersion: "1.8.3", TargetVersion: "2.1.0"},
+		{Package: models.Package{Name: "rspec", Type: "Rubygem"}, OldVersion: "3.4.0", TargetVersion: "3.5.0"},
+		{Package: models.Package{Name: "rake", Type: "Rubygem"}, OldVersion: "10.4.2", TargetVersion: "11.1.0"},
+	}
+	expected := `Gem::Specification.new do |s|
+  s.add_dependency "rack", "~> 2.0"
+  s.add_runtime_dependency('json', '~> 2.1')
+  s.add_development_dependency "rspec", "3.5.0"
+  s.add_development_dependency "rake", ">= 10.0"
+end
+`
+	content, changed := rewriteGemspec([]byte(gemspec), versionUpdates)
+	if !changed || string(content) != expected {
+		t.Errorf("Expected gemspec:\n%s\nGot:\n%s", expected, content)
+	}
+
+	if _, changed := rewriteGemspec([]byte(expected), versionUpdates); changed {
+		t.Error("Gemspec allowing the target versions shouldn't be changed")
+	}
+}
+
+func TestRubygemsUpdaterWithGemspec(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-gemspec")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	ioutil.WriteFile("Gemfile.lock", []byte("GEM\n"), 0644)
+	ioutil.WriteFile("app.gemspec", []byte(`s.add_development_dependency "rspec", "~> 3.4.0"`), 0644)
+	os.Setenv(config.ENV_GEMNASIUM_BUNDLE_UPDATE_CMD, "true")
+	defer os.Unsetenv(config.ENV_GEMNASIUM_BUNDLE_UPDATE_CMD)
+
+	versionUpdates := []VersionUpdate{
+		{Package: models.Package{Name: "rspec", Type: "Rubygem"}, OldVersion: "3.4.0", TargetVersion: "3.5.2"},
+	}
+	var orgDepFiles, uptDepFiles []models.DependencyFile
+	if err := RubygemsUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != nil {
+		t.Fatal(err)
+	}
+	if len(orgDepFiles) != 2 || len(uptDepFiles) != 2 {
+		t.Fatalf("Gemfile.lock and app.gemspec should be recorded, got: %v, %v", orgDepFiles, uptDepFiles)
+	}
+	if string(orgDepFiles[1].Content) != `s.add_development_dependency "rspec", "~> 3.4.0"` {
+		t.Errorf("Original gemspec should be recorded, got: %s", orgDepFiles[1].Content)
+	}
+	if string(uptDepFiles[0].Content) != `s.add_development_dependency "rspec", "~> 3.5.2"` {
+		t.Errorf("Gemspec should be updated, got: %s", uptDepFiles[0].Content)
+	}
+}
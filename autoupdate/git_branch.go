@@ -0,0 +1,245 @@
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+const GIT_BRANCH_PREFIX = "gemnasium/update-"
+
+var gitBranchInvalidChars = regexp.MustCompile(`[^A-Za-z0-9._-]+`)
+
+// Name of the branch of an update set: gemnasium/update-<package>, or
+// gemnasium/update-<package>-and-<n>-more when it updates several packages
+func updateSetBranch(metadata *PatchMetadata) string {
+	switch len(metadata.Changes) {
+	case 0:
+		return fmt.Sprintf("%sset-%d", GIT_BRANCH_PREFIX, metadata.UpdateSetID)
+	case 1:
+		return GIT_BRANCH_PREFIX + gitBranchInvalidChars.ReplaceAllString(metadata.Changes[0].Package, "-")
+	}
+	name := gitBranchInvalidChars.ReplaceAllString(metadata.Changes[0].Package, "-")
+	return fmt.Sprintf("%s%s-and-%d-more", GIT_BRANCH_PREFIX, name, len(metadata.Changes)-1)
+}
+
+// Message of the commit of an update set: the updated packages, and the
+// advisories fixed by their new versions
+func updateSetCommitMessage(metadata *PatchMetadata) string {
+	var msg string
+	switch len(metadata.Changes) {
+	case 0:
+		msg = "Update dependency files"
+	case 1:
+		c := metadata.Changes[0]
+		msg = fmt.Sprintf("Update %s from %s to %s", c.Package, c.OldVersion, c.NewVersion)
+	default:
+		msg = fmt.Sprintf("Update %d dependencies", len(metadata.Changes))
+	}
+	msg += "\n\n"
+	for _, c := range metadata.Changes {
+		msg += fmt.Sprintf("- %s %s => %s", c.Package, c.OldVersion, c.NewVersion)
+		if len(c.Advisories) > 0 {
+			msg += fmt.Sprintf(" (fixes %s)", strings.Join(c.Advisories, ", "))
+		}
+		msg += "\n"
+	}
+	// Remediations (see Remediate) aren't update sets
+	if metadata.UpdateSetID == 0 {
+		return msg + "\nRemediation by gemnasium org remediate, not tested.\n"
+	}
+	return msg + fmt.Sprintf("\nUpdate set %d, tested by gemnasium autoupdate.\n", metadata.UpdateSetID)
+}
+
+func git(args ...string) (string, error) {
+	out, err := exec.Command(utils.GitPath(), args...).CombinedOutput()
+	if err != nil {
+		return "", fmt.Errorf("git %s: %s\n%s", strings.Join(args, " "), err, out)
+	}
+	return strings.TrimSpace(string(out)), nil
+}
+
+// Current branch, or current commit when detached, to check it out again
+func currentGitRef() (string, error) {
+	current, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err == nil && current == "HEAD" {
+		current, err = git("rev-parse", "HEAD")
+	}
+	return current, err
+}
+
+// Commit the updated dependency files of a successful update set on a new
+// branch (see updateSetBranch), and push it to origin if asked. Existing
+// branches are never reset: when the branch exists locally (or on origin when
+// pushing), a suffix is added to its name (ie: gemnasium/update-rails-2). The
+// current branch is checked out again afterwards, with the other changes of
+// the working tree.
+func commitUpdateSet(metadata *PatchMetadata, name string, push bool) (string, error) {
+	current, err := currentGitRef()
+	if err != nil {
+		return "", err
+	}
+
+	exists := func(branch string) (bool, error) {
+		if _, err := git("rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err == nil {
+			return true, nil
+		}
+		if !push {
+			return false, nil
+		}
+		out, err := git("ls-remote", "--heads", "origin", branch)
+		return out != "", err
+	}
+	branch := name
+	for i := 2; ; i++ {
+		taken, err := exists(branch)
+		if err != nil {
+			return "", err
+		}
+		if !taken {
+			break
+		}
+		branch = fmt.Sprintf("%s-%d", name, i)
+	}
+	if _, err := git("checkout", "--quiet", "-b", branch); err != nil {
+		return "", err
+	}
+	_, err = git(append([]string{"add", "--"}, metadata.Files...)...)
+	if err == nil {
+		_, err = git("commit", "--quiet", "-m", updateSetCommitMessage(metadata))
+	}
+	if err == nil && push {
+		_, err = git("push", "--quiet", "origin", branch)
+	}
+	if _, checkoutErr := git("checkout", "--quiet", current); err == nil {
+		err = checkoutErr
+	}
+	if err != nil {
+		// Files staged before the failure are left as they were
+		git(append([]string{"reset", "--quiet", "--"}, metadata.Files...)...)
+	}
+	return branch, err
+}
+
+// Groups of update sets committed on the same branch, instead of one branch
+// per update set (see config.GitGroups and config.GitGroupTypes)
+type branchGroups struct {
+	patterns map[string]*regexp.Regexp
+	types    bool
+	// Branches created by the run, by group
+	branches map[string]string
+}
+
+func newBranchGroups() (*branchGroups, error) {
+	groups := &branchGroups{patterns: map[string]*regexp.Regexp{}, types: config.GitGroupTypes, branches: map[string]string{}}
+	for name, pattern := range config.GitGroups {
+		re, err := regexp.Compile(pattern)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid pattern of the %s group: %s", name, err)
+		}
+		groups.patterns[name] = re
+	}
+	return groups, nil
+}
+
+// Group of a package: the group whose pattern matches its name (the first one
+// by name if several do), or with GitGroupTypes, its runtime package for
+// @types/* packages and runtime packages (ie: @types/babel__core and
+// @babel/core are in the @babel/core group). Empty if it has no group.
+func (g *branchGroups) packageGroup(pkg string) string {
+	group := ""
+	for name, re := range g.patterns {
+		if re.MatchString(pkg) && (group == "" || name < group) {
+			group = name
+		}
+	}
+	if group != "" || !g.types {
+		return group
+	}
+	if runtime := strings.TrimPrefix(pkg, "@types/"); runtime != pkg {
+		if i := strings.Index(runtime, "__"); i != -1 {
+			runtime = "@" + runtime[:i] + "/" + runtime[i+2:]
+		}
+		return runtime
+	}
+	return pkg
+}
+
+// Group of an update set: the group of its packages, if they all have the
+// same one
+func (g *branchGroups) updateSetGroup(metadata *PatchMetadata) string {
+	group := ""
+	for i, c := range metadata.Changes {
+		pkgGroup := g.packageGroup(c.Package)
+		if pkgGroup == "" || (i > 0 && pkgGroup != group) {
+			return ""
+		}
+		group = pkgGroup
+	}
+	return group
+}
+
+// Commit a successful update set on its own branch, or on the branch of its
+// group: the first update set of the group creates the branch
+// (gemnasium/update-<group>), and the next ones are applied again on top of
+// it, since their files were updated from the original ones. They're only
+// committed there if the test suite still passes. orgDepFiles are restored
+// before checking out the branch of the group.
+func (g *branchGroups) commit(updateSet *UpdateSet, metadata *PatchMetadata, orgDepFiles []models.DependencyFile, testSuite []string, push bool) (string, error) {
+	group := g.updateSetGroup(metadata)
+	if group == "" {
+		return commitUpdateSet(metadata, updateSetBranch(metadata), push)
+	}
+	branch, ok := g.branches[group]
+	if !ok {
+		name := GIT_BRANCH_PREFIX + gitBranchInvalidChars.ReplaceAllString(strings.TrimPrefix(group, "@"), "-")
+		branch, err := commitUpdateSet(metadata, name, push)
+		if err == nil {
+			g.branches[group] = branch
+		}
+		return branch, err
+	}
+
+	if err := restoreDepFiles(orgDepFiles); err != nil {
+		return "", err
+	}
+	current, err := currentGitRef()
+	if err != nil {
+		return "", err
+	}
+	if _, err := git("checkout", "--quiet", branch); err != nil {
+		return "", err
+	}
+	fmt.Fprint(Output, i18n.T("autoupdate.git_group_applying", updateSet.ID, branch))
+	branchDepFiles, _, err := applyUpdateSet(updateSet)
+	if err == nil {
+		var out []byte
+		if out, err = executeTestSuiteWithRetries(testSuite, config.TestRetries); err != nil {
+			err = errors.New(i18n.T("autoupdate.git_group_failing", branch, out))
+		}
+	}
+	if err == nil {
+		_, err = git(append([]string{"add", "--"}, metadata.Files...)...)
+	}
+	if err == nil {
+		_, err = git("commit", "--quiet", "-m", updateSetCommitMessage(metadata))
+	}
+	if err == nil && push {
+		_, err = git("push", "--quiet", "origin", branch)
+	}
+	if err != nil {
+		git(append([]string{"reset", "--quiet", "--"}, metadata.Files...)...)
+		restoreDepFiles(branchDepFiles)
+	}
+	if _, checkoutErr := git("checkout", "--quiet", current); err == nil {
+		err = checkoutErr
+	}
+	return branch, err
+}
@@ -0,0 +1,245 @@
+package autoupdate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+func TestUpdateSetBranch(t *testing.T) {
+	tests := []struct {
+		metadata PatchMetadata
+		branch   string
+	}{
+		{PatchMetadata{UpdateSetID: 3}, "gemnasium/update-set-3"},
+		{PatchMetadata{Changes: []PatchChange{{Package: "rails"}}}, "gemnasium/update-rails"},
+		{PatchMetadata{Changes: []PatchChange{{Package: "golang.org/x/net"}, {Package: "@babel/core"}}}, "gemnasium/update-golang.org-x-net-and-1-more"},
+	}
+	for _, test := range tests {
+		if branch := updateSetBranch(&test.metadata); branch != test.branch {
+			t.Errorf("Expected %s, got %s", test.branch, branch)
+		}
+	}
+}
+
+func TestUpdateSetGroup(t *testing.T) {
+	config.GitGroups = map[string]string{"aws-sdk": "^aws-sdk-", "aws": "^aws-"}
+	defer func() { config.GitGroups = map[string]string{} }()
+	changes := func(packages ...string) *PatchMetadata {
+		metadata := &PatchMetadata{}
+		for _, pkg := range packages {
+			metadata.Changes = append(metadata.Changes, PatchChange{Package: pkg})
+		}
+		return metadata
+	}
+	tests := []struct {
+		metadata *PatchMetadata
+		types    bool
+		group    string
+	}{
+		{changes("aws-sdk-s3"), false, "aws"},
+		{changes("aws-sdk-s3", "aws-sdk-core"), false, "aws"},
+		{changes("aws-sdk-s3", "rails"), false, ""},
+		{changes("lodash"), false, ""},
+		{changes("lodash"), true, "lodash"},
+		{changes("@types/lodash", "lodash"), true, "lodash"},
+		{changes("@types/babel__core"), true, "@babel/core"},
+		{changes("@types/lodash", "react"), true, ""},
+		{changes(), false, ""},
+	}
+	for _, test := range tests {
+		config.GitGroupTypes = test.types
+		groups, err := newBranchGroups()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if group := groups.updateSetGroup(test.metadata); group != test.group {
+			t.Errorf("%+v (types: %v): expected group %q, got %q", test.metadata.Changes, test.types, test.group, group)
+		}
+	}
+	config.GitGroupTypes = false
+
+	config.GitGroups["broken"] = "(aws"
+	if _, err := newBranchGroups(); err == nil {
+		t.Error("Expected an error with an invalid pattern")
+	}
+}
+
+func TestCommitUpdateSet(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required")
+	}
+	dir, err := ioutil.TempDir("", "gemnasium-git-branch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, value := range map[string]string{"GIT_AUTHOR_NAME": "test", "GIT_AUTHOR_EMAIL": "test@example.com", "GIT_COMMITTER_NAME": "test", "GIT_COMMITTER_EMAIL": "test@example.com"} {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = filepath.Join(dir, "repo")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %s\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+
+	// Repository with an origin to push to
+	os.MkdirAll(filepath.Join(dir, "repo"), 0755)
+	git("init", "--quiet", "--bare", filepath.Join(dir, "origin.git"))
+	git("init", "--quiet")
+	git("remote", "add", "origin", filepath.Join(dir, "origin.git"))
+	ioutil.WriteFile(filepath.Join(dir, "repo", "Gemfile.lock"), []byte("rails (4.2.0)\n"), 0644)
+	git("add", "Gemfile.lock")
+	git("commit", "--quiet", "-m", "init")
+	current := git("rev-parse", "--abbrev-ref", "HEAD")
+
+	wd, _ := os.Getwd()
+	os.Chdir(filepath.Join(dir, "repo"))
+	defer os.Chdir(wd)
+	ioutil.WriteFile("Gemfile.lock", []byte("rails (4.2.11)\n"), 0644)
+	ioutil.WriteFile("notes.txt", []byte("not committed\n"), 0644)
+	metadata := &PatchMetadata{UpdateSetID: 7, Files: []string{"Gemfile.lock"}, Changes: []PatchChange{
+		{Package: "rails", OldVersion: "4.2.0", NewVersion: "4.2.11", Advisories: []string{"CVE-2016-0752"}},
+	}}
+	branch, err := commitUpdateSet(metadata, updateSetBranch(metadata), true)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if branch != "gemnasium/update-rails" || git("rev-parse", "--abbrev-ref", "HEAD") != current {
+		t.Errorf("Expected %s to be committed from %s, got %s", branch, current, git("rev-parse", "--abbrev-ref", "HEAD"))
+	}
+	if content := git("show", "origin/"+branch+":Gemfile.lock"); content != "rails (4.2.11)" {
+		t.Errorf("Unexpected pushed Gemfile.lock: %s", content)
+	}
+	if msg := git("log", "-1", "--format=%B", branch); !strings.HasPrefix(msg, "Update rails from 4.2.0 to 4.2.11\n\n- rails 4.2.0 => 4.2.11 (fixes CVE-2016-0752)") {
+		t.Errorf("Unexpected commit message: %s", msg)
+	}
+	if content, _ := ioutil.ReadFile("notes.txt"); string(content) != "not committed\n" {
+		t.Error("Other changes of the working tree should be kept")
+	}
+
+	// The branch exists on origin only: it's kept, and a suffix is added
+	git("branch", "-D", branch)
+	ioutil.WriteFile("Gemfile.lock", []byte("rails (4.2.11)\n"), 0644)
+	if branch, err = commitUpdateSet(metadata, updateSetBranch(metadata), true); err != nil {
+		t.Fatal(err)
+	}
+	if branch != "gemnasium/update-rails-2" {
+		t.Errorf("Expected a suffix to be added, got %s", branch)
+	}
+	if refs := git("ls-remote", "--heads", "origin"); !strings.Contains(refs, "refs/heads/gemnasium/update-rails\n") || !strings.Contains(refs, "refs/heads/gemnasium/update-rails-2") {
+		t.Errorf("Both branches should be on origin, got:\n%s", refs)
+	}
+}
+
+func TestBranchGroupsCommit(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required")
+	}
+	dir, err := ioutil.TempDir("", "gemnasium-git-group")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, value := range map[string]string{"GIT_AUTHOR_NAME": "test", "GIT_AUTHOR_EMAIL": "test@example.com", "GIT_COMMITTER_NAME": "test", "GIT_COMMITTER_EMAIL": "test@example.com"} {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+	git := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %s\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	const lockfile = "aws-sdk-core (2.0)\naws-sdk-s3 (2.0)\n"
+	git("init", "--quiet")
+	ioutil.WriteFile(filepath.Join(dir, "Gemfile.lock"), []byte(lockfile), 0644)
+	git("add", "Gemfile.lock")
+	git("commit", "--quiet", "-m", "init")
+	current := git("rev-parse", "--abbrev-ref", "HEAD")
+
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	var out bytes.Buffer
+	Output = &out
+	defer func() { Output = os.Stdout }()
+	config.GitGroups = map[string]string{"aws-sdk": "^aws-sdk-"}
+	defer func() { config.GitGroups = map[string]string{} }()
+
+	// Updates the versions of the current Gemfile.lock
+	updater := updaters["Rubygem"]
+	updaters["Rubygem"] = func(versionUpdates []VersionUpdate, orgDepFiles, uptDepFiles *[]models.DependencyFile) error {
+		org, err := models.NewDependencyFileE("Gemfile.lock")
+		if err != nil {
+			return err
+		}
+		*orgDepFiles = append(*orgDepFiles, *org)
+		content := string(org.Content)
+		for _, vu := range versionUpdates {
+			content = strings.Replace(content, vu.Package.Name+" ("+vu.OldVersion+")", vu.Package.Name+" ("+vu.TargetVersion+")", 1)
+		}
+		if err := ioutil.WriteFile("Gemfile.lock", []byte(content), 0644); err != nil {
+			return err
+		}
+		upt := *org
+		upt.Content = []byte(content)
+		*uptDepFiles = append(*uptDepFiles, upt)
+		return nil
+	}
+	defer func() { updaters["Rubygem"] = updater }()
+
+	groups, err := newBranchGroups()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for id, pkg := range map[int]string{1: "aws-sdk-core", 2: "aws-sdk-s3"} {
+		updateSet := &UpdateSet{ID: id, VersionUpdates: map[string][]VersionUpdate{
+			"Rubygem": {{Package: models.Package{Name: pkg, Type: "Rubygem"}, OldVersion: "2.0", TargetVersion: "2.1"}},
+		}}
+		orgDepFiles, uptDepFiles, err := applyUpdateSet(updateSet)
+		if err != nil {
+			t.Fatal(err)
+		}
+		metadata := newPatchMetadata(updateSet, uptDepFiles, nil)
+		branch, err := groups.commit(updateSet, metadata, orgDepFiles, []string{"true"}, false)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if branch != "gemnasium/update-aws-sdk" {
+			t.Errorf("Expected update set %d to be committed on the branch of its group, got %s", id, branch)
+		}
+		if err := restoreDepFiles(orgDepFiles); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	if content := git("show", "gemnasium/update-aws-sdk:Gemfile.lock"); content != "aws-sdk-core (2.1)\naws-sdk-s3 (2.1)" {
+		t.Errorf("Expected both updates on the branch of the group, got:\n%s", content)
+	}
+	if commits := git("rev-list", "--count", current+"..gemnasium/update-aws-sdk"); commits != "2" {
+		t.Errorf("Expected a commit per update set, got %s", commits)
+	}
+	if git("rev-parse", "--abbrev-ref", "HEAD") != current {
+		t.Errorf("Expected %s to be checked out again", current)
+	}
+	if content, _ := ioutil.ReadFile("Gemfile.lock"); string(content) != lockfile {
+		t.Errorf("Expected Gemfile.lock to be restored, got:\n%s", content)
+	}
+}
@@ -4,12 +4,12 @@ import (
 	"errors"
 	"fmt"
 	"os"
-	"os/exec"
 	"path"
 	"regexp"
 	"strings"
 
 	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
 	"github.com/gemnasium/toolbelt/models"
 )
 
@@ -18,8 +18,8 @@ const (
 )
 
 var (
-	cantInstallRequirements = errors.New("Can't install requirements")
-	cantFindInstaller       = "Can't find installer for package type: %s\n"
+	cantInstallRequirements = errors.New(i18n.T("autoupdate.cant_install_requirements"))
+	cantFindInstaller       = i18n.T("autoupdate.cant_find_installer")
 )
 
 type InstallRequirementsFunc func([]RequirementUpdate, *[]models.DependencyFile, *[]models.DependencyFile) error
@@ -47,9 +47,9 @@ func RubygemsInstaller(reqUpdates []RequirementUpdate, orgDepFiles, uptDepFiles
 			bi = biCMDEnv
 		}
 		parts := strings.Fields(bi)
-		cmd := exec.Command(parts[0], parts[1:]...)
+		cmd := command(parts[0], parts[1:]...)
 		cmd.Dir = path.Dir("f.Path")
-		fmt.Println("Running", bi)
+		fmt.Fprintln(Output, i18n.T("autoupdate.running", bi))
 		out, err := cmd.Output()
 		if err != nil {
 
@@ -62,9 +62,9 @@ func RubygemsInstaller(reqUpdates []RequirementUpdate, orgDepFiles, uptDepFiles
 			case mustBundleUpdate.MatchString(output):
 				bundleUpt := mustBundleUpdate.FindStringSubmatch(string(out))[1]
 				parts := strings.Fields(bundleUpt)
-				cmd := exec.Command(parts[0], parts[1:]...)
+				cmd := command(parts[0], parts[1:]...)
 				cmd.Dir = path.Dir("f.Path")
-				fmt.Println("Running", bundleUpt)
+				fmt.Fprintln(Output, i18n.T("autoupdate.running", bundleUpt))
 				err := cmd.Run()
 				if err != nil {
 					return cantInstallRequirements
@@ -72,7 +72,7 @@ func RubygemsInstaller(reqUpdates []RequirementUpdate, orgDepFiles, uptDepFiles
 			case couldNotFindCompatibleVersion.MatchString(output):
 				return cantInstallRequirements
 			default:
-				fmt.Printf("Error while installing packages:\n%s\n", string(out))
+				fmt.Fprint(Output, i18n.T("autoupdate.install_error", string(out)))
 				return err
 			}
 		}
@@ -91,7 +91,7 @@ func PatchFile(ru RequirementUpdate, orgDepFiles, uptDepFiles *[]models.Dependen
 	// fetch file content
 	f.Update()
 	*orgDepFiles = append(*orgDepFiles, f)
-	fmt.Println("Patching", f.Path)
+	fmt.Fprintln(Output, i18n.T("autoupdate.patching", f.Path))
 	err = f.Patch(ru.Patch)
 	if err != nil {
 		return err
@@ -0,0 +1,92 @@
+package autoupdate
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// Func template for lockfile readers. Given a package name, it returns the
+// version currently locked in the project, or an empty string if the package
+// isn't locked.
+type LockedVersionFunc func(packageName string) (string, error)
+
+// Package types without reader (Maven, Gradle, NuGet: no lockfile is read)
+// are never considered as already satisfied.
+var lockedVersionReaders = map[string]LockedVersionFunc{
+	"Rubygem": RubygemsLockedVersion,
+	"Npm":     lockfileLockedVersion("package-lock.json", "npm-shrinkwrap.json"),
+	"Go":      lockfileLockedVersion("go.sum"),
+	"Cargo":   lockfileLockedVersion("Cargo.lock"),
+}
+
+// Return a reader of the version locked in the first of the lockfiles found
+// (see models.DependencyFile.LockedPackages). When several versions of the
+// package are locked (ie: in Cargo.lock), the lowest one is returned.
+func lockfileLockedVersion(lockfiles ...string) LockedVersionFunc {
+	return func(packageName string) (string, error) {
+		for _, path := range lockfiles {
+			df, err := models.NewDependencyFileE(path)
+			if os.IsNotExist(err) {
+				continue
+			}
+			if err != nil {
+				return "", err
+			}
+			version := ""
+			for _, pkg := range df.LockedPackages() {
+				if pkg.Name == packageName && (version == "" || utils.CompareVersions(pkg.Version, version) < 0) {
+					version = pkg.Version
+				}
+			}
+			return version, nil
+		}
+		return "", nil
+	}
+}
+
+// Return the version of the gem locked in Gemfile.lock
+func RubygemsLockedVersion(packageName string) (string, error) {
+	f, err := os.Open("Gemfile.lock")
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+
+	// Only top level specs are indented with exactly 4 spaces
+	spec := regexp.MustCompile(`^    ` + regexp.QuoteMeta(packageName) + ` \(([^)]+)\)$`)
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		if m := spec.FindStringSubmatch(scanner.Text()); m != nil {
+			return m[1], nil
+		}
+	}
+	return "", scanner.Err()
+}
+
+// Check if all version updates of the update set are already satisfied by the
+// current lockfiles (ie: someone updated the packages manually, maybe to a
+// version above the target).
+// Update sets with requirement updates are never considered as satisfied, since
+// the requirements have to be patched anyway.
+func isAlreadySatisfied(updateSet *UpdateSet) bool {
+	if len(updateSet.RequirementUpdates) > 0 || len(updateSet.VersionUpdates) == 0 {
+		return false
+	}
+	for packageType, versionUpdates := range updateSet.VersionUpdates {
+		reader, ok := lockedVersionReaders[packageType]
+		if !ok {
+			return false
+		}
+		for _, vu := range versionUpdates {
+			version, err := reader(vu.Package.Name)
+			if err != nil || version == "" || utils.CompareVersions(version, vu.TargetVersion) < 0 {
+				return false
+			}
+		}
+	}
+	return true
+}
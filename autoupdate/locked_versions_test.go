@@ -0,0 +1,111 @@
+package autoupdate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/models"
+)
+
+const testGemfileLock = `GEM
+  remote: https://rubygems.org/
+  specs:
+    rack (1.5.2)
+    rails (4.0.3)
+      rack (~> 1.5.2)
+
+PLATFORMS
+  ruby
+
+DEPENDENCIES
+  rails (= 4.0.3)
+`
+
+func TestRubygemsLockedVersion(t *testing.T) {
+	err := ioutil.WriteFile("Gemfile.lock", []byte(testGemfileLock), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("Gemfile.lock")
+
+	var tt = []struct {
+		Name    string
+		Version string
+	}{
+		{"rails", "4.0.3"},
+		{"rack", "1.5.2"},
+		{"warden", ""},
+	}
+	for _, test := range tt {
+		version, err := RubygemsLockedVersion(test.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != test.Version {
+			t.Errorf("Expected %s to be locked at '%s', got: '%s'", test.Name, test.Version, version)
+		}
+	}
+}
+
+func TestIsAlreadySatisfied(t *testing.T) {
+	err := ioutil.WriteFile("Gemfile.lock", []byte(testGemfileLock), 0644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("Gemfile.lock")
+
+	newUpdateSet := func(target string) *UpdateSet {
+		return &UpdateSet{
+			ID: 1,
+			VersionUpdates: map[string][]VersionUpdate{
+				"Rubygem": []VersionUpdate{
+					VersionUpdate{Package: models.Package{Name: "rails"}, OldVersion: "4.0.2", TargetVersion: target},
+				},
+			},
+		}
+	}
+	if !isAlreadySatisfied(newUpdateSet("4.0.3")) {
+		t.Error("Update set should be satisfied by Gemfile.lock")
+	}
+	if !isAlreadySatisfied(newUpdateSet("4.0.2")) {
+		t.Error("Update set should be satisfied by a newer version in Gemfile.lock")
+	}
+	if isAlreadySatisfied(newUpdateSet("4.0.4")) {
+		t.Error("Update set should not be satisfied by Gemfile.lock")
+	}
+	if isAlreadySatisfied(&UpdateSet{ID: 1}) {
+		t.Error("Empty update set should not be considered as satisfied")
+	}
+}
+
+func TestLockfileLockedVersion(t *testing.T) {
+	cargoLock := "[[package]]\nname = \"serde\"\nversion = \"1.0.130\"\n\n[[package]]\nname = \"serde\"\nversion = \"0.9.15\"\n\n[[package]]\nname = \"rand\"\nversion = \"0.8.4\"\n"
+	if err := ioutil.WriteFile("Cargo.lock", []byte(cargoLock), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove("Cargo.lock")
+
+	var tt = []struct {
+		Name    string
+		Version string
+	}{
+		{"rand", "0.8.4"},
+		{"serde", "0.9.15"},
+		{"log", ""},
+	}
+	for _, test := range tt {
+		version, err := lockedVersionReaders["Cargo"](test.Name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if version != test.Version {
+			t.Errorf("Expected %s to be locked at '%s', got: '%s'", test.Name, test.Version, version)
+		}
+	}
+
+	// No package-lock.json nor npm-shrinkwrap.json
+	if version, err := lockedVersionReaders["Npm"]("lodash"); err != nil || version != "" {
+		t.Errorf("Expected lodash not to be locked, got: '%s' (%v)", version, err)
+	}
+}
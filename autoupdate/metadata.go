@@ -0,0 +1,118 @@
+package autoupdate
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/gemnasium/toolbelt/models"
+)
+
+// Ecosystems of the dependency files updated for each package type (see
+// DependencyFile.Ecosystem)
+var packageEcosystems = map[string]string{
+	"Rubygem": "ruby",
+	"Npm":     "npm",
+	"Go":      "go",
+	"Cargo":   "cargo",
+	"Maven":   "maven",
+	"Gradle":  "gradle",
+	"Nuget":   "nuget",
+}
+
+// A package updated by an update set
+type PatchChange struct {
+	Package    string `json:"package"`
+	Type       string `json:"type"`
+	OldVersion string `json:"old_version"`
+	NewVersion string `json:"new_version"`
+	// Changed files of the ecosystem of the package
+	Files []string `json:"files"`
+	// Identifiers (ie: CVE-2016-1234) of the advisories of the open alerts
+	// fixed by the new version
+	Advisories []string `json:"advisories"`
+	// Owners of the package or of its files (see models.Owners)
+	Owners []string `json:"owners,omitempty"`
+}
+
+// Description of the changes of an update set, sent along with the diff or the
+// updated files, so tools don't have to parse diffs to know what changed
+type PatchMetadata struct {
+	UpdateSetID int           `json:"update_set_id"`
+	Files       []string      `json:"files"`
+	Changes     []PatchChange `json:"changes"`
+	// Owners of all the changes, to assign or label the merge requests
+	Owners []string `json:"owners,omitempty"`
+}
+
+// Return the identifier of the advisory, or its ID if it has none
+func advisoryIdentifier(a models.Advisory) string {
+	if a.Identifier != "" {
+		return a.Identifier
+	}
+	return strconv.Itoa(a.ID)
+}
+
+// Return the advisories of the open alerts of the package fixed by version.
+// When the cured versions can't be parsed, the advisory is considered fixed.
+func fixedAdvisories(vu VersionUpdate, alerts []models.Alert) []string {
+	advisories := []string{}
+	for _, alert := range alerts {
+		a := alert.Advisory
+		if alert.Status == "closed" || a.Package.Name != vu.Package.Name || a.Package.Type != vu.Package.Type {
+			continue
+		}
+		if a.CuredVersions != "" {
+			if cured, err := satisfiesRequirement(vu.Package.Type, vu.TargetVersion, a.CuredVersions); err == nil && !cured {
+				continue
+			}
+		}
+		advisories = append(advisories, advisoryIdentifier(a))
+	}
+	return advisories
+}
+
+// Describe the changes of the update set, with the updated files, the open
+// alerts of the project, and the owners of the project's owners file if any
+func newPatchMetadata(updateSet *UpdateSet, uptDepFiles []models.DependencyFile, alerts []models.Alert) *PatchMetadata {
+	metadata := &PatchMetadata{UpdateSetID: updateSet.ID, Files: []string{}, Changes: []PatchChange{}}
+	// Best effort: the file is checked before the run
+	owners, _ := models.LoadOwners()
+	allOwners := map[string]bool{}
+	for _, df := range uptDepFiles {
+		metadata.Files = append(metadata.Files, df.Path)
+	}
+
+	packageTypes := []string{}
+	for packageType := range updateSet.VersionUpdates {
+		packageTypes = append(packageTypes, packageType)
+	}
+	sort.Strings(packageTypes)
+	for _, packageType := range packageTypes {
+		files := []string{}
+		for _, df := range uptDepFiles {
+			if df.Ecosystem() == packageEcosystems[packageType] {
+				files = append(files, df.Path)
+			}
+		}
+		for _, vu := range updateSet.VersionUpdates[packageType] {
+			changeOwners := owners.Of(vu.Package.Name, files...)
+			for _, owner := range changeOwners {
+				allOwners[owner] = true
+			}
+			metadata.Changes = append(metadata.Changes, PatchChange{
+				Package:    vu.Package.Name,
+				Type:       packageType,
+				OldVersion: vu.OldVersion,
+				NewVersion: vu.TargetVersion,
+				Files:      files,
+				Advisories: fixedAdvisories(vu, alerts),
+				Owners:     changeOwners,
+			})
+		}
+	}
+	for owner := range allOwners {
+		metadata.Owners = append(metadata.Owners, owner)
+	}
+	sort.Strings(metadata.Owners)
+	return metadata
+}
@@ -0,0 +1,64 @@
+package autoupdate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/models"
+)
+
+func TestNewPatchMetadata(t *testing.T) {
+	rack := models.Package{Name: "rack", Type: "Rubygem"}
+	updateSet := &UpdateSet{
+		ID: 4,
+		VersionUpdates: map[string][]VersionUpdate{
+			"Rubygem": {{Package: rack, OldVersion: "1.6.4", TargetVersion: "1.6.12"}},
+			"Npm":     {{Package: models.Package{Name: "lodash", Type: "Npm"}, OldVersion: "4.17.15", TargetVersion: "4.17.21"}},
+		},
+	}
+	uptDepFiles := []models.DependencyFile{{Path: "Gemfile.lock"}, {Path: "web/package-lock.json"}}
+	alerts := []models.Alert{
+		{Status: "acknowledged", Advisory: models.Advisory{ID: 1, Identifier: "CVE-2018-16471", Package: rack, CuredVersions: "~> 1.6.11"}},
+		// Not fixed by the target version
+		{Status: "acknowledged", Advisory: models.Advisory{ID: 2, Identifier: "CVE-2020-8161", Package: rack, CuredVersions: ">= 2.1.3"}},
+		{Status: "closed", Advisory: models.Advisory{ID: 3, Identifier: "CVE-2015-3225", Package: rack, CuredVersions: ">= 1.6.2"}},
+		// No identifier
+		{Status: "acknowledged", Advisory: models.Advisory{ID: 4, Package: models.Package{Name: "lodash", Type: "Npm"}}},
+	}
+
+	expected := &PatchMetadata{
+		UpdateSetID: 4,
+		Files:       []string{"Gemfile.lock", "web/package-lock.json"},
+		Changes: []PatchChange{
+			{Package: "lodash", Type: "Npm", OldVersion: "4.17.15", NewVersion: "4.17.21", Files: []string{"web/package-lock.json"}, Advisories: []string{"4"}},
+			{Package: "rack", Type: "Rubygem", OldVersion: "1.6.4", NewVersion: "1.6.12", Files: []string{"Gemfile.lock"}, Advisories: []string{"CVE-2018-16471"}},
+		},
+	}
+	if metadata := newPatchMetadata(updateSet, uptDepFiles, alerts); !reflect.DeepEqual(metadata, expected) {
+		t.Errorf("Expected metadata:\n%#v\nGot:\n%#v", expected, metadata)
+	}
+
+	// With an owners file
+	dir, err := ioutil.TempDir("", "gemnasium-owners")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	owners := "web/**  @frontend\npackage:rack  @backend @security\n"
+	if err := ioutil.WriteFile(filepath.Join(dir, models.OWNERS_FILE_PATH), []byte(owners), 0644); err != nil {
+		t.Fatal(err)
+	}
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	metadata := newPatchMetadata(updateSet, uptDepFiles, alerts)
+	if !reflect.DeepEqual(metadata.Owners, []string{"@backend", "@frontend", "@security"}) {
+		t.Errorf("Expected the owners of all the changes, got %v", metadata.Owners)
+	}
+	if !reflect.DeepEqual(metadata.Changes[0].Owners, []string{"@frontend"}) || !reflect.DeepEqual(metadata.Changes[1].Owners, []string{"@backend", "@security"}) {
+		t.Errorf("Expected the owners of each change, got %v and %v", metadata.Changes[0].Owners, metadata.Changes[1].Owners)
+	}
+}
@@ -0,0 +1,116 @@
+package autoupdate
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// Outcome of an autoupdate run
+type RunSummary struct {
+	Project string
+	// Number of update sets by result state (ie: "test_passed" => 1)
+	Results    map[string]int
+	StartedAt  time.Time
+	FinishedAt time.Time
+	Err        error
+}
+
+func (s *RunSummary) Status() string {
+	switch {
+	case s.Err != nil:
+		return "failed"
+	case s.Results[UPDATE_SET_SUCCESS] > 0:
+		return "succeeded"
+	}
+	return "completed"
+}
+
+func (s *RunSummary) Duration() time.Duration {
+	return s.FinishedAt.Sub(s.StartedAt).Round(time.Second)
+}
+
+// JSON document of the summary (--json)
+func (s *RunSummary) MarshalJSON() ([]byte, error) {
+	var errMsg string
+	if s.Err != nil {
+		errMsg = s.Err.Error()
+	}
+	return json.Marshal(struct {
+		Project    string         `json:"project"`
+		Status     string         `json:"status"`
+		Results    map[string]int `json:"results"`
+		StartedAt  time.Time      `json:"started_at"`
+		FinishedAt time.Time      `json:"finished_at"`
+		Duration   float64        `json:"duration"`
+		Error      string         `json:"error,omitempty"`
+	}{s.Project, s.Status(), s.Results, s.StartedAt, s.FinishedAt, s.Duration().Seconds(), errMsg})
+}
+
+const (
+	DEFAULT_EMAIL_SUBJECT = "[gemnasium] autoupdate {{.Status}} for {{.Project}}"
+	DEFAULT_EMAIL_BODY    = `Autoupdate {{.Status}} for {{.Project}} in {{.Duration}}.
+{{if .Err}}
+Error: {{.Err}}
+{{end}}
+Passed: {{index .Results "test_passed"}}
+Failed: {{index .Results "test_failed"}}
+Invalid: {{index .Results "invalid"}}
+Deferred: {{index .Results "deferred"}}
+Already satisfied: {{index .Results "already_satisfied"}}
+`
+)
+
+var sendMail = smtp.SendMail
+
+// Email the summary of the run with the SMTP settings of config. Subject and
+// body are text/template templates, executed with the RunSummary.
+func sendSummaryEmail(summary *RunSummary) error {
+	if len(config.SMTPTo) == 0 {
+		return fmt.Errorf("No recipients (see %s)", config.ENV_SMTP_TO)
+	}
+	subject, err := executeTemplate(config.SMTPSubject, DEFAULT_EMAIL_SUBJECT, summary)
+	if err != nil {
+		return err
+	}
+	body, err := executeTemplate(config.SMTPBody, DEFAULT_EMAIL_BODY, summary)
+	if err != nil {
+		return err
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", config.SMTPFrom)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(config.SMTPTo, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", strings.TrimSpace(subject))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\nContent-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.Replace(body, "\n", "\r\n", -1))
+
+	var auth smtp.Auth
+	if config.SMTPUsername != "" {
+		auth = smtp.PlainAuth("", config.SMTPUsername, config.SMTPPassword, config.SMTPHost)
+	}
+	addr := fmt.Sprintf("%s:%d", config.SMTPHost, config.SMTPPort)
+	return sendMail(addr, auth, config.SMTPFrom, config.SMTPTo, msg.Bytes())
+}
+
+func executeTemplate(text, defaultText string, data interface{}) (string, error) {
+	if text == "" {
+		text = defaultText
+	}
+	tmpl, err := template.New("email").Parse(text)
+	if err != nil {
+		return "", err
+	}
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, data); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
@@ -0,0 +1,77 @@
+package autoupdate
+
+import (
+	"encoding/json"
+	"errors"
+	"net/smtp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestSendSummaryEmail(t *testing.T) {
+	var addr, from string
+	var to []string
+	var msg []byte
+	sendMail = func(a string, auth smtp.Auth, f string, t []string, m []byte) error {
+		addr, from, to, msg = a, f, t, m
+		return nil
+	}
+	defer func() { sendMail = smtp.SendMail }()
+	config.SMTPHost, config.SMTPTo = "mail.example.com", []string{"dev@example.com", "ops@example.com"}
+	defer func() { config.SMTPHost, config.SMTPTo, config.SMTPSubject = "", nil, "" }()
+
+	start := time.Now()
+	summary := &RunSummary{
+		Project:    "blah",
+		Results:    map[string]int{UPDATE_SET_FAIL: 2, UPDATE_SET_SUCCESS: 1},
+		StartedAt:  start,
+		FinishedAt: start.Add(90 * time.Second),
+	}
+	if err := sendSummaryEmail(summary); err != nil {
+		t.Fatal(err)
+	}
+	if addr != "mail.example.com:25" || from != config.SMTPFrom || len(to) != 2 {
+		t.Errorf("Unexpected envelope: %s %s %v", addr, from, to)
+	}
+	for _, expected := range []string{
+		"To: dev@example.com, ops@example.com\r\n",
+		"Subject: [gemnasium] autoupdate succeeded for blah\r\n",
+		"Autoupdate succeeded for blah in 1m30s.\r\n",
+		"Failed: 2\r\n",
+	} {
+		if !strings.Contains(string(msg), expected) {
+			t.Errorf("Email should contain %q, got:\n%s", expected, msg)
+		}
+	}
+
+	config.SMTPSubject = "{{.Project}}: {{.Status}}"
+	summary.Err = errors.New("boom")
+	if err := sendSummaryEmail(summary); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(msg), "Subject: blah: failed\r\n") || !strings.Contains(string(msg), "Error: boom") {
+		t.Errorf("Email should use the subject template and contain the error, got:\n%s", msg)
+	}
+}
+
+func TestRunSummaryJSON(t *testing.T) {
+	start := time.Date(2016, 3, 1, 10, 0, 0, 0, time.UTC)
+	summary := &RunSummary{
+		Project:    "blah",
+		Results:    map[string]int{UPDATE_SET_FAIL: 1},
+		StartedAt:  start,
+		FinishedAt: start.Add(90 * time.Second),
+		Err:        errors.New("boom"),
+	}
+	data, err := json.Marshal(summary)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := `{"project":"blah","status":"failed","results":{"test_failed":1},"started_at":"2016-03-01T10:00:00Z","finished_at":"2016-03-01T10:01:30Z","duration":90,"error":"boom"}`
+	if string(data) != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, data)
+	}
+}
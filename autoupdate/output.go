@@ -0,0 +1,10 @@
+package autoupdate
+
+import (
+	"io"
+	"os"
+)
+
+// Where messages, tables and reports are printed. Library users can capture
+// or silence them with another writer (ie: ioutil.Discard).
+var Output io.Writer = os.Stdout
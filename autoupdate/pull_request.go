@@ -0,0 +1,172 @@
+package autoupdate
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"regexp"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/registry"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// Context of the commit statuses set on the branches of update sets
+const STATUS_CONTEXT = "gemnasium/autoupdate"
+
+var githubRemoteRegexp = regexp.MustCompile(`github\.com[/:]([\w.-]+)/([\w.-]+?)(?:\.git)?/?$`)
+
+// Pull request opened on GitHub
+type pullRequest struct {
+	Number  int    `json:"number"`
+	HTMLURL string `json:"html_url"`
+	NodeID  string `json:"node_id"`
+}
+
+// Pull requests of the branches of update sets, opened on the GitHub
+// repository of origin. The branches of groups get new commits after their
+// pull request is opened (see branchGroups): only their status is set then.
+type pullRequests struct {
+	repository string
+	// Branch the pull requests are opened against
+	base string
+	// Set the status of the branches (config.PRStatus by default)
+	status bool
+	opened map[string]*pullRequest
+}
+
+func newPullRequests() (*pullRequests, error) {
+	if config.GitHubToken == "" {
+		return nil, errors.New(i18n.T("autoupdate.pull_request_token_missing", config.ENV_GITHUB_TOKEN))
+	}
+	origin, err := git("remote", "get-url", "origin")
+	if err != nil {
+		return nil, err
+	}
+	m := githubRemoteRegexp.FindStringSubmatch(origin)
+	if m == nil {
+		return nil, errors.New(i18n.T("autoupdate.pull_request_not_github", origin))
+	}
+	base, err := git("rev-parse", "--abbrev-ref", "HEAD")
+	if err != nil {
+		return nil, err
+	}
+	if base == "HEAD" {
+		return nil, errors.New(i18n.T("autoupdate.pull_request_detached"))
+	}
+	return &pullRequests{repository: m[1] + "/" + m[2], base: base, status: config.PRStatus, opened: map[string]*pullRequest{}}, nil
+}
+
+// Open the pull request of the pushed branch if it's not opened yet, with
+// the labels, assignees and auto-merge of config, and set the status of the
+// head of the branch: the test suite passed with the update set.
+func (prs *pullRequests) publish(branch string, metadata *PatchMetadata) (*pullRequest, error) {
+	if prs.status {
+		sha, err := git("rev-parse", branch)
+		if err != nil {
+			return nil, err
+		}
+		status := map[string]string{
+			"state":       "success",
+			"context":     STATUS_CONTEXT,
+			"description": fmt.Sprintf("The test suite passes with update set %d", metadata.UpdateSetID),
+		}
+		if err := githubRequest("POST", "/repos/"+prs.repository+"/statuses/"+sha, status, nil); err != nil {
+			return nil, err
+		}
+	}
+	if pr, ok := prs.opened[branch]; ok {
+		return pr, nil
+	}
+
+	message := strings.SplitN(updateSetCommitMessage(metadata), "\n\n", 2)
+	pr := &pullRequest{}
+	body := map[string]string{"title": message[0], "head": branch, "base": prs.base, "body": message[1]}
+	if err := githubRequest("POST", "/repos/"+prs.repository+"/pulls", body, pr); err != nil {
+		return nil, err
+	}
+	prs.opened[branch] = pr
+
+	// Pull requests are issues, as far as labels and assignees are concerned
+	issue := fmt.Sprintf("/repos/%s/issues/%d", prs.repository, pr.Number)
+	if len(config.PRLabels) > 0 {
+		if err := githubRequest("POST", issue+"/labels", map[string][]string{"labels": config.PRLabels}, nil); err != nil {
+			return pr, err
+		}
+	}
+	if len(config.PRAssignees) > 0 {
+		if err := githubRequest("POST", issue+"/assignees", map[string][]string{"assignees": config.PRAssignees}, nil); err != nil {
+			return pr, err
+		}
+	}
+	if config.PRAutoMerge != "" {
+		if err := enableAutoMerge(pr, config.PRAutoMerge); err != nil {
+			return pr, err
+		}
+	}
+	return pr, nil
+}
+
+// Merge the pull request once its required checks pass. Auto-merge must be
+// allowed on the repository, and is only available with the GraphQL API.
+func enableAutoMerge(pr *pullRequest, method string) error {
+	method = strings.ToUpper(method)
+	if method != "MERGE" && method != "SQUASH" && method != "REBASE" {
+		return errors.New(i18n.T("autoupdate.pull_request_auto_merge_invalid", method))
+	}
+	query := map[string]interface{}{
+		"query": `mutation($id: ID!, $method: PullRequestMergeMethod!) {
+  enablePullRequestAutoMerge(input: {pullRequestId: $id, mergeMethod: $method}) { clientMutationId }
+}`,
+		"variables": map[string]string{"id": pr.NodeID, "method": method},
+	}
+	var result struct {
+		Errors []struct {
+			Message string `json:"message"`
+		} `json:"errors"`
+	}
+	if err := githubRequest("POST", "/graphql", query, &result); err != nil {
+		return err
+	}
+	if len(result.Errors) > 0 {
+		return errors.New(i18n.T("autoupdate.pull_request_auto_merge_error", pr.Number, result.Errors[0].Message))
+	}
+	return nil
+}
+
+// Send a request to the GitHub API, authenticated with config.GitHubToken,
+// and decode the json response into result
+func githubRequest(method, path string, body, result interface{}) error {
+	JSON, err := json.Marshal(body)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest(method, registry.GitHubURL+path, bytes.NewReader(JSON))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Authorization", "token "+config.GitHubToken)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := utils.NewHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	content, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("%s %s: %s\n%s", method, path, resp.Status, content)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(content, result)
+}
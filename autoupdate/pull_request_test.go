@@ -0,0 +1,121 @@
+package autoupdate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/registry"
+)
+
+func TestPullRequestsPublish(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required")
+	}
+	dir, err := ioutil.TempDir("", "gemnasium-pull-request")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, value := range map[string]string{"GIT_AUTHOR_NAME": "test", "GIT_AUTHOR_EMAIL": "test@example.com", "GIT_COMMITTER_NAME": "test", "GIT_COMMITTER_EMAIL": "test@example.com"} {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	for _, args := range [][]string{
+		{"init", "--quiet"},
+		{"remote", "add", "origin", "git@github.com:org/app.git"},
+		{"commit", "--quiet", "--allow-empty", "-m", "init"},
+		{"branch", "gemnasium/update-rails"},
+	} {
+		if out, err := exec.Command("git", args...).CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s\n%s", args, err, out)
+		}
+	}
+	head, _ := git("rev-parse", "HEAD")
+	base, _ := git("rev-parse", "--abbrev-ref", "HEAD")
+
+	requests := []string{}
+	bodies := map[string]map[string]interface{}{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "token s3cret" {
+			t.Errorf("Expected the GitHub token, got %q", r.Header.Get("Authorization"))
+		}
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		body := map[string]interface{}{}
+		json.NewDecoder(r.Body).Decode(&body)
+		bodies[r.URL.Path] = body
+		switch r.URL.Path {
+		case "/repos/org/app/pulls":
+			fmt.Fprintln(w, `{"number": 12, "html_url": "https://github.com/org/app/pull/12", "node_id": "PR_12"}`)
+		case "/graphql":
+			fmt.Fprintln(w, `{"data": {}}`)
+		default:
+			fmt.Fprintln(w, `{}`)
+		}
+	}))
+	defer ts.Close()
+	registry.GitHubURL = ts.URL
+	config.GitHubToken = "s3cret"
+	config.PRLabels = []string{"dependencies"}
+	config.PRAssignees = []string{"octocat"}
+	config.PRAutoMerge = "squash"
+	defer func() {
+		registry.GitHubURL = "https://api.github.com"
+		config.GitHubToken, config.PRLabels, config.PRAssignees, config.PRAutoMerge = "", nil, nil, ""
+	}()
+
+	prs, err := newPullRequests()
+	if err != nil {
+		t.Fatal(err)
+	}
+	metadata := &PatchMetadata{UpdateSetID: 7, Files: []string{"Gemfile.lock"}, Changes: []PatchChange{
+		{Package: "rails", OldVersion: "4.2.0", NewVersion: "4.2.11", Advisories: []string{"CVE-2016-0752"}},
+	}}
+	pr, err := prs.publish("gemnasium/update-rails", metadata)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if pr.HTMLURL != "https://github.com/org/app/pull/12" {
+		t.Errorf("Unexpected pull request: %+v", pr)
+	}
+	expected := []string{
+		"POST /repos/org/app/statuses/" + head,
+		"POST /repos/org/app/pulls",
+		"POST /repos/org/app/issues/12/labels",
+		"POST /repos/org/app/issues/12/assignees",
+		"POST /graphql",
+	}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected requests %v, got %v", expected, requests)
+	}
+	pull := bodies["/repos/org/app/pulls"]
+	if pull["title"] != "Update rails from 4.2.0 to 4.2.11" || pull["head"] != "gemnasium/update-rails" || pull["base"] != base {
+		t.Errorf("Unexpected pull request: %v", pull)
+	}
+	if status := bodies["/repos/org/app/statuses/"+head]; status["state"] != "success" || status["context"] != STATUS_CONTEXT {
+		t.Errorf("Unexpected status: %v", status)
+	}
+	if variables := bodies["/graphql"]["variables"]; !reflect.DeepEqual(variables, map[string]interface{}{"id": "PR_12", "method": "SQUASH"}) {
+		t.Errorf("Unexpected auto-merge variables: %v", variables)
+	}
+
+	// The pull request of the branch is already opened: only the status is set
+	requests = []string{}
+	if _, err := prs.publish("gemnasium/update-rails", metadata); err != nil {
+		t.Fatal(err)
+	}
+	if len(requests) != 1 || !strings.Contains(requests[0], "/statuses/") {
+		t.Errorf("Expected only the status to be set, got %v", requests)
+	}
+}
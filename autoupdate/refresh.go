@@ -0,0 +1,206 @@
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+var (
+	// Lines of the commit messages of update sets (see updateSetCommitMessage)
+	commitChangeRegexp    = regexp.MustCompile(`(?m)^- (\S+) (\S+) => (\S+)`)
+	commitUpdateSetRegexp = regexp.MustCompile(`(?m)^Update set (\d+), tested by gemnasium autoupdate\.$`)
+)
+
+// Rebase the gemnasium/* branches, local or on origin, onto base (the default
+// branch of origin if empty). When a rebase conflicts (ie: the lockfile
+// changed on base), the branch is reset to base and the versions of its
+// commits are updated again, then tested with testSuite if it's not empty.
+// Branches are force pushed to origin (with lease) unless push is false.
+// Update sets patching requirements (ie: gemspecs) can't be updated again,
+// only the versions of their commits are.
+func Refresh(base string, testSuite []string, push bool) error {
+	if envTS := os.Getenv(config.ENV_GEMNASIUM_TESTSUITE); envTS != "" && len(testSuite) == 0 {
+		testSuite = strings.Fields(envTS)
+	}
+	if out, err := git("status", "--porcelain", "--untracked-files=no"); err != nil {
+		return err
+	} else if out != "" {
+		return errors.New(i18n.T("refresh.dirty_working_tree"))
+	}
+	if push {
+		if _, err := git("fetch", "--quiet", "origin"); err != nil {
+			return err
+		}
+	}
+	if base == "" {
+		head, err := git("symbolic-ref", "--short", "refs/remotes/origin/HEAD")
+		if err != nil {
+			return errors.New(i18n.T("refresh.unknown_base"))
+		}
+		base = head
+	}
+	current, err := currentGitRef()
+	if err != nil {
+		return err
+	}
+	branches, err := updateBranches()
+	if err != nil {
+		return err
+	}
+	if len(branches) == 0 {
+		fmt.Fprint(Output, i18n.T("refresh.no_branches", GIT_BRANCH_PREFIX))
+		return nil
+	}
+
+	failed := 0
+	for _, branch := range branches {
+		if err := refreshBranch(branch, base, testSuite, push); err != nil {
+			fmt.Fprint(Output, i18n.T("refresh.failed", branch, err))
+			failed++
+		}
+	}
+	if _, err := git("checkout", "--quiet", current); err != nil {
+		return err
+	}
+	if failed > 0 {
+		return errors.New(i18n.T("refresh.failed_count", failed, len(branches)))
+	}
+	return nil
+}
+
+// Names of the gemnasium/* branches, local or on origin
+func updateBranches() ([]string, error) {
+	out, err := git("for-each-ref", "--format=%(refname)", "refs/heads/"+GIT_BRANCH_PREFIX+"*", "refs/remotes/origin/"+GIT_BRANCH_PREFIX+"*")
+	if err != nil {
+		return nil, err
+	}
+	names := map[string]bool{}
+	for _, ref := range strings.Fields(out) {
+		names[strings.TrimPrefix(strings.TrimPrefix(ref, "refs/heads/"), "refs/remotes/origin/")] = true
+	}
+	branches := []string{}
+	for name := range names {
+		branches = append(branches, name)
+	}
+	sort.Strings(branches)
+	return branches, nil
+}
+
+func refreshBranch(branch, base string, testSuite []string, push bool) error {
+	// Branches only on origin are checked out from there
+	if _, err := git("rev-parse", "--verify", "--quiet", "refs/heads/"+branch); err != nil {
+		if _, err := git("branch", "--quiet", branch, "origin/"+branch); err != nil {
+			return err
+		}
+	}
+	if _, err := git("merge-base", "--is-ancestor", base, branch); err == nil {
+		fmt.Fprint(Output, i18n.T("refresh.up_to_date", branch, base))
+		return nil
+	}
+	if _, err := git("checkout", "--quiet", branch); err != nil {
+		return err
+	}
+	if _, err := git("rebase", "--quiet", base); err == nil {
+		fmt.Fprint(Output, i18n.T("refresh.rebased", branch, base))
+	} else {
+		git("rebase", "--abort")
+		if err := updateBranchAgain(branch, base, testSuite); err != nil {
+			return err
+		}
+		fmt.Fprint(Output, i18n.T("refresh.updated_again", branch, base))
+	}
+	if push {
+		_, err := git("push", "--quiet", "--force-with-lease", "origin", branch)
+		return err
+	}
+	return nil
+}
+
+// Reset the branch to base and update the versions of its commits again, in
+// a single commit. The branch is left as it was if the update or the test
+// suite fails.
+func updateBranchAgain(branch, base string, testSuite []string) error {
+	messages, err := git("log", "--format=%B", base+".."+branch)
+	if err != nil {
+		return err
+	}
+	files, err := git("diff", "--name-only", base+"..."+branch)
+	if err != nil {
+		return err
+	}
+	packageType, err := branchPackageType(strings.Fields(files))
+	if err != nil {
+		return err
+	}
+	updateSet := &UpdateSet{VersionUpdates: map[string][]VersionUpdate{}}
+	for _, m := range commitChangeRegexp.FindAllStringSubmatch(messages, -1) {
+		vu := VersionUpdate{Package: models.Package{Name: m[1], Type: packageType}, OldVersion: m[2], TargetVersion: m[3]}
+		updateSet.VersionUpdates[packageType] = append(updateSet.VersionUpdates[packageType], vu)
+	}
+	if len(updateSet.VersionUpdates) == 0 {
+		return errors.New(i18n.T("refresh.no_changes"))
+	}
+	// The latest update set of the branch
+	if m := commitUpdateSetRegexp.FindStringSubmatch(messages); m != nil {
+		updateSet.ID, _ = strconv.Atoi(m[1])
+	}
+
+	head, err := git("rev-parse", "HEAD")
+	if err != nil {
+		return err
+	}
+	if _, err := git("reset", "--quiet", "--hard", base); err != nil {
+		return err
+	}
+	_, uptDepFiles, err := applyUpdateSet(updateSet)
+	defer removeToolchain()
+	if err == nil && len(testSuite) > 0 {
+		var out []byte
+		if out, err = executeTestSuiteWithRetries(testSuite, config.TestRetries); err != nil {
+			err = errors.New(i18n.T("refresh.testsuite_failing", out))
+		}
+	}
+	metadata := newPatchMetadata(updateSet, uptDepFiles, nil)
+	if err == nil {
+		_, err = git(append([]string{"add", "--"}, metadata.Files...)...)
+	}
+	if err == nil {
+		_, err = git("commit", "--quiet", "-m", updateSetCommitMessage(metadata))
+	}
+	if err != nil {
+		git("reset", "--quiet", "--hard", head)
+	}
+	return err
+}
+
+// Package type of the dependency files changed on a branch, if they're all
+// of the same ecosystem
+func branchPackageType(files []string) (string, error) {
+	packageType := ""
+	for _, path := range files {
+		ecosystem := (&models.DependencyFile{Path: path}).Ecosystem()
+		fileType := ""
+		for t, e := range packageEcosystems {
+			if e == ecosystem {
+				fileType = t
+			}
+		}
+		if fileType == "" || (packageType != "" && fileType != packageType) {
+			return "", errors.New(i18n.T("refresh.unknown_package_type", strings.Join(files, ", ")))
+		}
+		packageType = fileType
+	}
+	if packageType == "" {
+		return "", errors.New(i18n.T("refresh.no_changes"))
+	}
+	return packageType, nil
+}
@@ -0,0 +1,123 @@
+package autoupdate
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/models"
+)
+
+func TestRefresh(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required")
+	}
+	dir, err := ioutil.TempDir("", "gemnasium-refresh")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for name, value := range map[string]string{"GIT_AUTHOR_NAME": "test", "GIT_AUTHOR_EMAIL": "test@example.com", "GIT_COMMITTER_NAME": "test", "GIT_COMMITTER_EMAIL": "test@example.com"} {
+		os.Setenv(name, value)
+		defer os.Unsetenv(name)
+	}
+	run := func(args ...string) string {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = filepath.Join(dir, "repo")
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %s\n%s", args, err, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	write := func(path, content string) {
+		if err := ioutil.WriteFile(filepath.Join(dir, "repo", path), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Two update branches from the first commit of main: one conflicting with
+	// the new Gemfile.lock of main, one changing another file
+	os.MkdirAll(filepath.Join(dir, "repo"), 0755)
+	run("init", "--quiet", "--bare", filepath.Join(dir, "origin.git"))
+	run("init", "--quiet")
+	run("checkout", "--quiet", "-b", "main")
+	run("remote", "add", "origin", filepath.Join(dir, "origin.git"))
+	write("Gemfile.lock", "rack (1.6.4)\nrails (4.2.0)\n")
+	write("package-lock.json", "lodash 4.17.4\n")
+	run("add", ".")
+	run("commit", "--quiet", "-m", "init")
+	run("checkout", "--quiet", "-b", "gemnasium/update-rails")
+	write("Gemfile.lock", "rack (1.6.4)\nrails (4.2.11)\n")
+	run("commit", "--quiet", "-am", "Update rails from 4.2.0 to 4.2.11\n\n- rails 4.2.0 => 4.2.11\n\nUpdate set 7, tested by gemnasium autoupdate.\n")
+	run("checkout", "--quiet", "-b", "gemnasium/update-lodash", "main")
+	write("package-lock.json", "lodash 4.17.21\n")
+	run("commit", "--quiet", "-am", "Update lodash from 4.17.4 to 4.17.21\n\n- lodash 4.17.4 => 4.17.21\n\nUpdate set 8, tested by gemnasium autoupdate.\n")
+	run("checkout", "--quiet", "main")
+	write("Gemfile.lock", "rack (1.6.12)\nrails (4.2.0)\n")
+	run("commit", "--quiet", "-am", "Update rack")
+	run("push", "--quiet", "origin", "main", "gemnasium/update-rails", "gemnasium/update-lodash")
+	run("remote", "set-head", "origin", "main")
+	run("branch", "-D", "gemnasium/update-lodash")
+
+	wd, _ := os.Getwd()
+	os.Chdir(filepath.Join(dir, "repo"))
+	defer os.Chdir(wd)
+	var out bytes.Buffer
+	Output = &out
+	defer func() { Output = os.Stdout }()
+
+	// Updates the versions of the current Gemfile.lock
+	updater := updaters["Rubygem"]
+	updaters["Rubygem"] = func(versionUpdates []VersionUpdate, orgDepFiles, uptDepFiles *[]models.DependencyFile) error {
+		org, err := models.NewDependencyFileE("Gemfile.lock")
+		if err != nil {
+			return err
+		}
+		*orgDepFiles = append(*orgDepFiles, *org)
+		content := string(org.Content)
+		for _, vu := range versionUpdates {
+			content = strings.Replace(content, vu.Package.Name+" ("+vu.OldVersion+")", vu.Package.Name+" ("+vu.TargetVersion+")", 1)
+		}
+		if err := ioutil.WriteFile("Gemfile.lock", []byte(content), 0644); err != nil {
+			return err
+		}
+		upt := *org
+		upt.Content = []byte(content)
+		*uptDepFiles = append(*uptDepFiles, upt)
+		return nil
+	}
+	defer func() { updaters["Rubygem"] = updater }()
+
+	if err := Refresh("", []string{"true"}, true); err != nil {
+		t.Fatalf("%s\n%s", err, out.String())
+	}
+	if branch := run("rev-parse", "--abbrev-ref", "HEAD"); branch != "main" {
+		t.Errorf("Expected main to be checked out again, got %s", branch)
+	}
+	if content := run("show", "origin/gemnasium/update-rails:Gemfile.lock"); content != "rack (1.6.12)\nrails (4.2.11)" {
+		t.Errorf("Expected rails to be updated again on top of main, got:\n%s", content)
+	}
+	if msg := run("log", "-1", "--format=%B", "origin/gemnasium/update-rails"); !strings.Contains(msg, "- rails 4.2.0 => 4.2.11") || !strings.Contains(msg, "Update set 7,") {
+		t.Errorf("Unexpected commit message: %s", msg)
+	}
+	if content := run("show", "origin/gemnasium/update-lodash:Gemfile.lock"); content != "rack (1.6.12)\nrails (4.2.0)" {
+		t.Errorf("Expected the lodash branch to be rebased onto main, got:\n%s", content)
+	}
+	if count := run("rev-list", "--count", "main..origin/gemnasium/update-lodash"); count != "1" {
+		t.Errorf("Expected the lodash branch to keep its commit, got %s commits", count)
+	}
+
+	// Nothing to do the second time
+	out.Reset()
+	if err := Refresh("", nil, true); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Count(out.String(), "is up to date with") != 2 {
+		t.Errorf("Expected both branches to be up to date, got:\n%s", out.String())
+	}
+}
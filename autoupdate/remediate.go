@@ -0,0 +1,152 @@
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/gemnasium/toolbelt/registry"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// Project label holding the URL of its repository (see Remediate)
+const REPOSITORY_LABEL = "repository"
+
+// Update the package of the advisory in each affected project (see
+// models.FindAffectedProjects) to the lowest cured release above its locked
+// version, and open a pull request for it. Projects are cloned from the URL
+// of their repository label, or from cloneURL where {slug} and {name} are
+// replaced. Only the plan is printed unless openPRs is set.
+// Projects are remediated one at a time: the updaters run in the current
+// directory. The test suite isn't run, the CI of the pull request does.
+func Remediate(affected []models.AffectedProject, cloneURL string, openPRs bool) error {
+	failed := 0
+	for _, a := range affected {
+		url := repositoryURL(a.Project, cloneURL)
+		if !openPRs {
+			fmt.Fprint(Output, i18n.T("org.remediate_plan", a.Project.Slug, a.Advisory.Package.Name, a.Advisory.Package.Type, a.Advisory.CuredVersions, url))
+			continue
+		}
+		pr, err := remediateProject(a, url)
+		if err != nil {
+			fmt.Fprint(Output, i18n.T("org.remediate_failed", a.Project.Slug, err))
+			failed++
+			continue
+		}
+		fmt.Fprint(Output, i18n.T("org.remediate_opened", a.Project.Slug, pr.HTMLURL))
+	}
+	if !openPRs && len(affected) > 0 {
+		fmt.Fprint(Output, i18n.T("org.remediate_open_prs_hint"))
+	}
+	if failed > 0 {
+		return errors.New(i18n.T("org.remediate_failed_count", failed, len(affected)))
+	}
+	return nil
+}
+
+// URL of the repository of the project, empty if it's unknown
+func repositoryURL(project models.Project, cloneURL string) string {
+	if url := project.Labels[REPOSITORY_LABEL]; url != "" {
+		return url
+	}
+	return strings.NewReplacer("{slug}", project.Slug, "{name}", project.Name).Replace(cloneURL)
+}
+
+func remediateProject(a models.AffectedProject, url string) (*pullRequest, error) {
+	if url == "" {
+		return nil, errors.New(i18n.T("org.remediate_no_repository", REPOSITORY_LABEL))
+	}
+	dir, err := ioutil.TempDir("", "gemnasium-remediate")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if _, err := git("clone", "--quiet", "--depth", "1", url, dir); err != nil {
+		return nil, err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return nil, err
+	}
+	if err := os.Chdir(dir); err != nil {
+		return nil, err
+	}
+	defer os.Chdir(wd)
+
+	prs, err := newPullRequests()
+	if err != nil {
+		return nil, err
+	}
+	// The test suite isn't run: the status is left to the CI of the project
+	prs.status = false
+
+	pkg := a.Advisory.Package
+	read, ok := lockedVersionReaders[pkg.Type]
+	if !ok {
+		return nil, errors.New(i18n.T("org.remediate_no_reader", pkg.Type))
+	}
+	locked, err := read(pkg.Name)
+	if err != nil {
+		return nil, err
+	}
+	if locked == "" {
+		return nil, errors.New(i18n.T("org.remediate_not_locked", pkg.Name))
+	}
+	target, err := curedVersion(a.Advisory, locked)
+	if err != nil {
+		return nil, err
+	}
+
+	updateSet := &UpdateSet{VersionUpdates: map[string][]VersionUpdate{
+		pkg.Type: {{Package: pkg, OldVersion: locked, TargetVersion: target}},
+	}}
+	_, uptDepFiles, err := applyUpdateSet(updateSet)
+	defer removeToolchain()
+	if err != nil {
+		return nil, err
+	}
+	alerts := []models.Alert{{Advisory: a.Advisory, Status: "open"}}
+	metadata := newPatchMetadata(updateSet, uptDepFiles, alerts)
+	branch, err := commitUpdateSet(metadata, updateSetBranch(metadata), true)
+	if err != nil {
+		return nil, err
+	}
+	return prs.publish(branch, metadata)
+}
+
+// Lowest release of the package of the advisory above locked that is cured.
+// Yanked releases and prereleases are skipped.
+func curedVersion(advisory models.Advisory, locked string) (string, error) {
+	pkg := advisory.Package
+	if advisory.CuredVersions == "" {
+		return "", errors.New(i18n.T("org.remediate_no_cured", pkg.Name))
+	}
+	versions, err := registry.Versions(pkg.Type, pkg.Name)
+	if err != nil {
+		return "", err
+	}
+	target := ""
+	for _, v := range versions {
+		if v.Yanked || v.Prerelease || utils.CompareVersions(v.Number, locked) <= 0 {
+			continue
+		}
+		if target != "" && utils.CompareVersions(v.Number, target) >= 0 {
+			continue
+		}
+		cured, err := satisfiesRequirement(pkg.Type, v.Number, advisory.CuredVersions)
+		if err != nil {
+			return "", err
+		}
+		if cured {
+			target = v.Number
+		}
+	}
+	if target == "" {
+		return "", errors.New(i18n.T("org.remediate_no_fix", pkg.Name, locked, advisory.CuredVersions))
+	}
+	return target, nil
+}
@@ -0,0 +1,75 @@
+package autoupdate
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/gemnasium/toolbelt/registry"
+)
+
+func TestCuredVersion(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprint(w, `[
+			{"number": "4.1.0.rc1", "prerelease": true},
+			{"number": "4.0.5"},
+			{"number": "4.0.4"},
+			{"number": "4.0.3"},
+			{"number": "4.0.2"}
+		]`)
+	}))
+	defer ts.Close()
+	registry.RubygemsURL = ts.URL
+	config.CacheDir = ""
+
+	advisory := models.Advisory{Package: models.Package{Name: "rails", Type: "Rubygem"}, CuredVersions: ">= 4.0.4"}
+	for locked, expected := range map[string]string{"4.0.2": "4.0.4", "4.0.4": "4.0.5"} {
+		if target, err := curedVersion(advisory, locked); err != nil || target != expected {
+			t.Errorf("Expected %s to be updated to %s, got %q (%v)", locked, expected, target, err)
+		}
+	}
+	if _, err := curedVersion(advisory, "4.0.5"); err == nil {
+		t.Error("Expected an error without a cured release above 4.0.5")
+	}
+	advisory.CuredVersions = ""
+	if _, err := curedVersion(advisory, "4.0.2"); err == nil {
+		t.Error("Expected an error without cured versions")
+	}
+}
+
+func TestRemediatePlan(t *testing.T) {
+	var out bytes.Buffer
+	Output = &out
+	defer func() { Output = os.Stdout }()
+
+	advisory := models.Advisory{Identifier: "CVE-2021-23337", CuredVersions: ">=4.17.21", Package: models.Package{Name: "lodash", Type: "Npm"}}
+	affected := []models.AffectedProject{
+		{Project: models.Project{Slug: "shop", Name: "shop", Labels: map[string]string{"repository": "git@example.com:acme/shop-web.git"}}, Advisory: advisory},
+		{Project: models.Project{Slug: "api", Name: "api"}, Advisory: advisory},
+	}
+	if err := Remediate(affected, "git@example.com:acme/{name}.git", false); err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"shop: lodash (Npm), cured versions: >=4.17.21, repository: git@example.com:acme/shop-web.git\n",
+		"api: lodash (Npm), cured versions: >=4.17.21, repository: git@example.com:acme/api.git\n",
+		"--open-prs",
+	} {
+		if !strings.Contains(out.String(), line) {
+			t.Errorf("Expected the plan to contain %q, got:\n%s", line, out.String())
+		}
+	}
+
+	// Projects without a repository aren't cloned
+	out.Reset()
+	err := Remediate(affected[1:], "", true)
+	if err == nil || !strings.Contains(out.String(), "api: Unknown repository") {
+		t.Errorf("Expected api to fail without a repository, got %v:\n%s", err, out.String())
+	}
+}
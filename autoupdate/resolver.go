@@ -0,0 +1,222 @@
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/registry"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// Check that the target versions of the update set can be resolved together,
+// using the requirements published on the registries, before spending minutes
+// in the package manager.
+// Only dependencies pinned by the update set or locked by the project are
+// checked: anything else is left to the package manager. Requirements that
+// can't be parsed (git urls, dist tags, ...) are ignored as well.
+func simulateUpdateSet(updateSet *UpdateSet) error {
+	for packageType, versionUpdates := range updateSet.VersionUpdates {
+		pinned := map[string]string{}
+		for _, vu := range versionUpdates {
+			pinned[vu.Package.Name] = vu.TargetVersion
+		}
+		locked := lockedVersionReaders[packageType]
+
+		for _, vu := range versionUpdates {
+			deps, err := registry.Dependencies(packageType, vu.Package.Name, vu.TargetVersion)
+			if err == registry.ErrDependenciesUnsupported {
+				break
+			}
+			if err != nil {
+				return err
+			}
+			for name, requirement := range deps {
+				version, ok := pinned[name]
+				if !ok && locked != nil {
+					version, _ = locked(name)
+				}
+				if version == "" {
+					continue
+				}
+				ok, err := satisfiesRequirement(packageType, version, requirement)
+				if err == nil && !ok {
+					return errors.New(i18n.T("autoupdate.unresolvable", vu.Package.Name, vu.TargetVersion, name, requirement, version))
+				}
+			}
+		}
+	}
+	return nil
+}
+
+// A single version comparison, like ">= 1.2.3"
+type versionConstraint struct {
+	op      string
+	version string
+}
+
+func (c versionConstraint) match(version string) bool {
+	cmp := utils.CompareVersions(version, c.version)
+	switch c.op {
+	case "=":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	}
+	return false
+}
+
+// Return true if version matches the requirement, using the syntax of the
+// package manager of packageType.
+func satisfiesRequirement(packageType, version, requirement string) (bool, error) {
+	var alternatives [][]versionConstraint
+	var err error
+	switch strings.ToLower(packageType) {
+	case "rubygem":
+		var constraints []versionConstraint
+		constraints, err = parseGemRequirement(requirement)
+		alternatives = [][]versionConstraint{constraints}
+	case "npm":
+		alternatives, err = parseNpmRange(requirement)
+	default:
+		return false, fmt.Errorf("Unsupported package type: %s", packageType)
+	}
+	if err != nil {
+		return false, err
+	}
+
+	for _, constraints := range alternatives {
+		ok := true
+		for _, c := range constraints {
+			if !c.match(version) {
+				ok = false
+				break
+			}
+		}
+		if ok {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// Parse gem requirements like ">= 4.0, < 5" or "~> 1.2.3"
+// http://guides.rubygems.org/patterns/#declaring-dependencies
+func parseGemRequirement(requirement string) ([]versionConstraint, error) {
+	constraints := []versionConstraint{}
+	for _, part := range strings.Split(requirement, ",") {
+		part = strings.TrimSpace(part)
+		op := "="
+		for _, o := range []string{"~>", ">=", "<=", "!=", "=", ">", "<"} {
+			if strings.HasPrefix(part, o) {
+				op = o
+				part = strings.TrimSpace(part[len(o):])
+				break
+			}
+		}
+		if !isVersionNumber(part) {
+			return nil, fmt.Errorf("Invalid requirement: %s", requirement)
+		}
+		if op == "~>" {
+			// "~> 1.2.3" means ">= 1.2.3, < 1.3"
+			segments := strings.Split(part, ".")
+			if len(segments) > 1 {
+				segments = segments[:len(segments)-1]
+			}
+			constraints = append(constraints, versionConstraint{">=", part}, versionConstraint{"<", bumpVersion(segments)})
+			continue
+		}
+		constraints = append(constraints, versionConstraint{op, part})
+	}
+	return constraints, nil
+}
+
+// Parse npm semver ranges like "^1.2.3", "~1.2 || >=2.0.0 <3" or "1.x"
+// https://docs.npmjs.com/cli/v6/using-npm/semver#ranges
+func parseNpmRange(requirement string) ([][]versionConstraint, error) {
+	alternatives := [][]versionConstraint{}
+	for _, set := range strings.Split(requirement, "||") {
+		fields := strings.Fields(set)
+		// Hyphen ranges: "1.2.3 - 2.3.4"
+		if len(fields) == 3 && fields[1] == "-" {
+			if !isVersionNumber(fields[0]) || !isVersionNumber(fields[2]) {
+				return nil, fmt.Errorf("Invalid range: %s", requirement)
+			}
+			alternatives = append(alternatives, []versionConstraint{{">=", fields[0]}, {"<=", fields[2]}})
+			continue
+		}
+
+		constraints := []versionConstraint{}
+		for _, field := range fields {
+			op := ""
+			for _, o := range []string{">=", "<=", ">", "<", "=", "^", "~"} {
+				if strings.HasPrefix(field, o) {
+					op = o
+					field = strings.TrimPrefix(field[len(o):], "v")
+					break
+				}
+			}
+			if field == "*" || field == "x" || field == "X" {
+				continue
+			}
+			segments := strings.Split(field, ".")
+			for i, s := range segments {
+				if s == "x" || s == "X" || s == "*" {
+					segments = segments[:i]
+					break
+				}
+			}
+			version := strings.Join(segments, ".")
+			if !isVersionNumber(version) {
+				return nil, fmt.Errorf("Invalid range: %s", requirement)
+			}
+			partial := len(segments) < 3
+
+			switch {
+			case op == "^":
+				// Bump the first non-zero segment
+				i := 0
+				for i < len(segments)-1 && segments[i] == "0" {
+					i++
+				}
+				constraints = append(constraints, versionConstraint{">=", version}, versionConstraint{"<", bumpVersion(segments[:i+1])})
+			case op == "~" || (op == "" || op == "=") && partial:
+				upper := segments
+				if op == "~" && len(segments) > 1 {
+					upper = segments[:2]
+				}
+				constraints = append(constraints, versionConstraint{">=", version}, versionConstraint{"<", bumpVersion(upper)})
+			case op == "":
+				constraints = append(constraints, versionConstraint{"=", version})
+			default:
+				constraints = append(constraints, versionConstraint{op, version})
+			}
+		}
+		alternatives = append(alternatives, constraints)
+	}
+	return alternatives, nil
+}
+
+// Increment the last segment: ["1", "2"] => "1.3"
+func bumpVersion(segments []string) string {
+	bumped := make([]string, len(segments))
+	copy(bumped, segments)
+	last, _ := strconv.Atoi(bumped[len(bumped)-1])
+	bumped[len(bumped)-1] = strconv.Itoa(last + 1)
+	return strings.Join(bumped, ".")
+}
+
+// Return true if s starts with a digit, like "1.2.3" or "4.0.0.beta1"
+func isVersionNumber(s string) bool {
+	return s != "" && s[0] >= '0' && s[0] <= '9'
+}
@@ -0,0 +1,87 @@
+package autoupdate
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/gemnasium/toolbelt/registry"
+)
+
+func TestSatisfiesRequirement(t *testing.T) {
+	tests := []struct {
+		packageType, version, requirement string
+		expected                          bool
+	}{
+		{"Rubygem", "4.0.3", "= 4.0.3", true},
+		{"Rubygem", "4.0.4", "4.0.3", false},
+		{"Rubygem", "1.2.9", "~> 1.2.3", true},
+		{"Rubygem", "1.3.0", "~> 1.2.3", false},
+		{"Rubygem", "1.9", "~> 1.2", true},
+		{"Rubygem", "2.0", "~> 1.2", false},
+		{"Rubygem", "4.1.0", ">= 4.0, < 5", true},
+		{"Rubygem", "5.0.0", ">= 4.0, < 5", false},
+		{"npm", "1.9.0", "^1.2.3", true},
+		{"npm", "2.0.0", "^1.2.3", false},
+		{"npm", "0.2.9", "^0.2.3", true},
+		{"npm", "0.3.0", "^0.2.3", false},
+		{"npm", "1.2.9", "~1.2.3", true},
+		{"npm", "1.3.0", "~1.2.3", false},
+		{"npm", "1.2.5", "1.2.x", true},
+		{"npm", "1.3.0", "1.2", false},
+		{"npm", "3.1.0", "^1.0.0 || >=3.0.0 <4", true},
+		{"npm", "2.1.0", "^1.0.0 || >=3.0.0 <4", false},
+		{"npm", "2.0.0", "1.0.0 - 2.0.0", true},
+		{"npm", "7.0.0", "*", true},
+		{"npm", "7.0.0", "", true},
+	}
+	for _, test := range tests {
+		ok, err := satisfiesRequirement(test.packageType, test.version, test.requirement)
+		if err != nil {
+			t.Errorf("%s %s: %s", test.version, test.requirement, err)
+			continue
+		}
+		if ok != test.expected {
+			t.Errorf("Expected %s matching '%s' to be %v", test.version, test.requirement, test.expected)
+		}
+	}
+
+	if _, err := satisfiesRequirement("npm", "1.0.0", "github:user/repo"); err == nil {
+		t.Error("Git urls can't be resolved and should return an error")
+	}
+}
+
+func TestSimulateUpdateSet(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/express/4.14.0":
+			fmt.Fprintln(w, `{"dependencies": {"debug": "~2.2.0", "qs": "6.2.0"}}`)
+		default:
+			fmt.Fprintln(w, `{}`)
+		}
+	}))
+	defer ts.Close()
+	registry.NpmURL = ts.URL
+	config.CacheDir = ""
+
+	newUpdateSet := func(debugVersion string) *UpdateSet {
+		return &UpdateSet{
+			ID: 1,
+			VersionUpdates: map[string][]VersionUpdate{
+				"npm": []VersionUpdate{
+					VersionUpdate{Package: models.Package{Name: "express"}, OldVersion: "4.13.0", TargetVersion: "4.14.0"},
+					VersionUpdate{Package: models.Package{Name: "debug"}, OldVersion: "2.1.0", TargetVersion: debugVersion},
+				},
+			},
+		}
+	}
+	if err := simulateUpdateSet(newUpdateSet("2.2.0")); err != nil {
+		t.Errorf("Update set should be resolvable, got: %s", err)
+	}
+	if err := simulateUpdateSet(newUpdateSet("2.6.0")); err == nil {
+		t.Error("express 4.14.0 requires debug ~2.2.0, update set should not be resolvable")
+	}
+}
@@ -0,0 +1,289 @@
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+)
+
+const (
+	SERVICE_NAME         = "gemnasium-worker"
+	LAUNCHD_LABEL        = "com.gemnasium.worker"
+	DEFAULT_SERVICE_USER = "gemnasium"
+
+	SERVICE_SYSTEMD = "systemd"
+	SERVICE_LAUNCHD = "launchd"
+)
+
+var (
+	SystemdUnitDir = "/etc/systemd/system"
+	LaunchdDir     = "/Library/LaunchDaemons"
+	// Where the environment of the worker is written (it holds the API
+	// token, so it's only readable by root)
+	ServiceEnvDir = "/etc/gemnasium"
+
+	// Run the commands managing the service (replaced in tests)
+	runServiceCommand = func(name string, args ...string) error {
+		out, err := exec.Command(name, args...).CombinedOutput()
+		if err != nil {
+			return fmt.Errorf("%s %s: %s\n%s", name, strings.Join(args, " "), err, out)
+		}
+		return nil
+	}
+)
+
+// Service running "gemnasium autoupdate worker" under a dedicated user
+type Service struct {
+	// systemd or launchd (see DefaultServiceManager)
+	Manager    string
+	User       string
+	WorkingDir string
+	// Toolbelt executable, and the args of the worker command
+	Executable string
+	Args       []string
+	// Env vars of the worker, written to the environment file of the service
+	Env map[string]string
+}
+
+// Service manager of the platform, if it's supported
+func DefaultServiceManager() string {
+	switch runtime.GOOS {
+	case "linux":
+		return SERVICE_SYSTEMD
+	case "darwin":
+		return SERVICE_LAUNCHD
+	}
+	return runtime.GOOS
+}
+
+// Env vars the worker needs: the GEMNASIUM_* and AWS_* vars set in the
+// current environment (see config.DisplayEnvVars), and the API token
+// the toolbelt is logged in with.
+func ServiceEnv() map[string]string {
+	env := map[string]string{}
+	for _, kv := range os.Environ() {
+		if strings.HasPrefix(kv, "GEMNASIUM_") || strings.HasPrefix(kv, "AWS_") {
+			parts := strings.SplitN(kv, "=", 2)
+			env[parts[0]] = parts[1]
+		}
+	}
+	if config.APIKey != "" {
+		env[config.ENV_TOKEN] = config.APIKey
+	}
+	return env
+}
+
+func (s *Service) command() string {
+	return strings.Join(append([]string{s.Executable, "autoupdate", "worker"}, s.Args...), " ")
+}
+
+func (s *Service) envNames() []string {
+	names := []string{}
+	for name := range s.Env {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Hardened unit: the worker can only write to its working directory, its
+// home (/var/lib/gemnasium-worker, where package managers install things)
+// and a private /tmp, where jobs are cloned.
+var systemdUnit = template.Must(template.New("unit").Parse(`[Unit]
+Description=Gemnasium autoupdate worker
+Documentation=https://github.com/gemnasium/toolbelt
+Wants=network-online.target
+After=network-online.target
+
+[Service]
+Type=simple
+User={{.User}}
+WorkingDirectory={{.WorkingDir}}
+EnvironmentFile={{.EnvFile}}
+Environment=HOME=/var/lib/{{.Name}}
+ExecStart={{.Command}}
+Restart=on-failure
+RestartSec=30s
+StateDirectory={{.Name}}
+ReadWritePaths={{.WorkingDir}}
+NoNewPrivileges=yes
+PrivateTmp=yes
+PrivateDevices=yes
+ProtectSystem=strict
+ProtectHome=read-only
+ProtectKernelTunables=yes
+ProtectKernelModules=yes
+ProtectControlGroups=yes
+RestrictSUIDSGID=yes
+LockPersonality=yes
+
+[Install]
+WantedBy=multi-user.target
+`))
+
+// launchd has no environment file: env vars are part of the plist
+var launchdPlist = template.Must(template.New("plist").Parse(`<?xml version="1.0" encoding="UTF-8"?>
+<!DOCTYPE plist PUBLIC "-//Apple//DTD PLIST 1.0//EN" "http://www.apple.com/DTDs/PropertyList-1.0.dtd">
+<plist version="1.0">
+<dict>
+	<key>Label</key>
+	<string>{{.Label}}</string>
+	<key>UserName</key>
+	<string>{{.User}}</string>
+	<key>WorkingDirectory</key>
+	<string>{{.WorkingDir}}</string>
+	<key>ProgramArguments</key>
+	<array>
+{{- range .Args}}
+		<string>{{.}}</string>
+{{- end}}
+	</array>
+	<key>EnvironmentVariables</key>
+	<dict>
+{{- range .Env}}
+		<key>{{.Name}}</key>
+		<string>{{.Value}}</string>
+{{- end}}
+	</dict>
+	<key>RunAtLoad</key>
+	<true/>
+	<key>KeepAlive</key>
+	<true/>
+	<key>StandardOutPath</key>
+	<string>/usr/local/var/log/{{.Name}}.log</string>
+	<key>StandardErrorPath</key>
+	<string>/usr/local/var/log/{{.Name}}.log</string>
+</dict>
+</plist>
+`))
+
+// Path of the file describing the service: the unit or the plist
+func (s *Service) Path() string {
+	if s.Manager == SERVICE_LAUNCHD {
+		return filepath.Join(LaunchdDir, LAUNCHD_LABEL+".plist")
+	}
+	return filepath.Join(SystemdUnitDir, SERVICE_NAME+".service")
+}
+
+func (s *Service) envFile() string {
+	return filepath.Join(ServiceEnvDir, SERVICE_NAME+".env")
+}
+
+// Content of the unit or plist of the service
+func (s *Service) Render() (string, error) {
+	var b strings.Builder
+	switch s.Manager {
+	case SERVICE_SYSTEMD:
+		err := systemdUnit.Execute(&b, map[string]string{
+			"Name":       SERVICE_NAME,
+			"User":       s.User,
+			"WorkingDir": s.WorkingDir,
+			"EnvFile":    s.envFile(),
+			"Command":    s.command(),
+		})
+		return b.String(), err
+	case SERVICE_LAUNCHD:
+		type envVar struct{ Name, Value string }
+		env := []envVar{}
+		for _, name := range s.envNames() {
+			env = append(env, envVar{name, s.Env[name]})
+		}
+		err := launchdPlist.Execute(&b, map[string]interface{}{
+			"Label":      LAUNCHD_LABEL,
+			"Name":       SERVICE_NAME,
+			"User":       s.User,
+			"WorkingDir": s.WorkingDir,
+			"Args":       append([]string{s.Executable, "autoupdate", "worker"}, s.Args...),
+			"Env":        env,
+		})
+		return b.String(), err
+	}
+	return "", errors.New(i18n.T("worker.service_unsupported", s.Manager))
+}
+
+// Write the service files, create its user if needed (systemd only), and
+// start it
+func (s *Service) Install() error {
+	content, err := s.Render()
+	if err != nil {
+		return err
+	}
+	if err := runServiceCommand("id", "-u", s.User); err != nil {
+		if s.Manager != SERVICE_SYSTEMD {
+			return errors.New(i18n.T("worker.service_user_missing", s.User))
+		}
+		err = runServiceCommand("useradd", "--system", "--no-create-home", "--home-dir", "/var/lib/"+SERVICE_NAME, "--shell", "/usr/sbin/nologin", s.User)
+		if err != nil {
+			return err
+		}
+	}
+	if s.Manager == SERVICE_SYSTEMD {
+		var env strings.Builder
+		for _, name := range s.envNames() {
+			fmt.Fprintf(&env, "%s=%s\n", name, s.Env[name])
+		}
+		if err := os.MkdirAll(ServiceEnvDir, 0755); err != nil {
+			return err
+		}
+		if err := ioutil.WriteFile(s.envFile(), []byte(env.String()), 0600); err != nil {
+			return err
+		}
+	}
+	// The plist holds the env vars, token included
+	if err := ioutil.WriteFile(s.Path(), []byte(content), 0600); err != nil {
+		return err
+	}
+
+	if s.Manager == SERVICE_LAUNCHD {
+		err = runServiceCommand("launchctl", "load", "-w", s.Path())
+	} else if err = runServiceCommand("systemctl", "daemon-reload"); err == nil {
+		err = runServiceCommand("systemctl", "enable", "--now", SERVICE_NAME)
+	}
+	if err != nil {
+		return err
+	}
+	if s.Manager == SERVICE_LAUNCHD {
+		fmt.Fprint(Output, i18n.T("worker.service_installed", s.Path()))
+	} else {
+		fmt.Fprint(Output, i18n.T("worker.service_installed_with_env", s.Path(), s.envFile()))
+	}
+	return nil
+}
+
+// Stop the service and remove its files. Its user is kept.
+func (s *Service) Uninstall() error {
+	var err error
+	switch s.Manager {
+	case SERVICE_SYSTEMD:
+		err = runServiceCommand("systemctl", "disable", "--now", SERVICE_NAME)
+	case SERVICE_LAUNCHD:
+		err = runServiceCommand("launchctl", "unload", "-w", s.Path())
+	default:
+		return errors.New(i18n.T("worker.service_unsupported", s.Manager))
+	}
+	if err != nil {
+		return err
+	}
+	for _, path := range []string{s.Path(), s.envFile()} {
+		if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	if s.Manager == SERVICE_SYSTEMD {
+		if err := runServiceCommand("systemctl", "daemon-reload"); err != nil {
+			return err
+		}
+	}
+	fmt.Fprint(Output, i18n.T("worker.service_uninstalled", s.Path()))
+	return nil
+}
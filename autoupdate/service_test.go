@@ -0,0 +1,126 @@
+package autoupdate
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestServiceRender(t *testing.T) {
+	service := &Service{
+		Manager:    SERVICE_SYSTEMD,
+		User:       "gemnasium",
+		WorkingDir: "/srv/app",
+		Executable: "/usr/local/bin/gemnasium",
+		Args:       []string{"--queue", "api"},
+		Env:        map[string]string{"GEMNASIUM_TOKEN": "s3cret"},
+	}
+	unit, err := service.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{
+		"User=gemnasium",
+		"WorkingDirectory=/srv/app",
+		"EnvironmentFile=/etc/gemnasium/gemnasium-worker.env",
+		"ExecStart=/usr/local/bin/gemnasium autoupdate worker --queue api",
+		"ReadWritePaths=/srv/app",
+		"NoNewPrivileges=yes",
+	} {
+		if !strings.Contains(unit, line+"\n") {
+			t.Errorf("Expected the unit to contain %q, got:\n%s", line, unit)
+		}
+	}
+	if strings.Contains(unit, "s3cret") {
+		t.Errorf("Expected the token to be in the environment file only, got:\n%s", unit)
+	}
+
+	service.Manager = SERVICE_LAUNCHD
+	plist, err := service.Render()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, line := range []string{"<string>autoupdate</string>", "<key>GEMNASIUM_TOKEN</key>", "<string>s3cret</string>"} {
+		if !strings.Contains(plist, line) {
+			t.Errorf("Expected the plist to contain %q, got:\n%s", line, plist)
+		}
+	}
+
+	service.Manager = "windows"
+	if _, err := service.Render(); err == nil {
+		t.Error("Expected an error with an unsupported service manager")
+	}
+}
+
+func TestServiceInstallAndUninstall(t *testing.T) {
+	dir, err := ioutil.TempDir("", "service")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	unitDir, envDir := SystemdUnitDir, ServiceEnvDir
+	SystemdUnitDir, ServiceEnvDir = dir, filepath.Join(dir, "gemnasium")
+	defer func() { SystemdUnitDir, ServiceEnvDir = unitDir, envDir }()
+	var out bytes.Buffer
+	Output = &out
+	defer func() { Output = os.Stdout }()
+
+	commands := []string{}
+	run := runServiceCommand
+	runServiceCommand = func(name string, args ...string) error {
+		command := strings.Join(append([]string{name}, args...), " ")
+		commands = append(commands, command)
+		if command == "id -u gemnasium" {
+			return errors.New("no such user")
+		}
+		return nil
+	}
+	defer func() { runServiceCommand = run }()
+
+	service := &Service{Manager: SERVICE_SYSTEMD, User: "gemnasium", WorkingDir: dir, Executable: "gemnasium", Env: map[string]string{"GEMNASIUM_TOKEN": "s3cret"}}
+	if err := service.Install(); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{
+		"id -u gemnasium",
+		"useradd --system --no-create-home --home-dir /var/lib/gemnasium-worker --shell /usr/sbin/nologin gemnasium",
+		"systemctl daemon-reload",
+		"systemctl enable --now gemnasium-worker",
+	}
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("Expected commands %v, got %v", expected, commands)
+	}
+	env := filepath.Join(dir, "gemnasium", "gemnasium-worker.env")
+	info, err := os.Stat(env)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.Mode().Perm() != 0600 {
+		t.Errorf("Expected the environment file to be readable by root only, got %s", info.Mode())
+	}
+	if content, _ := ioutil.ReadFile(env); string(content) != "GEMNASIUM_TOKEN=s3cret\n" {
+		t.Errorf("Unexpected environment file: %s", content)
+	}
+	if _, err := os.Stat(filepath.Join(dir, "gemnasium-worker.service")); err != nil {
+		t.Error(err)
+	}
+
+	commands = []string{}
+	if err := service.Uninstall(); err != nil {
+		t.Fatal(err)
+	}
+	expected = []string{"systemctl disable --now gemnasium-worker", "systemctl daemon-reload"}
+	if !reflect.DeepEqual(commands, expected) {
+		t.Errorf("Expected commands %v, got %v", expected, commands)
+	}
+	for _, path := range []string{env, filepath.Join(dir, "gemnasium-worker.service")} {
+		if _, err := os.Stat(path); !os.IsNotExist(err) {
+			t.Errorf("Expected %s to be removed", path)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package autoupdate
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/i18n"
+)
+
+const (
+	GEM_INSTALL_CMD = "gem install"
+	NPM_UPGRADE_CMD = "npm install -g"
+)
+
+// Package managers upgraded by the current update set are installed in this
+// directory rather than globally: only the update and test commands use them
+// (see commandEnv), and they're removed with removeToolchain when the
+// dependency files are restored.
+var toolchainDir string
+
+// Directory of the toolchain of the current update set, created if needed
+func toolchainPrefix() (string, error) {
+	if toolchainDir == "" {
+		dir, err := ioutil.TempDir("", "gemnasium-toolchain")
+		if err != nil {
+			return "", err
+		}
+		toolchainDir = dir
+	}
+	return toolchainDir, nil
+}
+
+// Remove the toolchain of the current update set: the next commands use the
+// package managers of the system again
+func removeToolchain() {
+	if toolchainDir != "" {
+		os.RemoveAll(toolchainDir)
+		toolchainDir = ""
+	}
+}
+
+// Remove the update of the package manager itself (ie: "bundler") from the
+// version updates, and return it separately, as it's not installed like the
+// other packages.
+func splitToolchainUpdate(name string, versionUpdates []VersionUpdate) (*VersionUpdate, []VersionUpdate) {
+	var toolchain *VersionUpdate
+	others := []VersionUpdate{}
+	for i, vu := range versionUpdates {
+		if vu.Package.Name == name {
+			toolchain = &versionUpdates[i]
+			continue
+		}
+		others = append(others, vu)
+	}
+	return toolchain, others
+}
+
+// Run a command, printing its output on failure
+func runToolchainCommand(parts []string) error {
+	fmt.Fprint(Output, i18n.T("autoupdate.executing_update_command", strings.Join(parts, " ")))
+	out, err := command(parts[0], parts[1:]...).CombinedOutput()
+	if err != nil {
+		fmt.Fprintf(Output, "%s\n", out)
+	}
+	return err
+}
+
+// Install the target version of bundler (with GEMNASIUM_GEM_INSTALL_CMD) in
+// the toolchain of the update set, and record it in the BUNDLED WITH section
+// of Gemfile.lock with "bundle update --bundler=<version>"
+func upgradeBundler(vu VersionUpdate) error {
+	fmt.Fprint(Output, i18n.T("autoupdate.upgrading_toolchain", "bundler", vu.OldVersion, vu.TargetVersion))
+	prefix, err := toolchainPrefix()
+	if err != nil {
+		return err
+	}
+	commands := upgradeBundlerCommands(vu, prefix)
+	if err := runToolchainCommand(commands[0]); err != nil {
+		// The version can't be installed, the update set is invalid
+		return cantUpdateVersions
+	}
+	return runToolchainCommand(commands[1])
+}
+
+// Install the target version of npm (or GEMNASIUM_NPM_UPGRADE_CMD) in the
+// toolchain of the update set, and update the "engines" requirement of
+// package.json if it doesn't allow this version anymore.
+func upgradeNpm(vu VersionUpdate) error {
+	fmt.Fprint(Output, i18n.T("autoupdate.upgrading_toolchain", "npm", vu.OldVersion, vu.TargetVersion))
+	prefix, err := toolchainPrefix()
+	if err != nil {
+		return err
+	}
+	if err := runToolchainCommand(upgradeNpmCommand(vu, prefix)); err != nil {
+		return cantUpdateVersions
+	}
+
+	content, err := ioutil.ReadFile("package.json")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile("package.json", bumpEngine(content, "npm", vu.TargetVersion), 0644)
+}
+
+// Return true if the package is a dependency of package.json, and not only
+// the package manager required in "engines"
+func isNpmDependency(name string) bool {
+	content, err := ioutil.ReadFile("package.json")
+	if err != nil {
+		return false
+	}
+	var pkg map[string]map[string]interface{}
+	if json.Unmarshal(content, &pkg) != nil {
+		return false
+	}
+	for _, section := range []string{"dependencies", "devDependencies", "optionalDependencies", "peerDependencies"} {
+		if _, ok := pkg[section][name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+var (
+	enginesSection = regexp.MustCompile(`"engines"\s*:\s*\{[^}]*\}`)
+	simpleRange    = regexp.MustCompile(`^(\^|~|>=)?\s*\d`)
+)
+
+// Update the requirement of engine in the "engines" section of package.json,
+// when it isn't satisfied by version. The file is edited in place, to keep its
+// formatting. Simple ranges keep their operator ("^6.0.0" => "^7.1.0"), other
+// ones are replaced with ">=<version>".
+func bumpEngine(content []byte, engine, version string) []byte {
+	section := enginesSection.Find(content)
+	if section == nil {
+		return content
+	}
+	requirement := regexp.MustCompile(`("` + regexp.QuoteMeta(engine) + `"\s*:\s*")([^"]*)(")`)
+	m := requirement.FindSubmatch(section)
+	if m == nil {
+		return content
+	}
+	if ok, err := satisfiesRequirement("Npm", version, string(m[2])); err == nil && ok {
+		return content
+	}
+
+	operator := ">="
+	if sm := simpleRange.FindStringSubmatch(string(m[2])); sm != nil && !strings.ContainsAny(string(m[2]), " |<") {
+		operator = sm[1]
+	}
+	bumped := requirement.ReplaceAll(section, []byte("${1}"+operator+version+"${3}"))
+	return []byte(strings.Replace(string(content), string(section), string(bumped), 1))
+}
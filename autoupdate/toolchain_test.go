@@ -0,0 +1,123 @@
+package autoupdate
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+func TestBumpEngine(t *testing.T) {
+	tests := []struct {
+		Content  string
+		Expected string
+	}{
+		{`{"engines": {"node": ">=10", "npm": "^6.0.0"}}`, `{"engines": {"node": ">=10", "npm": "^7.1.0"}}`},
+		{`{"engines": {"npm": ">=6.4"}}`, `{"engines": {"npm": ">=6.4"}}`},
+		{`{"engines": {"npm": "6.14.0"}}`, `{"engines": {"npm": "7.1.0"}}`},
+		{`{"engines": {"npm": ">=5 <7"}}`, `{"engines": {"npm": ">=7.1.0"}}`},
+		{`{"dependencies": {"npm": "^6.0.0"}}`, `{"dependencies": {"npm": "^6.0.0"}}`},
+	}
+	for _, test := range tests {
+		if bumped := string(bumpEngine([]byte(test.Content), "npm", "7.1.0")); bumped != test.Expected {
+			t.Errorf("%s: expected %s, got %s", test.Content, test.Expected, bumped)
+		}
+	}
+}
+
+func TestRubygemsUpdaterWithBundler(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-bundler")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	ioutil.WriteFile("Gemfile.lock", []byte("BUNDLED WITH\n   1.17.3\n"), 0644)
+	// Fake gem and bundle, writing their arguments to the lockfile
+	ioutil.WriteFile("fake.sh", []byte(`echo "$@" >> Gemfile.lock`), 0755)
+	os.Setenv(config.ENV_GEMNASIUM_GEM_INSTALL_CMD, "sh fake.sh gem install")
+	defer os.Unsetenv(config.ENV_GEMNASIUM_GEM_INSTALL_CMD)
+	os.Setenv(config.ENV_GEMNASIUM_BUNDLE_UPDATE_CMD, "sh fake.sh bundle update")
+	defer os.Unsetenv(config.ENV_GEMNASIUM_BUNDLE_UPDATE_CMD)
+
+	versionUpdates := []VersionUpdate{
+		{Package: models.Package{Name: "rails", Type: "Rubygem"}, OldVersion: "5.2.0", TargetVersion: "5.2.4"},
+		{Package: models.Package{Name: "bundler", Type: "Rubygem"}, OldVersion: "1.17.3", TargetVersion: "2.1.4"},
+	}
+	var orgDepFiles, uptDepFiles []models.DependencyFile
+	if err := RubygemsUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != nil {
+		t.Fatal(err)
+	}
+	// bundler is installed in the toolchain of the update set, not globally
+	toolchain := toolchainDir
+	if toolchain == "" {
+		t.Fatal("Expected bundler to be installed in a toolchain directory")
+	}
+	expected := "BUNDLED WITH\n   1.17.3\ngem install --install-dir " + toolchain + " --bindir " + filepath.Join(toolchain, "bin") + " bundler -v 2.1.4\nbundle update --bundler=2.1.4\nbundle update rails\n"
+	if len(uptDepFiles) != 1 || string(uptDepFiles[0].Content) != expected {
+		t.Errorf("Expected Gemfile.lock:\n%s\nGot: %v", expected, uptDepFiles)
+	}
+	env := strings.Join(commandEnv([]string{"PATH=/usr/bin"}), "\n") + "\n"
+	if !strings.Contains(env, "PATH="+filepath.Join(toolchain, "bin")+string(os.PathListSeparator)+"/usr/bin\n") || !strings.Contains(env, "GEM_PATH="+toolchain+"\n") {
+		t.Errorf("Expected the toolchain to come first in the env of the commands, got:\n%s", env)
+	}
+	if err := restoreDepFiles(orgDepFiles); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(toolchain); !os.IsNotExist(err) || toolchainDir != "" {
+		t.Errorf("Expected the toolchain to be removed with the update set, got: %v", err)
+	}
+
+	// Only bundler is updated, the gems aren't
+	ioutil.WriteFile("Gemfile.lock", []byte("BUNDLED WITH\n   1.17.3\n"), 0644)
+	orgDepFiles, uptDepFiles = nil, nil
+	if err := RubygemsUpdater(versionUpdates[1:], &orgDepFiles, &uptDepFiles); err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(uptDepFiles[0].Content), "bundle update\n") {
+		t.Errorf("bundle update shouldn't be run without gems, got:\n%s", uptDepFiles[0].Content)
+	}
+}
+
+func TestNpmUpdaterWithNpm(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-npm-upgrade")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	ioutil.WriteFile("package.json", []byte(`{"engines": {"npm": "^6.0.0"}}`), 0644)
+	ioutil.WriteFile("package-lock.json", []byte(`{}`), 0644)
+	// Fake npm, writing its arguments to the lockfile
+	ioutil.WriteFile("npm.sh", []byte(`echo "$@" >> package-lock.json`), 0755)
+	os.Setenv(config.ENV_GEMNASIUM_NPM_UPGRADE_CMD, "sh npm.sh install -g")
+	defer os.Unsetenv(config.ENV_GEMNASIUM_NPM_UPGRADE_CMD)
+
+	versionUpdates := []VersionUpdate{
+		{Package: models.Package{Name: "npm", Type: "Npm"}, OldVersion: "6.14.0", TargetVersion: "7.1.0"},
+	}
+	var orgDepFiles, uptDepFiles []models.DependencyFile
+	if err := NpmUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != nil {
+		t.Fatal(err)
+	}
+	if len(uptDepFiles) != 2 {
+		t.Fatalf("package.json and package-lock.json should be recorded, got: %v", uptDepFiles)
+	}
+	if string(uptDepFiles[0].Content) != `{"engines": {"npm": "^7.1.0"}}` {
+		t.Errorf("engines should be bumped, got: %s", uptDepFiles[0].Content)
+	}
+	defer removeToolchain()
+	if string(uptDepFiles[1].Content) != "{}install -g --prefix "+toolchainDir+" npm@7.1.0\n" {
+		t.Errorf("npm should be upgraded in the toolchain of the update set, got: %s", uptDepFiles[1].Content)
+	}
+}
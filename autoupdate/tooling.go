@@ -0,0 +1,117 @@
+package autoupdate
+
+import (
+	"errors"
+	"os"
+	"os/exec"
+	"regexp"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// A binary needed to update packages of a given type
+type tool struct {
+	Name string
+	// Command overriding the default binary (ie: GEMNASIUM_BUNDLE_UPDATE_CMD)
+	Env        string
+	MinVersion string
+	// Argument printing the version, "--version" if empty
+	VersionArg string
+}
+
+// Lockfiles telling which package types are in play, and the tools required to
+// update them
+var requiredTools = map[string][]tool{
+	"Gemfile.lock": {
+		{Name: "bundle", Env: config.ENV_GEMNASIUM_BUNDLE_UPDATE_CMD, MinVersion: "1.0.0"},
+		{Name: "bundle", Env: config.ENV_GEMNASIUM_BUNDLE_INSTALL_CMD, MinVersion: "1.0.0"},
+	},
+	// package-lock.json was introduced with npm 5
+	"package-lock.json": {
+		{Name: "npm", Env: config.ENV_GEMNASIUM_NPM_UPDATE_CMD, MinVersion: "5.0.0"},
+	},
+	// go mod tidy was introduced with go 1.11
+	"go.mod": {
+		{Name: "go", Env: config.ENV_GEMNASIUM_GO_GET_CMD, MinVersion: "1.11", VersionArg: "version"},
+	},
+	"Cargo.lock": {
+		{Name: "cargo", Env: config.ENV_GEMNASIUM_CARGO_UPDATE_CMD, MinVersion: "1.0.0"},
+	},
+	"pom.xml": {
+		{Name: "mvn", Env: config.ENV_GEMNASIUM_MVN_UPDATE_CMD, MinVersion: "3.0.0"},
+	},
+	// dotnet add package was introduced with the .NET Core 2.0 SDK
+	"packages.lock.json": {
+		{Name: "dotnet", Env: config.ENV_GEMNASIUM_DOTNET_ADD_CMD, MinVersion: "2.0.0"},
+	},
+}
+
+var versionNumber = regexp.MustCompile(`\d+(\.\d+)+`)
+
+// Return the version printed by "<binary> <arg>" (ie: "bundle --version")
+var toolVersion = func(binary, arg string) (string, error) {
+	out, err := command(binary, arg).Output()
+	if err != nil {
+		return "", err
+	}
+	return versionNumber.FindString(string(out)), nil
+}
+
+// Check that the tools needed by the package types of the project, and the
+// test suite, are installed, with the minimum versions (overridable in
+// config), so autoupdate fails right away instead of failing in the middle of
+// the update sets.
+func checkTooling(testSuite []string) error {
+	problems := []string{}
+	if _, err := exec.LookPath(testSuite[0]); err != nil {
+		problems = append(problems, i18n.T("autoupdate.tool_missing", testSuite[0]))
+	}
+
+	checked := map[string]bool{}
+	for lockfile, tools := range requiredTools {
+		if _, err := os.Stat(lockfile); err != nil {
+			continue
+		}
+		for _, t := range tools {
+			binary := t.Name
+			if cmd := strings.Fields(os.Getenv(t.Env)); len(cmd) > 0 {
+				binary = cmd[0]
+			}
+			if checked[binary] {
+				continue
+			}
+			checked[binary] = true
+
+			if _, err := exec.LookPath(binary); err != nil {
+				problems = append(problems, i18n.T("autoupdate.tool_missing", binary))
+				continue
+			}
+			minVersion := t.MinVersion
+			if v, ok := config.MinToolVersions[binary]; ok {
+				minVersion = v
+			}
+			if minVersion == "" {
+				continue
+			}
+			arg := t.VersionArg
+			if arg == "" {
+				arg = "--version"
+			}
+			version, err := toolVersion(binary, arg)
+			if err != nil || version == "" {
+				problems = append(problems, i18n.T("autoupdate.tool_version_unknown", binary))
+				continue
+			}
+			if utils.CompareVersions(version, minVersion) < 0 {
+				problems = append(problems, i18n.T("autoupdate.tool_too_old", binary, version, minVersion))
+			}
+		}
+	}
+	if len(problems) > 0 {
+		return errors.New(i18n.T("autoupdate.tooling_check_failed", strings.Join(problems, "\n")))
+	}
+	return nil
+}
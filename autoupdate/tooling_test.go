@@ -0,0 +1,52 @@
+package autoupdate
+
+import (
+	"io/ioutil"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestCheckTooling(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-tooling")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	os.Setenv(config.ENV_GEMNASIUM_BUNDLE_UPDATE_CMD, "sh -c update")
+	os.Setenv(config.ENV_GEMNASIUM_BUNDLE_INSTALL_CMD, "sh -c install")
+	defer os.Unsetenv(config.ENV_GEMNASIUM_BUNDLE_UPDATE_CMD)
+	defer os.Unsetenv(config.ENV_GEMNASIUM_BUNDLE_INSTALL_CMD)
+	version := "0.9.2"
+	toolVersion = func(binary, arg string) (string, error) { return version, nil }
+
+	// No lockfile, only the test suite is checked
+	if err := checkTooling([]string{"sh", "test.sh"}); err != nil {
+		t.Errorf("Tooling check should pass, got: %s", err)
+	}
+	err = checkTooling([]string{"gemnasium-missing-binary"})
+	if err == nil || !strings.Contains(err.Error(), "gemnasium-missing-binary: not found") {
+		t.Errorf("Missing test suite binary should be reported, got: %v", err)
+	}
+
+	ioutil.WriteFile("Gemfile.lock", []byte(""), 0644)
+	err = checkTooling([]string{"sh", "test.sh"})
+	if err == nil || !strings.Contains(err.Error(), "sh: version 0.9.2 is installed, 1.0.0 or newer is required") {
+		t.Errorf("Outdated tool should be reported, got: %v", err)
+	}
+	version = "2.1.4"
+	if err := checkTooling([]string{"sh", "test.sh"}); err != nil {
+		t.Errorf("Tooling check should pass, got: %s", err)
+	}
+	config.MinToolVersions["sh"] = "3"
+	defer delete(config.MinToolVersions, "sh")
+	if err := checkTooling([]string{"sh", "test.sh"}); err == nil {
+		t.Error("Minimum version from config should be used")
+	}
+}
@@ -0,0 +1,82 @@
+package autoupdate
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// Commands run by the updaters, built from the default command or the one set
+// with its env var (ie: GEMNASIUM_BUNDLE_UPDATE_CMD), with the version updates
+// as arguments. They're also described by dry runs (see describeUpdateSet).
+
+// Fields of the command set with the env var, or of the default one
+func envCommand(envVar, defaultCmd string) []string {
+	if cmd := os.Getenv(envVar); cmd != "" {
+		return strings.Fields(cmd)
+	}
+	return strings.Fields(defaultCmd)
+}
+
+// bundle update <gem>...
+func bundleUpdateCommand(versionUpdates []VersionUpdate) []string {
+	parts := envCommand(config.ENV_GEMNASIUM_BUNDLE_UPDATE_CMD, BUNDLE_UPDATE_CMD)
+	for _, vu := range versionUpdates {
+		parts = append(parts, vu.Package.Name)
+	}
+	return parts
+}
+
+// gem install --install-dir <prefix> --bindir <prefix>/bin bundler -v <version>,
+// then bundle update --bundler=<version>
+func upgradeBundlerCommands(vu VersionUpdate, prefix string) [][]string {
+	install := append(envCommand(config.ENV_GEMNASIUM_GEM_INSTALL_CMD, GEM_INSTALL_CMD), "--install-dir", prefix, "--bindir", filepath.Join(prefix, "bin"))
+	return [][]string{
+		append(install, "bundler", "-v", vu.TargetVersion),
+		append(envCommand(config.ENV_GEMNASIUM_BUNDLE_UPDATE_CMD, BUNDLE_UPDATE_CMD), "--bundler="+vu.TargetVersion),
+	}
+}
+
+// npm install <package>@<version>...
+func npmInstallCommand(versionUpdates []VersionUpdate) []string {
+	parts := envCommand(config.ENV_GEMNASIUM_NPM_UPDATE_CMD, NPM_UPDATE_CMD)
+	for _, vu := range versionUpdates {
+		parts = append(parts, vu.Package.Name+"@"+vu.TargetVersion)
+	}
+	return parts
+}
+
+// npm install -g --prefix <prefix> npm@<version>
+func upgradeNpmCommand(vu VersionUpdate, prefix string) []string {
+	return append(envCommand(config.ENV_GEMNASIUM_NPM_UPGRADE_CMD, NPM_UPGRADE_CMD), "--prefix", prefix, "npm@"+vu.TargetVersion)
+}
+
+// go get <module>@v<version>..., then go mod tidy
+func goGetCommands(versionUpdates []VersionUpdate) [][]string {
+	parts := envCommand(config.ENV_GEMNASIUM_GO_GET_CMD, GO_GET_CMD)
+	for _, vu := range versionUpdates {
+		version := vu.TargetVersion
+		if !strings.HasPrefix(version, "v") {
+			version = "v" + version
+		}
+		parts = append(parts, vu.Package.Name+"@"+version)
+	}
+	return [][]string{parts, strings.Fields(GO_TIDY_CMD)}
+}
+
+// cargo update -p <crate> --precise <version>
+func cargoUpdateCommand(vu VersionUpdate) []string {
+	return append(envCommand(config.ENV_GEMNASIUM_CARGO_UPDATE_CMD, CARGO_UPDATE_CMD), "-p", vu.Package.Name, "--precise", vu.TargetVersion)
+}
+
+// mvn versions:use-dep-version -Dincludes=<groupId:artifactId> -DdepVersion=<version>
+func mvnUpdateCommand(vu VersionUpdate) []string {
+	return append(envCommand(config.ENV_GEMNASIUM_MVN_UPDATE_CMD, MVN_UPDATE_CMD), "-Dincludes="+vu.Package.Name, "-DdepVersion="+vu.TargetVersion, "-DforceVersion=true", "-DgenerateBackupPoms=false")
+}
+
+// dotnet add package <package> --version <version>
+func dotnetAddCommand(vu VersionUpdate) []string {
+	return append(envCommand(config.ENV_GEMNASIUM_DOTNET_ADD_CMD, DOTNET_ADD_CMD), vu.Package.Name, "--version", vu.TargetVersion)
+}
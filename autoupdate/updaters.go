@@ -3,22 +3,29 @@ package autoupdate
 import (
 	"errors"
 	"fmt"
+	"io/ioutil"
 	"os"
-	"os/exec"
+	"path/filepath"
 	"regexp"
 	"strings"
 
-	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
 	"github.com/gemnasium/toolbelt/models"
 )
 
 const (
 	BUNDLE_UPDATE_CMD = "bundle update"
+	NPM_UPDATE_CMD    = "npm install"
+	GO_GET_CMD        = "go get"
+	GO_TIDY_CMD       = "go mod tidy"
+	CARGO_UPDATE_CMD  = "cargo update"
+	MVN_UPDATE_CMD    = "mvn versions:use-dep-version"
+	DOTNET_ADD_CMD    = "dotnet add package"
 )
 
 var (
-	cantUpdateVersions = errors.New("Can't update versions")
-	cantFindUpdater    = "Can't find updater for package type: %s\n"
+	cantUpdateVersions = errors.New(i18n.T("autoupdate.cant_update_versions"))
+	cantFindUpdater    = i18n.T("autoupdate.cant_find_updater")
 )
 
 // Func template for updaters Update Funcs take an UpdateSet, and a ref on the
@@ -31,6 +38,12 @@ type UpdateFunc func([]VersionUpdate, *[]models.DependencyFile, *[]models.Depend
 
 var updaters = map[string]UpdateFunc{
 	"Rubygem": RubygemsUpdater,
+	"Npm":     NpmUpdater,
+	"Go":      GoModUpdater,
+	"Cargo":   CargoUpdater,
+	"Maven":   MavenUpdater,
+	"Gradle":  GradleUpdater,
+	"Nuget":   NugetUpdater,
 }
 
 func NewUpdater(packageType string) (UpdateFunc, error) {
@@ -40,34 +53,369 @@ func NewUpdater(packageType string) (UpdateFunc, error) {
 	return nil, fmt.Errorf(cantFindUpdater, packageType)
 }
 
+// Read a file the package manager may create or update: nil is returned if it
+// doesn't exist
+func optionalDependencyFile(path string) (*models.DependencyFile, error) {
+	df, err := models.NewDependencyFileE(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	return df, err
+}
+
 func RubygemsUpdater(versionUpdates []VersionUpdate, orgDepFiles, uptDepFiles *[]models.DependencyFile) error {
 	// we're going to update gemfile.lock, let's save it to later restoration
-	GemfileLock := models.NewDependencyFile("Gemfile.lock")
+	GemfileLock, err := models.NewDependencyFileE("Gemfile.lock")
+	if err != nil {
+		return err
+	}
 	*orgDepFiles = append(*orgDepFiles, *GemfileLock)
 
-	upt := BUNDLE_UPDATE_CMD
-	if uptEnv := os.Getenv(config.ENV_GEMNASIUM_BUNDLE_UPDATE_CMD); uptEnv != "" {
-		upt = uptEnv
+	// bundler itself is updated first, so the gems are updated with it
+	bundler, versionUpdates := splitToolchainUpdate("bundler", versionUpdates)
+	if bundler != nil {
+		if err := upgradeBundler(*bundler); err != nil {
+			return err
+		}
+	}
+
+	if len(versionUpdates) > 0 {
+		// gemspec requirements may prevent bundler from updating the gems
+		if err := updateGemspecs(versionUpdates, orgDepFiles, uptDepFiles); err != nil {
+			return err
+		}
+
+		for _, vu := range versionUpdates {
+			fmt.Fprint(Output, i18n.T("autoupdate.updating_dependency", vu.Package.Name, vu.OldVersion, vu.TargetVersion))
+		}
+		parts := bundleUpdateCommand(versionUpdates)
+		fmt.Fprint(Output, i18n.T("autoupdate.executing_update_command", strings.Join(parts, " ")))
+		out, err := command(parts[0], parts[1:]...).Output()
+		if err != nil {
+			couldNotFindCompatibleVersion := regexp.MustCompile("(?m)^Bundler could not find compatible versions for gem")
+			if couldNotFindCompatibleVersion.MatchString(string(out)) {
+				// We have an invalid updateSet, and must notify Gemnasium about it
+				return cantUpdateVersions
+			}
+
+			fmt.Fprintf(Output, "%s\n", out)
+			return err
+		}
+	}
+	GemfileLock.Update()
+	*uptDepFiles = append(*uptDepFiles, *GemfileLock)
+
+	return nil
+}
+
+// Files written by npm when installing packages
+var npmFiles = []string{"package.json", "package-lock.json", "npm-shrinkwrap.json"}
+
+// Install the target versions with "npm install <package>@<version>" (or
+// GEMNASIUM_NPM_UPDATE_CMD), which updates both package.json and the lockfile.
+// npm itself is upgraded globally, and its "engines" requirement bumped.
+func NpmUpdater(versionUpdates []VersionUpdate, orgDepFiles, uptDepFiles *[]models.DependencyFile) error {
+	// save the files npm is going to update, for later restoration
+	files := []*models.DependencyFile{}
+	for _, path := range npmFiles {
+		df, err := optionalDependencyFile(path)
+		if err != nil {
+			return err
+		}
+		if df != nil {
+			files = append(files, df)
+			*orgDepFiles = append(*orgDepFiles, *df)
+		}
+	}
+
+	// npm itself is upgraded first, unless it's a dependency of the project
+	if !isNpmDependency("npm") {
+		var npm *VersionUpdate
+		npm, versionUpdates = splitToolchainUpdate("npm", versionUpdates)
+		if npm != nil {
+			if err := upgradeNpm(*npm); err != nil {
+				return err
+			}
+		}
+	}
+
+	if len(versionUpdates) > 0 {
+		for _, vu := range versionUpdates {
+			fmt.Fprint(Output, i18n.T("autoupdate.updating_dependency", vu.Package.Name, vu.OldVersion, vu.TargetVersion))
+		}
+		parts := npmInstallCommand(versionUpdates)
+		fmt.Fprint(Output, i18n.T("autoupdate.executing_update_command", strings.Join(parts, " ")))
+		// npm reports errors on stderr
+		out, err := command(parts[0], parts[1:]...).CombinedOutput()
+		if err != nil {
+			couldNotResolve := regexp.MustCompile("(?m)(ERESOLVE|ETARGET|Could not resolve dependency|No matching version found)")
+			if couldNotResolve.MatchString(string(out)) {
+				// We have an invalid updateSet, and must notify Gemnasium about it
+				return cantUpdateVersions
+			}
+
+			fmt.Fprintf(Output, "%s\n", out)
+			return err
+		}
+	}
+	for _, df := range files {
+		df.Update()
+		*uptDepFiles = append(*uptDepFiles, *df)
 	}
-	parts := strings.Fields(upt)
+
+	return nil
+}
+
+// Install the target versions with "go get <module>@<version>" (or
+// GEMNASIUM_GO_GET_CMD), then clean go.mod and go.sum up with "go mod tidy"
+func GoModUpdater(versionUpdates []VersionUpdate, orgDepFiles, uptDepFiles *[]models.DependencyFile) error {
+	// save go.mod and go.sum for later restoration
+	files := []*models.DependencyFile{}
+	for _, path := range []string{"go.mod", "go.sum"} {
+		df, err := optionalDependencyFile(path)
+		if err != nil {
+			return err
+		}
+		if df != nil {
+			files = append(files, df)
+			*orgDepFiles = append(*orgDepFiles, *df)
+		}
+	}
+
 	for _, vu := range versionUpdates {
-		fmt.Printf("Updating dependency %s (%s => %s)\n", vu.Package.Name, vu.OldVersion, vu.TargetVersion)
-		parts = append(parts, vu.Package.Name)
+		fmt.Fprint(Output, i18n.T("autoupdate.updating_dependency", vu.Package.Name, vu.OldVersion, vu.TargetVersion))
+	}
+	couldNotResolve := regexp.MustCompile("(?m)(no matching versions for query|unknown revision|invalid version|conflicting requirements|cannot find module providing package)")
+	for _, cmd := range goGetCommands(versionUpdates) {
+		fmt.Fprint(Output, i18n.T("autoupdate.executing_update_command", strings.Join(cmd, " ")))
+		// go reports errors on stderr
+		out, err := command(cmd[0], cmd[1:]...).CombinedOutput()
+		if err != nil {
+			if couldNotResolve.MatchString(string(out)) {
+				// We have an invalid updateSet, and must notify Gemnasium about it
+				return cantUpdateVersions
+			}
+
+			fmt.Fprintf(Output, "%s\n", out)
+			return err
+		}
 	}
-	fmt.Printf("Executing update commmand: %s\n", strings.Join(parts, " "))
-	out, err := exec.Command(parts[0], parts[1:]...).Output()
+	for _, df := range files {
+		df.Update()
+		*uptDepFiles = append(*uptDepFiles, *df)
+	}
+
+	return nil
+}
+
+// Lock the target versions with "cargo update -p <crate> --precise <version>"
+// (or GEMNASIUM_CARGO_UPDATE_CMD), one crate at a time
+func CargoUpdater(versionUpdates []VersionUpdate, orgDepFiles, uptDepFiles *[]models.DependencyFile) error {
+	// we're going to update Cargo.lock, let's save it to later restoration
+	CargoLock, err := models.NewDependencyFileE("Cargo.lock")
 	if err != nil {
-		couldNotFindCompatibleVersion := regexp.MustCompile("(?m)^Bundler could not find compatible versions for gem")
-		if couldNotFindCompatibleVersion.MatchString(string(out)) {
+		return err
+	}
+	*orgDepFiles = append(*orgDepFiles, *CargoLock)
+
+	couldNotResolve := regexp.MustCompile("(?m)(failed to select a version|did not match any packages|no matching package named)")
+	for _, vu := range versionUpdates {
+		fmt.Fprint(Output, i18n.T("autoupdate.updating_dependency", vu.Package.Name, vu.OldVersion, vu.TargetVersion))
+		parts := cargoUpdateCommand(vu)
+		fmt.Fprint(Output, i18n.T("autoupdate.executing_update_command", strings.Join(parts, " ")))
+		// cargo reports errors on stderr
+		out, err := command(parts[0], parts[1:]...).CombinedOutput()
+		if err != nil {
+			if couldNotResolve.MatchString(string(out)) {
+				// We have an invalid updateSet, and must notify Gemnasium about it
+				return cantUpdateVersions
+			}
+
+			fmt.Fprintf(Output, "%s\n", out)
+			return err
+		}
+	}
+	CargoLock.Update()
+	*uptDepFiles = append(*uptDepFiles, *CargoLock)
+
+	return nil
+}
+
+// Set the target versions in pom.xml with the versions-maven-plugin:
+// "mvn versions:use-dep-version -Dincludes=<groupId:artifactId>
+// -DdepVersion=<version>" (or GEMNASIUM_MVN_UPDATE_CMD), one dependency at a
+// time. Package names are "groupId:artifactId".
+func MavenUpdater(versionUpdates []VersionUpdate, orgDepFiles, uptDepFiles *[]models.DependencyFile) error {
+	// we're going to update pom.xml, let's save it to later restoration
+	pom, err := models.NewDependencyFileE("pom.xml")
+	if err != nil {
+		return err
+	}
+	*orgDepFiles = append(*orgDepFiles, *pom)
+
+	couldNotResolve := regexp.MustCompile("(?m)(Could not resolve|is not available|No versions available)")
+	for _, vu := range versionUpdates {
+		fmt.Fprint(Output, i18n.T("autoupdate.updating_dependency", vu.Package.Name, vu.OldVersion, vu.TargetVersion))
+		parts := mvnUpdateCommand(vu)
+		fmt.Fprint(Output, i18n.T("autoupdate.executing_update_command", strings.Join(parts, " ")))
+		out, err := command(parts[0], parts[1:]...).CombinedOutput()
+		if err != nil {
+			if couldNotResolve.MatchString(string(out)) {
+				// We have an invalid updateSet, and must notify Gemnasium about it
+				return cantUpdateVersions
+			}
+
+			fmt.Fprintf(Output, "%s\n", out)
+			return err
+		}
+	}
+	sha := pom.SHA
+	pom.Update()
+	if pom.SHA == sha {
+		// The plugin only warns when dependencies can't be found in the pom
+		return cantUpdateVersions
+	}
+	*uptDepFiles = append(*uptDepFiles, *pom)
+
+	return nil
+}
+
+// Regexps matching the version of a dependency ("group:name") in a Gradle
+// build script, in string notation ('group:name:1.0', "group:name:1.0:jdk8",
+// 'group:name:1.0@jar') and map notation (group: 'group', name: 'name',
+// version: '1.0', or with "=" in Kotlin scripts). The version is the first
+// submatch.
+func gradleVersionRegexps(name string) []*regexp.Regexp {
+	parts := strings.SplitN(name, ":", 2)
+	if len(parts) != 2 {
+		return nil
+	}
+	group, artifact := regexp.QuoteMeta(parts[0]), regexp.QuoteMeta(parts[1])
+	return []*regexp.Regexp{
+		regexp.MustCompile(`["']` + group + `:` + artifact + `:([^"':@$]+)`),
+		regexp.MustCompile(`group\s*[:=]\s*["']` + group + `["']\s*,\s*name\s*[:=]\s*["']` + artifact + `["']\s*,\s*version\s*[:=]\s*["']([^"'$]+)["']`),
+	}
+}
+
+// Replace the first submatch of every match of re in content with repl, and
+// return the number of replacements
+func replaceSubmatches(re *regexp.Regexp, content []byte, repl string) ([]byte, int) {
+	matches := re.FindAllSubmatchIndex(content, -1)
+	result := []byte{}
+	last := 0
+	for _, m := range matches {
+		result = append(result, content[last:m[2]]...)
+		result = append(result, repl...)
+		last = m[3]
+	}
+	return append(result, content[last:]...), len(matches)
+}
+
+// Rewrite the version strings of the dependencies ("group:name") in the
+// Gradle build script (build.gradle or build.gradle.kts), and in
+// gradle.lockfile if dependencies are locked. Versions set with variables
+// can't be rewritten: the update set is invalid if a dependency isn't found.
+func GradleUpdater(versionUpdates []VersionUpdate, orgDepFiles, uptDepFiles *[]models.DependencyFile) error {
+	// we're going to update the build script, let's save it to later restoration
+	var script *models.DependencyFile
+	var err error
+	for _, path := range []string{"build.gradle", "build.gradle.kts"} {
+		if script, err = models.NewDependencyFileE(path); !os.IsNotExist(err) {
+			break
+		}
+	}
+	if os.IsNotExist(err) {
+		return errors.New(i18n.T("df.unreadable", "build.gradle"))
+	}
+	if err != nil {
+		return err
+	}
+	*orgDepFiles = append(*orgDepFiles, *script)
+	lockfile, err := optionalDependencyFile("gradle.lockfile")
+	if err != nil {
+		return err
+	}
+	if lockfile != nil {
+		*orgDepFiles = append(*orgDepFiles, *lockfile)
+	}
+
+	for _, vu := range versionUpdates {
+		fmt.Fprint(Output, i18n.T("autoupdate.updating_dependency", vu.Package.Name, vu.OldVersion, vu.TargetVersion))
+		found := 0
+		for _, re := range gradleVersionRegexps(vu.Package.Name) {
+			var n int
+			script.Content, n = replaceSubmatches(re, script.Content, vu.TargetVersion)
+			found += n
+		}
+		if lockfile != nil {
+			// group:name:1.0=compileClasspath,runtimeClasspath
+			re := regexp.MustCompile(`(?m)^` + regexp.QuoteMeta(vu.Package.Name) + `:([^=]+)=`)
+			lockfile.Content, _ = replaceSubmatches(re, lockfile.Content, vu.TargetVersion)
+		}
+		if found == 0 {
 			// We have an invalid updateSet, and must notify Gemnasium about it
 			return cantUpdateVersions
 		}
+	}
 
-		fmt.Printf("%s\n", out)
-		return err
+	for _, df := range []*models.DependencyFile{script, lockfile} {
+		if df == nil {
+			continue
+		}
+		if err := ioutil.WriteFile(df.Path, df.Content, 0644); err != nil {
+			return err
+		}
+		df.Update()
+		*uptDepFiles = append(*uptDepFiles, *df)
+	}
+
+	return nil
+}
+
+// Files written by dotnet when adding packages: the project files of the
+// current directory and the lockfile
+func nugetFiles() []string {
+	files, _ := filepath.Glob("*.csproj")
+	return append(files, "packages.lock.json", "packages.config")
+}
+
+// Install the target versions with "dotnet add package <package> --version
+// <version>" (or GEMNASIUM_DOTNET_ADD_CMD), one package at a time, which
+// updates the project file and the lockfile
+func NugetUpdater(versionUpdates []VersionUpdate, orgDepFiles, uptDepFiles *[]models.DependencyFile) error {
+	// save the files dotnet is going to update, for later restoration
+	files := []*models.DependencyFile{}
+	for _, path := range nugetFiles() {
+		df, err := optionalDependencyFile(path)
+		if err != nil {
+			return err
+		}
+		if df != nil {
+			files = append(files, df)
+			*orgDepFiles = append(*orgDepFiles, *df)
+		}
+	}
+
+	couldNotResolve := regexp.MustCompile("(?m)(NU1101|NU1102|NU1103|NU1107|NU1605|Unable to find package)")
+	for _, vu := range versionUpdates {
+		fmt.Fprint(Output, i18n.T("autoupdate.updating_dependency", vu.Package.Name, vu.OldVersion, vu.TargetVersion))
+		parts := dotnetAddCommand(vu)
+		fmt.Fprint(Output, i18n.T("autoupdate.executing_update_command", strings.Join(parts, " ")))
+		out, err := command(parts[0], parts[1:]...).CombinedOutput()
+		if err != nil {
+			if couldNotResolve.MatchString(string(out)) {
+				// We have an invalid updateSet, and must notify Gemnasium about it
+				return cantUpdateVersions
+			}
+
+			fmt.Fprintf(Output, "%s\n", out)
+			return err
+		}
+	}
+	for _, df := range files {
+		df.Update()
+		*uptDepFiles = append(*uptDepFiles, *df)
 	}
-	GemfileLock.Update()
-	*uptDepFiles = append(*uptDepFiles, *GemfileLock)
 
 	return nil
 }
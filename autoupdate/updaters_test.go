@@ -0,0 +1,298 @@
+package autoupdate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+func TestNpmUpdater(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-npm")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	ioutil.WriteFile("package.json", []byte(`{"dependencies":{"lodash":"^4.17.15"}}`), 0644)
+	ioutil.WriteFile("package-lock.json", []byte(`{"dependencies":{"lodash":{"version":"4.17.15"}}}`), 0644)
+	// Fake npm, writing its arguments to the lockfile
+	ioutil.WriteFile("npm.sh", []byte(`echo "$@" > package-lock.json`), 0755)
+	os.Setenv(config.ENV_GEMNASIUM_NPM_UPDATE_CMD, "sh npm.sh install")
+	defer os.Unsetenv(config.ENV_GEMNASIUM_NPM_UPDATE_CMD)
+
+	versionUpdates := []VersionUpdate{
+		{Package: models.Package{Name: "lodash", Type: "Npm"}, OldVersion: "4.17.15", TargetVersion: "4.17.21"},
+	}
+	var orgDepFiles, uptDepFiles []models.DependencyFile
+	if err := NpmUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != nil {
+		t.Fatal(err)
+	}
+	if len(orgDepFiles) != 2 || len(uptDepFiles) != 2 {
+		t.Fatalf("package.json and package-lock.json should be recorded, got: %v, %v", orgDepFiles, uptDepFiles)
+	}
+	if string(orgDepFiles[1].Content) != `{"dependencies":{"lodash":{"version":"4.17.15"}}}` {
+		t.Errorf("Original lockfile should be recorded, got: %s", orgDepFiles[1].Content)
+	}
+	if string(uptDepFiles[1].Content) != "install lodash@4.17.21\n" {
+		t.Errorf("Updated lockfile should be recorded, got: %s", uptDepFiles[1].Content)
+	}
+
+	// Conflicting versions
+	ioutil.WriteFile("npm.sh", []byte("echo 'npm ERR! code ERESOLVE' >&2; exit 1"), 0755)
+	orgDepFiles, uptDepFiles = nil, nil
+	if err := NpmUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != cantUpdateVersions {
+		t.Errorf("Expected cantUpdateVersions, got: %v", err)
+	}
+	if len(orgDepFiles) != 2 {
+		t.Errorf("Files should be recorded for restoration, got: %v", orgDepFiles)
+	}
+}
+
+func TestGoModUpdater(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-gomod")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	ioutil.WriteFile("go.mod", []byte("module example.com/app\n\nrequire golang.org/x/text v0.3.0\n"), 0644)
+	ioutil.WriteFile("go.sum", []byte("golang.org/x/text v0.3.0 h1:...\n"), 0644)
+	// Fake go binary, first in PATH, recording its arguments
+	os.Mkdir("bin", 0755)
+	ioutil.WriteFile("bin/go", []byte("#!/bin/sh\necho \"$@\" >> go.sum\n"), 0755)
+	path := os.Getenv("PATH")
+	os.Setenv("PATH", dir+"/bin:"+path)
+	defer os.Setenv("PATH", path)
+
+	versionUpdates := []VersionUpdate{
+		{Package: models.Package{Name: "golang.org/x/text", Type: "Go"}, OldVersion: "0.3.0", TargetVersion: "0.3.8"},
+	}
+	var orgDepFiles, uptDepFiles []models.DependencyFile
+	if err := GoModUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != nil {
+		t.Fatal(err)
+	}
+	if len(orgDepFiles) != 2 || len(uptDepFiles) != 2 {
+		t.Fatalf("go.mod and go.sum should be recorded, got: %v, %v", orgDepFiles, uptDepFiles)
+	}
+	expected := "golang.org/x/text v0.3.0 h1:...\nget golang.org/x/text@v0.3.8\nmod tidy\n"
+	if string(uptDepFiles[1].Content) != expected {
+		t.Errorf("Expected go.sum:\n%s\nGot:\n%s", expected, uptDepFiles[1].Content)
+	}
+
+	// Unknown version
+	ioutil.WriteFile("bin/go", []byte("#!/bin/sh\necho 'go: golang.org/x/text@v0.3.8: invalid version: unknown revision v0.3.8' >&2; exit 1\n"), 0755)
+	orgDepFiles, uptDepFiles = nil, nil
+	if err := GoModUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != cantUpdateVersions {
+		t.Errorf("Expected cantUpdateVersions, got: %v", err)
+	}
+}
+
+func TestCargoUpdater(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-cargo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	ioutil.WriteFile("Cargo.lock", []byte("[[package]]\nname = \"serde\"\nversion = \"1.0.100\"\n"), 0644)
+	// Fake cargo, writing its arguments to the lockfile
+	ioutil.WriteFile("cargo.sh", []byte(`echo "$@" >> Cargo.lock`), 0755)
+	os.Setenv(config.ENV_GEMNASIUM_CARGO_UPDATE_CMD, "sh cargo.sh update")
+	defer os.Unsetenv(config.ENV_GEMNASIUM_CARGO_UPDATE_CMD)
+
+	versionUpdates := []VersionUpdate{
+		{Package: models.Package{Name: "serde", Type: "Cargo"}, OldVersion: "1.0.100", TargetVersion: "1.0.130"},
+		{Package: models.Package{Name: "rand", Type: "Cargo"}, OldVersion: "0.7.0", TargetVersion: "0.7.3"},
+	}
+	var orgDepFiles, uptDepFiles []models.DependencyFile
+	if err := CargoUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != nil {
+		t.Fatal(err)
+	}
+	if len(orgDepFiles) != 1 || len(uptDepFiles) != 1 {
+		t.Fatalf("Cargo.lock should be recorded, got: %v, %v", orgDepFiles, uptDepFiles)
+	}
+	expected := "[[package]]\nname = \"serde\"\nversion = \"1.0.100\"\nupdate -p serde --precise 1.0.130\nupdate -p rand --precise 0.7.3\n"
+	if string(uptDepFiles[0].Content) != expected {
+		t.Errorf("Expected Cargo.lock:\n%s\nGot:\n%s", expected, uptDepFiles[0].Content)
+	}
+
+	// Unknown version
+	ioutil.WriteFile("cargo.sh", []byte("echo 'error: failed to select a version for the requirement `serde = \"=1.0.999\"`' >&2; exit 101"), 0755)
+	orgDepFiles, uptDepFiles = nil, nil
+	if err := CargoUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != cantUpdateVersions {
+		t.Errorf("Expected cantUpdateVersions, got: %v", err)
+	}
+	if len(orgDepFiles) != 1 {
+		t.Error("Cargo.lock should be recorded for restoration")
+	}
+}
+
+func TestUpdatersWithoutFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-updaters")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	// The files to update are missing: an error is returned instead of a panic
+	for name, updater := range map[string]UpdateFunc{"rubygems": RubygemsUpdater, "cargo": CargoUpdater, "maven": MavenUpdater, "gradle": GradleUpdater} {
+		var orgDepFiles, uptDepFiles []models.DependencyFile
+		if err := updater(nil, &orgDepFiles, &uptDepFiles); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
+
+func TestMavenUpdater(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-maven")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	ioutil.WriteFile("pom.xml", []byte("<project>\n"), 0644)
+	// Fake mvn, writing its arguments to the pom
+	ioutil.WriteFile("mvn.sh", []byte(`echo "$@" >> pom.xml`), 0755)
+	os.Setenv(config.ENV_GEMNASIUM_MVN_UPDATE_CMD, "sh mvn.sh versions:use-dep-version")
+	defer os.Unsetenv(config.ENV_GEMNASIUM_MVN_UPDATE_CMD)
+
+	versionUpdates := []VersionUpdate{
+		{Package: models.Package{Name: "junit:junit", Type: "Maven"}, OldVersion: "4.12", TargetVersion: "4.13.2"},
+	}
+	var orgDepFiles, uptDepFiles []models.DependencyFile
+	if err := MavenUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != nil {
+		t.Fatal(err)
+	}
+	if len(orgDepFiles) != 1 || len(uptDepFiles) != 1 {
+		t.Fatalf("pom.xml should be recorded, got: %v, %v", orgDepFiles, uptDepFiles)
+	}
+	expected := "<project>\nversions:use-dep-version -Dincludes=junit:junit -DdepVersion=4.13.2 -DforceVersion=true -DgenerateBackupPoms=false\n"
+	if string(uptDepFiles[0].Content) != expected {
+		t.Errorf("Expected pom.xml:\n%s\nGot:\n%s", expected, uptDepFiles[0].Content)
+	}
+
+	// Dependency not found in the pom: the plugin succeeds without changing it
+	ioutil.WriteFile("mvn.sh", []byte("echo '[INFO] BUILD SUCCESS'"), 0755)
+	orgDepFiles, uptDepFiles = nil, nil
+	if err := MavenUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != cantUpdateVersions {
+		t.Errorf("Expected cantUpdateVersions, got: %v", err)
+	}
+}
+
+func TestGradleUpdater(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-gradle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	script := `dependencies {
+    implementation 'com.google.guava:guava:28.0-jre'
+    testImplementation "junit:junit:4.12@jar"
+    implementation group: 'org.slf4j', name: 'slf4j-api', version: '1.7.25'
+}
+`
+	ioutil.WriteFile("build.gradle", []byte(script), 0644)
+	ioutil.WriteFile("gradle.lockfile", []byte("com.google.guava:guava:28.0-jre=compileClasspath\njunit:junit:4.12=testCompileClasspath\n"), 0644)
+
+	versionUpdates := []VersionUpdate{
+		{Package: models.Package{Name: "com.google.guava:guava", Type: "Gradle"}, OldVersion: "28.0-jre", TargetVersion: "30.1-jre"},
+		{Package: models.Package{Name: "junit:junit", Type: "Gradle"}, OldVersion: "4.12", TargetVersion: "4.13.2"},
+		{Package: models.Package{Name: "org.slf4j:slf4j-api", Type: "Gradle"}, OldVersion: "1.7.25", TargetVersion: "1.7.30"},
+	}
+	var orgDepFiles, uptDepFiles []models.DependencyFile
+	if err := GradleUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != nil {
+		t.Fatal(err)
+	}
+	if len(orgDepFiles) != 2 || len(uptDepFiles) != 2 {
+		t.Fatalf("build.gradle and gradle.lockfile should be recorded, got: %v, %v", orgDepFiles, uptDepFiles)
+	}
+	if string(orgDepFiles[0].Content) != script {
+		t.Errorf("Original build.gradle should be recorded, got:\n%s", orgDepFiles[0].Content)
+	}
+	expected := `dependencies {
+    implementation 'com.google.guava:guava:30.1-jre'
+    testImplementation "junit:junit:4.13.2@jar"
+    implementation group: 'org.slf4j', name: 'slf4j-api', version: '1.7.30'
+}
+`
+	if string(uptDepFiles[0].Content) != expected {
+		t.Errorf("Expected build.gradle:\n%s\nGot:\n%s", expected, uptDepFiles[0].Content)
+	}
+	expected = "com.google.guava:guava:30.1-jre=compileClasspath\njunit:junit:4.13.2=testCompileClasspath\n"
+	if string(uptDepFiles[1].Content) != expected {
+		t.Errorf("Expected gradle.lockfile:\n%s\nGot:\n%s", expected, uptDepFiles[1].Content)
+	}
+
+	// Version set with a variable
+	ioutil.WriteFile("build.gradle", []byte(`implementation "junit:junit:$junitVersion"`), 0644)
+	orgDepFiles, uptDepFiles = nil, nil
+	if err := GradleUpdater(versionUpdates[1:2], &orgDepFiles, &uptDepFiles); err != cantUpdateVersions {
+		t.Errorf("Expected cantUpdateVersions, got: %v", err)
+	}
+}
+
+func TestNugetUpdater(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-nuget")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	ioutil.WriteFile("App.csproj", []byte(`<Project Sdk="Microsoft.NET.Sdk"></Project>`), 0644)
+	ioutil.WriteFile("packages.lock.json", []byte("{}\n"), 0644)
+	// Fake dotnet, writing its arguments to the lockfile
+	ioutil.WriteFile("dotnet.sh", []byte(`echo "$@" >> packages.lock.json`), 0755)
+	os.Setenv(config.ENV_GEMNASIUM_DOTNET_ADD_CMD, "sh dotnet.sh add package")
+	defer os.Unsetenv(config.ENV_GEMNASIUM_DOTNET_ADD_CMD)
+
+	versionUpdates := []VersionUpdate{
+		{Package: models.Package{Name: "Newtonsoft.Json", Type: "Nuget"}, OldVersion: "12.0.3", TargetVersion: "13.0.1"},
+		{Package: models.Package{Name: "Serilog", Type: "Nuget"}, OldVersion: "2.9.0", TargetVersion: "2.10.0"},
+	}
+	var orgDepFiles, uptDepFiles []models.DependencyFile
+	if err := NugetUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != nil {
+		t.Fatal(err)
+	}
+	if len(orgDepFiles) != 2 || len(uptDepFiles) != 2 {
+		t.Fatalf("App.csproj and packages.lock.json should be recorded, got: %v, %v", orgDepFiles, uptDepFiles)
+	}
+	expected := "{}\nadd package Newtonsoft.Json --version 13.0.1\nadd package Serilog --version 2.10.0\n"
+	if string(uptDepFiles[1].Content) != expected {
+		t.Errorf("Expected packages.lock.json:\n%s\nGot:\n%s", expected, uptDepFiles[1].Content)
+	}
+
+	// Unknown version
+	ioutil.WriteFile("dotnet.sh", []byte("echo 'error: NU1102: Unable to find package Serilog with version (>= 2.99.0)'; exit 1"), 0755)
+	orgDepFiles, uptDepFiles = nil, nil
+	if err := NugetUpdater(versionUpdates, &orgDepFiles, &uptDepFiles); err != cantUpdateVersions {
+		t.Errorf("Expected cantUpdateVersions, got: %v", err)
+	}
+	if len(orgDepFiles) != 2 {
+		t.Errorf("Files should be recorded for restoration, got: %v", orgDepFiles)
+	}
+}
@@ -0,0 +1,77 @@
+package autoupdate
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/registry"
+)
+
+// Check that every target version of the update set has been released on the
+// official registry, hasn't been yanked, and has valid signatures (when the
+// registry supports them). This protects unattended runs from installing
+// freshly-poisoned releases. Packages of registries without client are
+// skipped.
+func verifyUpdateSet(updateSet *UpdateSet) error {
+	for packageType, versionUpdates := range updateSet.VersionUpdates {
+		for _, vu := range versionUpdates {
+			err := verifyVersionUpdate(packageType, vu)
+			if err == registry.ErrVersionsUnsupported {
+				fmt.Fprint(Output, i18n.T("autoupdate.verification_unsupported", vu.Package.Name, packageType))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func verifyVersionUpdate(packageType string, vu VersionUpdate) error {
+	versions, err := registry.FreshVersions(packageType, vu.Package.Name)
+	if err != nil {
+		return err
+	}
+	version := registry.FindVersion(versions, vu.TargetVersion)
+	if version == nil {
+		return errors.New(i18n.T("autoupdate.version_not_found_maybe_yanked", vu.Package.Name, vu.TargetVersion))
+	}
+	if version.Yanked {
+		return errors.New(i18n.T("autoupdate.version_yanked", vu.Package.Name, vu.TargetVersion))
+	}
+	err = registry.VerifySignatures(packageType, vu.Package.Name, version)
+	if err != nil && err != registry.ErrSignaturesUnsupported {
+		return err
+	}
+	return nil
+}
+
+// Check that every target version of the update set has been released for at
+// least minAge. Brand-new releases are more likely to be yanked or malicious.
+// Packages of registries without client are skipped, as their release dates
+// are unknown.
+func checkReleaseAge(updateSet *UpdateSet, minAge time.Duration) error {
+	for packageType, versionUpdates := range updateSet.VersionUpdates {
+		for _, vu := range versionUpdates {
+			versions, err := registry.FreshVersions(packageType, vu.Package.Name)
+			if err == registry.ErrVersionsUnsupported {
+				fmt.Fprint(Output, i18n.T("autoupdate.release_age_unsupported", vu.Package.Name, packageType))
+				continue
+			}
+			if err != nil {
+				return err
+			}
+			version := registry.FindVersion(versions, vu.TargetVersion)
+			if version == nil {
+				return errors.New(i18n.T("autoupdate.version_not_found", vu.Package.Name, vu.TargetVersion))
+			}
+			if age := time.Since(version.CreatedAt); age < minAge {
+				return errors.New(i18n.T("autoupdate.release_too_recent", vu.Package.Name, vu.TargetVersion, age/time.Hour*time.Hour, minAge))
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+package autoupdate
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/gemnasium/toolbelt/registry"
+)
+
+func TestCheckReleaseAge(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintf(w, `[
+			{"number": "4.0.4", "platform": "ruby", "created_at": "%s"},
+			{"number": "4.0.3", "platform": "ruby", "created_at": "2014-02-18T18:04:18.000Z"}
+		]`, time.Now().Add(-24*time.Hour).Format(time.RFC3339))
+	}))
+	defer ts.Close()
+	registry.RubygemsURL = ts.URL
+	config.CacheDir = ""
+
+	newUpdateSet := func(target string) *UpdateSet {
+		return &UpdateSet{
+			ID: 1,
+			VersionUpdates: map[string][]VersionUpdate{
+				"Rubygem": []VersionUpdate{
+					VersionUpdate{Package: models.Package{Name: "rails"}, OldVersion: "4.0.2", TargetVersion: target},
+				},
+			},
+		}
+	}
+	if err := checkReleaseAge(newUpdateSet("4.0.3"), 7*24*time.Hour); err != nil {
+		t.Errorf("4.0.3 is old enough, got: %s", err)
+	}
+	if err := checkReleaseAge(newUpdateSet("4.0.4"), 7*24*time.Hour); err == nil {
+		t.Error("4.0.4 was released yesterday and should be deferred")
+	}
+	if err := verifyUpdateSet(newUpdateSet("4.0.5")); err == nil {
+		t.Error("4.0.5 doesn't exist and should fail verification")
+	}
+}
+
+func TestVerifyUpdateSetWithUnsupportedRegistry(t *testing.T) {
+	updateSet := &UpdateSet{
+		ID: 1,
+		VersionUpdates: map[string][]VersionUpdate{
+			"Cargo": []VersionUpdate{
+				VersionUpdate{Package: models.Package{Name: "serde"}, OldVersion: "1.0.0", TargetVersion: "1.0.100"},
+			},
+		},
+	}
+	var out bytes.Buffer
+	Output = &out
+	defer func() { Output = os.Stdout }()
+	if err := verifyUpdateSet(updateSet); err != nil {
+		t.Errorf("Packages of unsupported registries should be skipped, got: %s", err)
+	}
+	if err := checkReleaseAge(updateSet, 7*24*time.Hour); err != nil {
+		t.Errorf("Packages of unsupported registries shouldn't be deferred, got: %s", err)
+	}
+	for _, expected := range []string{"Can't verify serde", "Can't check the release date of serde"} {
+		if !strings.Contains(out.String(), expected) {
+			t.Errorf("Expected %q in the output: %s", expected, out.String())
+		}
+	}
+}
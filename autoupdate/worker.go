@@ -0,0 +1,246 @@
+package autoupdate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+const (
+	DEFAULT_WORKER_INTERVAL = 30 * time.Second
+	// Max wait of SQS long polling
+	SQS_WAIT_TIME = 20 * time.Second
+)
+
+// An autoupdate run consumed by a worker, at a revision of the repository of
+// the project
+type Job struct {
+	ID      string `json:"id"`
+	Project string `json:"project_slug"`
+	// Name of the worker the job targets, any worker if empty
+	Runner string `json:"runner,omitempty"`
+	// Clone URL of the repository, the one of the working directory if empty
+	Repository string   `json:"repository,omitempty"`
+	Revision   string   `json:"revision,omitempty"`
+	TestSuite  []string `json:"test_suite,omitempty"`
+	// SQS message the job was read from
+	message *utils.SQSMessage
+}
+
+// Result of a job, reported to Gemnasium
+type JobResult struct {
+	Runner   string `json:"runner"`
+	ExitCode int    `json:"exit_code"`
+	// Summary of the run (see RunSummary), if it went that far
+	Summary json.RawMessage `json:"summary,omitempty"`
+	Error   string          `json:"error,omitempty"`
+}
+
+// Where a worker gets its jobs
+type JobQueue interface {
+	// Return the next job the runner can take, or nil if there's none
+	Next(ctx context.Context, runner string) (*Job, error)
+	// Acknowledge the job once its result is reported, so that it's not
+	// taken again
+	Done(ctx context.Context, job *Job) error
+}
+
+// Return the queue described by spec (see config.WorkerQueue): "api", or the
+// URL of an SQS queue
+func NewJobQueue(spec string) (JobQueue, error) {
+	switch {
+	case spec == "" || spec == "api":
+		return apiQueue{}, nil
+	case strings.HasPrefix(spec, "amqp://") || strings.HasPrefix(spec, "amqps://"):
+		return nil, fmt.Errorf("AMQP queues aren't supported, use the API or an SQS queue: %s", spec)
+	case strings.HasPrefix(spec, "https://") || strings.HasPrefix(spec, "http://"):
+		queue, err := utils.NewSQSQueue(spec, config.S3Region)
+		if err != nil {
+			return nil, err
+		}
+		return sqsQueue{queue}, nil
+	}
+	return nil, fmt.Errorf("Unknown worker queue: %s (expected api, or the URL of an SQS queue)", spec)
+}
+
+// Jobs of the runner pending on Gemnasium. They're done once their result is
+// reported.
+type apiQueue struct{}
+
+// The job is null if there's none
+func (apiQueue) Next(ctx context.Context, runner string) (*Job, error) {
+	var next struct {
+		Job *Job `json:"job"`
+	}
+	opts := &gemnasium.APIRequestOptions{
+		Method:  "GET",
+		URI:     "/autoupdate_jobs/next?runner=" + url.QueryEscape(runner),
+		Result:  &next,
+		Context: ctx,
+	}
+	err := gemnasium.APIRequest(opts)
+	return next.Job, err
+}
+
+func (apiQueue) Done(ctx context.Context, job *Job) error {
+	return nil
+}
+
+// Jobs sent as JSON messages to an SQS queue. Jobs targeting another runner
+// are left in the queue, and received again by other workers once their
+// visibility timeout expires.
+type sqsQueue struct {
+	queue utils.SQSQueue
+}
+
+func (q sqsQueue) Next(ctx context.Context, runner string) (*Job, error) {
+	m, err := q.queue.Receive(ctx, SQS_WAIT_TIME)
+	if err != nil || m == nil {
+		return nil, err
+	}
+	job := &Job{message: m}
+	if err := json.Unmarshal([]byte(m.Body), job); err != nil || job.ID == "" || job.Project == "" {
+		// Invalid messages would be received again and again
+		q.queue.Delete(ctx, m)
+		return nil, fmt.Errorf("Invalid job in message %s, deleted: %s", m.MessageId, m.Body)
+	}
+	if job.Runner != "" && job.Runner != runner {
+		return nil, nil
+	}
+	return job, nil
+}
+
+func (q sqsQueue) Done(ctx context.Context, job *Job) error {
+	return q.queue.Delete(ctx, job.message)
+}
+
+// Worker taking autoupdate jobs from a queue, each job being run by the
+// toolbelt executable in a sandbox: a clone of the repository, removed
+// afterwards
+type Worker struct {
+	Name  string
+	Queue JobQueue
+	// Delay between polls when there's no job
+	Interval time.Duration
+	// Stop once there's no pending job, instead of waiting for new ones
+	Once bool
+	// Toolbelt executable running the jobs
+	Executable string
+}
+
+// Take and run jobs until ctx is done (or until there's no pending job, with
+// Once). Errors of a job are reported with its result, they don't stop the
+// worker.
+func (w *Worker) Run(ctx context.Context) error {
+	fmt.Fprint(Output, i18n.T("worker.started", w.Name))
+	for ctx.Err() == nil {
+		job, err := w.Queue.Next(ctx, w.Name)
+		if err != nil && ctx.Err() == nil {
+			fmt.Fprint(Output, i18n.T("worker.error", err))
+		}
+		if job != nil {
+			w.process(ctx, job)
+			continue
+		}
+		if w.Once && err == nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+		case <-time.After(w.Interval):
+		}
+	}
+	return nil
+}
+
+// Run the job, then report its result and acknowledge it
+func (w *Worker) process(ctx context.Context, job *Job) {
+	fmt.Fprint(Output, i18n.T("worker.job_started", job.ID, job.Project))
+	result := w.runJob(ctx, job)
+	fmt.Fprint(Output, i18n.T("worker.job_done", job.ID, result.ExitCode))
+	if err := reportJobResult(ctx, job, result); err != nil {
+		fmt.Fprint(Output, i18n.T("worker.report_error", job.ID, err))
+		return
+	}
+	if err := w.Queue.Done(ctx, job); err != nil {
+		fmt.Fprint(Output, i18n.T("worker.error", err))
+	}
+}
+
+func (w *Worker) runJob(ctx context.Context, job *Job) *JobResult {
+	result := &JobResult{Runner: w.Name, ExitCode: -1}
+	dir, err := ioutil.TempDir("", "gemnasium-job")
+	if err != nil {
+		result.Error = err.Error()
+		return result
+	}
+	defer os.RemoveAll(dir)
+	if err := checkoutJob(ctx, job, dir); err != nil {
+		result.Error = err.Error()
+		return result
+	}
+
+	// Messages of the run go to the log of the worker, its summary to stdout
+	args := []string{"--json", "--non-interactive", "autoupdate", "run", "--project", job.Project}
+	if len(job.TestSuite) > 0 {
+		args = append(append(args, "--"), job.TestSuite...)
+	}
+	cmd := exec.CommandContext(ctx, w.Executable, args...)
+	cmd.Dir = dir
+	var stdout bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = Output
+	err = cmd.Run()
+	if cmd.ProcessState != nil {
+		result.ExitCode = cmd.ProcessState.ExitCode()
+	}
+	if summary := bytes.TrimSpace(stdout.Bytes()); json.Valid(summary) && len(summary) > 0 {
+		result.Summary = summary
+	}
+	var exitErr *exec.ExitError
+	if err != nil && (!errors.As(err, &exitErr) || result.Summary == nil) {
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Clone the repository of the job in dir, at its revision
+func checkoutJob(ctx context.Context, job *Job, dir string) error {
+	repository := job.Repository
+	if repository == "" {
+		repository = "."
+	}
+	commands := [][]string{{"clone", "--quiet", repository, dir}}
+	if job.Revision != "" {
+		commands = append(commands, []string{"-C", dir, "checkout", "--quiet", job.Revision})
+	}
+	for _, args := range commands {
+		if out, err := exec.CommandContext(ctx, utils.GitPath(), args...).CombinedOutput(); err != nil {
+			return fmt.Errorf("git %s: %s\n%s", strings.Join(args, " "), err, out)
+		}
+	}
+	return nil
+}
+
+func reportJobResult(ctx context.Context, job *Job, result *JobResult) error {
+	opts := &gemnasium.APIRequestOptions{
+		Method:  "POST",
+		URI:     fmt.Sprintf("/autoupdate_jobs/%s/result", job.ID),
+		Body:    result,
+		Context: ctx,
+	}
+	return gemnasium.APIRequest(opts)
+}
@@ -0,0 +1,113 @@
+package autoupdate
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestWorker(t *testing.T) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git is required")
+	}
+	dir, err := ioutil.TempDir("", "gemnasium-worker")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// Repository of the job
+	repo := filepath.Join(dir, "repo")
+	os.MkdirAll(repo, 0755)
+	ioutil.WriteFile(filepath.Join(repo, "Gemfile"), []byte("gem 'rails'\n"), 0644)
+	for _, args := range [][]string{{"init", "--quiet"}, {"add", "Gemfile"}, {"-c", "user.name=test", "-c", "user.email=test@example.com", "commit", "--quiet", "-m", "init"}} {
+		cmd := exec.Command("git", args...)
+		cmd.Dir = repo
+		if out, err := cmd.CombinedOutput(); err != nil {
+			t.Fatalf("git %v: %s\n%s", args, err, out)
+		}
+	}
+
+	// Toolbelt running the job: it prints its arguments and the files of the
+	// sandbox, as a summary
+	exe := filepath.Join(dir, "gemnasium")
+	script := "#!/bin/sh\necho \"{\\\"args\\\": \\\"$*\\\", \\\"files\\\": \\\"$(ls)\\\"}\"\nexit 1\n"
+	if err := ioutil.WriteFile(exe, []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	var results []JobResult
+	jobs := []string{`{"job": {"id": "42", "project_slug": "blah", "repository": "` + repo + `", "test_suite": ["rake", "-v"]}}`}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch {
+		case r.URL.Path == "/autoupdate_jobs/next" && r.URL.Query().Get("runner") == "ci-1":
+			if len(jobs) == 0 {
+				w.Write([]byte(`{"job": null}`))
+				return
+			}
+			w.Write([]byte(jobs[0]))
+			jobs = jobs[1:]
+		case r.Method == "POST" && r.URL.Path == "/autoupdate_jobs/42/result":
+			var result JobResult
+			json.NewDecoder(r.Body).Decode(&result)
+			results = append(results, result)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	var log bytes.Buffer
+	Output = &log
+	defer func() { Output = os.Stdout }()
+	queue, err := NewJobQueue("api")
+	if err != nil {
+		t.Fatal(err)
+	}
+	worker := &Worker{Name: "ci-1", Queue: queue, Interval: time.Millisecond, Once: true, Executable: exe}
+	if err := worker.Run(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(results) != 1 {
+		t.Fatalf("Expected the result of the job to be reported, got %v\n%s", results, log.String())
+	}
+	result := results[0]
+	if result.Runner != "ci-1" || result.ExitCode != 1 || result.Error != "" {
+		t.Errorf("Unexpected result: %+v", result)
+	}
+	var summary map[string]string
+	if err := json.Unmarshal(result.Summary, &summary); err != nil {
+		t.Fatal(err)
+	}
+	if summary["args"] != "--json --non-interactive autoupdate run --project blah -- rake -v" || summary["files"] != "Gemfile" {
+		t.Errorf("Unexpected run of the job: %v", summary)
+	}
+	if !strings.Contains(log.String(), "Job 42 done (exit status 1)") {
+		t.Errorf("Unexpected log:\n%s", log.String())
+	}
+}
+
+func TestNewJobQueue(t *testing.T) {
+	if q, err := NewJobQueue("https://sqs.eu-west-1.amazonaws.com/123456789012/jobs"); err != nil || q.(sqsQueue).queue.Region != "eu-west-1" {
+		t.Errorf("Expected an SQS queue in eu-west-1, got %+v (%v)", q, err)
+	}
+	for _, spec := range []string{"amqp://localhost/jobs", "redis"} {
+		if _, err := NewJobQueue(spec); err == nil {
+			t.Errorf("%s: expected an error", spec)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package autoupdate
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+// Move the dependencies off their yanked versions, to the next release, with
+// the updaters of their package types. Dependencies without a release to move
+// to, or without updater, are skipped. All files are restored if an updater
+// fails.
+func FixYanked(yanked []models.YankedDependency) error {
+	versionUpdates := map[string][]VersionUpdate{}
+	for _, yd := range yanked {
+		if yd.FixVersion == "" {
+			fmt.Fprint(Output, i18n.T("autoupdate.yanked_no_fix", yd.Package.Name, yd.LockedVersion))
+			continue
+		}
+		// Package types are capitalized in updaters (ie: "Rubygem")
+		packageType := strings.Title(strings.ToLower(yd.Package.Type))
+		versionUpdates[packageType] = append(versionUpdates[packageType], VersionUpdate{
+			Package:       yd.Package,
+			OldVersion:    yd.LockedVersion,
+			TargetVersion: yd.FixVersion,
+		})
+	}
+	packageTypes := []string{}
+	for packageType := range versionUpdates {
+		packageTypes = append(packageTypes, packageType)
+	}
+	sort.Strings(packageTypes)
+
+	var orgDepFiles, uptDepFiles []models.DependencyFile
+	for _, packageType := range packageTypes {
+		updater, err := NewUpdater(packageType)
+		if err != nil {
+			fmt.Fprint(Output, err)
+			continue
+		}
+		for _, vu := range versionUpdates[packageType] {
+			fmt.Fprint(Output, i18n.T("autoupdate.updating_dependency", vu.Package.Name, vu.OldVersion, vu.TargetVersion))
+		}
+		if err := updater(versionUpdates[packageType], &orgDepFiles, &uptDepFiles); err != nil {
+			if rerr := restoreDepFiles(orgDepFiles); rerr != nil {
+				fmt.Fprint(Output, i18n.T("autoupdate.restore_error", rerr))
+			}
+			return err
+		}
+	}
+	fmt.Fprintln(Output, i18n.T("common.done_capitalized"))
+	return nil
+}
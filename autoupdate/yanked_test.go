@@ -0,0 +1,52 @@
+package autoupdate
+
+import (
+	"io/ioutil"
+	"os"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+func TestFixYanked(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-yanked")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	Output = ioutil.Discard
+	defer func() { Output = os.Stdout }()
+
+	ioutil.WriteFile("package.json", []byte(`{"dependencies":{"left-pad":"^1.0.0"}}`), 0644)
+	ioutil.WriteFile("package-lock.json", []byte(`{"dependencies":{"left-pad":{"version":"1.0.0"}}}`), 0644)
+	// Fake npm, writing its arguments to the lockfile
+	ioutil.WriteFile("npm.sh", []byte(`echo "$@" > package-lock.json`), 0755)
+	os.Setenv(config.ENV_GEMNASIUM_NPM_UPDATE_CMD, "sh npm.sh install")
+	defer os.Unsetenv(config.ENV_GEMNASIUM_NPM_UPDATE_CMD)
+
+	yanked := []models.YankedDependency{
+		{Package: models.Package{Name: "left-pad", Type: "npm"}, LockedVersion: "1.0.0", FixVersion: "1.1.0"},
+		{Package: models.Package{Name: "rails", Type: "rubygem"}, LockedVersion: "4.0.5"},
+	}
+	if err := FixYanked(yanked); err != nil {
+		t.Fatal(err)
+	}
+	content, _ := ioutil.ReadFile("package-lock.json")
+	if string(content) != "install left-pad@1.1.0\n" {
+		t.Errorf("Expected left-pad to be updated, got: %s", content)
+	}
+
+	// Files are restored when the update fails
+	ioutil.WriteFile("npm.sh", []byte("echo 'npm ERR! code ERESOLVE' > package-lock.json; exit 1"), 0755)
+	if err := FixYanked(yanked); err == nil {
+		t.Error("Expected an error")
+	}
+	content, _ = ioutil.ReadFile("package-lock.json")
+	if string(content) != "install left-pad@1.1.0\n" {
+		t.Errorf("Expected package-lock.json to be restored, got: %s", content)
+	}
+}
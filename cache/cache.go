@@ -0,0 +1,159 @@
+package cache
+
+/*
+Management of the local cache directory (config.CacheDir), where each feature
+stores its data in its own component subdirectory.
+*/
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+const (
+	HTTP      = "http"
+	SNAPSHOTS = "snapshots"
+	INDEX     = "index"
+	FAILED    = "failed_sets"
+	DFILES    = "dependency_files"
+	ADVISORY  = "advisories"
+)
+
+// Components stored in the cache directory, with their description
+var Components = map[string]string{
+	HTTP:      "Registries responses",
+	SNAPSHOTS: "Snapshots of project dependencies, used by digest",
+	INDEX:     "Search index of projects, dependencies and advisories",
+	FAILED:    "Update sets that failed with the current lockfiles, skipped by autoupdate",
+	DFILES:    "Dependency files of projects, by revision",
+	ADVISORY:  "Advisory database, used by offline scans",
+}
+
+var ErrCacheDisabled = fmt.Errorf("Cache is disabled (%s is empty)", config.ENV_CACHE_DIR)
+
+// Return the directory of the cache component, or an empty string if caching
+// is disabled.
+func Dir(component string) string {
+	if config.CacheDir == "" {
+		return ""
+	}
+	return filepath.Join(config.CacheDir, component)
+}
+
+// Number of files and total size of a cache component
+type Usage struct {
+	Component string
+	Files     int
+	Size      int64
+}
+
+// Return the disk usage of each component, sorted by component name
+func DiskUsage() ([]Usage, error) {
+	if config.CacheDir == "" {
+		return nil, ErrCacheDisabled
+	}
+	usages := []Usage{}
+	for component := range Components {
+		u := Usage{Component: component}
+		err := walkFiles(Dir(component), func(path string, info os.FileInfo) error {
+			u.Files += 1
+			u.Size += info.Size()
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+		usages = append(usages, u)
+	}
+	sort.Slice(usages, func(i, j int) bool { return usages[i].Component < usages[j].Component })
+	return usages, nil
+}
+
+// Display the disk usage of each component
+func Info(output io.Writer) error {
+	usages, err := DiskUsage()
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(output, i18n.T("cache.directory", config.CacheDir))
+	table := utils.NewTable(output, "Component", "Files", "Size", "Description")
+	var total int64
+	for _, u := range usages {
+		table.Append(u.Component, strconv.Itoa(u.Files), utils.HumanSize(u.Size), Components[u.Component])
+		total += u.Size
+	}
+	table.SetFooter("", "Total", utils.HumanSize(total), "")
+	return table.Render()
+}
+
+// Remove the given components from the cache, or all of them if none is given
+func Clear(components ...string) error {
+	if config.CacheDir == "" {
+		return ErrCacheDisabled
+	}
+	if len(components) == 0 {
+		for component := range Components {
+			components = append(components, component)
+		}
+	}
+	for _, component := range components {
+		if _, ok := Components[component]; !ok {
+			return fmt.Errorf("Unknown cache component: %s", component)
+		}
+		if err := os.RemoveAll(Dir(component)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Remove the cached files which haven't been modified since olderThan.
+// Return the number of files removed, and the disk space reclaimed.
+func Prune(olderThan time.Duration) (removed int, freed int64, err error) {
+	if config.CacheDir == "" {
+		return 0, 0, ErrCacheDisabled
+	}
+	for component := range Components {
+		err = walkFiles(Dir(component), func(path string, info os.FileInfo) error {
+			if time.Since(info.ModTime()) < olderThan {
+				return nil
+			}
+			if err := os.Remove(path); err != nil {
+				return err
+			}
+			removed += 1
+			freed += info.Size()
+			return nil
+		})
+		if err != nil {
+			return removed, freed, err
+		}
+	}
+	return removed, freed, nil
+}
+
+// Call fn for each regular file found in dir. Missing dirs are ignored.
+func walkFiles(dir string, fn func(path string, info os.FileInfo) error) error {
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			if os.IsNotExist(err) {
+				return nil
+			}
+			return err
+		}
+		if !info.Mode().IsRegular() {
+			return nil
+		}
+		return fn(path, info)
+	})
+	return err
+}
@@ -0,0 +1,82 @@
+package cache
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func setupCacheDir(t *testing.T) string {
+	dir, err := ioutil.TempDir("", "gemnasium-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	config.CacheDir = dir
+	if err := os.MkdirAll(Dir(HTTP), 0755); err != nil {
+		t.Fatal(err)
+	}
+	for name, age := range map[string]time.Duration{"old.json": 40 * 24 * time.Hour, "new.json": time.Hour} {
+		path := filepath.Join(Dir(HTTP), name)
+		if err := ioutil.WriteFile(path, []byte("0123456789"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		mtime := time.Now().Add(-age)
+		if err := os.Chtimes(path, mtime, mtime); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestDiskUsage(t *testing.T) {
+	dir := setupCacheDir(t)
+	defer os.RemoveAll(dir)
+
+	usages, err := DiskUsage()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != len(Components) {
+		t.Fatalf("Expected %d components, got: %#v", len(Components), usages)
+	}
+	for _, u := range usages {
+		if u.Component == HTTP && (u.Files != 2 || u.Size != 20) {
+			t.Errorf("Expected 2 files (20 B) in http cache, got: %#v", u)
+		}
+	}
+}
+
+func TestPrune(t *testing.T) {
+	dir := setupCacheDir(t)
+	defer os.RemoveAll(dir)
+
+	removed, freed, err := Prune(30 * 24 * time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if removed != 1 || freed != 10 {
+		t.Errorf("Expected 1 file (10 B) to be removed, got: %d (%d B)", removed, freed)
+	}
+	if _, err := os.Stat(filepath.Join(Dir(HTTP), "new.json")); err != nil {
+		t.Errorf("new.json should have been kept: %s", err)
+	}
+}
+
+func TestClear(t *testing.T) {
+	dir := setupCacheDir(t)
+	defer os.RemoveAll(dir)
+
+	if err := Clear("unknown"); err == nil {
+		t.Error("Clear should fail with unknown components")
+	}
+	if err := Clear(); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := os.Stat(Dir(HTTP)); !os.IsNotExist(err) {
+		t.Error("http cache should have been removed")
+	}
+}
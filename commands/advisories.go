@@ -0,0 +1,17 @@
+package commands
+
+import (
+	"fmt"
+
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/urfave/cli"
+)
+
+func AdvisoriesSync(ctx *cli.Context) error {
+	db, fetched, err := models.SyncAdvisoryDB()
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Advisory database synced: %d advisories fetched, %d in total.\n", fetched, len(db.Advisories))
+	return nil
+}
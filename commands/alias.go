@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// Global flags expecting a value
+var globalFlagsWithValue = map[string]bool{"-t": true, "--token": true, "-q": true, "--query": true}
+
+// Expand user defined aliases (see config.Aliases) in the command line args,
+// or append the default command (see config.DefaultCommand) if no command is
+// given. Aliases are expanded only once, so they can't be recursive; they take
+// precedence over the builtin commands and short names.
+func ExpandArgs(args []string) []string {
+	i := commandIndex(args)
+	if i >= len(args) {
+		expanded := append([]string{}, args...)
+		return append(expanded, strings.Fields(config.DefaultCommand)...)
+	}
+
+	expanded := append([]string{}, args[:i]...)
+	if alias, ok := config.Aliases[args[i]]; ok {
+		expanded = append(expanded, strings.Fields(alias)...)
+	} else {
+		expanded = append(expanded, args[i])
+	}
+	return append(expanded, args[i+1:]...)
+}
@@ -0,0 +1,37 @@
+package commands
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestExpandArgs(t *testing.T) {
+	config.Aliases = map[string]string{
+		"p":  "dependency_files push",
+		"au": "autoupdate run --security-only",
+	}
+	config.DefaultCommand = "deps list"
+	defer func() {
+		config.Aliases = map[string]string{}
+		config.DefaultCommand = ""
+	}()
+
+	var tt = []struct {
+		Args     []string
+		Expected []string
+	}{
+		{[]string{"gemnasium"}, []string{"gemnasium", "deps", "list"}},
+		{[]string{"gemnasium", "-t", "abc"}, []string{"gemnasium", "-t", "abc", "deps", "list"}},
+		{[]string{"gemnasium", "p", "-y"}, []string{"gemnasium", "dependency_files", "push", "-y"}},
+		{[]string{"gemnasium", "--token", "abc", "-r", "au"}, []string{"gemnasium", "--token", "abc", "-r", "autoupdate", "run", "--security-only"}},
+		{[]string{"gemnasium", "projects", "p"}, []string{"gemnasium", "projects", "p"}},
+		{[]string{"gemnasium", "-t"}, []string{"gemnasium", "-t", "deps", "list"}},
+	}
+	for _, test := range tt {
+		if args := ExpandArgs(test.Args); !reflect.DeepEqual(args, test.Expected) {
+			t.Errorf("ExpandArgs(%v): expected %v, got %v", test.Args, test.Expected, args)
+		}
+	}
+}
@@ -1,8 +1,17 @@
 package commands
 
 import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
 	"github.com/gemnasium/toolbelt/auth"
 	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/gemnasium/toolbelt/utils"
+	"github.com/heroku/hk/term"
 	"github.com/urfave/cli"
 )
 
@@ -13,6 +22,7 @@ func App() *cli.App {
 	app.Version = config.VERSION
 	app.Author = "Gemnasium"
 	app.Email = "support@gemnasium.com"
+	app.EnableBashCompletion = true
 	app.Flags = []cli.Flag{
 		cli.StringFlag{
 			Name:  "token, t",
@@ -22,9 +32,151 @@ func App() *cli.App {
 			Name:  "raw, r",
 			Usage: "Raw format output",
 		},
+		cli.StringFlag{
+			Name:  "query, q",
+			Usage: "Only display the values at this jq-like path, for list commands (ex: --query '.[0].sha')",
+		},
+		cli.StringFlag{
+			Name:  "columns",
+			Usage: "Columns of tables to display, separated with a comma (ex: --columns path,sha,size,updated_at)",
+		},
+		cli.IntFlag{
+			Name:  "max-width",
+			Usage: "Max width of table columns, longer text is wrapped (default: 30)",
+		},
+		cli.BoolFlag{
+			Name:  "no-wrap",
+			Usage: "Don't wrap text in table columns, truncate it to --max-width instead (if set)",
+		},
+		cli.BoolFlag{
+			Name:  "json",
+			Usage: "Print JSON documents instead of tables and messages (dependency_files list and push, autoupdate run, eval). See --print-schema on these commands",
+		},
+		cli.BoolFlag{
+			Name:  "read-only",
+			Usage: "Block the commands changing data (push, create, update, apply, ...)",
+		},
+		cli.BoolFlag{
+			Name:  "non-interactive",
+			Usage: "Never prompt for input, fail instead (default when stdin isn't a terminal)",
+		},
+		cli.BoolFlag{
+			Name:  "strict-deprecations",
+			Usage: "Fail when using deprecated commands or flags, instead of displaying a warning",
+		},
+		cli.BoolFlag{
+			Name:  "no-retry",
+			Usage: "Don't retry API requests failing with network errors or transient statuses (502, 503, 504)",
+		},
+		cli.StringSliceFlag{
+			Name:  "resolve",
+			Usage: "Connect to this address instead of resolving the host, like curl (host:port:addr, ex: api.gemnasium.com:443:10.0.0.1). Can be repeated",
+		},
+		cli.BoolFlag{
+			Name:  "ipv4, 4",
+			Usage: "Only connect with IPv4 addresses",
+		},
+		cli.BoolFlag{
+			Name:  "ipv6, 6",
+			Usage: "Only connect with IPv6 addresses",
+		},
+		cli.StringSliceFlag{
+			Name:  "header, H",
+			Usage: "Add this header to API requests ('Name: value'), ie: for a gateway fronting the API. Values can reference env vars. Can be repeated",
+		},
+		cli.StringFlag{
+			Name:  "api-socket",
+			Usage: "Connect to the API through this unix socket",
+		},
+		cli.StringFlag{
+			Name:  "api-timeout",
+			Usage: "Max duration of each API request attempt (ex: 30s, 5m, 0 for unlimited, default: 2m)",
+		},
+		cli.StringFlag{
+			Name:  "timeout",
+			Usage: "Max duration of the whole command (ex: 10m): a partial report is displayed, and gemnasium exits with code 124",
+		},
+		cli.BoolFlag{
+			Name:  "debug",
+			Usage: "Log diagnostics on stderr, like the fields of API responses unknown to this release",
+		},
+		cli.StringFlag{
+			Name:  "proxy",
+			Usage: "Send requests through this proxy (default: from HTTPS_PROXY, HTTP_PROXY and NO_PROXY)",
+		},
 	}
 	app.Before = func(c *cli.Context) error {
 		config.RawFormat = c.Bool("raw")
+		config.Query = c.String("query")
+		if columns := c.String("columns"); columns != "" {
+			config.Columns = strings.Split(columns, ",")
+		}
+		config.MaxColumnWidth = c.Int("max-width")
+		config.NoWrap = c.Bool("no-wrap")
+		if c.Bool("read-only") {
+			config.ReadOnly = true
+		}
+		if c.Bool("json") {
+			config.JSONOutput = true
+		}
+		if c.Bool("non-interactive") || !term.IsTerminal(os.Stdin) {
+			config.NonInteractive = true
+		}
+		if c.Bool("no-retry") {
+			config.APIRetries = 0
+		}
+		if resolve := c.StringSlice("resolve"); len(resolve) > 0 {
+			config.Resolve = resolve
+		}
+		if _, err := utils.ParseResolve(config.Resolve); err != nil {
+			return err
+		}
+		switch {
+		case c.Bool("ipv4") && c.Bool("ipv6"):
+			return errors.New("--ipv4 and --ipv6 can't be combined")
+		case c.Bool("ipv4"):
+			config.IPVersion = 4
+		case c.Bool("ipv6"):
+			config.IPVersion = 6
+		}
+		for _, header := range c.StringSlice("header") {
+			name, value, err := config.ParseHeader(header)
+			if err != nil {
+				return err
+			}
+			config.APIHeaders[name] = value
+		}
+		if socket := c.String("api-socket"); socket != "" {
+			config.APISocket = socket
+		}
+		if timeout := c.String("api-timeout"); timeout != "" {
+			d, err := time.ParseDuration(timeout)
+			if err != nil {
+				return fmt.Errorf("Invalid --api-timeout: %s", err)
+			}
+			config.APITimeout = d
+		}
+		if timeout := c.String("timeout"); timeout != "" {
+			d, err := time.ParseDuration(timeout)
+			if err != nil {
+				return fmt.Errorf("Invalid --timeout: %s", err)
+			}
+			config.CommandTimeout = d
+		}
+		if config.CommandTimeout > 0 {
+			startWatchdog(config.CommandTimeout)
+		}
+		if c.Bool("debug") {
+			config.Debug = true
+		}
+		if proxy := c.String("proxy"); proxy != "" {
+			config.Proxy = proxy
+		}
+		if config.VCS != "" {
+			if _, err := models.LookupVCS(config.VCS); err != nil {
+				return err
+			}
+		}
 		return nil
 	}
 	app.Commands = []cli.Command{
@@ -92,18 +244,44 @@ func App() *cli.App {
 						},
 					},
 					Usage:  "Edit project details",
-					Action: ProjectsUpdate,
+					Action: mutating("projects update", ProjectsUpdate),
 				},
 				{
 					Name:      "create",
 					ShortName: "c",
 					Usage:     "Create a new project on Gemnasium",
-					Action:    ProjectsCreate,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "desc, d",
+							Usage: "Project description (prompted for otherwise)",
+						},
+					},
+					Action: mutating("projects create", ProjectsCreate),
 				},
 				{
 					Name:   "sync",
 					Usage:  "Start project synchronization",
-					Action: ProjectsSync,
+					Action: mutating("projects sync", ProjectsSync),
+				},
+				{
+					Name:  "label",
+					Usage: "Manage project labels (ex: team=payments), used to filter org commands",
+					Subcommands: []cli.Command{
+						{
+							Name:         "add",
+							Usage:        "Add labels to the project. Usage: gemnasium projects label add team=payments [tier=1 ...]",
+							Flags:        []cli.Flag{projectFlag},
+							Action:       mutating("projects label add", ProjectsLabelAdd),
+							BashComplete: completeFlags,
+						},
+						{
+							Name:         "remove",
+							Usage:        "Remove labels from the project. Usage: gemnasium projects label remove team [tier ...]",
+							Flags:        []cli.Flag{projectFlag},
+							Action:       mutating("projects label remove", ProjectsLabelRemove),
+							BashComplete: completeFlags,
+						},
+					},
 				},
 			},
 		},
@@ -117,10 +295,49 @@ func App() *cli.App {
 			},
 			Subcommands: []cli.Command{
 				{
-					Name:      "list",
-					ShortName: "l",
-					Usage:     "List the first level dependencies of the requested project. Usage: gemnasium deps list [project_slug]",
-					Action:    DependenciesList,
+					Name:         "list",
+					ShortName:    "l",
+					Usage:        "List the first level dependencies of the requested project. Usage: gemnasium deps list [project_slug]",
+					Action:       DependenciesList,
+					BashComplete: completeProjectArgs,
+				},
+				{
+					Name:         "outdated",
+					ShortName:    "o",
+					Usage:        "List the dependencies not locked to their latest release, with maintenance signals (last release, open-issue trend, deprecation). Usage: gemnasium deps outdated [project_slug]",
+					Action:       DependenciesOutdated,
+					BashComplete: completeProjectArgs,
+				},
+				{
+					Name:         "deprecated",
+					Usage:        "List the dependencies flagged as deprecated, abandoned or yanked by their registry, with suggested replacements. Usage: gemnasium deps deprecated [project_slug]",
+					Action:       DependenciesDeprecated,
+					BashComplete: completeProjectArgs,
+				},
+				{
+					Name:  "yanked",
+					Usage: "List the dependencies locked to versions yanked from their registry, and the releases to move to. Usage: gemnasium deps yanked [project_slug]",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "fix",
+							Usage: "Update the local lockfiles to move the dependencies to the next release, with the autoupdate updaters",
+						},
+					},
+					Action:       DependenciesYanked,
+					BashComplete: completeProjectArgs,
+				},
+				{
+					Name:  "graph",
+					Usage: "Export the dependency graph, with vulnerable dependencies highlighted. Usage: gemnasium deps graph [project_slug] | dot -Tsvg > deps.svg",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "format, f",
+							Value: "dot",
+							Usage: "Output format (dot or graphml)",
+						},
+					},
+					Action:       DependenciesGraph,
+					BashComplete: completeProjectArgs,
 				},
 			},
 		},
@@ -137,7 +354,26 @@ func App() *cli.App {
 					Name:      "list",
 					ShortName: "l",
 					Usage:     "List dependency files for project",
-					Action:    DependencyFilesList,
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "no-cache",
+							Usage: "Always fetch the dependency files, even if they're cached for the current revision",
+						},
+						cli.StringFlag{
+							Name:  "sort",
+							Usage: "Sort files by path, sha or updated (most recent first)",
+						},
+						cli.StringFlag{
+							Name:  "filter",
+							Usage: "Only list files matching this glob pattern or substring (ex: 'package.json', 'apps/*/Gemfile.lock')",
+						},
+						cli.StringFlag{
+							Name:  "ecosystem",
+							Usage: "Only list files of this ecosystem: ruby, npm, python, php, bower, go, cargo, maven, gradle or nuget",
+						},
+						printSchemaFlag,
+					},
+					Action: withSchema("dependency_files.list", DependencyFilesList),
 				},
 				{
 					Name:      "push",
@@ -148,9 +384,134 @@ func App() *cli.App {
 							Name:  "files, f",
 							Usage: "list of files to send, separated with a comma.",
 						},
+						cli.BoolFlag{
+							Name:  "yes, y",
+							Usage: "Don't ask for confirmation when the payload is bigger than GEMNASIUM_MAX_PAYLOAD_SIZE",
+						},
+						cli.StringFlag{
+							Name:  "fail-on",
+							Usage: "Exit with an error if files are in these states, separated with a comma: added, updated, unchanged, unsupported, removed, errors (parse errors) or warnings",
+						},
+						cli.BoolFlag{
+							Name:  "submodules",
+							Usage: "Push the files of git submodules to their own projects (project_slug of their .gemnasium.yml, or the path of their remote)",
+						},
+						cli.BoolFlag{
+							Name:  "prune",
+							Usage: "Mark the files known by Gemnasium but no longer present locally as removed",
+						},
+						cli.BoolFlag{
+							Name:  "fail-on-change",
+							Usage: "Exit with an error if files have been added or updated (same as --fail-on added,updated)",
+						},
+						cli.BoolFlag{
+							Name:  "incremental",
+							Usage: "Send paths and SHAs first, and only the content of the files Gemnasium doesn't have yet (or GEMNASIUM_INCREMENTAL_PUSH)",
+						},
+						printSchemaFlag,
 					},
 					Description: "Send files to Gemnasium. If --files is not set, all dependency files supported by Gemnasium found in the current path will be sent to Gemnasium API. You can ignore paths with GEMNASIUM_IGNORED_PATHS",
-					Action:      DependenciesPush,
+					Action:      withSchema("dependency_files.push", mutating("dependency_files push", DependenciesPush)),
+				},
+				{
+					Name:  "restore",
+					Usage: "Restore a dependency file with the last content known by Gemnasium. Usage: gemnasium df restore Gemfile.lock",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "yes, y",
+							Usage: "Don't ask for confirmation before overwriting the local file",
+						},
+					},
+					Action: mutating("dependency_files restore", DependencyFilesRestore),
+				},
+			},
+		},
+		{
+			Name:  "scan",
+			Usage: "Look for dependency files in the current path, without sending them",
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "emit-manifest",
+					Usage: "Write every file considered, whether it matched, the rule which excluded it and its SHA to this json file",
+				},
+				cli.StringFlag{
+					Name:  "emit-spdx",
+					Usage: "Write an SPDX 2.3 document (json) of the packages resolved in the lockfiles to this file",
+				},
+				cli.StringFlag{
+					Name:  "emit-sarif",
+					Usage: "With --offline, write the vulnerable packages to this file as a SARIF 2.1.0 log, for GitHub code scanning",
+				},
+				cli.BoolFlag{
+					Name:  "offline",
+					Usage: "Match the packages resolved in the lockfiles against the local advisory database (see 'advisories sync'), without calling the API",
+				},
+				cli.StringFlag{
+					Name:  "fail-on",
+					Usage: "With --offline, only exit with an error for advisories at or above this severity: low, medium, high or critical",
+				},
+			},
+			Action: Scan,
+		},
+		{
+			Name:  "webhooks",
+			Usage: "Webhooks sent by Gemnasium",
+			Subcommands: []cli.Command{
+				{
+					Name:  "simulate",
+					Usage: "Post the payload of a webhook event about a made up advisory, to test webhook handlers",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "event",
+							Value: models.WEBHOOK_EVENT_NEW_ADVISORY,
+							Usage: "Event to simulate: " + strings.Join(models.WebhookEvents, ", "),
+						},
+						cli.StringFlag{
+							Name:  "package",
+							Usage: "Package affected by the advisory",
+						},
+						cli.StringFlag{
+							Name:  "type",
+							Value: "Rubygem",
+							Usage: "Type of the package (ie: Rubygem, Npm, Packagist)",
+						},
+						cli.StringFlag{
+							Name:  "project",
+							Usage: "Project of alert events (default: the current project)",
+						},
+						cli.StringFlag{
+							Name:  "url",
+							Usage: "Endpoint the payload is posted to (default: GEMNASIUM_WEBHOOK_URL, or http://localhost:8080/webhooks)",
+						},
+						cli.BoolFlag{
+							Name:  "print",
+							Usage: "Print the payload instead of posting it (ie: to save it as a test fixture)",
+						},
+					},
+					Description: "Payloads are signed with GEMNASIUM_WEBHOOK_SECRET, in the X-Gemnasium-Signature header (sha256=<HMAC-SHA256 of the body>)",
+					Action:      WebhooksSimulate,
+				},
+			},
+		},
+		{
+			Name:  "sbom",
+			Usage: "Software Bill of Materials",
+			Subcommands: []cli.Command{
+				{
+					Name:  "push",
+					Usage: "Attach an SPDX document of the packages resolved in the lockfiles to a container image, as an OCI referrer",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "registry",
+							Usage: "Image the SBOM is attached to (ie: ghcr.io/org/app:tag or ghcr.io/org/app@sha256:...)",
+						},
+						cli.StringSliceFlag{
+							Name:  "attach",
+							Usage: "Attach this document too (.spdx.json, .cdx.json, .openvex.json, .vex.json or .sarif)",
+						},
+					},
+					Description: "Registry credentials are read from GEMNASIUM_OCI_USERNAME and GEMNASIUM_OCI_PASSWORD",
+					Action:      mutating("sbom push", SBOMPush),
 				},
 			},
 		},
@@ -167,21 +528,244 @@ func App() *cli.App {
 					Name:      "list",
 					ShortName: "l",
 					Usage:     "List the dependency alerts the given project is affected by",
-					Action:    DependencyAlertsList,
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "fail-on",
+							Usage: "Exit with an error if open alerts have advisories at or above this severity: low, medium, high or critical",
+						},
+						cli.StringFlag{
+							Name:  "emit-sarif",
+							Usage: "Write the open alerts to this file as a SARIF 2.1.0 log, located in the dependency files of the current path",
+						},
+						cli.StringFlag{
+							Name:  "owner",
+							Usage: "Only list the alerts of the packages owned by this team (see .gemnasium-owners)",
+						},
+					},
+					Action: DependencyAlertsList,
+				},
+			},
+		},
+		{
+			Name:   "report",
+			Usage:  "Reports",
+			Before: auth.AttemptLogin,
+			Subcommands: []cli.Command{
+				{
+					Name:  "freshness",
+					Usage: "Display how far the dependencies are lagging behind their latest release (in libyears). Usage: gemnasium report freshness [project_slug]",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "format, f",
+							Value: "table",
+							Usage: "Output format (table or json)",
+						},
+						cli.BoolFlag{
+							Name:  "push",
+							Usage: "Push the report to Gemnasium for trend tracking",
+						},
+						printSchemaFlag,
+					},
+					Action:       withSchema("report.freshness", ReportFreshness),
+					BashComplete: completeProjectArgs,
+				},
+				{
+					Name:  "risk",
+					Usage: "Display the risk score of the dependencies (0-10), combining CVSS, EPSS, freshness, maintenance and reachability. Usage: gemnasium report risk [project_slug]",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "format, f",
+							Value: "table",
+							Usage: "Output format (table or json)",
+						},
+						printSchemaFlag,
+					},
+					Action:       withSchema("report.risk", ReportRisk),
+					BashComplete: completeProjectArgs,
+				},
+			},
+		},
+		{
+			Name:   "check",
+			Usage:  "Fail if a dependency has a risk score above the threshold (see 'report risk'). Usage: gemnasium check --max-risk 7.5 [project_slug]",
+			Before: auth.AttemptLogin,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "max-risk",
+					Value: "7.5",
+					Usage: "Highest risk score allowed (0-10)",
+				},
+			},
+			Action:       Check,
+			BashComplete: completeProjectArgs,
+		},
+		{
+			Name:   "digest",
+			Usage:  "Display the changes (new and resolved alerts, dependency changes) of projects, to be sent by email or posted to a chat. Usage: gemnasium digest [--since 7d] [--format html] [project_slug...]",
+			Before: auth.AttemptLogin,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "since, s",
+					Value: "7d",
+					Usage: "Period covered by the digest (ex: 7d, 24h)",
+				},
+				cli.StringFlag{
+					Name:  "format, f",
+					Value: "text",
+					Usage: "Output format (text or html)",
+				},
+				cli.StringFlag{
+					Name:  "owner, o",
+					Usage: "Include the projects shared by this owner (\"owned\" for your own projects)",
+				},
+				orgLabelFlag,
+			},
+			Action:       Digest,
+			BashComplete: completeProjectArgs,
+		},
+		{
+			Name:   "tui",
+			Usage:  "Browse the open alerts and outdated dependencies of projects in a keyboard driven dashboard. Usage: gemnasium tui [--owner my-org] [project_slug...]",
+			Before: auth.AttemptLogin,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "owner, o",
+					Usage: "Include the projects shared by this owner (\"owned\" for your own projects)",
+				},
+				orgLabelFlag,
+			},
+			Action:       TUI,
+			BashComplete: completeProjectArgs,
+		},
+		{
+			Name:   "feed",
+			Usage:  "Generate an RSS or Atom feed of advisory events (alerts opened and resolved). Usage: gemnasium feed --format rss --out feed.xml [project_slug...]",
+			Before: auth.AttemptLogin,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "format, f",
+					Value: "rss",
+					Usage: "Feed format (rss or atom)",
+				},
+				cli.StringFlag{
+					Name:  "out",
+					Usage: "Write the feed to this file (default: stdout)",
+				},
+				cli.StringFlag{
+					Name:  "owner, o",
+					Usage: "Include the projects shared by this owner (\"owned\" for your own projects)",
+				},
+				orgLabelFlag,
+			},
+			Action:       Feed,
+			BashComplete: completeProjectArgs,
+		},
+		{
+			Name:   "advisories",
+			Usage:  "Local advisory database",
+			Before: auth.AttemptLogin,
+			Subcommands: []cli.Command{
+				{
+					Name:   "sync",
+					Usage:  "Download the advisories published since the last sync, for offline scans (scan --offline)",
+					Action: AdvisoriesSync,
+				},
+			},
+		},
+		{
+			Name:   "search",
+			Usage:  "Fuzzy search projects, dependencies and advisories, using a local index. Usage: gemnasium search <term>",
+			Before: auth.AttemptLogin,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "reindex",
+					Usage: "Rebuild the search index now",
+				},
+			},
+			Action: Search,
+		},
+		{
+			Name:   "org",
+			Usage:  "Organization wide operations, across all monitored projects",
+			Before: auth.AttemptLogin,
+			Subcommands: []cli.Command{
+				{
+					Name:  "deps",
+					Usage: "Report which projects use a package, and at which versions. Usage: gemnasium org deps --package lodash",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "package, p",
+							Usage: "Name of the package",
+						},
+						cli.StringFlag{
+							Name:  "type, t",
+							Usage: "Type of the package (rubygem, npm, packagist, ...)",
+						},
+						cli.StringFlag{
+							Name:  "owner, o",
+							Usage: "Only search the projects shared by this owner (\"owned\" for your own projects)",
+						},
+						orgLabelFlag,
+						orgConcurrencyFlag,
+						orgQPSFlag,
+						orgFailFastFlag,
+						orgKeepGoingFlag,
+					},
+					Action:       OrgDependencies,
+					BashComplete: completeFlags,
+				},
+				{
+					Name:  "remediate",
+					Usage: "Update a vulnerable package in the projects with an open alert about an advisory. Usage: gemnasium org remediate --advisory CVE-2021-44228 --open-prs",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "advisory, a",
+							Usage: "Identifier (ie: CVE-2021-44228) or ID of the advisory",
+						},
+						cli.BoolFlag{
+							Name:  "open-prs",
+							Usage: "Clone the affected projects, update the package and open pull requests (only the plan is printed otherwise)",
+						},
+						cli.StringFlag{
+							Name:  "clone-url",
+							Usage: "URL of the repositories of projects without a repository label, where {slug} and {name} are replaced (ie: git@github.com:acme/{name}.git)",
+						},
+						cli.StringFlag{
+							Name:  "owner, o",
+							Usage: "Only search the projects shared by this owner (\"owned\" for your own projects)",
+						},
+						orgLabelFlag,
+						orgConcurrencyFlag,
+						orgQPSFlag,
+						orgFailFastFlag,
+						orgKeepGoingFlag,
+					},
+					Action:       OrgRemediate,
+					BashComplete: completeFlags,
 				},
 			},
 		},
 		{
 			Name:      "eval",
 			ShortName: "e",
-			Usage:     "Live deps evaluation",
+			Usage:     "Live deps evaluation. Usage: gemnasium eval [file...]",
+			Description: `Evaluate dependency files, found in the current directory if none are given.
+   Files of several ecosystems (ie: Gemfile.lock and package.json) are evaluated by ecosystem, and
+   combined in a single report, with the statuses of each ecosystem.
+
+   The exit code is the worst runtime status: 0 (green), 1 (yellow) or 2 (red).`,
 			Flags: []cli.Flag{
 				cli.StringFlag{
 					Name:  "files, f",
 					Usage: "list of files to evaluate, separated with a comma.",
 				},
+				cli.StringFlag{
+					Name:  "as-of",
+					Usage: "Only consider the advisories published until this date (YYYY-MM-DD), ie: to find out when a vulnerability became known",
+				},
+				printSchemaFlag,
 			},
-			Action: LiveEvaluation,
+			Action: withSchema("eval", LiveEvaluation),
 		},
 		{
 			Name:      "autoupdate",
@@ -198,9 +782,31 @@ func App() *cli.App {
 							Name:  "project, p",
 							Usage: "Project slug (identifier on Gemnasium)",
 						},
+						cli.BoolFlag{
+							Name:  "dry-run",
+							Usage: "Show the packages that would be updated and the commands that would be run, without changing files, running tests or sending results to Gemnasium",
+						},
+						cli.StringFlag{
+							Name:  "patch-out",
+							Usage: "Write the changes of the dependency files of successful update sets to this file (unified diff), to apply them with git apply",
+						},
+						cli.BoolFlag{
+							Name:  "git-branch",
+							Usage: "Commit the dependency files of successful update sets on a branch (gemnasium/update-<package>)",
+						},
+						cli.BoolFlag{
+							Name:  "git-push",
+							Usage: "Commit successful update sets on a branch, and push it to origin",
+						},
+						cli.BoolFlag{
+							Name:  "pull-request",
+							Usage: "Push the branches of successful update sets, and open a pull request for each one on GitHub (token: GEMNASIUM_GITHUB_TOKEN)",
+						},
+						printSchemaFlag,
 					},
 					Description: `Auto-Update will fetch update sets from Gemnasium and run your test suite against them.
    The test suite can be passed as arguments, or through the env var GEMNASIUM_TESTSUITE.
+   With --dry-run, the update sets are only described: the packages to update, and the commands that would be run (bundle update, npm install, ...).
 
    Arguments:
 
@@ -212,6 +818,21 @@ func App() *cli.App {
    - GEMNASIUM_TESTSUITE: will be run for each iteration over update sets. This is typically your test suite script.
    - GEMNASIUM_BUNDLE_INSTALL_CMD: [Ruby Only] during each iteration, the new bundle will be installed. Default: "bundle install"
    - GEMNASIUM_BUNDLE_UPDATE_CMD: [Ruby Only] during each iteration, some gems might be updated. This command will be used. Default: "bundle update"
+   - GEMNASIUM_NPM_UPDATE_CMD: [npm Only] command used to install the target versions, the packages are appended (<package>@<version>). Default: "npm install"
+   - GEMNASIUM_GEM_INSTALL_CMD: [Ruby Only] command used to install bundler when an update set updates it, "bundler -v <version>" is appended. Default: "gem install"
+   - GEMNASIUM_NPM_UPGRADE_CMD: [npm Only] command used to upgrade npm when an update set updates it, "npm@<version>" is appended. Default: "npm install -g"
+   - GEMNASIUM_GO_GET_CMD: [Go Only] command used to get the target versions, the modules are appended (<module>@<version>), then "go mod tidy" is run. Default: "go get"
+   - GEMNASIUM_CARGO_UPDATE_CMD: [Rust Only] command used to lock the target versions, "-p <crate> --precise <version>" is appended. Default: "cargo update"
+   - GEMNASIUM_MVN_UPDATE_CMD: [Maven Only] command used to set the target versions in pom.xml, "-Dincludes=<groupId:artifactId> -DdepVersion=<version>" is appended. Default: "mvn versions:use-dep-version"
+   - GEMNASIUM_DOTNET_ADD_CMD: [NuGet Only] command used to install the target versions, "<package> --version <version>" is appended. Default: "dotnet add package"
+   - GEMNASIUM_VERIFY_VERSIONS: check target versions on the official registries (existence, yanked, signatures) before applying update sets.
+   - GEMNASIUM_MIN_RELEASE_AGE: defer update sets targeting versions released within this period (ex: "7d").
+   - GEMNASIUM_SIMULATE: check that update sets can be resolved before running the package managers.
+   - GEMNASIUM_TEST_RETRIES: run a failing test suite again, up to this number of times, before marking the update set as failed.
+   - GEMNASIUM_CLEAN_ENV: run update and test commands with a clean environment (see env, unset_env and pass_env in .gemnasium.yml).
+   - GEMNASIUM_PATCH_FALLBACK: use the patch command when a patch can't be applied natively.
+   - GEMNASIUM_ARTIFACTS: upload the logs and diff of failed update sets to Gemnasium ("api") or to an S3 bucket ("s3", see GEMNASIUM_S3_BUCKET).
+   - GEMNASIUM_SMTP_HOST: email a summary of the run (see GEMNASIUM_SMTP_TO, and the smtp section of .gemnasium.yml).
    - BRANCH: Current branch can be specified with this var, if the git command fails to run (git rev-parse --abbrev-ref HEAD).
    - REVISION: Current revision can be specified with this var, if the git command fails to run (git rev-parse --abbrev-ref HEAD)
 
@@ -221,7 +842,8 @@ func App() *cli.App {
    - cat script.sh | gemnasium autoupdate -p=your_project_slug
    - gemnasium autoupdate my_project_slug bundle exec rake
   `,
-					Action: AutoUpdateRun,
+					Action:       withSchema("autoupdate.run", AutoUpdateRun),
+					BashComplete: completeFlags,
 				},
 				{
 					Name:      "apply",
@@ -232,17 +854,223 @@ func App() *cli.App {
 							Name:  "project, p",
 							Usage: "Project slug (identifier on Gemnasium)",
 						},
+						cli.BoolFlag{
+							Name:  "latest",
+							Usage: "Apply the most recent patch set validated on the current branch",
+						},
+					},
+					Description: `Update the dependency files to match the best update that has been found so far.
+   With --latest, the most recent patch set validated on the current branch is downloaded and applied instead, without running the update sets again.
+   It's refused if the dependency files changed since the revision it was validated on. Shallow clones are deepened by up to GEMNASIUM_DEEPEN_BUDGET commits (default: 0) to find this revision.`,
+					Action:       mutating("autoupdate apply", AutoUpdateApply),
+					BashComplete: completeFlags,
+				},
+				{
+					Name:  "refresh",
+					Usage: "Rebase the branches of update sets onto the default branch, and force push them",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "base",
+							Usage: "Branch to rebase onto (default: the default branch of origin)",
+						},
+						cli.BoolFlag{
+							Name:  "no-push",
+							Usage: "Only refresh the local branches, without pushing them",
+						},
 					},
-					Description: `Update the dependency files to match the best update that has been found so far.`,
-					Action:      AutoUpdateApply,
+					Description: `The gemnasium/* branches created by "autoupdate run --git-branch", locally or on origin, are rebased onto the default branch, then force pushed to origin (with lease).
+   When a rebase conflicts (ie: the lockfile changed on the default branch), the branch is reset to the default branch and the versions listed in its commit messages are updated again, in a single commit.
+   The test suite, passed as arguments or through GEMNASIUM_TESTSUITE, is then run: the branch is left as it was if it fails. Requirements patched by the update sets (ie: gemspecs) aren't updated again.`,
+					Action:       mutating("autoupdate refresh", AutoUpdateRefresh),
+					BashComplete: completeFlags,
+				},
+				{
+					Name:  "worker",
+					Usage: "Take autoupdate jobs from a queue and run them, reporting their results",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "name",
+							Usage: "Name of the worker, jobs targeting other workers are left to them (default: GEMNASIUM_WORKER_NAME, or the hostname)",
+						},
+						cli.StringFlag{
+							Name:  "queue",
+							Usage: "Where jobs are taken from: api, or the URL of an SQS queue (default: GEMNASIUM_WORKER_QUEUE, or api)",
+						},
+						cli.StringFlag{
+							Name:  "interval",
+							Usage: "Delay between polls when there's no job (default: 30s)",
+						},
+						cli.BoolFlag{
+							Name:  "once",
+							Usage: "Stop once there's no pending job",
+						},
+					},
+					Description: `Turn the runner into an autoupdate worker: jobs are taken from the API, or from an SQS queue, and each one is run in a sandbox.
+   The repository of the job (the current one if it has none) is cloned in a temporary directory, checked out at the revision of the job, and "gemnasium autoupdate run" is run there with the test suite of the job.
+   Its summary and exit status are reported to Gemnasium, then the sandbox is removed. Jobs targeting another worker (by name) are left to it.
+
+   SQS messages are jobs as JSON documents: {"id": "42", "project_slug": "...", "repository": "...", "revision": "...", "test_suite": ["bundle", "exec", "rake"], "runner": "..."}.
+   AMQP queues aren't supported.`,
+					Action:       mutating("autoupdate worker", AutoUpdateWorker),
+					BashComplete: completeFlags,
 				},
 			},
 		},
+		{
+			Name:  "daemon",
+			Usage: "Run the autoupdate worker as a system service",
+			Subcommands: []cli.Command{
+				{
+					Name:  "install",
+					Usage: "Install and start the service running 'gemnasium autoupdate worker'",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "systemd",
+							Usage: "Install a systemd unit (default on Linux)",
+						},
+						cli.BoolFlag{
+							Name:  "launchd",
+							Usage: "Install a launchd daemon (default on macOS)",
+						},
+						cli.StringFlag{
+							Name:  "user",
+							Usage: "User running the worker, created if needed with systemd (default: gemnasium)",
+						},
+						cli.StringFlag{
+							Name:  "working-dir",
+							Usage: "Directory the worker runs in, the repository of jobs without one (default: current directory)",
+						},
+						cli.StringFlag{
+							Name:  "name",
+							Usage: "Name of the worker (see autoupdate worker --name)",
+						},
+						cli.StringFlag{
+							Name:  "queue",
+							Usage: "Where jobs are taken from (see autoupdate worker --queue)",
+						},
+						cli.StringFlag{
+							Name:  "interval",
+							Usage: "Delay between polls when there's no job (see autoupdate worker --interval)",
+						},
+						cli.BoolFlag{
+							Name:  "print",
+							Usage: "Print the unit (or plist) instead of installing it",
+						},
+					},
+					Description: `The GEMNASIUM_* and AWS_* env vars, and the API token, are written to /etc/gemnasium/gemnasium-worker.env (readable by root only), or to the plist with launchd.
+   The systemd unit is hardened: the worker can only write to its working directory, its home (/var/lib/gemnasium-worker) and a private /tmp.
+   Must be run as root. Windows services aren't supported: run 'gemnasium autoupdate worker' from a scheduled task instead.`,
+					Action:       mutating("daemon install", DaemonInstall),
+					BashComplete: completeFlags,
+				},
+				{
+					Name:  "uninstall",
+					Usage: "Stop the service and remove its files (its user is kept)",
+					Flags: []cli.Flag{
+						cli.BoolFlag{
+							Name:  "systemd",
+							Usage: "Uninstall the systemd unit (default on Linux)",
+						},
+						cli.BoolFlag{
+							Name:  "launchd",
+							Usage: "Uninstall the launchd daemon (default on macOS)",
+						},
+					},
+					Action:       mutating("daemon uninstall", DaemonUninstall),
+					BashComplete: completeFlags,
+				},
+			},
+		},
+		{
+			Name:  "cache",
+			Usage: "Manage the local cache",
+			Subcommands: []cli.Command{
+				{
+					Name:   "info",
+					Usage:  "Display the disk space used by each cache component",
+					Action: CacheInfo,
+				},
+				{
+					Name:   "clear",
+					Usage:  "Remove the given cache components, or the whole cache. Usage: gemnasium cache clear [component...]",
+					Action: CacheClear,
+				},
+				{
+					Name:  "prune",
+					Usage: "Remove old cached files",
+					Flags: []cli.Flag{
+						cli.StringFlag{
+							Name:  "older-than",
+							Value: "30d",
+							Usage: "Remove files not modified since this duration (ex: 30d, 12h)",
+						},
+					},
+					Action: CachePrune,
+				},
+			},
+		},
+		{
+			Name:  "version",
+			Usage: "Display the version and build info",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "check",
+					Usage: "Check that this version is supported by the API",
+				},
+			},
+			Action: Version,
+		},
+		{
+			Name:  "demo",
+			Usage: "Run the main commands against fixture projects and a fake API, without credentials or network access. Usage: gemnasium demo [step...]",
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "list",
+					Usage: "List the steps of the demo",
+				},
+			},
+			Action: Demo,
+		},
 		{
 			Name:   "env",
 			Usage:  "Display ENV vars used by gemnasium",
 			Action: DisplayEnvVars,
 		},
+		{
+			Name:        "completion",
+			Usage:       "Print the shell completion script. Usage: source <(gemnasium completion bash)",
+			Description: "Completes commands and flags, and project slugs (--project, project_slug arguments) and package names (--package) from the local search index (see gemnasium search). The index is refreshed in the background when outdated.\n\n   Arguments: bash or zsh.",
+			Action:      Completion,
+		},
 	}
 	return app
 }
+
+var projectFlag = cli.StringFlag{
+	Name:  "project, p",
+	Usage: "Project slug (default: current project)",
+}
+
+// Flags shared by org commands
+var (
+	orgLabelFlag = cli.StringSliceFlag{
+		Name:  "label, l",
+		Usage: "Only process projects with this label (ex: team=payments, or just a key). Can be repeated",
+	}
+	orgConcurrencyFlag = cli.IntFlag{
+		Name:  "concurrency, c",
+		Usage: "Number of projects processed concurrently (default: 4)",
+	}
+	orgQPSFlag = cli.Float64Flag{
+		Name:  "qps",
+		Usage: "Max number of API requests per second (default: unlimited)",
+	}
+	orgFailFastFlag = cli.BoolFlag{
+		Name:  "fail-fast",
+		Usage: "Stop at the first project failing",
+	}
+	orgKeepGoingFlag = cli.BoolTFlag{
+		Name:  "keep-going",
+		Usage: "Process all projects, even if some fail, and report failures at the end (default)",
+	}
+)
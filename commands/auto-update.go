@@ -1,21 +1,41 @@
 package commands
 
 import (
+	"fmt"
+	"os"
+	"time"
+
 	"github.com/gemnasium/toolbelt/auth"
 	"github.com/gemnasium/toolbelt/autoupdate"
+	"github.com/gemnasium/toolbelt/config"
 	"github.com/gemnasium/toolbelt/models"
 	"github.com/urfave/cli"
 )
 
 var auRunFunc = func(projectSlug string, args []string) error {
-	return autoupdate.Run(projectSlug, args)
+	ctx, stop := interruptContext()
+	defer stop()
+	return autoupdate.Run(ctx, projectSlug, args)
 }
 
 var auApplyFunc = func(projectSlug string, args []string) error {
 	return autoupdate.Apply(projectSlug, args)
 }
 
+var auApplyLatestFunc = func(projectSlug string) error {
+	return autoupdate.ApplyLatest(projectSlug)
+}
+
 func AutoUpdateRun(ctx *cli.Context) error {
+	if ctx.Bool("dry-run") {
+		config.DryRun = true
+	} else if err := checkWritable("autoupdate run"); err != nil {
+		return err
+	}
+	config.PatchOut = ctx.String("patch-out")
+	config.GitBranch = ctx.Bool("git-branch")
+	config.GitPush = ctx.Bool("git-push") || ctx.Bool("pull-request")
+	config.PullRequest = ctx.Bool("pull-request")
 	auth.AttemptLogin(ctx)
 	project, err := models.GetProject(ctx.String("project"))
 	if err != nil {
@@ -31,6 +51,93 @@ func AutoUpdateApply(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
+	if ctx.Bool("latest") {
+		return auApplyLatestFunc(project.Slug)
+	}
 	err = auApplyFunc(project.Slug, ctx.Args())
 	return err
 }
+
+func AutoUpdateRefresh(ctx *cli.Context) error {
+	return autoupdate.Refresh(ctx.String("base"), ctx.Args(), !ctx.Bool("no-push"))
+}
+
+func AutoUpdateWorker(ctx *cli.Context) error {
+	if name := ctx.String("name"); name != "" {
+		config.WorkerName = name
+	}
+	if queue := ctx.String("queue"); queue != "" {
+		config.WorkerQueue = queue
+	}
+	queue, err := autoupdate.NewJobQueue(config.WorkerQueue)
+	if err != nil {
+		return err
+	}
+	interval := autoupdate.DEFAULT_WORKER_INTERVAL
+	if s := ctx.String("interval"); s != "" {
+		if interval, err = time.ParseDuration(s); err != nil {
+			return fmt.Errorf("Invalid --interval: %s", err)
+		}
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	worker := &autoupdate.Worker{Name: config.WorkerName, Queue: queue, Interval: interval, Once: ctx.Bool("once"), Executable: exe}
+	runCtx, stop := interruptContext()
+	defer stop()
+	return worker.Run(runCtx)
+}
+
+// Service running the worker, from the flags of daemon install/uninstall
+func workerService(ctx *cli.Context) (*autoupdate.Service, error) {
+	service := &autoupdate.Service{Manager: autoupdate.DefaultServiceManager(), User: ctx.String("user"), WorkingDir: ctx.String("working-dir")}
+	if ctx.Bool("systemd") {
+		service.Manager = autoupdate.SERVICE_SYSTEMD
+	} else if ctx.Bool("launchd") {
+		service.Manager = autoupdate.SERVICE_LAUNCHD
+	}
+	if service.User == "" {
+		service.User = autoupdate.DEFAULT_SERVICE_USER
+	}
+	var err error
+	if service.WorkingDir == "" {
+		if service.WorkingDir, err = os.Getwd(); err != nil {
+			return nil, err
+		}
+	}
+	if service.Executable, err = os.Executable(); err != nil {
+		return nil, err
+	}
+	for _, flag := range []string{"name", "queue", "interval"} {
+		if value := ctx.String(flag); value != "" {
+			service.Args = append(service.Args, "--"+flag, value)
+		}
+	}
+	return service, nil
+}
+
+func DaemonInstall(ctx *cli.Context) error {
+	auth.AttemptLogin(ctx)
+	service, err := workerService(ctx)
+	if err != nil {
+		return err
+	}
+	service.Env = autoupdate.ServiceEnv()
+	if ctx.Bool("print") {
+		content, err := service.Render()
+		if err == nil {
+			fmt.Print(content)
+		}
+		return err
+	}
+	return service.Install()
+}
+
+func DaemonUninstall(ctx *cli.Context) error {
+	service, err := workerService(ctx)
+	if err != nil {
+		return err
+	}
+	return service.Uninstall()
+}
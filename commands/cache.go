@@ -0,0 +1,36 @@
+package commands
+
+import (
+	"fmt"
+	"os"
+
+	"github.com/gemnasium/toolbelt/cache"
+	"github.com/gemnasium/toolbelt/utils"
+	"github.com/urfave/cli"
+)
+
+func CacheInfo(ctx *cli.Context) error {
+	return cache.Info(os.Stdout)
+}
+
+func CacheClear(ctx *cli.Context) error {
+	err := cache.Clear(ctx.Args()...)
+	if err != nil {
+		return err
+	}
+	fmt.Println("Cache cleared.")
+	return nil
+}
+
+func CachePrune(ctx *cli.Context) error {
+	olderThan, err := utils.ParseDuration(ctx.String("older-than"))
+	if err != nil {
+		return err
+	}
+	removed, freed, err := cache.Prune(olderThan)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("%d file(s) removed, %s reclaimed.\n", removed, utils.HumanSize(freed))
+	return nil
+}
@@ -0,0 +1,115 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/urfave/cli"
+)
+
+// Flags whose values are completed with the names of the local search index,
+// by kind of names
+var completedFlags = map[string]string{
+	"project": models.SEARCH_KIND_PROJECT,
+	"package": models.SEARCH_KIND_DEPENDENCY,
+}
+
+// Complete flags and their values, and project_slug arguments with the slugs
+// of the local search index
+func completeProjectArgs(ctx *cli.Context) {
+	if !completeFlag(ctx) {
+		models.CompleteNames(ctx.App.Writer, models.SEARCH_KIND_PROJECT)
+	}
+}
+
+// Complete flags and their values
+func completeFlags(ctx *cli.Context) {
+	completeFlag(ctx)
+}
+
+// Complete the flag being typed, or the value of the previous flag. Return
+// false if the previous argument isn't a flag.
+func completeFlag(ctx *cli.Context) bool {
+	// The last argument is --generate-bash-completion
+	if len(os.Args) < 3 {
+		return false
+	}
+	previous := os.Args[len(os.Args)-2]
+	if !strings.HasPrefix(previous, "-") {
+		return false
+	}
+	if kind := completedFlagKind(ctx.Command, strings.TrimLeft(previous, "-")); kind != "" {
+		models.CompleteNames(ctx.App.Writer, kind)
+		return true
+	}
+	command := ctx.Command
+	cli.DefaultCompleteWithFlags(&command)(ctx)
+	return true
+}
+
+// Return the kind of names completing the flag of the command named name (any
+// of its aliases), or an empty string
+func completedFlagKind(command cli.Command, name string) string {
+	for _, flag := range command.Flags {
+		names := strings.Split(flag.GetName(), ",")
+		for _, n := range names {
+			if strings.TrimSpace(n) == name {
+				return completedFlags[strings.TrimSpace(names[0])]
+			}
+		}
+	}
+	return ""
+}
+
+const bashCompletion = `_gemnasium_complete() {
+  local cur opts
+  COMPREPLY=()
+  cur="${COMP_WORDS[COMP_CWORD]}"
+  if [[ "$cur" == "-"* ]]; then
+    opts=$( "${COMP_WORDS[@]:0:$COMP_CWORD}" "$cur" --generate-bash-completion 2>/dev/null )
+  else
+    opts=$( "${COMP_WORDS[@]:0:$COMP_CWORD}" --generate-bash-completion 2>/dev/null )
+  fi
+  COMPREPLY=( $(compgen -W "${opts}" -- "${cur}") )
+  return 0
+}
+
+complete -o bashdefault -o default -F _gemnasium_complete gemnasium
+`
+
+const zshCompletion = `#compdef gemnasium
+
+_gemnasium() {
+  local -a opts
+  if [[ "${words[CURRENT]}" == "-"* ]]; then
+    opts=("${(@f)$(${words[1,CURRENT]} --generate-bash-completion 2>/dev/null)}")
+  else
+    opts=("${(@f)$(${words[1,CURRENT-1]} --generate-bash-completion 2>/dev/null)}")
+  fi
+  if [[ -n "${opts[1]}" ]]; then
+    compadd -a opts
+  else
+    _files
+  fi
+}
+
+compdef _gemnasium gemnasium
+`
+
+// Print the completion script of the shell
+func Completion(ctx *cli.Context) error {
+	switch shell := ctx.Args().First(); shell {
+	case "bash":
+		fmt.Fprint(ctx.App.Writer, bashCompletion)
+	case "zsh":
+		fmt.Fprint(ctx.App.Writer, zshCompletion)
+	case "":
+		return errors.New("Please specify the shell: gemnasium completion bash|zsh")
+	default:
+		return fmt.Errorf("Unsupported shell '%s', expected bash or zsh", shell)
+	}
+	return nil
+}
@@ -0,0 +1,56 @@
+package commands
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+func TestCompletion(t *testing.T) {
+	config.APIKey = "abcdef123"
+	dir, err := ioutil.TempDir("", "gemnasium-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.CacheDir = dir
+	defer func() { config.CacheDir = "" }()
+	index := &models.SearchIndex{UpdatedAt: time.Now(), Entries: []models.SearchEntry{
+		{Kind: models.SEARCH_KIND_PROJECT, Name: "blog", Project: "blog"},
+		{Kind: models.SEARCH_KIND_PROJECT, Name: "My blog", Project: "blog"},
+		{Kind: models.SEARCH_KIND_DEPENDENCY, Name: "rails", Project: "blog"},
+		{Kind: models.SEARCH_KIND_PROJECT, Name: "shop", Project: "shop"},
+		{Kind: models.SEARCH_KIND_DEPENDENCY, Name: "rails", Project: "shop"},
+	}}
+	if err := index.Save(); err != nil {
+		t.Fatal(err)
+	}
+
+	var tt = []struct {
+		args     []string
+		expected string
+	}{
+		{[]string{"dependencies", "list"}, "blog\nshop\n"},
+		{[]string{"org", "deps", "--package"}, "rails\n"},
+		{[]string{"org", "deps", "-p"}, "rails\n"},
+		{[]string{"autoupdate", "run", "-p"}, "blog\nshop\n"},
+		{[]string{"digest", "--for"}, "--format\n"},
+	}
+	for _, test := range tt {
+		os.Args = append(append([]string{"gemnasium"}, test.args...), "--generate-bash-completion")
+		var out bytes.Buffer
+		app := App()
+		app.Writer = &out
+		if err := app.Run(os.Args); err != nil {
+			t.Fatal(err)
+		}
+		if out.String() != test.expected {
+			t.Errorf("%v: expected completions %q, got %q", test.args, test.expected, out.String())
+		}
+	}
+}
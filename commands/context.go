@@ -0,0 +1,23 @@
+package commands
+
+import (
+	"context"
+	"os"
+	"os/signal"
+	"syscall"
+)
+
+// Return a context canceled on interrupt (Ctrl-C) or SIGTERM, or when the
+// command times out (see startWatchdog), so that long running commands abort
+// the API requests in flight and stop cleanly
+func interruptContext() (context.Context, context.CancelFunc) {
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	if deadline.IsZero() {
+		return ctx, stop
+	}
+	ctx, cancel := context.WithDeadline(ctx, deadline)
+	return ctx, func() {
+		cancel()
+		stop()
+	}
+}
@@ -0,0 +1,82 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/gemnasium"
+)
+
+// Recover from a panic, to save a diagnostics bundle and display where it was
+// saved instead of a raw Go panic. Must be deferred in main.
+func RecoverPanic() {
+	r := recover()
+	if r == nil {
+		return
+	}
+	stack := debug.Stack()
+	fmt.Fprintln(os.Stderr, "Oops, gemnasium crashed unexpectedly.")
+	path, err := writeDiagnosticsBundle(r, stack)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Unable to save diagnostics (%s):\n%v\n%s", err, r, stack)
+	} else {
+		fmt.Fprintf(os.Stderr, "Diagnostics were saved to %s\nPlease attach this file when reporting the issue to support@gemnasium.com\n", path)
+	}
+	os.Exit(2)
+}
+
+func writeDiagnosticsBundle(r interface{}, stack []byte) (string, error) {
+	f, err := ioutil.TempFile("", fmt.Sprintf("gemnasium-crash-%s-*.txt", time.Now().Format("20060102-150405")))
+	if err != nil {
+		return "", err
+	}
+	defer f.Close()
+	writeDiagnostics(f, r, stack, os.Args)
+	return f.Name(), nil
+}
+
+func writeDiagnostics(w io.Writer, r interface{}, stack []byte, args []string) {
+	fmt.Fprintf(w, "gemnasium %s (%s, %s/%s)\n", config.VERSION, runtime.Version(), runtime.GOOS, runtime.GOARCH)
+	fmt.Fprintf(w, "Command: %s\n", strings.Join(sanitizeArgs(args), " "))
+	fmt.Fprintf(w, "\nPanic: %v\n\n%s\n", r, stack)
+
+	fmt.Fprintln(w, "Config:")
+	fmt.Fprintf(w, "  api_endpoint: %s\n", config.APIEndpoint)
+	fmt.Fprintf(w, "  api_key: %s\n", mask(config.APIKey))
+	fmt.Fprintf(w, "  project_slug: %s\n", config.ProjectSlug)
+	fmt.Fprintf(w, "  ignored_paths: %s\n", strings.Join(config.IgnoredPaths, ", "))
+	fmt.Fprintf(w, "  cache_dir: %s\n", config.CacheDir)
+
+	fmt.Fprintln(w, "\nLast API responses:")
+	for _, resp := range gemnasium.RecentResponses() {
+		fmt.Fprintf(w, "\n%s %s => %s\n%s\n", resp.Method, resp.URI, resp.Status, resp.Body)
+	}
+}
+
+// Hide the value of the token flag
+func sanitizeArgs(args []string) []string {
+	sanitized := append([]string{}, args...)
+	for i, arg := range sanitized {
+		switch {
+		case (arg == "-t" || arg == "--token") && i+1 < len(sanitized):
+			sanitized[i+1] = mask(sanitized[i+1])
+		case strings.HasPrefix(arg, "--token="):
+			sanitized[i] = "--token=" + mask(strings.TrimPrefix(arg, "--token="))
+		}
+	}
+	return sanitized
+}
+
+func mask(secret string) string {
+	if secret == "" {
+		return ""
+	}
+	return "[FILTERED]"
+}
@@ -0,0 +1,25 @@
+package commands
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestWriteDiagnostics(t *testing.T) {
+	config.APIKey = "secret-key"
+	var buf bytes.Buffer
+	writeDiagnostics(&buf, "boom", []byte("goroutine 1 [running]:"), []string{"gemnasium", "-t", "secret-token", "deps", "list"})
+
+	output := buf.String()
+	for _, s := range []string{"Panic: boom", "goroutine 1 [running]:", "Command: gemnasium -t [FILTERED] deps list", "api_key: [FILTERED]"} {
+		if !strings.Contains(output, s) {
+			t.Errorf("Expected diagnostics to contain '%s', got:\n%s", s, output)
+		}
+	}
+	if strings.Contains(output, "secret") {
+		t.Errorf("Secrets leaked in diagnostics:\n%s", output)
+	}
+}
@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"os"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/demo"
+	"github.com/gemnasium/toolbelt/utils"
+	"github.com/urfave/cli"
+)
+
+func Demo(ctx *cli.Context) error {
+	if ctx.Bool("list") {
+		table := utils.NewTable(os.Stdout, "Step", "Command", "Description")
+		for _, step := range demo.Steps {
+			table.Append(step.Name, "gemnasium "+strings.Join(step.Args, " "), step.Description)
+		}
+		return table.Render()
+	}
+	steps, err := demo.Select(ctx.Args())
+	if err != nil {
+		return err
+	}
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	_, err = demo.Run(os.Stdout, exe, steps)
+	return err
+}
@@ -1,6 +1,9 @@
 package commands
 
 import (
+	"fmt"
+
+	"github.com/gemnasium/toolbelt/autoupdate"
 	"github.com/gemnasium/toolbelt/models"
 	"github.com/urfave/cli"
 )
@@ -13,3 +16,50 @@ func DependenciesList(ctx *cli.Context) error {
 	err = models.ListDependencies(project)
 	return err
 }
+
+func DependenciesOutdated(ctx *cli.Context) error {
+	project, err := models.GetProject(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	err = models.ListOutdatedDependencies(project)
+	return err
+}
+
+func DependenciesDeprecated(ctx *cli.Context) error {
+	project, err := models.GetProject(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	err = models.ListDeprecatedDependencies(project)
+	return err
+}
+
+func DependenciesYanked(ctx *cli.Context) error {
+	project, err := models.GetProject(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("fix") {
+		if err := checkWritable("dependencies yanked --fix"); err != nil {
+			return err
+		}
+	}
+	yanked, err := models.ListYankedDependencies(project)
+	if err != nil || len(yanked) == 0 {
+		return err
+	}
+	if ctx.Bool("fix") {
+		return autoupdate.FixYanked(yanked)
+	}
+	return fmt.Errorf("%d dependencies locked to yanked versions found.\n", len(yanked))
+}
+
+func DependenciesGraph(ctx *cli.Context) error {
+	project, err := models.GetProject(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	err = models.GraphDependencies(project, ctx.String("format"))
+	return err
+}
@@ -6,11 +6,23 @@ import (
 )
 
 func DependencyAlertsList(ctx *cli.Context) error {
+	failOn, err := failOnSeverity(ctx)
+	if err != nil {
+		return err
+	}
 	project, err := models.GetProject(ctx.Args().First())
 	if err != nil {
 		return err
 	}
 
-	err = models.ListDependencyAlerts(project)
+	err = models.ListDependencyAlerts(project, ctx.String("owner"), ctx.String("emit-sarif"), failOn)
 	return err
 }
+
+// Return the severity of the --fail-on flag, empty if it isn't set
+func failOnSeverity(ctx *cli.Context) (string, error) {
+	if !ctx.IsSet("fail-on") {
+		return "", nil
+	}
+	return models.ParseSeverity(ctx.String("fail-on"))
+}
@@ -1,9 +1,14 @@
 package commands
 
 import (
+	"errors"
+	"os"
 	"strings"
 
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
 	"github.com/gemnasium/toolbelt/models"
+	"github.com/gemnasium/toolbelt/utils"
 	"github.com/urfave/cli"
 )
 
@@ -12,20 +17,87 @@ func DependencyFilesList(ctx *cli.Context) error {
 	if err != nil {
 		return err
 	}
-	err = models.ListDependencyFiles(project)
+	filter := models.DependencyFilesFilter{
+		Pattern:   ctx.String("filter"),
+		Ecosystem: ctx.String("ecosystem"),
+		Sort:      ctx.String("sort"),
+	}
+	err = models.ListDependencyFiles(project, !ctx.Bool("no-cache"), filter)
 	return err
 }
 
 func DependenciesPush(ctx *cli.Context) error {
-	project, err := models.GetProject()
-	if err != nil {
-		return err
+	if ctx.Bool("incremental") {
+		config.IncrementalPush = true
+	}
+	if ctx.Bool("submodules") {
+		mappings, err := models.SubmoduleMappings()
+		if err != nil {
+			return err
+		}
+		// Mappings of .gemnasium.yml take precedence
+		for dir, slug := range mappings {
+			if _, ok := config.ProjectMappings[dir]; !ok {
+				config.ProjectMappings[dir] = slug
+			}
+		}
+	}
+	// With project mappings, the project slug is only used for the files found
+	// outside of the mapped directories
+	slug := config.ProjectSlug
+	if len(config.ProjectMappings) == 0 {
+		project, err := models.GetProject()
+		if err != nil {
+			return err
+		}
+		slug = project.Slug
 	}
 	var files []string
 	if ctx.IsSet("files") {
 		// Only call strings.Split on non-empty strings, otherwise len(strings) will be 1 instead of 0.
 		files = strings.Split(ctx.String("files"), ",")
 	}
-	err = models.PushDependencyFiles(project.Slug, files)
+	if ctx.Bool("prune") && len(files) > 0 {
+		return errors.New(i18n.T("df.prune_with_files"))
+	}
+	// Only the result is printed on stdout in JSON, messages go to stderr
+	output := models.Output
+	if config.JSONOutput {
+		models.Output = os.Stderr
+	}
+	pushCtx, stop := interruptContext()
+	defer stop()
+	result, err := models.PushDependencyFiles(pushCtx, slug, files, ctx.Bool("yes"))
+	if err == nil && ctx.Bool("prune") {
+		result.Removed, err = models.PruneDependencyFiles(slug, ctx.Bool("yes"))
+	}
+	models.Output = output
+	if err != nil {
+		return err
+	}
+	if config.JSONOutput {
+		if err := utils.PrintJSON(models.Output, result); err != nil {
+			return err
+		}
+	} else {
+		result.Print()
+	}
+	var failOn []string
+	if ctx.IsSet("fail-on") {
+		failOn = strings.Split(ctx.String("fail-on"), ",")
+	}
+	return result.Check(failOn, ctx.Bool("fail-on-change"))
+}
+
+func DependencyFilesRestore(ctx *cli.Context) error {
+	project, err := models.GetProject()
+	if err != nil {
+		return err
+	}
+	path := ctx.Args().First()
+	if path == "" {
+		return errors.New("Please specify the path of the file to restore")
+	}
+	err = models.RestoreDependencyFile(project, path, ctx.Bool("yes"))
 	return err
 }
@@ -0,0 +1,119 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// A deprecated command or flag, and its replacement
+type Deprecation struct {
+	// Deprecated invocation: command words (ie: {"df", "ls"}) matched after the
+	// global flags, or a single flag (ie: {"--all"}) matched anywhere after the
+	// command
+	Args []string
+	// New invocation, replacing Args. Empty if there's no replacement (Args are
+	// dropped)
+	Replacement []string
+	// Version deprecating Args
+	Since string
+}
+
+func (d Deprecation) isFlag() bool {
+	return len(d.Args) == 1 && strings.HasPrefix(d.Args[0], "-")
+}
+
+func (d Deprecation) Warning() string {
+	msg := fmt.Sprintf("[deprecated] '%s' is deprecated since %s", strings.Join(d.Args, " "), d.Since)
+	if len(d.Replacement) == 0 {
+		return msg + " and will be removed"
+	}
+	return fmt.Sprintf("%s, use '%s' instead", msg, strings.Join(d.Replacement, " "))
+}
+
+// Deprecated commands and flags. Keep them here for at least one minor
+// release before removing the old invocations from the CLI.
+var deprecations = []Deprecation{}
+
+// Return the index of the command in args, after the global flags
+func commandIndex(args []string) int {
+	i := 1
+	for i < len(args) && strings.HasPrefix(args[i], "-") {
+		if globalFlagsWithValue[args[i]] {
+			i++
+		}
+		i++
+	}
+	if i > len(args) {
+		return len(args)
+	}
+	return i
+}
+
+// Strict mode is enabled with the --strict-deprecations global flag, or
+// GEMNASIUM_STRICT_DEPRECATIONS
+func strictDeprecations(args []string) bool {
+	if os.Getenv(config.ENV_STRICT_DEPRECATIONS) != "" {
+		return true
+	}
+	for _, arg := range args[1:commandIndex(args)] {
+		if arg == "--strict-deprecations" {
+			return true
+		}
+	}
+	return false
+}
+
+// Rewrite deprecated invocations in args with their replacement, and write a
+// warning for each of them to output (unless GEMNASIUM_NO_DEPRECATION_WARNINGS
+// is set). In strict mode, an error is returned instead.
+func ApplyDeprecations(args []string, output io.Writer) ([]string, error) {
+	strict := strictDeprecations(args)
+	quiet := os.Getenv(config.ENV_NO_DEPRECATION_WARNINGS) != ""
+	for _, d := range deprecations {
+		var matched bool
+		args, matched = d.rewrite(args)
+		if !matched {
+			continue
+		}
+		if strict {
+			return nil, fmt.Errorf("%s (--strict-deprecations)", d.Warning())
+		}
+		if !quiet {
+			fmt.Fprintln(output, d.Warning())
+		}
+	}
+	return args, nil
+}
+
+func (d Deprecation) rewrite(args []string) ([]string, bool) {
+	i := commandIndex(args)
+	if d.isFlag() {
+		for j := i; j < len(args); j++ {
+			if args[j] == d.Args[0] || strings.HasPrefix(args[j], d.Args[0]+"=") {
+				value := strings.TrimPrefix(args[j], d.Args[0])
+				rewritten := append([]string{}, args[:j]...)
+				if len(d.Replacement) > 0 {
+					rewritten = append(rewritten, d.Replacement[0]+value)
+				}
+				return append(rewritten, args[j+1:]...), true
+			}
+		}
+		return args, false
+	}
+
+	if len(args)-i < len(d.Args) {
+		return args, false
+	}
+	for k, word := range d.Args {
+		if args[i+k] != word {
+			return args, false
+		}
+	}
+	rewritten := append([]string{}, args[:i]...)
+	rewritten = append(rewritten, d.Replacement...)
+	return append(rewritten, args[i+len(d.Args):]...), true
+}
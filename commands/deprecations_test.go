@@ -0,0 +1,63 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"reflect"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestApplyDeprecations(t *testing.T) {
+	oldDeprecations := deprecations
+	deprecations = []Deprecation{
+		{Args: []string{"df", "ls"}, Replacement: []string{"df", "list"}, Since: "0.3.0"},
+		{Args: []string{"--files"}, Replacement: []string{"--file"}, Since: "0.3.0"},
+		{Args: []string{"--legacy"}, Since: "0.3.0"},
+	}
+	defer func() { deprecations = oldDeprecations }()
+
+	var tt = []struct {
+		Args     []string
+		Expected []string
+		Warnings string
+	}{
+		{[]string{"gemnasium", "df", "list"}, []string{"gemnasium", "df", "list"}, ""},
+		{[]string{"gemnasium", "-r", "df", "ls", "slug"}, []string{"gemnasium", "-r", "df", "list", "slug"},
+			"[deprecated] 'df ls' is deprecated since 0.3.0, use 'df list' instead\n"},
+		{[]string{"gemnasium", "eval", "--files=Gemfile", "--legacy"}, []string{"gemnasium", "eval", "--file=Gemfile"},
+			"[deprecated] '--files' is deprecated since 0.3.0, use '--file' instead\n" +
+				"[deprecated] '--legacy' is deprecated since 0.3.0 and will be removed\n"},
+	}
+	for _, test := range tt {
+		var buf bytes.Buffer
+		args, err := ApplyDeprecations(test.Args, &buf)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !reflect.DeepEqual(args, test.Expected) {
+			t.Errorf("%v: expected %v, got %v", test.Args, test.Expected, args)
+		}
+		if buf.String() != test.Warnings {
+			t.Errorf("%v: expected warnings %q, got %q", test.Args, test.Warnings, buf.String())
+		}
+	}
+
+	// warnings can be disabled
+	os.Setenv(config.ENV_NO_DEPRECATION_WARNINGS, "1")
+	var buf bytes.Buffer
+	ApplyDeprecations([]string{"gemnasium", "df", "ls"}, &buf)
+	os.Unsetenv(config.ENV_NO_DEPRECATION_WARNINGS)
+	if buf.Len() != 0 {
+		t.Errorf("Expected no warnings, got: %s", buf.String())
+	}
+
+	// strict mode
+	if _, err := ApplyDeprecations([]string{"gemnasium", "--strict-deprecations", "df", "ls"}, &buf); err == nil {
+		t.Error("Expected an error in strict mode")
+	}
+	if _, err := ApplyDeprecations([]string{"gemnasium", "--strict-deprecations", "df", "list"}, &buf); err != nil {
+		t.Errorf("Expected no error without deprecations, got: %s", err)
+	}
+}
@@ -0,0 +1,26 @@
+package commands
+
+import (
+	"time"
+
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/gemnasium/toolbelt/utils"
+	"github.com/urfave/cli"
+)
+
+func Digest(ctx *cli.Context) error {
+	since, err := utils.ParseDuration(ctx.String("since"))
+	if err != nil {
+		return err
+	}
+	labels, err := models.ParseLabels(ctx.StringSlice("label"), false)
+	if err != nil {
+		return err
+	}
+	projects, err := models.SelectProjects(ctx.Args(), ctx.String("owner"), labels)
+	if err != nil {
+		return err
+	}
+	err = models.ShowDigest(projects, time.Now().Add(-since), ctx.String("format"))
+	return err
+}
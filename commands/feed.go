@@ -0,0 +1,19 @@
+package commands
+
+import (
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/urfave/cli"
+)
+
+func Feed(ctx *cli.Context) error {
+	labels, err := models.ParseLabels(ctx.StringSlice("label"), false)
+	if err != nil {
+		return err
+	}
+	projects, err := models.SelectProjects(ctx.Args(), ctx.String("owner"), labels)
+	if err != nil {
+		return err
+	}
+	err = models.GenerateFeed(projects, ctx.String("format"), ctx.String("out"))
+	return err
+}
@@ -1,16 +1,52 @@
 package commands
 
 import (
+	"fmt"
+	"os"
 	"strings"
+	"time"
 
 	"github.com/gemnasium/toolbelt/auth"
+	"github.com/gemnasium/toolbelt/config"
 	"github.com/gemnasium/toolbelt/live-eval"
+	"github.com/gemnasium/toolbelt/models"
 	"github.com/urfave/cli"
 )
 
+// Parse a date (2006-01-02) or a time (RFC 3339). Dates are taken at the end
+// of the day, UTC.
+func parseDate(value string) (time.Time, error) {
+	if t, err := time.Parse(time.RFC3339, value); err == nil {
+		return t, nil
+	}
+	date, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("Invalid date '%s', expected YYYY-MM-DD or RFC 3339", value)
+	}
+	return date.Add(24*time.Hour - time.Second), nil
+}
+
 func LiveEvaluation(ctx *cli.Context) error {
 	auth.AttemptLogin(ctx)
-	files := strings.Split(ctx.String("files"), ",")
-	err := liveeval.LiveEvaluation(files)
+	// Files can be given with --files and as arguments
+	var files []string
+	if ctx.String("files") != "" {
+		files = strings.Split(ctx.String("files"), ",")
+	}
+	files = append(files, ctx.Args()...)
+	var asOf time.Time
+	if date := ctx.String("as-of"); date != "" {
+		var err error
+		if asOf, err = parseDate(date); err != nil {
+			return err
+		}
+	}
+	// Only the report is printed on stdout in JSON, messages go to stderr
+	output := models.Output
+	if config.JSONOutput {
+		models.Output = os.Stderr
+	}
+	err := liveeval.LiveEvaluation(files, asOf)
+	models.Output = output
 	return err
 }
@@ -0,0 +1,66 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/gemnasium/toolbelt/autoupdate"
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/urfave/cli"
+)
+
+// Override the org limits from config with the command flags
+func setOrgLimits(ctx *cli.Context) {
+	if ctx.IsSet("concurrency") {
+		config.OrgConcurrency = ctx.Int("concurrency")
+	}
+	if ctx.IsSet("qps") {
+		config.OrgQPS = ctx.Float64("qps")
+	}
+}
+
+func OrgDependencies(ctx *cli.Context) error {
+	setOrgLimits(ctx)
+	if ctx.Bool("fail-fast") && ctx.IsSet("keep-going") {
+		return errors.New("--fail-fast and --keep-going can't be used together")
+	}
+	name := ctx.String("package")
+	if name == "" {
+		return errors.New("Please specify a package with --package")
+	}
+	labels, err := models.ParseLabels(ctx.StringSlice("label"), false)
+	if err != nil {
+		return err
+	}
+	err = models.ListPackageUsages(ctx.String("owner"), labels, name, ctx.String("type"), ctx.Bool("fail-fast"))
+	return err
+}
+
+func OrgRemediate(ctx *cli.Context) error {
+	setOrgLimits(ctx)
+	if ctx.Bool("fail-fast") && ctx.IsSet("keep-going") {
+		return errors.New("--fail-fast and --keep-going can't be used together")
+	}
+	advisory := ctx.String("advisory")
+	if advisory == "" {
+		return errors.New(i18n.T("org.remediate_missing_advisory"))
+	}
+	if ctx.Bool("open-prs") {
+		if err := checkWritable("org remediate --open-prs"); err != nil {
+			return err
+		}
+	}
+	labels, err := models.ParseLabels(ctx.StringSlice("label"), false)
+	if err != nil {
+		return err
+	}
+	affected, searchErr := models.OrgAffectedProjects(ctx.String("owner"), labels, advisory, ctx.Bool("fail-fast"))
+	if ctx.Bool("fail-fast") && searchErr != nil {
+		return searchErr
+	}
+	if err := autoupdate.Remediate(affected, ctx.String("clone-url"), ctx.Bool("open-prs")); err != nil {
+		return err
+	}
+	return searchErr
+}
@@ -1,6 +1,7 @@
 package commands
 
 import (
+	"errors"
 	"os"
 
 	"github.com/gemnasium/toolbelt/models"
@@ -47,8 +48,13 @@ func ProjectsUpdate(ctx *cli.Context) error {
 
 func ProjectsCreate(ctx *cli.Context) error {
 	projectName := ctx.Args().First()
+	var desc *string
+	if ctx.IsSet("desc") {
+		descString := ctx.String("desc")
+		desc = &descString
+	}
 	// will scan from os.Stding if projectName is empty
-	err := models.CreateProject(projectName, os.Stdin)
+	err := models.CreateProject(projectName, desc, os.Stdin)
 	return err
 }
 
@@ -61,3 +67,31 @@ func ProjectsSync(ctx *cli.Context) error {
 	err = project.Sync()
 	return err
 }
+
+func ProjectsLabelAdd(ctx *cli.Context) error {
+	project, err := models.GetProject(ctx.String("project"))
+	if err != nil {
+		return err
+	}
+	labels, err := models.ParseLabels(ctx.Args(), true)
+	if err != nil {
+		return err
+	}
+	if len(labels) == 0 {
+		return errors.New("Please specify at least one label (key=value)")
+	}
+	err = project.AddLabels(labels)
+	return err
+}
+
+func ProjectsLabelRemove(ctx *cli.Context) error {
+	project, err := models.GetProject(ctx.String("project"))
+	if err != nil {
+		return err
+	}
+	if len(ctx.Args()) == 0 {
+		return errors.New("Please specify at least one label key")
+	}
+	err = project.RemoveLabels(ctx.Args())
+	return err
+}
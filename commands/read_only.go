@@ -0,0 +1,40 @@
+package commands
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/urfave/cli"
+)
+
+// Commands changing data, on Gemnasium or in the project directory. They're
+// blocked in read-only mode (see config.ReadOnly).
+var mutatingCommands = map[string]bool{"report freshness --push": true, "dependencies yanked --fix": true, "autoupdate run": true}
+
+// Register the command as mutating, and wrap its action to block it in
+// read-only mode.
+func mutating(name string, action func(*cli.Context) error) func(*cli.Context) error {
+	mutatingCommands[name] = true
+	return func(ctx *cli.Context) error {
+		if err := checkWritable(name); err != nil {
+			return err
+		}
+		return action(ctx)
+	}
+}
+
+// Return an error listing the blocked operations if read-only mode is enabled
+func checkWritable(operation string) error {
+	if !config.ReadOnly {
+		return nil
+	}
+	blocked := []string{}
+	for name := range mutatingCommands {
+		blocked = append(blocked, name)
+	}
+	sort.Strings(blocked)
+	return fmt.Errorf("'%s' is blocked: read-only mode is enabled (read_only in %s, %s or --read-only).\nBlocked operations: %s\n",
+		operation, config.CONFIG_FILE_PATH, config.ENV_READ_ONLY, strings.Join(blocked, ", "))
+}
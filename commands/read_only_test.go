@@ -0,0 +1,48 @@
+package commands
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestReadOnly(t *testing.T) {
+	config.APIKey = "abcdef123"
+	config.ProjectSlug = "projectSlug"
+	defer func() { config.ReadOnly = false }()
+
+	var called bool
+	auRunFunc = func(slug string, args []string) error {
+		called = true
+		return nil
+	}
+
+	app := App()
+	err := app.Run([]string{"gemnasium", "--read-only", "au", "r"})
+	if called {
+		t.Error("autoupdate run should be blocked in read-only mode")
+	}
+	if err == nil || !strings.Contains(err.Error(), "'autoupdate run' is blocked") {
+		t.Fatalf("Expected a read-only error, got: %v", err)
+	}
+	for _, name := range []string{"dependency_files push", "projects create", "report freshness --push"} {
+		if !strings.Contains(err.Error(), name) {
+			t.Errorf("Error should list %s as blocked, got: %s", name, err)
+		}
+	}
+
+	// Dry runs don't change anything
+	defer func() { config.DryRun = false }()
+	app = App()
+	if err := app.Run([]string{"gemnasium", "--read-only", "au", "r", "--dry-run"}); err != nil || !called || !config.DryRun {
+		t.Errorf("autoupdate run --dry-run should be allowed in read-only mode, got: %v", err)
+	}
+	called, config.DryRun = false, false
+
+	config.ReadOnly = false
+	app = App()
+	if err := app.Run([]string{"gemnasium", "au", "r"}); err != nil || !called {
+		t.Errorf("autoupdate run should be allowed, got: %v", err)
+	}
+}
@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/urfave/cli"
+)
+
+func ReportFreshness(ctx *cli.Context) error {
+	project, err := models.GetProject(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	if ctx.Bool("push") {
+		if err := checkWritable("report freshness --push"); err != nil {
+			return err
+		}
+	}
+	err = models.ReportFreshness(project, ctx.String("format"), ctx.Bool("push"))
+	return err
+}
+
+func ReportRisk(ctx *cli.Context) error {
+	project, err := models.GetProject(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	return models.ReportRisk(project, ctx.String("format"))
+}
+
+func Check(ctx *cli.Context) error {
+	maxRisk, err := strconv.ParseFloat(ctx.String("max-risk"), 64)
+	if err != nil {
+		return fmt.Errorf("Invalid max risk: %s", ctx.String("max-risk"))
+	}
+	project, err := models.GetProject(ctx.Args().First())
+	if err != nil {
+		return err
+	}
+	return models.CheckRisk(project, maxRisk)
+}
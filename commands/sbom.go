@@ -0,0 +1,16 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/urfave/cli"
+)
+
+func SBOMPush(ctx *cli.Context) error {
+	registry := ctx.String("registry")
+	if registry == "" {
+		return errors.New("Please specify the image to attach the SBOM to with --registry (ie: ghcr.io/org/app:tag)")
+	}
+	return models.PushSBOM(registry, ctx.StringSlice("attach"))
+}
@@ -0,0 +1,14 @@
+package commands
+
+import (
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/urfave/cli"
+)
+
+func Scan(ctx *cli.Context) error {
+	failOn, err := failOnSeverity(ctx)
+	if err != nil {
+		return err
+	}
+	return models.Scan(ctx.String("emit-manifest"), ctx.String("emit-spdx"), ctx.String("emit-sarif"), ctx.Bool("offline"), failOn)
+}
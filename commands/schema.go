@@ -0,0 +1,30 @@
+package commands
+
+import (
+	"os"
+
+	"github.com/gemnasium/toolbelt/schemas"
+	"github.com/urfave/cli"
+)
+
+// Flag of the commands printing JSON documents (see withSchema)
+var printSchemaFlag = cli.BoolFlag{
+	Name:  "print-schema",
+	Usage: "Print the JSON schema of the --json (or --format json) output of the command, and exit",
+}
+
+// Wrap the action of a command printing JSON documents, to print the schema of
+// these documents instead with --print-schema
+func withSchema(name string, action func(*cli.Context) error) func(*cli.Context) error {
+	return func(ctx *cli.Context) error {
+		if !ctx.Bool("print-schema") {
+			return action(ctx)
+		}
+		schema, err := schemas.Get(name)
+		if err != nil {
+			return err
+		}
+		_, err = os.Stdout.Write(schema)
+		return err
+	}
+}
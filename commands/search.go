@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/urfave/cli"
+)
+
+func Search(ctx *cli.Context) error {
+	if ctx.Bool("reindex") {
+		index, err := models.RefreshSearchIndex()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Search index refreshed (%d entries)\n", len(index.Entries))
+		if ctx.Args().First() == "" {
+			return nil
+		}
+	}
+	term := ctx.Args().First()
+	if term == "" {
+		return errors.New("Please specify a search term")
+	}
+	err := models.SearchAll(term)
+	return err
+}
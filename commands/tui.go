@@ -0,0 +1,65 @@
+package commands
+
+import (
+	"errors"
+	"os"
+
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/heroku/hk/term"
+	"github.com/urfave/cli"
+)
+
+// Keys of the dashboard, from the bytes read in raw mode
+var dashboardKeys = map[string]string{
+	"\x1b[A": "up",
+	"\x1b[B": "down",
+	"\x1b[C": "enter",
+	"\x1b[D": "back",
+	"\r":     "enter",
+	"\n":     "enter",
+	"\x1b":   "back",
+	"\x7f":   "back",
+	"\x03":   "q", // Ctrl-C doesn't send SIGINT in raw mode
+}
+
+func TUI(ctx *cli.Context) error {
+	if !term.IsTerminal(os.Stdin) || !term.IsTerminal(os.Stdout) {
+		return errors.New(i18n.T("tui.not_a_terminal"))
+	}
+	labels, err := models.ParseLabels(ctx.StringSlice("label"), false)
+	if err != nil {
+		return err
+	}
+	projects, err := models.SelectProjects(ctx.Args(), ctx.String("owner"), labels)
+	if err != nil {
+		return err
+	}
+	dashboard := models.NewDashboard(projects)
+
+	if err := term.MakeRaw(os.Stdin); err != nil {
+		return err
+	}
+	defer term.Restore(os.Stdin)
+	// Leave a clean screen
+	defer os.Stdout.WriteString("\033[2J\033[1;1H")
+
+	buf := make([]byte, 8)
+	for {
+		if lines, err := term.Lines(); err == nil {
+			dashboard.Height = lines
+		}
+		dashboard.Render(os.Stdout)
+		n, err := os.Stdin.Read(buf)
+		if err != nil {
+			return err
+		}
+		key := string(buf[:n])
+		if k, ok := dashboardKeys[key]; ok {
+			key = k
+		}
+		if dashboard.HandleKey(key) {
+			return nil
+		}
+	}
+}
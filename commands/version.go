@@ -0,0 +1,11 @@
+package commands
+
+import (
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/urfave/cli"
+)
+
+func Version(ctx *cli.Context) error {
+	err := models.ShowVersion(ctx.Bool("check"))
+	return err
+}
@@ -0,0 +1,57 @@
+package commands
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gemnasium/toolbelt/autoupdate"
+	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+// Exit code when the command times out, like timeout(1)
+const EXIT_TIMEOUT = 124
+
+// Time left to the commands honoring the deadline (see interruptContext) to
+// stop and report by themselves, before the watchdog exits
+var watchdogGrace = 10 * time.Second
+
+// Lambda to be overriden in tests
+var exit = os.Exit
+
+// Deadline of the command, zero if there's no timeout
+var deadline time.Time
+
+// Exit with a partial report on stderr if the command is still running after
+// timeout (config.CommandTimeout). The dependency files patched by the update
+// set being tested, if any, are restored first.
+func startWatchdog(timeout time.Duration) *time.Timer {
+	deadline = time.Now().Add(timeout)
+	return time.AfterFunc(timeout+watchdogGrace, func() {
+		writePartialReport(os.Stderr, timeout)
+		if err := autoupdate.RestoreAppliedDepFiles(); err != nil {
+			fmt.Fprint(os.Stderr, i18n.T("autoupdate.restore_error", err))
+		}
+		exit(EXIT_TIMEOUT)
+	})
+}
+
+// Report what the command was doing when it timed out: the progress of the
+// scan, if any, and the last API requests
+func writePartialReport(w io.Writer, timeout time.Duration) {
+	fmt.Fprint(w, i18n.T("timeout.expired", timeout, strings.Join(sanitizeArgs(os.Args), " ")))
+	if walked, current, matched := models.ScanProgress(); walked > 0 {
+		fmt.Fprint(w, i18n.T("timeout.scan_progress", walked, current))
+		fmt.Fprint(w, i18n.T("timeout.files_found", len(matched), strings.Join(matched, ", ")))
+	}
+	if responses := gemnasium.RecentResponses(); len(responses) > 0 {
+		fmt.Fprint(w, i18n.T("timeout.last_requests"))
+		for _, resp := range responses {
+			fmt.Fprintf(w, "  %s %s => %s\n", resp.Method, resp.URI, resp.Status)
+		}
+	}
+}
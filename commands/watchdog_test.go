@@ -0,0 +1,43 @@
+package commands
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestWatchdog(t *testing.T) {
+	codes := make(chan int, 1)
+	exit = func(code int) { codes <- code }
+	watchdogGrace = 0
+	defer func() {
+		exit = os.Exit
+		watchdogGrace = 10 * time.Second
+		deadline = time.Time{}
+	}()
+
+	startWatchdog(10 * time.Millisecond)
+	ctx, stop := interruptContext()
+	defer stop()
+	select {
+	case code := <-codes:
+		if code != EXIT_TIMEOUT {
+			t.Errorf("Expected exit code %d, got %d", EXIT_TIMEOUT, code)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("Expected the watchdog to exit")
+	}
+	select {
+	case <-ctx.Done():
+	case <-time.After(time.Second):
+		t.Error("Expected the context of the command to be done")
+	}
+
+	var buf bytes.Buffer
+	writePartialReport(&buf, time.Minute)
+	if !strings.Contains(buf.String(), "timed out after 1m0s") {
+		t.Errorf("Unexpected partial report: %s", buf.String())
+	}
+}
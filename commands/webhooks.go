@@ -0,0 +1,28 @@
+package commands
+
+import (
+	"errors"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/urfave/cli"
+)
+
+func WebhooksSimulate(ctx *cli.Context) error {
+	name := ctx.String("package")
+	if name == "" {
+		return errors.New("Please specify a package with --package")
+	}
+	project := ctx.String("project")
+	if project == "" {
+		project = config.ProjectSlug
+	}
+	url := ctx.String("url")
+	if url == "" {
+		url = config.WebhookURL
+	}
+	if ctx.Bool("print") {
+		url = ""
+	}
+	return models.SimulateWebhook(url, ctx.String("event"), models.Package{Name: name, Type: ctx.String("type")}, project)
+}
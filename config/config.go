@@ -4,7 +4,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	"gopkg.in/yaml.v1"
 )
@@ -13,8 +17,144 @@ var (
 	APIEndpoint = DEFAULT_API_ENDPOINT
 	APIKey,
 	ProjectSlug string
-	IgnoredPaths []string
-	RawFormat    bool
+	IgnoredPaths   []string
+	RawFormat      bool
+	ReadOnly       bool
+	NonInteractive bool     // never prompt: fail instead, telling which flag to supply
+	JSONOutput     bool     // print JSON documents instead of tables and messages
+	Query          string   // jq-like path of the values to display (list commands)
+	Columns        []string // columns of tables to display (ex: path,sha)
+	MaxColumnWidth int      // width of table columns, text is wrapped or truncated (NoWrap) beyond
+	NoWrap         bool
+	VerifyVersions bool
+	MinReleaseAge  string
+	SimulateSets   bool
+	DryRun         bool
+	PatchOut       string // file the diff of successful update sets is written to
+	GitBranch      bool   // commit successful update sets on a branch
+	GitPush        bool   // and push it to origin
+	// Update sets of the packages matching these patterns (by group name) are
+	// committed on the same branch, and with GitGroupTypes, @types/* packages
+	// with their runtime package
+	GitGroups     = map[string]string{}
+	GitGroupTypes bool
+	// Open a GitHub pull request for each pushed branch, with these labels,
+	// assignees and auto-merge method (merge, squash or rebase), and set the
+	// status of its commits to the result of the test suite
+	PullRequest    bool
+	PRLabels       []string
+	PRAssignees    []string
+	PRAutoMerge    string
+	PRStatus       = true
+	GitHubToken    string
+	TestRetries    int
+	PatchFallback  bool  // use the patch command when a patch can't be applied natively
+	CacheDir             = defaultCacheDir()
+	MaxPayloadSize int64 = DEFAULT_MAX_PAYLOAD_SIZE
+	MaxFileSize    int64 // content of bigger dependency files is only read when needed (0: no limit)
+	// How the SHAs of dependency files are computed (git, hg, svn or none),
+	// detected from the working directory if empty
+	VCS string
+	// Environment of the update and test commands run by autoupdate: vars to
+	// set and unset, and whether to start from a clean environment (only
+	// passing a few base vars, and PassEnv) instead of the current one
+	SubprocessEnv = map[string]string{}
+	UnsetEnv      []string
+	CleanEnv      bool
+	PassEnv       []string
+	// Minimum versions of the tools used by autoupdate (ie: "bundle" => "1.17")
+	MinToolVersions = map[string]string{}
+	// Where autoupdate uploads the artifacts of failed update sets: "api",
+	// "s3" (S3-compatible bucket), or nowhere if empty
+	Artifacts  string
+	S3Endpoint = DEFAULT_S3_ENDPOINT
+	S3Region   = DEFAULT_S3_REGION
+	S3Bucket   string
+	S3Prefix   string
+	// SMTP server used to email the summary of autoupdate runs. Subject and
+	// body are text/template templates.
+	SMTPHost     string
+	SMTPPort     = DEFAULT_SMTP_PORT
+	SMTPUsername string
+	SMTPPassword string
+	SMTPFrom     = DEFAULT_SMTP_FROM
+	SMTPTo       []string
+	SMTPSubject  string
+	SMTPBody     string
+	// Name of the autoupdate worker (jobs may target it), and where it gets
+	// its jobs: "api", or the URL of an SQS queue
+	WorkerName  = defaultWorkerName()
+	WorkerQueue = DEFAULT_WORKER_QUEUE
+	// Credentials of the OCI registry SBOMs are pushed to (sbom push)
+	OCIUsername string
+	OCIPassword string
+	// Endpoint simulated webhooks are posted to (webhooks simulate), and the
+	// secret their signature is computed with
+	WebhookURL    = DEFAULT_WEBHOOK_URL
+	WebhookSecret string
+	// Projects of the subdirectories of multi-project repos (ie: "services/api"
+	// => "org/api"), files found elsewhere are pushed to ProjectSlug
+	ProjectMappings = map[string]string{}
+	// Ignored paths of the subdirectories of multi-project repos, relative to
+	// these directories
+	ProjectIgnoredPaths = map[string][]string{}
+	// Registry mirrors, by package type (ie: "rubygem", "npm", "packagist")
+	RegistryMirrors = map[string]string{}
+	// Org-wide operations: number of projects processed concurrently, and max
+	// number of API requests per second (0: unlimited)
+	OrgConcurrency = DEFAULT_ORG_CONCURRENCY
+	OrgQPS         float64
+	// Only send the content of the files Gemnasium doesn't have yet (by SHA)
+	IncrementalPush bool
+	// Max number of commits fetched to deepen shallow clones, when a revision
+	// is missing from the history (0: never fetch)
+	DeepenBudget int
+	// Number of dependency files read and hashed concurrently while scanning
+	ScanConcurrency = runtime.NumCPU()
+	// Max number of connections per host (0: unlimited)
+	MaxConnsPerHost int
+	// API requests failing with network errors or these statuses are sent
+	// again, up to APIRetries times (0: never), after an exponential backoff
+	APIRetries         = DEFAULT_API_RETRIES
+	APIRetryBackoff    = DEFAULT_API_RETRY_BACKOFF
+	APIRetryMaxBackoff = DEFAULT_API_RETRY_MAX_BACKOFF
+	APIRetryStatuses   = []int{502, 503, 504}
+	// Max duration of each API request attempt (0: unlimited)
+	APITimeout = DEFAULT_API_TIMEOUT
+	// Max duration of the whole command (0: unlimited)
+	CommandTimeout time.Duration
+	// Addresses to connect to instead of resolving hosts ("host:port:addr"),
+	// and IP version to use (4 or 6, 0: both)
+	Resolve   []string
+	IPVersion int
+	// Unix socket to connect to instead of the API host (ie: a local gateway),
+	// headers added to API requests (values can reference env vars), and key
+	// used to sign them (HMAC-SHA256), for gateways fronting the API
+	APISocket     string
+	APIHeaders    = map[string]string{}
+	APISigningKey string
+	// Proxy used for all requests (default: from HTTPS_PROXY, HTTP_PROXY and
+	// NO_PROXY), PEM bundle of extra CA certificates to trust, and whether to
+	// skip TLS verification
+	Proxy    string
+	CACert   string
+	Insecure bool
+	// Log diagnostics on stderr (ie: fields of API responses the toolbelt
+	// doesn't know)
+	Debug bool
+	// Weights of the signals of the risk score of dependencies (see
+	// models.RiskScore)
+	RiskWeights = map[string]float64{"cvss": 1, "epss": 0.6, "freshness": 0.3, "maintenance": 0.6, "reachability": 0.5}
+	// Local copy of the advisory database, used by offline scans (default: in
+	// the cache directory)
+	AdvisoryDB string
+	// Build info, set at build time with -ldflags "-X ..." (see Makefile)
+	Commit    = "unknown"
+	BuildDate = "unknown"
+	// User defined commands aliases (ie: "p" => "dependency_files push"), and
+	// command run when gemnasium is called without arguments
+	Aliases        = map[string]string{}
+	DefaultCommand string
 )
 
 const (
@@ -29,11 +169,89 @@ const (
 	ENV_REVISION                     = "REVISION"
 	ENV_IGNORED_PATHS                = "GEMNASIUM_IGNORED_PATHS"
 	ENV_RAW_FORMAT                   = "GEMNASIUM_RAW_FORMAT"
+	ENV_READ_ONLY                    = "GEMNASIUM_READ_ONLY"
+	ENV_NON_INTERACTIVE              = "GEMNASIUM_NON_INTERACTIVE"
+	ENV_OUTPUT                       = "GEMNASIUM_OUTPUT"
+	ENV_LANG                         = "GEMNASIUM_LANG"
+	ENV_NO_DEPRECATION_WARNINGS      = "GEMNASIUM_NO_DEPRECATION_WARNINGS"
+	ENV_STRICT_DEPRECATIONS          = "GEMNASIUM_STRICT_DEPRECATIONS"
+	ENV_CACHE_DIR                    = "GEMNASIUM_CACHE_DIR"
+	ENV_MAX_PAYLOAD_SIZE             = "GEMNASIUM_MAX_PAYLOAD_SIZE"
+	ENV_MAX_FILE_SIZE                = "GEMNASIUM_MAX_FILE_SIZE"
+	ENV_VCS                          = "GEMNASIUM_VCS"
+	ENV_RUBYGEMS_MIRROR              = "GEMNASIUM_RUBYGEMS_MIRROR"
+	ENV_NPM_MIRROR                   = "GEMNASIUM_NPM_MIRROR"
+	ENV_PACKAGIST_MIRROR             = "GEMNASIUM_PACKAGIST_MIRROR"
+	ENV_ORG_CONCURRENCY              = "GEMNASIUM_ORG_CONCURRENCY"
+	ENV_ORG_QPS                      = "GEMNASIUM_ORG_QPS"
+	ENV_MAX_CONNS_PER_HOST           = "GEMNASIUM_MAX_CONNS_PER_HOST"
+	ENV_SCAN_CONCURRENCY             = "GEMNASIUM_SCAN_CONCURRENCY"
+	ENV_DEEPEN_BUDGET                = "GEMNASIUM_DEEPEN_BUDGET"
+	ENV_INCREMENTAL_PUSH             = "GEMNASIUM_INCREMENTAL_PUSH"
+	ENV_API_RETRIES                  = "GEMNASIUM_API_RETRIES"
+	ENV_API_RETRY_BACKOFF            = "GEMNASIUM_API_RETRY_BACKOFF"
+	ENV_API_RETRY_STATUSES           = "GEMNASIUM_API_RETRY_STATUSES"
+	ENV_API_TIMEOUT                  = "GEMNASIUM_API_TIMEOUT"
+	ENV_TIMEOUT                      = "GEMNASIUM_TIMEOUT"
+	ENV_RESOLVE                      = "GEMNASIUM_RESOLVE"
+	ENV_IP_VERSION                   = "GEMNASIUM_IP_VERSION"
+	ENV_API_SOCKET                   = "GEMNASIUM_API_SOCKET"
+	ENV_API_HEADERS                  = "GEMNASIUM_API_HEADERS"
+	ENV_API_SIGNING_KEY              = "GEMNASIUM_API_SIGNING_KEY"
+	ENV_PROXY                        = "GEMNASIUM_PROXY"
+	ENV_CA_CERT                      = "GEMNASIUM_CA_CERT"
+	ENV_INSECURE                     = "GEMNASIUM_INSECURE"
+	ENV_DEBUG                        = "GEMNASIUM_DEBUG"
+	ENV_ADVISORY_DB                  = "GEMNASIUM_ADVISORY_DB"
+	ENV_RISK_WEIGHTS                 = "GEMNASIUM_RISK_WEIGHTS"
 	ENV_GEMNASIUM_TESTSUITE          = "GEMNASIUM_TESTSUITE"
 	ENV_GEMNASIUM_BUNDLE_INSTALL_CMD = "GEMNASIUM_BUNDLE_INSTALL_CMD"
 	ENV_GEMNASIUM_BUNDLE_UPDATE_CMD  = "GEMNASIUM_BUNDLE_UPDATE_CMD"
+	ENV_GEMNASIUM_NPM_UPDATE_CMD     = "GEMNASIUM_NPM_UPDATE_CMD"
+	ENV_GEMNASIUM_GEM_INSTALL_CMD    = "GEMNASIUM_GEM_INSTALL_CMD"
+	ENV_GEMNASIUM_NPM_UPGRADE_CMD    = "GEMNASIUM_NPM_UPGRADE_CMD"
+	ENV_GEMNASIUM_GO_GET_CMD         = "GEMNASIUM_GO_GET_CMD"
+	ENV_GEMNASIUM_CARGO_UPDATE_CMD   = "GEMNASIUM_CARGO_UPDATE_CMD"
+	ENV_GEMNASIUM_MVN_UPDATE_CMD     = "GEMNASIUM_MVN_UPDATE_CMD"
+	ENV_GEMNASIUM_DOTNET_ADD_CMD     = "GEMNASIUM_DOTNET_ADD_CMD"
+	ENV_GEMNASIUM_VERIFY_VERSIONS    = "GEMNASIUM_VERIFY_VERSIONS"
+	ENV_GEMNASIUM_MIN_RELEASE_AGE    = "GEMNASIUM_MIN_RELEASE_AGE"
+	ENV_GEMNASIUM_SIMULATE           = "GEMNASIUM_SIMULATE"
+	ENV_GEMNASIUM_TEST_RETRIES       = "GEMNASIUM_TEST_RETRIES"
+	ENV_GEMNASIUM_CLEAN_ENV          = "GEMNASIUM_CLEAN_ENV"
+	ENV_GEMNASIUM_PATCH_FALLBACK     = "GEMNASIUM_PATCH_FALLBACK"
+	ENV_GEMNASIUM_ARTIFACTS          = "GEMNASIUM_ARTIFACTS"
+	ENV_S3_ENDPOINT                  = "GEMNASIUM_S3_ENDPOINT"
+	ENV_S3_REGION                    = "GEMNASIUM_S3_REGION"
+	ENV_S3_BUCKET                    = "GEMNASIUM_S3_BUCKET"
+	ENV_SMTP_HOST                    = "GEMNASIUM_SMTP_HOST"
+	ENV_SMTP_PORT                    = "GEMNASIUM_SMTP_PORT"
+	ENV_SMTP_USERNAME                = "GEMNASIUM_SMTP_USERNAME"
+	ENV_SMTP_PASSWORD                = "GEMNASIUM_SMTP_PASSWORD"
+	ENV_SMTP_FROM                    = "GEMNASIUM_SMTP_FROM"
+	ENV_SMTP_TO                      = "GEMNASIUM_SMTP_TO"
+	ENV_WORKER_NAME                  = "GEMNASIUM_WORKER_NAME"
+	ENV_WORKER_QUEUE                 = "GEMNASIUM_WORKER_QUEUE"
+	ENV_OCI_USERNAME                 = "GEMNASIUM_OCI_USERNAME"
+	ENV_OCI_PASSWORD                 = "GEMNASIUM_OCI_PASSWORD"
+	ENV_WEBHOOK_URL                  = "GEMNASIUM_WEBHOOK_URL"
+	ENV_WEBHOOK_SECRET               = "GEMNASIUM_WEBHOOK_SECRET"
+	ENV_GITHUB_TOKEN                 = "GEMNASIUM_GITHUB_TOKEN"
 
-	DEFAULT_API_ENDPOINT = "https://api.gemnasium.com/v1"
+	DEFAULT_API_ENDPOINT     = "https://api.gemnasium.com/v1"
+	DEFAULT_MAX_PAYLOAD_SIZE = 1024 * 1024 // 1 MB
+	DEFAULT_ORG_CONCURRENCY  = 4
+	DEFAULT_API_RETRIES      = 3
+	DEFAULT_S3_ENDPOINT      = "https://s3.amazonaws.com"
+	DEFAULT_S3_REGION        = "us-east-1"
+	DEFAULT_SMTP_PORT        = 25
+	DEFAULT_SMTP_FROM        = "gemnasium@localhost"
+	DEFAULT_WORKER_QUEUE     = "api"
+	DEFAULT_WEBHOOK_URL      = "http://localhost:8080/webhooks"
+
+	DEFAULT_API_RETRY_BACKOFF     = 500 * time.Millisecond
+	DEFAULT_API_RETRY_MAX_BACKOFF = 30 * time.Second
+	DEFAULT_API_TIMEOUT           = 2 * time.Minute
 )
 
 func init() {
@@ -49,6 +267,20 @@ func getEnvOrElse(name, defaultValue string) string {
 	return value
 }
 
+// Cache files are stored in ~/.gemnasium/cache by default
+func defaultCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(home, ".gemnasium", "cache")
+}
+
+func defaultWorkerName() string {
+	name, _ := os.Hostname()
+	return name
+}
+
 func loadConfig() {
 	dat, err := ioutil.ReadFile(CONFIG_FILE_PATH)
 	if err != nil {
@@ -71,11 +303,275 @@ func loadConfig() {
 	if project_slug, ok := c["project_slug"]; ok {
 		ProjectSlug = project_slug.(string)
 	}
+	if projects, ok := c["projects"].(map[interface{}]interface{}); ok {
+		for dir, project := range projects {
+			switch p := project.(type) {
+			case string:
+				ProjectMappings[dir.(string)] = p
+			case map[interface{}]interface{}:
+				// Long form, with the project settings
+				if slug, ok := p["slug"]; ok {
+					ProjectMappings[dir.(string)] = slug.(string)
+				}
+				if ignored_paths, ok := p["ignored_paths"]; ok {
+					for _, ip := range ignored_paths.([]interface{}) {
+						ProjectIgnoredPaths[dir.(string)] = append(ProjectIgnoredPaths[dir.(string)], ip.(string))
+					}
+				}
+			}
+		}
+	}
 	if ignored_paths, ok := c["ignored_paths"]; ok {
 		for _, ip := range ignored_paths.([]interface{}) {
 			IgnoredPaths = append(IgnoredPaths, ip.(string))
 		}
 	}
+	if read_only, ok := c["read_only"]; ok {
+		ReadOnly = read_only.(bool)
+	}
+	if cache_dir, ok := c["cache_dir"]; ok {
+		CacheDir = cache_dir.(string)
+	}
+	if max_payload_size, ok := c["max_payload_size"]; ok {
+		MaxPayloadSize = int64(max_payload_size.(int))
+	}
+	if max_file_size, ok := c["max_file_size"]; ok {
+		MaxFileSize = int64(max_file_size.(int))
+	}
+	if vcs, ok := c["vcs"]; ok {
+		VCS = vcs.(string)
+	}
+	if incremental_push, ok := c["incremental_push"]; ok {
+		IncrementalPush = incremental_push.(bool)
+	}
+	if registries, ok := c["registries"].(map[interface{}]interface{}); ok {
+		for packageType, url := range registries {
+			RegistryMirrors[packageType.(string)] = url.(string)
+		}
+	}
+	if aliases, ok := c["aliases"].(map[interface{}]interface{}); ok {
+		for alias, command := range aliases {
+			Aliases[alias.(string)] = command.(string)
+		}
+	}
+	if default_command, ok := c["default_command"]; ok {
+		DefaultCommand = default_command.(string)
+	}
+	if s3, ok := c["s3"].(map[interface{}]interface{}); ok {
+		if endpoint, ok := s3["endpoint"]; ok {
+			S3Endpoint = endpoint.(string)
+		}
+		if region, ok := s3["region"]; ok {
+			S3Region = region.(string)
+		}
+		if bucket, ok := s3["bucket"]; ok {
+			S3Bucket = bucket.(string)
+		}
+		if prefix, ok := s3["prefix"]; ok {
+			S3Prefix = prefix.(string)
+		}
+	}
+	if smtp, ok := c["smtp"].(map[interface{}]interface{}); ok {
+		if host, ok := smtp["host"]; ok {
+			SMTPHost = host.(string)
+		}
+		if port, ok := smtp["port"]; ok {
+			SMTPPort = port.(int)
+		}
+		if username, ok := smtp["username"]; ok {
+			SMTPUsername = username.(string)
+		}
+		if from, ok := smtp["from"]; ok {
+			SMTPFrom = from.(string)
+		}
+		if to, ok := smtp["to"]; ok {
+			for _, address := range to.([]interface{}) {
+				SMTPTo = append(SMTPTo, address.(string))
+			}
+		}
+		if subject, ok := smtp["subject"]; ok {
+			SMTPSubject = subject.(string)
+		}
+		if body, ok := smtp["body"]; ok {
+			SMTPBody = body.(string)
+		}
+	}
+	if worker, ok := c["worker"].(map[interface{}]interface{}); ok {
+		if name, ok := worker["name"]; ok {
+			WorkerName = name.(string)
+		}
+		if queue, ok := worker["queue"]; ok {
+			WorkerQueue = queue.(string)
+		}
+	}
+	if webhooks, ok := c["webhooks"].(map[interface{}]interface{}); ok {
+		if url, ok := webhooks["url"]; ok {
+			WebhookURL = url.(string)
+		}
+	}
+	if org, ok := c["org"].(map[interface{}]interface{}); ok {
+		if concurrency, ok := org["concurrency"]; ok {
+			OrgConcurrency = concurrency.(int)
+		}
+		if qps, ok := org["qps"]; ok {
+			switch v := qps.(type) {
+			case int:
+				OrgQPS = float64(v)
+			case float64:
+				OrgQPS = v
+			}
+		}
+	}
+	if max_conns_per_host, ok := c["max_conns_per_host"]; ok {
+		MaxConnsPerHost = max_conns_per_host.(int)
+	}
+	if api_retry, ok := c["api_retry"].(map[interface{}]interface{}); ok {
+		if attempts, ok := api_retry["attempts"]; ok {
+			APIRetries = attempts.(int)
+		}
+		if backoff, ok := api_retry["backoff"]; ok {
+			if d, err := time.ParseDuration(backoff.(string)); err == nil {
+				APIRetryBackoff = d
+			}
+		}
+		if max_backoff, ok := api_retry["max_backoff"]; ok {
+			if d, err := time.ParseDuration(max_backoff.(string)); err == nil {
+				APIRetryMaxBackoff = d
+			}
+		}
+		if statuses, ok := api_retry["statuses"]; ok {
+			APIRetryStatuses = []int{}
+			for _, status := range statuses.([]interface{}) {
+				APIRetryStatuses = append(APIRetryStatuses, status.(int))
+			}
+		}
+	}
+	if api_timeout, ok := c["api_timeout"]; ok {
+		if d, err := time.ParseDuration(api_timeout.(string)); err == nil {
+			APITimeout = d
+		}
+	}
+	if timeout, ok := c["timeout"]; ok {
+		if d, err := time.ParseDuration(timeout.(string)); err == nil {
+			CommandTimeout = d
+		}
+	}
+	if resolve, ok := c["resolve"]; ok {
+		for _, entry := range resolve.([]interface{}) {
+			Resolve = append(Resolve, entry.(string))
+		}
+	}
+	if ip_version, ok := c["ip_version"]; ok {
+		IPVersion = ip_version.(int)
+	}
+	if api_socket, ok := c["api_socket"]; ok {
+		APISocket = api_socket.(string)
+	}
+	if api_headers, ok := c["api_headers"].(map[interface{}]interface{}); ok {
+		for name, value := range api_headers {
+			APIHeaders[name.(string)] = value.(string)
+		}
+	}
+	if api_signing_key, ok := c["api_signing_key"]; ok {
+		APISigningKey = api_signing_key.(string)
+	}
+	if proxy, ok := c["proxy"]; ok {
+		Proxy = proxy.(string)
+	}
+	if ca_cert, ok := c["ca_cert"]; ok {
+		CACert = ca_cert.(string)
+	}
+	if insecure, ok := c["insecure"]; ok {
+		Insecure = insecure.(bool)
+	}
+	if risk_weights, ok := c["risk_weights"].(map[interface{}]interface{}); ok {
+		for signal, weight := range risk_weights {
+			switch w := weight.(type) {
+			case int:
+				RiskWeights[signal.(string)] = float64(w)
+			case float64:
+				RiskWeights[signal.(string)] = w
+			}
+		}
+	}
+	if advisory_db, ok := c["advisory_db"]; ok {
+		AdvisoryDB = advisory_db.(string)
+	}
+	if scan_concurrency, ok := c["scan_concurrency"]; ok {
+		ScanConcurrency = scan_concurrency.(int)
+	}
+	if deepen_budget, ok := c["deepen_budget"]; ok {
+		DeepenBudget = deepen_budget.(int)
+	}
+	if autoupdate, ok := c["autoupdate"].(map[interface{}]interface{}); ok {
+		if verify_versions, ok := autoupdate["verify_versions"]; ok {
+			VerifyVersions = verify_versions.(bool)
+		}
+		if min_release_age, ok := autoupdate["min_release_age"]; ok {
+			MinReleaseAge = min_release_age.(string)
+		}
+		if simulate, ok := autoupdate["simulate"]; ok {
+			SimulateSets = simulate.(bool)
+		}
+		if test_retries, ok := autoupdate["test_retries"]; ok {
+			TestRetries = test_retries.(int)
+		}
+		if patch_fallback, ok := autoupdate["patch_fallback"]; ok {
+			PatchFallback = patch_fallback.(bool)
+		}
+		if env, ok := autoupdate["env"].(map[interface{}]interface{}); ok {
+			for name, value := range env {
+				SubprocessEnv[name.(string)] = fmt.Sprint(value)
+			}
+		}
+		if unset_env, ok := autoupdate["unset_env"]; ok {
+			for _, name := range unset_env.([]interface{}) {
+				UnsetEnv = append(UnsetEnv, name.(string))
+			}
+		}
+		if min_tool_versions, ok := autoupdate["min_tool_versions"].(map[interface{}]interface{}); ok {
+			for binary, version := range min_tool_versions {
+				MinToolVersions[binary.(string)] = fmt.Sprint(version)
+			}
+		}
+		if artifacts, ok := autoupdate["artifacts"]; ok {
+			Artifacts = artifacts.(string)
+		}
+		if clean_env, ok := autoupdate["clean_env"]; ok {
+			CleanEnv = clean_env.(bool)
+		}
+		if git_groups, ok := autoupdate["git_groups"].(map[interface{}]interface{}); ok {
+			for name, pattern := range git_groups {
+				GitGroups[name.(string)] = pattern.(string)
+			}
+		}
+		if group_types, ok := autoupdate["group_types"]; ok {
+			GitGroupTypes = group_types.(bool)
+		}
+		if pull_requests, ok := autoupdate["pull_requests"].(map[interface{}]interface{}); ok {
+			if labels, ok := pull_requests["labels"]; ok {
+				for _, label := range labels.([]interface{}) {
+					PRLabels = append(PRLabels, label.(string))
+				}
+			}
+			if assignees, ok := pull_requests["assignees"]; ok {
+				for _, assignee := range assignees.([]interface{}) {
+					PRAssignees = append(PRAssignees, assignee.(string))
+				}
+			}
+			if auto_merge, ok := pull_requests["auto_merge"]; ok {
+				PRAutoMerge = auto_merge.(string)
+			}
+			if status, ok := pull_requests["status"]; ok {
+				PRStatus = status.(bool)
+			}
+		}
+		if pass_env, ok := autoupdate["pass_env"]; ok {
+			for _, name := range pass_env.([]interface{}) {
+				PassEnv = append(PassEnv, name.(string))
+			}
+		}
+	}
 }
 
 func loadEnv() {
@@ -88,6 +584,136 @@ func loadEnv() {
 	if raw := os.Getenv(ENV_RAW_FORMAT); raw != "" {
 		RawFormat = true
 	}
+	if readOnly := os.Getenv(ENV_READ_ONLY); readOnly != "" {
+		ReadOnly = true
+	}
+	if nonInteractive := os.Getenv(ENV_NON_INTERACTIVE); nonInteractive != "" {
+		NonInteractive = true
+	}
+	if os.Getenv(ENV_OUTPUT) == "json" {
+		JSONOutput = true
+	}
+	CacheDir = getEnvOrElse(ENV_CACHE_DIR, CacheDir)
+	if size, err := strconv.ParseInt(os.Getenv(ENV_MAX_PAYLOAD_SIZE), 10, 64); err == nil {
+		MaxPayloadSize = size
+	}
+	if size, err := strconv.ParseInt(os.Getenv(ENV_MAX_FILE_SIZE), 10, 64); err == nil {
+		MaxFileSize = size
+	}
+	VCS = getEnvOrElse(ENV_VCS, VCS)
+	if incremental := os.Getenv(ENV_INCREMENTAL_PUSH); incremental != "" {
+		IncrementalPush = true
+	}
+	for packageType, env := range map[string]string{"rubygem": ENV_RUBYGEMS_MIRROR, "npm": ENV_NPM_MIRROR, "packagist": ENV_PACKAGIST_MIRROR} {
+		if mirror := os.Getenv(env); mirror != "" {
+			RegistryMirrors[packageType] = mirror
+		}
+	}
+	if concurrency, err := strconv.Atoi(os.Getenv(ENV_ORG_CONCURRENCY)); err == nil {
+		OrgConcurrency = concurrency
+	}
+	if qps, err := strconv.ParseFloat(os.Getenv(ENV_ORG_QPS), 64); err == nil {
+		OrgQPS = qps
+	}
+	if conns, err := strconv.Atoi(os.Getenv(ENV_MAX_CONNS_PER_HOST)); err == nil {
+		MaxConnsPerHost = conns
+	}
+	if retries, err := strconv.Atoi(os.Getenv(ENV_API_RETRIES)); err == nil {
+		APIRetries = retries
+	}
+	if backoff, err := time.ParseDuration(os.Getenv(ENV_API_RETRY_BACKOFF)); err == nil {
+		APIRetryBackoff = backoff
+	}
+	if statuses := os.Getenv(ENV_API_RETRY_STATUSES); statuses != "" {
+		APIRetryStatuses = []int{}
+		for _, status := range strings.Split(statuses, ",") {
+			if code, err := strconv.Atoi(strings.TrimSpace(status)); err == nil {
+				APIRetryStatuses = append(APIRetryStatuses, code)
+			}
+		}
+	}
+	if timeout, err := time.ParseDuration(os.Getenv(ENV_API_TIMEOUT)); err == nil {
+		APITimeout = timeout
+	}
+	if timeout, err := time.ParseDuration(os.Getenv(ENV_TIMEOUT)); err == nil {
+		CommandTimeout = timeout
+	}
+	if resolve := os.Getenv(ENV_RESOLVE); resolve != "" {
+		Resolve = strings.Split(resolve, ",")
+	}
+	if version, err := strconv.Atoi(os.Getenv(ENV_IP_VERSION)); err == nil {
+		IPVersion = version
+	}
+	APISocket = getEnvOrElse(ENV_API_SOCKET, APISocket)
+	if headers := os.Getenv(ENV_API_HEADERS); headers != "" {
+		for _, header := range strings.Split(headers, ",") {
+			if name, value, err := ParseHeader(header); err == nil {
+				APIHeaders[name] = value
+			}
+		}
+	}
+	APISigningKey = getEnvOrElse(ENV_API_SIGNING_KEY, APISigningKey)
+	Proxy = getEnvOrElse(ENV_PROXY, Proxy)
+	CACert = getEnvOrElse(ENV_CA_CERT, CACert)
+	if insecure := os.Getenv(ENV_INSECURE); insecure != "" {
+		Insecure = true
+	}
+	if debug := os.Getenv(ENV_DEBUG); debug != "" {
+		Debug = true
+	}
+	AdvisoryDB = getEnvOrElse(ENV_ADVISORY_DB, AdvisoryDB)
+	for _, pair := range strings.Split(os.Getenv(ENV_RISK_WEIGHTS), ",") {
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			continue
+		}
+		if weight, err := strconv.ParseFloat(strings.TrimSpace(parts[1]), 64); err == nil {
+			RiskWeights[strings.TrimSpace(parts[0])] = weight
+		}
+	}
+	if concurrency, err := strconv.Atoi(os.Getenv(ENV_SCAN_CONCURRENCY)); err == nil {
+		ScanConcurrency = concurrency
+	}
+	if budget, err := strconv.Atoi(os.Getenv(ENV_DEEPEN_BUDGET)); err == nil {
+		DeepenBudget = budget
+	}
+	if verify := os.Getenv(ENV_GEMNASIUM_VERIFY_VERSIONS); verify != "" {
+		VerifyVersions = true
+	}
+	MinReleaseAge = getEnvOrElse(ENV_GEMNASIUM_MIN_RELEASE_AGE, MinReleaseAge)
+	if simulate := os.Getenv(ENV_GEMNASIUM_SIMULATE); simulate != "" {
+		SimulateSets = true
+	}
+	if retries, err := strconv.Atoi(os.Getenv(ENV_GEMNASIUM_TEST_RETRIES)); err == nil {
+		TestRetries = retries
+	}
+	if clean := os.Getenv(ENV_GEMNASIUM_CLEAN_ENV); clean != "" {
+		CleanEnv = true
+	}
+	if fallback := os.Getenv(ENV_GEMNASIUM_PATCH_FALLBACK); fallback != "" {
+		PatchFallback = true
+	}
+	Artifacts = getEnvOrElse(ENV_GEMNASIUM_ARTIFACTS, Artifacts)
+	S3Endpoint = getEnvOrElse(ENV_S3_ENDPOINT, S3Endpoint)
+	S3Region = getEnvOrElse(ENV_S3_REGION, S3Region)
+	S3Bucket = getEnvOrElse(ENV_S3_BUCKET, S3Bucket)
+	SMTPHost = getEnvOrElse(ENV_SMTP_HOST, SMTPHost)
+	if port, err := strconv.Atoi(os.Getenv(ENV_SMTP_PORT)); err == nil {
+		SMTPPort = port
+	}
+	SMTPUsername = getEnvOrElse(ENV_SMTP_USERNAME, SMTPUsername)
+	SMTPPassword = os.Getenv(ENV_SMTP_PASSWORD)
+	SMTPFrom = getEnvOrElse(ENV_SMTP_FROM, SMTPFrom)
+	if to := os.Getenv(ENV_SMTP_TO); to != "" {
+		SMTPTo = strings.Split(to, ",")
+	}
+	WorkerName = getEnvOrElse(ENV_WORKER_NAME, WorkerName)
+	WorkerQueue = getEnvOrElse(ENV_WORKER_QUEUE, WorkerQueue)
+	OCIUsername = os.Getenv(ENV_OCI_USERNAME)
+	OCIPassword = os.Getenv(ENV_OCI_PASSWORD)
+	WebhookURL = getEnvOrElse(ENV_WEBHOOK_URL, WebhookURL)
+	WebhookSecret = os.Getenv(ENV_WEBHOOK_SECRET)
+	GitHubToken = os.Getenv(ENV_GITHUB_TOKEN)
 }
 
 func DisplayEnvVars() {
@@ -99,11 +725,85 @@ func DisplayEnvVars() {
 		ENV_REVISION:                     "Current revision.",
 		ENV_IGNORED_PATHS:                "When using the 'eval' or 'df push' commands, if --files is empty, gemnasium will look for files locally. Paths to be ignored can be set with this var, separated with a comma.",
 		ENV_RAW_FORMAT:                   "Display raw json response from API server.",
+		ENV_OUTPUT:                       "Output format: 'json' prints JSON documents on stdout instead of tables and messages (see --json).",
+		ENV_READ_ONLY:                    "Block the commands changing data, on Gemnasium or locally (push, create, update, autoupdate...). Useful with tokens meant for reporting only.",
+		ENV_NON_INTERACTIVE:              "Never prompt for input (same as --non-interactive, enabled when stdin isn't a terminal): commands fail instead, telling which flag or argument to supply.",
+		ENV_NO_DEPRECATION_WARNINGS:      "Don't display warnings when using deprecated commands or flags.",
+		ENV_STRICT_DEPRECATIONS:          "Fail when using deprecated commands or flags (same as --strict-deprecations).",
+		ENV_LANG:                         "Language of messages (ex: fr). default: from LC_ALL, LC_MESSAGES or LANG, or English",
+		ENV_CACHE_DIR:                    "Directory where cached data (registry metadata, ...) is stored. default: ~/.gemnasium/cache",
+		ENV_MAX_PAYLOAD_SIZE:             "When pushing dependency files, ask for confirmation if the payload is bigger than this size (in bytes). default: 1048576 (1 MB)",
+		ENV_MAX_FILE_SIZE:                "Dependency files bigger than this size (in bytes) are hashed without keeping their content in memory, which is only read when needed (ie: to send them). default: no limit",
+		ENV_VCS:                          "How the SHA of dependency files is computed, like the VCS of the project does: git (blob SHA1), hg (file revision hash without parents, matching first revisions only), svn (SHA1 checksum) or none (SHA256). default: detected from the working directory",
+		ENV_INCREMENTAL_PUSH:             "When pushing dependency files, send their paths and SHAs first, and only the content of the files Gemnasium doesn't have yet (same as --incremental).",
+		ENV_RUBYGEMS_MIRROR:              "Rubygems mirror (ex: Artifactory, Nexus) used instead of https://rubygems.org to fetch gems metadata.",
+		ENV_NPM_MIRROR:                   "npm registry mirror used instead of https://registry.npmjs.org to fetch packages metadata.",
+		ENV_PACKAGIST_MIRROR:             "Packagist mirror used instead of https://repo.packagist.org to fetch packages metadata.",
+		ENV_ORG_CONCURRENCY:              "Number of projects processed concurrently by org commands. default: 4",
+		ENV_ORG_QPS:                      "Max number of API requests per second sent by org commands. default: unlimited",
+		ENV_MAX_CONNS_PER_HOST:           "Max number of connections per host (API and registries). default: unlimited",
+		ENV_API_RETRIES:                  "Number of times API requests failing with network errors or retryable statuses are sent again (0: never, same as --no-retry). default: 3",
+		ENV_API_RETRY_BACKOFF:            "Delay before the first retry of an API request, doubled on every attempt, with a random jitter (ex: 500ms, 2s). default: 500ms",
+		ENV_API_RETRY_STATUSES:           "HTTP statuses of the API responses to retry, separated with a comma. default: 502,503,504",
+		ENV_API_TIMEOUT:                  "Max duration of each API request attempt, after which it's aborted and retried (ex: 30s, 5m, 0 for unlimited). default: 2m",
+		ENV_TIMEOUT:                      "Max duration of the whole command (same as --timeout, ex: 10m): a partial report is displayed, and gemnasium exits with code 124. default: unlimited",
+		ENV_RESOLVE:                      "Addresses to connect to instead of resolving hosts, separated with a comma, like curl --resolve (ex: api.gemnasium.com:443:10.0.0.1).",
+		ENV_IP_VERSION:                   "Only connect with IPv4 (4) or IPv6 (6) addresses (same as --ipv4 and --ipv6). default: both",
+		ENV_API_SOCKET:                   "Unix socket to connect to instead of the API host, ie: a local gateway fronting the API (the API endpoint still gives the host and paths).",
+		ENV_API_HEADERS:                  "Headers added to API requests, as 'Name: value' separated with a comma (same as --header). Values can reference env vars (ex: 'X-Gateway-Token: ${GATEWAY_TOKEN}').",
+		ENV_API_SIGNING_KEY:              "Key used to sign API requests for a gateway: X-Gms-Signature is the HMAC-SHA256 of the method, path, X-Gms-Timestamp and SHA-256 of the body, separated with newlines.",
+		ENV_PROXY:                        "Proxy URL used for all requests (API and registries). default: from HTTPS_PROXY, HTTP_PROXY and NO_PROXY",
+		ENV_CA_CERT:                      "PEM file of CA certificates to trust in addition to the system ones (ex: the CA of a TLS-intercepting proxy).",
+		ENV_INSECURE:                     "Don't verify TLS certificates. Only meant for debugging, prefer GEMNASIUM_CA_CERT.",
+		ENV_DEBUG:                        "Log diagnostics on stderr, like the fields of API responses unknown to this release (same as --debug).",
+		ENV_ADVISORY_DB:                  "Path of the advisory database used by offline scans (scan --offline), downloaded with 'gemnasium advisories sync'. default: advisories/db.json in the cache directory",
+		ENV_RISK_WEIGHTS:                 "Weights of the signals of the risk score, as 'signal=weight' separated with a comma, between 0 and 1 (signals: cvss, epss, freshness, maintenance and reachability). default: cvss=1,epss=0.6,freshness=0.3,maintenance=0.6,reachability=0.5",
+		ENV_SCAN_CONCURRENCY:             "Number of dependency files read and hashed concurrently when looking for files locally. default: number of CPUs",
+		ENV_DEEPEN_BUDGET:                "Max number of commits fetched (git fetch --deepen) when a revision is missing from a shallow clone. default: 0 (never fetch)",
 		ENV_GEMNASIUM_TESTSUITE:          "Used for auto-update command, to set the testsuite to run.",
 		ENV_GEMNASIUM_BUNDLE_INSTALL_CMD: "[auto-update] Override command used with ruby sets. default: 'bundle install'",
 		ENV_GEMNASIUM_BUNDLE_UPDATE_CMD:  "[auto-update] Override command used with ruby sets. default: 'bundle update'",
+		ENV_GEMNASIUM_NPM_UPDATE_CMD:     "[auto-update] Override command used with npm sets, the packages to install are appended (<package>@<version>). default: 'npm install'",
+		ENV_GEMNASIUM_GEM_INSTALL_CMD:    "[auto-update] Override command used to install bundler when a ruby set updates it, '--install-dir <dir> --bindir <dir>/bin bundler -v <version>' is appended. default: 'gem install'",
+		ENV_GEMNASIUM_NPM_UPGRADE_CMD:    "[auto-update] Override command used to upgrade npm when an npm set updates it, '--prefix <dir> npm@<version>' is appended. default: 'npm install -g'",
+		ENV_GEMNASIUM_GO_GET_CMD:         "[auto-update] Override command used with Go modules sets, the modules to get are appended (<module>@<version>). 'go mod tidy' is run afterwards. default: 'go get'",
+		ENV_GEMNASIUM_CARGO_UPDATE_CMD:   "[auto-update] Override command used with Cargo sets, '-p <crate> --precise <version>' is appended. default: 'cargo update'",
+		ENV_GEMNASIUM_MVN_UPDATE_CMD:     "[auto-update] Override command used with Maven sets, '-Dincludes=<groupId:artifactId> -DdepVersion=<version>' is appended. default: 'mvn versions:use-dep-version'",
+		ENV_GEMNASIUM_DOTNET_ADD_CMD:     "[auto-update] Override command used with NuGet sets, '<package> --version <version>' is appended. default: 'dotnet add package'",
+		ENV_GEMNASIUM_VERIFY_VERSIONS:    "[auto-update] Check target versions on the official registries (existence, yanked, signatures) before applying update sets.",
+		ENV_GEMNASIUM_MIN_RELEASE_AGE:    "[auto-update] Defer update sets targeting versions released more recently than this (ex: 7d, 12h).",
+		ENV_GEMNASIUM_SIMULATE:           "[auto-update] Check that update sets can be resolved, using the requirements published on the registries, before running the package managers.",
+		ENV_GEMNASIUM_TEST_RETRIES:       "[auto-update] Number of times a failing test suite is run again before marking the update set as failed. default: 0",
+		ENV_GEMNASIUM_CLEAN_ENV:          "[auto-update] Run update and test commands with a clean environment (PATH, HOME, locale, and the pass_env vars of .gemnasium.yml) instead of the current one.",
+		ENV_GEMNASIUM_PATCH_FALLBACK:     "[auto-update] Use the patch command when a patch can't be applied natively (it must be installed).",
+		ENV_GEMNASIUM_ARTIFACTS:          "[auto-update] Upload the artifacts of failed update sets (logs, diff): api or s3.",
+		ENV_S3_ENDPOINT:                  "URL of the S3-compatible server used to upload artifacts. default: https://s3.amazonaws.com",
+		ENV_S3_REGION:                    "Region of the S3 bucket. default: us-east-1",
+		ENV_S3_BUCKET:                    "Name of the S3 bucket. Credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY.",
+		ENV_SMTP_HOST:                    "[auto-update] SMTP server used to email the summary of runs (see the smtp section of .gemnasium.yml).",
+		ENV_SMTP_PORT:                    "SMTP server port. default: 25",
+		ENV_SMTP_USERNAME:                "SMTP username, if the server requires authentication.",
+		ENV_SMTP_PASSWORD:                "SMTP password.",
+		ENV_SMTP_FROM:                    "Sender of emails. default: gemnasium@localhost",
+		ENV_SMTP_TO:                      "Recipients of emails, separated with a comma.",
+		ENV_WORKER_NAME:                  "[auto-update] Name of the worker (autoupdate worker), jobs targeting other workers are left to them. default: hostname",
+		ENV_WORKER_QUEUE:                 "[auto-update] Where the worker gets its jobs: api, or the URL of an SQS queue (credentials are read from AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY). default: api",
+		ENV_OCI_USERNAME:                 "Username on the OCI registry SBOMs are pushed to (sbom push), ie: your GitHub username for ghcr.io.",
+		ENV_OCI_PASSWORD:                 "Password or token on the OCI registry. Anonymous if not set.",
+		ENV_WEBHOOK_URL:                  "Endpoint simulated webhooks are posted to (webhooks simulate). default: http://localhost:8080/webhooks",
+		ENV_WEBHOOK_SECRET:               "Secret of the X-Gemnasium-Signature header of simulated webhooks (HMAC-SHA256 of the payload). Not signed if not set.",
+		ENV_GITHUB_TOKEN:                 "[auto-update] GitHub token used to open pull requests from the branches of successful update sets (autoupdate run --pull-request).",
 	}
 	for k, _ := range vars {
 		fmt.Printf("%s=%s\n", k, os.Getenv(k))
 	}
 }
+
+// Parse a "Name: value" header, as given to --header
+func ParseHeader(header string) (string, string, error) {
+	parts := strings.SplitN(header, ":", 2)
+	if len(parts) != 2 || strings.TrimSpace(parts[0]) == "" {
+		return "", "", fmt.Errorf("invalid header %q, expected 'Name: value'", header)
+	}
+	return strings.TrimSpace(parts[0]), strings.TrimSpace(parts[1]), nil
+}
@@ -0,0 +1,262 @@
+package demo
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+// Fake Gemnasium API, serving the fixture projects. It also serves the
+// rubygems and npm registries (under /rubygems and /npm), for the reports
+// using release dates.
+type api struct {
+	sync.Mutex
+	// SHAs of the dependency files pushed so far, by project and path
+	pushed map[string]map[string]string
+}
+
+// Start the fake API on a local port
+func NewServer() *httptest.Server {
+	a := &api{pushed: map[string]map[string]string{}}
+	return httptest.NewServer(a)
+}
+
+func (a *api) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case parts[0] == "rubygems":
+		a.serveRubygems(w, strings.TrimSuffix(path.Base(r.URL.Path), ".json"))
+	case parts[0] == "npm":
+		a.serveNpm(w, path.Base(r.URL.Path))
+	case r.URL.Path == models.CLIENT_VERSION_PATH:
+		respond(w, models.ClientVersion{LatestVersion: config.VERSION})
+	case r.URL.Path == models.ADVISORIES_PATH:
+		respond(w, advisories)
+	case parts[0] == "evaluate":
+		a.serveEvaluation(w, r, parts)
+	case parts[0] == "projects":
+		a.serveProjects(w, r, parts)
+	default:
+		notFound(w)
+	}
+}
+
+func respond(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(v)
+}
+
+func notFound(w http.ResponseWriter) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusNotFound)
+	json.NewEncoder(w).Encode(map[string]string{"message": "Not available in the demo"})
+}
+
+// /projects, /projects/:slug and their dependencies, alerts and dependency
+// files
+func (a *api) serveProjects(w http.ResponseWriter, r *http.Request, parts []string) {
+	if len(parts) == 1 {
+		owned := []models.Project{}
+		for _, p := range projects {
+			owned = append(owned, p.Project)
+		}
+		respond(w, map[string][]models.Project{"owned": owned})
+		return
+	}
+	p := findProject(parts[1])
+	if p == nil {
+		notFound(w)
+		return
+	}
+	switch strings.Join(parts[2:], "/") {
+	case "":
+		respond(w, p.Project)
+	case "dependencies":
+		respond(w, p.dependencies)
+	case "alerts":
+		respond(w, p.alerts)
+	case "dependency_files":
+		if r.Method == "POST" {
+			a.push(w, r, p)
+			return
+		}
+		dfiles, err := fixtureFiles(p.dir)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		respond(w, dfiles)
+	case "dependency_files/needed":
+		a.needed(w, r, p)
+	default:
+		notFound(w)
+	}
+}
+
+func findProject(slug string) *project {
+	for i := range projects {
+		if projects[i].Slug == slug {
+			return &projects[i]
+		}
+	}
+	return nil
+}
+
+// Files of the first push are added, then updated if their SHA changed
+func (a *api) push(w http.ResponseWriter, r *http.Request, p *project) {
+	var dfiles []models.DependencyFile
+	if err := json.NewDecoder(r.Body).Decode(&dfiles); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a.Lock()
+	defer a.Unlock()
+	pushed := a.pushed[p.Slug]
+	if pushed == nil {
+		pushed = map[string]string{}
+		a.pushed[p.Slug] = pushed
+	}
+	result := models.PushResult{Added: []models.PushedDependencyFile{}, Updated: []models.PushedDependencyFile{}, Unchanged: []models.PushedDependencyFile{}, Unsupported: []models.PushedDependencyFile{}}
+	for _, df := range dfiles {
+		file := models.PushedDependencyFile{DependencyFile: models.DependencyFile{Path: df.Path, SHA: df.SHA}}
+		switch sha, ok := pushed[df.Path]; {
+		case !ok:
+			result.Added = append(result.Added, file)
+		case sha != df.SHA:
+			result.Updated = append(result.Updated, file)
+		default:
+			result.Unchanged = append(result.Unchanged, file)
+		}
+		pushed[df.Path] = df.SHA
+	}
+	respond(w, result)
+}
+
+// Paths of the files whose SHA isn't known yet (incremental pushes)
+func (a *api) needed(w http.ResponseWriter, r *http.Request, p *project) {
+	var signatures []struct {
+		Path string `json:"path"`
+		SHA  string `json:"sha"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&signatures); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	a.Lock()
+	defer a.Unlock()
+	paths := []string{}
+	for _, s := range signatures {
+		if a.pushed[p.Slug][s.Path] != s.SHA {
+			paths = append(paths, s.Path)
+		}
+	}
+	respond(w, map[string][]string{"paths": paths})
+}
+
+// Live evaluations are done right away: the job of a file is the evaluation
+// of the fixture project it belongs to (by name), all green if there's none
+func (a *api) serveEvaluation(w http.ResponseWriter, r *http.Request, parts []string) {
+	if r.Method == "POST" {
+		var body struct {
+			DependencyFiles []models.DependencyFile `json:"dependency_files"`
+		}
+		if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		job := "none"
+		for _, df := range body.DependencyFiles {
+			if p := projectOfFile(path.Base(df.Path)); p != nil {
+				job = p.Slug
+			}
+		}
+		respond(w, map[string]string{"job_id": job})
+		return
+	}
+	result := map[string]interface{}{"runtime_status": "green", "development_status": "green", "dependencies": []models.Dependency{}}
+	if len(parts) > 1 {
+		if p := findProject(parts[1]); p != nil {
+			result["dependencies"] = p.dependencies
+			result["runtime_status"] = worstColor(p.dependencies, "runtime")
+			result["development_status"] = worstColor(p.dependencies, "development")
+		}
+	}
+	respond(w, map[string]interface{}{"status": "completed", "result": result})
+}
+
+func projectOfFile(name string) *project {
+	for i := range projects {
+		if files, err := fixtures.ReadDir("fixtures/" + projects[i].dir); err == nil {
+			for _, f := range files {
+				if f.Name() == name {
+					return &projects[i]
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func worstColor(deps []models.Dependency, depType string) string {
+	ranks := map[string]int{"green": 0, "yellow": 1, "red": 2}
+	worst := "green"
+	for _, dep := range deps {
+		if dep.Type == depType && ranks[dep.Color] > ranks[worst] {
+			worst = dep.Color
+		}
+	}
+	return worst
+}
+
+// Sorted versions of a package, by release date
+func releasesOf(name string) []string {
+	versions := []string{}
+	for number := range releases[name] {
+		versions = append(versions, number)
+	}
+	sort.Slice(versions, func(i, j int) bool {
+		return releases[name][versions[i]].Before(*releases[name][versions[j]])
+	})
+	return versions
+}
+
+// GET /api/v1/versions/:name.json
+func (a *api) serveRubygems(w http.ResponseWriter, name string) {
+	type gemVersion struct {
+		Number    string    `json:"number"`
+		Platform  string    `json:"platform"`
+		CreatedAt time.Time `json:"created_at"`
+	}
+	versions := []gemVersion{}
+	for _, number := range releasesOf(name) {
+		versions = append(versions, gemVersion{number, "ruby", *releases[name][number]})
+	}
+	if len(versions) == 0 {
+		notFound(w)
+		return
+	}
+	respond(w, versions)
+}
+
+// GET /:name, the package metadata
+func (a *api) serveNpm(w http.ResponseWriter, name string) {
+	versions := map[string]interface{}{}
+	times := map[string]time.Time{}
+	for _, number := range releasesOf(name) {
+		versions[number] = map[string]string{"version": number}
+		times[number] = *releases[name][number]
+	}
+	if len(versions) == 0 {
+		notFound(w)
+		return
+	}
+	respond(w, map[string]interface{}{"name": name, "versions": versions, "time": times})
+}
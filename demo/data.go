@@ -0,0 +1,95 @@
+package demo
+
+import (
+	"time"
+
+	"github.com/gemnasium/toolbelt/models"
+)
+
+// A fixture project, as known by the fake API
+type project struct {
+	models.Project
+	// Directory of the project in fixtures
+	dir          string
+	dependencies []models.Dependency
+	alerts       []models.Alert
+}
+
+func date(year int, month time.Month, day int) *time.Time {
+	d := time.Date(year, month, day, 10, 0, 0, 0, time.UTC)
+	return &d
+}
+
+var (
+	rack     = models.Package{Name: "rack", Slug: "gems/rack", Type: "Rubygem"}
+	rails    = models.Package{Name: "rails", Slug: "gems/rails", Type: "Rubygem"}
+	nokogiri = models.Package{Name: "nokogiri", Slug: "gems/nokogiri", Type: "Rubygem"}
+	rake     = models.Package{Name: "rake", Slug: "gems/rake", Type: "Rubygem"}
+	lodash   = models.Package{Name: "lodash", Slug: "npm/lodash", Type: "Npm"}
+	express  = models.Package{Name: "express", Slug: "npm/express", Type: "Npm"}
+)
+
+// Advisories of the fake API, also served to sync the offline database
+var advisories = []models.Advisory{
+	{
+		ID: 1, Identifier: "CVE-2015-3225", Title: "Potential Denial of Service Vulnerability in Rack",
+		Description:      "Carefully crafted requests can cause a SystemStackError and potentially cause a denial of service attack.",
+		AffectedVersions: "<1.5.4 || >=1.6.0 <1.6.2", CuredVersions: ">=1.5.4 <1.6.0 || >=1.6.2", Package: rack,
+		Links: []string{"https://groups.google.com/forum/#!topic/rubyonrails-security/gcUbICUmKMc"}, PublishedAt: date(2015, 6, 16), CVSSScore: 7.5, EPSSScore: 0.03,
+	},
+	{
+		ID: 2, Identifier: "CVE-2016-0752", Title: "Possible Information Leak Vulnerability in Action View",
+		Description:      "Specially crafted requests can be used to leak the contents of arbitrary files on the server.",
+		AffectedVersions: "<3.2.22.1 || >=4.0.0 <4.1.14.1 || >=4.2.0 <4.2.5.1", CuredVersions: ">=4.2.5.1", Package: rails,
+		Links: []string{"https://groups.google.com/forum/#!topic/rubyonrails-security/335P1DcLG00"}, PublishedAt: date(2016, 1, 25), CVSSScore: 7.5, EPSSScore: 0.97,
+	},
+	{
+		ID: 3, Identifier: "CVE-2015-1819", Title: "Denial of service in libxml2",
+		AffectedVersions: "<1.6.7", CuredVersions: ">=1.6.7", Package: nokogiri, PublishedAt: date(2015, 11, 25), CVSSScore: 5,
+	},
+	{
+		ID: 4, Identifier: "CVE-2019-10744", Title: "Prototype Pollution in lodash",
+		Description:      "defaultsDeep could be tricked into adding or modifying properties of Object.prototype.",
+		AffectedVersions: "<4.17.12", CuredVersions: ">=4.17.12", Package: lodash,
+		Links: []string{"https://github.com/lodash/lodash/pull/4336"}, PublishedAt: date(2019, 7, 26), CVSSScore: 9.1, EPSSScore: 0.02,
+	},
+}
+
+var projects = []project{
+	{
+		Project: models.Project{Name: "rails-app", Slug: "demo-rails-app", Description: "Rails application (demo)", Color: "red", Monitored: true, Private: true},
+		dir:     "rails-app",
+		dependencies: []models.Dependency{
+			{Requirement: "= 4.2.0", LockedVersion: "4.2.0", Package: rails, Type: "runtime", FirstLevel: true, Color: "red", Advisories: advisories[1:2]},
+			{Requirement: ">= 0", LockedVersion: "1.6.6.2", Package: nokogiri, Type: "runtime", FirstLevel: true, Color: "red", Advisories: advisories[2:3]},
+			{Requirement: "~> 1.6", LockedVersion: "1.6.0", Package: rack, Type: "runtime", Color: "red", Advisories: advisories[0:1]},
+			{Requirement: ">= 0", LockedVersion: "10.4.2", Package: rake, Type: "development", FirstLevel: true, Color: "yellow"},
+		},
+		alerts: []models.Alert{
+			{ID: 1, Advisory: advisories[0], OpenAt: *date(2015, 6, 16), Status: "open"},
+			{ID: 2, Advisory: advisories[1], OpenAt: *date(2016, 1, 25), Status: "acknowledged"},
+			{ID: 3, Advisory: advisories[2], OpenAt: *date(2015, 11, 25), Status: "closed", ClosedAt: date(2016, 1, 4)},
+		},
+	},
+	{
+		Project: models.Project{Name: "node-app", Slug: "demo-node-app", Description: "Node.js application (demo)", Color: "red", Monitored: true},
+		dir:     "node-app",
+		dependencies: []models.Dependency{
+			{Requirement: "^4.16.0", LockedVersion: "4.16.0", Package: express, Type: "runtime", FirstLevel: true, Color: "yellow"},
+			{Requirement: "^4.17.11", LockedVersion: "4.17.11", Package: lodash, Type: "runtime", FirstLevel: true, Color: "red", Advisories: advisories[3:4]},
+		},
+		alerts: []models.Alert{
+			{ID: 4, Advisory: advisories[3], OpenAt: *date(2019, 7, 26), Status: "open"},
+		},
+	},
+}
+
+// Release dates of the packages, served by the fake registries
+var releases = map[string]map[string]*time.Time{
+	"rack":     {"1.6.0": date(2014, 12, 18), "1.6.2": date(2015, 6, 16), "1.6.4": date(2015, 6, 18)},
+	"rails":    {"4.2.0": date(2014, 12, 20), "4.2.5.1": date(2016, 1, 25), "5.0.0": date(2016, 6, 30)},
+	"nokogiri": {"1.6.6.2": date(2015, 1, 23), "1.6.7": date(2015, 11, 29)},
+	"rake":     {"10.4.2": date(2014, 12, 2), "10.5.0": date(2016, 1, 13)},
+	"lodash":   {"4.17.11": date(2018, 9, 12), "4.17.12": date(2019, 7, 9), "4.17.21": date(2021, 2, 20)},
+	"express":  {"4.16.0": date(2017, 9, 28), "4.17.1": date(2019, 5, 26)},
+}
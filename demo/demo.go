@@ -0,0 +1,181 @@
+// Package demo runs the main commands of the toolbelt against fixture
+// projects and a fake API (see NewServer), without credentials or network
+// access: to explore their output formats and exit codes.
+package demo
+
+import (
+	"embed"
+	"fmt"
+	"io"
+	"io/fs"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// Fixture projects, a directory each, with their .gemnasium.yml
+//
+//go:embed all:fixtures
+var fixtures embed.FS
+
+// A command of the demo, run in the directory of a fixture project
+type Step struct {
+	Name        string
+	Description string
+	Project     string
+	Args        []string
+}
+
+var Steps = []Step{
+	{"version", "Check that the toolbelt is supported by the API", "rails-app", []string{"version", "--check"}},
+	{"projects", "List the projects", "rails-app", []string{"projects", "list"}},
+	{"dependencies", "List the dependencies of the project, with their status", "rails-app", []string{"dependencies", "list"}},
+	{"alerts", "List the alerts, failing on high severity advisories (exit status 1)", "rails-app", []string{"alerts", "list", "--fail-on", "high"}},
+	{"push", "Push the dependency files, as a JSON document", "node-app", []string{"--json", "dependency_files", "push"}},
+	{"push-again", "Push them again, sending only the files which changed", "node-app", []string{"dependency_files", "push", "--incremental"}},
+	{"files", "List the dependency files known by Gemnasium", "node-app", []string{"dependency_files", "list", "--no-cache"}},
+	{"eval", "Evaluate the local files, with the worst runtime status as exit status (2: red)", "rails-app", []string{"eval"}},
+	{"eval-json", "Same evaluation, as a JSON document", "node-app", []string{"--json", "eval"}},
+	{"scan", "Look for dependency files, and write an SPDX document of their packages", "rails-app", []string{"scan", "--emit-spdx", "sbom.spdx.json"}},
+	{"advisories", "Download the advisory database, for offline scans", "rails-app", []string{"advisories", "sync"}},
+	{"offline", "Match the lockfiles against the advisory database, failing on high severity advisories", "rails-app", []string{"scan", "--offline", "--fail-on", "high"}},
+	{"freshness", "Report how far the dependencies are lagging behind (libyears)", "rails-app", []string{"report", "freshness"}},
+	{"risk", "Report the risk score of the dependencies, as JSON", "rails-app", []string{"report", "risk", "--format", "json"}},
+	{"check", "Fail if a dependency is too risky", "rails-app", []string{"check", "--max-risk", "7.5"}},
+}
+
+// Outcome of a step
+type Result struct {
+	Step     Step
+	ExitCode int
+}
+
+// Return the steps with the given names, all of them if there are none
+func Select(names []string) ([]Step, error) {
+	if len(names) == 0 {
+		return Steps, nil
+	}
+	selected := []Step{}
+	for _, name := range names {
+		found := false
+		for _, step := range Steps {
+			if step.Name == name {
+				selected = append(selected, step)
+				found = true
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Unknown demo step: %s (see gemnasium demo --list)", name)
+		}
+	}
+	return selected, nil
+}
+
+// Write the fixture projects to dir
+func Extract(dir string) error {
+	return fs.WalkDir(fixtures, "fixtures", func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			return err
+		}
+		target := filepath.Join(dir, filepath.FromSlash(strings.TrimPrefix(path, "fixtures")))
+		if d.IsDir() {
+			return os.MkdirAll(target, 0755)
+		}
+		content, err := fixtures.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		return ioutil.WriteFile(target, content, 0644)
+	})
+}
+
+// Dependency files of a fixture project, as known by the fake API. Their SHAs
+// are git blob SHA1s, the steps being run with GEMNASIUM_VCS=git.
+func fixtureFiles(project string) ([]models.DependencyFile, error) {
+	git, err := models.LookupVCS("git")
+	if err != nil {
+		return nil, err
+	}
+	entries, err := fixtures.ReadDir("fixtures/" + project)
+	if err != nil {
+		return nil, err
+	}
+	dfiles := []models.DependencyFile{}
+	for _, entry := range entries {
+		if entry.Name() == config.CONFIG_FILE_PATH {
+			continue
+		}
+		content, err := fixtures.ReadFile("fixtures/" + project + "/" + entry.Name())
+		if err != nil {
+			return nil, err
+		}
+		dfiles = append(dfiles, models.DependencyFile{Path: entry.Name(), SHA: models.ContentSHA(git, content), Content: content, Size: int64(len(content))})
+	}
+	return dfiles, nil
+}
+
+// Environment of the steps: the fake API and registries, a cache of their own,
+// and git SHAs whatever the VCS of the temporary directory
+func environment(serverURL, cacheDir string) []string {
+	return append(os.Environ(),
+		config.ENV_API_ENDDPOINT+"="+serverURL,
+		config.ENV_TOKEN+"=demo",
+		config.ENV_CACHE_DIR+"="+cacheDir,
+		config.ENV_RUBYGEMS_MIRROR+"="+serverURL+"/rubygems",
+		config.ENV_NPM_MIRROR+"="+serverURL+"/npm",
+		config.ENV_NON_INTERACTIVE+"=1",
+		config.ENV_OUTPUT+"=",
+		config.ENV_READ_ONLY+"=",
+		config.ENV_VCS+"=git",
+	)
+}
+
+// Run the steps with the toolbelt executable exe, in a temporary copy of the
+// fixture projects, and print a summary of their exit statuses. Failing steps
+// don't stop the demo: their exit status is part of it.
+func Run(w io.Writer, exe string, steps []Step) ([]Result, error) {
+	dir, err := ioutil.TempDir("", "gemnasium-demo")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(dir)
+	if err := Extract(dir); err != nil {
+		return nil, err
+	}
+	server := NewServer()
+	defer server.Close()
+	env := environment(server.URL, filepath.Join(dir, ".cache"))
+
+	results := []Result{}
+	for _, step := range steps {
+		fmt.Fprintf(w, "\n# %s\n%s$ gemnasium %s\n", step.Description, step.Project+"/", strings.Join(step.Args, " "))
+		cmd := exec.Command(exe, step.Args...)
+		cmd.Dir = filepath.Join(dir, step.Project)
+		cmd.Env = env
+		cmd.Stdout = w
+		cmd.Stderr = w
+		code := 0
+		if err := cmd.Run(); err != nil {
+			exitErr, ok := err.(*exec.ExitError)
+			if !ok {
+				return results, err
+			}
+			code = exitErr.ExitCode()
+		}
+		fmt.Fprintf(w, "(exit status %d)\n", code)
+		results = append(results, Result{step, code})
+	}
+
+	fmt.Fprintln(w)
+	table := utils.NewTable(w, "Step", "Command", "Exit status")
+	for _, r := range results {
+		table.Append(r.Step.Name, "gemnasium "+strings.Join(r.Step.Args, " "), fmt.Sprint(r.ExitCode))
+	}
+	return results, table.Render()
+}
@@ -0,0 +1,111 @@
+package demo
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+func TestSelect(t *testing.T) {
+	steps, err := Select([]string{"eval", "projects"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(steps) != 2 || steps[0].Name != "eval" || steps[1].Name != "projects" {
+		t.Errorf("Expected the steps in the given order, got %+v", steps)
+	}
+	if _, err := Select([]string{"unknown"}); err == nil {
+		t.Error("Expected an error for an unknown step")
+	}
+	for _, step := range Steps {
+		if _, err := fixtures.ReadDir("fixtures/" + step.Project); err != nil {
+			t.Errorf("Unknown fixture project of step %s: %s", step.Name, step.Project)
+		}
+	}
+}
+
+func TestServer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-demo")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := Extract(dir); err != nil {
+		t.Fatal(err)
+	}
+	server := NewServer()
+	defer server.Close()
+
+	// The files known by the API are the extracted ones
+	resp, err := http.Get(server.URL + "/projects/demo-rails-app/dependency_files?page=1")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var dfiles []models.DependencyFile
+	json.NewDecoder(resp.Body).Decode(&dfiles)
+	resp.Body.Close()
+	if len(dfiles) != 2 {
+		t.Fatalf("Expected the Gemfile and Gemfile.lock, got %+v", dfiles)
+	}
+	config.VCS = "git"
+	defer func() { config.VCS = "" }()
+	for _, df := range dfiles {
+		if sha, err := models.GetFileSHA1(filepath.Join(dir, "rails-app", df.Path)); err != nil || sha != df.SHA {
+			t.Errorf("%s: expected the SHA of the extracted file, got %s (%v)", df.Path, sha, err)
+		}
+	}
+
+	push := func() models.PushResult {
+		body, _ := json.Marshal(dfiles)
+		resp, err := http.Post(server.URL+"/projects/demo-rails-app/dependency_files", "application/json", bytes.NewReader(body))
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		var result models.PushResult
+		json.NewDecoder(resp.Body).Decode(&result)
+		return result
+	}
+	if result := push(); len(result.Added) != 2 {
+		t.Errorf("Expected the files to be added, got %+v", result)
+	}
+	if result := push(); len(result.Unchanged) != 2 {
+		t.Errorf("Expected the files to be unchanged, got %+v", result)
+	}
+
+	body, _ := json.Marshal(map[string]interface{}{"dependency_files": dfiles})
+	resp, err = http.Post(server.URL+"/evaluate", "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var job map[string]string
+	json.NewDecoder(resp.Body).Decode(&job)
+	resp.Body.Close()
+	resp, err = http.Get(server.URL + "/evaluate/" + job["job_id"])
+	if err != nil {
+		t.Fatal(err)
+	}
+	var evaluation struct {
+		Status string `json:"status"`
+		Result struct {
+			RuntimeStatus     string `json:"runtime_status"`
+			DevelopmentStatus string `json:"development_status"`
+		} `json:"result"`
+	}
+	json.NewDecoder(resp.Body).Decode(&evaluation)
+	resp.Body.Close()
+	if evaluation.Status != "completed" || evaluation.Result.RuntimeStatus != "red" || evaluation.Result.DevelopmentStatus != "yellow" {
+		t.Errorf("Unexpected evaluation: %+v", evaluation)
+	}
+
+	if resp, err := http.Get(server.URL + "/projects/unknown"); err != nil || resp.StatusCode != http.StatusNotFound {
+		t.Errorf("Expected a 404 for unknown projects, got %v", err)
+	}
+}
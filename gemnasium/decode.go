@@ -0,0 +1,145 @@
+package gemnasium
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"reflect"
+	"sort"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// Where notices and debug messages (see config.Debug) are written
+var errOutput io.Writer = os.Stderr
+
+func debugf(format string, args ...interface{}) {
+	if config.Debug {
+		fmt.Fprintf(errOutput, "[debug] "+format+"\n", args...)
+	}
+}
+
+// Decode the response of uri into result. Responses are decoded leniently, so
+// that fields added or changed on the API don't break older releases: unknown
+// fields are ignored, and fields of an unexpected type are left empty. Both are
+// logged in debug mode.
+func decodeResponse(uri string, body []byte, result interface{}) error {
+	err := json.Unmarshal(body, result)
+	if typeErr, ok := err.(*json.UnmarshalTypeError); ok {
+		// The rest of the document is still decoded
+		debugf("%s: ignoring field %s: %s instead of %s", uri, typeErr.Field, typeErr.Value, typeErr.Type)
+		err = nil
+	}
+	if err != nil {
+		return fmt.Errorf("Invalid response from the API for %s: %s", uri, err)
+	}
+	if config.Debug {
+		for _, field := range UnknownFields(body, result) {
+			debugf("%s: unknown field %s", uri, field)
+		}
+	}
+	return nil
+}
+
+var unmarshalerType = reflect.TypeOf((*json.Unmarshaler)(nil)).Elem()
+
+// Return the paths of the fields of the JSON document which have no
+// counterpart in v (ie: "dependencies[].package.license"), sorted
+func UnknownFields(data []byte, v interface{}) []string {
+	var doc interface{}
+	if json.Unmarshal(data, &doc) != nil {
+		return nil
+	}
+	unknown := map[string]bool{}
+	unknownFields(doc, reflect.TypeOf(v), "", unknown)
+	fields := []string{}
+	for field := range unknown {
+		fields = append(fields, field)
+	}
+	sort.Strings(fields)
+	return fields
+}
+
+func unknownFields(doc interface{}, t reflect.Type, path string, unknown map[string]bool) {
+	for t.Kind() == reflect.Ptr {
+		t = t.Elem()
+	}
+	if reflect.PtrTo(t).Implements(unmarshalerType) {
+		return
+	}
+	switch value := doc.(type) {
+	case map[string]interface{}:
+		switch t.Kind() {
+		case reflect.Map:
+			for _, v := range value {
+				unknownFields(v, t.Elem(), path+"{}", unknown)
+			}
+		case reflect.Struct:
+			fields := jsonFields(t)
+			for key, v := range value {
+				field, ok := lookupField(fields, key)
+				if !ok {
+					unknown[joinPath(path, key)] = true
+					continue
+				}
+				unknownFields(v, field.Type, joinPath(path, key), unknown)
+			}
+		}
+	case []interface{}:
+		if t.Kind() == reflect.Slice || t.Kind() == reflect.Array {
+			for _, v := range value {
+				unknownFields(v, t.Elem(), path+"[]", unknown)
+			}
+		}
+	}
+}
+
+// Return the fields of the struct by JSON name, including the ones of
+// embedded structs
+func jsonFields(t reflect.Type) map[string]reflect.StructField {
+	fields := map[string]reflect.StructField{}
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("json")
+		if tag == "-" {
+			continue
+		}
+		name := strings.Split(tag, ",")[0]
+		if field.Anonymous && name == "" && field.Type.Kind() == reflect.Struct {
+			for n, f := range jsonFields(field.Type) {
+				fields[n] = f
+			}
+			continue
+		}
+		if field.PkgPath != "" {
+			continue
+		}
+		if name == "" {
+			name = field.Name
+		}
+		fields[name] = field
+	}
+	return fields
+}
+
+// Keys are matched case-insensitively, like encoding/json does
+func lookupField(fields map[string]reflect.StructField, key string) (reflect.StructField, bool) {
+	if field, ok := fields[key]; ok {
+		return field, true
+	}
+	for name, field := range fields {
+		if strings.EqualFold(name, key) {
+			return field, true
+		}
+	}
+	return reflect.StructField{}, false
+}
+
+func joinPath(path, key string) string {
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
@@ -0,0 +1,88 @@
+package gemnasium
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+type testPackage struct {
+	Name string `json:"name"`
+}
+
+type testDependency struct {
+	Package  testPackage `json:"package"`
+	Locked   string      `json:"locked"`
+	Priority int         `json:"priority"`
+}
+
+type testProject struct {
+	Slug         string            `json:"slug"`
+	Dependencies []testDependency  `json:"dependencies"`
+	Labels       map[string]string `json:"labels"`
+	UpdatedAt    time.Time         `json:"updated_at"`
+}
+
+func TestUnknownFields(t *testing.T) {
+	body := []byte(`{
+	  "slug": "blah",
+	  "visibility": "private",
+	  "updated_at": "2016-01-02T03:04:05Z",
+	  "labels": {"team": "core"},
+	  "dependencies": [
+	    {"package": {"name": "rails", "license": "MIT"}, "locked": "4.2.0"},
+	    {"package": {"name": "rack", "license": "MIT"}, "LOCKED": "1.6.0", "scope": "runtime"}
+	  ]
+	}`)
+	expected := []string{"dependencies[].package.license", "dependencies[].scope", "visibility"}
+	if fields := UnknownFields(body, &testProject{}); !reflect.DeepEqual(fields, expected) {
+		t.Errorf("Expected unknown fields %v, got %v", expected, fields)
+	}
+}
+
+func TestAPIRequestToleratesSchemaChanges(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		// priority became a string, and a field was added
+		fmt.Fprintln(w, `{"slug": "blah", "dependencies": [{"package": {"name": "rails"}, "locked": "4.2.0", "priority": "high"}], "visibility": "private"}`)
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	config.Debug = true
+	var debug bytes.Buffer
+	errOutput = &debug
+	defer func() {
+		config.Debug = false
+		errOutput = os.Stderr
+	}()
+
+	var project testProject
+	if err := APIRequest(&APIRequestOptions{Method: "GET", URI: "/projects/blah", Result: &project}); err != nil {
+		t.Fatal(err)
+	}
+	if project.Slug != "blah" || len(project.Dependencies) != 1 || project.Dependencies[0].Locked != "4.2.0" {
+		t.Errorf("Expected the rest of the response to be decoded, got %+v", project)
+	}
+	for _, message := range []string{"priority: string instead of int", "unknown field visibility"} {
+		if !strings.Contains(debug.String(), message) {
+			t.Errorf("Expected debug output to contain %q, got:\n%s", message, debug.String())
+		}
+	}
+
+	// Nothing is logged outside of debug mode
+	config.Debug = false
+	debug.Reset()
+	if err := APIRequest(&APIRequestOptions{Method: "GET", URI: "/projects/blah", Result: &project}); err != nil {
+		t.Fatal(err)
+	}
+	if debug.Len() != 0 {
+		t.Errorf("Expected no debug output, got:\n%s", debug.String())
+	}
+}
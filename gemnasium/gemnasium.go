@@ -2,6 +2,7 @@ package gemnasium
 
 import (
 	"bytes"
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -12,42 +13,87 @@ import (
 	"github.com/gemnasium/toolbelt/utils"
 )
 
+var (
+	client  = utils.NewAPIClient()
+	limiter *utils.RateLimiter
+)
+
+// Limit the number of API requests per second (qps <= 0: unlimited)
+func SetRateLimit(qps float64) {
+	limiter = utils.NewRateLimiter(qps)
+}
+
 type APIRequestOptions struct {
 	Method string
 	URI    string
 	Body   interface{}
 	Result interface{}
+	// Context of the request (default: context.Background()). Each attempt
+	// is also limited to config.APITimeout.
+	Context context.Context
+	// Headers of the response, set by APIRequest
+	ResponseHeader http.Header
+}
+
+// Error returned by APIRequest when the API responds with an error status,
+// along with the message of the response (or why it couldn't be decoded)
+type APIError struct {
+	StatusCode int
+	Status     string
+	Message    string
+}
+
+func (e *APIError) Error() string {
+	return fmt.Sprintf("%s: %s\n", e.Status, e.Message)
 }
 
 func APIRequest(opts *APIRequestOptions) error {
 	url := fmt.Sprintf("%s%s", config.APIEndpoint, opts.URI)
 
-	var reqBody io.Reader
-	if opts.Body == nil {
-		reqBody = nil
-	} else {
-		JSON, err := json.Marshal(opts.Body)
-		if err != nil {
+	var JSON []byte
+	if opts.Body != nil {
+		var err error
+		if JSON, err = json.Marshal(opts.Body); err != nil {
 			return err
 		}
-		reqBody = bytes.NewReader(JSON)
 	}
 
-	req, err := utils.NewAPIRequest(opts.Method, url, config.APIKey, reqBody)
-	if err != nil {
-		return err
+	ctx := opts.Context
+	if ctx == nil {
+		ctx = context.Background()
 	}
-	client := &http.Client{}
-	resp, err := client.Do(req)
-	if err != nil {
-		return err
-	}
-	defer resp.Body.Close()
 
-	body, err := ioutil.ReadAll(resp.Body)
-	if err != nil {
-		return err
+	// Transient failures (network errors, timeouts, rate limiting, 502,
+	// 503...) are retried up to config.APIRetries times (see retryable)
+	var resp *http.Response
+	var body []byte
+	for attempt := 1; ; attempt++ {
+		var reqBody io.Reader
+		if JSON != nil {
+			reqBody = bytes.NewReader(JSON)
+		}
+		req, err := utils.NewAPIRequest(opts.Method, url, config.APIKey, reqBody)
+		if err != nil {
+			return err
+		}
+		limiter.Wait()
+		resp, body, err = send(ctx, req)
+		if attempt > config.APIRetries || !retryable(opts.Method, resp, err) || ctx.Err() != nil {
+			if err != nil {
+				return err
+			}
+			break
+		}
+		if err == nil {
+			recordResponse(opts.Method, opts.URI, resp.Status, body)
+		}
+		// Canceling ctx interrupts the wait too
+		if err := sleep(ctx, retryDelay(resp, attempt)); err != nil {
+			return err
+		}
 	}
+	recordResponse(opts.Method, opts.URI, resp.Status, body)
+	opts.ResponseHeader = resp.Header
 
 	if resp.StatusCode != http.StatusOK && resp.StatusCode != http.StatusNoContent {
 		type errMsg struct {
@@ -55,9 +101,9 @@ func APIRequest(opts *APIRequestOptions) error {
 		}
 		em := &errMsg{}
 		if err := json.Unmarshal(body, &em); err != nil {
-			return fmt.Errorf("%s: %s\n", resp.Status, err)
+			return &APIError{resp.StatusCode, resp.Status, err.Error()}
 		}
-		return fmt.Errorf("%s: %s\n", resp.Status, em.Message)
+		return &APIError{resp.StatusCode, resp.Status, em.Message}
 	}
 
 	// if RawFormat flag is set, don't format the output
@@ -66,10 +112,30 @@ func APIRequest(opts *APIRequestOptions) error {
 	}
 
 	if opts.Result != nil {
-		if err = json.Unmarshal(body, opts.Result); err != nil {
+		if err := decodeResponse(opts.URI, body, opts.Result); err != nil {
 			return err
 		}
 	}
 
 	return nil
 }
+
+// Send the request and read the response, within config.APITimeout
+func send(ctx context.Context, req *http.Request) (*http.Response, []byte, error) {
+	attemptCtx := ctx
+	if config.APITimeout > 0 {
+		var cancel context.CancelFunc
+		attemptCtx, cancel = context.WithTimeout(ctx, config.APITimeout)
+		defer cancel()
+	}
+	resp, err := client.Do(req.WithContext(attemptCtx))
+	var body []byte
+	if err == nil {
+		body, err = ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
+	}
+	if err != nil && ctx.Err() == nil && attemptCtx.Err() == context.DeadlineExceeded {
+		return nil, nil, fmt.Errorf("%s %s: no response after %s (see %s)", req.Method, req.URL.Path, config.APITimeout, config.ENV_API_TIMEOUT)
+	}
+	return resp, body, err
+}
@@ -0,0 +1,40 @@
+package gemnasium
+
+import "sync"
+
+// Number of API responses kept in memory, for diagnostics
+const RECENT_RESPONSES_SIZE = 5
+
+// Max size of the response bodies kept
+const RECENT_RESPONSE_MAX_BODY = 2048
+
+type RecentResponse struct {
+	Method string
+	URI    string
+	Status string
+	Body   string
+}
+
+var (
+	recentMu        sync.Mutex
+	recentResponses []RecentResponse
+)
+
+func recordResponse(method, uri, status string, body []byte) {
+	if len(body) > RECENT_RESPONSE_MAX_BODY {
+		body = append(body[:RECENT_RESPONSE_MAX_BODY:RECENT_RESPONSE_MAX_BODY], "..."...)
+	}
+	recentMu.Lock()
+	defer recentMu.Unlock()
+	recentResponses = append(recentResponses, RecentResponse{method, uri, status, string(body)})
+	if len(recentResponses) > RECENT_RESPONSES_SIZE {
+		recentResponses = recentResponses[len(recentResponses)-RECENT_RESPONSES_SIZE:]
+	}
+}
+
+// Return the last API responses received, oldest first
+func RecentResponses() []RecentResponse {
+	recentMu.Lock()
+	defer recentMu.Unlock()
+	return append([]RecentResponse{}, recentResponses...)
+}
@@ -0,0 +1,101 @@
+package gemnasium
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// Lambda to be overriden in tests
+var sleep = sleepContext
+
+// Wait for d, or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// Longest Retry-After honored: the request fails if the API asks to wait more
+const MAX_RETRY_AFTER = 5 * time.Minute
+
+// Methods which can be sent twice without side effects
+var idempotentMethods = map[string]bool{"GET": true, "HEAD": true, "PUT": true, "DELETE": true, "OPTIONS": true}
+
+// Return true if the request should be sent again after this response:
+// rate limiting (429), and for idempotent methods, network errors and statuses
+// of config.APIRetryStatuses. Other requests (ie: POST) may have been
+// processed already, they're only retried when the connection couldn't be
+// established.
+func retryable(method string, resp *http.Response, err error) bool {
+	if err != nil {
+		var opErr *net.OpError
+		return idempotentMethods[method] || errors.As(err, &opErr) && opErr.Op == "dial"
+	}
+	if resp.StatusCode == http.StatusTooManyRequests {
+		return retryAfter(resp) <= MAX_RETRY_AFTER
+	}
+	if !idempotentMethods[method] {
+		return false
+	}
+	for _, status := range config.APIRetryStatuses {
+		if resp.StatusCode == status {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the delay before the attempt following attempt (starting at 1): the
+// backoff doubles on every attempt, with a random jitter of up to 50% so
+// clients don't retry in sync
+func backoff(attempt int) time.Duration {
+	delay := config.APIRetryBackoff << uint(attempt-1)
+	if max := config.APIRetryMaxBackoff; max > 0 && delay > max {
+		delay = max
+	}
+	if delay <= 0 {
+		return 0
+	}
+	return delay/2 + time.Duration(rand.Int63n(int64(delay/2)+1))
+}
+
+// Return the delay asked by the Retry-After header of the response (in
+// seconds, or an HTTP date), 0 if there's none
+func retryAfter(resp *http.Response) time.Duration {
+	value := resp.Header.Get("Retry-After")
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(value); err == nil && seconds > 0 {
+		return time.Duration(seconds) * time.Second
+	}
+	if date, err := http.ParseTime(value); err == nil && date.After(time.Now()) {
+		return time.Until(date).Round(time.Second)
+	}
+	return 0
+}
+
+// Return the delay before sending the request again, after attempt: the one
+// asked by the API when rate limited, otherwise the backoff
+func retryDelay(resp *http.Response, attempt int) time.Duration {
+	if resp != nil && resp.StatusCode == http.StatusTooManyRequests {
+		if wait := retryAfter(resp); wait > 0 {
+			fmt.Fprintf(errOutput, "Rate limited by the API, waiting %s before retrying...\n", wait)
+			return wait
+		}
+	}
+	return backoff(attempt)
+}
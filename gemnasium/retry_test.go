@@ -0,0 +1,227 @@
+package gemnasium
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"os"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestAPIRequestRetries(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		if n < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			fmt.Fprintln(w, `{"message": "unavailable"}`)
+			return
+		}
+		fmt.Fprintln(w, `{"slug": "blah"}`)
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	delays := []time.Duration{}
+	sleep = func(_ context.Context, d time.Duration) error { delays = append(delays, d); return nil }
+	defer func() { sleep = sleepContext }()
+
+	var result struct{ Slug string }
+	if err := APIRequest(&APIRequestOptions{Method: "GET", URI: "/projects/blah", Result: &result}); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&requests) != 3 || result.Slug != "blah" {
+		t.Errorf("Expected 3 requests and a result, got %d requests and %+v", atomic.LoadInt32(&requests), result)
+	}
+	if len(delays) != 2 || delays[0] < 250*time.Millisecond || delays[0] > 500*time.Millisecond || delays[1] < 500*time.Millisecond || delays[1] > time.Second {
+		t.Errorf("Expected exponential backoff with jitter, got %v", delays)
+	}
+
+	// Retries disabled
+	atomic.StoreInt32(&requests, 0)
+	config.APIRetries = 0
+	defer func() { config.APIRetries = config.DEFAULT_API_RETRIES }()
+	if err := APIRequest(&APIRequestOptions{Method: "GET", URI: "/projects"}); err == nil {
+		t.Error("Expected an error")
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Expected a single request, got %d", atomic.LoadInt32(&requests))
+	}
+}
+
+func TestAPIRequestDoesntRetryClientErrors(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		w.WriteHeader(http.StatusNotFound)
+		fmt.Fprintln(w, `{"message": "not found"}`)
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	sleep = func(context.Context, time.Duration) error { return nil }
+	defer func() { sleep = sleepContext }()
+
+	err := APIRequest(&APIRequestOptions{Method: "GET", URI: "/projects/blah"})
+	apiErr, ok := err.(*APIError)
+	if !ok || apiErr.StatusCode != http.StatusNotFound || apiErr.Message != "not found" {
+		t.Errorf("Expected a 404 API error, got %#v", err)
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Expected a single request, got %d", atomic.LoadInt32(&requests))
+	}
+}
+
+func TestAPIRequestTimeout(t *testing.T) {
+	done := make(chan struct{})
+	defer close(done)
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&requests, 1)
+		select {
+		case <-done:
+		case <-r.Context().Done():
+		}
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	config.APITimeout = 50 * time.Millisecond
+	config.APIRetries = 1
+	sleep = func(context.Context, time.Duration) error { return nil }
+	defer func() {
+		config.APITimeout = config.DEFAULT_API_TIMEOUT
+		config.APIRetries = config.DEFAULT_API_RETRIES
+		sleep = sleepContext
+	}()
+
+	err := APIRequest(&APIRequestOptions{Method: "GET", URI: "/projects"})
+	if err == nil || !strings.Contains(err.Error(), "no response after 50ms") {
+		t.Errorf("Expected a timeout error, got %v", err)
+	}
+	if atomic.LoadInt32(&requests) != 2 {
+		t.Errorf("Expected the request to be retried after the timeout, got %d requests", atomic.LoadInt32(&requests))
+	}
+
+	// Canceled requests aren't retried
+	atomic.StoreInt32(&requests, 0)
+	config.APITimeout = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	time.AfterFunc(50*time.Millisecond, cancel)
+	if err := APIRequest(&APIRequestOptions{Method: "GET", URI: "/projects", Context: ctx}); err == nil {
+		t.Error("Expected an error for the canceled request")
+	}
+	if atomic.LoadInt32(&requests) != 1 {
+		t.Errorf("Expected a single request, got %d", atomic.LoadInt32(&requests))
+	}
+}
+
+func TestAPIRequestCanceledDuringRetryDelay(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	// The first retry waits at least 250ms
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := APIRequest(&APIRequestOptions{Method: "GET", URI: "/projects", Context: ctx})
+	if err != context.DeadlineExceeded {
+		t.Errorf("Expected the deadline to interrupt the retry delay, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 200*time.Millisecond {
+		t.Errorf("Expected the request to return when its context is done, took %s", elapsed)
+	}
+}
+
+func TestAPIRequestRateLimited(t *testing.T) {
+	var requests int32
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		n := atomic.AddInt32(&requests, 1)
+		if n == 1 {
+			w.Header().Set("Retry-After", "12")
+			w.WriteHeader(http.StatusTooManyRequests)
+			fmt.Fprintln(w, `{"message": "rate limit exceeded"}`)
+			return
+		}
+		fmt.Fprintln(w, `{}`)
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	delays := []time.Duration{}
+	sleep = func(_ context.Context, d time.Duration) error { delays = append(delays, d); return nil }
+	var messages bytes.Buffer
+	errOutput = &messages
+	defer func() {
+		sleep = sleepContext
+		errOutput = os.Stderr
+	}()
+
+	if err := APIRequest(&APIRequestOptions{Method: "GET", URI: "/projects"}); err != nil {
+		t.Fatal(err)
+	}
+	if atomic.LoadInt32(&requests) != 2 || len(delays) != 1 || delays[0] != 12*time.Second {
+		t.Errorf("Expected a retry after 12s, got %d requests and delays %v", atomic.LoadInt32(&requests), delays)
+	}
+	if !strings.Contains(messages.String(), "waiting 12s") {
+		t.Errorf("Expected a rate limit message, got %q", messages.String())
+	}
+}
+
+func TestRetryAfter(t *testing.T) {
+	var tt = []struct {
+		header   string
+		expected time.Duration
+	}{
+		{"", 0},
+		{"30", 30 * time.Second},
+		{"soon", 0},
+		{time.Now().Add(time.Minute).UTC().Format(http.TimeFormat), time.Minute},
+		{time.Now().Add(-time.Minute).UTC().Format(http.TimeFormat), 0},
+	}
+	for _, test := range tt {
+		resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+		resp.Header.Set("Retry-After", test.header)
+		if wait := retryAfter(resp); wait < test.expected-time.Second || wait > test.expected {
+			t.Errorf("Retry-After %q: expected %s, got %s", test.header, test.expected, wait)
+		}
+	}
+	resp := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{"Retry-After": {"3600"}}}
+	if retryable("GET", resp, nil) {
+		t.Error("Expected requests asked to wait more than MAX_RETRY_AFTER not to be retried")
+	}
+}
+
+func TestRetryableMethods(t *testing.T) {
+	unavailable := &http.Response{StatusCode: http.StatusServiceUnavailable, Header: http.Header{}}
+	rateLimited := &http.Response{StatusCode: http.StatusTooManyRequests, Header: http.Header{}}
+	lost := errors.New("connection reset by peer")
+	refused := &url.Error{Op: "Post", URL: "https://api.gemnasium.com/v1/projects", Err: &net.OpError{Op: "dial", Net: "tcp", Err: errors.New("connection refused")}}
+	tt := []struct {
+		method   string
+		resp     *http.Response
+		err      error
+		expected bool
+	}{
+		{"GET", unavailable, nil, true},
+		{"PUT", nil, lost, true},
+		{"POST", unavailable, nil, false},
+		{"POST", nil, lost, false},
+		{"POST", nil, refused, true},
+		{"POST", rateLimited, nil, true},
+	}
+	for _, test := range tt {
+		if retryable(test.method, test.resp, test.err) != test.expected {
+			t.Errorf("%s (%v, %v): expected retryable to be %v", test.method, test.resp, test.err, test.expected)
+		}
+	}
+}
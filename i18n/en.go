@@ -0,0 +1,263 @@
+package i18n
+
+// English messages, the reference catalog
+var en = map[string]string{
+	"common.done":             "done",
+	"common.done_capitalized": "Done",
+	"common.confirm":          "Continue? [y/N] ",
+	"common.non_interactive":  "Can't ask for %s in non-interactive mode (--non-interactive, GEMNASIUM_NON_INTERACTIVE, or stdin isn't a terminal): %s",
+
+	// projects
+	"projects.shared_by":         "\nShared by: %s\n\n",
+	"projects.found":             "Found %d projects (%d unmonitored are hidden)\n\n",
+	"projects.nothing_to_update": "Please specify at least one thing to update (name, desc, or monitored",
+	"projects.updated":           "Project %s updated succesfully\n",
+	"projects.enter_name":        "Enter project name: ",
+	"projects.enter_description": "Enter project description: ",
+	"projects.created":           "Project '%s' created: https://gemnasium.com/%s (Remaining slots: %v)\n",
+	"projects.configure_hint":    "To configure this project, use the following command:\ngemnasium configure %s\n",
+	"projects.enter_slug":        "Enter project slug: ",
+	"projects.config_created":    "Your .gemnasium.yml was created!",
+	"projects.sync_started":      "Synchronization started for project %s\n",
+	"projects.slug_empty":        "[project slug] can't be empty",
+
+	// dependency files
+	"df.signature_mismatch": "%s: File signature doesn't match (expected: %s, got: %s)",
+	"df.file_disappeared":   "[warning] Skipping %s: file disappeared during scan\n",
+	"df.skipping":           "Skipping %s",
+	"df.unreadable":         "Unable to read file: %s",
+	"df.found":              "Found: %s\n",
+	"df.lfs_unavailable":    "%s is a Git LFS pointer, and its content can't be fetched (is git-lfs installed?): %s",
+	"df.registry_bypass":    "\n%d dependencies aren't sourced from a registry, they can't be matched against advisories:\n",
+	"df.sparse_excluded":    "[warning] Skipping %s: not checked out (sparse checkout)\n",
+	"df.push_aborted":       "Push aborted",
+	"df.incremental":        "%d of %d files changed, only their content is sent\n",
+	"df.sending":            "Sending files to Gemnasium: ",
+	"df.sending_to":         "Sending files to Gemnasium (%s): ",
+	"df.no_project":         "[warning] Skipping %s: no project mapped to this path\n",
+	"df.sent":               "done.\n\n",
+	"df.added":              "Added: %s\n",
+	"df.updated":            "Updated: %s\n",
+	"df.unchanged":          "Unchanged: %s\n",
+	"df.unsupported":        "Unsupported: %s\n",
+	"df.removed":            "Removed: %s\n",
+	"df.renamed":            "Renamed: %s\n",
+	"df.prune_summary":      "\n%d file(s) no longer present locally will be marked as removed on Gemnasium:\n%s\n",
+	"df.prune_with_files":   "--prune can't be used with --files, files not listed would be marked as removed",
+	"df.parse_errors":       "\nErrors:\n%s\n",
+	"df.parse_warnings":     "\nWarnings:\n%s\n",
+	"df.fail_on_state":      "  %s: %s",
+	"df.push_failed":        "\nPush failed, files are in unexpected states:\n%s\n",
+	"df.payload_summary":    "About to send %d file(s) (%s) to Gemnasium. Largest files:\n",
+	"df.payload_hint":       "Large payloads are usually caused by vendored dependencies (ex: node_modules), see GEMNASIUM_IGNORED_PATHS.",
+	"df.no_files_given":     "[warning] No files given, scanning current directory instead.",
+	"df.restore_up_to_date": "%s is already up to date\n",
+	"df.restore_aborted":    "Restore aborted",
+	"df.restored":           "%s restored (%s)\n",
+
+	// autoupdate
+	"autoupdate.revision_unknown":                "The current revision (%s) is unknown on Gemnasium, please push your dependency files before running autoupdate.\nSee `gemnasium df help push`.\n",
+	"autoupdate.revision_undetermined":           "Can't determine current revision, please use REVISION env var to specify it",
+	"autoupdate.restore_error":                   "Error while restoring files: %s\n",
+	"autoupdate.patch_out":                       "Patch of the update set appended to %s\n",
+	"autoupdate.patch_out_error":                 "Can't write the patch of the update set: %s\n",
+	"autoupdate.git_branch":                      "Update set committed on the branch %s\n",
+	"autoupdate.git_branch_error":                "Can't commit the update set on its branch: %s\n",
+	"autoupdate.pull_request_token_missing":      "Please set %s to open pull requests",
+	"autoupdate.pull_request_not_github":         "Pull requests can only be opened on GitHub repositories, origin is %s",
+	"autoupdate.pull_request_detached":           "Can't open pull requests from a detached HEAD: check out the branch they should be merged into",
+	"autoupdate.pull_request_auto_merge_invalid": "Unknown auto-merge method: %s (expected merge, squash or rebase)",
+	"autoupdate.pull_request_auto_merge_error":   "Can't enable auto-merge on #%d: %s",
+	"autoupdate.pull_request":                    "Pull request: %s\n",
+	"autoupdate.pull_request_error":              "Can't open the pull request of the branch %s: %s\n",
+	"autoupdate.git_group_applying":              "Applying update set %d again on top of the branch of its group, %s\n",
+	"autoupdate.git_group_failing":               "The test suite fails with the update set on top of the branch %s, it's not added to it:\n%s",
+	"autoupdate.no_patch_set":                    "No validated patch set found for branch %s",
+	"autoupdate.applying_patch_set":              "Applying update set #%d (validated on revision %s)\n",
+	"autoupdate.files_to_update":                 "%d file(s) to be updated.\n",
+	"autoupdate.updating_file":                   "Updating file %s: ",
+	"autoupdate.files_to_restore":                "%d file(s) to be restored.\n",
+	"autoupdate.restoring_file":                  "Restoring file %s: ",
+	"autoupdate.testsuite_empty":                 "Arg [testSuite] can't be empty",
+	"autoupdate.tooling_check_failed":            "Aborting, required tools are missing or outdated:\n%s",
+	"autoupdate.tool_missing":                    "  - %s: not found in $PATH",
+	"autoupdate.tool_version_unknown":            "  - %s: can't determine version",
+	"autoupdate.tool_too_old":                    "  - %s: version %s is installed, %s or newer is required",
+	"autoupdate.initial_testsuite_failing":       "Aborting, initial test suite run is failing:",
+	"autoupdate.executing_testsuite":             "Executing test script: ",
+	"autoupdate.testsuite_done":                  "done (%fs)\n",
+	"autoupdate.test_attempt_failed":             "Attempt %d/%d failed:\n%s\n",
+	"autoupdate.flaky_testsuite":                 "Test suite passed after %d attempts, it may be flaky.",
+	"autoupdate.job_done":                        "Job done!",
+	"autoupdate.update_set_header":               "\n========= [UpdateSet #%d] =========\n",
+	"autoupdate.already_satisfied":               "Target versions are already satisfied by the current lockfiles, skipping.",
+	"autoupdate.verification_failed":             "Verification failed: %s\n",
+	"autoupdate.verification_unsupported":        "Can't verify %s: the %s registry isn't supported, skipping\n",
+	"autoupdate.unresolvable":                    "%s %s requires %s %s, but %s would be installed",
+	"autoupdate.simulation_failed":               "Update set can't be resolved: %s\n",
+	"autoupdate.already_failed":                  "Skipping, the same update set (#%d) already failed with the current lockfiles on %s\n",
+	"autoupdate.cant_record_failure":             "Can't record update set failure: %s\n",
+	"autoupdate.uploading_artifacts":             "Uploading failure artifacts: ",
+	"autoupdate.artifacts_error":                 "Can't upload failure artifacts: %s\n",
+	"autoupdate.email_error":                     "Can't send summary email: %s\n",
+	"autoupdate.deferring":                       "Deferring update set: %s\n",
+	"autoupdate.pushing_result":                  "Pushing result (status='%s'): ",
+	"autoupdate.missing_result_args":             "Missing updateSet ID and/or State args",
+	"autoupdate.cant_install_requirements":       "Can't install requirements",
+	"autoupdate.cant_find_installer":             "Can't find installer for package type: %s\n",
+	"autoupdate.upgrading_toolchain":             "Upgrading %s (%s => %s)\n",
+	"autoupdate.cant_update_versions":            "Can't update versions",
+	"autoupdate.cant_find_updater":               "Can't find updater for package type: %s\n",
+	"autoupdate.running":                         "Running %s",
+	"autoupdate.install_error":                   "Error while installing packages:\n%s\n",
+	"autoupdate.patching":                        "Patching %s",
+	"autoupdate.updating_dependency":             "Updating dependency %s (%s => %s)\n",
+	"autoupdate.executing_update_command":        "Executing update commmand: %s\n",
+	"autoupdate.version_not_found":               "%s %s can't be found on the registry",
+	"autoupdate.version_not_found_maybe_yanked":  "%s %s can't be found on the registry (it may have been yanked)",
+	"autoupdate.yanked_no_fix":                   "[warning] Skipping %s: no release to move to from %s\n",
+	"autoupdate.version_yanked":                  "%s %s has been yanked",
+	"autoupdate.release_age_unsupported":         "Can't check the release date of %s: the %s registry isn't supported, skipping\n",
+	"autoupdate.release_too_recent":              "%s %s was released %s ago (cooldown: %s)",
+	"autoupdate.dry_run":                         "Dry run: no file is changed and no result is sent to Gemnasium. Test suite: %s\n",
+	"autoupdate.dry_run_command":                 "Would run: %s\n",
+	"autoupdate.dry_run_patch":                   "Would patch %s\n",
+	"autoupdate.dry_run_rewrite":                 "Would rewrite the versions in %s\n",
+
+	// autoupdate worker
+	"worker.started":                    "Worker %s waiting for autoupdate jobs\n",
+	"worker.job_started":                "Running job %s (project %s)\n",
+	"worker.job_done":                   "Job %s done (exit status %d)\n",
+	"worker.report_error":               "Can't report the result of job %s: %s\n",
+	"worker.error":                      "Worker error: %s\n",
+	"worker.service_unsupported":        "Can't install the worker as a service on %s: only systemd and launchd are supported. Run 'gemnasium autoupdate worker' from a scheduled task instead",
+	"worker.service_user_missing":       "User %s doesn't exist, create it or use --user",
+	"worker.service_installed":          "Worker service installed: %s\n",
+	"worker.service_installed_with_env": "Worker service installed: %s (environment in %s)\n",
+	"worker.service_uninstalled":        "Worker service uninstalled: %s\n",
+
+	// git history
+	"git.revision_missing":         "Revision %s isn't in the local history, fetch it with: git fetch origin %s",
+	"git.shallow_revision_missing": "Revision %s isn't in the history of this shallow clone (deepened by %d commits). Fetch it with 'git fetch origin %s' or 'git fetch --unshallow', or raise GEMNASIUM_DEEPEN_BUDGET to let gemnasium deepen the clone",
+	"git.deepening":                "Shallow clone: fetching %d more commits to find revision %s\n",
+	"git.files_changed_since":      "Dependency files changed since revision %s: %s",
+
+	// owners
+	"owners.missing_file": "Can't filter by owner: there's no %s file mapping packages and files to their owners",
+
+	// timeout
+	"timeout.expired":       "gemnasium timed out after %s (--timeout): %s\nPartial report:\n",
+	"timeout.scan_progress": "Scan: %d paths walked, last one: %s\n",
+	"timeout.files_found":   "Dependency files found so far (%d): %s\n",
+	"timeout.last_requests": "Last API requests:\n",
+
+	// severity
+	"severity.unknown":           "Unknown severity: %s (expected low, medium, high or critical)",
+	"severity.threshold_reached": "%d advisories at or above %s severity found.\n",
+
+	// advisories
+	"advisories.missing_db": "No advisory database found at %s, download it with 'gemnasium advisories sync'\n",
+	"advisories.stale_db":   "[warning] The advisory database was synced %d days ago, run 'gemnasium advisories sync' to get the latest advisories\n",
+
+	// version
+	"version.header":     "gemnasium %s\n",
+	"version.commit":     "Commit:     %s\n",
+	"version.build_date": "Build date: %s\n",
+	"version.go_version": "Go version: %s (%s/%s)\n",
+	"version.too_old":    "This toolbelt is too old for the server (minimum supported version: %s), please upgrade.\n",
+	"version.available":  "A new version of the toolbelt is available: %s\n",
+	"version.up_to_date": "This toolbelt is up to date",
+
+	// dependencies
+	"deps.no_yanked":     "No dependencies locked to yanked versions.\n",
+	"deps.no_deprecated": "No deprecated dependencies found.\n",
+
+	// reports
+	"report.no_risk_above":         "No dependencies with a risk score above %.1f.\n",
+	"report.risk_above":            "%d dependencies with a risk score above %.1f found.",
+	"report.freshness_skipped":     "Skipped (unknown release dates): %s\n",
+	"report.freshness_unsupported": "Not supported (no registry client for these package types): %s\n",
+	"report.digest_title":          "Gemnasium digest since %s\n",
+	"report.digest_error":          "  error: %s\n",
+	"report.digest_new_alert":      "  [new alert] %s (%s)\n",
+	"report.digest_resolved":       "  [resolved] %s (%s)\n",
+	"report.digest_added":          "  [added] %s %s\n",
+	"report.digest_updated":        "  [updated] %s %s -> %s\n",
+	"report.digest_removed":        "  [removed] %s %s\n",
+	"report.digest_empty":          "\nNothing changed.\n",
+	"report.feed_written":          "Feed written to %s (%d events)\n",
+
+	// scan
+	"scan.sarif_requires_offline": "--emit-sarif requires --offline",
+	"scan.found":                  "%d dependency file(s) found.\n",
+	"scan.manifest_written":       "Scan manifest written to %s\n",
+	"scan.spdx_written":           "SPDX document of %d package(s) written to %s\n",
+	"scan.sarif_written":          "SARIF log of %d result(s) written to %s\n",
+	"scan.no_vulnerabilities":     "No vulnerable packages found in the lockfiles.\n",
+	"scan.vulnerabilities_found":  "%d vulnerable package(s) found.\n",
+
+	// sbom
+	"sbom.unknown_document": "Unknown type of document: %s (expected a file name ending with %s)",
+	"sbom.attached":         "%s (%s) attached to %s/%s@%s: %s\n",
+
+	// webhooks
+	"webhooks.unknown_event":   "Unknown event: %s (expected %s)",
+	"webhooks.missing_project": "Please specify the project of the %s event with --project",
+	"webhooks.delivered":       "%s event (%s) delivered to %s: %s\n",
+
+	// autoupdate refresh
+	"refresh.dirty_working_tree":   "The working tree has uncommitted changes, commit or stash them before refreshing the branches",
+	"refresh.unknown_base":         "Can't find the default branch of origin, set it with --base (or run: git remote set-head origin --auto)",
+	"refresh.no_branches":          "No %s* branches to refresh\n",
+	"refresh.up_to_date":           "%s is up to date with %s\n",
+	"refresh.rebased":              "%s rebased onto %s\n",
+	"refresh.updated_again":        "%s conflicts with %s: its versions were updated again on top of it\n",
+	"refresh.failed":               "Can't refresh %s: %s\n",
+	"refresh.failed_count":         "%d of the %d branches couldn't be refreshed",
+	"refresh.no_changes":           "There are no version updates in the commits of the branch",
+	"refresh.unknown_package_type": "Can't tell which package manager updated these files: %s",
+	"refresh.testsuite_failing":    "The test suite fails with the updated versions:\n%s",
+
+	// live evaluation
+	"eval.as_of":         "\nAdvisories published until %s",
+	"eval.as_of_undated": " (%d advisories without publication date kept)",
+
+	// org
+	"org.no_projects": "No projects found for owner %s",
+	"org.no_usages":   "No projects depend on %s (%d projects searched)\n",
+
+	// search
+	"search.building_index": "Building search index...\n",
+	"search.refresh_error":  "Can't refresh search index: %s\n",
+	"search.no_results":     "No results for '%s'\n",
+
+	// cache
+	"cache.directory": "Cache directory: %s\n\n",
+
+	// org remediate
+	"org.remediate_missing_advisory":      "Please specify an advisory with --advisory (ie: CVE-2021-44228)",
+	"org.remediate_progress_affected":     "[%d/%d] %s: affected (%s)\n",
+	"org.remediate_progress_not_affected": "[%d/%d] %s: not affected\n",
+	"org.remediate_progress_error":        "[%d/%d] %s: error: %s\n",
+	"org.remediate_none":                  "No open alerts about %s (%d projects searched)\n",
+	"org.remediate_plan":                  "%s: %s (%s), cured versions: %s, repository: %s\n",
+	"org.remediate_open_prs_hint":         "Use --open-prs to update these projects and open pull requests\n",
+	"org.remediate_opened":                "%s: pull request %s\n",
+	"org.remediate_failed":                "%s: %s\n",
+	"org.remediate_failed_count":          "%d of %d projects couldn't be remediated",
+	"org.remediate_no_repository":         "Unknown repository: set the %s label of the project, or use --clone-url",
+	"org.remediate_no_reader":             "Can't read the locked versions of %s packages",
+	"org.remediate_not_locked":            "%s isn't locked in the dependency files",
+	"org.remediate_no_cured":              "The advisory doesn't tell which versions of %s are cured",
+	"org.remediate_no_fix":                "No release of %s above %s is cured (%s)",
+
+	// tui
+	"tui.not_a_terminal": "The dashboard needs a terminal",
+	"tui.projects":       "Projects (%d)",
+	"tui.alerts":         "Open alerts of %s (%d)",
+	"tui.outdated":       "Outdated dependencies of %s (%d)",
+	"tui.empty":          "Nothing to show",
+	"tui.error":          "Error: %s",
+	"tui.help_projects":  "↑/↓: select  enter/a: open alerts  d: outdated dependencies  r: reload  q: quit",
+	"tui.help_project":   "esc: back  a: open alerts  d: outdated dependencies  r: reload  q: quit",
+}
@@ -0,0 +1,92 @@
+package i18n
+
+/*
+Message catalogs for user-facing strings.
+
+Messages are identified by an ID and formatted with fmt.Sprintf. The English
+catalog (en.go) is the reference: a translation is a catalog with the same IDs,
+registered for its language with Register (ie: in fr.go). Messages missing
+from a translation fall back to English.
+
+The language is read from GEMNASIUM_LANG, then from the usual locale env vars
+(LC_ALL, LC_MESSAGES, LANG).
+*/
+
+import (
+	"fmt"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+const DEFAULT_LANGUAGE = "en"
+
+var (
+	mu       sync.RWMutex
+	catalogs = map[string]map[string]string{DEFAULT_LANGUAGE: en}
+	language = detectLanguage()
+)
+
+// Return the language of the user, as a 2 letters code (ie: "fr" for
+// "fr_FR.UTF-8")
+func detectLanguage() string {
+	for _, env := range []string{config.ENV_LANG, "LC_ALL", "LC_MESSAGES", "LANG"} {
+		if value := os.Getenv(env); value != "" {
+			return normalize(value)
+		}
+	}
+	return DEFAULT_LANGUAGE
+}
+
+func normalize(locale string) string {
+	locale = strings.ToLower(locale)
+	if i := strings.IndexAny(locale, "_.@-"); i >= 0 {
+		locale = locale[:i]
+	}
+	if locale == "" || locale == "c" || locale == "posix" {
+		return DEFAULT_LANGUAGE
+	}
+	return locale
+}
+
+// Register the messages of a language. Messages already registered for this
+// language are replaced.
+func Register(lang string, messages map[string]string) {
+	mu.Lock()
+	defer mu.Unlock()
+	lang = normalize(lang)
+	if catalogs[lang] == nil {
+		catalogs[lang] = map[string]string{}
+	}
+	for id, message := range messages {
+		catalogs[lang][id] = message
+	}
+}
+
+// Set the language of messages (ie: "fr", or a locale like "fr_FR.UTF-8")
+func SetLanguage(lang string) {
+	mu.Lock()
+	defer mu.Unlock()
+	language = normalize(lang)
+}
+
+// Return the message id in the current language, formatted with args.
+// Messages missing from the current language fall back to English, and
+// unknown ids are returned as is.
+func T(id string, args ...interface{}) string {
+	mu.RLock()
+	message, ok := catalogs[language][id]
+	if !ok {
+		message, ok = catalogs[DEFAULT_LANGUAGE][id]
+	}
+	mu.RUnlock()
+	if !ok {
+		message = id
+	}
+	if len(args) == 0 {
+		return message
+	}
+	return fmt.Sprintf(message, args...)
+}
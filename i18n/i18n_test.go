@@ -0,0 +1,53 @@
+package i18n
+
+import (
+	"os"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestDetectLanguage(t *testing.T) {
+	for _, env := range []string{config.ENV_LANG, "LC_ALL", "LC_MESSAGES", "LANG"} {
+		defer os.Setenv(env, os.Getenv(env))
+		os.Unsetenv(env)
+	}
+	if lang := detectLanguage(); lang != DEFAULT_LANGUAGE {
+		t.Errorf("Expected default language, got: %s", lang)
+	}
+	os.Setenv("LANG", "fr_FR.UTF-8")
+	if lang := detectLanguage(); lang != "fr" {
+		t.Errorf("Expected fr, got: %s", lang)
+	}
+	os.Setenv(config.ENV_LANG, "de")
+	if lang := detectLanguage(); lang != "de" {
+		t.Errorf("Expected de, got: %s", lang)
+	}
+	os.Setenv(config.ENV_LANG, "C")
+	if lang := detectLanguage(); lang != DEFAULT_LANGUAGE {
+		t.Errorf("Expected default language for C locale, got: %s", lang)
+	}
+}
+
+func TestT(t *testing.T) {
+	defer SetLanguage(language)
+	Register("fr", map[string]string{"df.found": "Trouvé : %s\n"})
+
+	SetLanguage("fr_FR.UTF-8")
+	if msg := T("df.found", "Gemfile"); msg != "Trouvé : Gemfile\n" {
+		t.Errorf("Unexpected translation: %q", msg)
+	}
+	// fallback to English
+	if msg := T("df.skipping", "vendor"); msg != "Skipping vendor" {
+		t.Errorf("Expected English fallback, got: %q", msg)
+	}
+	// unknown messages are returned as is
+	if msg := T("unknown.message"); msg != "unknown.message" {
+		t.Errorf("Expected the message id, got: %q", msg)
+	}
+
+	SetLanguage("en")
+	if msg := T("df.found", "Gemfile"); msg != "Found: Gemfile\n" {
+		t.Errorf("Unexpected message: %q", msg)
+	}
+}
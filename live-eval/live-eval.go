@@ -3,14 +3,17 @@ package liveeval
 import (
 	"encoding/json"
 	"fmt"
+	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
-	"strings"
+	"sort"
+	"sync"
 	"time"
 
 	"github.com/gemnasium/toolbelt/config"
 	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/gemnasium/toolbelt/i18n"
 	"github.com/gemnasium/toolbelt/models"
 	"github.com/gemnasium/toolbelt/utils"
 	"github.com/wsxiaoys/terminal/color"
@@ -20,16 +23,156 @@ const (
 	LIVE_EVAL_PATH = "/evaluate"
 )
 
-// Live evaluation of dependency files Several files can be sent, not only from
-// the same language (ie: package.json + Gemfile + Gemfile.lock) LiveEvaluation
-// will return 2 stases (color for Runtime / Dev.) and the list of deps with
-// their color.
-func LiveEvaluation(files []string) error {
+// Where the report is printed
+var Output io.Writer = os.Stdout
+
+// Delay between two checks of an evaluation job. Lambda to be overriden in
+// tests.
+var pollInterval = time.Second
+
+// Exit codes of the evaluation, by worst runtime status
+var exitCodes = map[string]int{"yellow": 1, "red": 2}
+
+// Returned when the worst runtime status isn't green. The exit code of the
+// command is the one of the status (see exitCodes).
+type StatusError struct {
+	Status string
+}
+
+func (e *StatusError) Error() string {
+	if e.Status == "red" {
+		return "There are important updates available.\n"
+	}
+	return "There are updates available.\n"
+}
+
+func (e *StatusError) ExitCode() int {
+	return exitCodes[e.Status]
+}
+
+// Statuses of the evaluation of the files of an ecosystem
+type Verdict struct {
+	Ecosystem         string              `json:"ecosystem"`
+	Files             []string            `json:"files"`
+	RuntimeStatus     string              `json:"runtime_status"`
+	DevelopmentStatus string              `json:"development_status"`
+	Dependencies      []models.Dependency `json:"-"`
+}
+
+// Combined report of the evaluation of all the files: the statuses are the
+// worst of the ecosystems
+type Report struct {
+	AsOf              *time.Time          `json:"as_of,omitempty"`
+	RuntimeStatus     string              `json:"runtime_status"`
+	DevelopmentStatus string              `json:"development_status"`
+	Ecosystems        []Verdict           `json:"ecosystems"`
+	Dependencies      []models.Dependency `json:"dependencies"`
+}
+
+// Live evaluation of dependency files. The files can be from several
+// ecosystems (ie: package.json + Gemfile + Gemfile.lock): they're evaluated
+// by ecosystem, concurrently, and a combined report is displayed, with the
+// statuses (color for Runtime / Dev.) of each ecosystem, and the list of deps
+// with their color. A StatusError is returned if the worst runtime status
+// isn't green.
+// If asOf isn't zero, the advisories published after this date are ignored
+// (see models.DependenciesAsOf).
+func LiveEvaluation(files []string, asOf time.Time) error {
 
 	dfiles, err := models.LookupDependencyFiles(files)
 	if err != nil {
 		return err
 	}
+	if err = models.ValidateDependencyFiles(dfiles); err != nil {
+		return err
+	}
+
+	groups := groupByEcosystem(dfiles)
+	verdicts := make([]Verdict, len(groups))
+	errs := make([]error, len(groups))
+	var mu sync.Mutex // serializes progress messages
+	var wg sync.WaitGroup
+	for i, g := range groups {
+		wg.Add(1)
+		go func(i int, g []*models.DependencyFile) {
+			defer wg.Done()
+			verdicts[i], errs[i] = evaluate(g)
+			mu.Lock()
+			defer mu.Unlock()
+			if errs[i] == nil && !config.RawFormat && !config.JSONOutput {
+				fmt.Fprintf(Output, "%s: %s\n", verdicts[i].Ecosystem, utils.StatusDots(verdicts[i].RuntimeStatus))
+			}
+		}(i, g)
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			return err
+		}
+	}
+	if config.RawFormat {
+		return nil
+	}
+
+	undated := 0
+	if !asOf.IsZero() {
+		for i := range verdicts {
+			undated += verdicts[i].asOf(asOf)
+		}
+	}
+	report := newReport(verdicts)
+	if !asOf.IsZero() {
+		report.AsOf = &asOf
+		if !config.JSONOutput {
+			fmt.Fprint(Output, i18n.T("eval.as_of", asOf.Format("2006-01-02")))
+			if undated > 0 {
+				fmt.Fprint(Output, i18n.T("eval.as_of_undated", undated))
+			}
+			fmt.Fprintln(Output)
+		}
+	}
+	if config.JSONOutput {
+		if err := utils.PrintJSON(Output, report); err != nil {
+			return err
+		}
+	} else if err := renderReport(report); err != nil {
+		return err
+	}
+
+	if exitCodes[report.RuntimeStatus] > 0 {
+		return &StatusError{report.RuntimeStatus}
+	}
+	return nil
+}
+
+// Group the files by ecosystem, sorted by ecosystem name
+func groupByEcosystem(dfiles []*models.DependencyFile) [][]*models.DependencyFile {
+	byEcosystem := map[string][]*models.DependencyFile{}
+	names := []string{}
+	for _, df := range dfiles {
+		ecosystem := df.Ecosystem()
+		if _, ok := byEcosystem[ecosystem]; !ok {
+			names = append(names, ecosystem)
+		}
+		byEcosystem[ecosystem] = append(byEcosystem[ecosystem], df)
+	}
+	sort.Strings(names)
+	groups := [][]*models.DependencyFile{}
+	for _, name := range names {
+		groups = append(groups, byEcosystem[name])
+	}
+	return groups
+}
+
+// Evaluate the files of an ecosystem, and wait until the job is done
+func evaluate(dfiles []*models.DependencyFile) (Verdict, error) {
+	verdict := Verdict{Ecosystem: dfiles[0].Ecosystem()}
+	if verdict.Ecosystem == "" {
+		verdict.Ecosystem = "other"
+	}
+	for _, df := range dfiles {
+		verdict.Files = append(verdict.Files, df.Path)
+	}
 
 	requestDeps := map[string][]*models.DependencyFile{"dependency_files": dfiles}
 	var jsonResp map[string]interface{}
@@ -40,19 +183,13 @@ func LiveEvaluation(files []string) error {
 		Body:   requestDeps,
 		Result: &jsonResp,
 	}
-	err = gemnasium.APIRequest(opts)
+	err := gemnasium.APIRequest(opts)
 	if err != nil {
-		return err
+		return verdict, err
 	}
 
 	// Wait until job is done
 	url := fmt.Sprintf("%s%s/%s", config.APIEndpoint, LIVE_EVAL_PATH, jsonResp["job_id"])
-	req, err := http.NewRequest("GET", url, nil)
-	if err != nil {
-		return err
-	}
-	req.SetBasicAuth("x", config.APIKey)
-	req.Header.Add("Content-Type", "application/json")
 	var response struct {
 		Status string `json:"status"`
 		Result struct {
@@ -61,18 +198,20 @@ func LiveEvaluation(files []string) error {
 			Dependencies      []models.Dependency `json:"dependencies"`
 		} `json:"result"`
 	}
-	var iter int // used to display the little dots for each loop bellow
-	client := &http.Client{}
+	client := utils.NewAPIClient()
 	for {
-		// use the same request again and again
+		req, err := utils.NewAPIRequest("GET", url, config.APIKey, nil)
+		if err != nil {
+			return verdict, err
+		}
 		resp, err := client.Do(req)
 		if err != nil {
-			return err
+			return verdict, err
 		}
-		defer resp.Body.Close()
 		body, err := ioutil.ReadAll(resp.Body)
+		resp.Body.Close()
 		if err != nil {
-			return err
+			return verdict, err
 		}
 
 		if resp.StatusCode != http.StatusOK {
@@ -80,33 +219,85 @@ func LiveEvaluation(files []string) error {
 		}
 
 		if err = json.Unmarshal(body, &response); err != nil {
-			return err
+			return verdict, err
 		}
 
-		if !config.RawFormat { // don't display status if RawFormat
-			iter += 1
-			fmt.Printf("\rJob Status: %s%s", response.Status, strings.Repeat(".", iter))
-		}
 		if response.Status != "working" && response.Status != "queued" { // Job has completed or failed or whatever
 			if config.RawFormat {
-				fmt.Printf("%s\n", body)
-				return nil
+				fmt.Fprintf(Output, "%s\n", body)
 			}
 			break
 		}
-		// Wait 1s before trying again
-		time.Sleep(time.Second * 1)
+		time.Sleep(pollInterval)
 	}
 
-	color.Println(fmt.Sprintf("\n\n%-12.12s %s", "Run. Status", utils.StatusDots(response.Result.RuntimeStatus)))
-	color.Println(fmt.Sprintf("%-12.12s %s\n\n", "Dev. Status", utils.StatusDots(response.Result.DevelopmentStatus)))
+	verdict.RuntimeStatus = response.Result.RuntimeStatus
+	verdict.DevelopmentStatus = response.Result.DevelopmentStatus
+	verdict.Dependencies = response.Result.Dependencies
+	return verdict, nil
+}
 
-	// Display deps in an ascii table
-	models.RenderDepsAsTable(response.Result.Dependencies, os.Stdout)
+// Evaluate the dependencies as of date, and update the statuses accordingly.
+// Return the number of advisories without publication date.
+func (v *Verdict) asOf(date time.Time) int {
+	deps, undated := models.DependenciesAsOf(v.Dependencies, date)
+	v.Dependencies = deps
+	runtime, development := []string{}, []string{}
+	for _, dep := range deps {
+		if dep.Type == "development" {
+			development = append(development, dep.Color)
+		} else {
+			runtime = append(runtime, dep.Color)
+		}
+	}
+	if len(runtime) > 0 {
+		v.RuntimeStatus = worstStatus(runtime...)
+	}
+	if len(development) > 0 {
+		v.DevelopmentStatus = worstStatus(development...)
+	}
+	return undated
+}
 
-	if response.Result.RuntimeStatus == "red" {
-		return fmt.Errorf("There are important updates available.\n")
+var statusRanks = map[string]int{"green": 1, "yellow": 2, "red": 3}
+
+// Return the worst of the statuses (red, then yellow, then green)
+func worstStatus(statuses ...string) string {
+	worst := ""
+	for _, status := range statuses {
+		if statusRanks[status] > statusRanks[worst] {
+			worst = status
+		}
 	}
+	return worst
+}
 
-	return nil
+func newReport(verdicts []Verdict) *Report {
+	report := &Report{Ecosystems: verdicts, Dependencies: []models.Dependency{}}
+	for _, v := range verdicts {
+		report.RuntimeStatus = worstStatus(report.RuntimeStatus, v.RuntimeStatus)
+		report.DevelopmentStatus = worstStatus(report.DevelopmentStatus, v.DevelopmentStatus)
+		report.Dependencies = append(report.Dependencies, v.Dependencies...)
+	}
+	return report
+}
+
+func renderReport(report *Report) error {
+	fmt.Fprint(Output, color.Sprint(fmt.Sprintf("\n%-12.12s %s\n", "Run. Status", utils.StatusDots(report.RuntimeStatus))))
+	fmt.Fprint(Output, color.Sprint(fmt.Sprintf("%-12.12s %s\n\n", "Dev. Status", utils.StatusDots(report.DevelopmentStatus))))
+
+	// Statuses by ecosystem, when there are several
+	if len(report.Ecosystems) > 1 {
+		table := utils.NewTable(Output, "Ecosystem", "Files", "Run. Status", "Dev. Status")
+		for _, v := range report.Ecosystems {
+			table.Append(v.Ecosystem, fmt.Sprint(len(v.Files)), utils.StatusDots(v.RuntimeStatus), utils.StatusDots(v.DevelopmentStatus))
+		}
+		if err := table.Render(); err != nil {
+			return err
+		}
+		fmt.Fprintln(Output)
+	}
+
+	// Display deps in an ascii table
+	return models.RenderDepsAsTable(report.Dependencies, Output)
 }
@@ -0,0 +1,93 @@
+package liveeval
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/models"
+)
+
+func TestLiveEvaluation(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-eval")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	files := map[string]string{
+		"Gemfile":      "source 'https://rubygems.org'\ngem 'rails'\n",
+		"package.json": `{"dependencies": {"lodash": "4.17.0"}}`,
+	}
+	paths := []string{}
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+
+	// One job per ecosystem: the npm one is red
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method + " " + r.URL.Path {
+		case "POST /evaluate":
+			var body struct {
+				DependencyFiles []models.DependencyFile `json:"dependency_files"`
+			}
+			json.NewDecoder(r.Body).Decode(&body)
+			if len(body.DependencyFiles) != 1 {
+				t.Errorf("Expected one file per evaluation, got %d", len(body.DependencyFiles))
+			}
+			fmt.Fprintf(w, `{"job_id": "%s"}`, filepath.Base(body.DependencyFiles[0].Path))
+		case "GET /evaluate/Gemfile":
+			fmt.Fprintln(w, `{"status": "done", "result": {"runtime_status": "green", "development_status": "yellow", "dependencies": [{"package": {"name": "rails"}, "color": "green"}]}}`)
+		case "GET /evaluate/package.json":
+			fmt.Fprintln(w, `{"status": "done", "result": {"runtime_status": "red", "development_status": "green", "dependencies": [{"package": {"name": "lodash"}, "color": "red"}]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	var out bytes.Buffer
+	Output = &out
+	config.JSONOutput = true
+	defer func() {
+		Output = os.Stdout
+		config.JSONOutput = false
+	}()
+
+	err = LiveEvaluation(paths, time.Time{})
+	statusErr, ok := err.(*StatusError)
+	if !ok || statusErr.ExitCode() != 2 {
+		t.Fatalf("Expected a red status error, got %v", err)
+	}
+	var report Report
+	if err := json.Unmarshal(out.Bytes(), &report); err != nil {
+		t.Fatal(err)
+	}
+	if report.RuntimeStatus != "red" || report.DevelopmentStatus != "yellow" || len(report.Ecosystems) != 2 || len(report.Dependencies) != 2 {
+		t.Errorf("Unexpected report: %+v", report)
+	}
+	if report.Ecosystems[0].Ecosystem != "npm" || report.Ecosystems[1].Ecosystem != "ruby" || !strings.HasSuffix(report.Ecosystems[1].Files[0], "Gemfile") {
+		t.Errorf("Expected the verdicts by ecosystem, got %+v", report.Ecosystems)
+	}
+}
+
+func TestWorstStatus(t *testing.T) {
+	if status := worstStatus("green", "", "yellow", "green"); status != "yellow" {
+		t.Errorf("Expected yellow, got %s", status)
+	}
+	if status := worstStatus(); status != "" {
+		t.Errorf("Expected no status, got %s", status)
+	}
+}
@@ -8,8 +8,12 @@ import (
 )
 
 func main() {
+	defer commands.RecoverPanic()
 	app := commands.App()
-	err := app.Run(os.Args)
+	args, err := commands.ApplyDeprecations(commands.ExpandArgs(os.Args), os.Stderr)
+	if err == nil {
+		err = app.Run(args)
+	}
 	if err != nil {
 		color.Printf("@{r!}%s", err.Error())
 		os.Exit(1)
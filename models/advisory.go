@@ -1,14 +1,47 @@
 package models
 
+import "time"
+
 type Advisory struct {
-	ID               int      `json:"id"`
-	Title            string   `json:"title"`
-	Identifier       string   `json:"identifier"`
-	Description      string   `json:"description"`
-	Solution         string   `json:"solution"`
-	AffectedVersions string   `json:"affected_versions"`
-	Package          Package  `json:"package"`
-	CuredVersions    string   `json:"cured_versions"`
-	Credits          string   `json:"credits"`
-	Links            []string `json:"links"`
+	ID               int        `json:"id"`
+	Title            string     `json:"title"`
+	Identifier       string     `json:"identifier"`
+	Description      string     `json:"description"`
+	Solution         string     `json:"solution"`
+	AffectedVersions string     `json:"affected_versions"`
+	Package          Package    `json:"package"`
+	CuredVersions    string     `json:"cured_versions"`
+	Credits          string     `json:"credits"`
+	Links            []string   `json:"links"`
+	PublishedAt      *time.Time `json:"published_at,omitempty"`
+	CVSSScore        float64    `json:"cvss_score,omitempty"`
+	EPSSScore        float64    `json:"epss_score,omitempty"`
+}
+
+// Return the dependencies as they would have been evaluated at date:
+// advisories published after it are removed, and dependencies which were only
+// red because of them turn yellow (an update fixes them now). Advisories
+// without publication date are kept, and counted in undated.
+func DependenciesAsOf(deps []Dependency, date time.Time) (asOf []Dependency, undated int) {
+	asOf = make([]Dependency, len(deps))
+	for i, dep := range deps {
+		asOf[i] = dep
+		if len(dep.Advisories) == 0 {
+			continue
+		}
+		asOf[i].Advisories = nil
+		for _, a := range dep.Advisories {
+			switch {
+			case a.PublishedAt == nil:
+				undated++
+			case a.PublishedAt.After(date):
+				continue
+			}
+			asOf[i].Advisories = append(asOf[i].Advisories, a)
+		}
+		if len(asOf[i].Advisories) == 0 && dep.Color == "red" {
+			asOf[i].Color = "yellow"
+		}
+	}
+	return asOf, undated
 }
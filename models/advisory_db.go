@@ -0,0 +1,234 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gemnasium/toolbelt/cache"
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+const ADVISORIES_PATH = "/advisories"
+
+// Offline scans warn when the database hasn't been synced for longer than this
+var AdvisoryDBMaxAge = 7 * 24 * time.Hour
+
+// Types of the advisory packages, by ecosystem of the locked packages (see
+// LockedPackage)
+var advisoryPackageTypes = map[string]string{
+	"gem":      "rubygem",
+	"npm":      "npm",
+	"composer": "packagist",
+	"cargo":    "cargo",
+	"golang":   "go",
+}
+
+// Local copy of the advisory database, to match lockfiles against advisories
+// without calling the API (see ScanOffline)
+type AdvisoryDB struct {
+	UpdatedAt  time.Time  `json:"updated_at"`
+	Advisories []Advisory `json:"advisories"`
+}
+
+// A locked package affected by an advisory
+type Vulnerability struct {
+	Package  LockedPackage
+	Advisory Advisory
+}
+
+func advisoryDBPath() string {
+	if config.AdvisoryDB != "" {
+		return config.AdvisoryDB
+	}
+	dir := cache.Dir(cache.ADVISORY)
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "db.json")
+}
+
+// Load the local advisory database. An error telling how to download it is
+// returned if there's none.
+func LoadAdvisoryDB() (*AdvisoryDB, error) {
+	path := advisoryDBPath()
+	if path == "" {
+		return nil, cache.ErrCacheDisabled
+	}
+	data, err := ioutil.ReadFile(path)
+	if os.IsNotExist(err) {
+		return nil, errors.New(i18n.T("advisories.missing_db", path))
+	}
+	if err != nil {
+		return nil, err
+	}
+	var db AdvisoryDB
+	if err := json.Unmarshal(data, &db); err != nil {
+		return nil, fmt.Errorf("%s: %s", path, err)
+	}
+	return &db, nil
+}
+
+func (db *AdvisoryDB) Save() error {
+	path := advisoryDBPath()
+	if path == "" {
+		return cache.ErrCacheDisabled
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(db)
+	if err != nil {
+		return err
+	}
+	// Write then rename, so a scan never reads a partial database
+	tmp := path + ".tmp"
+	if err := ioutil.WriteFile(tmp, data, 0644); err != nil {
+		return err
+	}
+	return os.Rename(tmp, path)
+}
+
+// Download the advisories published or updated since the last sync (all of
+// them the first time), and merge them into the local database. Return the
+// database and the number of advisories fetched.
+func SyncAdvisoryDB() (*AdvisoryDB, int, error) {
+	db, err := LoadAdvisoryDB()
+	if err != nil {
+		db = &AdvisoryDB{}
+	}
+	syncedAt := time.Now()
+	uri := ADVISORIES_PATH
+	if !db.UpdatedAt.IsZero() {
+		uri += "?since=" + url.QueryEscape(db.UpdatedAt.Format(time.RFC3339))
+	}
+	var advisories []Advisory
+	opts := &gemnasium.APIRequestOptions{
+		Method: "GET",
+		URI:    uri,
+		Result: &advisories,
+	}
+	if err := gemnasium.APIRequest(opts); err != nil {
+		return nil, 0, err
+	}
+
+	byID := map[int]int{}
+	for i, a := range db.Advisories {
+		byID[a.ID] = i
+	}
+	for _, a := range advisories {
+		if i, ok := byID[a.ID]; ok {
+			db.Advisories[i] = a
+		} else {
+			byID[a.ID] = len(db.Advisories)
+			db.Advisories = append(db.Advisories, a)
+		}
+	}
+	db.UpdatedAt = syncedAt
+	return db, len(advisories), db.Save()
+}
+
+// Return the advisories of the database affecting the locked package
+func (db *AdvisoryDB) Match(pkg LockedPackage) []Advisory {
+	matches := []Advisory{}
+	packageType := advisoryPackageTypes[pkg.Ecosystem]
+	for _, a := range db.Advisories {
+		if a.Package.Name != pkg.Name || !strings.EqualFold(a.Package.Type, packageType) {
+			continue
+		}
+		if affectsVersion(a.AffectedVersions, pkg.Version) {
+			matches = append(matches, a)
+		}
+	}
+	return matches
+}
+
+var versionComparison = regexp.MustCompile(`(>=|<=|!=|==|=|>|<)?\s*v?([0-9][^\s,|]*)`)
+
+// Return true if version is in the affected versions of an advisory, like
+// "<1.6.11 || >=2.0.0 <2.0.6". Alternatives are separated with "||", and
+// comparisons with spaces or commas. Unparseable ranges match nothing.
+func affectsVersion(affected, version string) bool {
+	if version == "" {
+		return false
+	}
+	for _, alternative := range strings.Split(affected, "||") {
+		comparisons := versionComparison.FindAllStringSubmatch(alternative, -1)
+		if len(comparisons) == 0 {
+			continue
+		}
+		matches := true
+		for _, c := range comparisons {
+			cmp := utils.CompareVersions(version, c[2])
+			switch c[1] {
+			case ">=":
+				matches = cmp >= 0
+			case "<=":
+				matches = cmp <= 0
+			case ">":
+				matches = cmp > 0
+			case "<":
+				matches = cmp < 0
+			case "!=":
+				matches = cmp != 0
+			default:
+				matches = cmp == 0
+			}
+			if !matches {
+				break
+			}
+		}
+		if matches {
+			return true
+		}
+	}
+	return false
+}
+
+// Match the packages resolved in the lockfiles among dfiles against the local
+// advisory database, without calling the API
+func ScanOffline(dfiles []*DependencyFile) ([]Vulnerability, error) {
+	db, err := LoadAdvisoryDB()
+	if err != nil {
+		return nil, err
+	}
+	if age := time.Since(db.UpdatedAt); age > AdvisoryDBMaxAge {
+		fmt.Fprint(Output, i18n.T("advisories.stale_db", int(age.Hours()/24)))
+	}
+	vulnerabilities := []Vulnerability{}
+	for _, df := range dfiles {
+		for _, pkg := range df.LockedPackages() {
+			for _, a := range db.Match(pkg) {
+				vulnerabilities = append(vulnerabilities, Vulnerability{pkg, a})
+			}
+		}
+	}
+	sort.SliceStable(vulnerabilities, func(i, j int) bool {
+		return vulnerabilities[i].Package.File < vulnerabilities[j].Package.File
+	})
+	return vulnerabilities, nil
+}
+
+func RenderVulnerabilitiesAsTable(vulnerabilities []Vulnerability) error {
+	table := utils.NewTable(Output, "File", "Package", "Locked", "Advisory", "Severity", "Cured Versions")
+	for _, v := range vulnerabilities {
+		advisory := v.Advisory.Identifier
+		if advisory == "" {
+			advisory = strconv.Itoa(v.Advisory.ID)
+		}
+		table.Append(v.Package.File, v.Package.Name, v.Package.Version, advisory+" "+v.Advisory.Title, v.Advisory.Severity(), v.Advisory.CuredVersions)
+	}
+	return table.Render()
+}
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestAffectsVersion(t *testing.T) {
+	var tt = []struct {
+		affected string
+		version  string
+		expected bool
+	}{
+		{"<1.6.11 || >=2.0.0 <2.0.6", "1.6.4", true},
+		{"<1.6.11 || >=2.0.0 <2.0.6", "1.6.11", false},
+		{"<1.6.11 || >=2.0.0 <2.0.6", "2.0.5", true},
+		{"<1.6.11 || >=2.0.0 <2.0.6", "2.0.6", false},
+		{">= 4.0, < 4.2.1", "4.1.0", true},
+		{"=1.0.0", "1.0.1", false},
+		{"1.0.0", "1.0.0", true},
+		{"<v1.2.0", "v1.1.9", true},
+		{"all versions", "1.0.0", false},
+	}
+	for _, test := range tt {
+		if result := affectsVersion(test.affected, test.version); result != test.expected {
+			t.Errorf("affectsVersion(%q, %q): expected %v, got %v", test.affected, test.version, test.expected, result)
+		}
+	}
+}
+
+func TestSyncAdvisoryDBAndScanOffline(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-advisories")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.AdvisoryDB = filepath.Join(dir, "db.json")
+	defer func() { config.AdvisoryDB = "" }()
+	Output = ioutil.Discard
+	defer func() { Output = os.Stdout }()
+
+	if _, err := ScanOffline(nil); err == nil {
+		t.Error("Expected an error without database")
+	}
+
+	syncs := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		syncs = append(syncs, r.URL.Query().Get("since"))
+		if len(syncs) == 1 {
+			fmt.Fprintln(w, `[
+  {"id": 1, "title": "DoS", "affected_versions": "<1.6.11", "package": {"name": "rack", "type": "Rubygem"}},
+  {"id": 2, "title": "XSS", "affected_versions": "<4.2.5", "package": {"name": "rails", "type": "Rubygem"}}
+]`)
+			return
+		}
+		fmt.Fprintln(w, `[{"id": 2, "title": "XSS", "affected_versions": "<4.2.0", "package": {"name": "rails", "type": "Rubygem"}}]`)
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	for i := 0; i < 2; i++ {
+		if _, _, err := SyncAdvisoryDB(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if syncs[0] != "" || syncs[1] == "" {
+		t.Errorf("Expected a full sync, then an incremental one, got: %q", syncs)
+	}
+
+	dfiles := []*DependencyFile{{Path: "Gemfile.lock", Content: []byte("GEM\n  specs:\n    rack (1.6.4)\n    rails (4.2.0)\n")}}
+	vulnerabilities, err := ScanOffline(dfiles)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(vulnerabilities) != 1 || vulnerabilities[0].Package.Name != "rack" || vulnerabilities[0].Advisory.ID != 1 {
+		t.Errorf("Expected rack to be the only vulnerable package, got: %v", vulnerabilities)
+	}
+}
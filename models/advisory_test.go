@@ -0,0 +1,40 @@
+package models
+
+import (
+	"testing"
+	"time"
+)
+
+func TestDependenciesAsOf(t *testing.T) {
+	published := func(date string) *time.Time {
+		t, _ := time.Parse("2006-01-02", date)
+		return &t
+	}
+	deps := []Dependency{
+		{Package: Package{Name: "rails"}, Color: "red", Advisories: []Advisory{
+			{Identifier: "CVE-2016-0752", PublishedAt: published("2016-01-25")},
+			{Identifier: "CVE-2016-6316", PublishedAt: published("2016-08-11")},
+		}},
+		{Package: Package{Name: "rack"}, Color: "red", Advisories: []Advisory{
+			{Identifier: "CVE-2016-6317", PublishedAt: published("2016-08-11")},
+		}},
+		{Package: Package{Name: "nokogiri"}, Color: "red", Advisories: []Advisory{{Identifier: "OSVDB-1"}}},
+		{Package: Package{Name: "sass"}, Color: "green"},
+	}
+	asOf, undated := DependenciesAsOf(deps, *published("2016-06-01"))
+	if undated != 1 {
+		t.Errorf("Expected 1 advisory without publication date, got %d", undated)
+	}
+	if len(asOf[0].Advisories) != 1 || asOf[0].Advisories[0].Identifier != "CVE-2016-0752" || asOf[0].Color != "red" {
+		t.Errorf("Expected rails to be red with the advisory published before, got %+v", asOf[0])
+	}
+	if len(asOf[1].Advisories) != 0 || asOf[1].Color != "yellow" {
+		t.Errorf("Expected rack to turn yellow without advisory, got %+v", asOf[1])
+	}
+	if len(asOf[2].Advisories) != 1 || asOf[2].Color != "red" {
+		t.Errorf("Expected undated advisories to be kept, got %+v", asOf[2])
+	}
+	if len(deps[1].Advisories) != 1 {
+		t.Error("Expected the dependencies to be left untouched")
+	}
+}
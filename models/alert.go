@@ -3,8 +3,9 @@ package models
 import "time"
 
 type Alert struct {
-	ID       int       `json:"id"`
-	Advisory Advisory  `json:"advisory"`
-	OpenAt   time.Time `json:"open_at"`
-	Status   string    `json:"status"`
+	ID       int        `json:"id"`
+	Advisory Advisory   `json:"advisory"`
+	OpenAt   time.Time  `json:"open_at"`
+	Status   string     `json:"status"`
+	ClosedAt *time.Time `json:"closed_at,omitempty"`
 }
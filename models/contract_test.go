@@ -0,0 +1,39 @@
+package models
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"path/filepath"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/gemnasium"
+)
+
+// Contract tests: the responses recorded from the API (in testdata/api) must
+// decode into the models, without fields of an unexpected type. Fields the
+// models don't know are fine, but listed to spot API additions.
+func TestAPIContracts(t *testing.T) {
+	var tt = []struct {
+		fixture string
+		result  interface{}
+	}{
+		{"projects.json", &map[string][]Project{}},
+		{"project.json", &Project{}},
+		{"dependencies.json", &[]Dependency{}},
+		{"alerts.json", &[]Alert{}},
+		{"dependency_files.json", &[]DependencyFile{}},
+	}
+	for _, test := range tt {
+		data, err := ioutil.ReadFile(filepath.Join("testdata", "api", test.fixture))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := json.Unmarshal(data, test.result); err != nil {
+			t.Errorf("%s: %s", test.fixture, err)
+			continue
+		}
+		if fields := gemnasium.UnknownFields(data, test.result); len(fields) > 0 {
+			t.Logf("%s: fields unknown to the models: %v", test.fixture, fields)
+		}
+	}
+}
@@ -0,0 +1,201 @@
+package models
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/wsxiaoys/terminal"
+)
+
+// Views of the dashboard
+const (
+	DASHBOARD_PROJECTS = "projects"
+	DASHBOARD_ALERTS   = "alerts"
+	DASHBOARD_OUTDATED = "outdated"
+)
+
+// Keyboard driven dashboard of the monitored projects: their open alerts and
+// outdated dependencies, loaded when a project is opened. It's read-only.
+type Dashboard struct {
+	Projects []Project
+	// Lines of the terminal, to scroll the lists (no scrolling if zero)
+	Height int
+
+	view     string
+	selected int
+	alerts   map[string][]Alert
+	outdated map[string][]Dependency
+	err      error
+}
+
+func NewDashboard(projects []Project) *Dashboard {
+	return &Dashboard{
+		Projects: projects,
+		view:     DASHBOARD_PROJECTS,
+		alerts:   map[string][]Alert{},
+		outdated: map[string][]Dependency{},
+	}
+}
+
+// Handle a key: up, down, enter, back, a, d, r or q. Return true to quit.
+func (d *Dashboard) HandleKey(key string) bool {
+	switch key {
+	case "q":
+		return true
+	case "up", "k":
+		if d.view == DASHBOARD_PROJECTS && d.selected > 0 {
+			d.selected--
+		}
+	case "down", "j":
+		if d.view == DASHBOARD_PROJECTS && d.selected < len(d.Projects)-1 {
+			d.selected++
+		}
+	case "enter", "a":
+		d.open(DASHBOARD_ALERTS)
+	case "d":
+		d.open(DASHBOARD_OUTDATED)
+	case "back", "h":
+		d.view, d.err = DASHBOARD_PROJECTS, nil
+	case "r":
+		if len(d.Projects) > 0 {
+			slug := d.Projects[d.selected].Slug
+			delete(d.alerts, slug)
+			delete(d.outdated, slug)
+			if d.view != DASHBOARD_PROJECTS {
+				d.open(d.view)
+			}
+		}
+	}
+	return false
+}
+
+// Show a view of the selected project, loading its data if needed
+func (d *Dashboard) open(view string) {
+	if len(d.Projects) == 0 {
+		return
+	}
+	d.view, d.err = view, nil
+	p := &d.Projects[d.selected]
+	switch view {
+	case DASHBOARD_ALERTS:
+		if _, ok := d.alerts[p.Slug]; ok {
+			return
+		}
+		alerts, err := p.Alerts()
+		if err != nil {
+			d.err = err
+			return
+		}
+		open := []Alert{}
+		for _, a := range alerts {
+			if a.Status != "closed" {
+				open = append(open, a)
+			}
+		}
+		d.alerts[p.Slug] = open
+	case DASHBOARD_OUTDATED:
+		if _, ok := d.outdated[p.Slug]; ok {
+			return
+		}
+		deps, err := p.Dependencies()
+		if err != nil {
+			d.err = err
+			return
+		}
+		outdated := []Dependency{}
+		for _, dep := range deps {
+			if dep.Color == "yellow" || dep.Color == "red" {
+				outdated = append(outdated, dep)
+			}
+		}
+		d.outdated[p.Slug] = outdated
+	}
+}
+
+// Draw the current view on the whole screen. Lines end with "\r\n", as the
+// terminal is in raw mode.
+func (d *Dashboard) Render(w io.Writer) {
+	screen := &terminal.TerminalWriter{Writer: w}
+	screen.Clear().Move(1, 1)
+	// Color syntax (see wsxiaoys/terminal/color) is only read in formats,
+	// names are args
+	line := func(format string, a ...interface{}) {
+		screen.Colorf(format, a...).Reset().Print("\r\n")
+	}
+	type row struct {
+		format string
+		args   []interface{}
+	}
+
+	rows := []row{}
+	switch d.view {
+	case DASHBOARD_PROJECTS:
+		line("@{!}%s", i18n.T("tui.projects", len(d.Projects)))
+		for _, p := range d.Projects {
+			rows = append(rows, row{dashboardColor(p.Color) + "%s", []interface{}{p.Slug}})
+		}
+	case DASHBOARD_ALERTS:
+		slug := d.Projects[d.selected].Slug
+		line("@{!}%s", i18n.T("tui.alerts", slug, len(d.alerts[slug])))
+		for _, a := range d.alerts[slug] {
+			identifier := a.Advisory.Identifier
+			if identifier == "" {
+				identifier = fmt.Sprint(a.Advisory.ID)
+			}
+			rows = append(rows, row{"@r%s@| %s (%s) %s", []interface{}{identifier, a.Advisory.Package.Name, a.Advisory.Package.Type, a.Status}})
+		}
+	case DASHBOARD_OUTDATED:
+		slug := d.Projects[d.selected].Slug
+		line("@{!}%s", i18n.T("tui.outdated", slug, len(d.outdated[slug])))
+		for _, dep := range d.outdated[slug] {
+			rows = append(rows, row{dashboardColor(dep.Color) + "%s@| %s (%s)", []interface{}{dep.Package.Name, dep.LockedVersion, dep.Requirement}})
+		}
+	}
+	line("")
+
+	if d.err != nil {
+		line("@r%s", i18n.T("tui.error", d.err))
+	} else if len(rows) == 0 {
+		line("%s", i18n.T("tui.empty"))
+	}
+	first := 0
+	if d.view == DASHBOARD_PROJECTS {
+		first = d.scroll()
+	}
+	for i := first; i < len(rows) && (d.Height == 0 || i-first < d.Height-4); i++ {
+		prefix := "  "
+		if d.view == DASHBOARD_PROJECTS && i == d.selected {
+			prefix = "@{!}> "
+		}
+		line(prefix+rows[i].format, rows[i].args...)
+	}
+
+	line("")
+	if d.view == DASHBOARD_PROJECTS {
+		screen.Colorf("@{.}%s", i18n.T("tui.help_projects")).Reset()
+	} else {
+		screen.Colorf("@{.}%s", i18n.T("tui.help_project")).Reset()
+	}
+}
+
+// First row displayed, so that the selected one is visible
+func (d *Dashboard) scroll() int {
+	visible := d.Height - 4
+	if d.Height == 0 || d.selected < visible {
+		return 0
+	}
+	return d.selected - visible + 1
+}
+
+func dashboardColor(c string) string {
+	switch c {
+	case "red":
+		return "@r"
+	case "yellow":
+		return "@y"
+	case "green":
+		return "@g"
+	}
+	return ""
+}
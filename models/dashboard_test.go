@@ -0,0 +1,75 @@
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestDashboard(t *testing.T) {
+	ts := OrgTestServer()
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	projects, err := getOrgProjects("acme", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := NewDashboard(projects)
+	render := func() string {
+		var buf bytes.Buffer
+		d.Render(&buf)
+		return buf.String()
+	}
+
+	if screen := render(); !strings.Contains(screen, "Projects (2)") || !strings.Contains(screen, "shop") || !strings.Contains(screen, "api") {
+		t.Errorf("Expected the list of projects, got:\n%q", screen)
+	}
+	// Moves stay in the list
+	for _, key := range []string{"down", "down", "up"} {
+		d.HandleKey(key)
+	}
+	if d.Projects[d.selected].Slug != "shop" {
+		t.Errorf("Expected shop to be selected, got %s", d.Projects[d.selected].Slug)
+	}
+
+	d.HandleKey("enter")
+	screen := render()
+	if !strings.Contains(screen, "Open alerts of shop (1)") || !strings.Contains(screen, "CVE-2021-23337") || strings.Contains(screen, "CVE-2020-8203") {
+		t.Errorf("Expected the open alerts of shop, got:\n%q", screen)
+	}
+	d.HandleKey("d")
+	screen = render()
+	if !strings.Contains(screen, "Outdated dependencies of shop (1)") || !strings.Contains(screen, "lodash") || strings.Contains(screen, "react") {
+		t.Errorf("Expected the outdated dependencies of shop, got:\n%q", screen)
+	}
+
+	d.HandleKey("back")
+	d.HandleKey("down")
+	d.HandleKey("enter")
+	if screen := render(); !strings.Contains(screen, "Open alerts of api (0)") || !strings.Contains(screen, "Nothing to show") {
+		t.Errorf("Expected no open alerts for api, got:\n%q", screen)
+	}
+	if !d.HandleKey("q") {
+		t.Error("Expected q to quit")
+	}
+}
+
+func TestDashboardScroll(t *testing.T) {
+	projects := []Project{}
+	for _, slug := range []string{"a", "b", "c", "d", "e", "f"} {
+		projects = append(projects, Project{Slug: "project-" + slug})
+	}
+	d := NewDashboard(projects)
+	d.Height = 7
+	for i := 0; i < 5; i++ {
+		d.HandleKey("down")
+	}
+	var buf bytes.Buffer
+	d.Render(&buf)
+	if screen := buf.String(); strings.Contains(screen, "project-c") || !strings.Contains(screen, "project-f") {
+		t.Errorf("Expected the list to scroll to the selected project, got:\n%q", screen)
+	}
+}
@@ -2,12 +2,12 @@ package models
 
 import (
 	"io"
-	"os"
 	"sort"
 	"strconv"
 	"strings"
 
-	"github.com/olekukonko/tablewriter"
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/utils"
 )
 
 type Dependency struct {
@@ -26,17 +26,18 @@ func ListDependencies(project *Project) error {
 	if err != nil {
 		return err
 	}
+	if config.Query != "" {
+		return utils.PrintQuery(Output, deps, config.Query)
+	}
 
-	RenderDepsAsTable(deps, os.Stdout)
-	return nil
+	return RenderDepsAsTable(deps, Output)
 }
 
 // Display deps in an ascii table
-func RenderDepsAsTable(deps []Dependency, output io.Writer) {
+func RenderDepsAsTable(deps []Dependency, output io.Writer) error {
 	// Display deps in an ascii table
-	table := tablewriter.NewWriter(output)
 	// TODO: Add a "type" header in deps have more than 1 type
-	table.SetHeader([]string{"Dependencies", "Requirements", "Locked", "Status", "Advisories"})
+	table := utils.NewTable(output, "Dependencies", "Requirements", "Locked", "Status", "Advisories")
 
 	for _, dep := range deps {
 		// transform dep.Advisories to []string
@@ -50,7 +51,7 @@ func RenderDepsAsTable(deps []Dependency, output io.Writer) {
 		if !dep.FirstLevel {
 			levelPrefix = "+-- "
 		}
-		table.Append([]string{levelPrefix + dep.Package.Name, dep.Requirement, dep.LockedVersion, dep.Color, strings.Join(advisories, ", ")})
+		table.Append(levelPrefix+dep.Package.Name, dep.Requirement, dep.LockedVersion, dep.Color, strings.Join(advisories, ", "))
 	}
-	table.Render() // Send output
+	return table.Render() // Send output
 }
@@ -1,34 +1,109 @@
 package models
 
 import (
+	"errors"
 	"fmt"
-	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/gemnasium/toolbelt/config"
 	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/utils"
 	"github.com/olekukonko/tablewriter"
 )
 
-func ListDependencyAlerts(project *Project) error {
-	var alerts []Alert
+// Fetch and return the alerts of the project
+func (p *Project) Alerts() (alerts []Alert, err error) {
 	opts := &gemnasium.APIRequestOptions{
 		Method: "GET",
-		URI:    fmt.Sprintf("/projects/%s/alerts", project.Slug),
+		URI:    fmt.Sprintf("/projects/%s/alerts", p.Slug),
 		Result: &alerts,
 	}
-	err := gemnasium.APIRequest(opts)
+	err = gemnasium.APIRequest(opts)
+	return alerts, err
+}
+
+// List the alerts of the project. With an owners file (see LoadOwners), the
+// owners of the packages are displayed, and the alerts can be filtered by
+// owner.
+// If failOn is not empty, an error is returned when open alerts have
+// advisories at or above this severity (see CheckSeverity).
+func ListDependencyAlerts(project *Project, owner, sarifPath, failOn string) error {
+	owners, err := LoadOwners()
 	if err != nil {
 		return err
 	}
+	if owner != "" && owners == nil {
+		return errors.New(i18n.T("owners.missing_file", OWNERS_FILE_PATH))
+	}
+	alerts, err := project.Alerts()
+	if err != nil {
+		return err
+	}
+	if owner != "" {
+		owned := []Alert{}
+		for _, alert := range alerts {
+			if owners.Owns(owner, alert.Advisory.Package.Name) {
+				owned = append(owned, alert)
+			}
+		}
+		alerts = owned
+	}
+	if sarifPath != "" {
+		if err := saveAlertsAsSARIF(alerts, sarifPath); err != nil {
+			return err
+		}
+	}
+	if config.Query != "" {
+		if err := utils.PrintQuery(Output, alerts, config.Query); err != nil {
+			return err
+		}
+		return checkAlertsSeverity(alerts, failOn)
+	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Advisory", "Date", "Status"})
-
-	table.SetAlignment(tablewriter.ALIGN_LEFT) // table is lost when ID have 2 or 3 digits...
+	headers := []string{"Advisory", "Date", "Status"}
+	if owners != nil {
+		headers = append(headers, "Package", "Owners")
+	}
+	table := utils.NewTable(Output, headers...)
+	table.Configure = func(t *tablewriter.Table) {
+		t.SetAlignment(tablewriter.ALIGN_LEFT) // table is lost when ID have 2 or 3 digits...
+	}
 	for _, alert := range alerts {
-		table.Append([]string{strconv.Itoa(alert.Advisory.ID), alert.OpenAt.Format(time.RFC822), alert.Status})
+		row := []string{strconv.Itoa(alert.Advisory.ID), alert.OpenAt.Format(time.RFC822), alert.Status}
+		if owners != nil {
+			row = append(row, alert.Advisory.Package.Name, strings.Join(owners.Of(alert.Advisory.Package.Name), " "))
+		}
+		table.Append(row...)
 	}
-	table.Render() // Send output
+	if err := table.Render(); err != nil {
+		return err
+	}
+	return checkAlertsSeverity(alerts, failOn)
+}
+
+func saveAlertsAsSARIF(alerts []Alert, path string) error {
+	dfiles, _, err := ScanDependencyFiles()
+	if err != nil {
+		return err
+	}
+	vulnerabilities := LocateAlerts(alerts, dfiles)
+	if err := NewSARIFLog(vulnerabilities).Save(path); err != nil {
+		return err
+	}
+	fmt.Fprint(Output, i18n.T("scan.sarif_written", len(vulnerabilities), path))
 	return nil
 }
+
+// Check the severity of the advisories of the alerts which aren't closed
+func checkAlertsSeverity(alerts []Alert, failOn string) error {
+	advisories := []Advisory{}
+	for _, alert := range alerts {
+		if alert.Status != "closed" {
+			advisories = append(advisories, alert.Advisory)
+		}
+	}
+	return CheckSeverity(advisories, failOn)
+}
@@ -3,7 +3,6 @@ package models
 import (
 	"bytes"
 	"fmt"
-	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -40,15 +39,11 @@ func TestListDependencyAlerts(t *testing.T) {
 		fmt.Fprintln(w, jsonOutput)
 	}))
 	defer ts.Close()
-	old := os.Stdout // keep backup of the real stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
-	config.APIEndpoint = ts.URL
-	ListDependencyAlerts(&Project{Slug: "blah"})
-	w.Close()
 	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	os.Stdout = old // restoring the real stdout
+	Output = &buf
+	defer func() { Output = os.Stdout }()
+	config.APIEndpoint = ts.URL
+	ListDependencyAlerts(&Project{Slug: "blah"}, "", "", "")
 
 	expectedOutput := "+----------+---------------------+--------------+\n"
 	expectedOutput += "| ADVISORY |        DATE         |    STATUS    |\n"
@@ -1,43 +1,102 @@
 package models
 
 import (
-	"bytes"
-	"crypto/sha1"
+	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
 	"io"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"os/exec"
 	"path/filepath"
 	"regexp"
+	"sort"
 	"strings"
+	"sync"
+	"time"
 
+	"github.com/gemnasium/toolbelt/cache"
 	"github.com/gemnasium/toolbelt/config"
 	"github.com/gemnasium/toolbelt/gemnasium"
-	"github.com/olekukonko/tablewriter"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/utils"
 )
 
 const (
-	SUPPORTED_DEPENDENCY_FILES = `(Gemfile|Gemfile\.lock|.*\.gemspec|package\.json|npm-shrinkwrap\.json|setup\.py|requirements\.txt|requires\.txt|composer\.json|composer\.lock|bower\.json|yarn\.lock)$`
+	SUPPORTED_DEPENDENCY_FILES = `(Gemfile|Gemfile\.lock|.*\.gemspec|package\.json|npm-shrinkwrap\.json|setup\.py|requirements\.txt|requires\.txt|composer\.json|composer\.lock|bower\.json|yarn\.lock|go\.mod|go\.sum|Cargo\.toml|Cargo\.lock|pom\.xml|build\.gradle|build\.gradle\.kts|gradle\.lockfile|packages\.config|.*\.csproj|packages\.lock\.json)$`
 )
 
 type DependencyFile struct {
-	Path    string `json:"path"`
-	SHA     string `json:"sha,omitempty"`
-	Content []byte `json:"content"`
+	Path      string     `json:"path"`
+	SHA       string     `json:"sha,omitempty"`
+	Content   []byte     `json:"content"`
+	Size      int64      `json:"size,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+	// Previous path of the file, when it has been moved (see detectRenames)
+	RenamedFrom string `json:"renamed_from,omitempty"`
 }
 
+// Read and hash the file in a single pass. Return nil if it can't be read:
+// use NewDependencyFileE to know why.
 func NewDependencyFile(filePath string) *DependencyFile {
-	content, err := ioutil.ReadFile(filePath)
+	df, _ := NewDependencyFileE(filePath)
+	return df
+}
+
+// Read and hash the file in a single pass, or return why it can't be read
+// (ie: an *os.PathError if it doesn't exist)
+func NewDependencyFileE(filePath string) (*DependencyFile, error) {
+	return newDependencyFile(filePath, 0)
+}
+
+// Read and hash the file in a single pass, without keeping the content of
+// the file if it's bigger than maxSize (see readFileSHA1 and LoadContent)
+func newDependencyFile(filePath string, maxSize int64) (*DependencyFile, error) {
+	content, sha, size, err := readFileSHA1(filePath, maxSize)
 	if err != nil {
+		return nil, err
+	}
+	return &DependencyFile{Path: filePath, SHA: sha, Content: content, Size: size}, nil
+}
+
+// Read the content of the file if it hasn't been kept when it was hashed
+// (see config.MaxFileSize)
+func (df *DependencyFile) LoadContent() error {
+	if df.Content != nil || df.Size == 0 {
 		return nil
 	}
-	sha, err := GetFileSHA1(filePath)
+	content, err := df.contents()
 	if err != nil {
-		return nil
+		return err
 	}
-	return &DependencyFile{Path: filePath, SHA: sha, Content: content}
+	df.Content = content
+	return nil
+}
+
+// Size of the content, whether it's been kept or not
+func (df *DependencyFile) contentSize() int64 {
+	if df.Content == nil {
+		return df.Size
+	}
+	return int64(len(df.Content))
+}
 
+// Return the content of the file, read from disk without keeping it if it
+// hasn't been kept when the file was hashed
+func (df *DependencyFile) contents() ([]byte, error) {
+	if df.Content != nil || df.Size == 0 {
+		return df.Content, nil
+	}
+	content, err := readFile(df.Path)
+	if err != nil {
+		return nil, err
+	}
+	if sha := ContentSHA1(content); sha != df.SHA {
+		return nil, errors.New(i18n.T("df.signature_mismatch", df.Path, df.SHA, sha))
+	}
+	return content, nil
 }
 
 func (df *DependencyFile) CheckFileSHA1() error {
@@ -47,7 +106,7 @@ func (df *DependencyFile) CheckFileSHA1() error {
 	}
 
 	if sum != df.SHA {
-		return fmt.Errorf("%s: File signature doesn't match (expected: %s, got: %s)", df.Path, df.SHA, sum)
+		return errors.New(i18n.T("df.signature_mismatch", df.Path, df.SHA, sum))
 	}
 	return nil
 }
@@ -75,9 +134,38 @@ func (df *DependencyFile) Update() error {
 	return nil
 }
 
-// Apply patch to the file referenced by Path
-// If Content is empty, the file content is read from the file directly
+// Apply patch (unified diff) to the file referenced by Path.
+// The patch is applied natively; if it fails and config.PatchFallback is set,
+// the patch command is used instead.
 func (df *DependencyFile) Patch(patch string) error {
+	err := df.applyPatch(patch)
+	if err != nil && config.PatchFallback {
+		err = df.patchWithCommand(patch)
+	}
+	if err != nil {
+		return err
+	}
+	return df.Update()
+}
+
+func (df *DependencyFile) applyPatch(patch string) error {
+	info, err := os.Stat(df.Path)
+	if err != nil {
+		return err
+	}
+	content, err := readFile(df.Path)
+	if err != nil {
+		return err
+	}
+	patched, err := applyUnifiedDiff(content, patch)
+	if err != nil {
+		return fmt.Errorf("%s: %s", df.Path, err)
+	}
+	return ioutil.WriteFile(df.Path, patched, info.Mode())
+}
+
+// Apply patch with the patch command, if available
+func (df *DependencyFile) patchWithCommand(patch string) error {
 	patchPath, err := exec.LookPath("patch")
 	if err != nil {
 		return err
@@ -107,72 +195,279 @@ func (df *DependencyFile) Patch(patch string) error {
 		return err
 	}
 	if err = cmd.Wait(); err != nil {
-		fmt.Println(string(out))
-		return err
-	}
-
-	err = df.Update()
-	if err != nil {
+		fmt.Fprintln(Output, string(out))
 		return err
 	}
 	return nil
 }
 
-// Return git SHA1 of the given file
-// TODO: Make this generic (ie: working with SVN)
+// Return the SHA of the given file, computed like the VCS of the project does
+// (see CurrentVCS), streamed without keeping its content in memory
 func GetFileSHA1(filePath string) (string, error) {
-	dat, err := ioutil.ReadFile(filePath)
+	_, sha, _, err := readFileSHA1(filePath, -1)
+	return sha, err
+}
+
+// Return the SHA of the given content, computed like the VCS of the project
+// does (see CurrentVCS)
+func ContentSHA1(dat []byte) string {
+	return ContentSHA(CurrentVCS(), dat)
+}
+
+// Return the path of the cached dependency files of the project, for its
+// current revision. An empty string is returned if the project has no
+// revision, or if caching is disabled.
+func dependencyFilesCachePath(project *Project) string {
+	dir := cache.Dir(cache.DFILES)
+	if dir == "" || project.CommitSHA == "" {
+		return ""
+	}
+	slug := strings.Replace(project.Slug, string(filepath.Separator), "_", -1)
+	return filepath.Join(dir, slug, project.CommitSHA+".json")
+}
+
+func fetchDependencyFilesWithSpinner(project *Project) ([]DependencyFile, error) {
+	spinner := utils.NewSpinner("Fetching dependency files")
+	spinner.Start()
+	defer spinner.Stop()
+	return project.fetchDependencyFiles(spinner.Progress)
+}
+
+// Return the dependency files of the project, from the cache if they've
+// already been fetched for the current revision of the project.
+func cachedDependencyFiles(project *Project) ([]DependencyFile, error) {
+	if err := project.Fetch(); err != nil {
+		return nil, err
+	}
+	path := dependencyFilesCachePath(project)
+	if data, err := ioutil.ReadFile(path); err == nil {
+		var dfiles []DependencyFile
+		if err := json.Unmarshal(data, &dfiles); err == nil {
+			return dfiles, nil
+		}
+	}
+
+	dfiles, err := fetchDependencyFilesWithSpinner(project)
+	if err != nil || path == "" {
+		return dfiles, err
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return dfiles, err
+	}
+	data, err := json.Marshal(dfiles)
 	if err != nil {
-		return "", err
+		return dfiles, err
+	}
+	return dfiles, ioutil.WriteFile(path, data, 0644)
+}
+
+// Ecosystems of dependency files, by file name
+var ecosystems = map[string]string{
+	"Gemfile":             "ruby",
+	"Gemfile.lock":        "ruby",
+	"package.json":        "npm",
+	"package-lock.json":   "npm",
+	"npm-shrinkwrap.json": "npm",
+	"yarn.lock":           "npm",
+	"setup.py":            "python",
+	"requirements.txt":    "python",
+	"requires.txt":        "python",
+	"composer.json":       "php",
+	"composer.lock":       "php",
+	"bower.json":          "bower",
+	"go.mod":              "go",
+	"go.sum":              "go",
+	"Cargo.toml":          "cargo",
+	"Cargo.lock":          "cargo",
+	"pom.xml":             "maven",
+	"build.gradle":        "gradle",
+	"build.gradle.kts":    "gradle",
+	"gradle.lockfile":     "gradle",
+	"packages.config":     "nuget",
+	"packages.lock.json":  "nuget",
+}
+
+// Return the ecosystem of the file (ie: ruby, npm), or "" if unknown
+func (df *DependencyFile) Ecosystem() string {
+	name := filepath.Base(df.Path)
+	if strings.HasSuffix(name, ".gemspec") {
+		return "ruby"
 	}
-	h := sha1.New()
-	header := fmt.Sprintf("blob %d\x00", len(dat))
-	io.WriteString(h, header)
-	io.Copy(h, bytes.NewReader(dat))
-	hash := h.Sum(nil)
+	if strings.HasSuffix(name, ".csproj") {
+		return "nuget"
+	}
+	return ecosystems[name]
+}
 
-	return fmt.Sprintf("%x", hash), nil
+// Options of the dependency files listing
+type DependencyFilesFilter struct {
+	// Glob pattern (or substring) matched against the path and the file name
+	Pattern   string
+	Ecosystem string
+	// path, sha or updated (most recent first)
+	Sort string
 }
 
-func ListDependencyFiles(project *Project) error {
+func (f DependencyFilesFilter) match(df DependencyFile) bool {
+	if f.Ecosystem != "" && !strings.EqualFold(df.Ecosystem(), f.Ecosystem) {
+		return false
+	}
+	if f.Pattern == "" || strings.Contains(df.Path, f.Pattern) {
+		return true
+	}
+	for _, s := range []string{df.Path, filepath.Base(df.Path)} {
+		if matched, _ := filepath.Match(f.Pattern, s); matched {
+			return true
+		}
+	}
+	return false
+}
+
+// Return the files matching the filter, sorted
+func (f DependencyFilesFilter) Apply(dfiles []DependencyFile) ([]DependencyFile, error) {
+	var less func(a, b DependencyFile) bool
+	switch f.Sort {
+	case "":
+	case "path":
+		less = func(a, b DependencyFile) bool { return a.Path < b.Path }
+	case "sha":
+		less = func(a, b DependencyFile) bool { return a.SHA < b.SHA }
+	case "updated":
+		less = func(a, b DependencyFile) bool {
+			if a.UpdatedAt == nil || b.UpdatedAt == nil {
+				return a.UpdatedAt != nil
+			}
+			return a.UpdatedAt.After(*b.UpdatedAt)
+		}
+	default:
+		return nil, fmt.Errorf("Unknown sort: %s (path, sha or updated)", f.Sort)
+	}
+
+	filtered := []DependencyFile{}
+	for _, df := range dfiles {
+		if f.match(df) {
+			filtered = append(filtered, df)
+		}
+	}
+	if less != nil {
+		sort.SliceStable(filtered, func(i, j int) bool { return less(filtered[i], filtered[j]) })
+	}
+	return filtered, nil
+}
 
-	dfiles, err := project.DependencyFiles()
+// List the dependency files of the project matching filter. They're cached by
+// revision unless useCache is false (or the raw output is requested).
+func ListDependencyFiles(project *Project, useCache bool, filter DependencyFilesFilter) error {
+	var dfiles []DependencyFile
+	var err error
+	if useCache && !config.RawFormat && cache.Dir(cache.DFILES) != "" {
+		dfiles, err = cachedDependencyFiles(project)
+	} else {
+		dfiles, err = fetchDependencyFilesWithSpinner(project)
+	}
 	if err != nil {
 		return err
 	}
+	dfiles, err = filter.Apply(dfiles)
+	if err != nil {
+		return err
+	}
+	if config.Query != "" {
+		return utils.PrintQuery(Output, dfiles, config.Query)
+	}
+	if config.JSONOutput {
+		return utils.PrintJSON(Output, dfiles)
+	}
 
-	table := tablewriter.NewWriter(os.Stdout)
-	table.SetHeader([]string{"Path", "SHA"})
-
+	table := utils.NewTable(Output, "Path", "SHA", "Size", "Updated at")
+	table.SetDefaultColumns("path", "sha")
 	for _, df := range dfiles {
-		table.Append([]string{df.Path, df.SHA})
+		size := df.Size
+		if size == 0 {
+			size = int64(len(df.Content))
+		}
+		var updatedAt string
+		if df.UpdatedAt != nil {
+			updatedAt = df.UpdatedAt.Format("2006-01-02 15:04")
+		}
+		table.Append(df.Path, df.SHA, utils.HumanSize(size), updatedAt)
 	}
-	table.Render() // Send output
+	return table.Render()
+}
 
-	return nil
+// Patterns of files created by editors while saving (vim, emacs, ...)
+var editorArtifacts = []string{
+	"*~",     // backup files
+	".#*",    // emacs lock files
+	"#*#",    // emacs auto-save files
+	".*.sw?", // vim swap files
+	"*.swp",
+	"4913", // vim checks the directory is writable with this file
+}
+
+func isEditorArtifact(name string) bool {
+	for _, pattern := range editorArtifacts {
+		if matched, _ := filepath.Match(pattern, name); matched {
+			return true
+		}
+	}
+	return false
 }
 
 var getLocalDependencyFiles = func() ([]*DependencyFile, error) {
+	dfiles, _, err := ScanDependencyFiles()
+	return dfiles, err
+}
+
+// Walk the current path, looking for supported dependency files.
+// Every path considered is recorded in the returned manifest, along with the
+// rule which excluded it (if any).
+func ScanDependencyFiles() ([]*DependencyFile, *ScanManifest, error) {
 	dfiles := []*DependencyFile{}
+	manifest := &ScanManifest{Entries: []ScanEntry{}}
+	// Indexes of the manifest entries of the matched files
+	matches := []int{}
+	rules := ignoreRules()
+	negation := hasNegation(rules)
 	searchDeps := func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			// Build systems may remove temp files while we're walking the tree
+			if os.IsNotExist(err) {
+				fmt.Fprint(Output, i18n.T("df.file_disappeared", path))
+				manifest.add(ScanEntry{Path: path, ExcludedBy: SCAN_RULE_VANISHED})
+				return nil
+			}
+			return err
+		}
+		entry := ScanEntry{Path: path, Dir: info.IsDir()}
+		recordScanWalk(path)
 
 		// Skip excluded paths
 		if info.IsDir() && info.Name() == ".git" {
+			entry.ExcludedBy = SCAN_RULE_GIT
+			manifest.add(entry)
 			return filepath.SkipDir
 		}
-		// Skip ignored_pathes
-		if len(config.IgnoredPaths) > 0 {
-			for _, path := range config.IgnoredPaths {
-				matched, err := filepath.Match(filepath.Clean(path), info.Name())
-				if err != nil {
-					return err
-				}
-
-				if matched {
-					fmt.Println("Skipping", info.Name())
-					return filepath.SkipDir
-				}
+		// Skip ignored_paths. Directories are walked anyway when some
+		// paths are included back (negation patterns).
+		if rule, ignored := ignoredBy(rules, path, info.IsDir()); ignored && path != "." {
+			entry.ExcludedBy = fmt.Sprintf("%s: %s", SCAN_RULE_IGNORED_PATHS, rule.Pattern)
+			manifest.add(entry)
+			if negation {
+				return nil
+			}
+			fmt.Fprintln(Output, i18n.T("df.skipping", info.Name()))
+			if info.IsDir() {
+				return filepath.SkipDir
 			}
+			return nil
+		}
+
+		// Editors create backup, swap and lock files next to the files being edited
+		if !info.IsDir() && isEditorArtifact(info.Name()) {
+			entry.ExcludedBy = SCAN_RULE_EDITOR_ARTIFACT
+			manifest.add(entry)
+			return nil
 		}
 
 		matched, err := regexp.MatchString(SUPPORTED_DEPENDENCY_FILES, info.Name())
@@ -181,79 +476,508 @@ var getLocalDependencyFiles = func() ([]*DependencyFile, error) {
 		}
 
 		if matched {
-			fmt.Printf("Found: %s\n", path)
-			dfiles = append(dfiles, NewDependencyFile(path))
+			// Files are read and hashed concurrently once the tree is walked
+			entry.Matched = true
+			manifest.add(entry)
+			matches = append(matches, len(manifest.Entries)-1)
+			recordScanMatch(path)
+			return nil
+		} else if !info.IsDir() {
+			entry.ExcludedBy = SCAN_RULE_UNSUPPORTED
 		}
+		manifest.add(entry)
 		return nil
 	}
-	err := filepath.Walk(".", searchDeps)
-	if err != nil {
-		return dfiles, err
+	if err := filepath.Walk(".", searchDeps); err != nil {
+		return dfiles, manifest, err
 	}
-	return dfiles, nil
+
+	paths := make([]string, len(matches))
+	for i, index := range matches {
+		paths[i] = manifest.Entries[index].Path
+	}
+	// Files are reported in the order of the walk, whatever the order they're
+	// read in
+	read, errs := readDependencyFiles(paths)
+	for i, df := range read {
+		entry := &manifest.Entries[matches[i]]
+		if errs[i] != nil {
+			if os.IsNotExist(errs[i]) {
+				fmt.Fprint(Output, i18n.T("df.file_disappeared", entry.Path))
+				entry.Matched = false
+				entry.ExcludedBy = SCAN_RULE_VANISHED
+				continue
+			}
+			return dfiles, manifest, errs[i]
+		}
+		fmt.Fprint(Output, i18n.T("df.found", entry.Path))
+		dfiles = append(dfiles, df)
+		entry.SHA = df.SHA
+		entry.Sources = df.Sources()
+	}
+	return dfiles, manifest, nil
+}
+
+// Read and hash the files, config.ScanConcurrency at a time. The files are
+// returned in the same order as paths, nil if they can't be read, along with
+// the errors of the files.
+func readDependencyFiles(paths []string) ([]*DependencyFile, []error) {
+	workers := config.ScanConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	dfiles := make([]*DependencyFile, len(paths))
+	errs := make([]error, len(paths))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				dfiles[i], errs[i] = newDependencyFile(paths[i], config.MaxFileSize)
+			}
+		}()
+	}
+	for i := range paths {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return dfiles, errs
 }
 
 // Push project dependencies
 // The current path will be scanned for supported dependency files (SUPPORTED_DEPENDENCY_FILES)
-func PushDependencyFiles(projectSlug string, files []string) error {
+// Unless assumeYes is true, the user is prompted for confirmation if the payload
+// is bigger than config.MaxPayloadSize. Requests are aborted when ctx is done.
+func PushDependencyFiles(ctx context.Context, projectSlug string, files []string, assumeYes bool) (*PushResult, error) {
 	dfiles, err := LookupDependencyFiles(files)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	if err = ValidateDependencyFiles(dfiles); err != nil {
+		return nil, err
 	}
 
-	fmt.Printf("Sending files to Gemnasium: ")
-	var jsonResp map[string][]DependencyFile
+	if size := payloadSize(dfiles); !assumeYes && size > config.MaxPayloadSize {
+		printPayloadSummary(dfiles, size)
+		if config.NonInteractive {
+			return nil, nonInteractiveError("a confirmation", "pass --yes to push anyway, or raise GEMNASIUM_MAX_PAYLOAD_SIZE")
+		}
+		if !confirmPush() {
+			return nil, errors.New(i18n.T("df.push_aborted"))
+		}
+	}
+
+	groups, err := groupByTarget(dfiles, pushTargets(projectSlug))
+	if err != nil {
+		return nil, err
+	}
+	result := &PushResult{}
+	for _, g := range groups {
+		r, err := pushToTarget(ctx, g.target, g.files, len(groups) > 1 || g.target.Dir != ".")
+		if err != nil {
+			return nil, err
+		}
+		result.merge(r, g.target.Dir)
+	}
+	return result, nil
+}
+
+// Push files to the project of target. Their paths are made relative to the
+// directory of the target first.
+func pushToTarget(ctx context.Context, target pushTarget, dfiles []*DependencyFile, showSlug bool) (*PushResult, error) {
+	relFiles := make([]*DependencyFile, len(dfiles))
+	for i, df := range dfiles {
+		rel := *df
+		rel.Path = target.relPath(df.Path)
+		relFiles[i] = &rel
+	}
+	renames := detectRenames(target, relFiles)
+	if config.IncrementalPush {
+		var err error
+		if relFiles, err = withoutKnownContents(ctx, target, relFiles); err != nil {
+			return nil, err
+		}
+	} else if err := loadContents(relFiles); err != nil {
+		return nil, err
+	}
 
+	if showSlug {
+		fmt.Fprint(Output, i18n.T("df.sending_to", target.Slug))
+	} else {
+		fmt.Fprint(Output, i18n.T("df.sending"))
+	}
+	var result PushResult
 	opts := &gemnasium.APIRequestOptions{
-		Method: "POST",
-		URI:    fmt.Sprintf("/projects/%s/dependency_files", projectSlug),
-		Body:   dfiles,
-		Result: &jsonResp,
+		Method:  "POST",
+		URI:     fmt.Sprintf("/projects/%s/dependency_files", target.Slug),
+		Body:    relFiles,
+		Result:  &result,
+		Context: ctx,
 	}
-	err = gemnasium.APIRequest(opts)
+	err := gemnasium.APIRequest(opts)
 	if err != nil {
-		return err
+		return nil, err
+	}
+	fmt.Fprint(Output, i18n.T("df.sent"))
+	result.Renamed = renames
+	return &result, nil
+}
+
+// Read the content of the files which hasn't been kept when they were hashed
+// (see config.MaxFileSize), before sending them
+func loadContents(dfiles []*DependencyFile) error {
+	for _, df := range dfiles {
+		if err := df.LoadContent(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Path and SHA of a dependency file, sent to find out which files Gemnasium
+// doesn't have yet
+type fileSignature struct {
+	Path        string `json:"path"`
+	SHA         string `json:"sha"`
+	RenamedFrom string `json:"renamed_from,omitempty"`
+}
+
+// Send the paths and SHAs of the files first, and return them without the
+// content of those Gemnasium already has (sent as null). All contents are sent
+// if the API doesn't support incremental pushes.
+func withoutKnownContents(ctx context.Context, target pushTarget, dfiles []*DependencyFile) ([]*DependencyFile, error) {
+	signatures := make([]fileSignature, len(dfiles))
+	for i, df := range dfiles {
+		signatures[i] = fileSignature{Path: df.Path, SHA: df.SHA, RenamedFrom: df.RenamedFrom}
+	}
+	var needed struct {
+		Paths []string `json:"paths"`
+	}
+	opts := &gemnasium.APIRequestOptions{
+		Method:  "POST",
+		URI:     fmt.Sprintf("/projects/%s/dependency_files/needed", target.Slug),
+		Body:    signatures,
+		Result:  &needed,
+		Context: ctx,
+	}
+	if err := gemnasium.APIRequest(opts); err != nil {
+		var apiErr *gemnasium.APIError
+		if errors.As(err, &apiErr) && apiErr.StatusCode == http.StatusNotFound {
+			return dfiles, loadContents(dfiles)
+		}
+		return nil, err
+	}
+
+	isNeeded := map[string]bool{}
+	for _, path := range needed.Paths {
+		isNeeded[path] = true
+	}
+	files := make([]*DependencyFile, len(dfiles))
+	for i, df := range dfiles {
+		files[i] = df
+		if !isNeeded[df.Path] {
+			known := *df
+			known.Content = nil
+			files[i] = &known
+		} else if err := df.LoadContent(); err != nil {
+			return nil, err
+		}
+	}
+	fmt.Fprint(Output, i18n.T("df.incremental", len(needed.Paths), len(dfiles)))
+	return files, nil
+}
+
+// A dependency file moved to another path
+type Rename struct {
+	From string `json:"from"`
+	To   string `json:"to"`
+}
+
+// Detect the files which have been moved since the last push: files known by
+// Gemnasium but missing locally, with the same SHA as a new local file. The
+// previous path is sent along with the file (RenamedFrom), so its history is
+// kept instead of being removed and added again.
+// Detection is best effort: the push goes on if the files known by Gemnasium
+// can't be fetched.
+func detectRenames(target pushTarget, dfiles []*DependencyFile) []Rename {
+	renames := []Rename{}
+	if config.RawFormat {
+		return renames
+	}
+	remote, err := (&Project{Slug: target.Slug}).DependencyFiles()
+	if err != nil {
+		return renames
+	}
+
+	known := map[string]bool{}
+	for _, df := range remote {
+		known[df.Path] = true
+	}
+	// Files gone locally, by SHA
+	gone := map[string][]string{}
+	sparseExcluded := sparseExcludedFiles()
+	for _, df := range remote {
+		if missingLocally(target.localPath(df.Path), sparseExcluded) && df.SHA != "" {
+			gone[df.SHA] = append(gone[df.SHA], df.Path)
+		}
+	}
+	for _, df := range dfiles {
+		candidates := gone[df.SHA]
+		if known[df.Path] || len(candidates) == 0 {
+			continue
+		}
+		// Prefer a file with the same name (ie: moved to another directory)
+		from := candidates[0]
+		for _, c := range candidates {
+			if filepath.Base(c) == filepath.Base(df.Path) {
+				from = c
+				break
+			}
+		}
+		for i, c := range candidates {
+			if c == from {
+				gone[df.SHA] = append(candidates[:i:i], candidates[i+1:]...)
+				break
+			}
+		}
+		df.RenamedFrom = from
+		renames = append(renames, Rename{From: from, To: df.Path})
+	}
+	return renames
+}
+
+// A dependency file, as returned by the API after a push.
+// The API may explain why a file couldn't be parsed (ie: "unsupported bundler
+// 2.5 format"), or warn about parts of it that have been ignored.
+type PushedDependencyFile struct {
+	DependencyFile
+	Error    string   `json:"error,omitempty"`
+	Warnings []string `json:"warnings,omitempty"`
+}
+
+// The pushed dependency files, by state
+type PushResult struct {
+	Added       []PushedDependencyFile `json:"added"`
+	Updated     []PushedDependencyFile `json:"updated"`
+	Unchanged   []PushedDependencyFile `json:"unchanged"`
+	Unsupported []PushedDependencyFile `json:"unsupported"`
+	// Files marked as removed, when pruning (see PruneDependencyFiles)
+	Removed []PushedDependencyFile `json:"removed,omitempty"`
+	// Files moved since the last push (see detectRenames)
+	Renamed []Rename `json:"renamed,omitempty"`
+}
+
+// States accepted by PushResult.Check, along with "errors" and "warnings"
+// (files with parse errors or warnings)
+var PUSH_STATES = []string{"added", "updated", "unchanged", "unsupported"}
+
+func (r *PushResult) files(state string) []PushedDependencyFile {
+	switch state {
+	case "added":
+		return r.Added
+	case "updated":
+		return r.Updated
+	case "unchanged":
+		return r.Unchanged
+	case "unsupported":
+		return r.Unsupported
+	case "removed":
+		return r.Removed
 	}
+	all := []PushedDependencyFile{}
+	for _, s := range PUSH_STATES {
+		for _, df := range r.files(s) {
+			if state == "errors" && df.Error != "" || state == "warnings" && len(df.Warnings) > 0 {
+				all = append(all, df)
+			}
+		}
+	}
+	return all
+}
 
-	added := []string{}
-	for _, df := range jsonResp["added"] {
-		added = append(added, df.Path)
+func pushedPaths(dfiles []PushedDependencyFile) []string {
+	paths := []string{}
+	for _, df := range dfiles {
+		paths = append(paths, df.Path)
 	}
-	updated := []string{}
-	for _, df := range jsonResp["updated"] {
-		updated = append(updated, df.Path)
+	return paths
+}
+
+// Return true if files have been added or updated
+func (r *PushResult) Changed() bool {
+	return len(r.Added) > 0 || len(r.Updated) > 0
+}
+
+// Return an error if files are in one of the failOn states (ie: unsupported),
+// or if files have changed and failOnChange is true.
+func (r *PushResult) Check(failOn []string, failOnChange bool) error {
+	if failOnChange {
+		failOn = append(failOn, "added", "updated")
 	}
-	unchanged := []string{}
-	for _, df := range jsonResp["unchanged"] {
-		unchanged = append(unchanged, df.Path)
+	problems := []string{}
+	for _, state := range failOn {
+		state = strings.TrimSpace(state)
+		switch state {
+		case "added", "updated", "unchanged", "unsupported", "removed", "errors", "warnings":
+		default:
+			return fmt.Errorf("Unknown state '%s' (%s, removed, errors or warnings)", state, strings.Join(PUSH_STATES, ", "))
+		}
+		if dfiles := r.files(state); len(dfiles) > 0 {
+			problems = append(problems, i18n.T("df.fail_on_state", state, strings.Join(pushedPaths(dfiles), ", ")))
+		}
 	}
-	unsupported := []string{}
-	for _, df := range jsonResp["unsupported"] {
-		unsupported = append(unsupported, df.Path)
+	if len(problems) > 0 {
+		return errors.New(i18n.T("df.push_failed", strings.Join(problems, "\n")))
 	}
-	fmt.Printf("done.\n\n")
-	fmt.Printf("Added: %s\n", strings.Join(added, ", "))
-	fmt.Printf("Updated: %s\n", strings.Join(updated, ", "))
-	fmt.Printf("Unchanged: %s\n", strings.Join(unchanged, ", "))
-	fmt.Printf("Unsupported: %s\n", strings.Join(unsupported, ", "))
 	return nil
 }
 
+// Display the files by state, and the parse errors and warnings returned by
+// the API for each file
+func (r *PushResult) Print() {
+	fmt.Fprint(Output, i18n.T("df.added", strings.Join(pushedPaths(r.Added), ", ")))
+	fmt.Fprint(Output, i18n.T("df.updated", strings.Join(pushedPaths(r.Updated), ", ")))
+	fmt.Fprint(Output, i18n.T("df.unchanged", strings.Join(pushedPaths(r.Unchanged), ", ")))
+	fmt.Fprint(Output, i18n.T("df.unsupported", strings.Join(pushedPaths(r.Unsupported), ", ")))
+	if len(r.Removed) > 0 {
+		fmt.Fprint(Output, i18n.T("df.removed", strings.Join(pushedPaths(r.Removed), ", ")))
+	}
+	if len(r.Renamed) > 0 {
+		renames := []string{}
+		for _, rn := range r.Renamed {
+			renames = append(renames, rn.From+" => "+rn.To)
+		}
+		fmt.Fprint(Output, i18n.T("df.renamed", strings.Join(renames, ", ")))
+	}
+
+	parseErrors := []string{}
+	warnings := []string{}
+	for _, state := range PUSH_STATES {
+		for _, df := range r.files(state) {
+			if df.Error != "" {
+				parseErrors = append(parseErrors, fmt.Sprintf("  %s: %s", df.Path, df.Error))
+			}
+			for _, w := range df.Warnings {
+				warnings = append(warnings, fmt.Sprintf("  %s: %s", df.Path, w))
+			}
+		}
+	}
+	if len(parseErrors) > 0 {
+		fmt.Fprint(Output, i18n.T("df.parse_errors", strings.Join(parseErrors, "\n")))
+	}
+	if len(warnings) > 0 {
+		fmt.Fprint(Output, i18n.T("df.parse_warnings", strings.Join(warnings, "\n")))
+	}
+}
+
+// Mark the dependency files of the project which are no longer present locally
+// (ie: a service removed from a monorepo) as removed on Gemnasium, as well as
+// the files of the mapped projects (config.ProjectMappings). Unless assumeYes
+// is true, the user is prompted for confirmation.
+func PruneDependencyFiles(projectSlug string, assumeYes bool) ([]PushedDependencyFile, error) {
+	targets := pushTargets(projectSlug)
+	removedByTarget := make([][]string, len(targets))
+	removed := []PushedDependencyFile{}
+	// Files left out of a sparse checkout are still in the repo
+	sparseExcluded := sparseExcludedFiles()
+	for i, target := range targets {
+		remote, err := (&Project{Slug: target.Slug}).DependencyFiles()
+		if err != nil {
+			return nil, err
+		}
+		for _, df := range remote {
+			path := target.localPath(df.Path)
+			if missingLocally(path, sparseExcluded) {
+				removedByTarget[i] = append(removedByTarget[i], df.Path)
+				removed = append(removed, PushedDependencyFile{DependencyFile: DependencyFile{Path: path, SHA: df.SHA}})
+			}
+		}
+	}
+	if len(removed) == 0 {
+		return removed, nil
+	}
+
+	fmt.Fprint(Output, i18n.T("df.prune_summary", len(removed), strings.Join(pushedPaths(removed), "\n")))
+	if !assumeYes && config.NonInteractive {
+		return nil, nonInteractiveError("a confirmation", "pass --yes to remove these files from Gemnasium")
+	}
+	if !assumeYes && !confirmPush() {
+		return nil, errors.New(i18n.T("df.push_aborted"))
+	}
+	for i, target := range targets {
+		if len(removedByTarget[i]) == 0 {
+			continue
+		}
+		opts := &gemnasium.APIRequestOptions{
+			Method: "DELETE",
+			URI:    fmt.Sprintf("/projects/%s/dependency_files", target.Slug),
+			Body:   map[string][]string{"paths": removedByTarget[i]},
+		}
+		if err := gemnasium.APIRequest(opts); err != nil {
+			return nil, err
+		}
+	}
+	return removed, nil
+}
+
+func payloadSize(dfiles []*DependencyFile) (size int64) {
+	for _, df := range dfiles {
+		size += df.contentSize()
+	}
+	return size
+}
+
+// Display the number of files, total size and largest files about to be sent
+func printPayloadSummary(dfiles []*DependencyFile, size int64) {
+	largest := make([]*DependencyFile, len(dfiles))
+	copy(largest, dfiles)
+	sort.SliceStable(largest, func(i, j int) bool { return largest[i].contentSize() > largest[j].contentSize() })
+	if len(largest) > 5 {
+		largest = largest[:5]
+	}
+
+	fmt.Fprint(Output, i18n.T("df.payload_summary", len(dfiles), utils.HumanSize(size)))
+	for _, df := range largest {
+		fmt.Fprintf(Output, "  %s (%s)\n", df.Path, utils.HumanSize(df.contentSize()))
+	}
+	fmt.Fprintln(Output, i18n.T("df.payload_hint"))
+}
+
+// Lambda to be overriden in tests
+var confirmPush = askConfirmation
+
+// Return the error of a prompt in non-interactive mode (see
+// config.NonInteractive): what was to be asked, and how to supply it
+func nonInteractiveError(what, hint string) error {
+	return errors.New(i18n.T("common.non_interactive", what, hint))
+}
+
+// Ask the user to continue, on stdin
+func askConfirmation() bool {
+	fmt.Fprint(Output, i18n.T("common.confirm"))
+	var answer string
+	fmt.Scanln(&answer)
+	return strings.ToLower(strings.TrimSpace(answer)) == "y"
+}
+
 // Load dependency files if files is not empty, otherwise search in the current
 // path for files
 func LookupDependencyFiles(files []string) ([]*DependencyFile, error) {
 	var dfiles = []*DependencyFile{}
 
 	if len(files) > 0 {
-		for _, path := range files {
-			df := NewDependencyFile(path)
-			if df == nil {
-				return nil, fmt.Errorf("Unable to read file: %s", path)
+		for _, path := range withoutSparseExcluded(files) {
+			df, err := newDependencyFile(path, config.MaxFileSize)
+			if err != nil {
+				return nil, err
 			}
 			dfiles = append(dfiles, df)
 		}
 	} else {
-		fmt.Println("[warning] No files given, scanning current directory instead.")
+		fmt.Fprintln(Output, i18n.T("df.no_files_given"))
 		files, err := getLocalDependencyFiles()
 		if err != nil {
 			return nil, err
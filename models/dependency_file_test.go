@@ -2,14 +2,19 @@ package models
 
 import (
 	"bytes"
+	"context"
 	"encoding/base64"
+	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
 	"os"
+	"reflect"
+	"strings"
+	"sync"
 	"testing"
+	"time"
 
 	"github.com/gemnasium/toolbelt/config"
 )
@@ -40,6 +45,10 @@ func TestNewDependencyFile(t *testing.T) {
 	if df == nil {
 		t.Errorf("NewDependencyFile returned nil")
 	}
+
+	if df, err := NewDependencyFileE(tmp.Name() + ".missing"); df != nil || !os.IsNotExist(err) {
+		t.Errorf("Expected a not exist error, got %v", err)
+	}
 }
 
 func TestCheckFileSHA1(t *testing.T) {
@@ -85,7 +94,9 @@ func TestPatch(t *testing.T) {
 	patch := "--- %s\n+++ titi\n@@ -1,3 +1,3 @@\n source 'https://rubygems.org'\n\n-gem 'rails', '3.2.18'\n+gem 'rails', '3.2.21'\n"
 	expected := "source 'https://rubygems.org'\n\ngem 'rails', '3.2.21\n'"
 
-	df.Patch(patch)
+	if err := df.Patch(patch); err != nil {
+		t.Fatal(err)
+	}
 
 	if bytes.Equal(df.Content, []byte(expected)) {
 		t.Errorf("DependencyFile content is incorrect (Exp: '%s', Got: '%s')\n", expected, df.Content)
@@ -151,6 +162,42 @@ func TestGetFileSHA1(t *testing.T) {
 	}
 }
 
+func TestReadFileSHA1(t *testing.T) {
+	content := []byte(strings.Repeat("GEM\n  specs:\n    rake (10.4.2)\n", 100))
+	files := map[string]string{"Gemfile.lock": string(content)}
+	inTempDir(t, files, func() {
+		read, sha, size, err := readFileSHA1("Gemfile.lock", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(read, content) || sha != ContentSHA1(content) || size != int64(len(content)) {
+			t.Errorf("Unexpected content, SHA (%s) or size (%d)", sha, size)
+		}
+
+		df, err := newDependencyFile("Gemfile.lock", 1024)
+		if err != nil || df.Content != nil || df.SHA != sha || df.Size != int64(len(content)) {
+			t.Fatalf("Expected the content of a file over the limit not to be kept, got %+v", df)
+		}
+		if packages := df.LockedPackages(); len(packages) != 100 {
+			t.Errorf("Expected the packages to be read from disk, got %d", len(packages))
+		}
+		if size := payloadSize([]*DependencyFile{df}); size != int64(len(content)) {
+			t.Errorf("Expected the size of the file in the payload, got %d", size)
+		}
+		if err := df.LoadContent(); err != nil || !bytes.Equal(df.Content, content) {
+			t.Errorf("Expected the content to be loaded, got %v", err)
+		}
+
+		changed, _ := newDependencyFile("Gemfile.lock", 1024)
+		if err := ioutil.WriteFile("Gemfile.lock", []byte("GEM\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		if err := changed.LoadContent(); err == nil {
+			t.Error("Expected an error when the file changed since it was hashed")
+		}
+	})
+}
+
 func TestListDependencyFiles(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		r.Header.Set("Content-Type", "application/json")
@@ -164,20 +211,15 @@ func TestListDependencyFiles(t *testing.T) {
 		fmt.Fprintln(w, jsonOutput)
 	}))
 	defer ts.Close()
-	old := os.Stdout // keep backup of the real stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
+	var buf bytes.Buffer
+	Output = &buf
+	defer func() { Output = os.Stdout }()
 	config.APIEndpoint = ts.URL
-	err := ListDependencyFiles(&Project{Slug: "blah"})
+	err := ListDependencyFiles(&Project{Slug: "blah"}, false, DependencyFilesFilter{})
 	if err != nil {
 		t.Error(err)
 	}
 
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	os.Stdout = old // restoring the real stdout
-
 	expectedOutput := "+--------------+--------------------+\n"
 	expectedOutput += "|     PATH     |        SHA         |\n"
 	expectedOutput += "+--------------+--------------------+\n"
@@ -189,6 +231,51 @@ func TestListDependencyFiles(t *testing.T) {
 	}
 }
 
+func TestDependencyFilesFilter(t *testing.T) {
+	older := time.Date(2015, 1, 1, 0, 0, 0, 0, time.UTC)
+	newer := time.Date(2015, 6, 1, 0, 0, 0, 0, time.UTC)
+	dfiles := []DependencyFile{
+		{Path: "Gemfile.lock", SHA: "c", UpdatedAt: &older},
+		{Path: "apps/web/package.json", SHA: "a", UpdatedAt: &newer},
+		{Path: "apps/api/package.json", SHA: "b"},
+		{Path: "app.gemspec", SHA: "d"},
+	}
+	paths := func(filter DependencyFilesFilter) string {
+		filtered, err := filter.Apply(dfiles)
+		if err != nil {
+			t.Fatal(err)
+		}
+		p := []string{}
+		for _, df := range filtered {
+			p = append(p, df.Path)
+		}
+		return strings.Join(p, ",")
+	}
+
+	tests := []struct {
+		filter   DependencyFilesFilter
+		expected string
+	}{
+		{DependencyFilesFilter{}, "Gemfile.lock,apps/web/package.json,apps/api/package.json,app.gemspec"},
+		{DependencyFilesFilter{Sort: "path"}, "Gemfile.lock,app.gemspec,apps/api/package.json,apps/web/package.json"},
+		{DependencyFilesFilter{Sort: "sha"}, "apps/web/package.json,apps/api/package.json,Gemfile.lock,app.gemspec"},
+		{DependencyFilesFilter{Sort: "updated"}, "apps/web/package.json,Gemfile.lock,apps/api/package.json,app.gemspec"},
+		{DependencyFilesFilter{Pattern: "package.json"}, "apps/web/package.json,apps/api/package.json"},
+		{DependencyFilesFilter{Pattern: "apps/*/package.json", Sort: "path"}, "apps/api/package.json,apps/web/package.json"},
+		{DependencyFilesFilter{Pattern: "*.lock"}, "Gemfile.lock"},
+		{DependencyFilesFilter{Ecosystem: "ruby"}, "Gemfile.lock,app.gemspec"},
+		{DependencyFilesFilter{Ecosystem: "npm", Pattern: "web"}, "apps/web/package.json"},
+	}
+	for _, test := range tests {
+		if got := paths(test.filter); got != test.expected {
+			t.Errorf("%+v: expected %s, got %s", test.filter, test.expected, got)
+		}
+	}
+	if _, err := (DependencyFilesFilter{Sort: "size"}).Apply(dfiles); err == nil {
+		t.Error("Expected an error for unknown sort")
+	}
+}
+
 func TestPushDependencyFiles(t *testing.T) {
 	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		r.Header.Set("Content-Type", "application/json")
@@ -206,28 +293,24 @@ func TestPushDependencyFiles(t *testing.T) {
 		fmt.Fprintln(w, jsonOutput)
 	}))
 	defer ts.Close()
-	old := os.Stdout // keep backup of the real stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
+	var buf bytes.Buffer
+	Output = &buf
+	defer func() { Output = os.Stdout }()
 	config.APIEndpoint = ts.URL
 
 	getLocalDependencyFiles = func() ([]*DependencyFile, error) {
 		return []*DependencyFile{
 			&DependencyFile{Path: "Gemfile", SHA: "Gemfile SHA-1", Content: []byte("Gemfile.lock base64 encoded content")},
-			&DependencyFile{Path: "Gemfile.lock", SHA: "Gemfile.lock SHA-1", Content: []byte("Gemfile base64 encoded content")},
-			&DependencyFile{Path: "js/package.json", SHA: "package.json SHA-1", Content: []byte("package.json content")},
+			&DependencyFile{Path: "Gemfile.lock", SHA: "Gemfile.lock SHA-1", Content: []byte("GEM\n  specs:\n\nDEPENDENCIES\n")},
+			&DependencyFile{Path: "js/package.json", SHA: "package.json SHA-1", Content: []byte(`{"name": "js"}`)},
 		}, nil
 	}
 
-	err := PushDependencyFiles("blah", []string{})
+	result, err := PushDependencyFiles(context.Background(), "blah", []string{}, false)
 	if err != nil {
-		t.Error(err)
+		t.Fatal(err)
 	}
-
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	os.Stdout = old // restoring the real stdout
+	result.Print()
 
 	expectedOutput := "[warning] No files given, scanning current directory instead.\n"
 	expectedOutput += "Sending files to Gemnasium: done.\n"
@@ -240,3 +323,274 @@ func TestPushDependencyFiles(t *testing.T) {
 		t.Errorf("Expected ouput:\n%s\n\nGot:\n%s", expectedOutput, buf.String())
 	}
 }
+
+func TestPushDependencyFilesIncremental(t *testing.T) {
+	var sent []DependencyFile
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.Method == "GET":
+			fmt.Fprintln(w, "[]")
+		case r.URL.Path == "/projects/blah/dependency_files/needed":
+			var signatures []map[string]interface{}
+			if err := json.NewDecoder(r.Body).Decode(&signatures); err != nil {
+				t.Fatal(err)
+			}
+			if len(signatures) != 2 || signatures[0]["content"] != nil {
+				t.Errorf("Expected only paths and SHAs, got: %v", signatures)
+			}
+			fmt.Fprintln(w, `{"paths": ["Gemfile.lock"]}`)
+		default:
+			if err := json.NewDecoder(r.Body).Decode(&sent); err != nil {
+				t.Fatal(err)
+			}
+			fmt.Fprintln(w, `{"added": [], "updated": [{"path": "Gemfile.lock"}], "unchanged": [{"path": "Gemfile"}], "unsupported": []}`)
+		}
+	}))
+	defer ts.Close()
+	Output = ioutil.Discard
+	defer func() { Output = os.Stdout }()
+	config.APIEndpoint = ts.URL
+	config.IncrementalPush = true
+	defer func() { config.IncrementalPush = false }()
+
+	localFiles := getLocalDependencyFiles
+	defer func() { getLocalDependencyFiles = localFiles }()
+	getLocalDependencyFiles = func() ([]*DependencyFile, error) {
+		return []*DependencyFile{
+			&DependencyFile{Path: "Gemfile", SHA: "Gemfile SHA-1", Content: []byte("source 'https://rubygems.org'\n")},
+			&DependencyFile{Path: "Gemfile.lock", SHA: "Gemfile.lock SHA-1", Content: []byte("GEM\n  specs:\n\nDEPENDENCIES\n")},
+		}, nil
+	}
+	if _, err := PushDependencyFiles(context.Background(), "blah", []string{}, false); err != nil {
+		t.Fatal(err)
+	}
+	if len(sent) != 2 || sent[0].Content != nil || sent[0].SHA != "Gemfile SHA-1" || len(sent[1].Content) == 0 {
+		t.Errorf("Expected only the content of Gemfile.lock to be sent, got: %+v", sent)
+	}
+}
+
+func TestPushDependencyFilesAboveMaxPayloadSize(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		t.Error("Files should not be sent without confirmation")
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	config.MaxPayloadSize = 10
+	defer func() { config.MaxPayloadSize = config.DEFAULT_MAX_PAYLOAD_SIZE }()
+
+	localFiles := getLocalDependencyFiles
+	defer func() { getLocalDependencyFiles = localFiles }()
+	getLocalDependencyFiles = func() ([]*DependencyFile, error) {
+		return []*DependencyFile{
+			&DependencyFile{Path: "node_modules/foo/package.json", SHA: "package.json SHA-1", Content: []byte(`{"name": "foo"}`)},
+		}, nil
+	}
+	var prompted bool
+	confirmPush = func() bool {
+		prompted = true
+		return false
+	}
+	defer func() { confirmPush = askConfirmation }()
+
+	_, err := PushDependencyFiles(context.Background(), "blah", []string{}, false)
+	if err == nil {
+		t.Error("Push should have been aborted")
+	}
+	if !prompted {
+		t.Error("User should have been prompted for confirmation")
+	}
+}
+
+func TestPushDependencyFilesWithParseErrors(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `{
+			"added": [{"path": "package.json", "sha": "package.json SHA-1", "warnings": ["engines field ignored"]}],
+			"updated": [],
+			"unchanged": [],
+			"unsupported": [{"path": "Gemfile.lock", "sha": "Gemfile.lock SHA-1", "error": "unsupported bundler 2.5 format"}]
+		}`)
+	}))
+	defer ts.Close()
+	var buf bytes.Buffer
+	Output = &buf
+	defer func() { Output = os.Stdout }()
+	config.APIEndpoint = ts.URL
+
+	localFiles := getLocalDependencyFiles
+	defer func() { getLocalDependencyFiles = localFiles }()
+	getLocalDependencyFiles = func() ([]*DependencyFile, error) {
+		return []*DependencyFile{
+			&DependencyFile{Path: "Gemfile.lock", SHA: "Gemfile.lock SHA-1", Content: []byte("GEM\n  specs:\n\nDEPENDENCIES\n")},
+			&DependencyFile{Path: "package.json", SHA: "package.json SHA-1", Content: []byte(`{"name": "app"}`)},
+		}, nil
+	}
+
+	result, err := PushDependencyFiles(context.Background(), "blah", []string{}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result.Print()
+
+	expectedOutput := "[warning] No files given, scanning current directory instead.\n"
+	expectedOutput += "Sending files to Gemnasium: done.\n"
+	expectedOutput += "\n"
+	expectedOutput += "Added: package.json\n"
+	expectedOutput += "Updated: \n"
+	expectedOutput += "Unchanged: \n"
+	expectedOutput += "Unsupported: Gemfile.lock\n"
+	expectedOutput += "\n"
+	expectedOutput += "Errors:\n"
+	expectedOutput += "  Gemfile.lock: unsupported bundler 2.5 format\n"
+	expectedOutput += "\n"
+	expectedOutput += "Warnings:\n"
+	expectedOutput += "  package.json: engines field ignored\n"
+	if buf.String() != expectedOutput {
+		t.Errorf("Expected ouput:\n%s\n\nGot:\n%s", expectedOutput, buf.String())
+	}
+
+	if err := result.Check(nil, false); err != nil {
+		t.Errorf("Check should pass without states, got: %s", err)
+	}
+	if err := result.Check([]string{"updated"}, false); err != nil {
+		t.Errorf("Check should pass without updated files, got: %s", err)
+	}
+	err = result.Check([]string{"unsupported", "warnings"}, false)
+	if err == nil || !strings.Contains(err.Error(), "unsupported: Gemfile.lock") || !strings.Contains(err.Error(), "warnings: package.json") {
+		t.Errorf("Unsupported files and warnings should be reported, got: %v", err)
+	}
+	err = result.Check(nil, true)
+	if err == nil || !strings.Contains(err.Error(), "added: package.json") {
+		t.Errorf("Added files should be reported, got: %v", err)
+	}
+	if err := result.Check([]string{"deleted"}, false); err == nil {
+		t.Error("Expected an error for unknown states")
+	}
+}
+
+func TestCachedDependencyFiles(t *testing.T) {
+	var mu sync.Mutex
+	pageRequests := 0
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/projects/blah" {
+			fmt.Fprintln(w, `{"slug": "blah", "commit_sha": "abc123"}`)
+			return
+		}
+		mu.Lock()
+		pageRequests++
+		mu.Unlock()
+		page := r.URL.Query().Get("page")
+		w.Header().Set("X-Total-Pages", "3")
+		fmt.Fprintf(w, `[{"path": "file%s", "sha": "sha%s"}]`, page, page)
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	dir, err := ioutil.TempDir("", "gemnasium-dfiles")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	oldCacheDir := config.CacheDir
+	config.CacheDir = dir
+	defer func() { config.CacheDir = oldCacheDir }()
+
+	for i := 0; i < 2; i++ {
+		dfiles, err := cachedDependencyFiles(&Project{Slug: "blah"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dfiles) != 3 || dfiles[0].Path != "file1" || dfiles[2].Path != "file3" {
+			t.Errorf("Expected the files of the 3 pages in order, got: %#v", dfiles)
+		}
+	}
+	if pageRequests != 3 {
+		t.Errorf("Pages should have been fetched once and then cached, got %d requests", pageRequests)
+	}
+}
+
+func TestPruneDependencyFiles(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-prune")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+	ioutil.WriteFile("Gemfile", []byte("source 'https://rubygems.org'\n"), 0644)
+
+	var deleted string
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprintln(w, `[{"path": "Gemfile", "sha": "Gemfile SHA-1"}, {"path": "services/old/package.json", "sha": "package.json SHA-1"}]`)
+		case "DELETE":
+			body, _ := ioutil.ReadAll(r.Body)
+			deleted = string(body)
+		}
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	confirmPush = func() bool {
+		t.Error("User should not be prompted with assumeYes")
+		return false
+	}
+	defer func() { confirmPush = askConfirmation }()
+
+	removed, err := PruneDependencyFiles("blah", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].Path != "services/old/package.json" {
+		t.Errorf("Only files missing locally should be removed, got: %v", removed)
+	}
+	if deleted != `{"paths":["services/old/package.json"]}` {
+		t.Errorf("Unexpected request body: %s", deleted)
+	}
+}
+
+func TestPushDependencyFilesWithRenames(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-renames")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	var pushed []DependencyFile
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case "GET":
+			fmt.Fprintln(w, `[
+				{"path": "Gemfile.lock", "sha": "Gemfile.lock SHA-1"},
+				{"path": "services/billing/package.json", "sha": "package.json SHA-1"}
+			]`)
+		case "POST":
+			json.NewDecoder(r.Body).Decode(&pushed)
+			fmt.Fprintln(w, `{"added": [{"path": "apps/billing/package.json", "sha": "package.json SHA-1"}], "updated": [], "unchanged": [], "unsupported": []}`)
+		}
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	localFiles := getLocalDependencyFiles
+	defer func() { getLocalDependencyFiles = localFiles }()
+	getLocalDependencyFiles = func() ([]*DependencyFile, error) {
+		return []*DependencyFile{
+			&DependencyFile{Path: "apps/billing/package.json", SHA: "package.json SHA-1", Content: []byte(`{"name": "billing"}`)},
+		}, nil
+	}
+
+	result, err := PushDependencyFiles(context.Background(), "blah", []string{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(pushed) != 1 || pushed[0].RenamedFrom != "services/billing/package.json" {
+		t.Errorf("Previous path should be sent, got: %+v", pushed)
+	}
+	expected := []Rename{{From: "services/billing/package.json", To: "apps/billing/package.json"}}
+	if !reflect.DeepEqual(result.Renamed, expected) {
+		t.Errorf("Expected renames: %v, got: %v", expected, result.Renamed)
+	}
+}
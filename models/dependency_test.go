@@ -3,7 +3,6 @@ package models
 import (
 	"bytes"
 	"fmt"
-	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
@@ -59,15 +58,11 @@ func TestListDependencies(t *testing.T) {
 		fmt.Fprintln(w, jsonOutput)
 	}))
 	defer ts.Close()
-	old := os.Stdout // keep backup of the real stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
+	var buf bytes.Buffer
+	Output = &buf
+	defer func() { Output = os.Stdout }()
 	config.APIEndpoint = ts.URL
 	ListDependencies(&Project{Slug: "blah"})
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	os.Stdout = old // restoring the real stdout
 
 	expectedOutput := "+------------------+--------------+--------+--------+------------+\n"
 	expectedOutput += "|   DEPENDENCIES   | REQUIREMENTS | LOCKED | STATUS | ADVISORIES |\n"
@@ -0,0 +1,85 @@
+package models
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/registry"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+const (
+	DEPENDENCY_DEPRECATED = "deprecated"
+	DEPENDENCY_ABANDONED  = "abandoned"
+	DEPENDENCY_YANKED     = "yanked"
+)
+
+// A dependency flagged by its registry: locked to a deprecated or yanked
+// version, or to a package which has been abandoned.
+type DeprecatedDependency struct {
+	Package       Package `json:"package"`
+	LockedVersion string  `json:"locked"`
+	Status        string  `json:"status"`
+	Message       string  `json:"message,omitempty"`
+	Replacement   string  `json:"replacement,omitempty"`
+}
+
+// Return the dependencies flagged as deprecated, abandoned or yanked by their
+// registries (npm deprecate flag, rubygems yanked versions, composer abandoned
+// field).
+// Dependencies from unsupported registries are ignored.
+func DeprecatedDependencies(deps []Dependency) []DeprecatedDependency {
+	deprecated := []DeprecatedDependency{}
+	for i, rv := range fetchRegistryVersions(deps) {
+		if rv.Err != nil || deps[i].LockedVersion == "" {
+			continue
+		}
+		dd := DeprecatedDependency{Package: deps[i].Package, LockedVersion: deps[i].LockedVersion}
+		locked := registry.FindVersion(rv.Versions, deps[i].LockedVersion)
+		switch {
+		case locked == nil:
+			dd.Status = DEPENDENCY_YANKED
+			dd.Message = "version is not available on the registry anymore"
+		case locked.Yanked:
+			dd.Status = DEPENDENCY_YANKED
+		case locked.Deprecated == DEPENDENCY_ABANDONED:
+			dd.Status = DEPENDENCY_ABANDONED
+			dd.Replacement = locked.Replacement
+		case locked.Deprecated != "":
+			dd.Status = DEPENDENCY_DEPRECATED
+			dd.Message = locked.Deprecated
+		default:
+			continue
+		}
+		deprecated = append(deprecated, dd)
+	}
+	return deprecated
+}
+
+// Display the deprecated dependencies of the project.
+// An error is returned if any is found.
+func ListDeprecatedDependencies(project *Project) error {
+	deps, err := project.Dependencies()
+	if err != nil {
+		return err
+	}
+
+	deprecated := DeprecatedDependencies(deps)
+	if len(deprecated) == 0 {
+		fmt.Fprint(Output, i18n.T("deps.no_deprecated"))
+		return nil
+	}
+	if err := RenderDeprecatedAsTable(deprecated, Output); err != nil {
+		return err
+	}
+	return fmt.Errorf("%d deprecated dependencies found.\n", len(deprecated))
+}
+
+func RenderDeprecatedAsTable(deprecated []DeprecatedDependency, output io.Writer) error {
+	table := utils.NewTable(output, "Dependencies", "Locked", "Status", "Message", "Replacement")
+	for _, dd := range deprecated {
+		table.Append(dd.Package.Name, dd.LockedVersion, dd.Status, dd.Message, dd.Replacement)
+	}
+	return table.Render()
+}
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/registry"
+)
+
+func TestDeprecatedDependencies(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/versions/rails.json":
+			fmt.Fprintln(w, `[{"number": "4.0.3", "platform": "ruby", "created_at": "2014-02-18T18:04:18.000Z"}]`)
+		case "/request":
+			fmt.Fprintln(w, `{"versions": {"2.88.2": {"deprecated": "request has been deprecated"}}, "time": {}}`)
+		case "/p2/guzzle/guzzle.json":
+			fmt.Fprintln(w, `{"packages": {"guzzle/guzzle": [
+				{"version": "v3.9.3", "time": "2015-03-18T18:23:50+00:00", "abandoned": "guzzlehttp/guzzle"},
+				{"version": "v3.9.2", "time": "2014-09-10T15:11:56+00:00"}
+			]}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	registry.RubygemsURL = ts.URL
+	registry.NpmURL = ts.URL
+	registry.PackagistURL = ts.URL
+	config.CacheDir = ""
+
+	deps := []Dependency{
+		Dependency{Package: Package{Name: "rails", Type: "rubygem"}, LockedVersion: "4.0.3"},
+		Dependency{Package: Package{Name: "rails", Type: "rubygem"}, LockedVersion: "4.0.2"},
+		Dependency{Package: Package{Name: "request", Type: "npm"}, LockedVersion: "2.88.2"},
+		Dependency{Package: Package{Name: "guzzle/guzzle", Type: "packagist"}, LockedVersion: "3.9.2"},
+	}
+	expected := []DeprecatedDependency{
+		DeprecatedDependency{Package: deps[1].Package, LockedVersion: "4.0.2", Status: DEPENDENCY_YANKED, Message: "version is not available on the registry anymore"},
+		DeprecatedDependency{Package: deps[2].Package, LockedVersion: "2.88.2", Status: DEPENDENCY_DEPRECATED, Message: "request has been deprecated"},
+		DeprecatedDependency{Package: deps[3].Package, LockedVersion: "3.9.2", Status: DEPENDENCY_ABANDONED, Replacement: "guzzlehttp/guzzle"},
+	}
+	deprecated := DeprecatedDependencies(deps)
+	if !reflect.DeepEqual(deprecated, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, deprecated)
+	}
+}
@@ -0,0 +1,251 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gemnasium/toolbelt/cache"
+	"github.com/gemnasium/toolbelt/i18n"
+)
+
+// A dependency locked to a new version
+type DependencyChange struct {
+	Package Package `json:"package"`
+	From    string  `json:"from"`
+	To      string  `json:"to"`
+}
+
+// Changes of a project since a given date
+type ProjectDigest struct {
+	Project        Project            `json:"project"`
+	NewAlerts      []Alert            `json:"new_alerts"`
+	ResolvedAlerts []Alert            `json:"resolved_alerts"`
+	Added          []Dependency       `json:"added"`
+	Removed        []Dependency       `json:"removed"`
+	Updated        []DependencyChange `json:"updated"`
+	// false when no snapshot of the dependencies was available to compare with
+	HasDependencyChanges bool  `json:"has_dependency_changes"`
+	Err                  error `json:"-"`
+}
+
+func (pd ProjectDigest) IsEmpty() bool {
+	return pd.Err == nil && len(pd.NewAlerts) == 0 && len(pd.ResolvedAlerts) == 0 &&
+		len(pd.Added) == 0 && len(pd.Removed) == 0 && len(pd.Updated) == 0
+}
+
+type Digest struct {
+	Since    time.Time       `json:"since"`
+	Projects []ProjectDigest `json:"projects"`
+}
+
+// Dependency changes are computed by comparing the current dependencies with
+// a snapshot taken by a previous digest. Snapshots are stored in the cache
+// directory, one file per project and run.
+func snapshotsDir(slug string) string {
+	dir := cache.Dir(cache.SNAPSHOTS)
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, strings.Replace(slug, string(filepath.Separator), "_", -1))
+}
+
+func saveDependencySnapshot(slug string, deps []Dependency, at time.Time) error {
+	dir := snapshotsDir(slug)
+	if dir == "" {
+		return nil
+	}
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(deps)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, fmt.Sprintf("%d.json", at.Unix())), data, 0644)
+}
+
+// Return the most recent snapshot taken at or before since. If there's none,
+// the oldest snapshot is returned. nil is returned if there's no snapshot at
+// all.
+func loadDependencySnapshot(slug string, since time.Time) []Dependency {
+	dir := snapshotsDir(slug)
+	if dir == "" {
+		return nil
+	}
+	files, err := ioutil.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+	timestamps := []int64{}
+	for _, f := range files {
+		ts, err := strconv.ParseInt(strings.TrimSuffix(f.Name(), ".json"), 10, 64)
+		if err == nil {
+			timestamps = append(timestamps, ts)
+		}
+	}
+	if len(timestamps) == 0 {
+		return nil
+	}
+	sort.Slice(timestamps, func(i, j int) bool { return timestamps[i] < timestamps[j] })
+	selected := timestamps[0]
+	for _, ts := range timestamps {
+		if ts <= since.Unix() {
+			selected = ts
+		}
+	}
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, fmt.Sprintf("%d.json", selected)))
+	if err != nil {
+		return nil
+	}
+	var deps []Dependency
+	if err := json.Unmarshal(data, &deps); err != nil {
+		return nil
+	}
+	return deps
+}
+
+// Compare two lists of dependencies, by package type and name
+func DiffDependencies(old, new []Dependency) (added, removed []Dependency, updated []DependencyChange) {
+	key := func(d Dependency) string { return d.Package.Type + "/" + d.Package.Name }
+	oldDeps := map[string]Dependency{}
+	for _, d := range old {
+		oldDeps[key(d)] = d
+	}
+	newDeps := map[string]bool{}
+	for _, d := range new {
+		newDeps[key(d)] = true
+		o, ok := oldDeps[key(d)]
+		switch {
+		case !ok:
+			added = append(added, d)
+		case o.LockedVersion != d.LockedVersion:
+			updated = append(updated, DependencyChange{d.Package, o.LockedVersion, d.LockedVersion})
+		}
+	}
+	for _, d := range old {
+		if !newDeps[key(d)] {
+			removed = append(removed, d)
+		}
+	}
+	return added, removed, updated
+}
+
+// Assemble the changes of the projects since the given date: new and resolved
+// alerts, and dependency changes.
+func BuildDigest(projects []Project, since time.Time) (*Digest, error) {
+	now := time.Now()
+	digest := &Digest{Since: since, Projects: make([]ProjectDigest, len(projects))}
+	err := forEachProject(projects, false, func(i int) error {
+		pd := &digest.Projects[i]
+		pd.Project = projects[i]
+		alerts, err := projects[i].Alerts()
+		if err != nil {
+			pd.Err = err
+			return err
+		}
+		for _, a := range alerts {
+			if !a.OpenAt.Before(since) {
+				pd.NewAlerts = append(pd.NewAlerts, a)
+			}
+			if a.ClosedAt != nil && !a.ClosedAt.Before(since) {
+				pd.ResolvedAlerts = append(pd.ResolvedAlerts, a)
+			}
+		}
+
+		deps, err := projects[i].Dependencies()
+		if err != nil {
+			pd.Err = err
+			return err
+		}
+		if old := loadDependencySnapshot(projects[i].Slug, since); old != nil {
+			pd.HasDependencyChanges = true
+			pd.Added, pd.Removed, pd.Updated = DiffDependencies(old, deps)
+		}
+		return saveDependencySnapshot(projects[i].Slug, deps, now)
+	}, func(i int, err error) {})
+	return digest, err
+}
+
+// Display the digest of the projects in the given format (text or html), to
+// be piped to sendmail or posted to a chat.
+func ShowDigest(projects []Project, since time.Time, format string) error {
+	if format != "text" && format != "html" {
+		return fmt.Errorf("Unknown format: %s", format)
+	}
+	digest, err := BuildDigest(projects, since)
+	if digest == nil {
+		return err
+	}
+	if format == "html" {
+		if renderErr := RenderDigestAsHTML(digest, Output); renderErr != nil {
+			return renderErr
+		}
+	} else {
+		RenderDigestAsText(digest, Output)
+	}
+	return err
+}
+
+func RenderDigestAsText(digest *Digest, output io.Writer) {
+	fmt.Fprint(output, i18n.T("report.digest_title", digest.Since.Format("2006-01-02")))
+	empty := true
+	for _, pd := range digest.Projects {
+		if pd.IsEmpty() {
+			continue
+		}
+		empty = false
+		fmt.Fprintf(output, "\n%s\n", pd.Project.Slug)
+		if pd.Err != nil {
+			fmt.Fprint(output, i18n.T("report.digest_error", pd.Err))
+			continue
+		}
+		for _, a := range pd.NewAlerts {
+			fmt.Fprint(output, i18n.T("report.digest_new_alert", a.Advisory.Title, a.Advisory.Package.Name))
+		}
+		for _, a := range pd.ResolvedAlerts {
+			fmt.Fprint(output, i18n.T("report.digest_resolved", a.Advisory.Title, a.Advisory.Package.Name))
+		}
+		for _, d := range pd.Added {
+			fmt.Fprint(output, i18n.T("report.digest_added", d.Package.Name, d.LockedVersion))
+		}
+		for _, c := range pd.Updated {
+			fmt.Fprint(output, i18n.T("report.digest_updated", c.Package.Name, c.From, c.To))
+		}
+		for _, d := range pd.Removed {
+			fmt.Fprint(output, i18n.T("report.digest_removed", d.Package.Name, d.LockedVersion))
+		}
+	}
+	if empty {
+		fmt.Fprint(output, i18n.T("report.digest_empty"))
+	}
+}
+
+var digestHTMLTemplate = template.Must(template.New("digest").Parse(`<html>
+<body>
+<h1>Gemnasium digest since {{.Since.Format "2006-01-02"}}</h1>
+{{range .Projects}}{{if not .IsEmpty}}<h2>{{.Project.Slug}}</h2>
+{{if .Err}}<p>Error: {{.Err}}</p>
+{{else}}<ul>
+{{range .NewAlerts}}<li><strong>New alert:</strong> {{.Advisory.Title}} ({{.Advisory.Package.Name}})</li>
+{{end}}{{range .ResolvedAlerts}}<li><strong>Resolved:</strong> {{.Advisory.Title}} ({{.Advisory.Package.Name}})</li>
+{{end}}{{range .Added}}<li>Added {{.Package.Name}} {{.LockedVersion}}</li>
+{{end}}{{range .Updated}}<li>Updated {{.Package.Name}} {{.From}} &rarr; {{.To}}</li>
+{{end}}{{range .Removed}}<li>Removed {{.Package.Name}} {{.LockedVersion}}</li>
+{{end}}</ul>
+{{end}}{{end}}{{end}}</body>
+</html>
+`))
+
+func RenderDigestAsHTML(digest *Digest, output io.Writer) error {
+	return digestHTMLTemplate.Execute(output, digest)
+}
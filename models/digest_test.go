@@ -0,0 +1,109 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestDiffDependencies(t *testing.T) {
+	old := []Dependency{
+		Dependency{Package: Package{Name: "rails", Type: "rubygem"}, LockedVersion: "4.2.0"},
+		Dependency{Package: Package{Name: "rack", Type: "rubygem"}, LockedVersion: "1.6.0"},
+		Dependency{Package: Package{Name: "json", Type: "rubygem"}, LockedVersion: "1.8.0"},
+	}
+	new := []Dependency{
+		Dependency{Package: Package{Name: "rails", Type: "rubygem"}, LockedVersion: "4.2.1"},
+		Dependency{Package: Package{Name: "rack", Type: "rubygem"}, LockedVersion: "1.6.0"},
+		Dependency{Package: Package{Name: "puma", Type: "rubygem"}, LockedVersion: "3.0.0"},
+	}
+	added, removed, updated := DiffDependencies(old, new)
+	if len(added) != 1 || added[0].Package.Name != "puma" {
+		t.Errorf("Expected puma to be added, got: %#v", added)
+	}
+	if len(removed) != 1 || removed[0].Package.Name != "json" {
+		t.Errorf("Expected json to be removed, got: %#v", removed)
+	}
+	if len(updated) != 1 || updated[0] != (DependencyChange{Package{Name: "rails", Type: "rubygem"}, "4.2.0", "4.2.1"}) {
+		t.Errorf("Expected rails to be updated, got: %#v", updated)
+	}
+}
+
+func TestBuildDigest(t *testing.T) {
+	railsVersion := "4.2.0"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/projects/blog/alerts":
+			fmt.Fprintln(w, `[
+				{"id": 1, "advisory": {"title": "Old XSS", "package": {"name": "rack"}}, "open_at": "2014-05-07T09:59:53Z", "status": "closed", "closed_at": "2100-01-01T00:00:00Z"},
+				{"id": 2, "advisory": {"title": "Older DOS", "package": {"name": "json"}}, "open_at": "2014-05-07T09:59:53Z", "status": "open"},
+				{"id": 3, "advisory": {"title": "New RCE", "package": {"name": "rails"}}, "open_at": "2100-01-01T00:00:00Z", "status": "open"}
+			]`)
+		case "/projects/blog/dependencies":
+			fmt.Fprintf(w, `[{"package": {"name": "rails", "type": "rubygem"}, "locked": "%s", "first_level": true}]`, railsVersion)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	dir, err := ioutil.TempDir("", "gemnasium-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.CacheDir = dir
+	defer func() { config.CacheDir = "" }()
+
+	since := time.Now().Add(-time.Hour)
+	projects := []Project{Project{Slug: "blog"}}
+
+	// first run: no snapshot to compare with
+	digest, err := BuildDigest(projects, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd := digest.Projects[0]
+	if len(pd.NewAlerts) != 1 || pd.NewAlerts[0].ID != 3 {
+		t.Errorf("Expected alert 3 to be new, got: %#v", pd.NewAlerts)
+	}
+	if len(pd.ResolvedAlerts) != 1 || pd.ResolvedAlerts[0].ID != 1 {
+		t.Errorf("Expected alert 1 to be resolved, got: %#v", pd.ResolvedAlerts)
+	}
+	if pd.HasDependencyChanges {
+		t.Error("Expected no dependency changes without snapshot")
+	}
+
+	// second run: compared with the snapshot of the first one
+	railsVersion = "4.2.1"
+	digest, err = BuildDigest(projects, since)
+	if err != nil {
+		t.Fatal(err)
+	}
+	pd = digest.Projects[0]
+	if !pd.HasDependencyChanges || len(pd.Updated) != 1 || pd.Updated[0].To != "4.2.1" {
+		t.Errorf("Expected rails to be updated, got: %#v", pd)
+	}
+
+	var buf bytes.Buffer
+	RenderDigestAsText(digest, &buf)
+	if !strings.Contains(buf.String(), "  [updated] rails 4.2.0 -> 4.2.1\n") || !strings.Contains(buf.String(), "  [new alert] New RCE (rails)\n") {
+		t.Errorf("Unexpected text digest:\n%s", buf.String())
+	}
+	buf.Reset()
+	if err := RenderDigestAsHTML(digest, &buf); err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(buf.String(), "<li>Updated rails 4.2.0 &rarr; 4.2.1</li>") {
+		t.Errorf("Unexpected html digest:\n%s", buf.String())
+	}
+}
@@ -0,0 +1,203 @@
+package models
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/gemnasium/toolbelt/i18n"
+)
+
+const (
+	FEED_EVENT_OPENED   = "opened"
+	FEED_EVENT_RESOLVED = "resolved"
+	FEED_TITLE          = "Gemnasium advisory events"
+)
+
+// An alert opened or resolved on a project
+type FeedEvent struct {
+	Project Project
+	Alert   Alert
+	Kind    string
+	Date    time.Time
+}
+
+func (e FeedEvent) Title() string {
+	return fmt.Sprintf("[%s] Alert %s: %s (%s)", e.Project.Slug, e.Kind, e.Alert.Advisory.Title, e.Alert.Advisory.Package.Name)
+}
+
+func (e FeedEvent) ID() string {
+	return fmt.Sprintf("gemnasium:%s:alert:%d:%s", e.Project.Slug, e.Alert.ID, e.Kind)
+}
+
+func (e FeedEvent) Link() string {
+	if len(e.Alert.Advisory.Links) > 0 {
+		return e.Alert.Advisory.Links[0]
+	}
+	return ""
+}
+
+// Collect the alert events of the projects, most recent first
+func CollectFeedEvents(projects []Project) ([]FeedEvent, error) {
+	results := make([][]FeedEvent, len(projects))
+	err := forEachProject(projects, false, func(i int) error {
+		alerts, err := projects[i].Alerts()
+		if err != nil {
+			return fmt.Errorf("%s: %s", projects[i].Slug, err)
+		}
+		for _, a := range alerts {
+			results[i] = append(results[i], FeedEvent{projects[i], a, FEED_EVENT_OPENED, a.OpenAt})
+			if a.ClosedAt != nil {
+				results[i] = append(results[i], FeedEvent{projects[i], a, FEED_EVENT_RESOLVED, *a.ClosedAt})
+			}
+		}
+		return nil
+	}, func(i int, err error) {
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+		}
+	})
+
+	events := []FeedEvent{}
+	for i := range projects {
+		events = append(events, results[i]...)
+	}
+	sort.SliceStable(events, func(i, j int) bool { return events[i].Date.After(events[j].Date) })
+	return events, err
+}
+
+type rssItem struct {
+	Title       string `xml:"title"`
+	Link        string `xml:"link,omitempty"`
+	Description string `xml:"description"`
+	GUID        struct {
+		IsPermaLink bool   `xml:"isPermaLink,attr"`
+		Value       string `xml:",chardata"`
+	} `xml:"guid"`
+	PubDate string `xml:"pubDate"`
+}
+
+type rss struct {
+	XMLName xml.Name `xml:"rss"`
+	Version string   `xml:"version,attr"`
+	Channel struct {
+		Title         string    `xml:"title"`
+		Link          string    `xml:"link"`
+		Description   string    `xml:"description"`
+		LastBuildDate string    `xml:"lastBuildDate"`
+		Items         []rssItem `xml:"item"`
+	} `xml:"channel"`
+}
+
+// Write the events as an RSS 2.0 feed
+func WriteRSS(events []FeedEvent, output io.Writer) error {
+	feed := rss{Version: "2.0"}
+	feed.Channel.Title = FEED_TITLE
+	feed.Channel.Link = "https://gemnasium.com"
+	feed.Channel.Description = "Security alerts opened and resolved on your projects"
+	feed.Channel.LastBuildDate = time.Now().UTC().Format(time.RFC1123Z)
+	for _, e := range events {
+		item := rssItem{Title: e.Title(), Link: e.Link(), Description: e.Alert.Advisory.Description, PubDate: e.Date.UTC().Format(time.RFC1123Z)}
+		item.GUID.Value = e.ID()
+		feed.Channel.Items = append(feed.Channel.Items, item)
+	}
+	return writeXML(feed, output)
+}
+
+type atomLink struct {
+	Href string `xml:"href,attr"`
+}
+
+type atomEntry struct {
+	Title   string    `xml:"title"`
+	ID      string    `xml:"id"`
+	Link    *atomLink `xml:"link,omitempty"`
+	Updated string    `xml:"updated"`
+	Summary string    `xml:"summary"`
+}
+
+type atom struct {
+	XMLName xml.Name    `xml:"feed"`
+	XMLNS   string      `xml:"xmlns,attr"`
+	Title   string      `xml:"title"`
+	ID      string      `xml:"id"`
+	Updated string      `xml:"updated"`
+	Entries []atomEntry `xml:"entry"`
+}
+
+// Write the events as an Atom feed
+func WriteAtom(events []FeedEvent, output io.Writer) error {
+	feed := atom{XMLNS: "http://www.w3.org/2005/Atom", Title: FEED_TITLE, ID: "gemnasium:advisory-events"}
+	feed.Updated = time.Now().UTC().Format(time.RFC3339)
+	if len(events) > 0 {
+		feed.Updated = events[0].Date.UTC().Format(time.RFC3339)
+	}
+	for _, e := range events {
+		entry := atomEntry{Title: e.Title(), ID: e.ID(), Updated: e.Date.UTC().Format(time.RFC3339), Summary: e.Alert.Advisory.Description}
+		if link := e.Link(); link != "" {
+			entry.Link = &atomLink{link}
+		}
+		feed.Entries = append(feed.Entries, entry)
+	}
+	return writeXML(feed, output)
+}
+
+func writeXML(v interface{}, output io.Writer) error {
+	io.WriteString(output, xml.Header)
+	enc := xml.NewEncoder(output)
+	enc.Indent("", "  ")
+	if err := enc.Encode(v); err != nil {
+		return err
+	}
+	_, err := io.WriteString(output, "\n")
+	return err
+}
+
+// Generate the feed of advisory events of the projects, in the given format
+// (rss or atom). The feed is written to stdout, or atomically replaces the out
+// file so readers never see a partial feed.
+func GenerateFeed(projects []Project, format, out string) error {
+	var write func([]FeedEvent, io.Writer) error
+	switch format {
+	case "rss":
+		write = WriteRSS
+	case "atom":
+		write = WriteAtom
+	default:
+		return fmt.Errorf("Unknown format: %s", format)
+	}
+
+	events, err := CollectFeedEvents(projects)
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		return write(events, Output)
+	}
+
+	tmp, err := ioutil.TempFile(filepath.Dir(out), ".feed")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+	if err := write(events, tmp); err != nil {
+		tmp.Close()
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		return err
+	}
+	if err := os.Chmod(tmp.Name(), 0644); err != nil {
+		return err
+	}
+	if err := os.Rename(tmp.Name(), out); err != nil {
+		return err
+	}
+	fmt.Fprint(Output, i18n.T("report.feed_written", out, len(events)))
+	return nil
+}
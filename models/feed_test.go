@@ -0,0 +1,74 @@
+package models
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestFeed(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/projects/blog/alerts":
+			fmt.Fprintln(w, `[
+				{"id": 1, "advisory": {"title": "XSS", "package": {"name": "rack"}, "links": ["https://example.com/xss"]}, "open_at": "2014-05-07T09:59:53Z", "status": "closed", "closed_at": "2014-06-01T00:00:00Z"},
+				{"id": 2, "advisory": {"title": "DOS", "package": {"name": "json"}}, "open_at": "2014-05-10T00:00:00Z", "status": "open"}
+			]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	events, err := CollectFeedEvents([]Project{Project{Slug: "blog"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(events) != 3 {
+		t.Fatalf("Expected 3 events, got: %#v", events)
+	}
+	if events[0].Kind != FEED_EVENT_RESOLVED || events[0].Alert.ID != 1 || events[2].Alert.ID != 1 {
+		t.Errorf("Expected events to be sorted by date, most recent first, got: %#v", events)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteRSS(events, &buf); err != nil {
+		t.Fatal(err)
+	}
+	var feed rss
+	if err := xml.Unmarshal(buf.Bytes(), &feed); err != nil {
+		t.Fatal(err)
+	}
+	item := feed.Channel.Items[0]
+	if item.Title != "[blog] Alert resolved: XSS (rack)" || item.Link != "https://example.com/xss" || item.GUID.Value != "gemnasium:blog:alert:1:resolved" {
+		t.Errorf("Unexpected RSS item: %#v", item)
+	}
+
+	dir, err := ioutil.TempDir("", "gemnasium-feed")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	out := filepath.Join(dir, "feed.xml")
+	if err := GenerateFeed([]Project{Project{Slug: "blog"}}, "atom", out); err != nil {
+		t.Fatal(err)
+	}
+	content, err := ioutil.ReadFile(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(content), `<feed xmlns="http://www.w3.org/2005/Atom">`) || !strings.Contains(string(content), "<updated>2014-06-01T00:00:00Z</updated>") {
+		t.Errorf("Unexpected Atom feed:\n%s", content)
+	}
+}
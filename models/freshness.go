@@ -0,0 +1,136 @@
+package models
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/registry"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+const year = 365 * 24 * time.Hour
+
+// Freshness of a single dependency, in libyears: the time elapsed between the
+// release of the locked version and the release of the latest version.
+// https://libyear.com
+type DependencyFreshness struct {
+	Package       Package `json:"package"`
+	LockedVersion string  `json:"locked"`
+	LatestVersion string  `json:"latest"`
+	Libyears      float64 `json:"libyears"`
+}
+
+type FreshnessReport struct {
+	Libyears     float64               `json:"libyears"`
+	Dependencies []DependencyFreshness `json:"dependencies"`
+	Skipped      []string              `json:"skipped,omitempty"`
+	// Package types without registry client (release dates are only known
+	// for Rubygems, npm and Packagist packages)
+	Unsupported []string `json:"unsupported,omitempty"`
+}
+
+// Compute the freshness of the given dependencies, using the release dates
+// known by their registries.
+// Dependencies without a locked version, or from an unsupported registry are
+// skipped.
+func ComputeFreshness(deps []Dependency) *FreshnessReport {
+	report := &FreshnessReport{Dependencies: []DependencyFreshness{}}
+	unsupported := map[string]bool{}
+	for i, rv := range fetchRegistryVersions(deps) {
+		dep := deps[i]
+		if dep.LockedVersion == "" {
+			continue
+		}
+		if rv.Err == registry.ErrVersionsUnsupported {
+			if !unsupported[dep.Package.Type] {
+				unsupported[dep.Package.Type] = true
+				report.Unsupported = append(report.Unsupported, dep.Package.Type)
+			}
+			continue
+		}
+		if rv.Err != nil {
+			report.Skipped = append(report.Skipped, dep.Package.Name)
+			continue
+		}
+		locked := registry.FindVersion(rv.Versions, dep.LockedVersion)
+		latest := registry.LatestVersion(rv.Versions)
+		if locked == nil || latest == nil {
+			report.Skipped = append(report.Skipped, dep.Package.Name)
+			continue
+		}
+
+		df := DependencyFreshness{Package: dep.Package, LockedVersion: dep.LockedVersion, LatestVersion: latest.Number}
+		if lag := latest.CreatedAt.Sub(locked.CreatedAt); lag > 0 {
+			df.Libyears = float64(lag) / float64(year)
+		}
+		report.Libyears += df.Libyears
+		report.Dependencies = append(report.Dependencies, df)
+	}
+
+	// Most outdated dependencies first
+	sort.SliceStable(report.Dependencies, func(i, j int) bool {
+		return report.Dependencies[i].Libyears > report.Dependencies[j].Libyears
+	})
+	return report
+}
+
+// Display the freshness report of the project dependencies, as a table or as
+// json (format), and optionally push it to Gemnasium for trend tracking.
+func ReportFreshness(project *Project, format string, push bool) error {
+	deps, err := project.Dependencies()
+	if err != nil {
+		return err
+	}
+
+	report := ComputeFreshness(deps)
+	switch format {
+	case "json":
+		err = utils.PrintJSON(Output, report)
+		if err != nil {
+			return err
+		}
+	case "table", "":
+		if err := RenderFreshnessAsTable(report, Output); err != nil {
+			return err
+		}
+	default:
+		return fmt.Errorf("Unknown format: %s", format)
+	}
+
+	if push {
+		opts := &gemnasium.APIRequestOptions{
+			Method: "POST",
+			URI:    fmt.Sprintf("/projects/%s/freshness", project.Slug),
+			Body:   report,
+		}
+		err = gemnasium.APIRequest(opts)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func RenderFreshnessAsTable(report *FreshnessReport, output io.Writer) error {
+	table := utils.NewTable(output, "Dependencies", "Locked", "Latest", "Libyears")
+	for _, df := range report.Dependencies {
+		table.Append(df.Package.Name, df.LockedVersion, df.LatestVersion, strconv.FormatFloat(df.Libyears, 'f', 2, 64))
+	}
+	table.SetFooter("", "", "Total", strconv.FormatFloat(report.Libyears, 'f', 2, 64))
+	if err := table.Render(); err != nil {
+		return err
+	}
+	if len(report.Skipped) > 0 {
+		fmt.Fprint(output, i18n.T("report.freshness_skipped", strings.Join(report.Skipped, ", ")))
+	}
+	if len(report.Unsupported) > 0 {
+		fmt.Fprint(output, i18n.T("report.freshness_unsupported", strings.Join(report.Unsupported, ", ")))
+	}
+	return nil
+}
@@ -0,0 +1,70 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/registry"
+)
+
+func TestComputeFreshness(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/versions/rails.json":
+			fmt.Fprintln(w, `[
+				{"number": "5.0.0.beta1", "platform": "ruby", "prerelease": true, "created_at": "2015-12-18T00:00:00.000Z"},
+				{"number": "4.2.0", "platform": "ruby", "created_at": "2014-12-20T00:00:00.000Z"},
+				{"number": "4.0.0", "platform": "ruby", "created_at": "2013-06-25T00:00:00.000Z"}
+			]`)
+		case "/api/v1/versions/rack.json":
+			fmt.Fprintln(w, `[{"number": "1.6.0", "platform": "ruby", "created_at": "2014-12-18T00:00:00.000Z"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	registry.RubygemsURL = ts.URL
+	config.CacheDir = ""
+
+	deps := []Dependency{
+		Dependency{Package: Package{Name: "rack", Type: "rubygem"}, LockedVersion: "1.6.0"},
+		Dependency{Package: Package{Name: "rails", Type: "rubygem"}, LockedVersion: "4.0.0"},
+		Dependency{Package: Package{Name: "unknown", Type: "rubygem"}, LockedVersion: "1.0.0"},
+		Dependency{Package: Package{Name: "requests", Type: "pypi"}, LockedVersion: "1.0.0"},
+	}
+	report := ComputeFreshness(deps)
+
+	if len(report.Dependencies) != 2 {
+		t.Fatalf("Expected 2 dependencies in report, got: %#v", report.Dependencies)
+	}
+	rails := report.Dependencies[0]
+	if rails.Package.Name != "rails" || rails.LatestVersion != "4.2.0" {
+		t.Errorf("Expected rails 4.2.0 to be the most outdated dependency, got: %#v", rails)
+	}
+	if rails.Libyears < 1.48 || rails.Libyears > 1.49 {
+		t.Errorf("Expected rails to be ~1.49 libyears behind, got: %f", rails.Libyears)
+	}
+	if report.Dependencies[1].Libyears != 0 {
+		t.Errorf("Expected rack to be up to date, got: %#v", report.Dependencies[1])
+	}
+	if report.Libyears != rails.Libyears {
+		t.Errorf("Expected total to be %f, got: %f", rails.Libyears, report.Libyears)
+	}
+	if strings.Join(report.Skipped, ",") != "unknown" {
+		t.Errorf("Expected unknown to be skipped, got: %v", report.Skipped)
+	}
+	if strings.Join(report.Unsupported, ",") != "pypi" {
+		t.Errorf("Expected pypi to be reported as unsupported, got: %v", report.Unsupported)
+	}
+
+	var buf bytes.Buffer
+	RenderFreshnessAsTable(report, &buf)
+	if !strings.Contains(buf.String(), "Skipped (unknown release dates): unknown\n") || !strings.Contains(buf.String(), "no registry client for these package types): pypi") {
+		t.Errorf("Skipped dependencies and unsupported types should be listed, got:\n%s", buf.String())
+	}
+}
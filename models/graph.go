@@ -0,0 +1,181 @@
+package models
+
+import (
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+)
+
+type graphNode struct {
+	ID         string
+	Label      string
+	Ecosystem  string
+	Vulnerable bool
+}
+
+type graphEdge struct {
+	Source, Target string
+}
+
+// Dependency graph of a project, built from the list of dependencies returned
+// by the API: transitive dependencies are listed right after the first level
+// dependency requiring them.
+type DependencyGraph struct {
+	Root  graphNode
+	Nodes []graphNode
+	Edges []graphEdge
+}
+
+func NewDependencyGraph(projectSlug string, deps []Dependency) *DependencyGraph {
+	g := &DependencyGraph{Root: graphNode{ID: projectSlug, Label: projectSlug}}
+	seen := map[string]bool{}
+	parent := g.Root.ID
+	for _, dep := range deps {
+		id := dep.Package.Slug
+		if id == "" {
+			id = dep.Package.Type + "/" + dep.Package.Name
+		}
+		if !seen[id] {
+			seen[id] = true
+			label := dep.Package.Name
+			if dep.LockedVersion != "" {
+				label += " " + dep.LockedVersion
+			}
+			g.Nodes = append(g.Nodes, graphNode{
+				ID:         id,
+				Label:      label,
+				Ecosystem:  dep.Package.Type,
+				Vulnerable: len(dep.Advisories) > 0 || dep.Color == "red",
+			})
+		}
+		if dep.FirstLevel {
+			g.Edges = append(g.Edges, graphEdge{g.Root.ID, id})
+			parent = id
+		} else {
+			g.Edges = append(g.Edges, graphEdge{parent, id})
+		}
+	}
+	return g
+}
+
+// Render the graph in the Graphviz DOT format, with one cluster per ecosystem.
+// Vulnerable dependencies are filled in red.
+func (g *DependencyGraph) RenderDot(output io.Writer) {
+	ecosystems := map[string][]graphNode{}
+	for _, n := range g.Nodes {
+		ecosystems[n.Ecosystem] = append(ecosystems[n.Ecosystem], n)
+	}
+	names := []string{}
+	for name := range ecosystems {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	fmt.Fprintf(output, "digraph %s {\n", dotQuote(g.Root.ID))
+	fmt.Fprintf(output, "  %s [shape=box];\n", dotQuote(g.Root.ID))
+	for i, name := range names {
+		fmt.Fprintf(output, "  subgraph cluster_%d {\n    label=%s;\n", i, dotQuote(name))
+		for _, n := range ecosystems[name] {
+			attrs := "label=" + dotQuote(n.Label)
+			if n.Vulnerable {
+				attrs += ", style=filled, fillcolor=red"
+			}
+			fmt.Fprintf(output, "    %s [%s];\n", dotQuote(n.ID), attrs)
+		}
+		fmt.Fprintln(output, "  }")
+	}
+	for _, e := range g.Edges {
+		fmt.Fprintf(output, "  %s -> %s;\n", dotQuote(e.Source), dotQuote(e.Target))
+	}
+	fmt.Fprintln(output, "}")
+}
+
+func dotQuote(s string) string {
+	return `"` + strings.Replace(s, `"`, `\"`, -1) + `"`
+}
+
+type graphMLData struct {
+	Key   string `xml:"key,attr"`
+	Value string `xml:",chardata"`
+}
+
+type graphMLNode struct {
+	ID   string        `xml:"id,attr"`
+	Data []graphMLData `xml:"data"`
+}
+
+type graphMLEdge struct {
+	Source string `xml:"source,attr"`
+	Target string `xml:"target,attr"`
+}
+
+type graphMLKey struct {
+	ID   string `xml:"id,attr"`
+	For  string `xml:"for,attr"`
+	Name string `xml:"attr.name,attr"`
+	Type string `xml:"attr.type,attr"`
+}
+
+type graphML struct {
+	XMLName xml.Name     `xml:"graphml"`
+	XMLNS   string       `xml:"xmlns,attr"`
+	Keys    []graphMLKey `xml:"key"`
+	Graph   struct {
+		ID          string        `xml:"id,attr"`
+		EdgeDefault string        `xml:"edgedefault,attr"`
+		Nodes       []graphMLNode `xml:"node"`
+		Edges       []graphMLEdge `xml:"edge"`
+	} `xml:"graph"`
+}
+
+// Render the graph in the GraphML format, with label, ecosystem and vulnerable
+// attributes on nodes.
+func (g *DependencyGraph) RenderGraphML(output io.Writer) error {
+	doc := graphML{
+		XMLNS: "http://graphml.graphdrawing.org/xmlns",
+		Keys: []graphMLKey{
+			graphMLKey{ID: "label", For: "node", Name: "label", Type: "string"},
+			graphMLKey{ID: "ecosystem", For: "node", Name: "ecosystem", Type: "string"},
+			graphMLKey{ID: "vulnerable", For: "node", Name: "vulnerable", Type: "boolean"},
+		},
+	}
+	doc.Graph.ID = g.Root.ID
+	doc.Graph.EdgeDefault = "directed"
+	for _, n := range append([]graphNode{g.Root}, g.Nodes...) {
+		doc.Graph.Nodes = append(doc.Graph.Nodes, graphMLNode{
+			ID: n.ID,
+			Data: []graphMLData{
+				graphMLData{"label", n.Label},
+				graphMLData{"ecosystem", n.Ecosystem},
+				graphMLData{"vulnerable", fmt.Sprintf("%t", n.Vulnerable)},
+			},
+		})
+	}
+	for _, e := range g.Edges {
+		doc.Graph.Edges = append(doc.Graph.Edges, graphMLEdge{e.Source, e.Target})
+	}
+
+	return writeXML(doc, output)
+}
+
+// Display the dependency graph of the project in the given format (dot or
+// graphml)
+func GraphDependencies(project *Project, format string) error {
+	deps, err := project.Dependencies()
+	if err != nil {
+		return err
+	}
+
+	g := NewDependencyGraph(project.Slug, deps)
+	switch format {
+	case "dot", "":
+		g.RenderDot(Output)
+		return nil
+	case "graphml":
+		return g.RenderGraphML(Output)
+	default:
+		return fmt.Errorf("Unknown format: %s", format)
+	}
+}
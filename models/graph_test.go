@@ -0,0 +1,61 @@
+package models
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func testGraphDeps() []Dependency {
+	return []Dependency{
+		Dependency{Package: Package{Name: "rails", Slug: "gems/rails", Type: "rubygem"}, LockedVersion: "3.1.12", FirstLevel: true, Color: "red"},
+		Dependency{Package: Package{Name: "activerecord", Slug: "gems/activerecord", Type: "rubygem"}, LockedVersion: "3.1.12", Advisories: []Advisory{Advisory{ID: 1}}},
+		Dependency{Package: Package{Name: "rack", Slug: "gems/rack", Type: "rubygem"}, LockedVersion: "1.3.10"},
+		Dependency{Package: Package{Name: "lodash", Slug: "npm/lodash", Type: "npm"}, LockedVersion: "4.17.21", FirstLevel: true, Color: "green"},
+	}
+}
+
+func TestRenderDot(t *testing.T) {
+	var buf bytes.Buffer
+	NewDependencyGraph("my-project", testGraphDeps()).RenderDot(&buf)
+
+	expected := `digraph "my-project" {
+  "my-project" [shape=box];
+  subgraph cluster_0 {
+    label="npm";
+    "npm/lodash" [label="lodash 4.17.21"];
+  }
+  subgraph cluster_1 {
+    label="rubygem";
+    "gems/rails" [label="rails 3.1.12", style=filled, fillcolor=red];
+    "gems/activerecord" [label="activerecord 3.1.12", style=filled, fillcolor=red];
+    "gems/rack" [label="rack 1.3.10"];
+  }
+  "my-project" -> "gems/rails";
+  "gems/rails" -> "gems/activerecord";
+  "gems/rails" -> "gems/rack";
+  "my-project" -> "npm/lodash";
+}
+`
+	if buf.String() != expected {
+		t.Errorf("Expected:\n%s\nGot:\n%s", expected, buf.String())
+	}
+}
+
+func TestRenderGraphML(t *testing.T) {
+	var buf bytes.Buffer
+	err := NewDependencyGraph("my-project", testGraphDeps()).RenderGraphML(&buf)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, s := range []string{
+		`<graph id="my-project" edgedefault="directed">`,
+		`<node id="gems/activerecord">`,
+		`<data key="vulnerable">true</data>`,
+		`<edge source="gems/rails" target="gems/rack"></edge>`,
+	} {
+		if !strings.Contains(buf.String(), s) {
+			t.Errorf("Expected GraphML output to contain %s, got:\n%s", s, buf.String())
+		}
+	}
+}
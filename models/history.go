@@ -0,0 +1,97 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+)
+
+// Number of commits fetched at a time when deepening a shallow clone
+const DEEPEN_STEP = 50
+
+func git(args ...string) ([]byte, error) {
+	return exec.Command("git", args...).Output()
+}
+
+// Return true if the commit is in the local history
+func hasCommit(revision string) bool {
+	_, err := git("cat-file", "-e", revision+"^{commit}")
+	return err == nil
+}
+
+// Return true if the current repository is a shallow clone (typical of CI)
+func isShallowRepository() bool {
+	out, err := git("rev-parse", "--is-shallow-repository")
+	return err == nil && strings.TrimSpace(string(out)) == "true"
+}
+
+// Make sure revision is in the local history. Shallow clones are deepened by
+// up to config.DeepenBudget commits to find it; otherwise the error tells
+// what to fetch.
+func EnsureRevision(revision string) error {
+	if hasCommit(revision) {
+		return nil
+	}
+	if !isShallowRepository() {
+		return errors.New(i18n.T("git.revision_missing", revision, revision))
+	}
+
+	deepened := 0
+	for deepened < config.DeepenBudget {
+		step := DEEPEN_STEP
+		if remaining := config.DeepenBudget - deepened; step > remaining {
+			step = remaining
+		}
+		fmt.Fprint(Output, i18n.T("git.deepening", step, revision))
+		if _, err := git("fetch", "--quiet", fmt.Sprintf("--deepen=%d", step)); err != nil {
+			break
+		}
+		deepened += step
+		if hasCommit(revision) {
+			return nil
+		}
+		if !isShallowRepository() {
+			// The whole history has been fetched
+			break
+		}
+	}
+	return errors.New(i18n.T("git.shallow_revision_missing", revision, deepened, revision))
+}
+
+// Return the files among paths which changed between revision and HEAD.
+// revision is fetched first if the clone is shallow (see EnsureRevision).
+func changedSince(revision string, paths []string) ([]string, error) {
+	if err := EnsureRevision(revision); err != nil {
+		return nil, err
+	}
+	out, err := git(append([]string{"diff", "--name-only", revision, "HEAD", "--"}, paths...)...)
+	if err != nil {
+		return nil, err
+	}
+	return strings.Fields(string(out)), nil
+}
+
+// Return an error if any of the dependency files changed since revision, as
+// files computed for this revision would overwrite the changes. Nothing is
+// checked outside of git repositories.
+func CheckUnchangedSince(revision string, dfiles []DependencyFile) error {
+	if _, err := git("rev-parse", "--git-dir"); err != nil {
+		return nil
+	}
+	paths := []string{}
+	for _, df := range dfiles {
+		paths = append(paths, df.Path)
+	}
+	changed, err := changedSince(revision, paths)
+	if err != nil {
+		return err
+	}
+	if len(changed) > 0 {
+		return errors.New(i18n.T("git.files_changed_since", revision, strings.Join(changed, ", ")))
+	}
+	return nil
+}
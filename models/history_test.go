@@ -0,0 +1,109 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// Create a repository with a commit per content of Gemfile, and return its
+// path and the revisions of the commits
+func createRepository(t *testing.T, contents ...string) (string, []string) {
+	if _, err := exec.LookPath("git"); err != nil {
+		t.Skip("git not available")
+	}
+	dir, err := ioutil.TempDir("", "gemnasium-history")
+	if err != nil {
+		t.Fatal(err)
+	}
+	run := func(args ...string) string {
+		cmd := exec.Command("git", append([]string{"-c", "user.name=test", "-c", "user.email=test@example.com"}, args...)...)
+		cmd.Dir = dir
+		out, err := cmd.CombinedOutput()
+		if err != nil {
+			t.Fatalf("git %v: %s", args, out)
+		}
+		return strings.TrimSpace(string(out))
+	}
+	run("init", "--quiet")
+	revisions := []string{}
+	for _, content := range contents {
+		if err := ioutil.WriteFile(filepath.Join(dir, "Gemfile"), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		run("add", "Gemfile")
+		run("commit", "--quiet", "--allow-empty", "-m", "update")
+		revisions = append(revisions, run("rev-parse", "HEAD"))
+	}
+	return dir, revisions
+}
+
+// Run fn in a shallow clone (depth 1) of the repository
+func inShallowClone(t *testing.T, repo string, fn func()) {
+	dir, err := ioutil.TempDir("", "gemnasium-clone")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if out, err := exec.Command("git", "clone", "--quiet", "--depth", "1", "file://"+repo, dir).CombinedOutput(); err != nil {
+		t.Fatalf("git clone: %s", out)
+	}
+	wd, _ := os.Getwd()
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	fn()
+}
+
+func TestEnsureRevisionInShallowClone(t *testing.T) {
+	repo, revisions := createRepository(t, "gem 'rails'\n", "gem 'rails'\n", "gem 'rails'\n")
+	defer os.RemoveAll(repo)
+	Output = ioutil.Discard
+	defer func() { Output = os.Stdout }()
+
+	inShallowClone(t, repo, func() {
+		err := EnsureRevision(revisions[0])
+		if err == nil || !strings.Contains(err.Error(), "git fetch --unshallow") {
+			t.Errorf("Expected error telling what to fetch, got: %v", err)
+		}
+		if hasCommit(revisions[0]) {
+			t.Error("Expected nothing to be fetched without budget")
+		}
+	})
+
+	config.DeepenBudget = 10
+	defer func() { config.DeepenBudget = 0 }()
+	inShallowClone(t, repo, func() {
+		if err := EnsureRevision(revisions[0]); err != nil {
+			t.Fatal(err)
+		}
+		if !hasCommit(revisions[0]) {
+			t.Error("Expected the clone to be deepened")
+		}
+	})
+}
+
+func TestCheckUnchangedSince(t *testing.T) {
+	repo, revisions := createRepository(t, "gem 'rails'\n", "gem 'rails'\n", "gem 'rails', '4.2.0'\n")
+	defer os.RemoveAll(repo)
+	Output = ioutil.Discard
+	defer func() { Output = os.Stdout }()
+	config.DeepenBudget = 10
+	defer func() { config.DeepenBudget = 0 }()
+
+	inShallowClone(t, repo, func() {
+		dfiles := []DependencyFile{{Path: "Gemfile"}}
+		if err := CheckUnchangedSince(revisions[1], dfiles); err == nil {
+			t.Error("Expected an error, Gemfile changed since the revision")
+		}
+		if err := CheckUnchangedSince(revisions[2], dfiles); err != nil {
+			t.Error(err)
+		}
+	})
+}
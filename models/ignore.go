@@ -0,0 +1,123 @@
+package models
+
+import (
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// A pattern of ignored_paths. Patterns without "/" match file and directory
+// names at any depth (ie: "vendor", "*.min.js"), other ones match paths
+// relative to the directory of the project, with "**" for any number of
+// directories (ie: "packages/*/test", "vendor/**"). Patterns ending with "/"
+// only match directories, and patterns starting with "!" include the paths
+// back. The last matching pattern wins.
+type ignoreRule struct {
+	Pattern string
+	// Directory of the project the pattern comes from ("." for the global
+	// ignored_paths)
+	Dir     string
+	negate  bool
+	dirOnly bool
+	glob    string
+}
+
+func newIgnoreRule(pattern, dir string) ignoreRule {
+	rule := ignoreRule{Pattern: pattern, Dir: dir}
+	glob := filepath.ToSlash(strings.TrimSpace(pattern))
+	if strings.HasPrefix(glob, "!") {
+		rule.negate = true
+		glob = glob[1:]
+	}
+	if strings.HasSuffix(glob, "/") {
+		rule.dirOnly = true
+	}
+	rule.glob = strings.TrimPrefix(path.Clean(glob), "./")
+	rule.glob = strings.TrimPrefix(rule.glob, "/")
+	return rule
+}
+
+// Return the rules of the global ignored_paths, then the ones of the projects
+// (config.ProjectIgnoredPaths), shallowest directories first
+func ignoreRules() []ignoreRule {
+	rules := []ignoreRule{}
+	for _, pattern := range config.IgnoredPaths {
+		rules = append(rules, newIgnoreRule(pattern, "."))
+	}
+	dirs := []string{}
+	for dir := range config.ProjectIgnoredPaths {
+		dirs = append(dirs, dir)
+	}
+	sort.Slice(dirs, func(i, j int) bool {
+		if len(dirs[i]) != len(dirs[j]) {
+			return len(dirs[i]) < len(dirs[j])
+		}
+		return dirs[i] < dirs[j]
+	})
+	for _, dir := range dirs {
+		target := pushTarget{Dir: strings.Trim(path.Clean(filepath.ToSlash(dir)), "/")}
+		if target.Dir == "" {
+			target.Dir = "."
+		}
+		for _, pattern := range config.ProjectIgnoredPaths[dir] {
+			rules = append(rules, newIgnoreRule(pattern, target.Dir))
+		}
+	}
+	return rules
+}
+
+// Return true if the rule matches the path, or one of its parent directories
+func (rule ignoreRule) match(localPath string, isDir bool) bool {
+	target := pushTarget{Dir: rule.Dir}
+	if !target.contains(localPath) {
+		return false
+	}
+	rel := target.relPath(localPath)
+	for {
+		if (isDir || !rule.dirOnly) && rule.matchPath(rel) {
+			return true
+		}
+		parent := path.Dir(rel)
+		if parent == "." || parent == rel {
+			return false
+		}
+		rel, isDir = parent, true
+	}
+}
+
+func (rule ignoreRule) matchPath(rel string) bool {
+	if !strings.Contains(rule.glob, "/") {
+		matched, _ := path.Match(rule.glob, path.Base(rel))
+		return matched
+	}
+	return utils.MatchGlob(rule.glob, rel)
+}
+
+// Return the rule excluding the path, if any
+func ignoredBy(rules []ignoreRule, localPath string, isDir bool) (*ignoreRule, bool) {
+	var last *ignoreRule
+	for i, rule := range rules {
+		if rule.match(localPath, isDir) {
+			last = &rules[i]
+		}
+	}
+	if last == nil || last.negate {
+		return nil, false
+	}
+	return last, true
+}
+
+// Return true if some paths are included back by rules: ignored directories
+// must be walked anyway
+func hasNegation(rules []ignoreRule) bool {
+	for _, rule := range rules {
+		if rule.negate {
+			return true
+		}
+	}
+	return false
+}
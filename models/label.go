@@ -0,0 +1,87 @@
+package models
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/wsxiaoys/terminal/color"
+)
+
+// Parse labels like "team=payments". If requireValue is false, labels may be
+// a single key ("team"), which is stored with an empty value.
+func ParseLabels(args []string, requireValue bool) (map[string]string, error) {
+	labels := map[string]string{}
+	for _, arg := range args {
+		parts := strings.SplitN(arg, "=", 2)
+		key := strings.TrimSpace(parts[0])
+		if key == "" {
+			return nil, fmt.Errorf("Invalid label: '%s'", arg)
+		}
+		if len(parts) == 1 {
+			if requireValue {
+				return nil, fmt.Errorf("Invalid label: '%s' (expected key=value)", arg)
+			}
+			labels[key] = ""
+			continue
+		}
+		labels[key] = strings.TrimSpace(parts[1])
+	}
+	return labels, nil
+}
+
+// Return true if the project has all the labels of the selector.
+// Labels of the selector with an empty value only need the key to be present.
+func (p *Project) MatchLabels(selector map[string]string) bool {
+	for key, value := range selector {
+		v, ok := p.Labels[key]
+		if !ok || (value != "" && v != value) {
+			return false
+		}
+	}
+	return true
+}
+
+// Return the labels of the project as a sorted list of "key=value"
+func (p *Project) LabelsList() []string {
+	list := []string{}
+	for key, value := range p.Labels {
+		list = append(list, key+"="+value)
+	}
+	sort.Strings(list)
+	return list
+}
+
+// Add labels to the project. Existing labels with the same keys are replaced.
+func (p *Project) AddLabels(labels map[string]string) error {
+	opts := &gemnasium.APIRequestOptions{
+		Method: "PATCH",
+		URI:    fmt.Sprintf("/projects/%s/labels", p.Slug),
+		Body:   labels,
+	}
+	err := gemnasium.APIRequest(opts)
+	if err != nil {
+		return err
+	}
+
+	fmt.Fprint(Output, color.Sprintf("@gLabels of project %s updated succesfully\n", p.Slug))
+	return nil
+}
+
+// Remove labels from the project
+func (p *Project) RemoveLabels(keys []string) error {
+	for _, key := range keys {
+		opts := &gemnasium.APIRequestOptions{
+			Method: "DELETE",
+			URI:    fmt.Sprintf("/projects/%s/labels/%s", p.Slug, key),
+		}
+		err := gemnasium.APIRequest(opts)
+		if err != nil {
+			return err
+		}
+	}
+
+	fmt.Fprint(Output, color.Sprintf("@gLabels of project %s updated succesfully\n", p.Slug))
+	return nil
+}
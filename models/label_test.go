@@ -0,0 +1,80 @@
+package models
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestParseLabels(t *testing.T) {
+	labels, err := ParseLabels([]string{"team=payments", "tier = 1", "critical"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{"team": "payments", "tier": "1", "critical": ""}
+	if !reflect.DeepEqual(labels, expected) {
+		t.Errorf("Expected %v, got: %v", expected, labels)
+	}
+
+	if _, err := ParseLabels([]string{"critical"}, true); err == nil {
+		t.Error("Expected an error for a label without value")
+	}
+	if _, err := ParseLabels([]string{"=payments"}, false); err == nil {
+		t.Error("Expected an error for a label without key")
+	}
+}
+
+func TestMatchLabels(t *testing.T) {
+	p := &Project{Labels: map[string]string{"team": "payments", "tier": "1"}}
+	var tt = []struct {
+		Selector map[string]string
+		Expected bool
+	}{
+		{map[string]string{}, true},
+		{map[string]string{"team": "payments"}, true},
+		{map[string]string{"team": "payments", "tier": "1"}, true},
+		{map[string]string{"tier": ""}, true},
+		{map[string]string{"team": "search"}, false},
+		{map[string]string{"team": "payments", "critical": ""}, false},
+	}
+	for _, test := range tt {
+		if p.MatchLabels(test.Selector) != test.Expected {
+			t.Errorf("MatchLabels(%v) should be %t", test.Selector, test.Expected)
+		}
+	}
+}
+
+func TestAddAndRemoveLabels(t *testing.T) {
+	requests := []string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests = append(requests, r.Method+" "+r.URL.Path)
+		if r.Method == "PATCH" {
+			var labels map[string]string
+			if err := json.NewDecoder(r.Body).Decode(&labels); err != nil {
+				t.Error(err)
+			}
+			if labels["team"] != "payments" {
+				t.Errorf("Unexpected labels: %v", labels)
+			}
+		}
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	p := &Project{Slug: "blog"}
+	if err := p.AddLabels(map[string]string{"team": "payments"}); err != nil {
+		t.Fatal(err)
+	}
+	if err := p.RemoveLabels([]string{"team", "tier"}); err != nil {
+		t.Fatal(err)
+	}
+	expected := []string{"PATCH /projects/blog/labels", "DELETE /projects/blog/labels/team", "DELETE /projects/blog/labels/tier"}
+	if !reflect.DeepEqual(requests, expected) {
+		t.Errorf("Expected requests %v, got: %v", expected, requests)
+	}
+}
@@ -0,0 +1,176 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// A package resolved in a lockfile. Originator and License are empty when
+// the lockfile doesn't provide them.
+type LockedPackage struct {
+	Name       string
+	Version    string
+	Ecosystem  string
+	Originator string
+	License    string
+	File       string
+}
+
+type lockfileReader func(content []byte) []LockedPackage
+
+// Lockfile readers by file name
+var lockfileReaders = map[string]lockfileReader{
+	"Gemfile.lock":        readGemfileLock,
+	"package-lock.json":   readPackageLock,
+	"npm-shrinkwrap.json": readPackageLock,
+	"composer.lock":       readComposerLock,
+	"Cargo.lock":          readCargoLock,
+	"go.sum":              readGoSum,
+}
+
+// Return the packages resolved in the file, nil if it isn't a supported
+// lockfile
+func (df *DependencyFile) LockedPackages() []LockedPackage {
+	read, ok := lockfileReaders[filepath.Base(df.Path)]
+	if !ok {
+		return nil
+	}
+	content, err := df.contents()
+	if err != nil {
+		return nil
+	}
+	packages := read(content)
+	for i := range packages {
+		packages[i].File = df.Path
+	}
+	return packages
+}
+
+// Only specs are indented with exactly 4 spaces
+var gemSpec = regexp.MustCompile(`^    ([^ ]+) \(([^)]+)\)$`)
+
+func readGemfileLock(content []byte) []LockedPackage {
+	packages := []LockedPackage{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		if m := gemSpec.FindStringSubmatch(scanner.Text()); m != nil {
+			packages = append(packages, LockedPackage{Name: m[1], Version: m[2], Ecosystem: "gem"})
+		}
+	}
+	return packages
+}
+
+// Lockfile v2 and v3 list the packages by install path, with their license;
+// v1 only has nested dependencies, without license.
+func readPackageLock(content []byte) []LockedPackage {
+	type npmPackage struct {
+		Version      string                `json:"version"`
+		License      interface{}           `json:"license"`
+		Link         bool                  `json:"link"`
+		Dependencies map[string]npmPackage `json:"dependencies"`
+	}
+	var lock struct {
+		Packages     map[string]npmPackage `json:"packages"`
+		Dependencies map[string]npmPackage `json:"dependencies"`
+	}
+	if json.Unmarshal(content, &lock) != nil {
+		return nil
+	}
+	packages := []LockedPackage{}
+	if len(lock.Packages) > 0 {
+		paths := []string{}
+		for path := range lock.Packages {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			pkg := lock.Packages[path]
+			i := strings.LastIndex(path, "node_modules/")
+			if i < 0 || pkg.Link {
+				continue // the project itself, or a workspace
+			}
+			// Old lockfiles have objects ({type, url}) as licenses
+			license, _ := pkg.License.(string)
+			packages = append(packages, LockedPackage{Name: path[i+len("node_modules/"):], Version: pkg.Version, Ecosystem: "npm", License: license})
+		}
+		return packages
+	}
+	var walk func(deps map[string]npmPackage)
+	walk = func(deps map[string]npmPackage) {
+		names := []string{}
+		for name := range deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			packages = append(packages, LockedPackage{Name: name, Version: deps[name].Version, Ecosystem: "npm"})
+			walk(deps[name].Dependencies)
+		}
+	}
+	walk(lock.Dependencies)
+	return packages
+}
+
+// Composer licenses are disjunctive: the package can be used under any of
+// them
+func readComposerLock(content []byte) []LockedPackage {
+	type composerPackage struct {
+		Name    string   `json:"name"`
+		Version string   `json:"version"`
+		License []string `json:"license"`
+		Authors []struct {
+			Name string `json:"name"`
+		} `json:"authors"`
+	}
+	var lock struct {
+		Packages    []composerPackage `json:"packages"`
+		PackagesDev []composerPackage `json:"packages-dev"`
+	}
+	if json.Unmarshal(content, &lock) != nil {
+		return nil
+	}
+	packages := []LockedPackage{}
+	for _, pkg := range append(lock.Packages, lock.PackagesDev...) {
+		locked := LockedPackage{Name: pkg.Name, Version: strings.TrimPrefix(pkg.Version, "v"), Ecosystem: "composer"}
+		if len(pkg.License) > 1 {
+			locked.License = "(" + strings.Join(pkg.License, " OR ") + ")"
+		} else if len(pkg.License) == 1 {
+			locked.License = pkg.License[0]
+		}
+		if len(pkg.Authors) > 0 {
+			locked.Originator = pkg.Authors[0].Name
+		}
+		packages = append(packages, locked)
+	}
+	return packages
+}
+
+var cargoPackage = regexp.MustCompile(`(?m)^\[\[package\]\]\s*\nname = "([^"]+)"\s*\nversion = "([^"]+)"`)
+
+func readCargoLock(content []byte) []LockedPackage {
+	packages := []LockedPackage{}
+	for _, m := range cargoPackage.FindAllSubmatch(content, -1) {
+		packages = append(packages, LockedPackage{Name: string(m[1]), Version: string(m[2]), Ecosystem: "cargo"})
+	}
+	return packages
+}
+
+// go.sum lists the hash of the go.mod of every module version of the build
+// graph, but only the module versions actually used have a tree hash
+func readGoSum(content []byte) []LockedPackage {
+	packages := []LockedPackage{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 || strings.HasSuffix(fields[1], "/go.mod") {
+			continue
+		}
+		packages = append(packages, LockedPackage{Name: fields[0], Version: fields[1], Ecosystem: "golang"})
+	}
+	return packages
+}
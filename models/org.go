@@ -0,0 +1,318 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// Return the monitored projects available to the current user. If owner is
+// set, only the projects shared by this owner (typically an organization) are
+// returned; use "owned" for the projects of the current user.
+// Projects are also filtered with the labels selector (see MatchLabels).
+func getOrgProjects(owner string, labels map[string]string) ([]Project, error) {
+	var projects map[string][]Project
+	opts := &gemnasium.APIRequestOptions{
+		Method: "GET",
+		URI:    LIST_PROJECTS_PATH,
+		Result: &projects,
+	}
+	err := gemnasium.APIRequest(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	owners := []string{}
+	for o := range projects {
+		if owner == "" || o == owner {
+			owners = append(owners, o)
+		}
+	}
+	if len(owners) == 0 {
+		return nil, errors.New(i18n.T("org.no_projects", owner))
+	}
+	sort.Strings(owners)
+
+	monitored := []Project{}
+	for _, o := range owners {
+		for _, p := range projects[o] {
+			if p.Monitored && p.MatchLabels(labels) {
+				monitored = append(monitored, p)
+			}
+		}
+	}
+	return monitored, nil
+}
+
+// Return the projects to process: the given slugs if any, the projects
+// matching owner and labels if set, or the current project otherwise.
+func SelectProjects(slugs []string, owner string, labels map[string]string) ([]Project, error) {
+	if len(slugs) > 0 {
+		projects := []Project{}
+		for _, slug := range slugs {
+			projects = append(projects, Project{Slug: slug})
+		}
+		return projects, nil
+	}
+	if owner != "" || len(labels) > 0 {
+		return getOrgProjects(owner, labels)
+	}
+	project, err := GetProject()
+	if err != nil {
+		return nil, err
+	}
+	return []Project{*project}, nil
+}
+
+// Call fn for each project, with config.OrgConcurrency projects processed
+// concurrently. API requests are limited to config.OrgQPS per second.
+// report is called with the result of each project as soon as it completes,
+// always from the calling goroutine. With failFast, no more projects are
+// started after the first error; otherwise all projects are processed.
+// The returned error summarizes the failures, if any.
+func forEachProject(projects []Project, failFast bool, fn func(i int) error, report func(i int, err error)) error {
+	gemnasium.SetRateLimit(config.OrgQPS)
+	defer gemnasium.SetRateLimit(0)
+
+	type result struct {
+		index int
+		err   error
+	}
+	workers := config.OrgConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	jobs := make(chan int)
+	results := make(chan result)
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	for w := 0; w < workers; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				results <- result{i, fn(i)}
+			}
+		}()
+	}
+	go func() {
+		defer close(jobs)
+		for i := range projects {
+			select {
+			case jobs <- i:
+			case <-stop:
+				return
+			}
+		}
+	}()
+	go func() {
+		wg.Wait()
+		close(results)
+	}()
+
+	var firstErr error
+	failed := 0
+	for r := range results {
+		report(r.index, r.err)
+		if r.err == nil {
+			continue
+		}
+		failed++
+		if firstErr == nil {
+			firstErr = r.err
+			if failFast {
+				close(stop)
+			}
+		}
+	}
+	if failFast && firstErr != nil {
+		return firstErr
+	}
+	if failed > 0 {
+		return fmt.Errorf("%d of %d projects failed", failed, len(projects))
+	}
+	return nil
+}
+
+// Usage of a package by a project
+type PackageUsage struct {
+	Project       Project    `json:"project"`
+	Requirement   string     `json:"requirement"`
+	LockedVersion string     `json:"locked"`
+	FirstLevel    bool       `json:"first_level"`
+	Color         string     `json:"color"`
+	Advisories    []Advisory `json:"advisories,omitempty"`
+}
+
+// Find the projects depending on the package name. If packageType is set,
+// dependencies from other package managers are ignored.
+// Progress is written to output as each project completes. Usages found are
+// returned even if some projects failed (unless failFast is set).
+func FindPackageUsages(projects []Project, name, packageType string, failFast bool, output io.Writer) ([]PackageUsage, error) {
+	results := make([][]PackageUsage, len(projects))
+	done := 0
+	err := forEachProject(projects, failFast, func(i int) error {
+		deps, err := projects[i].Dependencies()
+		if err != nil {
+			return err
+		}
+		for _, dep := range deps {
+			if dep.Package.Name != name {
+				continue
+			}
+			if packageType != "" && !strings.EqualFold(dep.Package.Type, packageType) {
+				continue
+			}
+			results[i] = append(results[i], PackageUsage{
+				Project:       projects[i],
+				Requirement:   dep.Requirement,
+				LockedVersion: dep.LockedVersion,
+				FirstLevel:    dep.FirstLevel,
+				Color:         dep.Color,
+				Advisories:    dep.Advisories,
+			})
+		}
+		return nil
+	}, func(i int, err error) {
+		done++
+		prefix := fmt.Sprintf("[%d/%d] %s:", done, len(projects), projects[i].Slug)
+		switch {
+		case err != nil:
+			fmt.Fprintf(output, "%s error: %s\n", prefix, err)
+		case len(results[i]) == 0:
+			fmt.Fprintf(output, "%s not used\n", prefix)
+		default:
+			versions := []string{}
+			for _, u := range results[i] {
+				versions = append(versions, u.LockedVersion)
+			}
+			fmt.Fprintf(output, "%s %s %s\n", prefix, name, strings.Join(versions, ", "))
+		}
+	})
+
+	usages := []PackageUsage{}
+	for i := range projects {
+		usages = append(usages, results[i]...)
+	}
+	if err != nil && failFast {
+		return nil, err
+	}
+	return usages, err
+}
+
+// Report which projects use the given package, and at which versions.
+// Results are displayed as soon as each project is processed, followed by a
+// summary table.
+func ListPackageUsages(owner string, labels map[string]string, name, packageType string, failFast bool) error {
+	projects, err := getOrgProjects(owner, labels)
+	if err != nil {
+		return err
+	}
+
+	usages, searchErr := FindPackageUsages(projects, name, packageType, failFast, Output)
+	if failFast && searchErr != nil {
+		return searchErr
+	}
+	fmt.Fprintln(Output)
+	if len(usages) == 0 {
+		fmt.Fprint(Output, i18n.T("org.no_usages", name, len(projects)))
+		return searchErr
+	}
+
+	if err := RenderPackageUsagesAsTable(usages, Output); err != nil {
+		return err
+	}
+	slugs, versions := map[string]bool{}, map[string]bool{}
+	for _, u := range usages {
+		slugs[u.Project.Slug] = true
+		versions[u.LockedVersion] = true
+	}
+	fmt.Fprintf(Output, "%s is used by %d projects, with %d distinct versions\n", name, len(slugs), len(versions))
+	return searchErr
+}
+
+// Display package usages in an ascii table, sorted by version
+func RenderPackageUsagesAsTable(usages []PackageUsage, output io.Writer) error {
+	sort.SliceStable(usages, func(i, j int) bool {
+		return usages[i].LockedVersion < usages[j].LockedVersion
+	})
+	table := utils.NewTable(output, "Project", "Requirement", "Locked", "Level", "Status")
+	for _, u := range usages {
+		level := "direct"
+		if !u.FirstLevel {
+			level = "transitive"
+		}
+		table.Append(u.Project.Slug, u.Requirement, u.LockedVersion, level, u.Color)
+	}
+	return table.Render()
+}
+
+// Open alert of a project about an advisory
+type AffectedProject struct {
+	Project  Project  `json:"project"`
+	Advisory Advisory `json:"advisory"`
+}
+
+// Find the org projects with an open alert about the advisory, reporting
+// progress on Output. Affected projects found are returned along with the
+// error of the projects that failed, unless failFast is set.
+func OrgAffectedProjects(owner string, labels map[string]string, advisory string, failFast bool) ([]AffectedProject, error) {
+	projects, err := getOrgProjects(owner, labels)
+	if err != nil {
+		return nil, err
+	}
+	affected, err := FindAffectedProjects(projects, advisory, failFast, Output)
+	if failFast && err != nil {
+		return nil, err
+	}
+	if len(affected) == 0 {
+		fmt.Fprint(Output, i18n.T("org.remediate_none", advisory, len(projects)))
+	}
+	return affected, err
+}
+
+// Find the projects with an open alert about the advisory: its identifier
+// (ie: CVE-2021-44228) or its ID. Progress is written to output as each
+// project completes.
+func FindAffectedProjects(projects []Project, advisory string, failFast bool, output io.Writer) ([]AffectedProject, error) {
+	results := make([][]AffectedProject, len(projects))
+	done := 0
+	err := forEachProject(projects, failFast, func(i int) error {
+		alerts, err := projects[i].Alerts()
+		if err != nil {
+			return err
+		}
+		for _, alert := range alerts {
+			a := alert.Advisory
+			if alert.Status != "closed" && (a.Identifier == advisory || strconv.Itoa(a.ID) == advisory) {
+				results[i] = append(results[i], AffectedProject{Project: projects[i], Advisory: a})
+			}
+		}
+		return nil
+	}, func(i int, err error) {
+		done++
+		switch {
+		case err != nil:
+			fmt.Fprint(output, i18n.T("org.remediate_progress_error", done, len(projects), projects[i].Slug, err))
+		case len(results[i]) == 0:
+			fmt.Fprint(output, i18n.T("org.remediate_progress_not_affected", done, len(projects), projects[i].Slug))
+		default:
+			fmt.Fprint(output, i18n.T("org.remediate_progress_affected", done, len(projects), projects[i].Slug, results[i][0].Advisory.Package.Name))
+		}
+	})
+
+	affected := []AffectedProject{}
+	for i := range projects {
+		affected = append(affected, results[i]...)
+	}
+	return affected, err
+}
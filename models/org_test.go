@@ -0,0 +1,160 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func OrgTestServer() *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/projects":
+			fmt.Fprintln(w, `{
+				"owned": [{"slug": "blog", "monitored": true}],
+				"acme": [
+					{"slug": "shop", "monitored": true, "labels": {"team": "payments"}},
+					{"slug": "legacy", "monitored": false},
+					{"slug": "api", "monitored": true}
+				],
+				"broken-org": [
+					{"slug": "broken", "monitored": true},
+					{"slug": "api", "monitored": true}
+				]
+			}`)
+		case "/projects/shop/dependencies":
+			fmt.Fprintln(w, `[
+				{"package": {"name": "lodash", "type": "npm"}, "locked": "4.17.4", "first_level": true, "color": "red"},
+				{"package": {"name": "react", "type": "npm"}, "locked": "16.0.0", "first_level": true}
+			]`)
+		case "/projects/api/dependencies":
+			fmt.Fprintln(w, `[
+				{"package": {"name": "express", "type": "npm"}, "locked": "4.16.0", "first_level": true},
+				{"package": {"name": "lodash", "type": "npm"}, "locked": "4.17.21", "color": "green"}
+			]`)
+		case "/projects/shop/alerts":
+			fmt.Fprintln(w, `[
+				{"id": 1, "status": "open", "advisory": {"id": 42, "identifier": "CVE-2021-23337", "cured_versions": ">=4.17.21", "package": {"name": "lodash", "type": "Npm"}}},
+				{"id": 2, "status": "closed", "advisory": {"id": 43, "identifier": "CVE-2020-8203", "package": {"name": "lodash", "type": "Npm"}}}
+			]`)
+		case "/projects/api/alerts":
+			fmt.Fprintln(w, `[{"id": 3, "status": "closed", "advisory": {"id": 42, "identifier": "CVE-2021-23337", "package": {"name": "lodash", "type": "Npm"}}}]`)
+		case "/projects/blog/dependencies":
+			fmt.Fprintln(w, `[{"package": {"name": "lodash", "type": "rubygem"}, "locked": "0.1.0", "first_level": true}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+}
+
+func TestFindPackageUsages(t *testing.T) {
+	ts := OrgTestServer()
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	projects, err := getOrgProjects("acme", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 2 {
+		t.Fatalf("Expected 2 monitored projects for acme, got: %#v", projects)
+	}
+
+	var output bytes.Buffer
+	usages, err := FindPackageUsages(projects, "lodash", "npm", false, &output)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(usages) != 2 {
+		t.Fatalf("Expected 2 usages of lodash, got: %#v", usages)
+	}
+	if usages[0].Project.Slug != "shop" || usages[0].LockedVersion != "4.17.4" || !usages[0].FirstLevel {
+		t.Errorf("Unexpected usage: %#v", usages[0])
+	}
+	if usages[1].Project.Slug != "api" || usages[1].LockedVersion != "4.17.21" || usages[1].FirstLevel {
+		t.Errorf("Unexpected usage: %#v", usages[1])
+	}
+	if !strings.Contains(output.String(), "/2] shop: lodash 4.17.4\n") {
+		t.Errorf("Expected progress for shop, got:\n%s", output.String())
+	}
+
+	if _, err := getOrgProjects("unknown", nil); err == nil {
+		t.Error("Expected an error for an unknown owner")
+	}
+
+	projects, err = getOrgProjects("", map[string]string{"team": "payments"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(projects) != 1 || projects[0].Slug != "shop" {
+		t.Errorf("Expected only shop to be labelled team=payments, got: %#v", projects)
+	}
+}
+
+func TestFindPackageUsagesWithFailures(t *testing.T) {
+	ts := OrgTestServer()
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	projects, err := getOrgProjects("broken-org", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// keep going: usages of the other projects are returned, with an error
+	var output bytes.Buffer
+	usages, err := FindPackageUsages(projects, "lodash", "", false, &output)
+	if err == nil || err.Error() != "1 of 2 projects failed" {
+		t.Errorf("Expected a summary of failures, got: %v", err)
+	}
+	if len(usages) != 1 || usages[0].Project.Slug != "api" {
+		t.Errorf("Expected the usage from api, got: %#v", usages)
+	}
+	if !strings.Contains(output.String(), "broken: error: ") {
+		t.Errorf("Expected the error of broken to be displayed, got:\n%s", output.String())
+	}
+
+	// fail fast
+	config.OrgConcurrency = 1
+	defer func() { config.OrgConcurrency = config.DEFAULT_ORG_CONCURRENCY }()
+	usages, err = FindPackageUsages(projects, "lodash", "", true, &output)
+	if err == nil || usages != nil {
+		t.Errorf("Expected an error and no usages, got: %v, %#v", err, usages)
+	}
+}
+
+func TestFindAffectedProjects(t *testing.T) {
+	ts := OrgTestServer()
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	projects, err := getOrgProjects("acme", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, advisory := range []string{"CVE-2021-23337", "42"} {
+		var output bytes.Buffer
+		affected, err := FindAffectedProjects(projects, advisory, false, &output)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(affected) != 1 || affected[0].Project.Slug != "shop" || affected[0].Advisory.CuredVersions != ">=4.17.21" {
+			t.Errorf("Expected only shop to be affected by %s, got: %#v", advisory, affected)
+		}
+		if !strings.Contains(output.String(), "/2] api: not affected\n") {
+			t.Errorf("Expected progress for api, got:\n%s", output.String())
+		}
+	}
+
+	// closed alerts don't count
+	affected, err := FindAffectedProjects(projects, "CVE-2020-8203", false, &bytes.Buffer{})
+	if err != nil || len(affected) != 0 {
+		t.Errorf("Expected no affected projects, got: %#v (%v)", affected, err)
+	}
+}
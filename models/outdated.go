@@ -0,0 +1,134 @@
+package models
+
+import (
+	"io"
+	"sync"
+	"time"
+
+	"github.com/gemnasium/toolbelt/registry"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// Max number of concurrent requests sent to the registries
+const REGISTRY_CONCURRENCY = 8
+
+// Period of the open-issue trends of outdated dependencies
+const ISSUE_TREND_PERIOD = 90 * 24 * time.Hour
+
+// Versions of a dependency, as known by its registry
+type registryVersions struct {
+	Versions []registry.Version
+	Err      error
+}
+
+// Fetch the versions of each dependency from the registries, concurrently.
+// Results are returned in the same order as deps.
+func fetchRegistryVersions(deps []Dependency) []registryVersions {
+	results := make([]registryVersions, len(deps))
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < REGISTRY_CONCURRENCY; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				versions, err := registry.Versions(deps[i].Package.Type, deps[i].Package.Name)
+				results[i] = registryVersions{versions, err}
+			}
+		}()
+	}
+	for i := range deps {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return results
+}
+
+// A dependency not locked to its latest release, with maintenance signals to
+// help judging the risk of the update.
+type OutdatedDependency struct {
+	Package       Package   `json:"package"`
+	LockedVersion string    `json:"locked"`
+	LatestVersion string    `json:"latest"`
+	LastRelease   time.Time `json:"last_release"`
+	Deprecated    string    `json:"deprecated,omitempty"`
+	// Issues opened and closed over ISSUE_TREND_PERIOD, when the package
+	// is hosted on GitHub
+	IssueTrend *registry.IssueTrend `json:"issue_trend,omitempty"`
+}
+
+// Fetch the open-issue trends of the outdated dependencies, concurrently.
+// Trends are best effort: they're left empty when the source repository of the
+// package is unknown, isn't on GitHub, or the API refuses the requests (ie:
+// rate limited).
+func fetchIssueTrends(outdated []OutdatedDependency) {
+	since := time.Now().Add(-ISSUE_TREND_PERIOD)
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for w := 0; w < REGISTRY_CONCURRENCY; w++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				repository, err := registry.Repository(outdated[i].Package.Type, outdated[i].Package.Name)
+				if err != nil || repository == "" {
+					continue
+				}
+				outdated[i].IssueTrend, _ = registry.FetchIssueTrend(repository, since)
+			}
+		}()
+	}
+	for i := range outdated {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+}
+
+// Return the dependencies which are not locked to their latest version.
+// Dependencies from unsupported registries are ignored.
+func OutdatedDependencies(deps []Dependency) []OutdatedDependency {
+	outdated := []OutdatedDependency{}
+	for i, rv := range fetchRegistryVersions(deps) {
+		if rv.Err != nil || deps[i].LockedVersion == "" {
+			continue
+		}
+		latest := registry.LatestVersion(rv.Versions)
+		if latest == nil || latest.Number == deps[i].LockedVersion {
+			continue
+		}
+		od := OutdatedDependency{
+			Package:       deps[i].Package,
+			LockedVersion: deps[i].LockedVersion,
+			LatestVersion: latest.Number,
+			LastRelease:   registry.LastRelease(rv.Versions).CreatedAt,
+		}
+		if locked := registry.FindVersion(rv.Versions, deps[i].LockedVersion); locked != nil {
+			od.Deprecated = locked.Deprecated
+		}
+		outdated = append(outdated, od)
+	}
+	fetchIssueTrends(outdated)
+	return outdated
+}
+
+func ListOutdatedDependencies(project *Project) error {
+	deps, err := project.Dependencies()
+	if err != nil {
+		return err
+	}
+	return RenderOutdatedAsTable(OutdatedDependencies(deps), Output)
+}
+
+func RenderOutdatedAsTable(outdated []OutdatedDependency, output io.Writer) error {
+	table := utils.NewTable(output, "Dependencies", "Locked", "Latest", "Last release", "Open issues (90d)", "Deprecated")
+	for _, od := range outdated {
+		trend := ""
+		if od.IssueTrend != nil {
+			trend = od.IssueTrend.String()
+		}
+		table.Append(od.Package.Name, od.LockedVersion, od.LatestVersion, od.LastRelease.Format("2006-01-02"), trend, od.Deprecated)
+	}
+	return table.Render()
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/registry"
+)
+
+func TestOutdatedDependencies(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/left-pad":
+			fmt.Fprintln(w, `{
+				"versions": {
+					"1.0.0": {"deprecated": "use String.prototype.padStart()"},
+					"1.3.0": {}
+				},
+				"repository": {"type": "git", "url": "git+https://github.com/stevemao/left-pad.git"},
+				"time": {"1.0.0": "2014-03-14T00:00:00.000Z", "1.3.0": "2018-04-09T00:00:00.000Z"}
+			}`)
+		case "/search/issues":
+			if q := r.URL.Query().Get("q"); strings.Contains(q, "created:") && strings.HasPrefix(q, "repo:stevemao/left-pad ") {
+				fmt.Fprintln(w, `{"total_count": 12}`)
+			} else {
+				fmt.Fprintln(w, `{"total_count": 4}`)
+			}
+		case "/lodash":
+			fmt.Fprintln(w, `{"versions": {"4.17.21": {}}, "time": {"4.17.21": "2021-02-20T00:00:00.000Z"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	registry.NpmURL = ts.URL
+	registry.GitHubURL = ts.URL
+	config.CacheDir = ""
+
+	deps := []Dependency{
+		Dependency{Package: Package{Name: "left-pad", Type: "npm"}, LockedVersion: "1.0.0"},
+		Dependency{Package: Package{Name: "lodash", Type: "npm"}, LockedVersion: "4.17.21"},
+		Dependency{Package: Package{Name: "unknown", Type: "npm"}, LockedVersion: "1.0.0"},
+	}
+	outdated := OutdatedDependencies(deps)
+	if len(outdated) != 1 {
+		t.Fatalf("Expected only left-pad to be outdated, got: %#v", outdated)
+	}
+	od := outdated[0]
+	if od.Package.Name != "left-pad" || od.LatestVersion != "1.3.0" || od.LastRelease.Year() != 2018 {
+		t.Errorf("Unexpected outdated dependency: %#v", od)
+	}
+	if od.Deprecated != "use String.prototype.padStart()" {
+		t.Errorf("left-pad 1.0.0 should be deprecated, got: '%s'", od.Deprecated)
+	}
+	if od.IssueTrend == nil || od.IssueTrend.String() != "+8" {
+		t.Errorf("Unexpected issue trend: %v", od.IssueTrend)
+	}
+}
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// CODEOWNERS-like file mapping packages and dependency files to their owners
+const OWNERS_FILE_PATH = ".gemnasium-owners"
+
+// Prefix of the patterns matching package names, instead of file paths
+const OWNERS_PACKAGE_PREFIX = "package:"
+
+type ownerRule struct {
+	pattern string
+	// The pattern matches package names (package:<glob>)
+	isPackage bool
+	owners    []string
+}
+
+// Owners of the packages and dependency files of the project. Each line of the
+// file is a pattern followed by owners (ie: "@payments"), the last matching
+// line wins:
+//
+//	# Files, like in CODEOWNERS (globs, "**" for any directory)
+//	apps/billing/**       @payments
+//	Gemfile.lock          @backend
+//	# Packages
+//	package:stripe        @payments
+//	package:@angular/*    @frontend
+//
+// A nil Owners has no rules.
+type Owners struct {
+	rules []ownerRule
+}
+
+// Load the owners file of the current directory. nil is returned if there's
+// none.
+func LoadOwners() (*Owners, error) {
+	f, err := os.Open(OWNERS_FILE_PATH)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return ParseOwners(f)
+}
+
+func ParseOwners(r io.Reader) (*Owners, error) {
+	owners := &Owners{}
+	scanner := bufio.NewScanner(r)
+	for n := 1; scanner.Scan(); n++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			return nil, fmt.Errorf("%s:%d: expected a pattern followed by owners", OWNERS_FILE_PATH, n)
+		}
+		rule := ownerRule{pattern: strings.TrimPrefix(fields[0], "/"), owners: fields[1:]}
+		if strings.HasPrefix(rule.pattern, OWNERS_PACKAGE_PREFIX) {
+			rule.pattern = strings.TrimPrefix(rule.pattern, OWNERS_PACKAGE_PREFIX)
+			rule.isPackage = true
+		}
+		owners.rules = append(owners.rules, rule)
+	}
+	return owners, scanner.Err()
+}
+
+// Patterns without slash match file names in any directory, like in
+// CODEOWNERS
+func (rule ownerRule) matchPath(filePath string) bool {
+	if !strings.Contains(rule.pattern, "/") {
+		return utils.MatchGlob(rule.pattern, path.Base(filePath))
+	}
+	return utils.MatchGlob(rule.pattern, filePath)
+}
+
+// Return the owners of the package, or of the dependency files it's declared
+// in (by the last matching rule), nil if there are none
+func (o *Owners) Of(packageName string, filePaths ...string) []string {
+	if o == nil {
+		return nil
+	}
+	for i := len(o.rules) - 1; i >= 0; i-- {
+		rule := o.rules[i]
+		if rule.isPackage {
+			if packageName != "" && utils.MatchGlob(rule.pattern, packageName) {
+				return rule.owners
+			}
+			continue
+		}
+		for _, p := range filePaths {
+			if rule.matchPath(p) {
+				return rule.owners
+			}
+		}
+	}
+	return nil
+}
+
+// Return true if owner (with or without "@") is among the owners of the
+// package or of the files
+func (o *Owners) Owns(owner, packageName string, filePaths ...string) bool {
+	for _, o := range o.Of(packageName, filePaths...) {
+		if strings.TrimPrefix(o, "@") == strings.TrimPrefix(owner, "@") {
+			return true
+		}
+	}
+	return false
+}
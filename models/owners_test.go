@@ -0,0 +1,49 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOwners(t *testing.T) {
+	owners, err := ParseOwners(strings.NewReader(`# Files
+Gemfile.lock          @backend
+/apps/billing/**      @payments
+# Packages
+package:stripe        @payments @security
+package:@angular/*    @frontend
+`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var tt = []struct {
+		pkg      string
+		paths    []string
+		expected []string
+	}{
+		{"rails", []string{"Gemfile.lock"}, []string{"@backend"}},
+		{"rails", []string{"apps/api/Gemfile.lock"}, []string{"@backend"}},
+		{"rails", []string{"apps/billing/Gemfile.lock"}, []string{"@payments"}},
+		{"stripe", []string{"Gemfile.lock"}, []string{"@payments", "@security"}},
+		{"@angular/core", nil, []string{"@frontend"}},
+		{"lodash", []string{"package.json"}, nil},
+	}
+	for _, test := range tt {
+		if result := owners.Of(test.pkg, test.paths...); !reflect.DeepEqual(result, test.expected) {
+			t.Errorf("Owners of %s %v: expected %v, got %v", test.pkg, test.paths, test.expected, result)
+		}
+	}
+	if !owners.Owns("security", "stripe") || owners.Owns("@backend", "stripe") {
+		t.Error("Expected stripe to be owned by @security only")
+	}
+
+	var none *Owners
+	if none.Of("rails", "Gemfile.lock") != nil {
+		t.Error("Expected no owners without owners file")
+	}
+
+	if _, err := ParseOwners(strings.NewReader("Gemfile.lock\n")); err == nil {
+		t.Error("Expected an error for a pattern without owners")
+	}
+}
@@ -0,0 +1,161 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A hunk of a unified diff: the old lines (context and removed) are replaced
+// with the new lines (context and added)
+type hunk struct {
+	oldStart int
+	oldLines []string
+	newLines []string
+	// "\ No newline at end of file" markers
+	oldNoEOL bool
+	newNoEOL bool
+}
+
+// Parse "@@ -l,s +l,s @@" hunk headers, returning the start line of the old
+// file
+func parseHunkHeader(line string) (int, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 || fields[0] != "@@" || !strings.HasPrefix(fields[1], "-") {
+		return 0, fmt.Errorf("Invalid hunk header: %s", line)
+	}
+	start := strings.SplitN(strings.TrimPrefix(fields[1], "-"), ",", 2)[0]
+	return strconv.Atoi(start)
+}
+
+// Parse the hunks of a unified diff, ignoring file headers and git extended
+// headers
+func parseUnifiedDiff(patch string) ([]hunk, error) {
+	hunks := []hunk{}
+	var current *hunk
+	var last byte
+	// The trailing newline of the patch isn't an empty context line
+	for _, line := range strings.Split(strings.TrimSuffix(patch, "\n"), "\n") {
+		switch {
+		case strings.HasPrefix(line, "@@"):
+			start, err := parseHunkHeader(line)
+			if err != nil {
+				return nil, err
+			}
+			hunks = append(hunks, hunk{oldStart: start})
+			current = &hunks[len(hunks)-1]
+			last = 0
+		case current == nil:
+			// Headers (---, +++, diff --git, index...)
+		case strings.HasPrefix(line, `\`):
+			if last == '-' {
+				current.oldNoEOL = true
+			} else {
+				current.newNoEOL = true
+				if last == ' ' {
+					current.oldNoEOL = true
+				}
+			}
+		case strings.HasPrefix(line, "---") || strings.HasPrefix(line, "diff "):
+			// Next file
+			current = nil
+		case line == "" || line[0] == ' ':
+			text := ""
+			if line != "" {
+				text = line[1:]
+			}
+			current.oldLines = append(current.oldLines, text)
+			current.newLines = append(current.newLines, text)
+			last = ' '
+		case line[0] == '-':
+			current.oldLines = append(current.oldLines, line[1:])
+			last = '-'
+		case line[0] == '+':
+			current.newLines = append(current.newLines, line[1:])
+			last = '+'
+		default:
+			return nil, fmt.Errorf("Invalid patch line: %s", line)
+		}
+	}
+	return hunks, nil
+}
+
+// Return the index of lines where want is found, from the index from, the
+// closest to around, or -1
+func findLines(lines, want []string, around, from int) int {
+	best := -1
+	for i := from; i+len(want) <= len(lines); i++ {
+		match := true
+		for j := range want {
+			if lines[i+j] != want[j] {
+				match = false
+				break
+			}
+		}
+		if match && (best == -1 || abs(i-around) < abs(best-around)) {
+			best = i
+		}
+	}
+	return best
+}
+
+func abs(n int) int {
+	if n < 0 {
+		return -n
+	}
+	return n
+}
+
+// Apply a unified diff to content, like the patch command does: hunks are
+// located by their context, so they may be shifted, but they must be in order
+// and match exactly (no fuzz). Hunks without old lines (zero context
+// insertions) are inserted after their start line.
+func applyUnifiedDiff(content []byte, patch string) ([]byte, error) {
+	hunks, err := parseUnifiedDiff(patch)
+	if err != nil {
+		return nil, err
+	}
+	if len(hunks) == 0 {
+		return nil, fmt.Errorf("No hunks found in patch")
+	}
+
+	text := string(content)
+	eol := text == "" || strings.HasSuffix(text, "\n")
+	lines := []string{}
+	if text != "" {
+		lines = strings.Split(strings.TrimSuffix(text, "\n"), "\n")
+	}
+	// Lines added by the previous hunks, and where the next hunk may start
+	offset, from := 0, 0
+	for n, h := range hunks {
+		var i int
+		if len(h.oldLines) == 0 {
+			i = h.oldStart + offset
+			if i < from || i > len(lines) {
+				i = -1
+			}
+		} else {
+			i = findLines(lines, h.oldLines, h.oldStart-1+offset, from)
+		}
+		if i == -1 {
+			return nil, fmt.Errorf("Hunk #%d can't be applied (line %d)", n+1, h.oldStart)
+		}
+		offset += len(h.newLines) - len(h.oldLines)
+		from = i + len(h.newLines)
+		patched := append([]string{}, lines[:i]...)
+		patched = append(patched, h.newLines...)
+		lines = append(patched, lines[i+len(h.oldLines):]...)
+		switch {
+		case h.newNoEOL:
+			eol = false
+		case h.oldNoEOL:
+			eol = true
+		}
+	}
+
+	result := strings.Join(lines, "\n")
+	if eol && len(lines) > 0 {
+		result += "\n"
+	}
+	return []byte(result), nil
+}
@@ -0,0 +1,115 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestApplyUnifiedDiff(t *testing.T) {
+	gemfile := "source 'https://rubygems.org'\n\ngem 'rails', '3.2.18'\ngem 'rake'\ngem 'pg'\n"
+	tests := []struct {
+		name     string
+		content  string
+		patch    string
+		expected string
+	}{
+		{
+			name:     "single hunk",
+			content:  gemfile,
+			patch:    "--- a/Gemfile\n+++ b/Gemfile\n@@ -1,3 +1,3 @@\n source 'https://rubygems.org'\n \n-gem 'rails', '3.2.18'\n+gem 'rails', '3.2.21'\n",
+			expected: "source 'https://rubygems.org'\n\ngem 'rails', '3.2.21'\ngem 'rake'\ngem 'pg'\n",
+		},
+		{
+			name:     "blank context line without leading space",
+			content:  gemfile,
+			patch:    "@@ -1,3 +1,3 @@\n source 'https://rubygems.org'\n\n-gem 'rails', '3.2.18'\n+gem 'rails', '3.2.21'\n",
+			expected: "source 'https://rubygems.org'\n\ngem 'rails', '3.2.21'\ngem 'rake'\ngem 'pg'\n",
+		},
+		{
+			name:     "shifted hunks",
+			content:  gemfile,
+			patch:    "@@ -1 +1,2 @@\n-gem 'rails', '3.2.18'\n+gem 'rails', '3.2.21'\n+gem 'puma'\n@@ -3 +4 @@\n-gem 'pg'\n+gem 'pg', '0.18.1'\n",
+			expected: "source 'https://rubygems.org'\n\ngem 'rails', '3.2.21'\ngem 'puma'\ngem 'rake'\ngem 'pg', '0.18.1'\n",
+		},
+		{
+			name:     "no newline at end of file",
+			content:  "gem 'rake'\ngem 'pg'",
+			patch:    "@@ -1,2 +1,2 @@\n gem 'rake'\n-gem 'pg'\n\\ No newline at end of file\n+gem 'pg', '0.18.1'\n",
+			expected: "gem 'rake'\ngem 'pg', '0.18.1'\n",
+		},
+		{
+			name:     "empty file",
+			content:  "",
+			patch:    "--- /dev/null\n+++ b/Gemfile\n@@ -0,0 +1 @@\n+gem 'rake'\n",
+			expected: "gem 'rake'\n",
+		},
+	}
+	for _, test := range tests {
+		result, err := applyUnifiedDiff([]byte(test.content), test.patch)
+		if err != nil {
+			t.Errorf("%s: %s", test.name, err)
+			continue
+		}
+		if string(result) != test.expected {
+			t.Errorf("%s: wrong result.\nExp:\n%s\nGot:\n%s", test.name, test.expected, result)
+		}
+	}
+}
+
+func TestApplyUnifiedDiffMismatch(t *testing.T) {
+	content := []byte("gem 'rails', '4.0.0'\n")
+	for _, patch := range []string{
+		"@@ -1 +1 @@\n-gem 'rails', '3.2.18'\n+gem 'rails', '3.2.21'\n",
+		"not a patch\n",
+		"@@ -1 +1 @@\n-gem 'rails', '4.0.0'\n*gem 'rails', '4.0.1'\n",
+	} {
+		if _, err := applyUnifiedDiff(content, patch); err == nil {
+			t.Errorf("Expected an error with patch:\n%s", patch)
+		}
+	}
+}
+
+func TestApplyUnifiedDiffZeroContextLikePatch(t *testing.T) {
+	if _, err := exec.LookPath("patch"); err != nil {
+		t.Skip("patch is required")
+	}
+	dir, err := ioutil.TempDir("", "gemnasium-patch")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+
+	// diff -U0 of a..e: insertions at the start, after b and after d, and a
+	// replacement of e, each hunk shifted by the previous ones
+	content := "a\nb\nc\nd\ne\n"
+	patch := "--- a/file\n+++ b/file\n" +
+		"@@ -0,0 +1 @@\n+X\n" +
+		"@@ -2,0 +4 @@\n+Y\n" +
+		"@@ -4,0 +7,2 @@\n+Z\n+Z\n" +
+		"@@ -5 +9 @@\n-e\n+E\n"
+	file := filepath.Join(dir, "file")
+	if err := ioutil.WriteFile(file, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	cmd := exec.Command("patch", "--quiet", file)
+	cmd.Stdin = strings.NewReader(patch)
+	if out, err := cmd.CombinedOutput(); err != nil {
+		t.Fatalf("patch: %s\n%s", err, out)
+	}
+	expected, _ := ioutil.ReadFile(file)
+	if string(expected) != "X\na\nb\nY\nc\nd\nZ\nZ\nE\n" {
+		t.Fatalf("Unexpected result of patch(1):\n%s", expected)
+	}
+
+	result, err := applyUnifiedDiff([]byte(content), patch)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(result) != string(expected) {
+		t.Errorf("Expected the result of patch(1):\n%s\nGot:\n%s", expected, result)
+	}
+}
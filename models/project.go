@@ -10,11 +10,13 @@ import (
 	"io/ioutil"
 	"log"
 	"net/http"
-	"os"
 	"strconv"
+	"strings"
+	"sync"
 
 	"github.com/gemnasium/toolbelt/config"
 	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/gemnasium/toolbelt/i18n"
 	"github.com/gemnasium/toolbelt/utils"
 	"github.com/olekukonko/tablewriter"
 	"github.com/wsxiaoys/terminal/color"
@@ -24,18 +26,21 @@ import (
 const (
 	LIST_PROJECTS_PATH  = "/projects"
 	CREATE_PROJECT_PATH = "/projects"
+
+	DEPENDENCY_FILES_PER_PAGE = 100
 )
 
 type Project struct {
-	Name              string `json:"name,omitempty"`
-	Slug              string `json:"slug,omitempty"`
-	Description       string `json:"description,omitempty"`
-	Origin            string `json:"origin,omitempty"`
-	Private           bool   `json:"private,omitempty"`
-	Color             string `json:"color,omitempty"`
-	Monitored         bool   `json:"monitored,omitempty"`
-	UnmonitoredReason string `json:"unmonitored_reason,omitempty"`
-	CommitSHA         string `json:"commit_sha"`
+	Name              string            `json:"name,omitempty"`
+	Slug              string            `json:"slug,omitempty"`
+	Description       string            `json:"description,omitempty"`
+	Origin            string            `json:"origin,omitempty"`
+	Private           bool              `json:"private,omitempty"`
+	Color             string            `json:"color,omitempty"`
+	Monitored         bool              `json:"monitored,omitempty"`
+	UnmonitoredReason string            `json:"unmonitored_reason,omitempty"`
+	CommitSHA         string            `json:"commit_sha"`
+	Labels            map[string]string `json:"labels,omitempty"`
 }
 
 // List projects on gemnasium
@@ -51,14 +56,16 @@ func ListProjects(privateProjectsOnly bool) error {
 	if err != nil {
 		return err
 	}
+	if config.Query != "" {
+		return utils.PrintQuery(Output, projects, config.Query)
+	}
 
 	for owner, _ := range projects {
 		MonitoredProjectsCount := 0
 		if owner != "owned" {
-			fmt.Printf("\nShared by: %s\n\n", owner)
+			fmt.Fprint(Output, i18n.T("projects.shared_by", owner))
 		}
-		table := tablewriter.NewWriter(os.Stdout)
-		table.SetHeader([]string{"Name", "Slug", "Private"})
+		table := utils.NewTable(Output, "Name", "Slug", "Private")
 		for _, project := range projects[owner] {
 			if !project.Monitored || (!project.Private && privateProjectsOnly) {
 				continue
@@ -70,11 +77,13 @@ func ListProjects(privateProjectsOnly bool) error {
 			} else {
 				private = ""
 			}
-			table.Append([]string{project.Name, project.Slug, private})
+			table.Append(project.Name, project.Slug, private)
 			MonitoredProjectsCount += 1
 		}
-		table.Render()
-		color.Printf("@{g!}Found %d projects (%d unmonitored are hidden)\n\n", MonitoredProjectsCount, len(projects[owner])-MonitoredProjectsCount)
+		if err := table.Render(); err != nil {
+			return err
+		}
+		fmt.Fprint(Output, color.Sprint("@{g!}"+i18n.T("projects.found", MonitoredProjectsCount, len(projects[owner])-MonitoredProjectsCount)))
 	}
 	return nil
 }
@@ -90,8 +99,8 @@ func (p *Project) Show() error {
 		return nil
 	}
 
-	color.Println(fmt.Sprintf("%s: %s\n", p.Name, utils.StatusDots(p.Color)))
-	table := tablewriter.NewWriter(os.Stdout)
+	fmt.Fprintln(Output, color.Sprintf("%s: %s\n", p.Name, utils.StatusDots(p.Color)))
+	table := tablewriter.NewWriter(Output)
 	table.SetRowLine(true)
 
 	table.Append([]string{"Slug", p.Slug})
@@ -102,6 +111,9 @@ func (p *Project) Show() error {
 	if !p.Monitored {
 		table.Append([]string{"Unmonitored reason", p.UnmonitoredReason})
 	}
+	if len(p.Labels) > 0 {
+		table.Append([]string{"Labels", strings.Join(p.LabelsList(), ", ")})
+	}
 
 	table.Render()
 	return nil
@@ -111,7 +123,7 @@ func (p *Project) Show() error {
 // http://docs.gemnasium.apiary.io/#patch-%2Fprojects%2F%7Bslug%7D
 func (p *Project) Update(name, desc *string, monitored *bool) error {
 	if name == nil && desc == nil && monitored == nil {
-		return errors.New("Please specify at least one thing to update (name, desc, or monitored")
+		return errors.New(i18n.T("projects.nothing_to_update"))
 	}
 
 	update := make(map[string]interface{})
@@ -134,40 +146,46 @@ func (p *Project) Update(name, desc *string, monitored *bool) error {
 		return err
 	}
 
-	color.Printf("@gProject %s updated succesfully\n", p.Slug)
+	fmt.Fprint(Output, color.Sprint("@g"+i18n.T("projects.updated", p.Slug)))
 	return nil
 }
 
 // Create a new project on gemnasium.
 // The first arg is used as the project name.
 // If no arg is provided, the user will be prompted to enter a project name.
+// The description is read from r when nil, unless in non-interactive mode.
 // http://docs.gemnasium.apiary.io/#post-%2Fprojects
-func CreateProject(projectName string, r io.Reader) error {
+func CreateProject(projectName string, description *string, r io.Reader) error {
 	project := &Project{Name: projectName}
 	if project.Name == "" {
-		fmt.Printf("Enter project name: ")
+		if config.NonInteractive {
+			return nonInteractiveError("the project name", "pass it as argument: gemnasium projects create <name>")
+		}
+		fmt.Fprint(Output, i18n.T("projects.enter_name"))
 		_, err := fmt.Scanln(&project.Name)
 		if err != nil {
 			return err
 		}
 	}
-	fmt.Printf("Enter project description: ")
-	scanner := bufio.NewScanner(r)
-	scanner.Scan()
-	project.Description = scanner.Text()
-	fmt.Println("") // quickfix for goconvey
+	if description != nil {
+		project.Description = *description
+	} else if !config.NonInteractive {
+		fmt.Fprint(Output, i18n.T("projects.enter_description"))
+		scanner := bufio.NewScanner(r)
+		scanner.Scan()
+		project.Description = scanner.Text()
+		fmt.Fprintln(Output, "") // quickfix for goconvey
+	}
 
 	projectAsJson, err := json.Marshal(project)
 	if err != nil {
 		return err
 	}
-	client := &http.Client{}
-	req, err := http.NewRequest("POST", config.APIEndpoint+CREATE_PROJECT_PATH, bytes.NewReader(projectAsJson))
+	client := utils.NewAPIClient()
+	req, err := utils.NewAPIRequest("POST", config.APIEndpoint+CREATE_PROJECT_PATH, config.APIKey, bytes.NewReader(projectAsJson))
 	if err != nil {
 		return err
 	}
-	req.SetBasicAuth("x", config.APIKey)
-	req.Header.Add("Content-Type", "application/json")
 	resp, err := client.Do(req)
 	if err != nil {
 		return err
@@ -187,15 +205,18 @@ func CreateProject(projectName string, r io.Reader) error {
 	if err := json.Unmarshal(body, &jsonResp); err != nil {
 		return err
 	}
-	fmt.Printf("Project '%s' created: https://gemnasium.com/%s (Remaining slots: %v)\n", project.Name, jsonResp["slug"], jsonResp["remaining_slot_count"])
-	fmt.Printf("To configure this project, use the following command:\ngemnasium configure %s\n", jsonResp["slug"])
+	fmt.Fprint(Output, i18n.T("projects.created", project.Name, jsonResp["slug"], jsonResp["remaining_slot_count"]))
+	fmt.Fprint(Output, i18n.T("projects.configure_hint", jsonResp["slug"]))
 	return nil
 }
 
 // Create a project config gile (.gemnasium.yml)
 func (p *Project) Configure(slug string, r io.Reader, w io.Writer) error {
 	if slug == "" {
-		fmt.Printf("Enter project slug: ")
+		if config.NonInteractive {
+			return nonInteractiveError("the project slug", "pass it as argument: gemnasium configure <project_slug>")
+		}
+		fmt.Fprint(Output, i18n.T("projects.enter_slug"))
 		_, err := fmt.Scanln(&slug)
 		if err != nil {
 			return err
@@ -213,7 +234,7 @@ func (p *Project) Configure(slug string, r io.Reader, w io.Writer) error {
 	if err != nil {
 		return err
 	}
-	color.Println("@gYour .gemnasium.yml was created!")
+	fmt.Fprintln(Output, color.Sprint("@g"+i18n.T("projects.config_created")))
 	return nil
 }
 
@@ -229,7 +250,7 @@ func (p *Project) Sync() error {
 		return err
 	}
 
-	color.Printf("@gSynchronization started for project %s\n", p.Slug)
+	fmt.Fprint(Output, color.Sprint("@g"+i18n.T("projects.sync_started", p.Slug)))
 	return nil
 }
 
@@ -253,14 +274,77 @@ func (p *Project) Dependencies() (deps []Dependency, err error) {
 }
 
 // Fetch and return the dependency files ([]DependecyFile) for the current project
-func (p *Project) DependencyFiles() (dfiles []DependencyFile, err error) {
-	opts := &gemnasium.APIRequestOptions{
-		Method: "GET",
-		URI:    fmt.Sprintf("/projects/%s/dependency_files", p.Slug),
-		Result: &dfiles,
+func (p *Project) DependencyFiles() ([]DependencyFile, error) {
+	return p.fetchDependencyFiles(func(done, total int) {})
+}
+
+// Dependency files are fetched by pages. The first page tells the number of
+// pages (X-Total-Pages header), the next ones are fetched concurrently
+// (config.OrgConcurrency at a time). progress is called after each page.
+func (p *Project) fetchDependencyFiles(progress func(done, total int)) ([]DependencyFile, error) {
+	fetchPage := func(page int) ([]DependencyFile, http.Header, error) {
+		var dfiles []DependencyFile
+		opts := &gemnasium.APIRequestOptions{
+			Method: "GET",
+			URI:    fmt.Sprintf("/projects/%s/dependency_files?page=%d&per_page=%d", p.Slug, page, DEPENDENCY_FILES_PER_PAGE),
+			Result: &dfiles,
+		}
+		err := gemnasium.APIRequest(opts)
+		return dfiles, opts.ResponseHeader, err
 	}
-	err = gemnasium.APIRequest(opts)
-	return dfiles, err
+
+	first, header, err := fetchPage(1)
+	if err != nil {
+		return nil, err
+	}
+	total, _ := strconv.Atoi(header.Get("X-Total-Pages"))
+	if total <= 1 {
+		progress(1, 1)
+		return first, nil
+	}
+	progress(1, total)
+
+	workers := config.OrgConcurrency
+	if workers < 1 {
+		workers = 1
+	}
+	pages := make([][]DependencyFile, total)
+	pages[0] = first
+	done := 1
+	var firstErr error
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, workers)
+	for page := 2; page <= total; page++ {
+		wg.Add(1)
+		sem <- struct{}{}
+		go func(page int) {
+			defer wg.Done()
+			defer func() { <-sem }()
+			dfiles, _, err := fetchPage(page)
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				if firstErr == nil {
+					firstErr = err
+				}
+				return
+			}
+			pages[page-1] = dfiles
+			done++
+			progress(done, total)
+		}(page)
+	}
+	wg.Wait()
+	if firstErr != nil {
+		return nil, firstErr
+	}
+
+	dfiles := []DependencyFile{}
+	for _, page := range pages {
+		dfiles = append(dfiles, page...)
+	}
+	return dfiles, nil
 }
 
 // Return a new Project with Slug set.
@@ -273,7 +357,7 @@ func GetProject(slugs ...string) (*Project, error) {
 		}
 	}
 	if slug == "" {
-		return nil, errors.New("[project slug] can't be empty")
+		return nil, errors.New(i18n.T("projects.slug_empty"))
 	}
 	return &Project{Slug: slug}, nil
 }
@@ -0,0 +1,120 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+)
+
+// A project dependency files are pushed to, and the directory of these files
+// ("." for the whole tree). Paths sent to the project are relative to Dir.
+type pushTarget struct {
+	Slug string
+	Dir  string
+}
+
+// Return the path of the file relative to the directory of the target
+func (t pushTarget) relPath(localPath string) string {
+	p := filepath.ToSlash(filepath.Clean(localPath))
+	if t.Dir == "." {
+		return p
+	}
+	return strings.TrimPrefix(p, t.Dir+"/")
+}
+
+// Return the local path of a file of the target project
+func (t pushTarget) localPath(relPath string) string {
+	return filepath.FromSlash(path.Join(t.Dir, relPath))
+}
+
+func (t pushTarget) contains(localPath string) bool {
+	p := filepath.ToSlash(filepath.Clean(localPath))
+	return t.Dir == "." || strings.HasPrefix(p, t.Dir+"/")
+}
+
+// Return the targets of a push: the projects mapped to subdirectories
+// (config.ProjectMappings), deepest directories first, and projectSlug for
+// the other files (if not empty).
+func pushTargets(projectSlug string) []pushTarget {
+	targets := []pushTarget{}
+	for dir, slug := range config.ProjectMappings {
+		dir = strings.Trim(path.Clean(filepath.ToSlash(dir)), "/")
+		if dir == "" {
+			dir = "."
+		}
+		targets = append(targets, pushTarget{Slug: slug, Dir: dir})
+	}
+	sort.Slice(targets, func(i, j int) bool {
+		if len(targets[i].Dir) != len(targets[j].Dir) {
+			return len(targets[i].Dir) > len(targets[j].Dir)
+		}
+		return targets[i].Dir < targets[j].Dir
+	})
+	if projectSlug != "" {
+		targets = append(targets, pushTarget{Slug: projectSlug, Dir: "."})
+	}
+	return targets
+}
+
+type targetFiles struct {
+	target pushTarget
+	files  []*DependencyFile
+}
+
+// Route each file to the target of the deepest directory containing it.
+// Files outside of the mapped directories are skipped if there's no default
+// project.
+func groupByTarget(dfiles []*DependencyFile, targets []pushTarget) ([]targetFiles, error) {
+	if len(targets) == 0 {
+		return nil, errors.New(i18n.T("projects.slug_empty"))
+	}
+	groups := make([]targetFiles, len(targets))
+	for i, t := range targets {
+		groups[i].target = t
+	}
+	for _, df := range dfiles {
+		found := false
+		for i, t := range targets {
+			if t.contains(df.Path) {
+				groups[i].files = append(groups[i].files, df)
+				found = true
+				break
+			}
+		}
+		if !found {
+			fmt.Fprint(os.Stderr, i18n.T("df.no_project", df.Path))
+		}
+	}
+	nonEmpty := []targetFiles{}
+	for _, g := range groups {
+		if len(g.files) > 0 {
+			nonEmpty = append(nonEmpty, g)
+		}
+	}
+	return nonEmpty, nil
+}
+
+// Add the files of r, pushed from dir, to the result
+func (result *PushResult) merge(r *PushResult, dir string) {
+	t := pushTarget{Dir: dir}
+	local := func(dfiles []PushedDependencyFile) []PushedDependencyFile {
+		for i := range dfiles {
+			dfiles[i].Path = t.localPath(dfiles[i].Path)
+		}
+		return dfiles
+	}
+	result.Added = append(result.Added, local(r.Added)...)
+	result.Updated = append(result.Updated, local(r.Updated)...)
+	result.Unchanged = append(result.Unchanged, local(r.Unchanged)...)
+	result.Unsupported = append(result.Unsupported, local(r.Unsupported)...)
+	for _, rn := range r.Renamed {
+		result.Renamed = append(result.Renamed, Rename{From: t.localPath(rn.From), To: t.localPath(rn.To)})
+	}
+}
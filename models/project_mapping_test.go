@@ -0,0 +1,78 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"sort"
+	"sync"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestPushDependencyFilesWithProjectMappings(t *testing.T) {
+	var mu sync.Mutex
+	pushed := map[string][]string{}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "GET" {
+			fmt.Fprintln(w, `[]`)
+			return
+		}
+		var dfiles []DependencyFile
+		json.NewDecoder(r.Body).Decode(&dfiles)
+		mu.Lock()
+		for _, df := range dfiles {
+			pushed[r.URL.Path] = append(pushed[r.URL.Path], df.Path)
+		}
+		mu.Unlock()
+		json.NewEncoder(w).Encode(map[string][]DependencyFile{"added": dfiles})
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	config.ProjectMappings = map[string]string{"services/api": "org/api", "./services/web/": "org/web"}
+	defer func() { config.ProjectMappings = map[string]string{} }()
+
+	localFiles := getLocalDependencyFiles
+	defer func() { getLocalDependencyFiles = localFiles }()
+	getLocalDependencyFiles = func() ([]*DependencyFile, error) {
+		return []*DependencyFile{
+			&DependencyFile{Path: "Gemfile", SHA: "Gemfile SHA-1"},
+			&DependencyFile{Path: "services/api/Gemfile.lock", SHA: "Gemfile.lock SHA-1", Content: []byte("GEM\n  specs:\n\nDEPENDENCIES\n")},
+			&DependencyFile{Path: "services/web/package.json", SHA: "package.json SHA-1", Content: []byte(`{}`)},
+			&DependencyFile{Path: "services/web/admin/package.json", SHA: "admin package.json SHA-1", Content: []byte(`{}`)},
+			&DependencyFile{Path: "services/api-docs/package.json", SHA: "docs package.json SHA-1", Content: []byte(`{}`)},
+		}, nil
+	}
+
+	result, err := PushDependencyFiles(context.Background(), "org/main", []string{}, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string][]string{
+		"/projects/org/api/dependency_files":  {"Gemfile.lock"},
+		"/projects/org/web/dependency_files":  {"package.json", "admin/package.json"},
+		"/projects/org/main/dependency_files": {"Gemfile", "services/api-docs/package.json"},
+	}
+	if !reflect.DeepEqual(pushed, expected) {
+		t.Errorf("Expected pushed files: %v, got: %v", expected, pushed)
+	}
+	added := pushedPaths(result.Added)
+	sort.Strings(added)
+	expectedAdded := []string{"Gemfile", "services/api-docs/package.json", "services/api/Gemfile.lock", "services/web/admin/package.json", "services/web/package.json"}
+	if !reflect.DeepEqual(added, expectedAdded) {
+		t.Errorf("Expected added files: %v, got: %v", expectedAdded, added)
+	}
+
+	// Without default project, files outside of the mapped directories are skipped
+	pushed = map[string][]string{}
+	if _, err := PushDependencyFiles(context.Background(), "", []string{}, true); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := pushed["/projects/org/main/dependency_files"]; ok || len(pushed) != 2 {
+		t.Errorf("Only mapped projects should receive files, got: %v", pushed)
+	}
+}
@@ -5,7 +5,6 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
 	"net/http"
 	"net/http/httptest"
@@ -66,7 +65,7 @@ func TestCreateProject(t *testing.T) {
 	config.APIEndpoint = ts.URL
 	config.APIKey = apiKey
 	r := strings.NewReader("Project description\n")
-	err := CreateProject("test_project", r)
+	err := CreateProject("test_project", nil, r)
 	if err != nil {
 		t.Error(err)
 	}
@@ -82,7 +81,7 @@ func TestCreateProjectWithWrongToken(t *testing.T) {
 	config.APIEndpoint = ts.URL
 	config.APIKey = "invalid_key"
 	r := strings.NewReader("Project description\n")
-	err := CreateProject("test_project", r)
+	err := CreateProject("test_project", nil, r)
 	if err.Error() != "Server returned non-200 status: 401 Unauthorized\n" {
 		t.Error(err)
 	}
@@ -114,14 +113,10 @@ func TestSyncProject(t *testing.T) {
 	}))
 	defer ts.Close()
 	config.APIEndpoint = ts.URL
-	// silent stdout
-	old := os.Stdout
-	_, w, _ := os.Pipe()
-	w.Close()
-	os.Stdout = w
+	Output = ioutil.Discard
+	defer func() { Output = os.Stdout }()
 	p := &Project{Slug: "blah"}
 	err := p.Sync()
-	os.Stdout = old
 	if err != nil {
 		t.Errorf("SyncProject failed with err: %s", err)
 	}
@@ -144,9 +139,9 @@ func TestUpdateProject(t *testing.T) {
 		fmt.Fprintln(w, jsonOutput)
 	}))
 	defer ts.Close()
-	old := os.Stdout // keep backup of the real stdout
-	r, w, _ := os.Pipe()
-	os.Stdout = w
+	var buf bytes.Buffer
+	Output = &buf
+	defer func() { Output = os.Stdout }()
 	config.APIEndpoint = ts.URL
 	var name, desc *string
 	var monitored *bool
@@ -161,10 +156,6 @@ func TestUpdateProject(t *testing.T) {
 	if err != nil {
 		t.Fatal(err)
 	}
-	w.Close()
-	var buf bytes.Buffer
-	io.Copy(&buf, r)
-	os.Stdout = old // restoring the real stdout
 
 	expectedOutput := color.Sprintf("@gProject %s updated succesfully\n", "blah")
 	if buf.String() != expectedOutput {
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"time"
+)
+
+const (
+	READ_FILE_ATTEMPTS = 3
+	READ_FILE_DELAY    = 100 * time.Millisecond
+)
+
+// Read the file, retrying a few times on transient errors (ie: the file is
+// locked by another process on Windows). The content of Git LFS pointers is
+// fetched with git-lfs.
+func readFile(filePath string) ([]byte, error) {
+	var content []byte
+	var err error
+	for i := 0; i < READ_FILE_ATTEMPTS; i++ {
+		content, err = ioutil.ReadFile(filePath)
+		if err == nil || !isTransientReadError(err) {
+			break
+		}
+		time.Sleep(READ_FILE_DELAY)
+	}
+	if err == nil && isLFSPointer(content) {
+		return lfsSmudge(filePath, content)
+	}
+	return content, err
+}
+
+// Read the file once, computing its SHA (see CurrentVCS) while it's read, with
+// the same retries and LFS handling as readFile. The content is only kept if the file
+// isn't bigger than maxSize (no limit if 0, never kept if negative, except
+// for possible LFS pointers): bigger files are streamed through the hash, and
+// nil content is returned (see DependencyFile.LoadContent).
+func readFileSHA1(filePath string, maxSize int64) (content []byte, sha string, size int64, err error) {
+	for i := 0; i < READ_FILE_ATTEMPTS; i++ {
+		content, sha, size, err = hashFile(filePath, maxSize)
+		if err == nil || !isTransientReadError(err) {
+			break
+		}
+		time.Sleep(READ_FILE_DELAY)
+	}
+	if err == nil && isLFSPointer(content) {
+		if content, err = lfsSmudge(filePath, content); err != nil {
+			return nil, "", 0, err
+		}
+		return content, ContentSHA1(content), int64(len(content)), nil
+	}
+	return content, sha, size, err
+}
+
+func hashFile(filePath string, maxSize int64) ([]byte, string, int64, error) {
+	f, err := os.Open(filePath)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	defer f.Close()
+	info, err := f.Stat()
+	if err != nil {
+		return nil, "", 0, err
+	}
+	size := info.Size()
+
+	// The size is part of some headers (ie: git blob), so it must be known
+	// before hashing the content
+	h := CurrentVCS().NewHash(size)
+	var buf *bytes.Buffer
+	var r io.Reader = f
+	if maxSize == 0 || size <= maxSize || size < LFS_POINTER_MAX_SIZE {
+		buf = bytes.NewBuffer(make([]byte, 0, size))
+		r = io.TeeReader(f, buf)
+	}
+	n, err := io.Copy(h, r)
+	if err != nil {
+		return nil, "", 0, err
+	}
+	if n != size {
+		return nil, "", 0, fmt.Errorf("%s: size changed while reading (%d bytes, %d read)", filePath, size, n)
+	}
+	sha := fmt.Sprintf("%x", h.Sum(nil))
+	if buf == nil {
+		return nil, sha, size, nil
+	}
+	return buf.Bytes(), sha, size, nil
+}
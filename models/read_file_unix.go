@@ -0,0 +1,16 @@
+//go:build !windows
+// +build !windows
+
+package models
+
+import (
+	"os"
+	"syscall"
+)
+
+func isTransientReadError(err error) bool {
+	if pe, ok := err.(*os.PathError); ok {
+		return pe.Err == syscall.EBUSY
+	}
+	return false
+}
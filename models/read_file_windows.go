@@ -0,0 +1,22 @@
+//go:build windows
+// +build windows
+
+package models
+
+import (
+	"os"
+	"syscall"
+)
+
+const (
+	errorSharingViolation syscall.Errno = 32
+	errorLockViolation    syscall.Errno = 33
+)
+
+// Files being written by editors or build tools are often locked on Windows
+func isTransientReadError(err error) bool {
+	if pe, ok := err.(*os.PathError); ok {
+		return pe.Err == errorSharingViolation || pe.Err == errorLockViolation || pe.Err == syscall.EBUSY
+	}
+	return false
+}
@@ -0,0 +1,109 @@
+package models
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"os/exec"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/gemnasium/toolbelt/i18n"
+)
+
+// Fetch the dependency file known by the server for the project, with its
+// content
+func (p *Project) DependencyFile(path string) (*DependencyFile, error) {
+	dfiles, err := p.DependencyFiles()
+	if err != nil {
+		return nil, err
+	}
+	for _, df := range dfiles {
+		if df.Path != path {
+			continue
+		}
+		if len(df.Content) == 0 {
+			opts := &gemnasium.APIRequestOptions{
+				Method: "GET",
+				URI:    fmt.Sprintf("/projects/%s/dependency_files/%s", p.Slug, df.SHA),
+				Result: &df,
+			}
+			if err := gemnasium.APIRequest(opts); err != nil {
+				return nil, err
+			}
+		}
+		return &df, nil
+	}
+	return nil, fmt.Errorf("%s is unknown on Gemnasium for project %s", path, p.Slug)
+}
+
+// Lambda to be overriden in tests
+var confirmRestore = askConfirmation
+
+// Display the differences between the local file and content, using diff.
+// Nothing is displayed if diff is not available.
+func printDiff(path string, content []byte) {
+	local, _ := readFile(path)
+	fmt.Fprint(Output, Diff(path+" (local)", path+" (gemnasium)", local, content))
+}
+
+// Return the unified diff between from and to, using diff. An empty string is
+// returned if diff is not available.
+func Diff(fromLabel, toLabel string, from, to []byte) string {
+	diffPath, err := exec.LookPath("diff")
+	if err != nil {
+		return ""
+	}
+	paths := []string{}
+	for _, content := range [][]byte{from, to} {
+		tmp, err := ioutil.TempFile("", "gemnasium-diff")
+		if err != nil {
+			return ""
+		}
+		defer os.Remove(tmp.Name())
+		tmp.Write(content)
+		tmp.Close()
+		paths = append(paths, tmp.Name())
+	}
+	out, _ := exec.Command(diffPath, "-u", "--label", fromLabel, "--label", toLabel, paths[0], paths[1]).Output()
+	return string(out)
+}
+
+// Replace the local dependency file with the last content known by
+// Gemnasium, after displaying the changes and asking for confirmation (unless
+// assumeYes is set).
+func RestoreDependencyFile(project *Project, path string, assumeYes bool) error {
+	remote, err := project.DependencyFile(path)
+	if err != nil {
+		return err
+	}
+
+	mode := os.FileMode(0644)
+	if info, err := os.Stat(path); err == nil {
+		mode = info.Mode()
+		if local, err := readFile(path); err == nil && bytes.Equal(local, remote.Content) {
+			fmt.Fprint(Output, i18n.T("df.restore_up_to_date", path))
+			return nil
+		}
+	}
+
+	printDiff(path, remote.Content)
+	if !assumeYes && config.NonInteractive {
+		return nonInteractiveError("a confirmation", "pass --yes to overwrite the local file")
+	}
+	if !assumeYes && !confirmRestore() {
+		return errors.New(i18n.T("df.restore_aborted"))
+	}
+	if err := ioutil.WriteFile(path, remote.Content, mode); err != nil {
+		return err
+	}
+	if remote.SHA != "" {
+		if err := remote.CheckFileSHA1(); err != nil {
+			return err
+		}
+	}
+	fmt.Fprint(Output, i18n.T("df.restored", path, remote.SHA))
+	return nil
+}
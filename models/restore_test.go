@@ -0,0 +1,60 @@
+package models
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestRestoreDependencyFile(t *testing.T) {
+	content := "GEM\n  remote: https://rubygems.org/\n  specs:\n    rake (10.4.2)\n"
+	// git blob SHA1 of content
+	sha := "1e8f0ef6df2b9d9ef4e2a8fac09c1b1a8806ecd1"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/projects/blog/dependency_files":
+			fmt.Fprintf(w, `[{"path": "Gemfile.lock", "sha": "%s"}]`, sha)
+		case "/projects/blog/dependency_files/" + sha:
+			fmt.Fprintf(w, `{"path": "Gemfile.lock", "sha": "%s", "content": "R0VNCiAgcmVtb3RlOiBodHRwczovL3J1YnlnZW1zLm9yZy8KICBzcGVjczoKICAgIHJha2UgKDEwLjQuMikK"}`, sha)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	inTempDir(t, map[string]string{"Gemfile.lock": "<<<<<<< HEAD\n"}, func() {
+
+		confirmRestore = func() bool { return false }
+		if err := RestoreDependencyFile(&Project{Slug: "blog"}, "Gemfile.lock", false); err == nil {
+			t.Error("Expected restore to be aborted")
+		}
+
+		confirmRestore = func() bool { return true }
+		defer func() { confirmRestore = askConfirmation }()
+		config.NonInteractive = true
+		err := RestoreDependencyFile(&Project{Slug: "blog"}, "Gemfile.lock", false)
+		config.NonInteractive = false
+		if err == nil || !strings.Contains(err.Error(), "--yes") {
+			t.Errorf("Expected an error telling to pass --yes in non-interactive mode, got: %v", err)
+		}
+
+		if err := RestoreDependencyFile(&Project{Slug: "blog"}, "Gemfile.lock", false); err != nil {
+			t.Fatal(err)
+		}
+		restored, _ := ioutil.ReadFile("Gemfile.lock")
+		if string(restored) != content {
+			t.Errorf("Unexpected content after restore: %q", restored)
+		}
+
+		if err := RestoreDependencyFile(&Project{Slug: "blog"}, "Gemfile", true); err == nil {
+			t.Error("Expected an error for a file unknown on Gemnasium")
+		}
+	})
+}
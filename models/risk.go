@@ -0,0 +1,188 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"sort"
+	"strconv"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/registry"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+const (
+	// Max risk score
+	RISK_MAX = 10.0
+	// CVSS score assumed for advisories without one (medium severity)
+	RISK_DEFAULT_CVSS = 5.0
+	// Lag (in libyears) at which the freshness signal is maxed out
+	RISK_MAX_LIBYEARS = 3.0
+	// Packages without release for longer than this are considered
+	// unmaintained
+	RISK_STALE_PACKAGE = 2 * year
+)
+
+// Signals of the risk of a dependency: the highest CVSS (0-10) and EPSS
+// (0-1) scores of its advisories, its freshness and maintenance status
+type RiskSignals struct {
+	CVSS     float64 `json:"cvss"`
+	EPSS     float64 `json:"epss"`
+	Libyears float64 `json:"libyears"`
+	// deprecated, abandoned, yanked or stale (no release for 2 years)
+	Maintenance string `json:"maintenance,omitempty"`
+	// 1 for direct runtime dependencies, 0.7 for indirect ones, 0.3 for
+	// development ones: an approximation of how likely the code is reached
+	Reachability float64 `json:"reachability"`
+}
+
+type DependencyRisk struct {
+	Package       Package     `json:"package"`
+	LockedVersion string      `json:"locked"`
+	Score         float64     `json:"score"`
+	Signals       RiskSignals `json:"signals"`
+}
+
+var maintenanceRisks = map[string]float64{
+	DEPENDENCY_YANKED:     1,
+	DEPENDENCY_ABANDONED:  1,
+	DEPENDENCY_DEPRECATED: 0.7,
+	"stale":               0.5,
+}
+
+// Combine the signals into a score between 0 and 10, with the weights of
+// config.RiskWeights (between 0 and 1): each signal, scaled by its weight,
+// adds to the risk left by the others (1 - (1 - w1*s1) * (1 - w2*s2)...), so
+// a single critical signal is enough to score high. The score is then scaled
+// down for dependencies less likely to be reached, by up to the reachability
+// weight.
+func RiskScore(s RiskSignals, weights map[string]float64) float64 {
+	signals := map[string]float64{
+		"cvss":        s.CVSS / 10,
+		"epss":        s.EPSS,
+		"freshness":   math.Min(s.Libyears/RISK_MAX_LIBYEARS, 1),
+		"maintenance": maintenanceRisks[s.Maintenance],
+	}
+	weight := func(signal string) float64 {
+		return math.Max(0, math.Min(weights[signal], 1))
+	}
+	safe := 1.0
+	for signal, value := range signals {
+		safe *= 1 - weight(signal)*value
+	}
+	score := RISK_MAX * (1 - safe) * (1 - weight("reachability")*(1-s.Reachability))
+	return math.Round(score*10) / 10
+}
+
+// Compute the risk of the given dependencies, riskiest first. Freshness and
+// maintenance signals come from the registries: they're left empty for
+// unsupported ones.
+func ComputeRisks(deps []Dependency) []DependencyRisk {
+	risks := []DependencyRisk{}
+	for i, rv := range fetchRegistryVersions(deps) {
+		dep := deps[i]
+		risk := DependencyRisk{Package: dep.Package, LockedVersion: dep.LockedVersion}
+		s := &risk.Signals
+
+		for _, a := range dep.Advisories {
+			cvss := a.CVSSScore
+			if cvss == 0 {
+				cvss = RISK_DEFAULT_CVSS
+			}
+			s.CVSS = math.Max(s.CVSS, cvss)
+			s.EPSS = math.Max(s.EPSS, a.EPSSScore)
+		}
+
+		switch {
+		case dep.Type == "development":
+			s.Reachability = 0.3
+		case dep.FirstLevel:
+			s.Reachability = 1
+		default:
+			s.Reachability = 0.7
+		}
+
+		if rv.Err == nil && dep.LockedVersion != "" {
+			locked := registry.FindVersion(rv.Versions, dep.LockedVersion)
+			latest := registry.LatestVersion(rv.Versions)
+			last := registry.LastRelease(rv.Versions)
+			switch {
+			case locked == nil || locked.Yanked:
+				s.Maintenance = DEPENDENCY_YANKED
+			case locked.Deprecated == DEPENDENCY_ABANDONED:
+				s.Maintenance = DEPENDENCY_ABANDONED
+			case locked.Deprecated != "":
+				s.Maintenance = DEPENDENCY_DEPRECATED
+			case last != nil && time.Since(last.CreatedAt) > RISK_STALE_PACKAGE:
+				s.Maintenance = "stale"
+			}
+			if locked != nil && latest != nil {
+				if lag := latest.CreatedAt.Sub(locked.CreatedAt); lag > 0 {
+					s.Libyears = math.Round(float64(lag)/float64(year)*100) / 100
+				}
+			}
+		}
+
+		risk.Score = RiskScore(risk.Signals, config.RiskWeights)
+		risks = append(risks, risk)
+	}
+	sort.SliceStable(risks, func(i, j int) bool {
+		return risks[i].Score > risks[j].Score
+	})
+	return risks
+}
+
+// Display the risk of the project dependencies, as a table or as json
+// (format)
+func ReportRisk(project *Project, format string) error {
+	deps, err := project.Dependencies()
+	if err != nil {
+		return err
+	}
+	risks := ComputeRisks(deps)
+	switch format {
+	case "json":
+		return utils.PrintJSON(Output, risks)
+	case "table", "":
+		return RenderRisksAsTable(risks, Output)
+	}
+	return fmt.Errorf("Unknown format: %s", format)
+}
+
+// Display the dependencies of the project whose risk score is above maxRisk,
+// and return an error if there are any
+func CheckRisk(project *Project, maxRisk float64) error {
+	deps, err := project.Dependencies()
+	if err != nil {
+		return err
+	}
+	over := []DependencyRisk{}
+	for _, risk := range ComputeRisks(deps) {
+		if risk.Score > maxRisk {
+			over = append(over, risk)
+		}
+	}
+	if len(over) == 0 {
+		fmt.Fprint(Output, i18n.T("report.no_risk_above", maxRisk))
+		return nil
+	}
+	if err := RenderRisksAsTable(over, Output); err != nil {
+		return err
+	}
+	return errors.New(i18n.T("report.risk_above", len(over), maxRisk))
+}
+
+func RenderRisksAsTable(risks []DependencyRisk, output io.Writer) error {
+	table := utils.NewTable(output, "Dependencies", "Locked", "Risk", "CVSS", "EPSS", "Libyears", "Maintenance")
+	for _, r := range risks {
+		s := r.Signals
+		table.Append(r.Package.Name, r.LockedVersion, strconv.FormatFloat(r.Score, 'f', 1, 64),
+			strconv.FormatFloat(s.CVSS, 'f', 1, 64), strconv.FormatFloat(s.EPSS, 'f', 2, 64),
+			strconv.FormatFloat(s.Libyears, 'f', 2, 64), s.Maintenance)
+	}
+	return table.Render()
+}
@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestRiskScore(t *testing.T) {
+	var tt = []struct {
+		signals  RiskSignals
+		expected float64
+	}{
+		{RiskSignals{CVSS: 9.8, Reachability: 1}, 9.8},
+		{RiskSignals{CVSS: 9.8, Reachability: 0.3}, 6.4},
+		{RiskSignals{Libyears: 4, Maintenance: DEPENDENCY_ABANDONED, Reachability: 1}, 7.2},
+		{RiskSignals{CVSS: 5, EPSS: 0.5, Reachability: 1}, 6.5},
+		{RiskSignals{Reachability: 1}, 0},
+	}
+	for _, test := range tt {
+		if score := RiskScore(test.signals, config.RiskWeights); score != test.expected {
+			t.Errorf("Risk of %+v: expected %.1f, got %.1f", test.signals, test.expected, score)
+		}
+	}
+
+	if score := RiskScore(RiskSignals{CVSS: 9.8, Reachability: 1}, map[string]float64{"cvss": 0}); score != 0 {
+		t.Errorf("Expected a zero score when cvss is ignored, got %.1f", score)
+	}
+}
+
+func TestComputeRisks(t *testing.T) {
+	// No registry for these packages: only advisories and reachability count
+	deps := []Dependency{
+		{Package: Package{Name: "serde", Type: "cargo"}, LockedVersion: "1.0.0", FirstLevel: true},
+		{Package: Package{Name: "rand", Type: "cargo"}, LockedVersion: "0.5.0", Advisories: []Advisory{{ID: 1}}},
+		{Package: Package{Name: "tokio", Type: "cargo"}, LockedVersion: "0.1.0", FirstLevel: true, Advisories: []Advisory{{ID: 2, CVSSScore: 7.5, EPSSScore: 0.2}, {ID: 3, CVSSScore: 4}}},
+	}
+	risks := ComputeRisks(deps)
+	if len(risks) != 3 {
+		t.Fatalf("Expected 3 risks, got %d", len(risks))
+	}
+	names := []string{risks[0].Package.Name, risks[1].Package.Name, risks[2].Package.Name}
+	if names[0] != "tokio" || names[1] != "rand" || names[2] != "serde" {
+		t.Errorf("Expected riskiest dependencies first, got %v", names)
+	}
+	if s := risks[0].Signals; s.CVSS != 7.5 || s.EPSS != 0.2 || s.Reachability != 1 {
+		t.Errorf("Expected the highest scores of the advisories, got %+v", s)
+	}
+	if s := risks[1].Signals; s.CVSS != RISK_DEFAULT_CVSS || s.Reachability != 0.7 {
+		t.Errorf("Expected the default CVSS score for an indirect dependency, got %+v", s)
+	}
+}
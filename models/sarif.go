@@ -0,0 +1,217 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// See https://docs.oasis-open.org/sarif/sarif/v2.1.0/sarif-v2.1.0.html
+const (
+	SARIF_VERSION = "2.1.0"
+	SARIF_SCHEMA  = "https://json.schemastore.org/sarif-2.1.0.json"
+)
+
+type SARIFLog struct {
+	Version string     `json:"version"`
+	Schema  string     `json:"$schema"`
+	Runs    []SARIFRun `json:"runs"`
+}
+
+type SARIFRun struct {
+	Tool    SARIFTool     `json:"tool"`
+	Results []SARIFResult `json:"results"`
+}
+
+type SARIFTool struct {
+	Driver SARIFDriver `json:"driver"`
+}
+
+type SARIFDriver struct {
+	Name           string      `json:"name"`
+	Version        string      `json:"version"`
+	InformationURI string      `json:"informationUri"`
+	Rules          []SARIFRule `json:"rules"`
+}
+
+type SARIFRule struct {
+	ID               string            `json:"id"`
+	ShortDescription SARIFMessage      `json:"shortDescription"`
+	FullDescription  SARIFMessage      `json:"fullDescription"`
+	HelpURI          string            `json:"helpUri,omitempty"`
+	Properties       map[string]string `json:"properties"`
+}
+
+type SARIFMessage struct {
+	Text string `json:"text"`
+}
+
+type SARIFResult struct {
+	RuleID    string          `json:"ruleId"`
+	Level     string          `json:"level"`
+	Message   SARIFMessage    `json:"message"`
+	Locations []SARIFLocation `json:"locations"`
+}
+
+type SARIFLocation struct {
+	PhysicalLocation SARIFPhysicalLocation `json:"physicalLocation"`
+}
+
+type SARIFPhysicalLocation struct {
+	ArtifactLocation SARIFArtifactLocation `json:"artifactLocation"`
+	Region           *SARIFRegion          `json:"region,omitempty"`
+}
+
+type SARIFArtifactLocation struct {
+	URI string `json:"uri"`
+}
+
+type SARIFRegion struct {
+	StartLine int `json:"startLine"`
+}
+
+// SARIF levels by severity
+var sarifLevels = map[string]string{
+	SEVERITY_CRITICAL: "error",
+	SEVERITY_HIGH:     "error",
+	SEVERITY_MEDIUM:   "warning",
+	SEVERITY_LOW:      "note",
+}
+
+// Build a SARIF log with a result per vulnerability, located at the line of
+// the dependency file where the package is declared. There's a rule per
+// advisory.
+func NewSARIFLog(vulnerabilities []Vulnerability) *SARIFLog {
+	driver := SARIFDriver{Name: "gemnasium", Version: config.VERSION, InformationURI: "https://gemnasium.com", Rules: []SARIFRule{}}
+	run := SARIFRun{Results: []SARIFResult{}}
+	rules := map[string]bool{}
+	lines := map[string]map[string]int{} // by file, then package
+
+	for _, v := range vulnerabilities {
+		ruleID := sarifRuleID(v.Advisory)
+		if !rules[ruleID] {
+			rules[ruleID] = true
+			driver.Rules = append(driver.Rules, newSARIFRule(ruleID, v.Advisory))
+		}
+
+		location := SARIFPhysicalLocation{ArtifactLocation: SARIFArtifactLocation{URI: filepath.ToSlash(v.Package.File)}}
+		if lines[v.Package.File] == nil {
+			lines[v.Package.File] = map[string]int{}
+		}
+		line, ok := lines[v.Package.File][v.Package.Name]
+		if !ok {
+			line = packageLine(v.Package.File, v.Package.Name)
+			lines[v.Package.File][v.Package.Name] = line
+		}
+		if line > 0 {
+			location.Region = &SARIFRegion{StartLine: line}
+		}
+
+		message := fmt.Sprintf("%s is affected by %s: %s", strings.TrimSpace(v.Package.Name+" "+v.Package.Version), ruleID, v.Advisory.Title)
+		if v.Advisory.CuredVersions != "" {
+			message += fmt.Sprintf(" (cured versions: %s)", v.Advisory.CuredVersions)
+		}
+		run.Results = append(run.Results, SARIFResult{
+			RuleID:    ruleID,
+			Level:     sarifLevels[v.Advisory.Severity()],
+			Message:   SARIFMessage{message},
+			Locations: []SARIFLocation{{location}},
+		})
+	}
+	run.Tool.Driver = driver
+	return &SARIFLog{Version: SARIF_VERSION, Schema: SARIF_SCHEMA, Runs: []SARIFRun{run}}
+}
+
+func sarifRuleID(a Advisory) string {
+	if a.Identifier != "" {
+		return a.Identifier
+	}
+	return "GMS-" + strconv.Itoa(a.ID)
+}
+
+func newSARIFRule(id string, a Advisory) SARIFRule {
+	cvss := a.CVSSScore
+	if cvss == 0 {
+		cvss = RISK_DEFAULT_CVSS
+	}
+	rule := SARIFRule{
+		ID:               id,
+		ShortDescription: SARIFMessage{a.Title},
+		FullDescription:  SARIFMessage{a.Description},
+		// GitHub code scanning ranks results with the security-severity
+		Properties: map[string]string{"security-severity": strconv.FormatFloat(cvss, 'f', 1, 64)},
+	}
+	if rule.FullDescription.Text == "" {
+		rule.FullDescription.Text = a.Title
+	}
+	if len(a.Links) > 0 {
+		rule.HelpURI = a.Links[0]
+	}
+	return rule
+}
+
+// Locate the alerts which aren't closed in the dependency files: at the
+// packages resolved in the lockfiles, or else at the first file naming the
+// package. Alerts which can't be located are left out.
+func LocateAlerts(alerts []Alert, dfiles []*DependencyFile) []Vulnerability {
+	vulnerabilities := []Vulnerability{}
+	for _, alert := range alerts {
+		if alert.Status == "closed" {
+			continue
+		}
+		located := false
+		for _, df := range dfiles {
+			for _, pkg := range df.LockedPackages() {
+				if pkg.Name == alert.Advisory.Package.Name && strings.EqualFold(advisoryPackageTypes[pkg.Ecosystem], alert.Advisory.Package.Type) {
+					vulnerabilities = append(vulnerabilities, Vulnerability{pkg, alert.Advisory})
+					located = true
+				}
+			}
+		}
+		if located {
+			continue
+		}
+		for _, df := range dfiles {
+			if packageLine(df.Path, alert.Advisory.Package.Name) > 0 {
+				pkg := LockedPackage{Name: alert.Advisory.Package.Name, File: df.Path}
+				vulnerabilities = append(vulnerabilities, Vulnerability{pkg, alert.Advisory})
+				break
+			}
+		}
+	}
+	return vulnerabilities
+}
+
+// Return the first line of the file where the package is named (as a whole
+// word, possibly quoted or scoped), 0 if it can't be found
+func packageLine(path, name string) int {
+	content, err := ioutil.ReadFile(path)
+	if err != nil {
+		return 0
+	}
+	pattern := regexp.MustCompile(`(^|[\s"'/])` + regexp.QuoteMeta(name) + `($|[\s"':@(,=])`)
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for line := 1; scanner.Scan(); line++ {
+		if pattern.MatchString(scanner.Text()) {
+			return line
+		}
+	}
+	return 0
+}
+
+// Write the log as json to path
+func (l *SARIFLog) Save(path string) error {
+	body, err := json.MarshalIndent(l, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, body, 0644)
+}
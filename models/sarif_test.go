@@ -0,0 +1,52 @@
+package models
+
+import (
+	"testing"
+)
+
+func TestNewSARIFLog(t *testing.T) {
+	files := map[string]string{
+		"Gemfile":      "source 'https://rubygems.org'\ngem 'rails'\ngem 'rack', '~> 1.6'\n",
+		"Gemfile.lock": "GEM\n  remote: https://rubygems.org/\n  specs:\n    rack (1.6.4)\n    rails (4.2.0)\n      rack (~> 1.6)\n",
+	}
+	inTempDir(t, files, func() {
+		rack := Advisory{ID: 42, Identifier: "CVE-2015-3225", Title: "Potential Denial of Service", CVSSScore: 7.5, CuredVersions: ">=1.6.2"}
+		rails := Advisory{ID: 43, Title: "XSS in helpers"}
+		rack.Package = Package{Name: "rack", Type: "Rubygem"}
+		rails.Package = Package{Name: "rails", Type: "Rubygem"}
+		alerts := []Alert{
+			{ID: 1, Advisory: rack, Status: "open"},
+			{ID: 2, Advisory: rails, Status: "acknowledged"},
+			{ID: 3, Advisory: Advisory{ID: 44, Package: Package{Name: "sinatra", Type: "Rubygem"}}, Status: "open"},
+			{ID: 4, Advisory: rack, Status: "closed"},
+		}
+		dfiles := []*DependencyFile{NewDependencyFile("Gemfile"), NewDependencyFile("Gemfile.lock")}
+
+		vulnerabilities := LocateAlerts(alerts, dfiles)
+		if len(vulnerabilities) != 2 {
+			t.Fatalf("Expected the 2 open alerts of locked packages, got %+v", vulnerabilities)
+		}
+		log := NewSARIFLog(vulnerabilities)
+		if log.Version != SARIF_VERSION || len(log.Runs) != 1 {
+			t.Fatalf("Unexpected log: %+v", log)
+		}
+		run := log.Runs[0]
+		if len(run.Tool.Driver.Rules) != 2 || run.Tool.Driver.Rules[0].ID != "CVE-2015-3225" || run.Tool.Driver.Rules[1].ID != "GMS-43" {
+			t.Errorf("Expected a rule per advisory, got %+v", run.Tool.Driver.Rules)
+		}
+		if severity := run.Tool.Driver.Rules[1].Properties["security-severity"]; severity != "5.0" {
+			t.Errorf("Expected the default security severity, got %s", severity)
+		}
+		result := run.Results[0]
+		if result.RuleID != "CVE-2015-3225" || result.Level != "error" {
+			t.Errorf("Unexpected result: %+v", result)
+		}
+		location := result.Locations[0].PhysicalLocation
+		if location.ArtifactLocation.URI != "Gemfile.lock" || location.Region == nil || location.Region.StartLine != 4 {
+			t.Errorf("Expected the result at the line of the locked package, got %+v", location)
+		}
+		if level := run.Results[1].Level; level != "warning" {
+			t.Errorf("Expected a warning for a medium severity, got %s", level)
+		}
+	})
+}
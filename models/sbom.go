@@ -0,0 +1,104 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+const SPDX_MEDIA_TYPE = "application/spdx+json"
+
+// Media types of the documents which can be attached along with the SBOM, by
+// suffix of their file name
+var sbomAttachmentTypes = []struct {
+	suffix    string
+	mediaType string
+}{
+	{".spdx.json", SPDX_MEDIA_TYPE},
+	{".cdx.json", "application/vnd.cyclonedx+json"},
+	{".openvex.json", "application/vnd.openvex+json"},
+	{".vex.json", "application/vnd.openvex+json"},
+	{".sarif", "application/sarif+json"},
+	{".sarif.json", "application/sarif+json"},
+}
+
+// Return the media type of a document to attach, from its file name
+func attachmentMediaType(path string) (string, error) {
+	for _, t := range sbomAttachmentTypes {
+		if strings.HasSuffix(path, t.suffix) {
+			return t.mediaType, nil
+		}
+	}
+	suffixes := []string{}
+	for _, t := range sbomAttachmentTypes {
+		suffixes = append(suffixes, t.suffix)
+	}
+	return "", errors.New(i18n.T("sbom.unknown_document", path, strings.Join(suffixes, ", ")))
+}
+
+// Attach an SPDX document of the packages resolved in the lockfiles of the
+// current path (see Scan) to the image, as an OCI referrer, so that it can be
+// discovered along with the image (ie: oras discover). Other documents, like
+// VEX documents or SARIF logs, can be attached too: one referrer is pushed
+// per document.
+func PushSBOM(reference string, attachments []string) error {
+	ref, err := utils.ParseOCIReference(reference)
+	if err != nil {
+		return err
+	}
+	type document struct {
+		title, mediaType string
+		content          []byte
+	}
+	documents := []document{}
+	for _, path := range attachments {
+		mediaType, err := attachmentMediaType(path)
+		if err != nil {
+			return err
+		}
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			return err
+		}
+		documents = append(documents, document{filepath.Base(path), mediaType, content})
+	}
+
+	dfiles, _, err := ScanDependencyFiles()
+	if err != nil {
+		return err
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		return err
+	}
+	now := time.Now()
+	sbom, err := json.MarshalIndent(NewSPDXDocument(filepath.Base(wd), dfiles, now), "", "  ")
+	if err != nil {
+		return err
+	}
+	documents = append([]document{{"sbom.spdx.json", SPDX_MEDIA_TYPE, sbom}}, documents...)
+
+	registry := &utils.OCIRegistry{Host: ref.Registry, Username: config.OCIUsername, Password: config.OCIPassword}
+	subject, err := registry.Resolve(ref)
+	if err != nil {
+		return err
+	}
+	annotations := map[string]string{"org.opencontainers.image.created": now.UTC().Format(time.RFC3339)}
+	for _, doc := range documents {
+		desc, err := registry.Attach(ref.Repository, subject, doc.mediaType, doc.title, doc.content, annotations)
+		if err != nil {
+			return err
+		}
+		fmt.Fprint(Output, i18n.T("sbom.attached", doc.title, doc.mediaType, ref.Registry, ref.Repository, subject.Digest, desc.Digest))
+	}
+	return nil
+}
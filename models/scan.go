@@ -0,0 +1,171 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// Rules excluding paths from the scan, as reported in the manifest
+const (
+	SCAN_RULE_GIT             = ".git directory"
+	SCAN_RULE_IGNORED_PATHS   = "ignored_paths"
+	SCAN_RULE_EDITOR_ARTIFACT = "editor temp file"
+	SCAN_RULE_UNSUPPORTED     = "unsupported file"
+	SCAN_RULE_VANISHED        = "disappeared during scan"
+)
+
+// A path considered during the scan
+type ScanEntry struct {
+	Path       string `json:"path"`
+	Dir        bool   `json:"dir,omitempty"`
+	Matched    bool   `json:"matched"`
+	ExcludedBy string `json:"excluded_by,omitempty"`
+	SHA        string `json:"sha,omitempty"`
+	// Dependencies of the file not sourced from a registry (see Sources)
+	Sources []DependencySource `json:"sources,omitempty"`
+}
+
+// Record of every path considered during a scan, useful to debug why a
+// dependency file wasn't picked up.
+type ScanManifest struct {
+	Entries []ScanEntry `json:"entries"`
+}
+
+func (m *ScanManifest) add(entry ScanEntry) {
+	m.Entries = append(m.Entries, entry)
+}
+
+// Progress of the running scan, reported by the command watchdog when it
+// times out
+var scanProgress struct {
+	sync.Mutex
+	walked  int
+	current string
+	matched []string
+}
+
+func recordScanWalk(path string) {
+	scanProgress.Lock()
+	defer scanProgress.Unlock()
+	scanProgress.walked++
+	scanProgress.current = path
+}
+
+func recordScanMatch(path string) {
+	scanProgress.Lock()
+	defer scanProgress.Unlock()
+	scanProgress.matched = append(scanProgress.matched, path)
+}
+
+// Return the number of paths walked by the running (or last) scan, the path
+// being walked, and the dependency files matched so far
+func ScanProgress() (walked int, current string, matched []string) {
+	scanProgress.Lock()
+	defer scanProgress.Unlock()
+	return scanProgress.walked, scanProgress.current, append([]string{}, scanProgress.matched...)
+}
+
+// Write the manifest as json to path
+func (m *ScanManifest) Save(path string) error {
+	body, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, body, 0644)
+}
+
+// Scan the current path for dependency files, without sending them.
+// If manifestPath is not empty, the scan manifest is written to this file.
+// If spdxPath is not empty, an SPDX document of the packages resolved in the
+// lockfiles is written to this file.
+// If offline is true, these packages are matched against the local advisory
+// database (see ScanOffline), and an error is returned if any is vulnerable,
+// or only if advisories are at or above the failOn severity when set.
+// If sarifPath is not empty, the vulnerabilities found offline are written to
+// this file as a SARIF log.
+func Scan(manifestPath, spdxPath, sarifPath string, offline bool, failOn string) error {
+	if sarifPath != "" && !offline {
+		return errors.New(i18n.T("scan.sarif_requires_offline"))
+	}
+	dfiles, manifest, err := ScanDependencyFiles()
+	if err != nil {
+		return err
+	}
+	fmt.Fprint(Output, i18n.T("scan.found", len(dfiles)))
+	if err := printSources(manifest); err != nil {
+		return err
+	}
+
+	if manifestPath != "" {
+		if err = manifest.Save(manifestPath); err != nil {
+			return err
+		}
+		fmt.Fprint(Output, i18n.T("scan.manifest_written", manifestPath))
+	}
+	if spdxPath != "" {
+		wd, err := os.Getwd()
+		if err != nil {
+			return err
+		}
+		doc := NewSPDXDocument(filepath.Base(wd), dfiles, time.Now())
+		if err = doc.Save(spdxPath); err != nil {
+			return err
+		}
+		fmt.Fprint(Output, i18n.T("scan.spdx_written", len(doc.Packages)-1, spdxPath))
+	}
+	if offline {
+		vulnerabilities, err := ScanOffline(dfiles)
+		if err != nil {
+			return err
+		}
+		if sarifPath != "" {
+			if err := NewSARIFLog(vulnerabilities).Save(sarifPath); err != nil {
+				return err
+			}
+			fmt.Fprint(Output, i18n.T("scan.sarif_written", len(vulnerabilities), sarifPath))
+		}
+		if len(vulnerabilities) == 0 {
+			fmt.Fprint(Output, i18n.T("scan.no_vulnerabilities"))
+			return nil
+		}
+		if err := RenderVulnerabilitiesAsTable(vulnerabilities); err != nil {
+			return err
+		}
+		if failOn != "" {
+			advisories := []Advisory{}
+			for _, v := range vulnerabilities {
+				advisories = append(advisories, v.Advisory)
+			}
+			return CheckSeverity(advisories, failOn)
+		}
+		return errors.New(i18n.T("scan.vulnerabilities_found", len(vulnerabilities)))
+	}
+	return nil
+}
+
+// Report the dependencies which aren't sourced from a registry, as they
+// bypass advisory matching
+func printSources(manifest *ScanManifest) error {
+	table := utils.NewTable(Output, "File", "Package", "Source", "Status")
+	count := 0
+	for _, entry := range manifest.Entries {
+		for _, source := range entry.Sources {
+			table.Append(entry.Path, source.Package, source.Source, source.Status)
+			count++
+		}
+	}
+	if count == 0 {
+		return nil
+	}
+	fmt.Fprint(Output, i18n.T("df.registry_bypass", count))
+	return table.Render()
+}
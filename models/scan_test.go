@@ -0,0 +1,192 @@
+package models
+
+import (
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// keep a ref on the real func, as other tests are overriding it
+var scanLocalDependencyFiles = getLocalDependencyFiles
+
+// Run fn in a temporary directory, with the given files
+func inTempDir(t *testing.T, files map[string]string, fn func()) {
+	dir, err := ioutil.TempDir("", "gemnasium-scan")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	for path, content := range files {
+		path = filepath.Join(dir, path)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := ioutil.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chdir(dir); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Chdir(wd)
+	fn()
+}
+
+func TestGetLocalDependencyFilesWithVanishedFile(t *testing.T) {
+	files := map[string]string{"Gemfile": "source 'https://rubygems.org'\n"}
+	inTempDir(t, files, func() {
+		// A dangling symlink behaves like a file removed between Walk and ReadFile
+		if err := os.Symlink("tmp-lockfile", "Gemfile.lock"); err != nil {
+			t.Skip("symlinks not supported:", err)
+		}
+		dfiles, err := scanLocalDependencyFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dfiles) != 1 || dfiles[0] == nil || dfiles[0].Path != "Gemfile" {
+			t.Errorf("Expected only Gemfile to be found, got: %#v", dfiles)
+		}
+	})
+}
+
+func TestGetLocalDependencyFilesSkipsEditorArtifacts(t *testing.T) {
+	files := map[string]string{
+		"package.json":         "{}",
+		"package.json~":        "{}",
+		".#package.json":       "user@host.1234",
+		"#package.json#":       "{}",
+		".package.json.swp":    "",
+		"js/.package.json.swx": "",
+		"js/4913":              "",
+	}
+	inTempDir(t, files, func() {
+		dfiles, err := scanLocalDependencyFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dfiles) != 1 || dfiles[0].Path != "package.json" {
+			paths := []string{}
+			for _, df := range dfiles {
+				paths = append(paths, df.Path)
+			}
+			t.Errorf("Expected only package.json to be found, got: %v", paths)
+		}
+	})
+}
+
+func TestScanDependencyFilesManifest(t *testing.T) {
+	files := map[string]string{
+		"Gemfile":        "source 'https://rubygems.org'\n\ngem 'rails', '3.2.18'\n",
+		"Gemfile~":       "",
+		"README.md":      "",
+		".git/HEAD":      "",
+		"vendor/Gemfile": "",
+	}
+	config.IgnoredPaths = []string{"vendor"}
+	defer func() { config.IgnoredPaths = nil }()
+	inTempDir(t, files, func() {
+		_, manifest, err := ScanDependencyFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		expected := []ScanEntry{
+			ScanEntry{Path: ".", Dir: true},
+			ScanEntry{Path: ".git", Dir: true, ExcludedBy: SCAN_RULE_GIT},
+			ScanEntry{Path: "Gemfile", Matched: true, SHA: "752751b8bf149dfea0d8b8b45cec23e6ac30b4a1"},
+			ScanEntry{Path: "Gemfile~", ExcludedBy: SCAN_RULE_EDITOR_ARTIFACT},
+			ScanEntry{Path: "README.md", ExcludedBy: SCAN_RULE_UNSUPPORTED},
+			ScanEntry{Path: "vendor", Dir: true, ExcludedBy: "ignored_paths: vendor"},
+		}
+		if !reflect.DeepEqual(manifest.Entries, expected) {
+			t.Errorf("Expected manifest:\n%#v\nGot:\n%#v", expected, manifest.Entries)
+		}
+	})
+}
+
+func TestScanDependencyFilesConcurrently(t *testing.T) {
+	files := map[string]string{}
+	expected := []string{}
+	for i := 0; i < 20; i++ {
+		path := filepath.Join(fmt.Sprintf("app%02d", i), "package.json")
+		files[path] = fmt.Sprintf(`{"name": "app%02d"}`, i)
+		expected = append(expected, path)
+	}
+	config.ScanConcurrency = 8
+	defer func() { config.ScanConcurrency = runtime.NumCPU() }()
+	inTempDir(t, files, func() {
+		dfiles, _, err := ScanDependencyFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths := []string{}
+		for _, df := range dfiles {
+			paths = append(paths, df.Path)
+			if string(df.Content) != files[df.Path] || df.SHA == "" {
+				t.Errorf("Unexpected content or SHA for %s: %s (%s)", df.Path, df.Content, df.SHA)
+			}
+		}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Errorf("Expected files in walk order:\n%v\nGot:\n%v", expected, paths)
+		}
+	})
+}
+
+func TestScanDependencyFilesIgnoredPaths(t *testing.T) {
+	files := map[string]string{
+		"Gemfile":                        "",
+		"vendor/bundle/Gemfile":          "",
+		"vendor/keep/Gemfile":            "",
+		"packages/web/package.json":      "{}",
+		"packages/web/test/package.json": "{}",
+		"packages/api/test/package.json": "{}",
+		"services/api/Gemfile":           "",
+		"services/api/spec/Gemfile":      "",
+		"js/node_modules/package.json":   "{}",
+	}
+	config.IgnoredPaths = []string{"vendor/**", "!vendor/keep/Gemfile", "packages/*/test", "node_modules/"}
+	config.ProjectIgnoredPaths = map[string][]string{"services/api": []string{"spec"}}
+	defer func() {
+		config.IgnoredPaths = nil
+		config.ProjectIgnoredPaths = map[string][]string{}
+	}()
+	inTempDir(t, files, func() {
+		dfiles, _, err := ScanDependencyFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		paths := []string{}
+		for _, df := range dfiles {
+			paths = append(paths, filepath.ToSlash(df.Path))
+		}
+		expected := []string{"Gemfile", "packages/web/package.json", "services/api/Gemfile", "vendor/keep/Gemfile"}
+		if !reflect.DeepEqual(paths, expected) {
+			t.Errorf("Expected files:\n%v\nGot:\n%v", expected, paths)
+		}
+	})
+}
+
+func TestScanDependencyFilesIgnoredFile(t *testing.T) {
+	// Ignoring a file must not skip the rest of its directory
+	files := map[string]string{"Cargo.toml": "", "Gemfile": "", "package.json": "{}"}
+	config.IgnoredPaths = []string{"Gemfile"}
+	defer func() { config.IgnoredPaths = nil }()
+	inTempDir(t, files, func() {
+		dfiles, _, err := ScanDependencyFiles()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if len(dfiles) != 2 || dfiles[0].Path != "Cargo.toml" || dfiles[1].Path != "package.json" {
+			t.Errorf("Expected Cargo.toml and package.json, got: %v", dfiles)
+		}
+	})
+}
@@ -0,0 +1,281 @@
+package models
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/gemnasium/toolbelt/cache"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+const (
+	SEARCH_KIND_PROJECT    = "project"
+	SEARCH_KIND_DEPENDENCY = "dependency"
+	SEARCH_KIND_ADVISORY   = "advisory"
+
+	// Max number of results displayed
+	SEARCH_MAX_RESULTS = 50
+)
+
+// The index is refreshed in the background when older than this
+var SearchIndexTTL = time.Hour
+
+type SearchEntry struct {
+	Kind    string `json:"kind"`
+	Name    string `json:"name"`
+	Project string `json:"project"`
+	Version string `json:"version,omitempty"`
+	Status  string `json:"status,omitempty"`
+}
+
+// Local index of the names of projects, dependencies and advisories, stored
+// in the cache directory
+type SearchIndex struct {
+	UpdatedAt time.Time     `json:"updated_at"`
+	Entries   []SearchEntry `json:"entries"`
+}
+
+func searchIndexPath() string {
+	dir := cache.Dir(cache.INDEX)
+	if dir == "" {
+		return ""
+	}
+	return filepath.Join(dir, "search.json")
+}
+
+// Build the index from the projects available to the current user
+func BuildSearchIndex(projects []Project) (*SearchIndex, error) {
+	results := make([][]SearchEntry, len(projects))
+	err := forEachProject(projects, false, func(i int) error {
+		p := projects[i]
+		entries := []SearchEntry{SearchEntry{Kind: SEARCH_KIND_PROJECT, Name: p.Slug, Project: p.Slug, Status: p.Color}}
+		if p.Name != "" && p.Name != p.Slug {
+			entries = append(entries, SearchEntry{Kind: SEARCH_KIND_PROJECT, Name: p.Name, Project: p.Slug, Status: p.Color})
+		}
+		deps, err := p.Dependencies()
+		if err != nil {
+			return fmt.Errorf("%s: %s", p.Slug, err)
+		}
+		for _, d := range deps {
+			entries = append(entries, SearchEntry{Kind: SEARCH_KIND_DEPENDENCY, Name: d.Package.Name, Project: p.Slug, Version: d.LockedVersion, Status: d.Color})
+		}
+		alerts, err := p.Alerts()
+		if err != nil {
+			return fmt.Errorf("%s: %s", p.Slug, err)
+		}
+		for _, a := range alerts {
+			name := a.Advisory.Title
+			if a.Advisory.Identifier != "" {
+				name = a.Advisory.Identifier + " " + name
+			}
+			if a.Advisory.Package.Name != "" {
+				name += " (" + a.Advisory.Package.Name + ")"
+			}
+			entries = append(entries, SearchEntry{Kind: SEARCH_KIND_ADVISORY, Name: name, Project: p.Slug, Status: a.Status})
+		}
+		results[i] = entries
+		return nil
+	}, func(i int, err error) {})
+
+	index := &SearchIndex{UpdatedAt: time.Now()}
+	for i := range projects {
+		index.Entries = append(index.Entries, results[i]...)
+	}
+	return index, err
+}
+
+func (index *SearchIndex) Save() error {
+	path := searchIndexPath()
+	if path == "" {
+		return nil
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return err
+	}
+	data, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, data, 0644)
+}
+
+// Load the index from the cache. nil is returned if there's no index yet.
+func loadSearchIndex() *SearchIndex {
+	path := searchIndexPath()
+	if path == "" {
+		return nil
+	}
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil
+	}
+	var index SearchIndex
+	if err := json.Unmarshal(data, &index); err != nil {
+		return nil
+	}
+	return &index
+}
+
+// Return how well s matches term, or -1 if it doesn't match at all.
+// The characters of term must appear in s in the same order (case
+// insensitive); exact, prefix and substring matches score higher, as well as
+// consecutive characters.
+func fuzzyScore(term, s string) int {
+	term, s = strings.ToLower(term), strings.ToLower(s)
+	switch {
+	case term == s:
+		return 3000
+	case strings.HasPrefix(s, term):
+		return 2000 - len(s)
+	case strings.Contains(s, term):
+		return 1000 - len(s)
+	}
+	score, j, last := 0, 0, -2
+	for i := 0; i < len(s) && j < len(term); i++ {
+		if s[i] != term[j] {
+			continue
+		}
+		if last == i-1 {
+			score += 5
+		} else {
+			score += 1
+		}
+		last = i
+		j++
+	}
+	if j < len(term) {
+		return -1
+	}
+	return score
+}
+
+// Return the entries matching term, best matches first
+func (index *SearchIndex) Search(term string) []SearchEntry {
+	type match struct {
+		entry SearchEntry
+		score int
+	}
+	matches := []match{}
+	for _, e := range index.Entries {
+		if score := fuzzyScore(term, e.Name); score >= 0 {
+			matches = append(matches, match{e, score})
+		}
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].score > matches[j].score })
+	results := []SearchEntry{}
+	for _, m := range matches {
+		results = append(results, m.entry)
+	}
+	return results
+}
+
+// Rebuild the search index from all the projects of the current user
+func RefreshSearchIndex() (*SearchIndex, error) {
+	projects, err := getOrgProjects("", nil)
+	if err != nil {
+		return nil, err
+	}
+	index, err := BuildSearchIndex(projects)
+	if saveErr := index.Save(); saveErr != nil {
+		return index, saveErr
+	}
+	return index, err
+}
+
+// Refresh the index in a separate process, so the current search doesn't wait
+// for it.
+var refreshSearchIndexInBackground = func() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	cmd := exec.Command(exe, "search", "--reindex")
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+	return cmd.Process.Release()
+}
+
+// Search term in the local index. The index is built first if missing, and
+// refreshed in the background when outdated.
+func SearchAll(term string) error {
+	index := loadSearchIndex()
+	if index == nil {
+		fmt.Fprint(Output, i18n.T("search.building_index"))
+		var err error
+		index, err = RefreshSearchIndex()
+		if err != nil {
+			return err
+		}
+	} else if time.Since(index.UpdatedAt) > SearchIndexTTL {
+		if err := refreshSearchIndexInBackground(); err != nil {
+			fmt.Fprint(os.Stderr, i18n.T("search.refresh_error", err))
+		}
+	}
+
+	results := index.Search(term)
+	if len(results) == 0 {
+		fmt.Fprint(Output, i18n.T("search.no_results", term))
+		return nil
+	}
+	if err := RenderSearchResultsAsTable(results, Output); err != nil {
+		return err
+	}
+	if len(results) > SEARCH_MAX_RESULTS {
+		fmt.Fprintf(Output, "%d more results not displayed\n", len(results)-SEARCH_MAX_RESULTS)
+	}
+	return nil
+}
+
+// Display search results in an ascii table (up to SEARCH_MAX_RESULTS)
+func RenderSearchResultsAsTable(results []SearchEntry, output io.Writer) error {
+	table := utils.NewTable(output, "Type", "Name", "Project", "Version", "Status")
+	for i, r := range results {
+		if i == SEARCH_MAX_RESULTS {
+			break
+		}
+		table.Append(r.Kind, r.Name, r.Project, r.Version, r.Status)
+	}
+	return table.Render()
+}
+
+// Return the distinct names of the entries of this kind, sorted. Only slugs
+// are returned for projects.
+func (index *SearchIndex) Names(kind string) []string {
+	seen := map[string]bool{}
+	names := []string{}
+	for _, e := range index.Entries {
+		if e.Kind != kind || seen[e.Name] || (kind == SEARCH_KIND_PROJECT && e.Name != e.Project) {
+			continue
+		}
+		seen[e.Name] = true
+		names = append(names, e.Name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Print the names of this kind from the local index, one per line, for shell
+// completion. Completion must be instant: the index is never built here, only
+// refreshed in the background when outdated.
+func CompleteNames(output io.Writer, kind string) {
+	index := loadSearchIndex()
+	if index == nil {
+		return
+	}
+	if time.Since(index.UpdatedAt) > SearchIndexTTL {
+		refreshSearchIndexInBackground()
+	}
+	for _, name := range index.Names(kind) {
+		fmt.Fprintln(output, name)
+	}
+}
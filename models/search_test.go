@@ -0,0 +1,117 @@
+package models
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestFuzzyScore(t *testing.T) {
+	var tt = []struct {
+		Term, S string
+		Match   bool
+	}{
+		{"rails", "rails", true},
+		{"rails", "rails-html-sanitizer", true},
+		{"rails", "sprockets-rails", true},
+		{"rhs", "rails-html-sanitizer", true},
+		{"RACK", "rack", true},
+		{"xyz", "rails", false},
+		{"slira", "rails", false},
+	}
+	for _, test := range tt {
+		if score := fuzzyScore(test.Term, test.S); (score >= 0) != test.Match {
+			t.Errorf("fuzzyScore(%s, %s) = %d, expected match: %t", test.Term, test.S, score, test.Match)
+		}
+	}
+	if fuzzyScore("rails", "rails") <= fuzzyScore("rails", "rails-html-sanitizer") {
+		t.Error("Exact match should score higher than prefix match")
+	}
+	if fuzzyScore("rails", "rails-html-sanitizer") <= fuzzyScore("rails", "sprockets-rails") {
+		t.Error("Prefix match should score higher than substring match")
+	}
+	if fuzzyScore("rails", "sprockets-rails") <= fuzzyScore("rhs", "rails-html-sanitizer") {
+		t.Error("Substring match should score higher than fuzzy match")
+	}
+}
+
+func TestSearchIndex(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		switch r.URL.Path {
+		case "/projects":
+			fmt.Fprintln(w, `{"owned": [{"slug": "blog", "name": "My blog", "monitored": true}]}`)
+		case "/projects/blog/dependencies":
+			fmt.Fprintln(w, `[
+				{"package": {"name": "rails", "type": "rubygem"}, "locked": "4.2.0", "color": "red"},
+				{"package": {"name": "sprockets-rails", "type": "rubygem"}, "locked": "2.3.3", "color": "green"}
+			]`)
+		case "/projects/blog/alerts":
+			fmt.Fprintln(w, `[{"id": 1, "advisory": {"identifier": "CVE-2016-0752", "title": "Directory traversal", "package": {"name": "rails"}}, "status": "open"}]`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+	dir, err := ioutil.TempDir("", "gemnasium-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.CacheDir = dir
+	defer func() { config.CacheDir = "" }()
+
+	if _, err := RefreshSearchIndex(); err != nil {
+		t.Fatal(err)
+	}
+	index := loadSearchIndex()
+	if index == nil || len(index.Entries) != 5 {
+		t.Fatalf("Expected 5 entries in the saved index, got: %#v", index)
+	}
+
+	results := index.Search("rails")
+	if len(results) != 3 || results[0].Name != "rails" || results[0].Version != "4.2.0" || results[1].Name != "sprockets-rails" || results[2].Kind != SEARCH_KIND_ADVISORY {
+		t.Errorf("Unexpected results: %#v", results)
+	}
+	results = index.Search("cve-2016")
+	if len(results) != 1 || results[0].Kind != SEARCH_KIND_ADVISORY {
+		t.Errorf("Unexpected results: %#v", results)
+	}
+
+	// outdated index is refreshed in the background
+	refreshed := false
+	oldRefresh := refreshSearchIndexInBackground
+	refreshSearchIndexInBackground = func() error {
+		refreshed = true
+		return nil
+	}
+	defer func() { refreshSearchIndexInBackground = oldRefresh }()
+	index.UpdatedAt = time.Now().Add(-2 * SearchIndexTTL)
+	index.Save()
+	if err := SearchAll("blog"); err != nil {
+		t.Fatal(err)
+	}
+	if !refreshed {
+		t.Error("Expected the outdated index to be refreshed")
+	}
+
+	// Completion of names, from the same index
+	var out bytes.Buffer
+	refreshed = false
+	CompleteNames(&out, SEARCH_KIND_DEPENDENCY)
+	if out.String() != "rails\nsprockets-rails\n" || !refreshed {
+		t.Errorf("Expected package names and a background refresh, got %q (refreshed: %t)", out.String(), refreshed)
+	}
+	if names := index.Names(SEARCH_KIND_PROJECT); !reflect.DeepEqual(names, []string{"blog"}) {
+		t.Errorf("Expected project slugs only, got %v", names)
+	}
+}
@@ -0,0 +1,64 @@
+package models
+
+import (
+	"errors"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/i18n"
+)
+
+// Severities of advisories, from their CVSS score
+const (
+	SEVERITY_LOW      = "low"
+	SEVERITY_MEDIUM   = "medium"
+	SEVERITY_HIGH     = "high"
+	SEVERITY_CRITICAL = "critical"
+)
+
+var severityRanks = map[string]int{SEVERITY_LOW: 1, SEVERITY_MEDIUM: 2, SEVERITY_HIGH: 3, SEVERITY_CRITICAL: 4}
+
+// Return the severity of the advisory, using the CVSS v3 ratings. Advisories
+// without CVSS score are considered of medium severity (see
+// RISK_DEFAULT_CVSS).
+func (a Advisory) Severity() string {
+	cvss := a.CVSSScore
+	if cvss == 0 {
+		cvss = RISK_DEFAULT_CVSS
+	}
+	switch {
+	case cvss >= 9:
+		return SEVERITY_CRITICAL
+	case cvss >= 7:
+		return SEVERITY_HIGH
+	case cvss >= 4:
+		return SEVERITY_MEDIUM
+	}
+	return SEVERITY_LOW
+}
+
+// Return the severity, lower cased, or an error if it's unknown
+func ParseSeverity(severity string) (string, error) {
+	severity = strings.ToLower(strings.TrimSpace(severity))
+	if _, ok := severityRanks[severity]; !ok {
+		return "", errors.New(i18n.T("severity.unknown", severity))
+	}
+	return severity, nil
+}
+
+// Return an error if any of the advisories is at or above the threshold
+// severity. Nothing is checked if threshold is empty.
+func CheckSeverity(advisories []Advisory, threshold string) error {
+	if threshold == "" {
+		return nil
+	}
+	count := 0
+	for _, a := range advisories {
+		if severityRanks[a.Severity()] >= severityRanks[threshold] {
+			count++
+		}
+	}
+	if count > 0 {
+		return errors.New(i18n.T("severity.threshold_reached", count, threshold))
+	}
+	return nil
+}
@@ -0,0 +1,31 @@
+package models
+
+import "testing"
+
+func TestCheckSeverity(t *testing.T) {
+	advisories := []Advisory{{ID: 1, CVSSScore: 3.1}, {ID: 2}, {ID: 3, CVSSScore: 7.5}}
+	var tt = []struct {
+		threshold string
+		fails     bool
+	}{
+		{"", false},
+		{SEVERITY_LOW, true},
+		{SEVERITY_HIGH, true},
+		{SEVERITY_CRITICAL, false},
+	}
+	for _, test := range tt {
+		if err := CheckSeverity(advisories, test.threshold); (err != nil) != test.fails {
+			t.Errorf("Threshold %q: expected failure: %v, got: %v", test.threshold, test.fails, err)
+		}
+	}
+	if advisories[1].Severity() != SEVERITY_MEDIUM {
+		t.Errorf("Expected advisories without CVSS score to be of medium severity, got %s", advisories[1].Severity())
+	}
+
+	if severity, err := ParseSeverity(" High"); err != nil || severity != SEVERITY_HIGH {
+		t.Errorf("Expected high severity, got %q (%v)", severity, err)
+	}
+	if _, err := ParseSeverity("severe"); err == nil {
+		t.Error("Expected an error for an unknown severity")
+	}
+}
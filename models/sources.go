@@ -0,0 +1,276 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Statuses of the dependencies which aren't released on a registry. They
+// can't be matched against advisories.
+const (
+	SOURCE_GIT_BRANCH   = "git branch"
+	SOURCE_GIT_REF      = "git ref"
+	SOURCE_GIT_COMMIT   = "git commit"
+	SOURCE_LOCAL_PATH   = "local path"
+	SOURCE_HTTP_TARBALL = "http tarball"
+)
+
+// A dependency sourced from a git repository, a local path or an url instead
+// of a registry release
+type DependencySource struct {
+	Package string `json:"package"`
+	Source  string `json:"source"`
+	Status  string `json:"status"`
+}
+
+type sourceAuditor func(content []byte) []DependencySource
+
+// Source auditors by file name
+var sourceAuditors = map[string]sourceAuditor{
+	"Gemfile":          auditGemfile,
+	"package.json":     auditPackageJSON,
+	"bower.json":       auditPackageJSON,
+	"composer.json":    auditComposerJSON,
+	"requirements.txt": auditRequirements,
+	"Cargo.toml":       auditCargoToml,
+	"go.mod":           auditGoMod,
+}
+
+// Return the dependencies of the file which aren't sourced from a registry,
+// nil if there are none
+func (df *DependencyFile) Sources() []DependencySource {
+	audit, ok := sourceAuditors[filepath.Base(df.Path)]
+	if !ok {
+		return nil
+	}
+	content, err := df.contents()
+	if err != nil {
+		return nil
+	}
+	if sources := audit(content); len(sources) > 0 {
+		return sources
+	}
+	return nil
+}
+
+var gitCommit = regexp.MustCompile(`^[0-9a-f]{7,40}$`)
+
+// Return the status of a git dependency, from the ref it's pinned to (if any)
+func gitStatus(ref string) string {
+	switch {
+	case ref == "":
+		return SOURCE_GIT_BRANCH
+	case gitCommit.MatchString(ref):
+		return SOURCE_GIT_COMMIT
+	}
+	return SOURCE_GIT_REF
+}
+
+func isLocalPath(spec string) bool {
+	for _, prefix := range []string{"file:", "link:", "./", "../", "/", "~/"} {
+		if strings.HasPrefix(spec, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func isURL(spec string) bool {
+	return strings.HasPrefix(spec, "http://") || strings.HasPrefix(spec, "https://")
+}
+
+var (
+	gemDeclaration = regexp.MustCompile(`^\s*gem\s+["']([^"']+)["'](.*)$`)
+	gemOption      = regexp.MustCompile(`:?(git|github|path|branch|tag|ref)(?::|\s*=>)\s*["']([^"']+)["']`)
+)
+
+func auditGemfile(content []byte) []DependencySource {
+	sources := []DependencySource{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		m := gemDeclaration.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		options := map[string]string{}
+		for _, o := range gemOption.FindAllStringSubmatch(m[2], -1) {
+			options[o[1]] = o[2]
+		}
+		switch {
+		case options["path"] != "":
+			sources = append(sources, DependencySource{m[1], options["path"], SOURCE_LOCAL_PATH})
+		case options["git"] != "" || options["github"] != "":
+			status := SOURCE_GIT_BRANCH
+			if options["tag"] != "" {
+				status = SOURCE_GIT_REF
+			} else if options["ref"] != "" {
+				status = gitStatus(options["ref"])
+			}
+			source := options["git"]
+			if source == "" {
+				source = "github:" + options["github"]
+			}
+			sources = append(sources, DependencySource{m[1], source, status})
+		}
+	}
+	return sources
+}
+
+var githubShorthand = regexp.MustCompile(`^[\w.-]+/[\w.-]+(#.*)?$`)
+
+// Return the status of an npm (or bower) dependency, empty for registry
+// releases. See https://docs.npmjs.com/cli/configuring-npm/package-json#dependencies
+func npmSourceStatus(spec string) string {
+	switch {
+	case isLocalPath(spec):
+		return SOURCE_LOCAL_PATH
+	case isURL(spec) && !strings.Contains(spec, ".git#") && !strings.HasSuffix(spec, ".git"):
+		return SOURCE_HTTP_TARBALL
+	case strings.HasPrefix(spec, "git") || strings.HasPrefix(spec, "bitbucket:") || isURL(spec) || githubShorthand.MatchString(spec):
+		ref := ""
+		if i := strings.LastIndex(spec, "#"); i >= 0 {
+			ref = spec[i+1:]
+		}
+		return gitStatus(ref)
+	}
+	return ""
+}
+
+func auditPackageJSON(content []byte) []DependencySource {
+	var pkg map[string]json.RawMessage
+	if json.Unmarshal(content, &pkg) != nil {
+		return nil
+	}
+	sources := []DependencySource{}
+	for _, section := range []string{"dependencies", "devDependencies", "optionalDependencies", "peerDependencies"} {
+		var deps map[string]string
+		if json.Unmarshal(pkg[section], &deps) != nil {
+			continue
+		}
+		for _, name := range sortedKeys(deps) {
+			if status := npmSourceStatus(deps[name]); status != "" {
+				sources = append(sources, DependencySource{name, deps[name], status})
+			}
+		}
+	}
+	return sources
+}
+
+// Packages required with a "dev-<branch>" version are installed from VCS
+// repositories
+func auditComposerJSON(content []byte) []DependencySource {
+	var pkg map[string]json.RawMessage
+	if json.Unmarshal(content, &pkg) != nil {
+		return nil
+	}
+	sources := []DependencySource{}
+	for _, section := range []string{"require", "require-dev"} {
+		var deps map[string]string
+		if json.Unmarshal(pkg[section], &deps) != nil {
+			continue
+		}
+		for _, name := range sortedKeys(deps) {
+			if strings.HasPrefix(deps[name], "dev-") {
+				sources = append(sources, DependencySource{name, deps[name], SOURCE_GIT_BRANCH})
+			}
+		}
+	}
+	return sources
+}
+
+var (
+	requirementURL = regexp.MustCompile(`^(?:([\w.-]+)\s*@\s*)?(\S+)$`)
+	eggName        = regexp.MustCompile(`#egg=([\w.-]+)`)
+	vcsRef         = regexp.MustCompile(`://[^#]*@([^#@/]+)(?:#|$)`)
+)
+
+func auditRequirements(content []byte) []DependencySource {
+	sources := []DependencySource{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		line = strings.TrimSpace(strings.TrimPrefix(strings.TrimPrefix(line, "-e"), "--editable"))
+		m := requirementURL.FindStringSubmatch(line)
+		if m == nil {
+			continue
+		}
+		name, spec := m[1], m[2]
+		if egg := eggName.FindStringSubmatch(spec); egg != nil {
+			name = egg[1]
+		}
+		if name == "" {
+			name = spec
+		}
+		switch {
+		case strings.HasPrefix(spec, "git+"):
+			ref := ""
+			if r := vcsRef.FindStringSubmatch(spec); r != nil {
+				ref = r[1]
+			}
+			sources = append(sources, DependencySource{name, spec, gitStatus(ref)})
+		case isLocalPath(spec):
+			sources = append(sources, DependencySource{name, spec, SOURCE_LOCAL_PATH})
+		case isURL(spec):
+			sources = append(sources, DependencySource{name, spec, SOURCE_HTTP_TARBALL})
+		}
+	}
+	return sources
+}
+
+var (
+	cargoInlineTable = regexp.MustCompile(`^\s*([\w-]+)\s*=\s*\{(.*)\}`)
+	cargoKey         = regexp.MustCompile(`(git|path|branch|tag|rev)\s*=\s*"([^"]*)"`)
+)
+
+func auditCargoToml(content []byte) []DependencySource {
+	sources := []DependencySource{}
+	scanner := bufio.NewScanner(bytes.NewReader(content))
+	for scanner.Scan() {
+		m := cargoInlineTable.FindStringSubmatch(scanner.Text())
+		if m == nil {
+			continue
+		}
+		keys := map[string]string{}
+		for _, k := range cargoKey.FindAllStringSubmatch(m[2], -1) {
+			keys[k[1]] = k[2]
+		}
+		switch {
+		case keys["path"] != "":
+			sources = append(sources, DependencySource{m[1], keys["path"], SOURCE_LOCAL_PATH})
+		case keys["git"] != "":
+			status := SOURCE_GIT_BRANCH
+			if keys["tag"] != "" {
+				status = SOURCE_GIT_REF
+			} else if keys["rev"] != "" {
+				status = gitStatus(keys["rev"])
+			}
+			sources = append(sources, DependencySource{m[1], keys["git"], status})
+		}
+	}
+	return sources
+}
+
+// Modules replaced with a local directory
+var goModLocalReplace = regexp.MustCompile(`(?m)^\s*(?:replace\s+)?(\S+)(?:\s+\S+)?\s+=>\s+(\.{0,2}/\S*)\s*$`)
+
+func auditGoMod(content []byte) []DependencySource {
+	sources := []DependencySource{}
+	for _, m := range goModLocalReplace.FindAllSubmatch(content, -1) {
+		sources = append(sources, DependencySource{string(m[1]), string(m[2]), SOURCE_LOCAL_PATH})
+	}
+	return sources
+}
+
+func sortedKeys(m map[string]string) []string {
+	keys := []string{}
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
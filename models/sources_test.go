@@ -0,0 +1,100 @@
+package models
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestDependencyFileSources(t *testing.T) {
+	var tt = []struct {
+		path     string
+		content  string
+		expected []DependencySource
+	}{
+		{
+			"Gemfile",
+			`source 'https://rubygems.org'
+gem 'rails', '4.2.0'
+gem 'devise', git: 'https://github.com/plataformatec/devise.git'
+gem 'rspec', :git => 'https://github.com/rspec/rspec.git', :tag => 'v3.0.0'
+gem 'sidekiq', github: 'mperham/sidekiq', ref: 'a1b2c3d'
+gem 'core', path: '../core'
+`,
+			[]DependencySource{
+				{"devise", "https://github.com/plataformatec/devise.git", SOURCE_GIT_BRANCH},
+				{"rspec", "https://github.com/rspec/rspec.git", SOURCE_GIT_REF},
+				{"sidekiq", "github:mperham/sidekiq", SOURCE_GIT_COMMIT},
+				{"core", "../core", SOURCE_LOCAL_PATH},
+			},
+		},
+		{
+			"web/package.json",
+			`{
+  "dependencies": {
+    "express": "^4.16.0",
+    "lodash": "lodash/lodash#4.17.21",
+    "left-pad": "git+https://github.com/stevemao/left-pad.git",
+    "shared": "file:../shared",
+    "tarball": "https://example.com/tarball-1.0.0.tgz"
+  },
+  "devDependencies": {"mocha": "mochajs/mocha#0123456789abcdef"}
+}`,
+			[]DependencySource{
+				{"left-pad", "git+https://github.com/stevemao/left-pad.git", SOURCE_GIT_BRANCH},
+				{"lodash", "lodash/lodash#4.17.21", SOURCE_GIT_REF},
+				{"shared", "file:../shared", SOURCE_LOCAL_PATH},
+				{"tarball", "https://example.com/tarball-1.0.0.tgz", SOURCE_HTTP_TARBALL},
+				{"mocha", "mochajs/mocha#0123456789abcdef", SOURCE_GIT_COMMIT},
+			},
+		},
+		{
+			"composer.json",
+			`{"require": {"monolog/monolog": "1.0.*", "acme/lib": "dev-master"}}`,
+			[]DependencySource{{"acme/lib", "dev-master", SOURCE_GIT_BRANCH}},
+		},
+		{
+			"requirements.txt",
+			`Django==1.11
+-e git+https://github.com/org/lib.git@v1.2#egg=lib
+requests @ https://example.com/requests-2.0.tar.gz
+./local/pkg
+`,
+			[]DependencySource{
+				{"lib", "git+https://github.com/org/lib.git@v1.2#egg=lib", SOURCE_GIT_REF},
+				{"requests", "https://example.com/requests-2.0.tar.gz", SOURCE_HTTP_TARBALL},
+				{"./local/pkg", "./local/pkg", SOURCE_LOCAL_PATH},
+			},
+		},
+		{
+			"Cargo.toml",
+			`[dependencies]
+serde = "1.0"
+rand = { git = "https://github.com/rust-random/rand", branch = "master" }
+utils = { path = "../utils" }
+`,
+			[]DependencySource{
+				{"rand", "https://github.com/rust-random/rand", SOURCE_GIT_BRANCH},
+				{"utils", "../utils", SOURCE_LOCAL_PATH},
+			},
+		},
+		{
+			"go.mod",
+			`module example.com/app
+
+require example.com/lib v1.0.0
+
+replace example.com/lib => ../lib
+replace example.com/other v1.0.0 => example.com/fork v1.0.1
+`,
+			[]DependencySource{{"example.com/lib", "../lib", SOURCE_LOCAL_PATH}},
+		},
+		{"Gemfile.lock", "GEM\n", nil},
+	}
+	for _, test := range tt {
+		df := &DependencyFile{Path: test.path, Content: []byte(test.content)}
+		sources := df.Sources()
+		if !reflect.DeepEqual(sources, test.expected) {
+			t.Errorf("%s: expected sources:\n%v\nGot:\n%v", test.path, test.expected, sources)
+		}
+	}
+}
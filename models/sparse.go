@@ -0,0 +1,93 @@
+package models
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/i18n"
+)
+
+// Files of Git LFS are stored as pointers until they're checked out with
+// git-lfs: https://github.com/git-lfs/git-lfs/blob/main/docs/spec.md
+const (
+	LFS_POINTER_PREFIX   = "version https://git-lfs.github.com/spec/"
+	LFS_POINTER_MAX_SIZE = 1024
+)
+
+func isLFSPointer(content []byte) bool {
+	return len(content) < LFS_POINTER_MAX_SIZE &&
+		bytes.HasPrefix(content, []byte(LFS_POINTER_PREFIX)) &&
+		bytes.Contains(content, []byte("\noid sha256:"))
+}
+
+// Return the content of the LFS pointer of filePath, fetched with "git lfs
+// smudge" (from the local LFS cache, or the LFS server)
+var lfsSmudge = func(filePath string, pointer []byte) ([]byte, error) {
+	cmd := exec.Command("git", "lfs", "smudge", filePath)
+	cmd.Stdin = bytes.NewReader(pointer)
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	content, err := cmd.Output()
+	if err != nil {
+		return nil, errors.New(i18n.T("df.lfs_unavailable", filePath, strings.TrimSpace(stderr.String())))
+	}
+	return content, nil
+}
+
+// Return the files of the current directory excluded from a sparse checkout:
+// they're in the index, with the skip-worktree bit, but not in the working
+// tree. Paths are relative to the current directory. An empty set is returned
+// outside of git repositories.
+var sparseExcludedFiles = func() map[string]bool {
+	// "S" tags the files with the skip-worktree bit
+	out, err := exec.Command("git", "ls-files", "-v").Output()
+	if err != nil {
+		return map[string]bool{}
+	}
+	return parseSkipWorktree(out)
+}
+
+func parseSkipWorktree(lsFiles []byte) map[string]bool {
+	excluded := map[string]bool{}
+	scanner := bufio.NewScanner(bytes.NewReader(lsFiles))
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "S ") {
+			excluded[filepath.FromSlash(line[2:])] = true
+		}
+	}
+	return excluded
+}
+
+// Return true if the file doesn't exist locally, and isn't just left out of a
+// sparse checkout
+func missingLocally(path string, sparseExcluded map[string]bool) bool {
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		return false
+	}
+	return !sparseExcluded[filepath.Clean(path)]
+}
+
+// Remove the files excluded from the sparse checkout from files, with a
+// warning, as they can't be read
+func withoutSparseExcluded(files []string) []string {
+	sparseExcluded := sparseExcludedFiles()
+	if len(sparseExcluded) == 0 {
+		return files
+	}
+	kept := []string{}
+	for _, path := range files {
+		if _, err := os.Stat(path); os.IsNotExist(err) && sparseExcluded[filepath.Clean(path)] {
+			fmt.Fprint(Output, i18n.T("df.sparse_excluded", path))
+			continue
+		}
+		kept = append(kept, path)
+	}
+	return kept
+}
@@ -0,0 +1,91 @@
+package models
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+const lfsPointer = "version https://git-lfs.github.com/spec/v1\noid sha256:4d7a214614ab2935c943f9e0ff69d22eadbb8f32b1258daaa5e2ca24d17e2393\nsize 12345\n"
+
+func TestReadFileWithLFSPointer(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-lfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "package-lock.json")
+	ioutil.WriteFile(path, []byte(lfsPointer), 0644)
+
+	var smudged string
+	defer func(smudge func(string, []byte) ([]byte, error)) { lfsSmudge = smudge }(lfsSmudge)
+	lfsSmudge = func(filePath string, pointer []byte) ([]byte, error) {
+		smudged = string(pointer)
+		return []byte(`{"lockfileVersion": 1}`), nil
+	}
+
+	content, err := readFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(content) != `{"lockfileVersion": 1}` || smudged != lfsPointer {
+		t.Errorf("Content of the LFS pointer should be fetched, got: %s", content)
+	}
+
+	if isLFSPointer([]byte(`{"version": "https://git-lfs.github.com/spec/v1"}`)) {
+		t.Error("Regular files shouldn't be LFS pointers")
+	}
+}
+
+func TestParseSkipWorktree(t *testing.T) {
+	lsFiles := "H Gemfile\nS services/api/package.json\nH services/web/package.json\nS docs/Gemfile.lock\n"
+	expected := map[string]bool{
+		filepath.FromSlash("services/api/package.json"): true,
+		filepath.FromSlash("docs/Gemfile.lock"):         true,
+	}
+	if excluded := parseSkipWorktree([]byte(lsFiles)); !reflect.DeepEqual(excluded, expected) {
+		t.Errorf("Expected %v, got %v", expected, excluded)
+	}
+}
+
+func TestPruneDependencyFilesWithSparseCheckout(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-sparse")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	defer func(excluded func() map[string]bool) { sparseExcludedFiles = excluded }(sparseExcludedFiles)
+	sparseExcludedFiles = func() map[string]bool {
+		return map[string]bool{filepath.FromSlash("services/api/package.json"): true}
+	}
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		fmt.Fprintln(w, `[{"path": "services/api/package.json", "sha": "api SHA-1"}, {"path": "services/old/package.json", "sha": "old SHA-1"}]`)
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	removed, err := PruneDependencyFiles("blah", true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(removed) != 1 || removed[0].Path != filepath.FromSlash("services/old/package.json") {
+		t.Errorf("Files left out of the sparse checkout shouldn't be removed, got: %v", removed)
+	}
+
+	// Files left out of the sparse checkout are skipped instead of failing
+	dfiles, err := LookupDependencyFiles([]string{"services/api/package.json"})
+	if err != nil || len(dfiles) != 0 {
+		t.Errorf("Expected no files, got: %v, %v", dfiles, err)
+	}
+}
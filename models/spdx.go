@@ -0,0 +1,162 @@
+package models
+
+import (
+	"crypto/sha1"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/url"
+	"regexp"
+	"strings"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// See https://spdx.github.io/spdx-spec/v2.3/
+const (
+	SPDX_VERSION      = "SPDX-2.3"
+	SPDX_NOASSERTION  = "NOASSERTION"
+	SPDX_DOCUMENT_ID  = "SPDXRef-DOCUMENT"
+	SPDX_NAMESPACE    = "https://gemnasium.com/spdx/"
+	SPDX_DATA_LICENSE = "CC0-1.0"
+)
+
+type SPDXDocument struct {
+	SPDXVersion       string             `json:"spdxVersion"`
+	DataLicense       string             `json:"dataLicense"`
+	SPDXID            string             `json:"SPDXID"`
+	Name              string             `json:"name"`
+	DocumentNamespace string             `json:"documentNamespace"`
+	CreationInfo      SPDXCreationInfo   `json:"creationInfo"`
+	DocumentDescribes []string           `json:"documentDescribes"`
+	Packages          []SPDXPackage      `json:"packages"`
+	Relationships     []SPDXRelationship `json:"relationships"`
+}
+
+type SPDXCreationInfo struct {
+	Created  string   `json:"created"`
+	Creators []string `json:"creators"`
+}
+
+type SPDXPackage struct {
+	Name             string            `json:"name"`
+	SPDXID           string            `json:"SPDXID"`
+	VersionInfo      string            `json:"versionInfo,omitempty"`
+	Originator       string            `json:"originator,omitempty"`
+	DownloadLocation string            `json:"downloadLocation"`
+	FilesAnalyzed    bool              `json:"filesAnalyzed"`
+	LicenseConcluded string            `json:"licenseConcluded"`
+	LicenseDeclared  string            `json:"licenseDeclared"`
+	CopyrightText    string            `json:"copyrightText"`
+	SourceInfo       string            `json:"sourceInfo,omitempty"`
+	ExternalRefs     []SPDXExternalRef `json:"externalRefs,omitempty"`
+}
+
+type SPDXExternalRef struct {
+	ReferenceCategory string `json:"referenceCategory"`
+	ReferenceType     string `json:"referenceType"`
+	ReferenceLocator  string `json:"referenceLocator"`
+}
+
+type SPDXRelationship struct {
+	SPDXElementID      string `json:"spdxElementId"`
+	RelationshipType   string `json:"relationshipType"`
+	RelatedSPDXElement string `json:"relatedSpdxElement"`
+}
+
+// SPDX ids may only contain letters, numbers, "." and "-"
+var spdxIDInvalidChars = regexp.MustCompile(`[^A-Za-z0-9.]+`)
+
+func spdxID(parts ...string) string {
+	return "SPDXRef-" + spdxIDInvalidChars.ReplaceAllString(strings.Join(parts, "-"), "-")
+}
+
+// Package URL of a locked package, see https://github.com/package-url/purl-spec
+func purl(pkg LockedPackage) string {
+	name := url.PathEscape(pkg.Name)
+	switch pkg.Ecosystem {
+	case "composer", "golang":
+		// Namespaced names keep their slashes
+		name = strings.Replace(name, "%2F", "/", -1)
+	case "npm":
+		// The scope is the namespace, with its "@" encoded
+		name = strings.Replace(strings.Replace(name, "%2F", "/", 1), "@", "%40", 1)
+	}
+	return fmt.Sprintf("pkg:%s/%s@%s", pkg.Ecosystem, name, url.PathEscape(pkg.Version))
+}
+
+// Build an SPDX document of the packages resolved in the lockfiles among
+// dfiles. The project is the root package, depending on all of them.
+func NewSPDXDocument(name string, dfiles []*DependencyFile, now time.Time) *SPDXDocument {
+	rootID := spdxID("Project", name)
+	doc := &SPDXDocument{
+		SPDXVersion: SPDX_VERSION,
+		DataLicense: SPDX_DATA_LICENSE,
+		SPDXID:      SPDX_DOCUMENT_ID,
+		Name:        name,
+		CreationInfo: SPDXCreationInfo{
+			Created:  now.UTC().Format(time.RFC3339),
+			Creators: []string{"Tool: gemnasium-toolbelt-" + config.VERSION},
+		},
+		DocumentDescribes: []string{rootID},
+		Packages: []SPDXPackage{{
+			Name:             name,
+			SPDXID:           rootID,
+			DownloadLocation: SPDX_NOASSERTION,
+			LicenseConcluded: SPDX_NOASSERTION,
+			LicenseDeclared:  SPDX_NOASSERTION,
+			CopyrightText:    SPDX_NOASSERTION,
+		}},
+		Relationships: []SPDXRelationship{{SPDX_DOCUMENT_ID, "DESCRIBES", rootID}},
+	}
+
+	seen := map[string]bool{}
+	for _, df := range dfiles {
+		for _, pkg := range df.LockedPackages() {
+			id := spdxID("Package", pkg.Ecosystem, pkg.Name, pkg.Version)
+			if seen[id] {
+				continue
+			}
+			seen[id] = true
+			license := SPDX_NOASSERTION
+			if pkg.License != "" {
+				license = pkg.License
+			}
+			p := SPDXPackage{
+				Name:             pkg.Name,
+				SPDXID:           id,
+				VersionInfo:      pkg.Version,
+				DownloadLocation: SPDX_NOASSERTION,
+				LicenseConcluded: SPDX_NOASSERTION,
+				LicenseDeclared:  license,
+				CopyrightText:    SPDX_NOASSERTION,
+				SourceInfo:       "resolved in " + pkg.File,
+				ExternalRefs:     []SPDXExternalRef{{"PACKAGE-MANAGER", "purl", purl(pkg)}},
+			}
+			if pkg.Originator != "" {
+				p.Originator = "Person: " + pkg.Originator
+			}
+			doc.Packages = append(doc.Packages, p)
+			doc.Relationships = append(doc.Relationships, SPDXRelationship{rootID, "DEPENDS_ON", id})
+		}
+	}
+
+	// The namespace must be unique to this document
+	hash := sha1.New()
+	fmt.Fprint(hash, doc.CreationInfo.Created)
+	for _, p := range doc.Packages {
+		fmt.Fprint(hash, p.SPDXID)
+	}
+	doc.DocumentNamespace = fmt.Sprintf("%s%s-%x", SPDX_NAMESPACE, url.PathEscape(name), hash.Sum(nil))
+	return doc
+}
+
+// Write the document as json to path
+func (doc *SPDXDocument) Save(path string) error {
+	body, err := json.MarshalIndent(doc, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(path, body, 0644)
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestNewSPDXDocument(t *testing.T) {
+	dfiles := []*DependencyFile{
+		{Path: "Gemfile.lock", Content: []byte("GEM\n  remote: https://rubygems.org/\n  specs:\n    rack (1.6.4)\n    rails (4.2.0)\n      rack (~> 1.6)\n\nDEPENDENCIES\n  rails\n")},
+		{Path: "web/package-lock.json", Content: []byte(`{"lockfileVersion": 2, "packages": {
+  "": {"name": "web"},
+  "node_modules/@babel/core": {"version": "7.0.0", "license": "MIT"},
+  "node_modules/old": {"version": "0.1.0", "license": {"type": "BSD"}}
+}}`)},
+		{Path: "composer.lock", Content: []byte(`{"packages": [{"name": "monolog/monolog", "version": "v1.0.2", "license": ["MIT", "Apache-2.0"], "authors": [{"name": "Jordi Boggiano"}]}]}`)},
+		{Path: "go.sum", Content: []byte("example.com/lib v1.0.0 h1:abc=\nexample.com/lib v1.0.0/go.mod h1:def=\nexample.com/old v0.1.0/go.mod h1:ghi=\n")},
+		{Path: "Gemfile", Content: []byte("gem 'rails'\n")},
+	}
+	now := time.Date(2018, 1, 2, 3, 4, 5, 0, time.UTC)
+	doc := NewSPDXDocument("my project", dfiles, now)
+
+	if doc.SPDXVersion != "SPDX-2.3" || doc.CreationInfo.Created != "2018-01-02T03:04:05Z" {
+		t.Errorf("Unexpected document header: %+v", doc)
+	}
+	if !strings.HasPrefix(doc.DocumentNamespace, "https://gemnasium.com/spdx/my%20project-") {
+		t.Errorf("Unexpected namespace: %s", doc.DocumentNamespace)
+	}
+	if doc.DocumentDescribes[0] != "SPDXRef-Project-my-project" {
+		t.Errorf("Unexpected root package: %v", doc.DocumentDescribes)
+	}
+
+	type summary struct{ id, version, originator, license, purl string }
+	packages := []summary{}
+	for _, p := range doc.Packages[1:] {
+		packages = append(packages, summary{p.SPDXID, p.VersionInfo, p.Originator, p.LicenseDeclared, p.ExternalRefs[0].ReferenceLocator})
+	}
+	expected := []summary{
+		{"SPDXRef-Package-gem-rack-1.6.4", "1.6.4", "", "NOASSERTION", "pkg:gem/rack@1.6.4"},
+		{"SPDXRef-Package-gem-rails-4.2.0", "4.2.0", "", "NOASSERTION", "pkg:gem/rails@4.2.0"},
+		{"SPDXRef-Package-npm-babel-core-7.0.0", "7.0.0", "", "MIT", "pkg:npm/%40babel/core@7.0.0"},
+		{"SPDXRef-Package-npm-old-0.1.0", "0.1.0", "", "NOASSERTION", "pkg:npm/old@0.1.0"},
+		{"SPDXRef-Package-composer-monolog-monolog-1.0.2", "1.0.2", "Person: Jordi Boggiano", "(MIT OR Apache-2.0)", "pkg:composer/monolog/monolog@1.0.2"},
+		{"SPDXRef-Package-golang-example.com-lib-v1.0.0", "v1.0.0", "", "NOASSERTION", "pkg:golang/example.com/lib@v1.0.0"},
+	}
+	if !reflect.DeepEqual(packages, expected) {
+		t.Errorf("Expected packages:\n%v\nGot:\n%v", expected, packages)
+	}
+	if len(doc.Relationships) != len(expected)+1 || doc.Relationships[1].RelationshipType != "DEPENDS_ON" {
+		t.Errorf("Unexpected relationships: %v", doc.Relationships)
+	}
+}
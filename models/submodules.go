@@ -0,0 +1,129 @@
+package models
+
+import (
+	"bufio"
+	"io/ioutil"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/config"
+	"gopkg.in/yaml.v1"
+)
+
+// A git submodule, declared in .gitmodules
+type Submodule struct {
+	Path string
+	URL  string
+}
+
+var (
+	gitmodulesSection = regexp.MustCompile(`^\[submodule\s+"(.*)"\]$`)
+	gitmodulesKey     = regexp.MustCompile(`^(\w+)\s*=\s*(.*)$`)
+)
+
+// Read the submodules declared in the .gitmodules file of dir, and the ones of
+// the submodules themselves. Paths are relative to dir.
+func readSubmodules(dir string) ([]Submodule, error) {
+	f, err := os.Open(filepath.Join(dir, ".gitmodules"))
+	if os.IsNotExist(err) {
+		return []Submodule{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	submodules := []Submodule{}
+	var current *Submodule
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if gitmodulesSection.MatchString(line) {
+			submodules = append(submodules, Submodule{})
+			current = &submodules[len(submodules)-1]
+			continue
+		}
+		m := gitmodulesKey.FindStringSubmatch(line)
+		if m == nil || current == nil {
+			continue
+		}
+		switch m[1] {
+		case "path":
+			current.Path = path.Clean(m[2])
+		case "url":
+			current.URL = m[2]
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	all := []Submodule{}
+	for _, sm := range submodules {
+		if sm.Path == "" {
+			continue
+		}
+		all = append(all, sm)
+		nested, err := readSubmodules(filepath.Join(dir, filepath.FromSlash(sm.Path)))
+		if err != nil {
+			return nil, err
+		}
+		for _, n := range nested {
+			all = append(all, Submodule{Path: path.Join(sm.Path, n.Path), URL: n.URL})
+		}
+	}
+	return all, nil
+}
+
+// Return the project slug matching a git remote: the path of the repository,
+// like "owner/repo" for "git@github.com:owner/repo.git"
+func slugFromRemote(url string) string {
+	url = strings.TrimSuffix(strings.TrimSuffix(url, "/"), ".git")
+	if i := strings.Index(url, "://"); i >= 0 {
+		// https://github.com/owner/repo, ssh://git@host:22/owner/repo
+		url = url[i+3:]
+		if j := strings.Index(url, "/"); j >= 0 {
+			return url[j+1:]
+		}
+		return ""
+	}
+	// git@github.com:owner/repo
+	if i := strings.Index(url, ":"); i >= 0 {
+		return url[i+1:]
+	}
+	return ""
+}
+
+// Return the project slug of the submodule: the project_slug of its
+// .gemnasium.yml if any, or the one matching its remote
+func (sm Submodule) ProjectSlug() string {
+	data, err := ioutil.ReadFile(filepath.Join(filepath.FromSlash(sm.Path), config.CONFIG_FILE_PATH))
+	if err == nil {
+		var c map[string]interface{}
+		if yaml.Unmarshal(data, &c) == nil {
+			if slug, ok := c["project_slug"].(string); ok && slug != "" {
+				return slug
+			}
+		}
+	}
+	return slugFromRemote(sm.URL)
+}
+
+// Return the projects of the submodules of the current directory, by path, so
+// their files are pushed to their own projects (see config.ProjectMappings)
+func SubmoduleMappings() (map[string]string, error) {
+	submodules, err := readSubmodules(".")
+	if err != nil {
+		return nil, err
+	}
+	mappings := map[string]string{}
+	for _, sm := range submodules {
+		if slug := sm.ProjectSlug(); slug != "" {
+			mappings[sm.Path] = slug
+		}
+	}
+	return mappings, nil
+}
@@ -0,0 +1,62 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestSlugFromRemote(t *testing.T) {
+	tests := map[string]string{
+		"git@github.com:owner/repo.git":         "owner/repo",
+		"https://github.com/owner/repo.git":     "owner/repo",
+		"https://gitlab.com/group/sub/repo/":    "group/sub/repo",
+		"ssh://git@git.example.com:22/org/repo": "org/repo",
+		"../relative":                           "",
+	}
+	for url, expected := range tests {
+		if slug := slugFromRemote(url); slug != expected {
+			t.Errorf("%s: expected '%s', got '%s'", url, expected, slug)
+		}
+	}
+}
+
+func TestSubmoduleMappings(t *testing.T) {
+	dir, err := ioutil.TempDir("", "gemnasium-submodules")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	wd, _ := os.Getwd()
+	os.Chdir(dir)
+	defer os.Chdir(wd)
+
+	ioutil.WriteFile(".gitmodules", []byte(`[submodule "api"]
+	path = services/api
+	url = git@github.com:org/api.git
+[submodule "web"]
+	path = services/web
+	url = https://github.com/org/web
+`), 0644)
+	os.MkdirAll(filepath.Join("services", "web", "vendor", "ui"), 0755)
+	ioutil.WriteFile(filepath.Join("services", "web", ".gemnasium.yml"), []byte("project_slug: 0123456789abcdef\n"), 0644)
+	ioutil.WriteFile(filepath.Join("services", "web", ".gitmodules"), []byte(`[submodule "ui"]
+	path = vendor/ui
+	url = git@github.com:org/ui.git
+`), 0644)
+
+	mappings, err := SubmoduleMappings()
+	if err != nil {
+		t.Fatal(err)
+	}
+	expected := map[string]string{
+		"services/api":           "org/api",
+		"services/web":           "0123456789abcdef",
+		"services/web/vendor/ui": "org/ui",
+	}
+	if !reflect.DeepEqual(mappings, expected) {
+		t.Errorf("Expected mappings: %v, got: %v", expected, mappings)
+	}
+}
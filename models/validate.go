@@ -0,0 +1,119 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// Func template for local validators. They must be fast, as they're run on
+// every file before being sent to Gemnasium.
+type ValidateFunc func(content []byte) error
+
+// Validators by file name
+var validators = map[string][]ValidateFunc{
+	"package.json":        []ValidateFunc{validateJSON},
+	"npm-shrinkwrap.json": []ValidateFunc{validateJSON, validateNoConflictMarkers},
+	"composer.json":       []ValidateFunc{validateJSON},
+	"composer.lock":       []ValidateFunc{validateJSON, validateNoConflictMarkers},
+	"bower.json":          []ValidateFunc{validateJSON},
+	"Gemfile.lock":        []ValidateFunc{validateNoConflictMarkers, validateGemfileLock},
+	"yarn.lock":           []ValidateFunc{validateNoConflictMarkers},
+	"go.mod":              []ValidateFunc{validateNoConflictMarkers},
+	"go.sum":              []ValidateFunc{validateNoConflictMarkers},
+	"Cargo.lock":          []ValidateFunc{validateNoConflictMarkers},
+	"pom.xml":             []ValidateFunc{validateNoConflictMarkers, validateXML},
+	"gradle.lockfile":     []ValidateFunc{validateNoConflictMarkers},
+	"packages.config":     []ValidateFunc{validateNoConflictMarkers, validateXML},
+	"packages.lock.json":  []ValidateFunc{validateJSON, validateNoConflictMarkers},
+	// Project files, by extension
+	".csproj": []ValidateFunc{validateNoConflictMarkers, validateXML},
+}
+
+var conflictMarker = regexp.MustCompile(`(?m)^(<<<<<<<|>>>>>>>) `)
+
+func validateJSON(content []byte) error {
+	var v interface{}
+	if err := json.Unmarshal(content, &v); err != nil {
+		return fmt.Errorf("invalid JSON: %s", err)
+	}
+	return nil
+}
+
+func validateXML(content []byte) error {
+	decoder := xml.NewDecoder(bytes.NewReader(content))
+	for {
+		_, err := decoder.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("invalid XML: %s", err)
+		}
+	}
+}
+
+func validateNoConflictMarkers(content []byte) error {
+	if conflictMarker.Match(content) {
+		return fmt.Errorf("unresolved merge conflict")
+	}
+	return nil
+}
+
+// A Gemfile.lock must have at least one source section (GEM, GIT or PATH) and
+// a DEPENDENCIES section.
+func validateGemfileLock(content []byte) error {
+	hasSource := false
+	for _, section := range []string{"GEM", "GIT", "PATH"} {
+		if bytes.HasPrefix(content, []byte(section+"\n")) || bytes.Contains(content, []byte("\n"+section+"\n")) {
+			hasSource = true
+		}
+	}
+	if !hasSource {
+		return fmt.Errorf("no GEM, GIT or PATH section found")
+	}
+	if !bytes.Contains(content, []byte("\nDEPENDENCIES\n")) {
+		return fmt.Errorf("no DEPENDENCIES section found")
+	}
+	return nil
+}
+
+// Run the local validators matching the file name
+func (df *DependencyFile) Validate() error {
+	name := filepath.Base(df.Path)
+	if filepath.Ext(name) == ".csproj" {
+		name = ".csproj"
+	}
+	if len(validators[name]) == 0 {
+		return nil
+	}
+	content, err := df.contents()
+	if err != nil {
+		return err
+	}
+	for _, validate := range validators[name] {
+		if err := validate(content); err != nil {
+			return fmt.Errorf("%s: %s", df.Path, err)
+		}
+	}
+	return nil
+}
+
+// Validate all files, and return an error listing the invalid ones
+func ValidateDependencyFiles(dfiles []*DependencyFile) error {
+	invalid := []string{}
+	for _, df := range dfiles {
+		if err := df.Validate(); err != nil {
+			invalid = append(invalid, err.Error())
+		}
+	}
+	if len(invalid) > 0 {
+		return fmt.Errorf("%d invalid dependency file(s):\n%s\n", len(invalid), strings.Join(invalid, "\n"))
+	}
+	return nil
+}
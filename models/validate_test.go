@@ -0,0 +1,48 @@
+package models
+
+import "testing"
+
+func TestValidate(t *testing.T) {
+	var tt = []struct {
+		Path    string
+		Content string
+		Valid   bool
+	}{
+		{"package.json", `{"name": "app"}`, true},
+		{"js/package.json", `{"name": "app",}`, false},
+		{"composer.lock", "{\n<<<<<<< HEAD\n}", false},
+		{"Gemfile.lock", "GEM\n  specs:\n    rails (4.0.3)\n\nDEPENDENCIES\n  rails\n", true},
+		{"Gemfile.lock", "GEM\n  specs:\n    rails (4.0.3)\n", false},
+		{"Gemfile.lock", "GEM\n  specs:\n<<<<<<< HEAD\n    rails (4.0.3)\n=======\n    rails (4.0.4)\n>>>>>>> master\n\nDEPENDENCIES\n  rails\n", false},
+		{"pom.xml", "<project><modelVersion>4.0.0</modelVersion></project>", true},
+		{"pom.xml", "<project><modelVersion>4.0.0</project>", false},
+		{"src/App/App.csproj", `<Project Sdk="Microsoft.NET.Sdk"><ItemGroup><PackageReference Include="Newtonsoft.Json" Version="12.0.3" /></ItemGroup></Project>`, true},
+		{"src/App/App.csproj", `<Project Sdk="Microsoft.NET.Sdk"><ItemGroup></Project>`, false},
+		{"Gemfile", "not validated", true},
+	}
+	for _, test := range tt {
+		df := &DependencyFile{Path: test.Path, Content: []byte(test.Content)}
+		err := df.Validate()
+		if test.Valid && err != nil {
+			t.Errorf("%s should be valid, got: %s", test.Path, err)
+		}
+		if !test.Valid && err == nil {
+			t.Errorf("%s should be invalid:\n%s", test.Path, test.Content)
+		}
+	}
+}
+
+func TestValidateDependencyFiles(t *testing.T) {
+	dfiles := []*DependencyFile{
+		&DependencyFile{Path: "package.json", Content: []byte(`{}`)},
+		&DependencyFile{Path: "composer.json", Content: []byte(`{`)},
+	}
+	err := ValidateDependencyFiles(dfiles)
+	if err == nil {
+		t.Fatal("composer.json should be invalid")
+	}
+	expected := "1 invalid dependency file(s):\ncomposer.json: invalid JSON: unexpected end of JSON input\n"
+	if err.Error() != expected {
+		t.Errorf("Expected error:\n%s\nGot:\n%s", expected, err)
+	}
+}
@@ -0,0 +1,136 @@
+package models
+
+import (
+	"crypto/sha1"
+	"crypto/sha256"
+	"fmt"
+	"hash"
+	"os"
+	"path/filepath"
+	"sync"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// Version control system of the project: the SHA of dependency files is
+// computed the same way it does, so that it matches the one of the versioned
+// files
+type VCS interface {
+	Name() string
+	// Hash of a file content of the given size, the content is written to it
+	NewHash(size int64) hash.Hash
+}
+
+// Git blob SHA1: the content, prefixed with a "blob <size>" header
+type gitVCS struct{}
+
+func (gitVCS) Name() string { return "git" }
+
+func (gitVCS) NewHash(size int64) hash.Hash {
+	h := sha1.New()
+	fmt.Fprintf(h, "blob %d\x00", size)
+	return h
+}
+
+// Mercurial file revision hash, computed without the parents of the revision
+// (two null revisions): it only matches the hash of the first revision of a
+// file. The parents are in the filelog, which the hash can't read (it only
+// gets the content), so later revisions get a hash of their own, stable for
+// a given content but different from the one of Mercurial. Files starting
+// with "\x01\n" (metadata marker) are escaped by Mercurial, and won't match
+// either.
+type hgVCS struct{}
+
+func (hgVCS) Name() string { return "hg" }
+
+func (hgVCS) NewHash(size int64) hash.Hash {
+	h := sha1.New()
+	h.Write(make([]byte, 2*sha1.Size))
+	return h
+}
+
+// SHA1 checksum of the content, as stored by Subversion (1.7+) in its
+// pristine store (see svn info)
+type svnVCS struct{}
+
+func (svnVCS) Name() string { return "svn" }
+
+func (svnVCS) NewHash(size int64) hash.Hash { return sha1.New() }
+
+// Plain SHA256 of the content, when the project isn't versioned
+type noVCS struct{}
+
+func (noVCS) Name() string { return "none" }
+
+func (noVCS) NewHash(size int64) hash.Hash { return sha256.New() }
+
+// Supported VCS, with the directory marking the root of their working copies
+var vcsMarkers = []struct {
+	dir string
+	vcs VCS
+}{
+	{".git", gitVCS{}},
+	{".hg", hgVCS{}},
+	{".svn", svnVCS{}},
+}
+
+var (
+	detectedVCS VCS
+	detectOnce  sync.Once
+)
+
+// Return the VCS with the given name (git, hg, svn or none)
+func LookupVCS(name string) (VCS, error) {
+	if name == (noVCS{}).Name() {
+		return noVCS{}, nil
+	}
+	for _, m := range vcsMarkers {
+		if m.vcs.Name() == name {
+			return m.vcs, nil
+		}
+	}
+	return nil, fmt.Errorf("Unknown VCS: %s (expected git, hg, svn or none)", name)
+}
+
+// Return the VCS of the working copy dir belongs to, looking for a .git, .hg
+// or .svn directory in dir and its parents. A .git file (submodules and
+// worktrees) counts too.
+func DetectVCS(dir string) VCS {
+	dir, err := filepath.Abs(dir)
+	if err != nil {
+		return noVCS{}
+	}
+	for {
+		for _, m := range vcsMarkers {
+			if _, err := os.Stat(filepath.Join(dir, m.dir)); err == nil {
+				return m.vcs
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			return noVCS{}
+		}
+		dir = parent
+	}
+}
+
+// Return the VCS used to compute the SHA of dependency files: the one set with
+// config.VCS, or the one detected from the working directory (once)
+func CurrentVCS() VCS {
+	if config.VCS != "" {
+		if vcs, err := LookupVCS(config.VCS); err == nil {
+			return vcs
+		}
+	}
+	detectOnce.Do(func() {
+		detectedVCS = DetectVCS(".")
+	})
+	return detectedVCS
+}
+
+// Return the SHA of content, computed like vcs does
+func ContentSHA(vcs VCS, content []byte) string {
+	h := vcs.NewHash(int64(len(content)))
+	h.Write(content)
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
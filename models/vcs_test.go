@@ -0,0 +1,67 @@
+package models
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// The SHAs of the test fixtures are git blob SHA1s, whatever the working
+// directory of the tests
+func TestMain(m *testing.M) {
+	config.VCS = "git"
+	os.Exit(m.Run())
+}
+
+func TestContentSHA(t *testing.T) {
+	content := []byte("hello\n")
+	expected := map[string]string{
+		"git":  "ce013625030ba8dba906f756967f9e9ca394464a",
+		"hg":   "2c186c8c5bc0df5af5b951afe407d803f9e6b8c9",
+		"svn":  "f572d396fae9206628714fb2ce00f72e94f2258f",
+		"none": "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03",
+	}
+	for name, sha := range expected {
+		vcs, err := LookupVCS(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got := ContentSHA(vcs, content); got != sha {
+			t.Errorf("%s: expected %s, got %s", name, sha, got)
+		}
+	}
+	if _, err := LookupVCS("cvs"); err == nil {
+		t.Error("Expected an error for an unknown VCS")
+	}
+}
+
+func TestDetectVCS(t *testing.T) {
+	files := map[string]string{
+		"git/.git/HEAD":      "ref: refs/heads/master\n",
+		"git/app/Gemfile":    "",
+		"hg/.hg/requires":    "",
+		"svn/.svn/wc.db":     "",
+		"plain/Gemfile.lock": "",
+	}
+	inTempDir(t, files, func() {
+		expected := map[string]string{"git/app": "git", "hg": "hg", "svn": "svn", "plain": "none"}
+		for dir, name := range expected {
+			if vcs := DetectVCS(filepath.FromSlash(dir)); vcs.Name() != name {
+				t.Errorf("%s: expected %s, got %s", dir, name, vcs.Name())
+			}
+		}
+
+		// The SHA of files is computed like the VCS does
+		if err := ioutil.WriteFile("plain/Gemfile.lock", []byte("hello\n"), 0644); err != nil {
+			t.Fatal(err)
+		}
+		config.VCS = "none"
+		defer func() { config.VCS = "git" }()
+		if sha, err := GetFileSHA1("plain/Gemfile.lock"); err != nil || sha != "5891b5b522d5df086d0ff0b110fbd9d21bb4fc7163af34d08286a2e846f6be03" {
+			t.Errorf("Expected the SHA256 of the file, got %s (%v)", sha, err)
+		}
+	})
+}
@@ -0,0 +1,54 @@
+package models
+
+import (
+	"errors"
+	"fmt"
+	"runtime"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/gemnasium"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/utils"
+	"github.com/wsxiaoys/terminal/color"
+)
+
+const CLIENT_VERSION_PATH = "/toolbelt/version"
+
+// Toolbelt versions supported by the API
+type ClientVersion struct {
+	MinimumVersion string `json:"minimum_version"`
+	LatestVersion  string `json:"latest_version"`
+}
+
+// Display the version and build info of the toolbelt. With check, the API is
+// queried for the minimum supported version, and an error is returned if the
+// toolbelt is too old.
+func ShowVersion(check bool) error {
+	fmt.Fprint(Output, i18n.T("version.header", config.VERSION))
+	fmt.Fprint(Output, i18n.T("version.commit", config.Commit))
+	fmt.Fprint(Output, i18n.T("version.build_date", config.BuildDate))
+	fmt.Fprint(Output, i18n.T("version.go_version", runtime.Version(), runtime.GOOS, runtime.GOARCH))
+	if !check {
+		return nil
+	}
+
+	var cv ClientVersion
+	opts := &gemnasium.APIRequestOptions{
+		Method: "GET",
+		URI:    CLIENT_VERSION_PATH,
+		Result: &cv,
+	}
+	if err := gemnasium.APIRequest(opts); err != nil {
+		return err
+	}
+	fmt.Fprintln(Output)
+	if cv.MinimumVersion != "" && utils.CompareVersions(config.VERSION, cv.MinimumVersion) < 0 {
+		return errors.New(i18n.T("version.too_old", cv.MinimumVersion))
+	}
+	if cv.LatestVersion != "" && utils.CompareVersions(config.VERSION, cv.LatestVersion) < 0 {
+		fmt.Fprint(Output, color.Sprint("@y"+i18n.T("version.available", cv.LatestVersion)))
+		return nil
+	}
+	fmt.Fprintln(Output, color.Sprint("@g"+i18n.T("version.up_to_date")))
+	return nil
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestShowVersionCheck(t *testing.T) {
+	minimum := "0.1.0"
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != CLIENT_VERSION_PATH {
+			t.Errorf("Unexpected request: %s", r.URL.Path)
+		}
+		fmt.Fprintf(w, `{"minimum_version": "%s", "latest_version": "99.0.0"}`, minimum)
+	}))
+	defer ts.Close()
+	config.APIEndpoint = ts.URL
+
+	if err := ShowVersion(true); err != nil {
+		t.Errorf("Expected version to be supported, got: %s", err)
+	}
+	minimum = "99.0.0"
+	if err := ShowVersion(true); err == nil {
+		t.Error("Expected an error when the version is too old")
+	}
+}
@@ -0,0 +1,140 @@
+package models
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+const (
+	WEBHOOK_EVENT_NEW_ADVISORY   = "new_advisory"
+	WEBHOOK_EVENT_ALERT_OPENED   = "alert_opened"
+	WEBHOOK_EVENT_ALERT_RESOLVED = "alert_resolved"
+)
+
+var WebhookEvents = []string{WEBHOOK_EVENT_NEW_ADVISORY, WEBHOOK_EVENT_ALERT_OPENED, WEBHOOK_EVENT_ALERT_RESOLVED}
+
+// Payload of the webhooks sent by Gemnasium. Alert events have the project
+// and the alert, new advisories only the advisory.
+type WebhookPayload struct {
+	Event       string    `json:"event"`
+	DeliveryID  string    `json:"delivery_id"`
+	DeliveredAt time.Time `json:"delivered_at"`
+	Advisory    *Advisory `json:"advisory,omitempty"`
+	Project     *Project  `json:"project,omitempty"`
+	Alert       *Alert    `json:"alert,omitempty"`
+}
+
+// Build the payload of a simulated event, about a made up advisory of the
+// package. The project is required for alert events.
+func NewWebhookPayload(event string, pkg Package, project string, now time.Time) (*WebhookPayload, error) {
+	known := false
+	for _, e := range WebhookEvents {
+		known = known || e == event
+	}
+	if !known {
+		return nil, errors.New(i18n.T("webhooks.unknown_event", event, strings.Join(WebhookEvents, ", ")))
+	}
+	if pkg.Slug == "" {
+		pkg.Slug = strings.ToLower(pkg.Type) + "/" + pkg.Name
+	}
+	published := now.Add(-time.Hour).UTC().Truncate(time.Second)
+	advisory := Advisory{
+		ID:               1,
+		Title:            fmt.Sprintf("Simulated vulnerability in %s", pkg.Name),
+		Identifier:       "GMS-SIMULATED-0001",
+		Description:      "This advisory was generated by 'gemnasium webhooks simulate', it doesn't describe a real vulnerability.",
+		Solution:         "Upgrade to a cured version.",
+		AffectedVersions: "< 0.0.0",
+		CuredVersions:    ">= 0.0.0",
+		Package:          pkg,
+		Links:            []string{"https://gemnasium.com/" + pkg.Slug},
+		PublishedAt:      &published,
+		CVSSScore:        7.5,
+	}
+	payload := &WebhookPayload{
+		Event:       event,
+		DeliveryID:  fmt.Sprintf("simulated-%d", now.UnixNano()),
+		DeliveredAt: now.UTC().Truncate(time.Second),
+	}
+	if event == WEBHOOK_EVENT_NEW_ADVISORY {
+		payload.Advisory = &advisory
+		return payload, nil
+	}
+
+	if project == "" {
+		return nil, errors.New(i18n.T("webhooks.missing_project", event))
+	}
+	alert := Alert{ID: 1, Advisory: advisory, OpenAt: published, Status: "open"}
+	if event == WEBHOOK_EVENT_ALERT_RESOLVED {
+		alert.Status = "closed"
+		alert.ClosedAt = &payload.DeliveredAt
+	}
+	payload.Project = &Project{Slug: project, Name: project}
+	payload.Alert = &alert
+	return payload, nil
+}
+
+// Signature of the payload, as sent in the X-Gemnasium-Signature header:
+// sha256=<hex HMAC-SHA256 of the body>
+func WebhookSignature(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Post the payload to the endpoint as Gemnasium would, signed with
+// config.WebhookSecret if set. Non-2xx responses are errors.
+func PostWebhook(url string, payload *WebhookPayload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequest("POST", url, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "Gemnasium-Webhook")
+	req.Header.Set("X-Gemnasium-Event", payload.Event)
+	req.Header.Set("X-Gemnasium-Delivery", payload.DeliveryID)
+	if config.WebhookSecret != "" {
+		req.Header.Set("X-Gemnasium-Signature", WebhookSignature(config.WebhookSecret, body))
+	}
+	resp, err := utils.NewHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return fmt.Errorf("POST %s: %s\n%s", url, resp.Status, msg)
+	}
+	fmt.Fprint(Output, i18n.T("webhooks.delivered", payload.Event, payload.DeliveryID, url, resp.Status))
+	return nil
+}
+
+// Generate the payload of the event and post it to url, or print it when
+// url is empty (ie: to save it as a fixture)
+func SimulateWebhook(url, event string, pkg Package, project string) error {
+	payload, err := NewWebhookPayload(event, pkg, project, time.Now())
+	if err != nil {
+		return err
+	}
+	if url == "" {
+		return utils.PrintJSON(Output, payload)
+	}
+	return PostWebhook(url, payload)
+}
@@ -0,0 +1,69 @@
+package models
+
+import (
+	"bytes"
+	"encoding/json"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestNewWebhookPayload(t *testing.T) {
+	now := time.Date(2015, 3, 4, 10, 0, 0, 0, time.UTC)
+	payload, err := NewWebhookPayload(WEBHOOK_EVENT_NEW_ADVISORY, Package{Name: "rails", Type: "Rubygem"}, "", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Advisory == nil || payload.Advisory.Package.Slug != "rubygem/rails" || payload.Alert != nil || payload.Project != nil {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+
+	payload, err = NewWebhookPayload(WEBHOOK_EVENT_ALERT_RESOLVED, Package{Name: "rails", Type: "Rubygem"}, "blog", now)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if payload.Alert == nil || payload.Alert.Status != "closed" || payload.Alert.ClosedAt == nil || payload.Project.Slug != "blog" {
+		t.Errorf("Unexpected payload: %+v", payload)
+	}
+
+	if _, err := NewWebhookPayload(WEBHOOK_EVENT_ALERT_OPENED, Package{Name: "rails"}, "", now); err == nil {
+		t.Error("Expected an error without project")
+	}
+	if _, err := NewWebhookPayload("deploy", Package{Name: "rails"}, "", now); err == nil {
+		t.Error("Expected an error for an unknown event")
+	}
+}
+
+func TestSimulateWebhook(t *testing.T) {
+	config.WebhookSecret = "s3cr3t"
+	defer func() { config.WebhookSecret = "" }()
+	var received WebhookPayload
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, _ := ioutil.ReadAll(r.Body)
+		if r.Header.Get("X-Gemnasium-Event") != "alert_opened" || r.Header.Get("X-Gemnasium-Signature") != WebhookSignature("s3cr3t", body) {
+			t.Errorf("Unexpected headers: %v", r.Header)
+		}
+		json.Unmarshal(body, &received)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer ts.Close()
+
+	var out bytes.Buffer
+	Output = &out
+	defer func() { Output = os.Stdout }()
+	if err := SimulateWebhook(ts.URL, WEBHOOK_EVENT_ALERT_OPENED, Package{Name: "lodash", Type: "Npm"}, "blog"); err != nil {
+		t.Fatal(err)
+	}
+	if received.Alert == nil || received.Alert.Advisory.Package.Name != "lodash" || received.Project.Slug != "blog" {
+		t.Errorf("Unexpected payload: %+v", received)
+	}
+	if !strings.Contains(out.String(), "204 No Content") {
+		t.Errorf("Unexpected output: %s", out.String())
+	}
+}
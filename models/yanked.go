@@ -0,0 +1,64 @@
+package models
+
+import (
+	"fmt"
+	"io"
+
+	"github.com/gemnasium/toolbelt/i18n"
+	"github.com/gemnasium/toolbelt/registry"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// A dependency locked to a version yanked from its registry (or not available
+// anymore), and the next release it can be moved to, if any
+type YankedDependency struct {
+	Package       Package `json:"package"`
+	LockedVersion string  `json:"locked"`
+	FixVersion    string  `json:"fix,omitempty"`
+}
+
+// Return the dependencies locked to yanked versions, according to their
+// registries (responses are cached, see registry.CacheTTL).
+// Dependencies from unsupported registries are ignored.
+func YankedDependencies(deps []Dependency) []YankedDependency {
+	yanked := []YankedDependency{}
+	for i, rv := range fetchRegistryVersions(deps) {
+		if rv.Err != nil || deps[i].LockedVersion == "" {
+			continue
+		}
+		locked := registry.FindVersion(rv.Versions, deps[i].LockedVersion)
+		if locked != nil && !locked.Yanked {
+			continue
+		}
+		yd := YankedDependency{Package: deps[i].Package, LockedVersion: deps[i].LockedVersion}
+		if next := registry.NextRelease(rv.Versions, deps[i].LockedVersion); next != nil {
+			yd.FixVersion = next.Number
+		}
+		yanked = append(yanked, yd)
+	}
+	return yanked
+}
+
+// Display the dependencies of the project locked to yanked versions, and
+// return them
+func ListYankedDependencies(project *Project) ([]YankedDependency, error) {
+	deps, err := project.Dependencies()
+	if err != nil {
+		return nil, err
+	}
+
+	yanked := YankedDependencies(deps)
+	if len(yanked) == 0 {
+		fmt.Fprint(Output, i18n.T("deps.no_yanked"))
+		return yanked, nil
+	}
+	return yanked, RenderYankedAsTable(yanked, Output)
+}
+
+func RenderYankedAsTable(yanked []YankedDependency, output io.Writer) error {
+	table := utils.NewTable(output, "Dependencies", "Type", "Locked", "Fix")
+	for _, yd := range yanked {
+		table.Append(yd.Package.Name, yd.Package.Type, yd.LockedVersion, yd.FixVersion)
+	}
+	return table.Render()
+}
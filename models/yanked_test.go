@@ -0,0 +1,46 @@
+package models
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"testing"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/registry"
+)
+
+func TestYankedDependencies(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/versions/rails.json":
+			fmt.Fprintln(w, `[{"number": "4.0.4", "created_at": "2014-03-14T18:04:18.000Z"},
+				{"number": "4.0.3", "created_at": "2014-02-18T18:04:18.000Z"}]`)
+		case "/left-pad":
+			fmt.Fprintln(w, `{"versions": {"1.1.0": {}}, "time": {"1.0.0": "2016-03-22T00:00:00.000Z", "1.1.0": "2016-03-23T00:00:00.000Z"}}`)
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	registry.RubygemsURL = ts.URL
+	registry.NpmURL = ts.URL
+	config.CacheDir = ""
+
+	deps := []Dependency{
+		Dependency{Package: Package{Name: "rails", Type: "rubygem"}, LockedVersion: "4.0.3"},
+		Dependency{Package: Package{Name: "rails", Type: "rubygem"}, LockedVersion: "4.0.2"},
+		Dependency{Package: Package{Name: "rails", Type: "rubygem"}, LockedVersion: "4.0.5"},
+		Dependency{Package: Package{Name: "left-pad", Type: "npm"}, LockedVersion: "1.0.0"},
+	}
+	expected := []YankedDependency{
+		YankedDependency{Package: deps[1].Package, LockedVersion: "4.0.2", FixVersion: "4.0.3"},
+		YankedDependency{Package: deps[2].Package, LockedVersion: "4.0.5"},
+		YankedDependency{Package: deps[3].Package, LockedVersion: "1.0.0", FixVersion: "1.1.0"},
+	}
+	yanked := YankedDependencies(deps)
+	if !reflect.DeepEqual(yanked, expected) {
+		t.Errorf("Expected:\n%#v\nGot:\n%#v", expected, yanked)
+	}
+}
@@ -0,0 +1,55 @@
+package registry
+
+import (
+	"crypto/sha1"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/gemnasium/toolbelt/cache"
+)
+
+// Registry responses are kept for a day. Set to 0 to disable the cache.
+var CacheTTL = 24 * time.Hour
+
+// Return the path of the cache file for url, or an empty string if caching is
+// disabled.
+func cachePath(url string) string {
+	dir := cache.Dir(cache.HTTP)
+	if dir == "" || CacheTTL == 0 {
+		return ""
+	}
+	return filepath.Join(dir, fmt.Sprintf("%x.json", sha1.Sum([]byte(url))))
+}
+
+// Return the cached response body for url, if any and not expired
+func readCache(url string) ([]byte, bool) {
+	path := cachePath(url)
+	if path == "" {
+		return nil, false
+	}
+	info, err := os.Stat(path)
+	if err != nil || time.Since(info.ModTime()) > CacheTTL {
+		return nil, false
+	}
+	body, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	return body, true
+}
+
+// Cache is best effort: errors are ignored, the response will be fetched again
+// next time.
+func writeCache(url string, body []byte) {
+	path := cachePath(url)
+	if path == "" {
+		return
+	}
+	if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+		return
+	}
+	ioutil.WriteFile(path, body, 0644)
+}
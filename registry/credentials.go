@@ -0,0 +1,118 @@
+package registry
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/gemnasium/toolbelt/auth"
+	"gopkg.in/yaml.v1"
+)
+
+// Set the credentials of a request to a mirror, from the configs the package
+// managers run by the updaters read:
+// - Bundler: BUNDLE_<HOST> env vars, then .bundle/config and ~/.bundle/config
+// - npm: _authToken and _auth of .npmrc and ~/.npmrc
+// Hosts unknown to both use the .netrc file (like the Gemnasium API
+// credentials). Credentials in the URL always take precedence.
+func setCredentials(req *http.Request) {
+	if req.URL.User != nil {
+		return
+	}
+	if creds := bundlerCredentials(req.URL.Hostname()); creds != "" {
+		user, pass := creds, ""
+		if i := strings.Index(creds, ":"); i != -1 {
+			user, pass = creds[:i], creds[i+1:]
+		}
+		req.SetBasicAuth(user, pass)
+		return
+	}
+	if key, value := npmCredentials(req.URL.Host + req.URL.Path); key == "_authToken" {
+		req.Header.Set("Authorization", "Bearer "+value)
+		return
+	} else if key == "_auth" {
+		req.Header.Set("Authorization", "Basic "+value)
+		return
+	}
+	if user, pass := auth.HostCreds(req.URL.Host); user != "" || pass != "" {
+		req.SetBasicAuth(user, pass)
+	}
+}
+
+// Return the "user:password" Bundler has for host: BUNDLE_GEMS__EXAMPLE__COM
+// for gems.example.com ("." becomes "__", and "-" becomes "___")
+func bundlerCredentials(host string) string {
+	key := "BUNDLE_" + strings.ToUpper(strings.NewReplacer(".", "__", "-", "___").Replace(host))
+	if creds := os.Getenv(key); creds != "" {
+		return creds
+	}
+	configs := []string{filepath.Join(".bundle", "config")}
+	if home, err := os.UserHomeDir(); err == nil {
+		configs = append(configs, filepath.Join(home, ".bundle", "config"))
+	}
+	for _, path := range configs {
+		content, err := ioutil.ReadFile(path)
+		if err != nil {
+			continue
+		}
+		settings := map[string]interface{}{}
+		if err := yaml.Unmarshal(content, &settings); err != nil {
+			continue
+		}
+		if creds, ok := settings[key].(string); ok && creds != "" {
+			return creds
+		}
+	}
+	return ""
+}
+
+// Return the credential npm has for the URL (without scheme), and its key:
+// _authToken (a bearer token) or _auth (base64 of "user:password"). Scoped
+// settings like "//npm.example.com/repository/npm/:_authToken=${NPM_TOKEN}"
+// apply to the URLs starting with their prefix, the longest prefix wins.
+func npmCredentials(rawurl string) (key, value string) {
+	configs := []string{".npmrc"}
+	if home, err := os.UserHomeDir(); err == nil {
+		configs = append(configs, filepath.Join(home, ".npmrc"))
+	}
+	for _, path := range configs {
+		f, err := os.Open(path)
+		if err != nil {
+			continue
+		}
+		prefix := ""
+		scanner := bufio.NewScanner(f)
+		for scanner.Scan() {
+			line := strings.TrimSpace(scanner.Text())
+			if !strings.HasPrefix(line, "//") {
+				continue
+			}
+			i := strings.Index(line, "=")
+			if i == -1 {
+				continue
+			}
+			setting, v := strings.TrimSpace(line[:i]), os.ExpandEnv(strings.TrimSpace(line[i+1:]))
+			j := strings.LastIndex(setting, ":")
+			if j == -1 {
+				continue
+			}
+			p, k := strings.TrimPrefix(setting[:j], "//"), setting[j+1:]
+			if (k != "_authToken" && k != "_auth") || !strings.HasPrefix(rawurl, p) || len(p) < len(prefix) {
+				continue
+			}
+			// A token wins over a password for the same prefix
+			if len(p) == len(prefix) && key == "_authToken" {
+				continue
+			}
+			prefix, key, value = p, k, v
+		}
+		f.Close()
+		if key != "" {
+			return key, value
+		}
+	}
+	return "", ""
+}
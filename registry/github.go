@@ -0,0 +1,57 @@
+package registry
+
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"regexp"
+	"time"
+)
+
+var (
+	GitHubURL = "https://api.github.com"
+
+	ErrIssuesUnsupported = errors.New("Issues are only fetched from GitHub repositories")
+)
+
+var githubRepositoryRegexp = regexp.MustCompile(`github\.com[/:]([\w.-]+)/([\w.-]+?)(?:\.git)?(?:[/#?]|$)`)
+
+// Issues opened and closed on the repository of a package since a date
+type IssueTrend struct {
+	Opened int `json:"opened"`
+	Closed int `json:"closed"`
+}
+
+// Growth of the open issues over the period, ie: "+12" or "-3"
+func (t IssueTrend) String() string {
+	return fmt.Sprintf("%+d", t.Opened-t.Closed)
+}
+
+// Count the issues opened and closed on the GitHub repository since the date,
+// with the search API (pull requests are excluded). Requests are anonymous
+// unless api.github.com has credentials in the .netrc file (ie: a token as
+// password), and the search API only allows a few anonymous requests per
+// minute.
+func FetchIssueTrend(repository string, since time.Time) (*IssueTrend, error) {
+	m := githubRepositoryRegexp.FindStringSubmatch(repository)
+	if m == nil {
+		return nil, ErrIssuesUnsupported
+	}
+	count := func(qualifier string) (int, error) {
+		var result struct {
+			TotalCount int `json:"total_count"`
+		}
+		q := fmt.Sprintf("repo:%s/%s is:issue %s:>=%s", m[1], m[2], qualifier, since.Format("2006-01-02"))
+		err := getJSON(fmt.Sprintf("%s/search/issues?per_page=1&q=%s", GitHubURL, url.QueryEscape(q)), &result, false)
+		return result.TotalCount, err
+	}
+	var trend IssueTrend
+	var err error
+	if trend.Opened, err = count("created"); err != nil {
+		return nil, err
+	}
+	if trend.Closed, err = count("closed"); err != nil {
+		return nil, err
+	}
+	return &trend, nil
+}
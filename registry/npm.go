@@ -0,0 +1,123 @@
+package registry
+
+import (
+	"crypto/ecdsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Fetch the versions of a package from the npm registry
+// https://github.com/npm/registry/blob/master/docs/responses/package-metadata.md
+func NpmVersions(name string, fresh bool) ([]Version, error) {
+	var pkg struct {
+		Versions map[string]struct {
+			Deprecated string `json:"deprecated"`
+			Dist       struct {
+				Integrity  string      `json:"integrity"`
+				Signatures []Signature `json:"signatures"`
+			} `json:"dist"`
+		} `json:"versions"`
+		Time map[string]time.Time `json:"time"`
+	}
+	err := getJSON(fmt.Sprintf("%s/%s", baseURL("npm", NpmURL), npmEscape(name)), &pkg, fresh)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []Version{}
+	for number, v := range pkg.Versions {
+		versions = append(versions, Version{
+			Number:     number,
+			CreatedAt:  pkg.Time[number],
+			Prerelease: strings.Contains(number, "-"),
+			Deprecated: v.Deprecated,
+			Integrity:  v.Dist.Integrity,
+			Signatures: v.Dist.Signatures,
+		})
+	}
+	// Unpublished versions are still listed in "time", but not in "versions"
+	for number, createdAt := range pkg.Time {
+		if _, ok := pkg.Versions[number]; ok || number == "created" || number == "modified" {
+			continue
+		}
+		versions = append(versions, Version{Number: number, CreatedAt: createdAt, Yanked: true})
+	}
+	return versions, nil
+}
+
+// Fetch the dependencies of a package version from the npm registry
+func NpmDependencies(name, version string) (map[string]string, error) {
+	var pkg struct {
+		Dependencies map[string]string `json:"dependencies"`
+	}
+	err := getJSON(fmt.Sprintf("%s/%s/%s", baseURL("npm", NpmURL), npmEscape(name), version), &pkg, false)
+	if err != nil {
+		return nil, err
+	}
+	if pkg.Dependencies == nil {
+		pkg.Dependencies = map[string]string{}
+	}
+	return pkg.Dependencies, nil
+}
+
+// Verify the ECDSA registry signatures of an npm package version.
+// The signed message is "<name>@<version>:<integrity>".
+// https://docs.npmjs.com/about-registry-signatures
+func NpmVerifySignatures(name string, version *Version) error {
+	if len(version.Signatures) == 0 {
+		return fmt.Errorf("%s@%s is not signed", name, version.Number)
+	}
+
+	var keys struct {
+		Keys []struct {
+			KeyID   string     `json:"keyid"`
+			Key     string     `json:"key"`
+			Expires *time.Time `json:"expires"`
+		} `json:"keys"`
+	}
+	err := getJSON(fmt.Sprintf("%s/-/npm/v1/keys", baseURL("npm", NpmURL)), &keys, false)
+	if err != nil {
+		return err
+	}
+
+	digest := sha256.Sum256([]byte(fmt.Sprintf("%s@%s:%s", name, version.Number, version.Integrity)))
+	for _, sig := range version.Signatures {
+		for _, k := range keys.Keys {
+			if k.KeyID != sig.KeyID {
+				continue
+			}
+			if k.Expires != nil && k.Expires.Before(version.CreatedAt) {
+				return fmt.Errorf("%s@%s is signed with an expired key (%s)", name, version.Number, k.KeyID)
+			}
+			der, err := base64.StdEncoding.DecodeString(k.Key)
+			if err != nil {
+				return err
+			}
+			pub, err := x509.ParsePKIXPublicKey(der)
+			if err != nil {
+				return err
+			}
+			ecdsaPub, ok := pub.(*ecdsa.PublicKey)
+			if !ok {
+				return fmt.Errorf("Unsupported registry key type for %s", k.KeyID)
+			}
+			rawSig, err := base64.StdEncoding.DecodeString(sig.Sig)
+			if err != nil {
+				return err
+			}
+			if ecdsa.VerifyASN1(ecdsaPub, digest[:], rawSig) {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("%s@%s: invalid registry signature", name, version.Number)
+}
+
+// Scoped packages must be requested as @scope%2fname
+func npmEscape(name string) string {
+	return strings.Replace(name, "/", "%2f", 1)
+}
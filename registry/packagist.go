@@ -0,0 +1,54 @@
+package registry
+
+import (
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Fetch the versions of a composer package from packagist.org
+// The p2 metadata is minified: each version only lists the fields which differ
+// from the previous one.
+// https://packagist.org/apidoc#get-package-metadata-v2
+func PackagistVersions(name string, fresh bool) ([]Version, error) {
+	var pkg struct {
+		Packages map[string][]map[string]interface{} `json:"packages"`
+	}
+	err := getJSON(fmt.Sprintf("%s/p2/%s.json", baseURL("packagist", PackagistURL), strings.ToLower(name)), &pkg, fresh)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []Version{}
+	current := map[string]interface{}{}
+	for _, fields := range pkg.Packages[strings.ToLower(name)] {
+		for k, v := range fields {
+			if v == "__unset" {
+				delete(current, k)
+				continue
+			}
+			current[k] = v
+		}
+
+		number, _ := current["version_normalized"].(string)
+		if n, ok := current["version"].(string); ok {
+			number = strings.TrimPrefix(n, "v")
+		}
+		v := Version{Number: number, Prerelease: strings.Contains(number, "-")}
+		if t, ok := current["time"].(string); ok {
+			v.CreatedAt, _ = time.Parse(time.RFC3339, t)
+		}
+		// "abandoned" is either true, or the name of the suggested replacement
+		switch abandoned := current["abandoned"].(type) {
+		case bool:
+			if abandoned {
+				v.Deprecated = "abandoned"
+			}
+		case string:
+			v.Deprecated = "abandoned"
+			v.Replacement = abandoned
+		}
+		versions = append(versions, v)
+	}
+	return versions, nil
+}
@@ -0,0 +1,221 @@
+package registry
+
+/*
+Registry clients, used to fetch metadata about packages directly from the
+official registries (rubygems.org, npmjs.org, ...), without going through the
+Gemnasium API.
+*/
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+var (
+	RubygemsURL  = "https://rubygems.org"
+	NpmURL       = "https://registry.npmjs.org"
+	PackagistURL = "https://repo.packagist.org"
+
+	ErrVersionsUnsupported     = errors.New("There is no registry client for this package type")
+	ErrSignaturesUnsupported   = errors.New("Package signatures are not supported by this registry")
+	ErrDependenciesUnsupported = errors.New("Package dependencies are not supported by this registry")
+)
+
+type Signature struct {
+	KeyID string `json:"keyid"`
+	Sig   string `json:"sig"`
+}
+
+// A released version of a package, as known by its registry
+type Version struct {
+	Number      string
+	CreatedAt   time.Time
+	Yanked      bool
+	Prerelease  bool
+	Deprecated  string
+	Replacement string
+	Integrity   string
+	Signatures  []Signature
+}
+
+// Func template for registry clients. Return all the versions released for the
+// given package name, bypassing the cache if fresh is set.
+type VersionsFunc func(name string, fresh bool) ([]Version, error)
+
+var versionsFuncs = map[string]VersionsFunc{
+	"rubygem":   RubygemsVersions,
+	"npm":       NpmVersions,
+	"packagist": PackagistVersions,
+}
+
+// Func template for signature checks. Return nil if the signatures of the given
+// version are valid, and ErrSignaturesUnsupported if the registry doesn't sign
+// packages.
+type VerifySignaturesFunc func(name string, version *Version) error
+
+var signaturesVerifiers = map[string]VerifySignaturesFunc{
+	"npm": NpmVerifySignatures,
+}
+
+// Func template for dependency lookups. Return the runtime requirements of the
+// given version, by package name (ie: "activesupport" => "= 4.0.3").
+type DependenciesFunc func(name, version string) (map[string]string, error)
+
+var dependenciesFuncs = map[string]DependenciesFunc{
+	"rubygem": RubygemsDependencies,
+	"npm":     NpmDependencies,
+}
+
+// Return the versions of the package, fetched from the registry matching
+// packageType (case insensitive, ie: "Rubygem" or "rubygem"), or
+// ErrVersionsUnsupported if there is no client for its registry.
+func Versions(packageType, name string) ([]Version, error) {
+	fn, ok := versionsFuncs[strings.ToLower(packageType)]
+	if !ok {
+		return nil, ErrVersionsUnsupported
+	}
+	return fn(name, false)
+}
+
+// Same as Versions, without the cache. Used by the checks protecting from bad
+// releases (yanked, signatures, cooldown), which can't wait for the cache to
+// expire.
+func FreshVersions(packageType, name string) ([]Version, error) {
+	fn, ok := versionsFuncs[strings.ToLower(packageType)]
+	if !ok {
+		return nil, ErrVersionsUnsupported
+	}
+	return fn(name, true)
+}
+
+// Check the signatures of the given version against the registry keys.
+func VerifySignatures(packageType, name string, version *Version) error {
+	fn, ok := signaturesVerifiers[strings.ToLower(packageType)]
+	if !ok {
+		return ErrSignaturesUnsupported
+	}
+	return fn(name, version)
+}
+
+// Return the runtime requirements of the given version of the package, or
+// ErrDependenciesUnsupported if the registry doesn't publish them.
+func Dependencies(packageType, name, version string) (map[string]string, error) {
+	fn, ok := dependenciesFuncs[strings.ToLower(packageType)]
+	if !ok {
+		return nil, ErrDependenciesUnsupported
+	}
+	return fn(name, version)
+}
+
+// Return the highest version, ignoring yanked and prerelease versions, or nil if
+// there is none. Backports (ie: 4.2.9 released after 5.1.0) aren't the latest
+// version, even when they're the last release.
+func LatestVersion(versions []Version) *Version {
+	var latest *Version
+	for i, v := range versions {
+		if v.Yanked || v.Prerelease {
+			continue
+		}
+		if latest == nil || utils.CompareVersions(v.Number, latest.Number) > 0 {
+			latest = &versions[i]
+		}
+	}
+	return latest
+}
+
+// Return the most recently released version, ignoring yanked and prerelease
+// versions, or nil if there is none (see LatestVersion for the highest one).
+func LastRelease(versions []Version) *Version {
+	var last *Version
+	for i, v := range versions {
+		if v.Yanked || v.Prerelease {
+			continue
+		}
+		if last == nil || v.CreatedAt.After(last.CreatedAt) {
+			last = &versions[i]
+		}
+	}
+	return last
+}
+
+// Return the lowest release above number (ie: the fix of a yanked version),
+// ignoring yanked and prerelease versions, or nil if there is none.
+func NextRelease(versions []Version, number string) *Version {
+	var next *Version
+	for i, v := range versions {
+		if v.Yanked || v.Prerelease || utils.CompareVersions(v.Number, number) <= 0 {
+			continue
+		}
+		if next == nil || utils.CompareVersions(v.Number, next.Number) < 0 {
+			next = &versions[i]
+		}
+	}
+	return next
+}
+
+// Return the version matching number, or nil if it's not part of versions.
+func FindVersion(versions []Version, number string) *Version {
+	for i, v := range versions {
+		if v.Number == number {
+			return &versions[i]
+		}
+	}
+	return nil
+}
+
+// Return the base URL of the registry for packageType: the mirror set in config
+// if any, official otherwise.
+func baseURL(packageType, official string) string {
+	if mirror, ok := config.RegistryMirrors[packageType]; ok && mirror != "" {
+		return strings.TrimSuffix(mirror, "/")
+	}
+	return official
+}
+
+var client = utils.NewHTTPClient()
+
+// GET rawurl and decode the json response into result
+// Responses are cached for CacheTTL (see cache.go). With fresh, the cache is
+// only written.
+// Mirrors requiring authentication can either have credentials in their URL,
+// or in the configs of the package managers (see setCredentials).
+func getJSON(rawurl string, result interface{}, fresh bool) error {
+	if body, ok := readCache(rawurl); ok && !fresh {
+		return json.Unmarshal(body, result)
+	}
+
+	req, err := http.NewRequest("GET", rawurl, nil)
+	if err != nil {
+		return err
+	}
+	setCredentials(req)
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return fmt.Errorf("%s: package not found", req.URL.Redacted())
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: %s", req.URL.Redacted(), resp.Status)
+	}
+	body, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if err = json.Unmarshal(body, result); err != nil {
+		return err
+	}
+	writeCache(rawurl, body)
+	return nil
+}
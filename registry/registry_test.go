@@ -0,0 +1,274 @@
+package registry
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+func TestRubygemsVersions(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v1/versions/rails.json" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		fmt.Fprintln(w, `[
+			{"number": "4.0.3", "platform": "ruby", "created_at": "2014-02-18T18:04:18.000Z"},
+			{"number": "4.0.3", "platform": "java", "created_at": "2014-02-18T18:05:18.000Z"},
+			{"number": "4.0.2", "platform": "ruby", "created_at": "2013-12-03T19:09:05.000Z"}
+		]`)
+	}))
+	defer ts.Close()
+	RubygemsURL = ts.URL
+	config.CacheDir = ""
+
+	versions, err := Versions("Rubygem", "rails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 2 {
+		t.Fatalf("Expected 2 versions, got: %#v", versions)
+	}
+	v := FindVersion(versions, "4.0.3")
+	if v == nil {
+		t.Fatal("Version 4.0.3 should be found")
+	}
+	if v.CreatedAt.Year() != 2014 {
+		t.Errorf("Invalid creation date: %s", v.CreatedAt)
+	}
+	if FindVersion(versions, "4.0.4") != nil {
+		t.Error("Version 4.0.4 should not be found")
+	}
+}
+
+func TestVersionsWithUnknownPackageType(t *testing.T) {
+	_, err := Versions("Unknown", "foo")
+	if err != ErrVersionsUnsupported {
+		t.Errorf("Expected ErrVersionsUnsupported, got: %v", err)
+	}
+}
+
+func TestNpmVersionsAndSignatures(t *testing.T) {
+	priv, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKIXPublicKey(&priv.PublicKey)
+	if err != nil {
+		t.Fatal(err)
+	}
+	digest := sha256.Sum256([]byte("@scope/pkg@1.0.0:sha512-abc"))
+	sig, err := ecdsa.SignASN1(rand.Reader, priv, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.RequestURI {
+		case "/@scope%2fpkg":
+			fmt.Fprintf(w, `{
+				"versions": {
+					"1.0.0": {"dist": {"integrity": "sha512-abc", "signatures": [{"keyid": "SHA256:test", "sig": "%s"}]}},
+					"1.0.1": {"dist": {"integrity": "sha512-def", "signatures": [{"keyid": "SHA256:test", "sig": "%s"}]}}
+				},
+				"time": {
+					"created": "2015-01-01T00:00:00.000Z",
+					"1.0.0": "2015-01-01T00:00:00.000Z",
+					"1.0.1": "2015-02-01T00:00:00.000Z",
+					"1.0.2": "2015-03-01T00:00:00.000Z"
+				}
+			}`, base64.StdEncoding.EncodeToString(sig), base64.StdEncoding.EncodeToString(sig))
+		case "/-/npm/v1/keys":
+			fmt.Fprintf(w, `{"keys": [{"keyid": "SHA256:test", "key": "%s", "expires": null}]}`, base64.StdEncoding.EncodeToString(der))
+		default:
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer ts.Close()
+	NpmURL = ts.URL
+	config.CacheDir = ""
+
+	versions, err := Versions("Npm", "@scope/pkg")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 3 {
+		t.Fatalf("Expected 3 versions, got: %#v", versions)
+	}
+	if v := FindVersion(versions, "1.0.2"); v == nil || !v.Yanked {
+		t.Errorf("Version 1.0.2 should be yanked, got: %#v", v)
+	}
+
+	if err := VerifySignatures("Npm", "@scope/pkg", FindVersion(versions, "1.0.0")); err != nil {
+		t.Errorf("Signature of 1.0.0 should be valid, got: %s", err)
+	}
+	// 1.0.1 has been signed with the 1.0.0 message
+	if err := VerifySignatures("Npm", "@scope/pkg", FindVersion(versions, "1.0.1")); err == nil {
+		t.Error("Signature of 1.0.1 should be invalid")
+	}
+	if err := VerifySignatures("Rubygem", "rails", &Version{Number: "1.0.0"}); err != ErrSignaturesUnsupported {
+		t.Errorf("Expected ErrSignaturesUnsupported, got: %v", err)
+	}
+}
+
+func TestGetJSONWithCache(t *testing.T) {
+	var calls int
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls += 1
+		fmt.Fprintln(w, `{"name": "rails"}`)
+	}))
+	defer ts.Close()
+
+	dir, err := ioutil.TempDir("", "gemnasium-cache")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	config.CacheDir = dir
+	defer func() { config.CacheDir = "" }()
+
+	for i := 0; i < 2; i++ {
+		var result map[string]string
+		if err := getJSON(ts.URL, &result, false); err != nil {
+			t.Fatal(err)
+		}
+		if result["name"] != "rails" {
+			t.Errorf("Unexpected result: %#v", result)
+		}
+	}
+	if calls != 1 {
+		t.Errorf("Second request should have been served from cache, got %d calls", calls)
+	}
+	var result map[string]string
+	if err := getJSON(ts.URL, &result, true); err != nil || calls != 2 {
+		t.Errorf("Fresh requests shouldn't be served from cache, got %d calls (%v)", calls, err)
+	}
+}
+
+func TestMirror(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/gems/rubygems/api/v1/versions/rails.json" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		if user, pass, ok := r.BasicAuth(); !ok || user != "user" || pass != "secret" {
+			t.Errorf("Expected credentials from mirror URL, got: %s:%s", user, pass)
+		}
+		fmt.Fprintln(w, `[{"number": "4.0.3", "platform": "ruby", "created_at": "2014-02-18T18:04:18.000Z"}]`)
+	}))
+	defer ts.Close()
+	RubygemsURL = "http://rubygems.invalid"
+	config.CacheDir = ""
+	config.RegistryMirrors["rubygem"] = strings.Replace(ts.URL, "http://", "http://user:secret@", 1) + "/api/gems/rubygems/"
+	defer delete(config.RegistryMirrors, "rubygem")
+
+	versions, err := Versions("Rubygem", "rails")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(versions) != 1 {
+		t.Errorf("Expected 1 version, got: %#v", versions)
+	}
+}
+
+func TestMirrorCredentials(t *testing.T) {
+	home := os.Getenv("HOME")
+	os.Setenv("HOME", os.TempDir())
+	defer os.Setenv("HOME", home)
+
+	// Bundler credentials of the host
+	os.Setenv("BUNDLE_GEMS__EXAMPLE___CORP__COM", "user:secret")
+	defer os.Unsetenv("BUNDLE_GEMS__EXAMPLE___CORP__COM")
+	req, _ := http.NewRequest("GET", "https://gems.example-corp.com/api/v1/versions/rails.json", nil)
+	setCredentials(req)
+	if user, pass, ok := req.BasicAuth(); !ok || user != "user" || pass != "secret" {
+		t.Errorf("Expected credentials from BUNDLE_GEMS__EXAMPLE___CORP__COM, got: %s:%s", user, pass)
+	}
+
+	// npm token of the longest matching prefix
+	os.Setenv("NPM_TOKEN", "t0ken")
+	defer os.Unsetenv("NPM_TOKEN")
+	npmrc := "registry=https://npm.example.com/repository/npm/\n" +
+		"//npm.example.com/:_auth=" + base64.StdEncoding.EncodeToString([]byte("user:secret")) + "\n" +
+		"//npm.example.com/repository/npm/:_authToken=${NPM_TOKEN}\n"
+	if err := ioutil.WriteFile(".npmrc", []byte(npmrc), 0644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(".npmrc")
+	var tt = []struct {
+		URL           string
+		Authorization string
+	}{
+		{"https://npm.example.com/repository/npm/rails", "Bearer t0ken"},
+		{"https://npm.example.com/other/rails", "Basic " + base64.StdEncoding.EncodeToString([]byte("user:secret"))},
+		{"https://npm.example.org/repository/npm/rails", ""},
+	}
+	for _, test := range tt {
+		req, _ := http.NewRequest("GET", test.URL, nil)
+		setCredentials(req)
+		if auth := req.Header.Get("Authorization"); auth != test.Authorization {
+			t.Errorf("%s: expected Authorization %q, got %q", test.URL, test.Authorization, auth)
+		}
+	}
+}
+
+func TestRubygemsDependencies(t *testing.T) {
+	ts := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/v2/rubygems/rails/versions/4.0.3.json" {
+			t.Errorf("Unexpected path: %s", r.URL.Path)
+		}
+		fmt.Fprintln(w, `{"number": "4.0.3", "dependencies": {
+			"development": [{"name": "rake", "requirements": ">= 0"}],
+			"runtime": [{"name": "activesupport", "requirements": "= 4.0.3"}, {"name": "bundler", "requirements": "< 2.0, >= 1.3.0"}]
+		}}`)
+	}))
+	defer ts.Close()
+	RubygemsURL = ts.URL
+	config.CacheDir = ""
+
+	deps, err := Dependencies("Rubygem", "rails", "4.0.3")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(deps) != 2 || deps["activesupport"] != "= 4.0.3" || deps["bundler"] != "< 2.0, >= 1.3.0" {
+		t.Errorf("Unexpected dependencies: %#v", deps)
+	}
+	if _, err := Dependencies("packagist", "monolog/monolog", "1.0.0"); err != ErrDependenciesUnsupported {
+		t.Errorf("Expected ErrDependenciesUnsupported, got: %v", err)
+	}
+}
+
+func TestFetchIssueTrendWithoutGitHubRepository(t *testing.T) {
+	for _, repository := range []string{"https://gitlab.com/org/app", "https://example.com/github.com"} {
+		if _, err := FetchIssueTrend(repository, time.Now()); err != ErrIssuesUnsupported {
+			t.Errorf("%s: expected ErrIssuesUnsupported, got: %v", repository, err)
+		}
+	}
+}
+
+func TestLatestVersion(t *testing.T) {
+	versions := []Version{
+		Version{Number: "4.2.8", CreatedAt: time.Date(2017, 2, 21, 0, 0, 0, 0, time.UTC)},
+		Version{Number: "5.1.0", CreatedAt: time.Date(2017, 4, 27, 0, 0, 0, 0, time.UTC)},
+		Version{Number: "4.2.9", CreatedAt: time.Date(2017, 6, 26, 0, 0, 0, 0, time.UTC)},
+		Version{Number: "5.2.0.rc1", CreatedAt: time.Date(2017, 11, 27, 0, 0, 0, 0, time.UTC), Prerelease: true},
+	}
+	if latest := LatestVersion(versions); latest == nil || latest.Number != "5.1.0" {
+		t.Errorf("Expected 5.1.0 to be the latest version, not the 4.2.9 backport, got: %v", latest)
+	}
+	if last := LastRelease(versions); last == nil || last.Number != "4.2.9" {
+		t.Errorf("Expected 4.2.9 to be the last release, got: %v", last)
+	}
+}
@@ -0,0 +1,87 @@
+package registry
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+)
+
+var ErrRepositoryUnsupported = errors.New("Source repositories are not published by this registry")
+
+// Func template for source repository lookups. Return the URL of the source
+// repository of the package, as published by its maintainers (it may be
+// empty).
+type RepositoryFunc func(name string) (string, error)
+
+var repositoryFuncs = map[string]RepositoryFunc{
+	"rubygem":   RubygemsRepository,
+	"npm":       NpmRepository,
+	"packagist": PackagistRepository,
+}
+
+// Return the URL of the source repository of the package, or
+// ErrRepositoryUnsupported if the registry doesn't publish it.
+func Repository(packageType, name string) (string, error) {
+	fn, ok := repositoryFuncs[strings.ToLower(packageType)]
+	if !ok {
+		return "", ErrRepositoryUnsupported
+	}
+	return fn(name)
+}
+
+// Source code URI of the gem, or its homepage
+// http://guides.rubygems.org/rubygems-org-api/#gem-methods
+func RubygemsRepository(name string) (string, error) {
+	var gem struct {
+		SourceCodeURI string `json:"source_code_uri"`
+		HomepageURI   string `json:"homepage_uri"`
+	}
+	err := getJSON(fmt.Sprintf("%s/api/v1/gems/%s.json", baseURL("rubygem", RubygemsURL), url.PathEscape(name)), &gem, false)
+	if err != nil {
+		return "", err
+	}
+	if gem.SourceCodeURI != "" {
+		return gem.SourceCodeURI, nil
+	}
+	return gem.HomepageURI, nil
+}
+
+// "repository" of the package.json, either a string or {"type", "url"}
+func NpmRepository(name string) (string, error) {
+	var pkg struct {
+		Repository json.RawMessage `json:"repository"`
+	}
+	err := getJSON(fmt.Sprintf("%s/%s", baseURL("npm", NpmURL), npmEscape(name)), &pkg, false)
+	if err != nil || len(pkg.Repository) == 0 {
+		return "", err
+	}
+	var repository struct {
+		URL string `json:"url"`
+	}
+	if err := json.Unmarshal(pkg.Repository, &repository.URL); err == nil {
+		return repository.URL, nil
+	}
+	err = json.Unmarshal(pkg.Repository, &repository)
+	return repository.URL, err
+}
+
+// Source URL of the latest version of the composer package
+func PackagistRepository(name string) (string, error) {
+	var pkg struct {
+		Packages map[string][]struct {
+			Source struct {
+				URL string `json:"url"`
+			} `json:"source"`
+		} `json:"packages"`
+	}
+	err := getJSON(fmt.Sprintf("%s/p2/%s.json", baseURL("packagist", PackagistURL), strings.ToLower(name)), &pkg, false)
+	if err != nil {
+		return "", err
+	}
+	if versions := pkg.Packages[strings.ToLower(name)]; len(versions) > 0 {
+		return versions[0].Source.URL, nil
+	}
+	return "", nil
+}
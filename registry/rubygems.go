@@ -0,0 +1,56 @@
+package registry
+
+import (
+	"fmt"
+	"net/url"
+	"time"
+)
+
+// Fetch the versions of a gem from rubygems.org
+// Yanked versions are not listed by the API.
+// http://guides.rubygems.org/rubygems-org-api/#gem-version-methods
+func RubygemsVersions(name string, fresh bool) ([]Version, error) {
+	var gemVersions []struct {
+		Number     string    `json:"number"`
+		Platform   string    `json:"platform"`
+		Prerelease bool      `json:"prerelease"`
+		CreatedAt  time.Time `json:"created_at"`
+	}
+	err := getJSON(fmt.Sprintf("%s/api/v1/versions/%s.json", baseURL("rubygem", RubygemsURL), url.QueryEscape(name)), &gemVersions, fresh)
+	if err != nil {
+		return nil, err
+	}
+
+	versions := []Version{}
+	for _, gv := range gemVersions {
+		// Platform specific gems are released with the same number
+		if gv.Platform != "" && gv.Platform != "ruby" {
+			continue
+		}
+		versions = append(versions, Version{Number: gv.Number, CreatedAt: gv.CreatedAt, Prerelease: gv.Prerelease})
+	}
+	return versions, nil
+}
+
+// Fetch the runtime dependencies of a gem version from rubygems.org
+// http://guides.rubygems.org/rubygems-org-api-v2/
+func RubygemsDependencies(name, version string) (map[string]string, error) {
+	var gemVersion struct {
+		Dependencies struct {
+			Runtime []struct {
+				Name         string `json:"name"`
+				Requirements string `json:"requirements"`
+			} `json:"runtime"`
+		} `json:"dependencies"`
+	}
+	err := getJSON(fmt.Sprintf("%s/api/v2/rubygems/%s/versions/%s.json", baseURL("rubygem", RubygemsURL), url.PathEscape(name), url.PathEscape(version)), &gemVersion, false)
+	if err != nil {
+		return nil, err
+	}
+
+	deps := map[string]string{}
+	for _, d := range gemVersion.Dependencies.Runtime {
+		deps[d.Name] = d.Requirements
+	}
+	return deps, nil
+}
@@ -0,0 +1,38 @@
+// Package schemas embeds the JSON schemas of the documents printed with --json
+// (or --format json), named after their command (ie: "dependency_files.push").
+//
+// The documents are part of the interface of the toolbelt: a field can be
+// added to them, but renaming, removing or changing the type of a field
+// requires a new version of the schema (the "version" of the schema, also at
+// the end of its "$id").
+package schemas
+
+import (
+	"embed"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+//go:embed *.json
+var files embed.FS
+
+// Return the names of the schemas
+func Names() []string {
+	entries, _ := files.ReadDir(".")
+	names := []string{}
+	for _, entry := range entries {
+		names = append(names, strings.TrimSuffix(entry.Name(), ".json"))
+	}
+	sort.Strings(names)
+	return names
+}
+
+// Return the schema of the output of the command name
+func Get(name string) ([]byte, error) {
+	data, err := files.ReadFile(name + ".json")
+	if err != nil {
+		return nil, fmt.Errorf("Unknown schema: %s (available: %s)", name, strings.Join(Names(), ", "))
+	}
+	return data, nil
+}
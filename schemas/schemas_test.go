@@ -0,0 +1,158 @@
+package schemas
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/gemnasium/toolbelt/autoupdate"
+	liveeval "github.com/gemnasium/toolbelt/live-eval"
+	"github.com/gemnasium/toolbelt/models"
+	"github.com/gemnasium/toolbelt/utils"
+)
+
+// Check the documents printed by the commands against their schema, so that
+// renaming a field without a new version of the schema fails
+func TestSchemas(t *testing.T) {
+	now := time.Date(2016, 3, 1, 10, 0, 0, 0, time.UTC)
+	pkg := models.Package{Name: "rails", Slug: "gems/rails", Type: "Rubygem"}
+	dfile := models.DependencyFile{Path: "Gemfile.lock", SHA: "752751b8bf149dfea0d8b8b45cec23e6ac30b4a1", Content: []byte("GEM"), Size: 3, UpdatedAt: &now, RenamedFrom: "old/Gemfile.lock"}
+	documents := map[string]interface{}{
+		"dependency_files.list": []models.DependencyFile{dfile, {Path: "package.json"}},
+		"dependency_files.push": models.PushResult{
+			Added:       []models.PushedDependencyFile{{DependencyFile: dfile, Warnings: []string{"ignored section"}}},
+			Unsupported: []models.PushedDependencyFile{{DependencyFile: models.DependencyFile{Path: "Podfile"}, Error: "unsupported"}},
+			Renamed:     []models.Rename{{From: "old/Gemfile.lock", To: "Gemfile.lock"}},
+		},
+		"autoupdate.run": &autoupdate.RunSummary{Project: "blah", Results: map[string]int{"test_passed": 1}, StartedAt: now, FinishedAt: now, Err: errors.New("boom")},
+		"eval": liveeval.Report{
+			AsOf:          &now,
+			RuntimeStatus: "red",
+			Ecosystems:    []liveeval.Verdict{{Ecosystem: "ruby", Files: []string{"Gemfile"}, RuntimeStatus: "red", DevelopmentStatus: "green"}},
+			Dependencies:  []models.Dependency{{Requirement: "~> 4.2", LockedVersion: "4.2.0", Package: pkg, Type: "runtime", FirstLevel: true, Color: "red", Advisories: []models.Advisory{{ID: 1}}}},
+		},
+		"report.freshness": models.FreshnessReport{Libyears: 1.5, Dependencies: []models.DependencyFreshness{{Package: pkg, LockedVersion: "4.2.0", LatestVersion: "5.0.0", Libyears: 1.5}}, Skipped: []string{"rake"}},
+		"report.risk":      []models.DependencyRisk{{Package: pkg, LockedVersion: "4.2.0", Score: 7.2, Signals: models.RiskSignals{CVSS: 9.8, Maintenance: "stale", Reachability: 1}}},
+	}
+	if len(documents) != len(Names()) {
+		t.Errorf("Expected a document for each schema: %v", Names())
+	}
+
+	for name, v := range documents {
+		data, err := Get(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var schema map[string]interface{}
+		if err := json.Unmarshal(data, &schema); err != nil {
+			t.Fatalf("%s: %s", name, err)
+		}
+		version := fmt.Sprintf("/v%v.json", schema["version"])
+		if id, _ := schema["$id"].(string); !strings.HasSuffix(id, version) {
+			t.Errorf("%s: expected the $id to end with the version (%s), got %s", name, version, id)
+		}
+
+		var buf bytes.Buffer
+		if err := utils.PrintJSON(&buf, v); err != nil {
+			t.Fatal(err)
+		}
+		var doc interface{}
+		if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+			t.Fatal(err)
+		}
+		for _, e := range validate(schema, schema, doc, name) {
+			t.Error(e)
+		}
+	}
+
+	if _, err := Get("unknown"); err == nil {
+		t.Error("Expected an error for an unknown schema")
+	}
+}
+
+// Minimal JSON schema validation: $ref to $defs, type, enum, properties,
+// required, additionalProperties and items
+func validate(root, schema map[string]interface{}, doc interface{}, path string) []string {
+	if ref, ok := schema["$ref"].(string); ok {
+		defs, _ := root["$defs"].(map[string]interface{})
+		def, ok := defs[strings.TrimPrefix(ref, "#/$defs/")].(map[string]interface{})
+		if !ok {
+			return []string{fmt.Sprintf("%s: unknown $ref %s", path, ref)}
+		}
+		return validate(root, def, doc, path)
+	}
+	if types, ok := schema["type"]; ok && !hasType(types, doc) {
+		return []string{fmt.Sprintf("%s: %v isn't of type %v", path, doc, types)}
+	}
+	if enum, ok := schema["enum"].([]interface{}); ok {
+		found := false
+		for _, value := range enum {
+			found = found || value == doc
+		}
+		if !found {
+			return []string{fmt.Sprintf("%s: %v isn't one of %v", path, doc, enum)}
+		}
+	}
+
+	errs := []string{}
+	switch doc := doc.(type) {
+	case map[string]interface{}:
+		props, _ := schema["properties"].(map[string]interface{})
+		required, _ := schema["required"].([]interface{})
+		for _, key := range required {
+			if _, ok := doc[key.(string)]; !ok {
+				errs = append(errs, fmt.Sprintf("%s: missing required %s", path, key))
+			}
+		}
+		for key, value := range doc {
+			if prop, ok := props[key].(map[string]interface{}); ok {
+				errs = append(errs, validate(root, prop, value, path+"."+key)...)
+			} else if additional, ok := schema["additionalProperties"].(map[string]interface{}); ok {
+				errs = append(errs, validate(root, additional, value, path+"."+key)...)
+			} else if schema["additionalProperties"] == false {
+				errs = append(errs, fmt.Sprintf("%s: undocumented %s", path, key))
+			}
+		}
+	case []interface{}:
+		if items, ok := schema["items"].(map[string]interface{}); ok {
+			for i, item := range doc {
+				errs = append(errs, validate(root, items, item, fmt.Sprintf("%s[%d]", path, i))...)
+			}
+		}
+	}
+	return errs
+}
+
+func hasType(types interface{}, doc interface{}) bool {
+	list, ok := types.([]interface{})
+	if !ok {
+		list = []interface{}{types}
+	}
+	for _, t := range list {
+		switch t {
+		case "object":
+			_, ok = doc.(map[string]interface{})
+		case "array":
+			_, ok = doc.([]interface{})
+		case "string":
+			_, ok = doc.(string)
+		case "number":
+			_, ok = doc.(float64)
+		case "integer":
+			f, isNumber := doc.(float64)
+			ok = isNumber && f == float64(int64(f))
+		case "boolean":
+			_, ok = doc.(bool)
+		case "null":
+			ok = doc == nil
+		}
+		if ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,68 @@
+package utils
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// Return the HTTP client of the Gemnasium API. When config.APISocket is set,
+// all connections go to this unix socket (ie: a local zero-trust proxy), like
+// curl --unix-socket: the API endpoint only gives the host and paths.
+func NewAPIClient() *http.Client {
+	client := NewHTTPClient()
+	transport := client.Transport.(*http.Transport)
+	dial := transport.DialContext
+	transport.DialContext = func(ctx context.Context, network, addr string) (net.Conn, error) {
+		if config.APISocket != "" {
+			return (&net.Dialer{}).DialContext(ctx, "unix", config.APISocket)
+		}
+		return dial(ctx, network, addr)
+	}
+	return client
+}
+
+// Add the headers required by the gateway fronting the API: the ones of
+// config.APIHeaders, with env vars expanded (ie: "Bearer ${GATEWAY_TOKEN}"),
+// and a signature of the request if config.APISigningKey is set (see
+// signRequest)
+func addGatewayHeaders(req *http.Request) error {
+	for name, value := range config.APIHeaders {
+		req.Header.Set(name, os.ExpandEnv(value))
+	}
+	if config.APISigningKey == "" {
+		return nil
+	}
+	return signRequest(req, config.APISigningKey, time.Now())
+}
+
+// Sign the request with HMAC-SHA256: the X-Gms-Signature header is the hex
+// signature of "<method>\n<path and query>\n<X-Gms-Timestamp>\n<hex SHA-256
+// of the body>"
+func signRequest(req *http.Request, key string, now time.Time) error {
+	var body []byte
+	if req.GetBody != nil {
+		r, err := req.GetBody()
+		if err != nil {
+			return err
+		}
+		if body, err = ioutil.ReadAll(r); err != nil {
+			return err
+		}
+	}
+	timestamp := now.UTC().Format(time.RFC3339)
+	bodySum := sha256.Sum256(body)
+	mac := hmac.New(sha256.New, []byte(key))
+	mac.Write([]byte(req.Method + "\n" + req.URL.RequestURI() + "\n" + timestamp + "\n" + hex.EncodeToString(bodySum[:])))
+	req.Header.Set("X-Gms-Timestamp", timestamp)
+	req.Header.Set("X-Gms-Signature", hex.EncodeToString(mac.Sum(nil)))
+	return nil
+}
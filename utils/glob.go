@@ -0,0 +1,34 @@
+package utils
+
+import (
+	"path"
+	"strings"
+)
+
+// Match a relative, slash separated path against a glob pattern. Segments are
+// matched with path.Match, and "**" matches any number of segments (ie:
+// "packages/**/test" matches "packages/test" and "packages/a/b/test").
+func MatchGlob(pattern, name string) bool {
+	return matchSegments(strings.Split(pattern, "/"), strings.Split(name, "/"))
+}
+
+func matchSegments(pattern, name []string) bool {
+	for len(pattern) > 0 {
+		if pattern[0] == "**" {
+			for i := 0; i <= len(name); i++ {
+				if matchSegments(pattern[1:], name[i:]) {
+					return true
+				}
+			}
+			return false
+		}
+		if len(name) == 0 {
+			return false
+		}
+		if matched, err := path.Match(pattern[0], name[0]); err != nil || !matched {
+			return false
+		}
+		pattern, name = pattern[1:], name[1:]
+	}
+	return len(name) == 0
+}
@@ -0,0 +1,162 @@
+package utils
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gemnasium/toolbelt/config"
+)
+
+// Return an HTTP client honoring the per-host connection limit
+// (see config.MaxConnsPerHost), the DNS overrides (see dialContext), the
+// proxy (see proxy) and the TLS settings (see TLSConfig)
+func NewHTTPClient() *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.MaxConnsPerHost = config.MaxConnsPerHost
+	transport.DialContext = dialContext(&net.Dialer{Timeout: 30 * time.Second, KeepAlive: 30 * time.Second})
+	transport.Proxy = proxy
+	tlsConfig, err := TLSConfig()
+	if err != nil {
+		// Clients are created on init: fail every request instead
+		transport.Proxy = func(*http.Request) (*url.URL, error) { return nil, err }
+	}
+	transport.TLSClientConfig = tlsConfig
+	return &http.Client{Transport: transport}
+}
+
+// Return the proxy of config.Proxy if set, or the one of the HTTPS_PROXY,
+// HTTP_PROXY and NO_PROXY env vars. It's read on every request, as the
+// clients are created before the command line is parsed.
+func proxy(req *http.Request) (*url.URL, error) {
+	if config.Proxy == "" {
+		return http.ProxyFromEnvironment(req)
+	}
+	u, err := url.Parse(config.Proxy)
+	if err != nil || u.Host == "" {
+		return nil, fmt.Errorf("Invalid proxy URL '%s'", config.Proxy)
+	}
+	return u, nil
+}
+
+// Return the TLS config of the clients: the certificates of config.CACert
+// are trusted in addition to the system ones, and certificates aren't
+// verified at all if config.Insecure is set
+func TLSConfig() (*tls.Config, error) {
+	tlsConfig := &tls.Config{InsecureSkipVerify: config.Insecure}
+	if config.CACert == "" {
+		return tlsConfig, nil
+	}
+	pem, err := ioutil.ReadFile(config.CACert)
+	if err != nil {
+		return nil, err
+	}
+	pool, err := x509.SystemCertPool()
+	if err != nil {
+		pool = x509.NewCertPool()
+	}
+	if !pool.AppendCertsFromPEM(pem) {
+		return nil, fmt.Errorf("No PEM certificate found in '%s'", config.CACert)
+	}
+	tlsConfig.RootCAs = pool
+	return tlsConfig, nil
+}
+
+// An address to connect to instead of resolving host, for port (like curl
+// --resolve host:port:addr)
+type ResolveEntry struct {
+	Host string
+	Port string
+	Addr string
+}
+
+// Parse entries like "api.gemnasium.com:443:10.0.0.1" ("[::1]" for IPv6
+// addresses)
+func ParseResolve(entries []string) ([]ResolveEntry, error) {
+	resolve := []ResolveEntry{}
+	for _, entry := range entries {
+		parts := strings.SplitN(strings.TrimSpace(entry), ":", 3)
+		if len(parts) != 3 || parts[0] == "" || parts[1] == "" || parts[2] == "" {
+			return nil, fmt.Errorf("Invalid resolve entry '%s', expected host:port:addr", entry)
+		}
+		addr := strings.TrimSuffix(strings.TrimPrefix(parts[2], "["), "]")
+		if net.ParseIP(addr) == nil {
+			return nil, fmt.Errorf("Invalid address in resolve entry '%s'", entry)
+		}
+		resolve = append(resolve, ResolveEntry{Host: strings.ToLower(parts[0]), Port: parts[1], Addr: addr})
+	}
+	return resolve, nil
+}
+
+// Return the address to dial for addr (host:port): the address of the
+// matching config.Resolve entry, if any
+func resolveAddr(addr string) string {
+	host, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return addr
+	}
+	resolve, _ := ParseResolve(config.Resolve)
+	for _, entry := range resolve {
+		if entry.Host == strings.ToLower(host) && entry.Port == port {
+			return net.JoinHostPort(entry.Addr, port)
+		}
+	}
+	return addr
+}
+
+// Dial with the overrides of config.Resolve and config.IPVersion. They're read
+// on every connection, as the clients are created before the command line is
+// parsed. TLS still uses the original host name (SNI, certificates).
+func dialContext(dialer *net.Dialer) func(ctx context.Context, network, addr string) (net.Conn, error) {
+	return func(ctx context.Context, network, addr string) (net.Conn, error) {
+		switch config.IPVersion {
+		case 4:
+			network = "tcp4"
+		case 6:
+			network = "tcp6"
+		}
+		return dialer.DialContext(ctx, network, resolveAddr(addr))
+	}
+}
+
+// Limit the rate of operations to a number of queries per second.
+// A nil RateLimiter doesn't limit anything.
+type RateLimiter struct {
+	mu       sync.Mutex
+	interval time.Duration
+	next     time.Time
+}
+
+// Return a RateLimiter allowing qps operations per second, or nil if qps is
+// not positive.
+func NewRateLimiter(qps float64) *RateLimiter {
+	if qps <= 0 {
+		return nil
+	}
+	return &RateLimiter{interval: time.Duration(float64(time.Second) / qps)}
+}
+
+// Block until the next operation is allowed
+func (l *RateLimiter) Wait() {
+	if l == nil {
+		return
+	}
+	l.mu.Lock()
+	now := time.Now()
+	wait := l.next.Sub(now)
+	if wait < 0 {
+		wait = 0
+		l.next = now
+	}
+	l.next = l.next.Add(l.interval)
+	l.mu.Unlock()
+	time.Sleep(wait)
+}
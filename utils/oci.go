@@ -0,0 +1,334 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const (
+	OCI_MANIFEST_MEDIA_TYPE = "application/vnd.oci.image.manifest.v1+json"
+	OCI_INDEX_MEDIA_TYPE    = "application/vnd.oci.image.index.v1+json"
+	// Empty config of artifacts ("{}")
+	OCI_EMPTY_MEDIA_TYPE = "application/vnd.oci.empty.v1+json"
+)
+
+// Manifest types accepted when resolving the image artifacts are attached to
+var ociAcceptedManifests = []string{
+	OCI_MANIFEST_MEDIA_TYPE,
+	OCI_INDEX_MEDIA_TYPE,
+	"application/vnd.docker.distribution.manifest.v2+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+}
+
+// Reference of an image: registry/repository, with a tag or a digest
+type OCIReference struct {
+	Registry   string
+	Repository string
+	Tag        string
+	Digest     string
+}
+
+var ociReferenceRegexp = regexp.MustCompile(`^([^/]+)/([a-z0-9]+(?:[._/-][a-z0-9]+)*)(?::([\w][\w.-]{0,127}))?(?:@(sha256:[a-f0-9]{64}))?$`)
+
+// Parse a reference like ghcr.io/org/app:tag or ghcr.io/org/app@sha256:...
+// The registry is required, the tag defaults to "latest".
+func ParseOCIReference(s string) (OCIReference, error) {
+	m := ociReferenceRegexp.FindStringSubmatch(s)
+	if m == nil || !strings.ContainsAny(m[1], ".:") && m[1] != "localhost" {
+		return OCIReference{}, fmt.Errorf("Invalid image reference: %s (expected registry/repository:tag, ex: ghcr.io/org/app:latest)", s)
+	}
+	ref := OCIReference{Registry: m[1], Repository: m[2], Tag: m[3], Digest: m[4]}
+	if ref.Tag == "" && ref.Digest == "" {
+		ref.Tag = "latest"
+	}
+	return ref, nil
+}
+
+func (r OCIReference) String() string {
+	if r.Digest != "" {
+		return fmt.Sprintf("%s/%s@%s", r.Registry, r.Repository, r.Digest)
+	}
+	return fmt.Sprintf("%s/%s:%s", r.Registry, r.Repository, r.Tag)
+}
+
+// Content descriptor: type, digest and size of a blob or a manifest
+type OCIDescriptor struct {
+	MediaType    string            `json:"mediaType"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+type ociManifest struct {
+	SchemaVersion int               `json:"schemaVersion"`
+	MediaType     string            `json:"mediaType"`
+	ArtifactType  string            `json:"artifactType,omitempty"`
+	Config        *OCIDescriptor    `json:"config,omitempty"`
+	Layers        []OCIDescriptor   `json:"layers,omitempty"`
+	Manifests     []OCIDescriptor   `json:"manifests,omitempty"`
+	Subject       *OCIDescriptor    `json:"subject,omitempty"`
+	Annotations   map[string]string `json:"annotations,omitempty"`
+}
+
+// Client of an OCI registry (distribution API), with basic or token
+// authentication. Registries on localhost are reached over plain HTTP.
+type OCIRegistry struct {
+	Host     string
+	Username string
+	Password string
+	// Authorization header of the requests, once authenticated
+	authorization string
+}
+
+func ociDigest(data []byte) string {
+	return fmt.Sprintf("sha256:%x", sha256.Sum256(data))
+}
+
+func (r *OCIRegistry) url(path string) string {
+	scheme := "https"
+	if host := strings.Split(r.Host, ":")[0]; host == "localhost" || host == "127.0.0.1" {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/v2/%s", scheme, r.Host, path)
+}
+
+// Send the request, authenticating as asked by the registry on 401
+func (r *OCIRegistry) do(method, url string, header http.Header, body []byte) (*http.Response, error) {
+	send := func() (*http.Response, error) {
+		req, err := http.NewRequest(method, url, bytes.NewReader(body))
+		if err != nil {
+			return nil, err
+		}
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		if r.authorization != "" {
+			req.Header.Set("Authorization", r.authorization)
+		}
+		return NewHTTPClient().Do(req)
+	}
+	resp, err := send()
+	if err != nil || resp.StatusCode != http.StatusUnauthorized {
+		return resp, err
+	}
+	resp.Body.Close()
+	if err := r.authenticate(resp.Header.Get("WWW-Authenticate")); err != nil {
+		return nil, err
+	}
+	return send()
+}
+
+var ociChallengeParam = regexp.MustCompile(`(\w+)="([^"]*)"`)
+
+// Answer the authentication challenge of the registry: basic credentials, or
+// a bearer token fetched from its token server with them (anonymous if there
+// are none)
+func (r *OCIRegistry) authenticate(challenge string) error {
+	scheme := strings.ToLower(strings.SplitN(challenge, " ", 2)[0])
+	switch scheme {
+	case "basic":
+		if r.Username == "" {
+			return fmt.Errorf("%s: credentials are required", r.Host)
+		}
+		r.authorization = "Basic " + base64.StdEncoding.EncodeToString([]byte(r.Username+":"+r.Password))
+		return nil
+	case "bearer":
+	default:
+		return fmt.Errorf("%s: unsupported authentication: %s", r.Host, challenge)
+	}
+	params := map[string]string{}
+	for _, m := range ociChallengeParam.FindAllStringSubmatch(challenge, -1) {
+		params[m[1]] = m[2]
+	}
+	query := url.Values{}
+	for _, name := range []string{"service", "scope"} {
+		if params[name] != "" {
+			query.Set(name, params[name])
+		}
+	}
+	req, err := http.NewRequest("GET", params["realm"]+"?"+query.Encode(), nil)
+	if err != nil {
+		return err
+	}
+	if r.Username != "" {
+		req.SetBasicAuth(r.Username, r.Password)
+	}
+	resp, err := NewHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%s: authentication failed: %s", r.Host, resp.Status)
+	}
+	var token struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&token); err != nil {
+		return err
+	}
+	if token.Token == "" {
+		token.Token = token.AccessToken
+	}
+	r.authorization = "Bearer " + token.Token
+	return nil
+}
+
+func ociError(resp *http.Response) error {
+	msg, _ := ioutil.ReadAll(io.LimitReader(resp.Body, 4096))
+	return fmt.Errorf("%s %s: %s\n%s", resp.Request.Method, resp.Request.URL.Redacted(), resp.Status, msg)
+}
+
+// Return the descriptor of the manifest the tag or digest of ref points to
+func (r *OCIRegistry) Resolve(ref OCIReference) (OCIDescriptor, error) {
+	reference := ref.Digest
+	if reference == "" {
+		reference = ref.Tag
+	}
+	header := http.Header{"Accept": {strings.Join(ociAcceptedManifests, ", ")}}
+	resp, err := r.do("HEAD", r.url(ref.Repository+"/manifests/"+reference), header, nil)
+	if err != nil {
+		return OCIDescriptor{}, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return OCIDescriptor{}, fmt.Errorf("%s: %s", ref, resp.Status)
+	}
+	desc := OCIDescriptor{MediaType: resp.Header.Get("Content-Type"), Digest: resp.Header.Get("Docker-Content-Digest"), Size: resp.ContentLength}
+	if desc.Digest == "" {
+		desc.Digest = ref.Digest
+	}
+	if desc.Digest == "" {
+		return OCIDescriptor{}, fmt.Errorf("%s: the registry didn't return the digest of the image", ref)
+	}
+	return desc, nil
+}
+
+// Upload a blob, unless the repository already has it
+func (r *OCIRegistry) PushBlob(repository, mediaType string, data []byte) (OCIDescriptor, error) {
+	desc := OCIDescriptor{MediaType: mediaType, Digest: ociDigest(data), Size: int64(len(data))}
+	resp, err := r.do("HEAD", r.url(repository+"/blobs/"+desc.Digest), nil, nil)
+	if err != nil {
+		return desc, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode == http.StatusOK {
+		return desc, nil
+	}
+
+	resp, err = r.do("POST", r.url(repository+"/blobs/uploads/"), nil, nil)
+	if err != nil {
+		return desc, err
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusAccepted {
+		return desc, ociError(resp)
+	}
+	location, err := resp.Request.URL.Parse(resp.Header.Get("Location"))
+	if err != nil {
+		return desc, err
+	}
+	query := location.Query()
+	query.Set("digest", desc.Digest)
+	location.RawQuery = query.Encode()
+	header := http.Header{"Content-Type": {"application/octet-stream"}}
+	resp, err = r.do("PUT", location.String(), header, data)
+	if err != nil {
+		return desc, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return desc, ociError(resp)
+	}
+	return desc, nil
+}
+
+// Upload a manifest, with the given tag or as its digest. It returns whether
+// the registry supports the referrers API (OCI-Subject header).
+func (r *OCIRegistry) pushManifest(repository, reference, mediaType string, manifest []byte) (bool, error) {
+	header := http.Header{"Content-Type": {mediaType}}
+	resp, err := r.do("PUT", r.url(repository+"/manifests/"+reference), header, manifest)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusCreated {
+		return false, ociError(resp)
+	}
+	return resp.Header.Get("OCI-Subject") != "", nil
+}
+
+// Attach a file to the subject manifest as an OCI referrer: an artifact
+// manifest of the given type, with the file as its single layer. It returns
+// the descriptor of this manifest.
+// On registries without the referrers API, the referrers tag
+// (sha256-<digest of the subject>) is updated instead, as the OCI
+// distribution spec describes.
+func (r *OCIRegistry) Attach(repository string, subject OCIDescriptor, artifactType, title string, data []byte, annotations map[string]string) (OCIDescriptor, error) {
+	config, err := r.PushBlob(repository, OCI_EMPTY_MEDIA_TYPE, []byte("{}"))
+	if err != nil {
+		return OCIDescriptor{}, err
+	}
+	layer, err := r.PushBlob(repository, artifactType, data)
+	if err != nil {
+		return OCIDescriptor{}, err
+	}
+	layer.Annotations = map[string]string{"org.opencontainers.image.title": title}
+	manifest, err := json.Marshal(ociManifest{
+		SchemaVersion: 2,
+		MediaType:     OCI_MANIFEST_MEDIA_TYPE,
+		ArtifactType:  artifactType,
+		Config:        &config,
+		Layers:        []OCIDescriptor{layer},
+		Subject:       &subject,
+		Annotations:   annotations,
+	})
+	if err != nil {
+		return OCIDescriptor{}, err
+	}
+	desc := OCIDescriptor{MediaType: OCI_MANIFEST_MEDIA_TYPE, ArtifactType: artifactType, Digest: ociDigest(manifest), Size: int64(len(manifest)), Annotations: annotations}
+	referrers, err := r.pushManifest(repository, desc.Digest, OCI_MANIFEST_MEDIA_TYPE, manifest)
+	if err != nil || referrers {
+		return desc, err
+	}
+	return desc, r.addToReferrersTag(repository, subject, desc)
+}
+
+// Add the descriptor to the index of the referrers tag of the subject
+func (r *OCIRegistry) addToReferrersTag(repository string, subject, desc OCIDescriptor) error {
+	tag := strings.Replace(subject.Digest, ":", "-", 1)
+	index := ociManifest{SchemaVersion: 2, MediaType: OCI_INDEX_MEDIA_TYPE, Manifests: []OCIDescriptor{}}
+	header := http.Header{"Accept": {OCI_INDEX_MEDIA_TYPE}}
+	resp, err := r.do("GET", r.url(repository+"/manifests/"+tag), header, nil)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		if err := json.NewDecoder(resp.Body).Decode(&index); err != nil {
+			return err
+		}
+	case http.StatusNotFound:
+	default:
+		return ociError(resp)
+	}
+	index.Manifests = append(index.Manifests, desc)
+	body, err := json.Marshal(index)
+	if err != nil {
+		return err
+	}
+	_, err = r.pushManifest(repository, tag, OCI_INDEX_MEDIA_TYPE, body)
+	return err
+}
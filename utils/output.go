@@ -0,0 +1,31 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+// Print v as an indented JSON document (--json). Keys are sorted, so the
+// output doesn't depend on the order of the struct fields (the documents are
+// described by the schemas of the schemas package).
+func PrintJSON(output io.Writer, v interface{}) error {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	// Maps are marshaled with sorted keys: decode the document as generic
+	// values, keeping numbers as is, and encode it again
+	var doc interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&doc); err != nil {
+		return err
+	}
+	if data, err = json.MarshalIndent(doc, "", "  "); err != nil {
+		return err
+	}
+	_, err = fmt.Fprintln(output, string(data))
+	return err
+}
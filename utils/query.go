@@ -0,0 +1,173 @@
+package utils
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// Evaluate a jq-like path (ie: ".[0].sha", ".owned[].slug") on v, which is
+// converted to generic JSON values first. "[]" iterates over arrays (and
+// objects values), so several values may be returned.
+func Query(v interface{}, path string) ([]interface{}, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return nil, err
+	}
+	var root interface{}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	if err := dec.Decode(&root); err != nil {
+		return nil, err
+	}
+
+	if !strings.HasPrefix(path, ".") {
+		return nil, fmt.Errorf("Invalid query '%s': must start with '.'", path)
+	}
+	values := []interface{}{root}
+	rest := path[1:]
+	for rest != "" {
+		var step func(interface{}) ([]interface{}, error)
+		switch {
+		case rest[0] == '.':
+			rest = rest[1:]
+			continue
+		case rest[0] == '[':
+			end := strings.Index(rest, "]")
+			if end < 0 {
+				return nil, fmt.Errorf("Invalid query '%s': missing ']'", path)
+			}
+			step, err = indexStep(rest[1:end])
+			if err != nil {
+				return nil, fmt.Errorf("Invalid query '%s': %s", path, err)
+			}
+			rest = rest[end+1:]
+		default:
+			end := strings.IndexAny(rest, ".[")
+			if end < 0 {
+				end = len(rest)
+			}
+			step = keyStep(rest[:end])
+			rest = rest[end:]
+		}
+
+		next := []interface{}{}
+		for _, v := range values {
+			results, err := step(v)
+			if err != nil {
+				return nil, err
+			}
+			next = append(next, results...)
+		}
+		values = next
+	}
+	return values, nil
+}
+
+func keyStep(key string) func(interface{}) ([]interface{}, error) {
+	return func(v interface{}) ([]interface{}, error) {
+		switch o := v.(type) {
+		case map[string]interface{}:
+			return []interface{}{o[key]}, nil
+		case nil:
+			return []interface{}{nil}, nil
+		}
+		return nil, fmt.Errorf("Cannot get key '%s' of %s", key, jsonKind(v))
+	}
+}
+
+// Index step: empty (iterate), an integer (negative from the end), or a
+// quoted key
+func indexStep(index string) (func(interface{}) ([]interface{}, error), error) {
+	if index == "" {
+		return func(v interface{}) ([]interface{}, error) {
+			switch o := v.(type) {
+			case []interface{}:
+				return o, nil
+			case map[string]interface{}:
+				values := []interface{}{}
+				for _, key := range sortedKeys(o) {
+					values = append(values, o[key])
+				}
+				return values, nil
+			}
+			return nil, fmt.Errorf("Cannot iterate over %s", jsonKind(v))
+		}, nil
+	}
+	if key, err := strconv.Unquote(index); err == nil {
+		return keyStep(key), nil
+	}
+	n, err := strconv.Atoi(index)
+	if err != nil {
+		return nil, fmt.Errorf("invalid index [%s]", index)
+	}
+	return func(v interface{}) ([]interface{}, error) {
+		switch a := v.(type) {
+		case []interface{}:
+			i := n
+			if i < 0 {
+				i += len(a)
+			}
+			if i < 0 || i >= len(a) {
+				return []interface{}{nil}, nil
+			}
+			return []interface{}{a[i]}, nil
+		case nil:
+			return []interface{}{nil}, nil
+		}
+		return nil, fmt.Errorf("Cannot index %s with %d", jsonKind(v), n)
+	}, nil
+}
+
+func sortedKeys(o map[string]interface{}) []string {
+	keys := []string{}
+	for key := range o {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func jsonKind(v interface{}) string {
+	switch v.(type) {
+	case map[string]interface{}:
+		return "an object"
+	case []interface{}:
+		return "an array"
+	case string:
+		return "a string"
+	case json.Number:
+		return "a number"
+	case bool:
+		return "a boolean"
+	}
+	return "null"
+}
+
+// Print the values matching path, one per line: strings and numbers as-is
+// (for shell scripts), other values as JSON.
+func PrintQuery(output io.Writer, v interface{}, path string) error {
+	values, err := Query(v, path)
+	if err != nil {
+		return err
+	}
+	for _, value := range values {
+		switch s := value.(type) {
+		case string:
+			fmt.Fprintln(output, s)
+		case json.Number:
+			fmt.Fprintln(output, s.String())
+		default:
+			data, err := json.Marshal(value)
+			if err != nil {
+				return err
+			}
+			fmt.Fprintln(output, string(data))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,90 @@
+package utils
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+)
+
+// Location and credentials of an S3-compatible bucket. Credentials are read
+// from the usual AWS env vars.
+type S3Bucket struct {
+	Endpoint string // ie: https://s3.amazonaws.com, or any S3-compatible server
+	Region   string
+	Name     string
+}
+
+// Upload body to the bucket, with the given key
+func (b S3Bucket) PutObject(key, contentType string, body []byte) (string, error) {
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return "", fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set to upload to S3")
+	}
+	url := fmt.Sprintf("%s/%s/%s", strings.TrimSuffix(b.Endpoint, "/"), b.Name, strings.TrimPrefix(key, "/"))
+	req, err := http.NewRequest("PUT", url, bytes.NewReader(body))
+	if err != nil {
+		return "", err
+	}
+	req.Header.Set("Content-Type", contentType)
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	signAWSRequest(req, body, "s3", b.Region, accessKey, secretKey, time.Now().UTC())
+
+	resp, err := NewHTTPClient().Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := ioutil.ReadAll(resp.Body)
+		return "", fmt.Errorf("%s: %s\n%s", req.URL.Redacted(), resp.Status, msg)
+	}
+	return url, nil
+}
+
+// Sign the request to the AWS service (ie: s3, sqs) with AWS Signature Version 4
+// https://docs.aws.amazon.com/general/latest/gr/sigv4_signing.html
+func signAWSRequest(req *http.Request, body []byte, service, region, accessKey, secretKey string, now time.Time) {
+	amzDate := now.Format("20060102T150405Z")
+	date := now.Format("20060102")
+	payloadHash := fmt.Sprintf("%x", sha256.Sum256(body))
+	req.Header.Set("X-Amz-Date", amzDate)
+	req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+
+	signed := []string{"host", "x-amz-content-sha256", "x-amz-date"}
+	if req.Header.Get("X-Amz-Security-Token") != "" {
+		signed = append(signed, "x-amz-security-token")
+	}
+	var headers strings.Builder
+	for _, h := range signed {
+		value := req.Header.Get(h)
+		if h == "host" {
+			value = req.URL.Host
+		}
+		fmt.Fprintf(&headers, "%s:%s\n", h, strings.TrimSpace(value))
+	}
+	signedHeaders := strings.Join(signed, ";")
+	canonicalRequest := strings.Join([]string{req.Method, req.URL.EscapedPath(), req.URL.RawQuery, headers.String(), signedHeaders, payloadHash}, "\n")
+
+	scope := fmt.Sprintf("%s/%s/%s/aws4_request", date, region, service)
+	stringToSign := fmt.Sprintf("AWS4-HMAC-SHA256\n%s\n%s\n%x", amzDate, scope, sha256.Sum256([]byte(canonicalRequest)))
+	key := []byte("AWS4" + secretKey)
+	for _, part := range []string{date, region, service, "aws4_request"} {
+		key = hmacSHA256(key, part)
+	}
+	signature := fmt.Sprintf("%x", hmacSHA256(key, stringToSign))
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s", accessKey, scope, signedHeaders, signature))
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,76 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/heroku/hk/term"
+)
+
+var spinnerFrames = []string{"|", "/", "-", "\\"}
+
+// Progress indicator for long operations, displayed on stderr.
+// A nil Spinner doesn't display anything.
+type Spinner struct {
+	mu       sync.Mutex
+	output   io.Writer
+	message  string
+	progress string
+	stop     chan struct{}
+	done     chan struct{}
+}
+
+// Return a Spinner displaying message, or nil if stderr is not a terminal
+// (so redirected outputs and CI logs aren't cluttered).
+func NewSpinner(message string) *Spinner {
+	if !term.IsTerminal(os.Stderr) {
+		return nil
+	}
+	return &Spinner{output: os.Stderr, message: message}
+}
+
+func (s *Spinner) Start() {
+	if s == nil {
+		return
+	}
+	s.stop = make(chan struct{})
+	s.done = make(chan struct{})
+	go func() {
+		defer close(s.done)
+		ticker := time.NewTicker(100 * time.Millisecond)
+		defer ticker.Stop()
+		for i := 0; ; i++ {
+			s.mu.Lock()
+			fmt.Fprintf(s.output, "\r%s %s %s", spinnerFrames[i%len(spinnerFrames)], s.message, s.progress)
+			s.mu.Unlock()
+			select {
+			case <-ticker.C:
+			case <-s.stop:
+				fmt.Fprint(s.output, "\r\033[K")
+				return
+			}
+		}
+	}()
+}
+
+// Display done/total next to the message
+func (s *Spinner) Progress(done, total int) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.progress = fmt.Sprintf("(%d/%d)", done, total)
+	s.mu.Unlock()
+}
+
+// Stop the spinner and clear its line
+func (s *Spinner) Stop() {
+	if s == nil || s.stop == nil {
+		return
+	}
+	close(s.stop)
+	<-s.done
+}
@@ -0,0 +1,104 @@
+package utils
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+)
+
+// SQS queue, used with the JSON protocol. Credentials are read from the usual
+// AWS env vars.
+type SQSQueue struct {
+	URL    string // ie: https://sqs.eu-west-1.amazonaws.com/123456789012/gemnasium-jobs
+	Region string
+}
+
+// A message received from the queue
+type SQSMessage struct {
+	MessageId     string
+	ReceiptHandle string
+	Body          string
+}
+
+// Return the queue with the given URL, in the region of its host
+// (sqs.<region>.amazonaws.com), or defaultRegion for other hosts (ie: a local
+// SQS-compatible server)
+func NewSQSQueue(queueURL, defaultRegion string) (SQSQueue, error) {
+	u, err := url.Parse(queueURL)
+	if err != nil || u.Host == "" {
+		return SQSQueue{}, fmt.Errorf("Invalid SQS queue URL: %s", queueURL)
+	}
+	region := defaultRegion
+	if parts := strings.Split(u.Hostname(), "."); len(parts) == 4 && parts[0] == "sqs" && parts[2] == "amazonaws" {
+		region = parts[1]
+	}
+	return SQSQueue{URL: queueURL, Region: region}, nil
+}
+
+// Receive a message, waiting up to wait for one (long polling). It returns
+// nil if there's none.
+func (q SQSQueue) Receive(ctx context.Context, wait time.Duration) (*SQSMessage, error) {
+	var result struct {
+		Messages []SQSMessage
+	}
+	params := map[string]interface{}{"QueueUrl": q.URL, "MaxNumberOfMessages": 1, "WaitTimeSeconds": int(wait.Seconds())}
+	if err := q.call(ctx, "ReceiveMessage", params, &result); err != nil {
+		return nil, err
+	}
+	if len(result.Messages) == 0 {
+		return nil, nil
+	}
+	return &result.Messages[0], nil
+}
+
+// Delete a received message, so that it's not received again
+func (q SQSQueue) Delete(ctx context.Context, m *SQSMessage) error {
+	return q.call(ctx, "DeleteMessage", map[string]interface{}{"QueueUrl": q.URL, "ReceiptHandle": m.ReceiptHandle}, nil)
+}
+
+func (q SQSQueue) call(ctx context.Context, action string, params map[string]interface{}, result interface{}) error {
+	accessKey, secretKey := os.Getenv("AWS_ACCESS_KEY_ID"), os.Getenv("AWS_SECRET_ACCESS_KEY")
+	if accessKey == "" || secretKey == "" {
+		return fmt.Errorf("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set to use SQS")
+	}
+	body, err := json.Marshal(params)
+	if err != nil {
+		return err
+	}
+	u, _ := url.Parse(q.URL)
+	req, err := http.NewRequest("POST", u.Scheme+"://"+u.Host+"/", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req = req.WithContext(ctx)
+	req.Header.Set("Content-Type", "application/x-amz-json-1.0")
+	req.Header.Set("X-Amz-Target", "AmazonSQS."+action)
+	if token := os.Getenv("AWS_SESSION_TOKEN"); token != "" {
+		req.Header.Set("X-Amz-Security-Token", token)
+	}
+	signAWSRequest(req, body, "sqs", q.Region, accessKey, secretKey, time.Now().UTC())
+
+	resp, err := NewHTTPClient().Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	data, err := ioutil.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("SQS %s: %s\n%s", action, resp.Status, data)
+	}
+	if result == nil {
+		return nil
+	}
+	return json.Unmarshal(data, result)
+}
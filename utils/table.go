@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/gemnasium/toolbelt/config"
+	"github.com/olekukonko/tablewriter"
+)
+
+// An ascii table, rendered with tablewriter once all rows are appended, so
+// the columns selected with --columns and the width options (--max-width,
+// --no-wrap) can be applied.
+type Table struct {
+	output   io.Writer
+	header   []string
+	defaults []string
+	rows     [][]string
+	footer   []string
+	// Options applied to the tablewriter table before rendering
+	Configure func(*tablewriter.Table)
+}
+
+func NewTable(output io.Writer, header ...string) *Table {
+	return &Table{output: output, header: header}
+}
+
+// Only display these columns unless others are selected with --columns
+func (t *Table) SetDefaultColumns(columns ...string) {
+	t.defaults = columns
+}
+
+func (t *Table) Append(row ...string) {
+	t.rows = append(t.rows, row)
+}
+
+func (t *Table) SetFooter(footer ...string) {
+	t.footer = footer
+}
+
+// Return the name of the column, used with --columns: "Last release" =>
+// "last_release"
+func columnName(header string) string {
+	return strings.Replace(strings.ToLower(header), " ", "_", -1)
+}
+
+// Return the indexes of the columns to display
+func (t *Table) selectedColumns() ([]int, error) {
+	columns := config.Columns
+	if len(columns) == 0 {
+		columns = t.defaults
+	}
+	if len(columns) == 0 {
+		indexes := make([]int, len(t.header))
+		for i := range t.header {
+			indexes[i] = i
+		}
+		return indexes, nil
+	}
+
+	names := make([]string, len(t.header))
+	for i, h := range t.header {
+		names[i] = columnName(h)
+	}
+	indexes := []int{}
+	for _, column := range columns {
+		found := false
+		for i, name := range names {
+			if name == columnName(strings.TrimSpace(column)) {
+				indexes = append(indexes, i)
+				found = true
+				break
+			}
+		}
+		if !found {
+			return nil, fmt.Errorf("Unknown column '%s' (available: %s)", column, strings.Join(names, ", "))
+		}
+	}
+	return indexes, nil
+}
+
+// Truncate s to width characters, ending with "..."
+func truncate(s string, width int) string {
+	if width <= 0 || utf8.RuneCountInString(s) <= width {
+		return s
+	}
+	if width <= 3 {
+		return string([]rune(s)[:width])
+	}
+	return string([]rune(s)[:width-3]) + "..."
+}
+
+func (t *Table) project(row []string, indexes []int) []string {
+	projected := make([]string, len(indexes))
+	for i, index := range indexes {
+		if index < len(row) {
+			projected[i] = row[index]
+		}
+		if config.NoWrap {
+			projected[i] = truncate(projected[i], config.MaxColumnWidth)
+		}
+	}
+	return projected
+}
+
+func (t *Table) Render() error {
+	indexes, err := t.selectedColumns()
+	if err != nil {
+		return err
+	}
+	table := tablewriter.NewWriter(t.output)
+	if config.NoWrap {
+		table.SetAutoWrapText(false)
+	} else if config.MaxColumnWidth > 0 {
+		table.SetColWidth(config.MaxColumnWidth)
+	}
+	if t.Configure != nil {
+		t.Configure(table)
+	}
+	table.SetHeader(t.project(t.header, indexes))
+	for _, row := range t.rows {
+		table.Append(t.project(row, indexes))
+	}
+	if t.footer != nil {
+		table.SetFooter(t.project(t.footer, indexes))
+	}
+	table.Render()
+	return nil
+}
@@ -7,7 +7,9 @@ import (
 	"net/http"
 	"os"
 	"os/exec"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/gemnasium/toolbelt/config"
 	"github.com/mgutz/ansi"
@@ -36,6 +38,9 @@ func NewAPIRequest(method, urlStr, APIKey string, body io.Reader) (*http.Request
 	req.Header.Add("X-Gms-Client-Version", config.VERSION)
 	req.Header.Add("X-Gms-Revision", GetCurrentRevision())
 	req.Header.Add("X-Gms-Branch", GetCurrentBranch())
+	if err := addGatewayHeaders(req); err != nil {
+		return nil, err
+	}
 	return req, nil
 }
 
@@ -87,3 +92,73 @@ func GitPath() string {
 	path, _ := exec.LookPath("git")
 	return path
 }
+
+// Parse a duration like time.ParseDuration, with support for days ("7d").
+func ParseDuration(s string) (time.Duration, error) {
+	if strings.HasSuffix(s, "d") {
+		days, err := strconv.Atoi(strings.TrimSuffix(s, "d"))
+		if err != nil {
+			return 0, fmt.Errorf("invalid duration: %s", s)
+		}
+		return time.Duration(days) * 24 * time.Hour, nil
+	}
+	return time.ParseDuration(s)
+}
+
+// Format size in bytes for humans (ie: 1.5 MB)
+func HumanSize(size int64) string {
+	units := []string{"B", "KB", "MB", "GB", "TB"}
+	s := float64(size)
+	i := 0
+	for s >= 1024 && i < len(units)-1 {
+		s /= 1024
+		i++
+	}
+	if i == 0 {
+		return fmt.Sprintf("%d B", size)
+	}
+	return fmt.Sprintf("%.1f %s", s, units[i])
+}
+
+// Compare versions like "1.2.10" and "1.2.9", number by number.
+// Pre-releases ("1.2.0-rc1") are lower than the corresponding release.
+// Return -1 if a < b, 0 if a == b, and 1 if a > b.
+func CompareVersions(a, b string) int {
+	a, b = strings.TrimPrefix(a, "v"), strings.TrimPrefix(b, "v")
+	aRelease, aPre := splitPrerelease(a)
+	bRelease, bPre := splitPrerelease(b)
+	aParts, bParts := strings.Split(aRelease, "."), strings.Split(bRelease, ".")
+	for i := 0; i < len(aParts) || i < len(bParts); i++ {
+		var x, y int
+		if i < len(aParts) {
+			x, _ = strconv.Atoi(aParts[i])
+		}
+		if i < len(bParts) {
+			y, _ = strconv.Atoi(bParts[i])
+		}
+		if x != y {
+			if x < y {
+				return -1
+			}
+			return 1
+		}
+	}
+	switch {
+	case aPre == bPre:
+		return 0
+	case aPre == "":
+		return 1
+	case bPre == "":
+		return -1
+	case aPre < bPre:
+		return -1
+	}
+	return 1
+}
+
+func splitPrerelease(version string) (release, prerelease string) {
+	if i := strings.Index(version, "-"); i >= 0 {
+		return version[:i], version[i+1:]
+	}
+	return version, ""
+}